@@ -1,17 +1,64 @@
 // Package app provides shared CLI options and exit metadata.
 package app
 
+import (
+	"errors"
+	"io"
+	"time"
+)
+
 // Options holds global CLI settings.
 type Options struct {
-	Verbose int
-	Quiet   bool
-	JSON    bool
-	Plain   bool
-	NoColor bool
-	NoInput bool
-	Config  string
-	Cloud   string
-	BaseURL string
+	Verbose       int
+	Quiet         bool
+	JSON          bool
+	Plain         bool
+	NDJSON        bool
+	Output        string
+	NoColor       bool
+	NoInput       bool
+	Config        string
+	Cloud         string
+	BaseURL       string
+	Profile       string
+	OutputVersion int
+	Retries       int
+	RetryBackoff  time.Duration
+	Timeout       time.Duration
+	CacheTTL      time.Duration
+	NoCache       bool
+	RateLimit     int
+	PageSize      int
+	Units         string
+	ReadOnly      bool
+	DryRun        bool
+	Timezone      string
+	Fields        string
+	Columns       string
+	// Template is the --format Go template rows are rendered through.
+	Template string
+	// Markdown renders rows as a markdown table (--format markdown).
+	Markdown bool
+	// Fixtures is the directory canned API responses are read from
+	// instead of the network (--fixtures or WITHINGS_FIXTURES).
+	Fixtures string
+	// Record is the cassette every round trip is saved to (--record);
+	// Replay the cassette responses are answered from (--replay).
+	Record string
+	Replay string
+	// Sort is the --sort column rows are ordered by; SortDesc reverses it.
+	Sort     string
+	SortDesc bool
+	// Redact hashes identifiers and jitters values in output (--redact).
+	Redact bool
+	// Thresholds holds the [thresholds] config rules that color table
+	// cells, keyed by measure type or column name.
+	Thresholds map[string]string
+	// Stdout receives rendered output and Stderr progress and warnings;
+	// nil means os.Stdout and os.Stderr. --output points Stdout at the
+	// temporary file that replaces the target.
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 const (
@@ -27,8 +74,14 @@ const (
 	ExitCodeNetwork = 4
 	// ExitCodeAPI indicates an upstream API error.
 	ExitCodeAPI = 5
+	// ExitCodeUnchanged indicates there was nothing to do.
+	ExitCodeUnchanged = 6
 )
 
+// ErrSilent marks an exit whose error message is not printed, for
+// commands that only report through their exit code.
+var ErrSilent = errors.New("silent exit")
+
 // ExitError couples an exit code with an error.
 type ExitError struct {
 	Code int
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/state"
+)
+
+const (
+	configKeyBookmarkPrefix = "bookmark_"
+	bookmarkSeparator       = "_"
+	bookmarkBase10          = 10
+	bookmarkBitSize         = 64
+	bookmarkTable           = "bookmarks"
+	stateFileSuffix         = ".state.json"
+)
+
+var errInvalidBookmark = errors.New("invalid bookmark")
+
+// BookmarkKey builds the config key for a data type and optional user ID.
+func BookmarkKey(name, userID string) string {
+	key := configKeyBookmarkPrefix + name
+	if userID != emptyString {
+		key += bookmarkSeparator + userID
+	}
+
+	return key
+}
+
+// StatePath returns the state store of the active profile, next to its
+// config file (config.toml keeps its state in config.state.json).
+func StatePath(opts app.Options) (string, error) {
+	config, err := loadUserConfig(opts)
+	if err != nil {
+		return emptyString, err
+	}
+
+	base := strings.TrimSuffix(config.Path, filepath.Ext(config.Path))
+
+	return base + stateFileSuffix, nil
+}
+
+// OpenState opens the state store of the active profile.
+func OpenState(opts app.Options) (*state.Store, error) {
+	path, err := StatePath(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return state.Open(path), nil
+}
+
+// LoadBookmark returns the stored server updatetime for a bookmark key, or
+// zero when none has been recorded yet. Bookmarks saved in the user config
+// by earlier releases are still read.
+func LoadBookmark(opts app.Options, key string) (int64, error) {
+	store, err := OpenState(opts)
+	if err != nil {
+		return defaultInt64, err
+	}
+
+	var value int64
+
+	found := false
+
+	err = store.View(func(tx *state.Tx) error {
+		found, err = tx.Get(bookmarkTable, key, &value)
+
+		return err
+	})
+	if err != nil {
+		return defaultInt64, fmt.Errorf("bookmark %s: %w", key, err)
+	}
+
+	if found {
+		return value, nil
+	}
+
+	return loadLegacyBookmark(opts, key)
+}
+
+func loadLegacyBookmark(opts app.Options, key string) (int64, error) {
+	config, err := loadUserConfig(opts)
+	if err != nil {
+		return defaultInt64, err
+	}
+
+	raw := config.Value(key)
+	if raw == emptyString {
+		return defaultInt64, nil
+	}
+
+	value, err := strconv.ParseInt(raw, bookmarkBase10, bookmarkBitSize)
+	if err != nil {
+		return defaultInt64, fmt.Errorf(
+			"%w %s: %w",
+			errInvalidBookmark,
+			key,
+			err,
+		)
+	}
+
+	return value, nil
+}
+
+// SaveBookmark records a server updatetime in the state store. Bookmarks
+// only move forward so an out-of-order or concurrent run cannot rewind
+// them.
+func SaveBookmark(opts app.Options, key string, value int64) error {
+	current, err := LoadBookmark(opts, key)
+	if err != nil && !errors.Is(err, errInvalidBookmark) {
+		return err
+	}
+
+	if current >= value {
+		return nil
+	}
+
+	store, err := OpenState(opts)
+	if err != nil {
+		return err
+	}
+
+	err = store.Update(func(tx *state.Tx) error {
+		var stored int64
+
+		found, err := tx.Get(bookmarkTable, key, &stored)
+		if err != nil || (found && stored >= value) {
+			return err
+		}
+
+		return tx.Put(bookmarkTable, key, value)
+	})
+	if err != nil {
+		return fmt.Errorf("bookmark %s: %w", key, err)
+	}
+
+	return nil
+}
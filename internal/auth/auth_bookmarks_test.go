@@ -0,0 +1,78 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testBookmarkName   = "measures"
+	testBookmarkUser   = "42"
+	testBookmarkFirst  = 1700000000
+	testBookmarkLater  = 1700003600
+	testBookmarkGotFmt = "bookmark got %d want %d"
+)
+
+// TestBookmarkKey scopes bookmarks by data type and user ID.
+func TestBookmarkKey(t *testing.T) {
+	t.Parallel()
+
+	if got := BookmarkKey(testBookmarkName, emptyString); got !=
+		"bookmark_measures" {
+		t.Fatalf(testGotWantFormat, got, "bookmark_measures")
+	}
+
+	if got := BookmarkKey(testBookmarkName, testBookmarkUser); got !=
+		"bookmark_measures_42" {
+		t.Fatalf(testGotWantFormat, got, "bookmark_measures_42")
+	}
+}
+
+// TestSaveBookmarkMovesForward stores bookmarks and never rewinds them.
+func TestSaveBookmarkMovesForward(t *testing.T) {
+	t.Parallel()
+
+	opts := testAppOptions(filepath.Join(t.TempDir(), "config.toml"))
+	key := BookmarkKey(testBookmarkName, emptyString)
+
+	got, err := LoadBookmark(opts, key)
+	if err != nil || got != defaultInt64 {
+		t.Fatalf("empty bookmark got %d, %v", got, err)
+	}
+
+	for _, value := range []int64{testBookmarkLater, testBookmarkFirst} {
+		err = SaveBookmark(opts, key, value)
+		if err != nil {
+			t.Fatalf("save bookmark: %v", err)
+		}
+	}
+
+	got, err = LoadBookmark(opts, key)
+	if err != nil {
+		t.Fatalf("load bookmark: %v", err)
+	}
+
+	if got != testBookmarkLater {
+		t.Fatalf(testBookmarkGotFmt, got, testBookmarkLater)
+	}
+}
+
+// TestLoadBookmarkInvalid rejects non-numeric stored values.
+func TestLoadBookmarkInvalid(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	key := BookmarkKey(testBookmarkName, emptyString)
+
+	err := writeConfigFile(configPath, map[string]string{key: "soon"})
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	_, err = LoadBookmark(testAppOptions(configPath), key)
+	if !errors.Is(err, errInvalidBookmark) {
+		t.Fatalf("err got %v want %v", err, errInvalidBookmark)
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
 )
 
 const (
@@ -21,8 +24,7 @@ const (
 )
 
 const (
-	defaultUserConfigRelPath = ".config/withings-cli/config.toml"
-	projectConfigFilename    = "withings-cli.toml"
+	projectConfigFilename = "withings-cli.toml"
 )
 
 const (
@@ -33,6 +35,7 @@ const (
 	configLineCountBase    = 0
 	configLineEnding       = "\n"
 	configCommentLookahead = 1
+	configSectionSeparator = "."
 )
 
 type configFile struct {
@@ -58,7 +61,7 @@ func emptyConfigKeyValue() configKeyValue {
 }
 
 func loadConfigSources(
-	configPath string,
+	opts app.Options,
 ) (configSources, error) {
 	projectPath, err := projectConfigPath()
 	if err != nil {
@@ -70,12 +73,7 @@ func loadConfigSources(
 		return configSources{}, err
 	}
 
-	userPath, err := userConfigPath(configPath)
-	if err != nil {
-		return configSources{}, err
-	}
-
-	userConfig, err := loadConfigFile(userPath)
+	userConfig, err := loadUserConfig(opts)
 	if err != nil {
 		return configSources{}, err
 	}
@@ -86,6 +84,57 @@ func loadConfigSources(
 	}, nil
 }
 
+// ConfigValues returns the requested settings, preferring the project
+// config over the active profile's user config. Missing keys are omitted.
+func ConfigValues(opts app.Options, keys ...string) (map[string]string, error) {
+	sources, err := loadConfigSources(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+
+	for _, key := range keys {
+		value := resolveValue(
+			emptyString,
+			sources.Project.Value(key),
+			sources.User.Value(key),
+		)
+		if value != emptyString {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// ConfigSection returns every key of a [section] table without the
+// section prefix, preferring the project config over the active
+// profile's user config.
+func ConfigSection(opts app.Options, section string) (
+	map[string]string,
+	error,
+) {
+	sources, err := loadConfigSources(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := section + configSectionSeparator
+	values := map[string]string{}
+
+	for _, config := range []*configFile{sources.User, sources.Project} {
+		for key, value := range config.Values {
+			name, ok := strings.CutPrefix(key, prefix)
+			if ok && name != emptyString {
+				values[name] = value
+			}
+		}
+	}
+
+	return values, nil
+}
+
 func projectConfigPath() (string, error) {
 	wd, err := os.Getwd()
 	if err != nil {
@@ -100,12 +149,7 @@ func userConfigPath(override string) (string, error) {
 		return override, nil
 	}
 
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return emptyString, fmt.Errorf("resolve home directory: %w", err)
-	}
-
-	return filepath.Join(homeDir, defaultUserConfigRelPath), nil
+	return defaultUserConfigPath()
 }
 
 func loadConfigFile(path string) (*configFile, error) {
@@ -128,7 +172,10 @@ func loadConfigFile(path string) (*configFile, error) {
 	}
 
 	config.Exists = true
-	config.Lines = strings.Split(string(data), configLineEnding)
+	config.Lines = strings.Split(
+		strings.TrimSuffix(string(data), configLineEnding),
+		configLineEnding,
+	)
 	config.parseLines()
 
 	return config, nil
@@ -139,9 +186,12 @@ func (c *configFile) Value(key string) string {
 	return c.Values[key]
 }
 
-// Set stores a key/value pair in the config.
+// Set stores a key/value pair in the config. A dotted key such as
+// experimental.sync is written as sync under an [experimental] table.
 func (c *configFile) Set(key, value string) {
-	line := fmt.Sprintf("%s = %s", key, tomlQuote(value))
+	section, name := splitSectionKey(key)
+	line := fmt.Sprintf("%s = %s", name, tomlQuote(value))
+
 	if idx, ok := c.KeyIndex[key]; ok {
 		c.Lines[idx] = line
 		c.Values[key] = value
@@ -149,9 +199,65 @@ func (c *configFile) Set(key, value string) {
 		return
 	}
 
-	c.Lines = append(c.Lines, line)
-	c.KeyIndex[key] = len(c.Lines) - configIndexOffset
-	c.Values[key] = value
+	c.Lines = slices.Insert(c.Lines, c.insertIndex(section), line)
+	c.parseLines()
+}
+
+// insertIndex returns where a new key of section goes: top-level keys
+// before the first table, table keys at the end of their table. A missing
+// table is appended.
+func (c *configFile) insertIndex(section string) int {
+	current := emptyString
+	index := len(c.Lines)
+
+	for idx, line := range c.Lines {
+		name, ok := parseSectionLine(line)
+		if !ok {
+			continue
+		}
+
+		if current == section {
+			index = idx
+
+			break
+		}
+
+		current = name
+	}
+
+	if current == section {
+		return trimTrailingBlank(c.Lines, index)
+	}
+
+	if section != emptyString {
+		last := len(c.Lines) - configIndexOffset
+		if last >= 0 && strings.TrimSpace(c.Lines[last]) != emptyString {
+			c.Lines = append(c.Lines, emptyString)
+		}
+
+		c.Lines = append(c.Lines, "["+section+"]")
+	}
+
+	return len(c.Lines)
+}
+
+// trimTrailingBlank keeps the blank line that separates tables below the
+// inserted key.
+func trimTrailingBlank(lines []string, index int) int {
+	for index > 0 && strings.TrimSpace(lines[index-1]) == emptyString {
+		index--
+	}
+
+	return index
+}
+
+func splitSectionKey(key string) (string, string) {
+	section, name, ok := strings.Cut(key, configSectionSeparator)
+	if !ok {
+		return emptyString, key
+	}
+
+	return section, name
 }
 
 // Unset removes a key from the config.
@@ -195,21 +301,47 @@ func (c *configFile) Save() error {
 	return nil
 }
 
+// parseLines indexes the keys. Keys inside a [table] are stored as
+// table.key.
 func (c *configFile) parseLines() {
 	c.Values = map[string]string{}
 	c.KeyIndex = map[string]int{}
 
+	section := emptyString
+
 	for idx, line := range c.Lines {
+		if name, ok := parseSectionLine(line); ok {
+			section = name
+
+			continue
+		}
+
 		pair, ok := parseConfigLine(line)
 		if !ok {
 			continue
 		}
 
+		if section != emptyString {
+			pair.Key = section + configSectionSeparator + pair.Key
+		}
+
 		c.Values[pair.Key] = pair.Value
 		c.KeyIndex[pair.Key] = idx
 	}
 }
 
+// parseSectionLine returns the name of a [table] header line.
+func parseSectionLine(line string) (string, bool) {
+	trimmed := strings.TrimSpace(stripInlineComment(strings.TrimSpace(line)))
+	if !isSectionLine(trimmed) || !strings.HasSuffix(trimmed, "]") {
+		return emptyString, false
+	}
+
+	name := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+
+	return name, name != emptyString
+}
+
 func parseConfigLine(line string) (configKeyValue, bool) {
 	trimmed := strings.TrimSpace(line)
 	if trimmed == emptyString {
@@ -0,0 +1,403 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// Config scopes accepted by the config command.
+const (
+	ConfigScopeUser    = "user"
+	ConfigScopeProject = "project"
+)
+
+const (
+	configPlainHeader = "key\tvalue\tscope"
+	pathPlainHeader   = "scope\tpath\texists"
+	maskedValue       = "********"
+)
+
+var (
+	errInvalidConfigScope = errors.New(
+		"invalid --scope (expected user or project)",
+	)
+	errInvalidConfigKey = errors.New(
+		"invalid config key (use letters, digits, '-' or '_', and " +
+			"table.key for a table entry)",
+	)
+	errConfigKeyNotSet = errors.New("config key not set")
+	errProjectSecret   = errors.New(
+		"refusing to store a token or secret in the project config " +
+			"(it is meant to be committed); use --scope user",
+	)
+)
+
+// configKeyPattern matches a bare TOML key, optionally inside one table.
+//
+//nolint:gochecknoglobals // Static pattern for bare TOML keys.
+var configKeyPattern = regexp.MustCompile(
+	`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)?$`,
+)
+
+// secretConfigKeys are masked by `config list`.
+//
+//nolint:gochecknoglobals // Static set of secret keys.
+var secretConfigKeys = map[string]bool{
+	configKeyAccessToken:  true,
+	configKeyRefreshToken: true,
+	configKeyClientSecret: true,
+}
+
+type configEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Scope string `json:"scope"`
+}
+
+type configPathEntry struct {
+	Scope  string `json:"scope"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+}
+
+// ConfigPaths writes where the active profile's config and the project
+// config live, and whether they exist yet.
+func ConfigPaths(appOpts app.Options) error {
+	infos := diagnoseConfigFiles(appOpts)
+	entries := make([]configPathEntry, defaultInt, len(infos))
+
+	for _, info := range infos {
+		if info.Path == emptyString {
+			return info.Err
+		}
+
+		entries = append(entries, configPathEntry{
+			Scope:  info.Scope,
+			Path:   info.Path,
+			Exists: info.Exists,
+		})
+	}
+
+	if appOpts.JSON {
+		return writeConfigOutput(appOpts, entries)
+	}
+
+	return writeConfigOutput(appOpts, formatPathLines(appOpts, entries))
+}
+
+// SetConfig stores key = value in the config of scope (default user, the
+// active profile's file). Tokens and secrets are refused for the project
+// config.
+func SetConfig(appOpts app.Options, scope, key, value string) error {
+	if scope == ConfigScopeProject && secretConfigKeys[key] {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %s", errProjectSecret, key),
+		)
+	}
+
+	config, err := loadScopedConfig(appOpts, scope, key)
+	if err != nil {
+		return err
+	}
+
+	config.Set(key, value)
+
+	err = config.Save()
+	if err != nil {
+		return err
+	}
+
+	err = warnProjectOverride(appOpts, scope, key)
+	if err != nil {
+		return err
+	}
+
+	return writeConfigMessage(
+		appOpts,
+		fmt.Sprintf("Set %s in %s.", key, config.Path),
+	)
+}
+
+// UnsetConfig removes key from the config of scope. Removing a key that
+// is not set succeeds.
+func UnsetConfig(appOpts app.Options, scope, key string) error {
+	config, err := loadScopedConfig(appOpts, scope, key)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := config.KeyIndex[key]; !ok {
+		return writeConfigMessage(
+			appOpts,
+			fmt.Sprintf("%s is not set in %s.", key, config.Path),
+		)
+	}
+
+	config.Unset(key)
+
+	err = config.Save()
+	if err != nil {
+		return err
+	}
+
+	return writeConfigMessage(
+		appOpts,
+		fmt.Sprintf("Unset %s in %s.", key, config.Path),
+	)
+}
+
+// GetConfig writes the value of key. Without a scope the effective value
+// is used (project over user). Missing keys exit with code 1.
+func GetConfig(appOpts app.Options, scope, key string) error {
+	err := validateConfigKey(key)
+	if err != nil {
+		return err
+	}
+
+	entries, err := loadConfigEntries(appOpts, scope)
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(entries, func(entry configEntry) bool {
+		return entry.Key == key
+	})
+	if index < defaultInt {
+		return app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("%w: %s", errConfigKeyNotSet, key),
+		)
+	}
+
+	if appOpts.JSON {
+		return writeConfigOutput(appOpts, entries[index])
+	}
+
+	return writeConfigOutput(appOpts, entries[index].Value)
+}
+
+// ListConfig writes every key of scope, or the effective settings with
+// their source when no scope is given. Token and secret values are masked.
+func ListConfig(appOpts app.Options, scope string) error {
+	entries, err := loadConfigEntries(appOpts, scope)
+	if err != nil {
+		return err
+	}
+
+	for index := range entries {
+		if secretConfigKeys[entries[index].Key] {
+			entries[index].Value = maskedValue
+		}
+	}
+
+	if appOpts.JSON {
+		return writeConfigOutput(appOpts, entries)
+	}
+
+	return writeConfigOutput(appOpts, formatConfigLines(appOpts, entries))
+}
+
+// loadScopedConfig validates key and loads the file a write targets.
+func loadScopedConfig(
+	appOpts app.Options,
+	scope, key string,
+) (*configFile, error) {
+	err := validateConfigKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scope {
+	case emptyString, ConfigScopeUser:
+		return loadUserConfig(appOpts)
+	case ConfigScopeProject:
+		path, err := projectConfigPath()
+		if err != nil {
+			return nil, err
+		}
+
+		return loadConfigFile(path)
+	default:
+		return nil, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidConfigScope, scope),
+		)
+	}
+}
+
+func validateConfigKey(key string) error {
+	if !configKeyPattern.MatchString(key) {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidConfigKey, key),
+		)
+	}
+
+	return nil
+}
+
+// loadConfigEntries returns the keys of one scope, or all keys with the
+// project config overriding the user config, sorted by key.
+func loadConfigEntries(
+	appOpts app.Options,
+	scope string,
+) ([]configEntry, error) {
+	switch scope {
+	case emptyString, ConfigScopeUser, ConfigScopeProject:
+	default:
+		return nil, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidConfigScope, scope),
+		)
+	}
+
+	sources, err := loadConfigSources(appOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]configEntry{}
+
+	if scope != ConfigScopeProject {
+		addConfigEntries(values, sources.User, ConfigScopeUser)
+	}
+
+	if scope != ConfigScopeUser {
+		addConfigEntries(values, sources.Project, ConfigScopeProject)
+	}
+
+	entries := make([]configEntry, defaultInt, len(values))
+	for _, entry := range values {
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(left, right configEntry) int {
+		return strings.Compare(left.Key, right.Key)
+	})
+
+	return entries, nil
+}
+
+func addConfigEntries(
+	values map[string]configEntry,
+	config *configFile,
+	scope string,
+) {
+	for key, value := range config.Values {
+		values[key] = configEntry{Key: key, Value: value, Scope: scope}
+	}
+}
+
+// warnProjectOverride notes when a user setting is shadowed by the
+// project config in the working directory.
+func warnProjectOverride(appOpts app.Options, scope, key string) error {
+	if scope == ConfigScopeProject {
+		return nil
+	}
+
+	path, err := projectConfigPath()
+	if err != nil {
+		return err
+	}
+
+	project, err := loadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := project.KeyIndex[key]; !ok {
+		return nil
+	}
+
+	return output.WriteWarning(appOpts, fmt.Sprintf(
+		"warning: %s in %s overrides this value",
+		key,
+		project.Path,
+	))
+}
+
+func formatConfigLines(appOpts app.Options, entries []configEntry) []string {
+	lines := make([]string, defaultInt, len(entries)+1)
+
+	if appOpts.Plain {
+		lines = append(lines, configPlainHeader)
+
+		for _, entry := range entries {
+			lines = append(lines, strings.Join(
+				[]string{entry.Key, entry.Value, entry.Scope},
+				"\t",
+			))
+		}
+
+		return lines
+	}
+
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf(
+			"%s = %s  # %s",
+			entry.Key,
+			tomlQuote(entry.Value),
+			entry.Scope,
+		))
+	}
+
+	return lines
+}
+
+func formatPathLines(
+	appOpts app.Options,
+	entries []configPathEntry,
+) []string {
+	lines := make([]string, defaultInt, len(entries)+1)
+
+	if appOpts.Plain {
+		lines = append(lines, pathPlainHeader)
+	}
+
+	for _, entry := range entries {
+		if appOpts.Plain {
+			lines = append(lines, strings.Join([]string{
+				entry.Scope,
+				entry.Path,
+				strconv.FormatBool(entry.Exists),
+			}, "\t"))
+
+			continue
+		}
+
+		state := "exists"
+		if !entry.Exists {
+			state = "not created yet"
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"%s: %s (%s)",
+			entry.Scope,
+			entry.Path,
+			state,
+		))
+	}
+
+	return lines
+}
+
+func writeConfigOutput(appOpts app.Options, data any) error {
+	err := output.WriteOutput(appOpts, data)
+	if err != nil {
+		return fmt.Errorf("write config output: %w", err)
+	}
+
+	return nil
+}
+
+func writeConfigMessage(appOpts app.Options, message string) error {
+	return writeConfigOutput(appOpts, message)
+}
@@ -0,0 +1,150 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	testConfigKey   = "columns_activity_get"
+	testConfigValue = `km = distance / 1000 # "quoted"`
+)
+
+// TestSetConfigRoundTrip keeps quotes and comment characters intact.
+func TestSetConfigRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	opts := testAppOptions(filepath.Join(t.TempDir(), "config.toml"))
+	opts.Quiet = true
+
+	err := SetConfig(opts, ConfigScopeUser, testConfigKey, testConfigValue)
+	if err != nil {
+		t.Fatalf("SetConfig: %v", err)
+	}
+
+	entries, err := loadConfigEntries(opts, ConfigScopeUser)
+	if err != nil {
+		t.Fatalf("loadConfigEntries: %v", err)
+	}
+
+	want := configEntry{
+		Key:   testConfigKey,
+		Value: testConfigValue,
+		Scope: ConfigScopeUser,
+	}
+	if len(entries) != 1 || entries[0] != want {
+		t.Fatalf("got %+v, want [%+v]", entries, want)
+	}
+
+	err = UnsetConfig(opts, emptyString, testConfigKey)
+	if err != nil {
+		t.Fatalf("UnsetConfig: %v", err)
+	}
+
+	entries, err = loadConfigEntries(opts, ConfigScopeUser)
+	if err != nil {
+		t.Fatalf("loadConfigEntries: %v", err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("got %+v, want no entries", entries)
+	}
+}
+
+// TestConfigRejectsInvalidInput exits with usage errors.
+func TestConfigRejectsInvalidInput(t *testing.T) {
+	t.Parallel()
+
+	opts := testAppOptions(filepath.Join(t.TempDir(), "config.toml"))
+	opts.Quiet = true
+
+	tests := []struct {
+		name  string
+		scope string
+		key   string
+		want  error
+	}{
+		{"scope", "system", testConfigKey, errInvalidConfigScope},
+		{"key", ConfigScopeUser, "a = b", errInvalidConfigKey},
+		{"secret", ConfigScopeProject, configKeyRefreshToken, errProjectSecret},
+	}
+
+	for _, test := range tests {
+		err := SetConfig(opts, test.scope, test.key, testConfigValue)
+		if !errors.Is(err, test.want) {
+			t.Fatalf("%s: got %v, want %v", test.name, err, test.want)
+		}
+
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) ||
+			exitErr.Code != app.ExitCodeUsage {
+			t.Fatalf("%s: got %v, want usage error", test.name, err)
+		}
+	}
+}
+
+// TestGetConfigMissingKey exits with code 1.
+func TestGetConfigMissingKey(t *testing.T) {
+	t.Parallel()
+
+	opts := testAppOptions(filepath.Join(t.TempDir(), "config.toml"))
+
+	err := GetConfig(opts, ConfigScopeUser, testConfigKey)
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeFailure {
+		t.Fatalf("got %v, want exit code %d", err, app.ExitCodeFailure)
+	}
+}
+
+// TestSetConfigTableKeys keeps top-level keys above the first table and
+// table keys inside their table.
+func TestSetConfigTableKeys(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	err := os.WriteFile(
+		path,
+		[]byte("cloud = \"eu\"\n\n[experimental]\nsync = \"true\"\n"),
+		configFileMode,
+	)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	config, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+
+	config.Set("units", "imperial")
+	config.Set("experimental.tui", "true")
+	config.Set("other.key", "1")
+
+	want := strings.Join([]string{
+		`cloud = "eu"`,
+		`units = "imperial"`,
+		``,
+		`[experimental]`,
+		`sync = "true"`,
+		`tui = "true"`,
+		``,
+		`[other]`,
+		`key = "1"`,
+	}, "\n")
+	if got := strings.Join(config.Lines, "\n"); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if config.Value("experimental.sync") != "true" ||
+		config.Value("sync") != emptyString {
+		t.Fatalf("unexpected values %v", config.Values)
+	}
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// Tokens are stored as "enc:v1:" followed by base64 of salt, nonce, and
+// the AES-256-GCM ciphertext. The key is derived from the passphrase with
+// PBKDF2-SHA256, so stored tokens stay readable only with the same
+// passphrase or key file.
+const (
+	encryptedPrefix  = "enc:v1:"
+	cryptSaltSize    = 16
+	cryptKeySize     = 32
+	cryptIterations  = 600000
+	keyFileComment   = "#"
+	cryptSecretShort = 8
+)
+
+var (
+	errPassphraseRequired = errors.New(
+		"tokens are encrypted; set " + envConfigPassphrase + " or " +
+			envConfigKeyFile,
+	)
+	errPassphraseShort = errors.New(
+		"token passphrase must be at least 8 characters",
+	)
+	errDecryptToken = errors.New(
+		"decrypt token (wrong passphrase or key file?)",
+	)
+	errKeyFileEmpty = errors.New("key file has no key")
+)
+
+// derivedKeys caches keys per salt, since each derivation is slow on
+// purpose and a run reads the same tokens more than once.
+//
+//nolint:gochecknoglobals // Per-process cache of derived keys.
+var derivedKeys sync.Map
+
+// tokenSecret returns the passphrase from WITHINGS_CONFIG_PASSPHRASE or
+// the first non-comment line of the WITHINGS_CONFIG_KEY_FILE file (e.g.
+// an age identity), or "" when encryption is not configured.
+func tokenSecret() (string, error) {
+	secret := os.Getenv(envConfigPassphrase)
+
+	if secret == emptyString {
+		path := os.Getenv(envConfigKeyFile)
+		if path == emptyString {
+			return emptyString, nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return emptyString, fmt.Errorf("read key file: %w", err)
+		}
+
+		secret = firstKeyLine(content)
+		if secret == emptyString {
+			return emptyString, fmt.Errorf("%w: %s", errKeyFileEmpty, path)
+		}
+	}
+
+	if len(secret) < cryptSecretShort {
+		return emptyString, errPassphraseShort
+	}
+
+	return secret, nil
+}
+
+func firstKeyLine(content []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != emptyString && !strings.HasPrefix(line, keyFileComment) {
+			return line
+		}
+	}
+
+	return emptyString
+}
+
+// encryptToken encrypts value when a passphrase or key file is set and
+// returns it unchanged otherwise.
+func encryptToken(value string) (string, error) {
+	if value == emptyString {
+		return value, nil
+	}
+
+	secret, err := tokenSecret()
+	if err != nil || secret == emptyString {
+		return value, err
+	}
+
+	salt := make([]byte, cryptSaltSize)
+	_, _ = rand.Read(salt)
+
+	aead, err := tokenCipher(secret, salt)
+	if err != nil {
+		return emptyString, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, _ = rand.Read(nonce)
+
+	sealed := aead.Seal(append(salt, nonce...), nonce, []byte(value), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptToken returns plaintext tokens unchanged and decrypts values
+// written by encryptToken.
+func decryptToken(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := tokenSecret()
+	if err != nil {
+		return emptyString, app.NewExitError(app.ExitCodeAuth, err)
+	}
+
+	if secret == emptyString {
+		return emptyString, app.NewExitError(
+			app.ExitCodeAuth,
+			errPassphraseRequired,
+		)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < cryptSaltSize {
+		return emptyString, app.NewExitError(app.ExitCodeAuth, errDecryptToken)
+	}
+
+	aead, err := tokenCipher(secret, sealed[:cryptSaltSize])
+	if err != nil {
+		return emptyString, err
+	}
+
+	rest := sealed[cryptSaltSize:]
+	if len(rest) < aead.NonceSize() {
+		return emptyString, app.NewExitError(app.ExitCodeAuth, errDecryptToken)
+	}
+
+	plain, err := aead.Open(
+		nil,
+		rest[:aead.NonceSize()],
+		rest[aead.NonceSize():],
+		nil,
+	)
+	if err != nil {
+		return emptyString, app.NewExitError(app.ExitCodeAuth, errDecryptToken)
+	}
+
+	return string(plain), nil
+}
+
+func tokenCipher(secret string, salt []byte) (cipher.AEAD, error) {
+	cacheKey := secret + "\x00" + string(salt)
+
+	key, ok := derivedKeys.Load(cacheKey)
+	if !ok {
+		derived, err := pbkdf2.Key(
+			sha256.New,
+			secret,
+			salt,
+			cryptIterations,
+			cryptKeySize,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("derive token key: %w", err)
+		}
+
+		key, _ = derivedKeys.LoadOrStore(cacheKey, derived)
+	}
+
+	keyBytes, _ := key.([]byte)
+
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("token cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("token cipher: %w", err)
+	}
+
+	return aead, nil
+}
@@ -0,0 +1,91 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cryptTestToken = "access-token-value"
+
+// TestTokenEncryptionRoundTrip encrypts with a passphrase and needs the
+// same one to decrypt.
+//
+//nolint:paralleltest // Sets process environment variables.
+func TestTokenEncryptionRoundTrip(t *testing.T) {
+	t.Setenv(envConfigKeyFile, emptyString)
+	t.Setenv(envConfigPassphrase, "correct horse")
+
+	sealed, err := encryptToken(cryptTestToken)
+	if err != nil {
+		t.Fatalf("encryptToken: %v", err)
+	}
+
+	if !strings.HasPrefix(sealed, encryptedPrefix) ||
+		strings.Contains(sealed, cryptTestToken) {
+		t.Fatalf("sealed token got %q", sealed)
+	}
+
+	plain, err := decryptToken(sealed)
+	if err != nil || plain != cryptTestToken {
+		t.Fatalf("decryptToken got %q, %v", plain, err)
+	}
+
+	t.Setenv(envConfigPassphrase, "wrong horse")
+
+	_, err = decryptToken(sealed)
+	if !errors.Is(err, errDecryptToken) {
+		t.Fatalf("wrong passphrase got %v", err)
+	}
+
+	t.Setenv(envConfigPassphrase, emptyString)
+
+	_, err = decryptToken(sealed)
+	if !errors.Is(err, errPassphraseRequired) {
+		t.Fatalf("missing passphrase got %v", err)
+	}
+}
+
+// TestTokenEncryptionDisabled keeps tokens in plain text without a
+// passphrase and passes plain tokens through decryption.
+//
+//nolint:paralleltest // Sets process environment variables.
+func TestTokenEncryptionDisabled(t *testing.T) {
+	t.Setenv(envConfigPassphrase, emptyString)
+	t.Setenv(envConfigKeyFile, emptyString)
+
+	sealed, err := encryptToken(cryptTestToken)
+	if err != nil || sealed != cryptTestToken {
+		t.Fatalf("encryptToken got %q, %v", sealed, err)
+	}
+
+	plain, err := decryptToken(cryptTestToken)
+	if err != nil || plain != cryptTestToken {
+		t.Fatalf("decryptToken got %q, %v", plain, err)
+	}
+}
+
+// TestTokenSecretKeyFile skips comments in the key file.
+//
+//nolint:paralleltest // Sets process environment variables.
+func TestTokenSecretKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key.txt")
+	content := "# created: 2026-01-01\n# public key: age1xyz\n" +
+		"AGE-SECRET-KEY-1TEST\n"
+
+	err := os.WriteFile(path, []byte(content), configFileMode)
+	if err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+
+	t.Setenv(envConfigPassphrase, emptyString)
+	t.Setenv(envConfigKeyFile, path)
+
+	secret, err := tokenSecret()
+	if err != nil || secret != "AGE-SECRET-KEY-1TEST" {
+		t.Fatalf("tokenSecret got %q, %v", secret, err)
+	}
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// configScopeCount is the number of config files a command reads.
+const configScopeCount = 2
+
+// ConfigFileInfo describes one config file for `withings doctor`.
+type ConfigFileInfo struct {
+	Scope  string
+	Path   string
+	Exists bool
+	Mode   os.FileMode
+	Err    error
+}
+
+// Diagnostics is a snapshot of the local auth setup. Secrets are reduced
+// to whether they are present.
+type Diagnostics struct {
+	Configs         []ConfigFileInfo
+	AccessToken     bool
+	RefreshToken    bool
+	ExpiresAt       time.Time
+	ClientID        bool
+	ClientIDSource  string
+	ClientSecret    bool
+	PublicClient    bool
+	RedirectURI     string
+	AccountBaseURL  string
+	ConfigLoadError error
+}
+
+// Diagnose inspects the config files and credentials of the active
+// profile without contacting the API.
+func Diagnose(opts app.Options) Diagnostics {
+	report := Diagnostics{
+		Configs:         nil,
+		AccessToken:     false,
+		RefreshToken:    false,
+		ExpiresAt:       time.Time{},
+		ClientID:        false,
+		ClientIDSource:  emptyString,
+		ClientSecret:    false,
+		PublicClient:    false,
+		RedirectURI:     emptyString,
+		AccountBaseURL:  accountBaseURL(opts.Cloud),
+		ConfigLoadError: nil,
+	}
+
+	report.Configs = diagnoseConfigFiles(opts)
+
+	sources, err := loadConfigSources(opts)
+	if err != nil {
+		report.ConfigLoadError = err
+
+		return report
+	}
+
+	state := buildTokenState(sources.Project, sources.User)
+	client := resolveAuthConfig(emptyString, sources)
+
+	report.AccessToken = state.AccessToken != emptyString
+	report.RefreshToken = state.RefreshToken != emptyString
+	report.ExpiresAt = state.ExpiresAt
+	report.ClientID = client.ClientID != emptyString
+	report.ClientIDSource = credentialSource(
+		resolveValue(
+			emptyString,
+			sources.Project.Value(configKeyClientID),
+			sources.User.Value(configKeyClientID),
+		),
+		envClientID,
+	)
+	report.ClientSecret = client.ClientSecret != emptyString
+	report.PublicClient = sources.User.Value(configKeyPKCE) == pkceEnabledValue
+	report.RedirectURI = client.RedirectURI
+
+	return report
+}
+
+func credentialSource(configValue, envName string) string {
+	if configValue != emptyString {
+		return "config"
+	}
+
+	if os.Getenv(envName) != emptyString {
+		return "env " + envName
+	}
+
+	return emptyString
+}
+
+// diagnoseConfigFiles stats the active profile's config and the project
+// config in the working directory.
+func diagnoseConfigFiles(opts app.Options) []ConfigFileInfo {
+	infos := make([]ConfigFileInfo, defaultInt, configScopeCount)
+
+	userPath, err := activeConfigPath(opts)
+	infos = append(infos, statConfigFile(ConfigScopeUser, userPath, err))
+
+	projectPath, err := projectConfigPath()
+	infos = append(infos, statConfigFile(ConfigScopeProject, projectPath, err))
+
+	return infos
+}
+
+func activeConfigPath(opts app.Options) (string, error) {
+	basePath, err := userConfigPath(opts.Config)
+	if err != nil {
+		return emptyString, err
+	}
+
+	base, err := loadConfigFile(basePath)
+	if err != nil {
+		return basePath, err
+	}
+
+	name, err := activeProfile(opts.Profile, base)
+	if err != nil || name == defaultProfileName {
+		return basePath, err
+	}
+
+	return profileConfigPath(basePath, name), nil
+}
+
+func statConfigFile(scope, path string, pathErr error) ConfigFileInfo {
+	info := ConfigFileInfo{
+		Scope:  scope,
+		Path:   path,
+		Exists: false,
+		Mode:   emptyFileMode,
+		Err:    pathErr,
+	}
+
+	if pathErr != nil {
+		return info
+	}
+
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return info
+	}
+
+	if err != nil {
+		info.Err = fmt.Errorf("stat config: %w", err)
+
+		return info
+	}
+
+	info.Exists = true
+	info.Mode = stat.Mode().Perm()
+
+	//nolint:gosec // Config path is user-controlled by design.
+	file, err := os.Open(path)
+	if err != nil {
+		info.Err = fmt.Errorf("read config: %w", err)
+
+		return info
+	}
+
+	_ = file.Close()
+
+	return info
+}
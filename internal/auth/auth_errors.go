@@ -11,10 +11,14 @@ var (
 	errAuthorizationFailed      = errors.New("authorization failed")
 	errAuthRequired             = errors.New("authentication required")
 	errClientCredentialsMissing = errors.New("missing client ID or secret")
+	errClientIDMissing          = errors.New("missing client ID")
 	errInputRequired            = errors.New(
 		"input required but prompting disabled",
 	)
 	errMissingAuthCode    = errors.New("missing code")
+	errRefreshNotNeeded   = errors.New("token refresh not needed")
+	errRefreshFailed      = errors.New("token refresh failed")
+	errInvalidWindow      = errors.New("--if-expiring must not be negative")
 	errInvalidOpenMode    = errors.New("invalid open mode")
 	errStateMismatch      = errors.New("state mismatch")
 	errTokenRequestFailed = errors.New("token request failed")
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/mreimbold/withings-cli/internal/app"
@@ -44,6 +45,13 @@ type LoginOptions struct {
 	RedirectURI string
 	NoOpen      bool
 	Listen      string
+	PKCE        bool
+	// Manual prints the authorize URL and reads the redirect URL from
+	// stdin instead of waiting for the local callback.
+	Manual bool
+	// Scope is the comma-separated list of OAuth scopes to request; empty
+	// uses config login_scope, then the default.
+	Scope string
 }
 
 // LogoutOptions defines logout options.
@@ -51,18 +59,35 @@ type LogoutOptions struct {
 	Force bool
 }
 
+// StatusOptions defines auth status options.
+type StatusOptions struct {
+	// Check prints nothing and only reports through the exit code: 0
+	// with a valid access token, 3 when it is absent or expired.
+	Check bool
+}
+
 // Login performs the OAuth login flow and stores tokens.
 func Login(ctx context.Context, opts LoginOptions, appOpts app.Options) error {
-	sources, err := loadConfigSources(appOpts.Config)
+	sources, err := loadConfigSources(appOpts)
 	if err != nil {
 		return err
 	}
 
 	userConfig := sources.User
 
-	authConfig := resolveAuthConfig(opts.RedirectURI)
+	authConfig := resolveAuthConfig(opts.RedirectURI, sources)
+
+	opts.Scope, err = resolveLoginScope(opts.Scope, userConfig)
+	if err != nil {
+		return err
+	}
+
+	if opts.PKCE {
+		err = requireClientID(authConfig)
+	} else {
+		err = requireClientCredentials(authConfig, errClientCredentialsMissing)
+	}
 
-	err = requireClientCredentials(authConfig, errClientCredentialsMissing)
 	if err != nil {
 		return err
 	}
@@ -83,12 +108,24 @@ func executeAuthLogin(
 ) error {
 	state := randomState()
 
+	pkce := pkcePair{Verifier: pkceNoVerifier, Challenge: pkceNoChallenge}
+
+	if opts.PKCE {
+		generated, err := newPKCEPair()
+		if err != nil {
+			return err
+		}
+
+		pkce = generated
+	}
+
 	authorizeURL, err := buildAuthorizeURL(
 		accountBaseURL(appOpts.Cloud),
 		authConfig.ClientID,
 		authConfig.RedirectURI,
-		emptyString,
+		opts.Scope,
 		state,
+		pkce.Challenge,
 	)
 	if err != nil {
 		return err
@@ -99,26 +136,46 @@ func executeAuthLogin(
 		openMode = authPrintURL
 	}
 
-	code, err := waitForAuthCode(
-		ctx,
-		authConfig.RedirectURI,
-		opts.Listen,
-		state,
-		authorizeURL,
-		openMode,
-	)
+	var code string
+
+	if opts.Manual {
+		code, err = readAuthCode(appOpts, state, authorizeURL)
+	} else {
+		code, err = waitForAuthCode(
+			ctx,
+			authConfig.RedirectURI,
+			opts.Listen,
+			state,
+			authorizeURL,
+			openMode,
+		)
+	}
+
 	if err != nil {
 		return err
 	}
 
-	return completeAuthLogin(ctx, appOpts, authConfig, code, userConfig)
+	userConfig.Set(configKeyLoginScope, opts.Scope)
+
+	return completeAuthLogin(
+		ctx,
+		appOpts,
+		authConfig,
+		authGrant{Code: code, Verifier: pkce.Verifier},
+		userConfig,
+	)
+}
+
+type authGrant struct {
+	Code     string
+	Verifier string
 }
 
 func completeAuthLogin(
 	ctx context.Context,
 	appOpts app.Options,
 	authConfig authClientConfig,
-	code string,
+	grant authGrant,
 	userConfig *configFile,
 ) error {
 	apiURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
@@ -126,16 +183,24 @@ func completeAuthLogin(
 
 	token, err := exchangeToken(
 		ctx,
+		appOpts,
 		tokenURL,
 		authConfig.ClientID,
 		authConfig.ClientSecret,
-		code,
+		grant.Code,
 		authConfig.RedirectURI,
+		grant.Verifier,
 	)
 	if err != nil {
 		return classifyTokenError(err)
 	}
 
+	if grant.Verifier != pkceNoVerifier {
+		userConfig.Set(configKeyPKCE, pkceEnabledValue)
+	} else {
+		userConfig.Unset(configKeyPKCE)
+	}
+
 	err = persistTokens(userConfig, token)
 	if err != nil {
 		return err
@@ -153,8 +218,8 @@ func completeAuthLogin(
 }
 
 // Status reports token status.
-func Status(appOpts app.Options) error {
-	sources, err := loadConfigSources(appOpts.Config)
+func Status(opts StatusOptions, appOpts app.Options) error {
+	sources, err := loadConfigSources(appOpts)
 	if err != nil {
 		return err
 	}
@@ -164,6 +229,21 @@ func Status(appOpts app.Options) error {
 
 	status := buildAuthStatus(projectConfig, userConfig)
 
+	if opts.Check {
+		return checkAuthStatus(status)
+	}
+
+	if len(status.MissingScopes) > defaultInt {
+		err = output.WriteWarning(appOpts, fmt.Sprintf(
+			"warning: requested scopes not granted: %s "+
+				"(run `withings auth login` again and allow them)",
+			strings.Join(status.MissingScopes, scopeSeparator),
+		))
+		if err != nil {
+			return fmt.Errorf("write status warning: %w", err)
+		}
+	}
+
 	if appOpts.JSON {
 		err = output.WriteOutput(appOpts, status.toMap())
 		if err != nil {
@@ -181,9 +261,18 @@ func Status(appOpts app.Options) error {
 	return nil
 }
 
+// checkAuthStatus turns the token state into a silent exit code.
+func checkAuthStatus(status authStatus) error {
+	if status.AccessToken == emptyString || status.Expired {
+		return app.NewExitError(app.ExitCodeAuth, app.ErrSilent)
+	}
+
+	return nil
+}
+
 // Logout removes stored tokens.
 func Logout(opts LogoutOptions, appOpts app.Options) error {
-	sources, err := loadConfigSources(appOpts.Config)
+	sources, err := loadConfigSources(appOpts)
 	if err != nil {
 		return err
 	}
@@ -264,44 +353,42 @@ func authCallbackHandler(
 	errCh chan<- error,
 ) http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
-		query := request.URL.Query()
-		if query.Get("state") != state {
-			errCh <- errStateMismatch
-
-			http.Error(
-				writer,
-				errStateMismatch.Error(),
-				http.StatusBadRequest,
-			)
-
-			return
-		}
-
-		if errText := query.Get("error"); errText != emptyString {
-			errCh <- fmt.Errorf("%w: %s", errAuthorizationFailed, errText)
+		code, err := codeFromQuery(request.URL.Query(), state)
+		if err != nil {
+			errCh <- err
 
-			http.Error(writer, errText, http.StatusBadRequest)
+			http.Error(writer, err.Error(), http.StatusBadRequest)
 
 			return
 		}
 
-		code := query.Get("code")
-		if code == emptyString {
-			errCh <- errMissingAuthCode
+		codeCh <- code
 
-			http.Error(
-				writer,
-				errMissingAuthCode.Error(),
-				http.StatusBadRequest,
-			)
+		_, _ = fmt.Fprintln(writer, "Auth complete. You can close this tab.")
+	}
+}
 
-			return
-		}
+// codeFromQuery checks the state of an OAuth redirect and returns its
+// authorization code.
+func codeFromQuery(query url.Values, state string) (string, error) {
+	if query.Get("state") != state {
+		return emptyString, errStateMismatch
+	}
 
-		codeCh <- code
+	if errText := query.Get("error"); errText != emptyString {
+		return emptyString, fmt.Errorf(
+			"%w: %s",
+			errAuthorizationFailed,
+			errText,
+		)
+	}
 
-		_, _ = fmt.Fprintln(writer, "Auth complete. You can close this tab.")
+	code := query.Get("code")
+	if code == emptyString {
+		return emptyString, errMissingAuthCode
 	}
+
+	return code, nil
 }
 
 func awaitAuthCode(
@@ -335,10 +422,20 @@ func persistTokens(config *configFile, token tokenBody) error {
 	obtainedAt := time.Now().UTC()
 	expiresAt := obtainedAt.Add(time.Duration(token.ExpiresIn) * time.Second)
 
-	config.Set(configKeyAccessToken, token.AccessToken)
+	accessToken, err := encryptToken(token.AccessToken)
+	if err != nil {
+		return err
+	}
 
-	if token.RefreshToken != emptyString {
-		config.Set(configKeyRefreshToken, token.RefreshToken)
+	refreshToken, err := encryptToken(token.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	config.Set(configKeyAccessToken, accessToken)
+
+	if refreshToken != emptyString {
+		config.Set(configKeyRefreshToken, refreshToken)
 	}
 
 	config.Set(configKeyTokenType, token.TokenType)
@@ -358,6 +455,7 @@ func removeTokenKeys(config *configFile) {
 	config.Unset(configKeyUserID)
 	config.Unset(configKeyTokenExpiresAt)
 	config.Unset(configKeyTokenObtained)
+	config.Unset(configKeyPKCE)
 }
 
 type authStatus struct {
@@ -366,10 +464,14 @@ type authStatus struct {
 	RefreshToken  string
 	RefreshSource string
 	Scope         string
-	TokenType     string
-	UserID        string
-	ExpiresAt     time.Time
-	Expired       bool
+	// RequestedScope is what `auth login` asks for; MissingScopes lists
+	// the requested scopes the stored token was not granted.
+	RequestedScope string
+	MissingScopes  []string
+	TokenType      string
+	UserID         string
+	ExpiresAt      time.Time
+	Expired        bool
 }
 
 func buildAuthStatus(projectConfig, userConfig *configFile) authStatus {
@@ -389,6 +491,12 @@ func buildAuthStatus(projectConfig, userConfig *configFile) authStatus {
 		userConfig.Value(configKeyScope),
 	)
 
+	requestedScope := resolveValue(
+		projectConfig.Value(configKeyLoginScope),
+		userConfig.Value(configKeyLoginScope),
+		defaultAuthScope,
+	)
+
 	tokenType := resolveValue(
 		emptyString,
 		projectConfig.Value(configKeyTokenType),
@@ -404,15 +512,17 @@ func buildAuthStatus(projectConfig, userConfig *configFile) authStatus {
 	expiresAt := parseTime(userConfig.Value(configKeyTokenExpiresAt))
 
 	return authStatus{
-		AccessToken:   accessToken.Value,
-		AccessSource:  accessToken.Source,
-		RefreshToken:  refreshToken.Value,
-		RefreshSource: refreshToken.Source,
-		Scope:         scope,
-		TokenType:     tokenType,
-		UserID:        userID,
-		ExpiresAt:     expiresAt,
-		Expired:       isExpired(expiresAt),
+		AccessToken:    accessToken.Value,
+		AccessSource:   accessToken.Source,
+		RefreshToken:   refreshToken.Value,
+		RefreshSource:  refreshToken.Source,
+		Scope:          scope,
+		RequestedScope: requestedScope,
+		MissingScopes:  missingScopes(requestedScope, scope),
+		TokenType:      tokenType,
+		UserID:         userID,
+		ExpiresAt:      expiresAt,
+		Expired:        isExpired(expiresAt),
 	}
 }
 
@@ -423,9 +533,12 @@ func (status authStatus) toMap() map[string]any {
 		"access_token_source":   status.AccessSource,
 		"refresh_token_source":  status.RefreshSource,
 		"scope":                 status.Scope,
+		"requested_scope":       status.RequestedScope,
+		"missing_scopes":        append([]string{}, status.MissingScopes...),
 		"token_type":            status.TokenType,
 		"user_id":               status.UserID,
 		"token_expires_at":      formatExpiry(status.ExpiresAt),
+		"expires_in":            formatExpiresIn(status.ExpiresAt, time.Now()),
 		"expired":               status.Expired,
 	}
 }
@@ -439,17 +552,25 @@ func (status authStatus) toLines() []string {
 		status.RefreshSource + ")"
 	scopeLine := "Scope: " +
 		defaultIfEmpty(status.Scope, statusUnknownText)
+	requestedLine := "Requested scope: " + status.RequestedScope
 	tokenTypeLine := "Token type: " +
 		defaultIfEmpty(status.TokenType, statusUnknownText)
 	userLine := "User ID: " +
 		defaultIfEmpty(status.UserID, statusUnknownText)
 	expiresLine := "Expires at: " + formatExpiry(status.ExpiresAt)
+
+	expiresIn := formatExpiresIn(status.ExpiresAt, time.Now())
+	if expiresIn != emptyString {
+		expiresLine += " (" + expiresIn + ")"
+	}
+
 	expiredLine := "Expired: " + strconv.FormatBool(status.Expired)
 
 	return []string{
 		accessLine,
 		refreshLine,
 		scopeLine,
+		requestedLine,
 		tokenTypeLine,
 		userLine,
 		expiresLine,
@@ -457,18 +578,55 @@ func (status authStatus) toLines() []string {
 	}
 }
 
-func resolveAuthConfig(redirectOverride string) authClientConfig {
+// resolveAuthConfig prefers credentials stored in the config over the
+// environment so each profile can use its own app registration; a shared
+// project config overrides the profile. The redirect URI saved by `auth
+// set-client` applies unless overridden. The secret never comes from the
+// project config, which is meant to be committed.
+func resolveAuthConfig(
+	redirectOverride string,
+	sources configSources,
+) authClientConfig {
 	return authClientConfig{
-		ClientID:     os.Getenv(envClientID),
-		ClientSecret: os.Getenv(envClientSecret),
+		ClientID: resolveValue(
+			sources.Project.Value(configKeyClientID),
+			sources.User.Value(configKeyClientID),
+			os.Getenv(envClientID),
+		),
+		ClientSecret: resolveValue(
+			emptyString,
+			sources.User.Value(configKeyClientSecret),
+			os.Getenv(envClientSecret),
+		),
 		RedirectURI: resolveValue(
 			redirectOverride,
-			emptyString,
-			emptyString,
+			sources.Project.Value(configKeyRedirectURI),
+			sources.User.Value(configKeyRedirectURI),
 		),
 	}
 }
 
+// ClientCredentials resolves the app's client ID and secret, which signed
+// actions use instead of an access token.
+func ClientCredentials(appOpts app.Options) (withings.Credentials, error) {
+	sources, err := loadConfigSources(appOpts)
+	if err != nil {
+		return withings.Credentials{}, err
+	}
+
+	config := resolveAuthConfig(emptyString, sources)
+
+	err = requireClientCredentials(config, errClientCredentialsMissing)
+	if err != nil {
+		return withings.Credentials{}, err
+	}
+
+	return withings.Credentials{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+	}, nil
+}
+
 func requireClientCredentials(config authClientConfig, missingErr error) error {
 	if config.ClientID == emptyString || config.ClientSecret == emptyString {
 		return app.NewExitError(app.ExitCodeUsage, missingErr)
@@ -477,6 +635,14 @@ func requireClientCredentials(config authClientConfig, missingErr error) error {
 	return nil
 }
 
+func requireClientID(config authClientConfig) error {
+	if config.ClientID == emptyString {
+		return app.NewExitError(app.ExitCodeUsage, errClientIDMissing)
+	}
+
+	return nil
+}
+
 func buildLocalRedirectURI(listenAddr string) string {
 	return "http://" + listenAddr + "/callback"
 }
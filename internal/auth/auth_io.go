@@ -48,6 +48,21 @@ func confirm(prompt string, opts app.Options) (bool, error) {
 	return answer == "y" || answer == "yes", nil
 }
 
+// ConfirmWrite asks prompt on stderr before a state-changing call. When
+// prompting is impossible (--no-input or no terminal) it fails with a
+// usage error that names --yes.
+func ConfirmWrite(prompt string, opts app.Options) (bool, error) {
+	ok, err := confirm(prompt, opts)
+	if errors.Is(err, errInputRequired) {
+		return false, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: pass --yes", errInputRequired),
+		)
+	}
+
+	return ok, err
+}
+
 const emptyFileMode os.FileMode = 0
 
 func isTerminal(file *os.File) bool {
@@ -91,3 +106,43 @@ func formatExpiry(expiresAt time.Time) string {
 
 	return expiresAt.Format(time.RFC3339)
 }
+
+// formatExpiresIn describes the time left until expiresAt, e.g. "expires
+// in 42m" or "expired 3h ago"; an unknown expiry gives "".
+func formatExpiresIn(expiresAt, now time.Time) string {
+	if expiresAt.IsZero() {
+		return emptyString
+	}
+
+	left := expiresAt.Sub(now)
+	if left <= 0 {
+		return "expired " + humanizeDuration(-left) + " ago"
+	}
+
+	return "expires in " + humanizeDuration(left)
+}
+
+// humanizeDuration renders d with its two largest units, from days down
+// to minutes; anything shorter reads as "<1m".
+func humanizeDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+
+	days := d / day
+	hours := (d % day) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%dd", days)
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	case minutes > 0:
+		return fmt.Sprintf("%dm", minutes)
+	default:
+		return "<1m"
+	}
+}
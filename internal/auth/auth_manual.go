@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const manualPrompt = "After approving, your browser is sent to the redirect " +
+	"URI (the page may fail to load).\nPaste the full URL from the address " +
+	"bar: "
+
+var errRedirectMissing = errors.New("no redirect URL pasted")
+
+// readAuthCode completes the login without the local callback: it prints
+// the authorize URL, then reads the redirect URL pasted on stdin. Piped
+// stdin works too, so scripts can hand the URL over.
+func readAuthCode(
+	appOpts app.Options,
+	state string,
+	authorizeURL string,
+) (string, error) {
+	if appOpts.NoInput {
+		return emptyString, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: --manual reads the redirect URL", errInputRequired),
+		)
+	}
+
+	err := writeAuthURL(authorizeURL)
+	if err != nil {
+		return emptyString, err
+	}
+
+	_, err = fmt.Fprint(os.Stderr, manualPrompt)
+	if err != nil {
+		return emptyString, fmt.Errorf("write prompt: %w", err)
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return emptyString, fmt.Errorf("read input: %w", err)
+	}
+
+	return parseRedirect(line, state)
+}
+
+// parseRedirect takes the code from a pasted redirect URL, or from just
+// its query string.
+func parseRedirect(pasted, state string) (string, error) {
+	pasted = strings.TrimSpace(pasted)
+	if pasted == emptyString {
+		return emptyString, app.NewExitError(
+			app.ExitCodeUsage,
+			errRedirectMissing,
+		)
+	}
+
+	if _, query, ok := strings.Cut(pasted, "?"); ok {
+		pasted = query
+	}
+
+	pasted, _, _ = strings.Cut(pasted, "#")
+
+	query, err := url.ParseQuery(pasted)
+	if err != nil {
+		return emptyString, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("parse redirect URL: %w", err),
+		)
+	}
+
+	return codeFromQuery(query, state)
+}
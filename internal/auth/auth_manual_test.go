@@ -0,0 +1,42 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+const manualTestState = "abc123"
+
+// TestParseRedirect accepts full URLs and bare query strings and checks
+// the state.
+func TestParseRedirect(t *testing.T) {
+	t.Parallel()
+
+	accepted := []string{
+		"http://127.0.0.1:8787/callback?code=c0de&state=abc123\n",
+		"  https://example.com/cb?state=abc123&code=c0de#frag ",
+		"code=c0de&state=abc123",
+	}
+
+	for _, pasted := range accepted {
+		code, err := parseRedirect(pasted, manualTestState)
+		if err != nil || code != "c0de" {
+			t.Fatalf("parseRedirect(%q) got %q, %v", pasted, code, err)
+		}
+	}
+
+	rejected := map[string]error{
+		"http://x/cb?code=c0de&state=other":            errStateMismatch,
+		"http://x/cb?state=abc123":                     errMissingAuthCode,
+		"http://x/cb?error=access_denied&state=abc123": errAuthorizationFailed,
+		"   ": errRedirectMissing,
+	}
+
+	for pasted, want := range rejected {
+		_, err := parseRedirect(pasted, manualTestState)
+		if !errors.Is(err, want) {
+			t.Fatalf("parseRedirect(%q) got %v want %v", pasted, err, want)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/withings"
 )
 
@@ -38,7 +39,6 @@ const (
 	oauthResponseTypeCode   = "code"
 	oauthScopeKey           = "scope"
 	oauthStateKey           = "state"
-	tokenRequestTimeout     = 30 * time.Second
 	tokenNullLiteral        = "null"
 	tokenQuoteByte          = '"'
 )
@@ -102,6 +102,7 @@ func buildAuthorizeURL(
 	redirectURI string,
 	scope string,
 	state string,
+	codeChallenge string,
 ) (string, error) {
 	resolvedScope := scope
 	if resolvedScope == emptyString {
@@ -120,6 +121,12 @@ func buildAuthorizeURL(
 	query.Set(oauthRedirectURIKey, redirectURI)
 	query.Set(oauthStateKey, state)
 	query.Set(oauthScopeKey, resolvedScope)
+
+	if codeChallenge != pkceNoChallenge {
+		query.Set(oauthCodeChallengeKey, codeChallenge)
+		query.Set(oauthChallengeMethod, pkceMethodS256)
+	}
+
 	parsedURL.RawQuery = query.Encode()
 
 	return parsedURL.String(), nil
@@ -127,25 +134,32 @@ func buildAuthorizeURL(
 
 func exchangeToken(
 	ctx context.Context,
+	appOpts app.Options,
 	tokenURL string,
 	clientID string,
 	clientSecret string,
 	code string,
 	redirectURI string,
+	codeVerifier string,
 ) (tokenBody, error) {
 	values := url.Values{}
 	values.Set(oauthActionKey, oauthActionRequestToken)
 	values.Set(oauthGrantTypeKey, oauthGrantAuthorization)
 	values.Set(oauthClientIDKey, clientID)
-	values.Set(oauthClientSecretKey, clientSecret)
+	setClientSecret(values, clientSecret)
 	values.Set(oauthCodeKey, code)
 	values.Set(oauthRedirectURIKey, redirectURI)
 
-	return doTokenRequest(ctx, tokenURL, values)
+	if codeVerifier != pkceNoVerifier {
+		values.Set(oauthCodeVerifierKey, codeVerifier)
+	}
+
+	return doTokenRequest(ctx, appOpts, tokenURL, values)
 }
 
 func refreshToken(
 	ctx context.Context,
+	appOpts app.Options,
 	tokenURL string,
 	clientID string,
 	clientSecret string,
@@ -155,18 +169,28 @@ func refreshToken(
 	values.Set(oauthActionKey, oauthActionRequestToken)
 	values.Set(oauthGrantTypeKey, oauthGrantRefresh)
 	values.Set(oauthClientIDKey, clientID)
-	values.Set(oauthClientSecretKey, clientSecret)
+	setClientSecret(values, clientSecret)
 	values.Set(oauthRefreshTokenKey, refresh)
 
-	return doTokenRequest(ctx, tokenURL, values)
+	return doTokenRequest(ctx, appOpts, tokenURL, values)
+}
+
+// setClientSecret omits the secret for PKCE public clients.
+func setClientSecret(values url.Values, clientSecret string) {
+	if clientSecret == emptyString {
+		return
+	}
+
+	values.Set(oauthClientSecretKey, clientSecret)
 }
 
 func doTokenRequest(
 	ctx context.Context,
+	appOpts app.Options,
 	tokenURL string,
 	values url.Values,
 ) (tokenBody, error) {
-	requestCtx, cancel := context.WithTimeout(ctx, tokenRequestTimeout)
+	requestCtx, cancel := tokenRequestContext(ctx, appOpts.Timeout)
 	defer cancel()
 
 	req, err := buildTokenRequest(requestCtx, tokenURL, values)
@@ -174,7 +198,7 @@ func doTokenRequest(
 		return tokenBody{}, err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := withings.HTTPClient(appOpts).Do(req)
 	if err != nil {
 		return tokenBody{}, networkError{err: err}
 	}
@@ -207,6 +231,20 @@ func doTokenRequest(
 	return body, nil
 }
 
+// tokenRequestContext applies --timeout to a token request. Unlike API
+// requests, token requests are never retried: authorization codes are
+// single-use.
+func tokenRequestContext(
+	ctx context.Context,
+	timeout time.Duration,
+) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
 func buildTokenRequest(
 	ctx context.Context,
 	tokenURL string,
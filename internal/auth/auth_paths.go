@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	configAppDir       = "withings-cli"
+	configFileName     = "config.toml"
+	legacyConfigRelDir = ".config"
+)
+
+// defaultUserConfigPath returns config.toml in the platform config
+// directory: $XDG_CONFIG_HOME (else ~/.config) on Linux, %AppData% on
+// Windows, and ~/Library/Application Support on macOS.
+func defaultUserConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return emptyString, fmt.Errorf("resolve home directory: %w", err)
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		// An unusable $XDG_CONFIG_HOME (e.g. relative) keeps the
+		// historical location.
+		configDir = filepath.Join(homeDir, legacyConfigRelDir)
+	}
+
+	return resolveUserConfigPath(configDir, homeDir), nil
+}
+
+// resolveUserConfigPath moves a config directory left at the historical
+// ~/.config/withings-cli into configDir, profiles and state included, the
+// first time the new location is used. When the move fails the old
+// location stays in use, so nothing is lost.
+func resolveUserConfigPath(configDir, homeDir string) string {
+	dir := filepath.Join(configDir, configAppDir)
+	legacyDir := filepath.Join(homeDir, legacyConfigRelDir, configAppDir)
+
+	if filepath.Clean(dir) == filepath.Clean(legacyDir) ||
+		pathExists(dir) || !pathExists(legacyDir) {
+		return filepath.Join(dir, configFileName)
+	}
+
+	err := migrateConfigDir(legacyDir, dir)
+	if err != nil {
+		return filepath.Join(legacyDir, configFileName)
+	}
+
+	return filepath.Join(dir, configFileName)
+}
+
+func migrateConfigDir(from, to string) error {
+	err := os.MkdirAll(filepath.Dir(to), configDirMode)
+	if err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+
+	err = os.Rename(from, to)
+	if err != nil {
+		return fmt.Errorf("move config %s to %s: %w", from, to, err)
+	}
+
+	return nil
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+
+	return !errors.Is(err, os.ErrNotExist)
+}
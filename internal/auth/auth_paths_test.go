@@ -0,0 +1,88 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestResolveUserConfigPathMigrates moves the legacy directory once.
+func TestResolveUserConfigPathMigrates(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	legacyDir := filepath.Join(home, legacyConfigRelDir, configAppDir)
+	configDir := filepath.Join(home, "AppData", "Roaming")
+
+	err := os.MkdirAll(filepath.Join(legacyDir, profilesDirName), 0o700)
+	if err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	err = os.WriteFile(
+		filepath.Join(legacyDir, configFileName),
+		[]byte("cloud = \"us\"\n"),
+		0o600,
+	)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	want := filepath.Join(configDir, configAppDir, configFileName)
+
+	got := resolveUserConfigPath(configDir, home)
+	if got != want {
+		t.Fatalf(testGotWantFormat, got, want)
+	}
+
+	config, err := loadConfigFile(got)
+	if err != nil || config.Value(configKeyCloud) != "us" {
+		t.Fatalf("migrated config got %v, %v", config, err)
+	}
+
+	if pathExists(legacyDir) {
+		t.Fatal("legacy directory left behind")
+	}
+
+	if !pathExists(filepath.Join(configDir, configAppDir, profilesDirName)) {
+		t.Fatal("profiles not migrated")
+	}
+}
+
+// TestResolveUserConfigPathKeepsExisting never overwrites the new
+// location and leaves a fresh setup alone.
+func TestResolveUserConfigPathKeepsExisting(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	legacyDir := filepath.Join(home, legacyConfigRelDir, configAppDir)
+	configDir := filepath.Join(home, "xdg")
+	newDir := filepath.Join(configDir, configAppDir)
+
+	for _, dir := range []string{legacyDir, newDir} {
+		err := os.MkdirAll(dir, 0o700)
+		if err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	want := filepath.Join(configDir, configAppDir, configFileName)
+
+	if got := resolveUserConfigPath(configDir, home); got != want {
+		t.Fatalf(testGotWantFormat, got, want)
+	}
+
+	if !pathExists(legacyDir) {
+		t.Fatal("legacy directory removed although the new one exists")
+	}
+
+	fresh := t.TempDir()
+	freshDir := filepath.Join(fresh, legacyConfigRelDir)
+	want = filepath.Join(freshDir, configAppDir, configFileName)
+
+	got := resolveUserConfigPath(freshDir, fresh)
+	if got != want {
+		t.Fatalf(testGotWantFormat, got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+const (
+	pkceVerifierBytes     = 32
+	pkceMethodS256        = "S256"
+	pkceEnabledValue      = "true"
+	oauthCodeChallengeKey = "code_challenge"
+	oauthChallengeMethod  = "code_challenge_method"
+	oauthCodeVerifierKey  = "code_verifier"
+	configKeyPKCE         = "pkce"
+	pkceNoChallenge       = ""
+	pkceNoVerifier        = ""
+)
+
+// pkcePair holds an RFC 7636 code verifier and its S256 challenge.
+type pkcePair struct {
+	Verifier  string
+	Challenge string
+}
+
+func newPKCEPair() (pkcePair, error) {
+	data := make([]byte, pkceVerifierBytes)
+
+	_, err := rand.Read(data)
+	if err != nil {
+		return pkcePair{}, fmt.Errorf("generate pkce verifier: %w", err)
+	}
+
+	verifier := base64.RawURLEncoding.EncodeToString(data)
+
+	return pkcePair{
+		Verifier:  verifier,
+		Challenge: pkceChallenge(verifier),
+	}, nil
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
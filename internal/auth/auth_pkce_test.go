@@ -0,0 +1,67 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"net/url"
+	"testing"
+)
+
+const (
+	pkceTestVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	pkceTestChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+// TestPKCEChallenge matches the RFC 7636 appendix B example.
+func TestPKCEChallenge(t *testing.T) {
+	t.Parallel()
+
+	got := pkceChallenge(pkceTestVerifier)
+	if got != pkceTestChallenge {
+		t.Fatalf("challenge got %q want %q", got, pkceTestChallenge)
+	}
+}
+
+// TestNewPKCEPair derives the challenge from the generated verifier.
+func TestNewPKCEPair(t *testing.T) {
+	t.Parallel()
+
+	pair, err := newPKCEPair()
+	if err != nil {
+		t.Fatalf("newPKCEPair: %v", err)
+	}
+
+	if pair.Challenge != pkceChallenge(pair.Verifier) {
+		t.Fatalf("challenge does not match verifier %q", pair.Verifier)
+	}
+}
+
+// TestBuildAuthorizeURLPKCE adds the challenge and method when set.
+func TestBuildAuthorizeURLPKCE(t *testing.T) {
+	t.Parallel()
+
+	raw, err := buildAuthorizeURL(
+		"https://account.withings.com",
+		"client",
+		"http://127.0.0.1:9876/callback",
+		emptyString,
+		"state",
+		pkceTestChallenge,
+	)
+	if err != nil {
+		t.Fatalf("buildAuthorizeURL: %v", err)
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+
+	query := parsed.Query()
+	if query.Get(oauthCodeChallengeKey) != pkceTestChallenge {
+		t.Fatalf("code_challenge got %q", query.Get(oauthCodeChallengeKey))
+	}
+
+	if query.Get(oauthChallengeMethod) != pkceMethodS256 {
+		t.Fatalf("method got %q", query.Get(oauthChallengeMethod))
+	}
+}
@@ -0,0 +1,387 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	configKeyProfile      = "profile"
+	configKeyCloud        = "cloud"
+	configKeyClientID     = "client_id"
+	configKeyClientSecret = "client_secret"
+	envProfile            = "WITHINGS_PROFILE"
+	defaultProfileName    = "default"
+	profilesDirName       = "profiles"
+	profileFileExt        = ".toml"
+	profilePlainHeader    = "name\tcloud\tactive"
+	profileCloudUnset     = "-"
+	profileActiveMarker   = "* "
+	profileInactiveMarker = "  "
+)
+
+var (
+	errInvalidProfileName = errors.New(
+		"invalid profile name (use letters, digits, '-' or '_')",
+	)
+	errUnknownProfile      = errors.New("unknown profile")
+	errProfileExists       = errors.New("profile already exists")
+	errProfileCloud        = errors.New("invalid --cloud (expected eu or us)")
+	errDefaultProfileWrite = errors.New("the default profile always exists")
+)
+
+//nolint:gochecknoglobals // Static pattern for profile file names.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// ProfileOptions defines profile creation options.
+type ProfileOptions struct {
+	Name     string
+	Cloud    string
+	ClientID string
+	Use      bool
+}
+
+type profileEntry struct {
+	Name   string `json:"name"`
+	Cloud  string `json:"cloud"`
+	Active bool   `json:"active"`
+}
+
+// loadUserConfig loads the config of the active profile. The default
+// profile is the user config file itself; named profiles live next to it
+// under profiles/<name>.toml.
+func loadUserConfig(opts app.Options) (*configFile, error) {
+	basePath, err := userConfigPath(opts.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := loadConfigFile(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := activeProfile(opts.Profile, base)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == defaultProfileName {
+		return base, nil
+	}
+
+	config, err := loadConfigFile(profileConfigPath(basePath, name))
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.Exists {
+		return nil, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errUnknownProfile, name),
+		)
+	}
+
+	return config, nil
+}
+
+// activeProfile resolves --profile, then WITHINGS_PROFILE, then the
+// profile selected with `profile use`.
+func activeProfile(flagValue string, base *configFile) (string, error) {
+	name := resolveValue(
+		flagValue,
+		os.Getenv(envProfile),
+		base.Value(configKeyProfile),
+	)
+	if name == emptyString {
+		return defaultProfileName, nil
+	}
+
+	if !profileNamePattern.MatchString(name) {
+		return emptyString, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidProfileName, name),
+		)
+	}
+
+	return name, nil
+}
+
+func profileConfigPath(basePath, name string) string {
+	return filepath.Join(
+		filepath.Dir(basePath),
+		profilesDirName,
+		name+profileFileExt,
+	)
+}
+
+// CreateProfile writes a new named profile.
+func CreateProfile(opts ProfileOptions, appOpts app.Options) error {
+	err := validateNewProfile(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	basePath, err := userConfigPath(appOpts.Config)
+	if err != nil {
+		return err
+	}
+
+	config, err := loadConfigFile(profileConfigPath(basePath, opts.Name))
+	if err != nil {
+		return err
+	}
+
+	if config.Exists {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errProfileExists, opts.Name),
+		)
+	}
+
+	if opts.Cloud != emptyString {
+		config.Set(configKeyCloud, opts.Cloud)
+	}
+
+	if opts.ClientID != emptyString {
+		config.Set(configKeyClientID, opts.ClientID)
+	}
+
+	err = config.Save()
+	if err != nil {
+		return err
+	}
+
+	if opts.Use {
+		return UseProfile(opts.Name, appOpts)
+	}
+
+	return writeProfileMessage(appOpts, "Created profile "+opts.Name+".")
+}
+
+func validateNewProfile(opts ProfileOptions) error {
+	if opts.Name == defaultProfileName {
+		return errDefaultProfileWrite
+	}
+
+	if !profileNamePattern.MatchString(opts.Name) {
+		return fmt.Errorf("%w: %q", errInvalidProfileName, opts.Name)
+	}
+
+	switch opts.Cloud {
+	case emptyString, "eu", "us":
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errProfileCloud, opts.Cloud)
+	}
+}
+
+// UseProfile makes a profile the default for later commands.
+func UseProfile(name string, appOpts app.Options) error {
+	basePath, err := userConfigPath(appOpts.Config)
+	if err != nil {
+		return err
+	}
+
+	base, err := loadConfigFile(basePath)
+	if err != nil {
+		return err
+	}
+
+	if name == defaultProfileName {
+		base.Unset(configKeyProfile)
+	} else {
+		err = requireProfile(basePath, name)
+		if err != nil {
+			return err
+		}
+
+		base.Set(configKeyProfile, name)
+	}
+
+	err = base.Save()
+	if err != nil {
+		return err
+	}
+
+	return writeProfileMessage(appOpts, "Using profile "+name+".")
+}
+
+func requireProfile(basePath, name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidProfileName, name),
+		)
+	}
+
+	_, err := os.Stat(profileConfigPath(basePath, name))
+	if err != nil {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errUnknownProfile, name),
+		)
+	}
+
+	return nil
+}
+
+// ListProfiles writes the default and named profiles.
+func ListProfiles(appOpts app.Options) error {
+	entries, err := loadProfileEntries(appOpts)
+	if err != nil {
+		return err
+	}
+
+	if appOpts.JSON {
+		err = output.WriteOutput(appOpts, entries)
+	} else {
+		err = output.WriteOutput(appOpts, formatProfileLines(appOpts, entries))
+	}
+
+	if err != nil {
+		return fmt.Errorf("write profile output: %w", err)
+	}
+
+	return nil
+}
+
+func loadProfileEntries(appOpts app.Options) ([]profileEntry, error) {
+	basePath, err := userConfigPath(appOpts.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := loadConfigFile(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := activeProfile(appOpts.Profile, base)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := profileNames(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []profileEntry{{
+		Name:   defaultProfileName,
+		Cloud:  base.Value(configKeyCloud),
+		Active: active == defaultProfileName,
+	}}
+
+	for _, name := range names {
+		config, err := loadConfigFile(profileConfigPath(basePath, name))
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, profileEntry{
+			Name:   name,
+			Cloud:  config.Value(configKeyCloud),
+			Active: active == name,
+		})
+	}
+
+	return entries, nil
+}
+
+// ProfileNames returns the default profile and every named profile next
+// to the user config at configPath (or the default location), for shell
+// completion.
+func ProfileNames(configPath string) ([]string, error) {
+	basePath, err := userConfigPath(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := profileNames(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]string{defaultProfileName}, names...), nil
+}
+
+func profileNames(basePath string) ([]string, error) {
+	dir := filepath.Join(filepath.Dir(basePath), profilesDirName)
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("read profiles %s: %w", dir, err)
+	}
+
+	var names []string
+
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), profileFileExt)
+		if file.IsDir() || !ok || !profileNamePattern.MatchString(name) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func formatProfileLines(appOpts app.Options, entries []profileEntry) []string {
+	lines := make([]string, defaultInt, len(entries))
+
+	if appOpts.Plain {
+		lines = append(lines, profilePlainHeader)
+
+		for _, entry := range entries {
+			lines = append(lines, strings.Join([]string{
+				entry.Name,
+				entry.Cloud,
+				strconv.FormatBool(entry.Active),
+			}, "\t"))
+		}
+
+		return lines
+	}
+
+	for _, entry := range entries {
+		marker := profileInactiveMarker
+		if entry.Active {
+			marker = profileActiveMarker
+		}
+
+		lines = append(lines, fmt.Sprintf(
+			"%s%s (cloud: %s)",
+			marker,
+			entry.Name,
+			defaultIfEmpty(entry.Cloud, profileCloudUnset),
+		))
+	}
+
+	return lines
+}
+
+func writeProfileMessage(appOpts app.Options, message string) error {
+	err := output.WriteOutput(appOpts, message)
+	if err != nil {
+		return fmt.Errorf("write profile output: %w", err)
+	}
+
+	return nil
+}
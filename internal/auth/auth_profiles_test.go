@@ -0,0 +1,128 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	testProfileName  = "spouse"
+	testProfileCloud = "us"
+	testProfileID    = "spouse-client"
+)
+
+// TestProfileScopesUserConfig stores tokens per profile.
+func TestProfileScopesUserConfig(t *testing.T) {
+	t.Parallel()
+
+	opts := testAppOptions(filepath.Join(t.TempDir(), "config.toml"))
+	opts.Quiet = true
+
+	err := CreateProfile(ProfileOptions{
+		Name:     testProfileName,
+		Cloud:    testProfileCloud,
+		ClientID: testProfileID,
+		Use:      true,
+	}, opts)
+	if err != nil {
+		t.Fatalf("CreateProfile: %v", err)
+	}
+
+	config, err := loadUserConfig(opts)
+	if err != nil {
+		t.Fatalf("loadUserConfig: %v", err)
+	}
+
+	wantPath := profileConfigPath(opts.Config, testProfileName)
+	if config.Path != wantPath {
+		t.Fatalf(testGotWantFormat, config.Path, wantPath)
+	}
+
+	settings, err := ConfigValues(opts, configKeyCloud)
+	if err != nil {
+		t.Fatalf("ConfigValues: %v", err)
+	}
+
+	if cloud := settings[configKeyCloud]; cloud != testProfileCloud {
+		t.Fatalf(testGotWantFormat, cloud, testProfileCloud)
+	}
+
+	sources, err := loadConfigSources(opts)
+	if err != nil {
+		t.Fatalf("loadConfigSources: %v", err)
+	}
+
+	authConfig := resolveAuthConfig(emptyString, sources)
+	if authConfig.ClientID != testProfileID {
+		t.Fatalf(testGotWantFormat, authConfig.ClientID, testProfileID)
+	}
+
+	opts.Profile = defaultProfileName
+
+	config, err = loadUserConfig(opts)
+	if err != nil {
+		t.Fatalf("loadUserConfig default: %v", err)
+	}
+
+	if config.Path != opts.Config {
+		t.Fatalf(testGotWantFormat, config.Path, opts.Config)
+	}
+}
+
+// TestLoadUserConfigUnknownProfile rejects profiles that were not created.
+func TestLoadUserConfigUnknownProfile(t *testing.T) {
+	t.Parallel()
+
+	opts := testAppOptions(filepath.Join(t.TempDir(), "config.toml"))
+	opts.Profile = "missing"
+
+	_, err := loadUserConfig(opts)
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf(testExitErrFormat, err)
+	}
+
+	if exitErr.Code != app.ExitCodeUsage {
+		t.Fatalf(testExitCodeFormat, exitErr.Code, app.ExitCodeUsage)
+	}
+
+	if !errors.Is(err, errUnknownProfile) {
+		t.Fatalf("expected errUnknownProfile, got %v", err)
+	}
+}
+
+// TestCreateProfileRejectsInvalid guards names and clouds.
+func TestCreateProfileRejectsInvalid(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		opts ProfileOptions
+		want error
+	}{
+		{testProfileOptions("../escape", emptyString), errInvalidProfileName},
+		{testProfileOptions(defaultProfileName, emptyString),
+			errDefaultProfileWrite},
+		{testProfileOptions(testProfileName, "asia"), errProfileCloud},
+	}
+
+	for _, tc := range cases {
+		err := validateNewProfile(tc.opts)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%q: got %v want %v", tc.opts.Name, err, tc.want)
+		}
+	}
+}
+
+func testProfileOptions(name, cloud string) ProfileOptions {
+	return ProfileOptions{
+		Name:     name,
+		Cloud:    cloud,
+		ClientID: emptyString,
+		Use:      false,
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// RefreshOptions defines explicit token refresh options.
+type RefreshOptions struct {
+	IfExpiring  time.Duration
+	AllProfiles bool
+	Now         func() time.Time
+}
+
+// Refresh exchanges the stored refresh token for a new access token.
+// With IfExpiring set it only refreshes when the access token expires
+// within that window and otherwise exits with ExitCodeUnchanged.
+func Refresh(
+	ctx context.Context,
+	opts RefreshOptions,
+	appOpts app.Options,
+) error {
+	if opts.IfExpiring < 0 {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidWindow)
+	}
+
+	nowFunc := opts.Now
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	if opts.AllProfiles {
+		return refreshAllProfiles(ctx, opts, appOpts, nowFunc())
+	}
+
+	expiresAt, err := refreshProfile(ctx, opts, appOpts, nowFunc())
+	if err != nil {
+		return err
+	}
+
+	return writeRefreshResult(appOpts, expiresAt)
+}
+
+// refreshProfile refreshes the token of the profile selected in appOpts
+// and returns the new expiry as RFC3339. When no refresh is due it returns
+// the current expiry with an ExitCodeUnchanged error.
+func refreshProfile(
+	ctx context.Context,
+	opts RefreshOptions,
+	appOpts app.Options,
+	now time.Time,
+) (string, error) {
+	state, sources, err := loadTokenState(appOpts)
+	if err != nil {
+		return emptyString, err
+	}
+
+	if !refreshDue(state, opts.IfExpiring, now) {
+		expiresAt := state.ExpiresAt.UTC().Format(time.RFC3339)
+
+		return expiresAt, app.NewExitError(
+			app.ExitCodeUnchanged,
+			fmt.Errorf("%w: expires at %s", errRefreshNotNeeded, expiresAt),
+		)
+	}
+
+	token, err := refreshAccessToken(ctx, appOpts, sources, state)
+	if err != nil {
+		return emptyString, err
+	}
+
+	return now.UTC().
+		Add(time.Duration(token.ExpiresIn) * time.Second).
+		Format(time.RFC3339), nil
+}
+
+// refreshDue reports whether a refresh is needed. A zero window always
+// refreshes; unknown expiry or a missing access token count as due.
+func refreshDue(state tokenState, window time.Duration, now time.Time) bool {
+	if window == 0 || state.AccessToken == emptyString {
+		return true
+	}
+
+	if state.ExpiresAt.IsZero() {
+		return true
+	}
+
+	return !now.Add(window).Before(state.ExpiresAt)
+}
+
+func writeRefreshResult(appOpts app.Options, expiresAt string) error {
+	var err error
+
+	if appOpts.JSON {
+		err = output.WriteOutput(appOpts, map[string]any{
+			"refreshed":  true,
+			"expires_at": expiresAt,
+		})
+	} else {
+		err = output.WriteOutput(
+			appOpts,
+			"Token refreshed; expires at "+expiresAt+".",
+		)
+	}
+
+	if err != nil {
+		return fmt.Errorf("write refresh output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	refreshStatusRefreshed = "refreshed"
+	refreshStatusSkipped   = "skipped"
+	refreshStatusFailed    = "failed"
+	refreshPlainHeader     = "profile\tstatus\texpires_at\terror"
+	refreshHeaderRows      = 1
+)
+
+type profileRefresh struct {
+	Profile   string `json:"profile"`
+	Status    string `json:"status"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+	code      int
+}
+
+// refreshAllProfiles refreshes every configured profile in turn. One
+// failing profile does not stop the others; the exit code combines the
+// per-profile outcomes.
+func refreshAllProfiles(
+	ctx context.Context,
+	opts RefreshOptions,
+	appOpts app.Options,
+	now time.Time,
+) error {
+	entries, err := loadProfileEntries(appOpts)
+	if err != nil {
+		return err
+	}
+
+	results := make([]profileRefresh, defaultInt, len(entries))
+
+	for _, entry := range entries {
+		profileOpts := appOpts
+		profileOpts.Profile = entry.Name
+
+		if entry.Cloud != emptyString {
+			profileOpts.Cloud = entry.Cloud
+		}
+
+		expiresAt, err := refreshProfile(ctx, opts, profileOpts, now)
+		results = append(results, newProfileRefresh(entry.Name, expiresAt, err))
+	}
+
+	err = writeProfileRefreshes(appOpts, results)
+	if err != nil {
+		return err
+	}
+
+	return combinedRefreshError(results)
+}
+
+func newProfileRefresh(
+	name string,
+	expiresAt string,
+	err error,
+) profileRefresh {
+	result := profileRefresh{
+		Profile:   name,
+		Status:    refreshStatusRefreshed,
+		ExpiresAt: expiresAt,
+		Error:     emptyString,
+		code:      app.ExitCodeSuccess,
+	}
+
+	if err == nil {
+		return result
+	}
+
+	result.code = app.ExitCodeFailure
+
+	var exitErr *app.ExitError
+	if errors.As(err, &exitErr) {
+		result.code = exitErr.Code
+	}
+
+	if result.code == app.ExitCodeUnchanged {
+		result.Status = refreshStatusSkipped
+
+		return result
+	}
+
+	result.Status = refreshStatusFailed
+	result.Error = err.Error()
+
+	return result
+}
+
+// combinedRefreshError returns nil when at least one profile was refreshed
+// and none failed. Failures exit with their shared code (or 1 when codes
+// differ); when every profile was skipped the exit code is 6.
+func combinedRefreshError(results []profileRefresh) error {
+	failed := defaultInt
+	failedCode := app.ExitCodeSuccess
+	refreshed := false
+
+	for _, result := range results {
+		switch result.Status {
+		case refreshStatusFailed:
+			failed++
+
+			if failedCode != app.ExitCodeSuccess && failedCode != result.code {
+				failedCode = app.ExitCodeFailure
+			} else {
+				failedCode = result.code
+			}
+		case refreshStatusRefreshed:
+			refreshed = true
+		}
+	}
+
+	if failed > defaultInt {
+		return app.NewExitError(failedCode, fmt.Errorf(
+			"%w for %d of %d profiles",
+			errRefreshFailed,
+			failed,
+			len(results),
+		))
+	}
+
+	if !refreshed {
+		return app.NewExitError(
+			app.ExitCodeUnchanged,
+			fmt.Errorf("%w for any profile", errRefreshNotNeeded),
+		)
+	}
+
+	return nil
+}
+
+func writeProfileRefreshes(
+	appOpts app.Options,
+	results []profileRefresh,
+) error {
+	var err error
+
+	switch {
+	case appOpts.JSON:
+		err = output.WriteOutput(appOpts, results)
+	case appOpts.Plain || appOpts.NDJSON:
+		err = output.WritePlain(appOpts, formatRefreshPlain(results))
+	default:
+		err = output.WriteOutput(appOpts, formatRefreshLines(results))
+	}
+
+	if err != nil {
+		return fmt.Errorf("write refresh output: %w", err)
+	}
+
+	return nil
+}
+
+func formatRefreshPlain(results []profileRefresh) []string {
+	lines := make([]string, defaultInt, len(results)+refreshHeaderRows)
+	lines = append(lines, refreshPlainHeader)
+
+	for _, result := range results {
+		lines = append(lines, strings.Join([]string{
+			result.Profile,
+			result.Status,
+			result.ExpiresAt,
+			result.Error,
+		}, "\t"))
+	}
+
+	return lines
+}
+
+func formatRefreshLines(results []profileRefresh) []string {
+	lines := make([]string, defaultInt, len(results))
+
+	for _, result := range results {
+		switch result.Status {
+		case refreshStatusFailed:
+			lines = append(lines, result.Profile+": failed: "+result.Error)
+		case refreshStatusSkipped:
+			lines = append(lines, result.Profile+
+				": not expiring; expires at "+result.ExpiresAt+".")
+		default:
+			lines = append(lines, result.Profile+
+				": refreshed; expires at "+result.ExpiresAt+".")
+		}
+	}
+
+	return lines
+}
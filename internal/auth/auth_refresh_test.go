@@ -0,0 +1,192 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const testRefreshWindow = time.Hour
+
+// TestRefreshDue decides based on the expiry window.
+func TestRefreshDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		state  tokenState
+		window time.Duration
+		want   bool
+	}{
+		{"no window", refreshState(now.Add(testRefreshWindow)), 0, true},
+		{"fresh", refreshState(now.Add(2 * time.Hour)), time.Hour, false},
+		{"expiring", refreshState(now.Add(time.Minute)), time.Hour, true},
+		{"unknown expiry", refreshState(time.Time{}), time.Hour, true},
+	}
+
+	for _, tc := range cases {
+		got := refreshDue(tc.state, tc.window, now)
+		if got != tc.want {
+			t.Fatalf("%s: got %t want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRefreshNotNeeded exits with ExitCodeUnchanged for fresh tokens.
+func TestRefreshNotNeeded(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+
+	err := writeConfigFile(configPath, map[string]string{
+		configKeyAccessToken:    testTokenUser,
+		configKeyRefreshToken:   testTokenUserRefresh,
+		configKeyTokenExpiresAt: now.Add(3 * time.Hour).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	err = Refresh(
+		context.Background(),
+		RefreshOptions{
+			IfExpiring: testRefreshWindow,
+			Now:        func() time.Time { return now },
+		},
+		testAppOptions(configPath),
+	)
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf(testExitErrFormat, err)
+	}
+
+	if exitErr.Code != app.ExitCodeUnchanged {
+		t.Fatalf(testExitCodeFormat, exitErr.Code, app.ExitCodeUnchanged)
+	}
+
+	if !errors.Is(err, errRefreshNotNeeded) {
+		t.Fatalf("expected errRefreshNotNeeded, got %v", err)
+	}
+}
+
+// TestRefreshAllProfiles reports each profile and combines exit codes.
+func TestRefreshAllProfiles(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	fresh := now.Add(3 * time.Hour).Format(time.RFC3339)
+
+	err := writeConfigFile(configPath, map[string]string{
+		configKeyAccessToken:    testTokenUser,
+		configKeyRefreshToken:   testTokenUserRefresh,
+		configKeyTokenExpiresAt: fresh,
+	})
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	profilePath := profileConfigPath(configPath, testProfileName)
+
+	err = os.MkdirAll(filepath.Dir(profilePath), configDirMode)
+	if err != nil {
+		t.Fatalf("create profiles dir: %v", err)
+	}
+
+	err = writeConfigFile(profilePath, map[string]string{
+		configKeyAccessToken: testTokenUser,
+	})
+	if err != nil {
+		t.Fatalf("write profile: %v", err)
+	}
+
+	appOpts := testAppOptions(configPath)
+	appOpts.Quiet = true
+
+	err = Refresh(
+		context.Background(),
+		RefreshOptions{
+			IfExpiring:  testRefreshWindow,
+			AllProfiles: true,
+			Now:         func() time.Time { return now },
+		},
+		appOpts,
+	)
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf(testExitErrFormat, err)
+	}
+
+	if exitErr.Code != app.ExitCodeAuth {
+		t.Fatalf(testExitCodeFormat, exitErr.Code, app.ExitCodeAuth)
+	}
+
+	if !errors.Is(err, errRefreshFailed) {
+		t.Fatalf("expected errRefreshFailed, got %v", err)
+	}
+}
+
+// TestCombinedRefreshError maps per-profile outcomes to one exit code.
+func TestCombinedRefreshError(t *testing.T) {
+	t.Parallel()
+
+	refreshed := refreshOutcome(refreshStatusRefreshed, app.ExitCodeSuccess)
+	skipped := refreshOutcome(refreshStatusSkipped, app.ExitCodeUnchanged)
+	authFailed := refreshOutcome(refreshStatusFailed, app.ExitCodeAuth)
+	netFailed := refreshOutcome(refreshStatusFailed, app.ExitCodeNetwork)
+
+	cases := []struct {
+		name    string
+		results []profileRefresh
+		want    int
+	}{
+		{"ok", []profileRefresh{refreshed, skipped}, app.ExitCodeSuccess},
+		{"skip", []profileRefresh{skipped, skipped}, app.ExitCodeUnchanged},
+		{"auth", []profileRefresh{refreshed, authFailed}, app.ExitCodeAuth},
+		{"mixed", []profileRefresh{authFailed, netFailed}, app.ExitCodeFailure},
+	}
+
+	for _, tc := range cases {
+		got := app.ExitCodeSuccess
+
+		var exitErr *app.ExitError
+		if errors.As(combinedRefreshError(tc.results), &exitErr) {
+			got = exitErr.Code
+		}
+
+		if got != tc.want {
+			t.Fatalf("%s: got %d want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func refreshOutcome(status string, code int) profileRefresh {
+	return profileRefresh{
+		Profile:   testProfileName,
+		Status:    status,
+		ExpiresAt: emptyString,
+		Error:     emptyString,
+		code:      code,
+	}
+}
+
+func refreshState(expiresAt time.Time) tokenState {
+	return tokenState{
+		AccessToken:   testTokenUser,
+		AccessSource:  testSourceUser,
+		RefreshToken:  testTokenUserRefresh,
+		RefreshSource: testSourceUser,
+		ExpiresAt:     expiresAt,
+	}
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	// configKeyLoginScope holds the scopes `auth login` requests. The
+	// `scope` key keeps the scopes Withings actually granted.
+	configKeyLoginScope = "login_scope"
+	scopeSeparator      = ","
+)
+
+var errInvalidScope = errors.New(
+	"invalid scope (expected a comma-separated list of " +
+		"user.info, user.metrics, user.activity, user.sleepevents)",
+)
+
+// knownScopes are the OAuth scopes Withings grants to API apps.
+//
+//nolint:gochecknoglobals // Static list of OAuth scopes.
+var knownScopes = []string{
+	"user.info",
+	"user.metrics",
+	"user.activity",
+	"user.sleepevents",
+}
+
+// resolveLoginScope picks the requested scopes from the flag, then the
+// config, then the default, and normalizes them to a deduplicated
+// comma-separated list.
+func resolveLoginScope(flagValue string, userConfig *configFile) (
+	string,
+	error,
+) {
+	raw := resolveValue(
+		flagValue,
+		userConfig.Value(configKeyLoginScope),
+		defaultAuthScope,
+	)
+
+	scopes := splitScopes(raw)
+	if len(scopes) == defaultInt {
+		return emptyString, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidScope, raw),
+		)
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(knownScopes, scope) {
+			return emptyString, app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidScope, scope),
+			)
+		}
+	}
+
+	return strings.Join(scopes, scopeSeparator), nil
+}
+
+// splitScopes splits a scope list on commas (Withings returns granted
+// scopes that way too), dropping blanks and duplicates.
+func splitScopes(raw string) []string {
+	var scopes []string
+
+	for scope := range strings.SplitSeq(raw, scopeSeparator) {
+		trimmed := strings.TrimSpace(scope)
+		if trimmed != emptyString && !slices.Contains(scopes, trimmed) {
+			scopes = append(scopes, trimmed)
+		}
+	}
+
+	return scopes
+}
+
+// missingScopes lists the requested scopes the granted list lacks. An
+// unknown grant (no token yet) reports nothing.
+func missingScopes(requested, granted string) []string {
+	grantedScopes := splitScopes(granted)
+	if len(grantedScopes) == defaultInt {
+		return nil
+	}
+
+	var missing []string
+
+	for _, scope := range splitScopes(requested) {
+		if !slices.Contains(grantedScopes, scope) {
+			missing = append(missing, scope)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,80 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestResolveLoginScope prefers the flag, then config, then the default.
+func TestResolveLoginScope(t *testing.T) {
+	t.Parallel()
+
+	config, err := loadConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+
+	scope, err := resolveLoginScope(emptyString, config)
+	if err != nil || scope != defaultAuthScope {
+		t.Fatalf("default got %q, %v", scope, err)
+	}
+
+	config.Set(configKeyLoginScope, "user.info")
+
+	scope, err = resolveLoginScope(emptyString, config)
+	if err != nil || scope != "user.info" {
+		t.Fatalf("config got %q, %v", scope, err)
+	}
+
+	scope, err = resolveLoginScope(
+		" user.sleepevents, user.metrics,,user.metrics",
+		config,
+	)
+	if err != nil || scope != "user.sleepevents,user.metrics" {
+		t.Fatalf("flag got %q, %v", scope, err)
+	}
+}
+
+// TestResolveLoginScopeInvalid rejects unknown and empty scope lists.
+func TestResolveLoginScopeInvalid(t *testing.T) {
+	t.Parallel()
+
+	config, err := loadConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+
+	for _, raw := range []string{"user.metrics,user.heart", " , "} {
+		_, err = resolveLoginScope(raw, config)
+		if !errors.Is(err, errInvalidScope) {
+			t.Fatalf("%q: expected invalid scope, got %v", raw, err)
+		}
+
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+			t.Fatalf("%q: expected usage exit, got %v", raw, err)
+		}
+	}
+}
+
+// TestMissingScopes reports requested scopes absent from the grant.
+func TestMissingScopes(t *testing.T) {
+	t.Parallel()
+
+	missing := missingScopes(
+		"user.metrics,user.activity,user.sleepevents",
+		"user.activity,user.metrics",
+	)
+	if !slices.Equal(missing, []string{"user.sleepevents"}) {
+		t.Fatalf("missing got %v", missing)
+	}
+
+	if missing = missingScopes(defaultAuthScope, emptyString); missing != nil {
+		t.Fatalf("unknown grant got %v", missing)
+	}
+}
@@ -0,0 +1,374 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	configKeyRedirectURI = "redirect_uri"
+	redirectSchemeHTTP   = "http"
+	redirectSchemeHTTPS  = "https"
+	maskedPromptValue    = "****"
+	developerDashboard   = "https://developer.withings.com/dashboard/"
+)
+
+var (
+	errInvalidClientID     = errors.New("invalid client ID")
+	errInvalidClientSecret = errors.New("invalid client secret")
+	errInvalidRedirectURI  = errors.New(
+		"invalid redirect URI (expected absolute http or https URL)",
+	)
+)
+
+// SetClientOptions defines the app registration to store. Empty values
+// are prompted for, offering the current ones as defaults.
+type SetClientOptions struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	// Login runs `auth login` after saving; PromptLogin asks instead when
+	// the terminal allows it.
+	Login       bool
+	PromptLogin bool
+	Listen      string
+	// Scope selects the config that receives the client ID and redirect
+	// URI: ConfigScopeUser (default) or ConfigScopeProject. The secret
+	// always goes to the user config.
+	Scope string
+}
+
+//nolint:tagliatelle // Keep snake_case like the config keys.
+type setClientResult struct {
+	ClientID     string `json:"client_id"`
+	RedirectURI  string `json:"redirect_uri"`
+	AuthorizeURL string `json:"authorize_url"`
+	Config       string `json:"config"`
+	SecretConfig string `json:"secret_config,omitempty"`
+}
+
+type clientPrompt struct {
+	Label   string
+	Flag    string
+	Value   string
+	Current string
+	Secret  bool
+}
+
+// SetClient stores the client ID, secret, and redirect URI in the active
+// profile, or the ID and redirect URI in the project config, after
+// checking that they form a valid authorize URL.
+func SetClient(
+	ctx context.Context,
+	opts SetClientOptions,
+	appOpts app.Options,
+) error {
+	sources, err := loadConfigSources(appOpts)
+	if err != nil {
+		return err
+	}
+
+	config, err := setClientTarget(sources, opts.Scope)
+	if err != nil {
+		return err
+	}
+
+	current := resolveAuthConfig(emptyString, sources)
+	if current.RedirectURI == emptyString {
+		current.RedirectURI = buildLocalRedirectURI(opts.Listen)
+	}
+
+	client, err := promptClient(opts, current, appOpts)
+	if err != nil {
+		return err
+	}
+
+	authorizeURL, err := validateClient(client, appOpts.Cloud)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	result := setClientResult{
+		ClientID:     client.ClientID,
+		RedirectURI:  client.RedirectURI,
+		AuthorizeURL: authorizeURL,
+		Config:       config.Path,
+		SecretConfig: emptyString,
+	}
+
+	if storeClient(config, sources.User, client) && config != sources.User {
+		err = sources.User.Save()
+		if err != nil {
+			return err
+		}
+
+		result.SecretConfig = sources.User.Path
+	}
+
+	err = config.Save()
+	if err != nil {
+		return err
+	}
+
+	err = warnProjectOverride(appOpts, opts.Scope, configKeyClientID)
+	if err != nil {
+		return err
+	}
+
+	err = writeSetClientResult(appOpts, result)
+	if err != nil {
+		return err
+	}
+
+	login, err := shouldLogin(opts, appOpts)
+	if err != nil || !login {
+		return err
+	}
+
+	return Login(ctx, LoginOptions{
+		RedirectURI: client.RedirectURI,
+		NoOpen:      false,
+		Listen:      loginListenAddr(client.RedirectURI, opts.Listen),
+		PKCE:        false,
+		Manual:      false,
+		Scope:       emptyString,
+	}, appOpts)
+}
+
+// setClientTarget picks the config that receives the client ID and
+// redirect URI.
+func setClientTarget(sources configSources, scope string) (
+	*configFile,
+	error,
+) {
+	switch scope {
+	case emptyString, ConfigScopeUser:
+		return sources.User, nil
+	case ConfigScopeProject:
+		return sources.Project, nil
+	default:
+		return nil, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidConfigScope, scope),
+		)
+	}
+}
+
+func promptClient(
+	opts SetClientOptions,
+	current authClientConfig,
+	appOpts app.Options,
+) (authClientConfig, error) {
+	prompts := []clientPrompt{
+		{"Client ID", "--client-id", opts.ClientID, current.ClientID, false},
+		{
+			"Client secret",
+			"--client-secret",
+			opts.ClientSecret,
+			current.ClientSecret,
+			true,
+		},
+		{
+			"Redirect URI",
+			"--redirect-uri",
+			opts.RedirectURI,
+			current.RedirectURI,
+			false,
+		},
+	}
+
+	values := make([]string, defaultInt, len(prompts))
+
+	for _, prompt := range prompts {
+		value, err := promptClientValue(prompt, appOpts)
+		if err != nil {
+			return authClientConfig{}, err
+		}
+
+		values = append(values, value)
+	}
+
+	return authClientConfig{
+		ClientID:     values[0],
+		ClientSecret: values[1],
+		RedirectURI:  values[2],
+	}, nil
+}
+
+// promptClientValue returns the flag value, else the answer to a prompt
+// that keeps the current value on an empty line. Without a terminal the
+// current value is used when there is one.
+func promptClientValue(
+	prompt clientPrompt,
+	appOpts app.Options,
+) (string, error) {
+	if prompt.Value != emptyString {
+		return strings.TrimSpace(prompt.Value), nil
+	}
+
+	shown := prompt.Current
+	if prompt.Secret && shown != emptyString {
+		shown = maskedPromptValue
+	}
+
+	label := prompt.Label + ": "
+	if shown != emptyString {
+		label = fmt.Sprintf("%s [%s]: ", prompt.Label, shown)
+	}
+
+	answer, err := readLine(label, appOpts)
+	if errors.Is(err, errInputRequired) {
+		if prompt.Current != emptyString {
+			return prompt.Current, nil
+		}
+
+		return emptyString, app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: pass %s", errInputRequired, prompt.Flag),
+		)
+	}
+
+	if err != nil {
+		return emptyString, err
+	}
+
+	if answer == emptyString {
+		return prompt.Current, nil
+	}
+
+	return answer, nil
+}
+
+// validateClient checks the values and returns the authorize URL they
+// produce, so typos surface before the browser round trip.
+func validateClient(client authClientConfig, cloud string) (string, error) {
+	if client.ClientID == emptyString {
+		return emptyString, errClientIDMissing
+	}
+
+	if strings.ContainsFunc(client.ClientID, isSpace) {
+		return emptyString, fmt.Errorf(
+			"%w: %q",
+			errInvalidClientID,
+			client.ClientID,
+		)
+	}
+
+	if client.ClientSecret == emptyString ||
+		strings.ContainsFunc(client.ClientSecret, isSpace) {
+		return emptyString, errInvalidClientSecret
+	}
+
+	parsed, err := url.Parse(client.RedirectURI)
+	if err != nil || parsed.Host == emptyString ||
+		(parsed.Scheme != redirectSchemeHTTP &&
+			parsed.Scheme != redirectSchemeHTTPS) {
+		return emptyString, fmt.Errorf(
+			"%w: %q",
+			errInvalidRedirectURI,
+			client.RedirectURI,
+		)
+	}
+
+	return buildAuthorizeURL(
+		accountBaseURL(cloud),
+		client.ClientID,
+		client.RedirectURI,
+		emptyString,
+		randomState(),
+		pkceNoChallenge,
+	)
+}
+
+func isSpace(char rune) bool {
+	return strings.ContainsRune(" \t\r\n", char)
+}
+
+// storeClient writes the client ID and redirect URI to config and the
+// secret to secrets, reporting whether the secret was written. A secret
+// that only comes from WITHINGS_CLIENT_SECRET is not copied.
+func storeClient(
+	config, secrets *configFile,
+	client authClientConfig,
+) bool {
+	config.Set(configKeyClientID, client.ClientID)
+	config.Set(configKeyRedirectURI, client.RedirectURI)
+
+	if secrets.Value(configKeyClientSecret) == emptyString &&
+		client.ClientSecret == os.Getenv(envClientSecret) {
+		return false
+	}
+
+	secrets.Set(configKeyClientSecret, client.ClientSecret)
+
+	return true
+}
+
+func shouldLogin(opts SetClientOptions, appOpts app.Options) (bool, error) {
+	// Prompting after JSON output would block scripts.
+	if opts.Login || !opts.PromptLogin || appOpts.JSON {
+		return opts.Login, nil
+	}
+
+	login, err := confirm("Log in now? [y/N]: ", appOpts)
+	if errors.Is(err, errInputRequired) {
+		return false, nil
+	}
+
+	return login, err
+}
+
+// loginListenAddr listens on the redirect URI's own address when it
+// points at this machine, so a custom port needs no --listen.
+func loginListenAddr(redirectURI, fallback string) string {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || parsed.Port() == emptyString {
+		return fallback
+	}
+
+	host := parsed.Hostname()
+	if host == "localhost" {
+		return parsed.Host
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return fallback
+	}
+
+	return parsed.Host
+}
+
+func writeSetClientResult(appOpts app.Options, result setClientResult) error {
+	if appOpts.JSON {
+		return writeConfigOutput(appOpts, result)
+	}
+
+	lines := []string{
+		fmt.Sprintf("Saved client %s to %s.", result.ClientID, result.Config),
+	}
+
+	if result.SecretConfig != emptyString {
+		lines = append(lines, fmt.Sprintf(
+			"Saved the client secret to %s (never the project config).",
+			result.SecretConfig,
+		))
+	}
+
+	lines = append(lines,
+		"Authorize URL: "+result.AuthorizeURL,
+		fmt.Sprintf(
+			"The redirect URI must match the callback registered at %s.",
+			developerDashboard,
+		),
+	)
+
+	return writeConfigOutput(appOpts, lines)
+}
@@ -0,0 +1,183 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testSetClientID     = "client-123"
+	testSetClientSecret = "secret-456"
+	testSetRedirectURI  = "http://127.0.0.1:9999/cb"
+	testSetListenAddr   = "127.0.0.1:9876"
+)
+
+// TestValidateClientBuildsAuthorizeURL returns the URL login would open.
+func TestValidateClientBuildsAuthorizeURL(t *testing.T) {
+	t.Parallel()
+
+	authorizeURL, err := validateClient(authClientConfig{
+		ClientID:     testSetClientID,
+		ClientSecret: testSetClientSecret,
+		RedirectURI:  testSetRedirectURI,
+	}, "eu")
+	if err != nil {
+		t.Fatalf("validateClient: %v", err)
+	}
+
+	parsed, err := url.Parse(authorizeURL)
+	if err != nil {
+		t.Fatalf("parse authorize URL: %v", err)
+	}
+
+	query := parsed.Query()
+	if query.Get(oauthClientIDKey) != testSetClientID ||
+		query.Get(oauthRedirectURIKey) != testSetRedirectURI {
+		t.Fatalf("unexpected authorize URL %q", authorizeURL)
+	}
+}
+
+// TestValidateClientRejectsInvalidValues names the bad value.
+func TestValidateClientRejectsInvalidValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		client authClientConfig
+		want   error
+	}{
+		{
+			name: "missing-id",
+			client: authClientConfig{
+				ClientID:     emptyString,
+				ClientSecret: testSetClientSecret,
+				RedirectURI:  testSetRedirectURI,
+			},
+			want: errClientIDMissing,
+		},
+		{
+			name: "id-with-space",
+			client: authClientConfig{
+				ClientID:     "client 123",
+				ClientSecret: testSetClientSecret,
+				RedirectURI:  testSetRedirectURI,
+			},
+			want: errInvalidClientID,
+		},
+		{
+			name: "missing-secret",
+			client: authClientConfig{
+				ClientID:     testSetClientID,
+				ClientSecret: emptyString,
+				RedirectURI:  testSetRedirectURI,
+			},
+			want: errInvalidClientSecret,
+		},
+		{
+			name: "relative-redirect",
+			client: authClientConfig{
+				ClientID:     testSetClientID,
+				ClientSecret: testSetClientSecret,
+				RedirectURI:  "/callback",
+			},
+			want: errInvalidRedirectURI,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := validateClient(test.client, "eu")
+		if !errors.Is(err, test.want) {
+			t.Fatalf("%s: got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+// TestLoginListenAddr follows loopback redirect URIs only.
+func TestLoginListenAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		testSetRedirectURI:                 "127.0.0.1:9999",
+		"http://localhost:8000/callback":   "localhost:8000",
+		"https://example.com:8443/cb":      testSetListenAddr,
+		"http://127.0.0.1/callback":        testSetListenAddr,
+		"https://example.com/withings/cb/": testSetListenAddr,
+	}
+
+	for redirectURI, want := range tests {
+		got := loginListenAddr(redirectURI, testSetListenAddr)
+		if got != want {
+			t.Fatalf("%s: got %q, want %q", redirectURI, got, want)
+		}
+	}
+}
+
+// TestStoreClientSkipsEnvSecret keeps env-only secrets out of the file.
+func TestStoreClientSkipsEnvSecret(t *testing.T) {
+	t.Setenv(envClientSecret, testSetClientSecret)
+
+	config, err := loadConfigFile(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatalf("loadConfigFile: %v", err)
+	}
+
+	client := authClientConfig{
+		ClientID:     testSetClientID,
+		ClientSecret: testSetClientSecret,
+		RedirectURI:  testSetRedirectURI,
+	}
+
+	if storeClient(config, config, client) ||
+		config.Value(configKeyClientSecret) != emptyString {
+		t.Fatal("stored the secret from the environment")
+	}
+
+	client.ClientSecret = "typed-secret"
+	if !storeClient(config, config, client) {
+		t.Fatal("typed secret not reported as stored")
+	}
+
+	if got := config.Value(configKeyClientSecret); got != client.ClientSecret {
+		t.Fatalf(testGotWantFormat, got, client.ClientSecret)
+	}
+}
+
+// TestStoreClientProjectKeepsSecret writes the secret to the user config
+// only.
+func TestStoreClientProjectKeepsSecret(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	project, err := loadConfigFile(filepath.Join(dir, "withings-cli.toml"))
+	if err != nil {
+		t.Fatalf("loadConfigFile project: %v", err)
+	}
+
+	user, err := loadConfigFile(filepath.Join(dir, "config.toml"))
+	if err != nil {
+		t.Fatalf("loadConfigFile user: %v", err)
+	}
+
+	storeClient(project, user, authClientConfig{
+		ClientID:     testSetClientID,
+		ClientSecret: "typed-secret",
+		RedirectURI:  testSetRedirectURI,
+	})
+
+	if project.Value(configKeyClientSecret) != emptyString {
+		t.Fatal("stored the secret in the project config")
+	}
+
+	if got := project.Value(configKeyClientID); got != testSetClientID {
+		t.Fatalf(testGotWantFormat, got, testSetClientID)
+	}
+
+	if user.Value(configKeyClientSecret) != "typed-secret" ||
+		user.Value(configKeyClientID) != emptyString {
+		t.Fatalf("user config got %v", user.Values)
+	}
+}
@@ -0,0 +1,69 @@
+//nolint:testpackage // test unexported helpers.
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestFormatExpiresIn humanizes the time left on a token.
+func TestFormatExpiresIn(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expiresAt time.Time
+		want      string
+	}{
+		{expiresAt: time.Time{}, want: ""},
+		{
+			expiresAt: now.Add(42*time.Minute + 30*time.Second),
+			want:      "expires in 42m",
+		},
+		{
+			expiresAt: now.Add(2*time.Hour + 5*time.Minute),
+			want:      "expires in 2h5m",
+		},
+		{expiresAt: now.Add(3 * time.Hour), want: "expires in 3h"},
+		{expiresAt: now.Add(50 * time.Hour), want: "expires in 2d2h"},
+		{expiresAt: now.Add(20 * time.Second), want: "expires in <1m"},
+		{expiresAt: now.Add(-3 * time.Hour), want: "expired 3h ago"},
+		{expiresAt: now, want: "expired <1m ago"},
+	}
+
+	for _, test := range tests {
+		got := formatExpiresIn(test.expiresAt, now)
+		if got != test.want {
+			t.Fatalf("%v: got %q want %q", test.expiresAt, got, test.want)
+		}
+	}
+}
+
+// TestCheckAuthStatus exits 3 without printing for unusable tokens.
+func TestCheckAuthStatus(t *testing.T) {
+	t.Parallel()
+
+	valid := authStatus{AccessToken: "token", Expired: false}
+	if err := checkAuthStatus(valid); err != nil {
+		t.Fatalf("valid token: %v", err)
+	}
+
+	for _, status := range []authStatus{
+		{AccessToken: emptyString, Expired: false},
+		{AccessToken: "token", Expired: true},
+	} {
+		err := checkAuthStatus(status)
+
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeAuth {
+			t.Fatalf("%+v: got %v, want auth exit", status, err)
+		}
+
+		if !errors.Is(err, app.ErrSilent) {
+			t.Fatalf("%+v: expected silent exit, got %v", status, err)
+		}
+	}
+}
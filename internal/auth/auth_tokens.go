@@ -9,7 +9,12 @@ import (
 	"github.com/mreimbold/withings-cli/internal/withings"
 )
 
-const tokenRefreshSkew = 30 * time.Second
+const (
+	tokenRefreshSkew = 30 * time.Second
+	// fixtureToken stands in for a login when --fixtures or --replay
+	// answers every request locally.
+	fixtureToken = "fixtures"
+)
 
 type tokenState struct {
 	AccessToken   string
@@ -20,87 +25,131 @@ type tokenState struct {
 }
 
 // EnsureAccessToken resolves a usable access token, refreshing if needed.
+// With --fixtures or --replay no login is needed and a placeholder is
+// returned.
 func EnsureAccessToken(
 	ctx context.Context,
 	opts app.Options,
 ) (string, error) {
-	state, userConfig, err := loadTokenState(opts)
+	if opts.Fixtures != emptyString || opts.Replay != emptyString {
+		return fixtureToken, nil
+	}
+
+	state, sources, err := loadTokenState(opts)
+	if err != nil {
+		return emptyString, err
+	}
+
+	token, err := usableAccessToken(state)
+	if err != nil || token != emptyString {
+		return token, err
+	}
+
+	refreshed, err := refreshAccessToken(ctx, opts, sources, state)
+	if err != nil {
+		return emptyString, err
+	}
+
+	return refreshed.AccessToken, nil
+}
+
+// TokenSource refreshes the stored tokens of the profile selected in
+// Options; data clients call it when the API rejects the access token.
+type TokenSource struct {
+	Options app.Options
+}
+
+// Refresh exchanges the stored refresh token for a new access token and
+// stores both, regardless of the recorded expiry.
+func (s TokenSource) Refresh(ctx context.Context) (string, error) {
+	state, sources, err := loadTokenState(s.Options)
 	if err != nil {
 		return emptyString, err
 	}
 
-	if token := usableAccessToken(state); token != emptyString {
-		return token, nil
+	token, err := refreshAccessToken(ctx, s.Options, sources, state)
+	if err != nil {
+		return emptyString, err
 	}
 
-	return refreshAccessToken(ctx, opts, userConfig, state)
+	return token.AccessToken, nil
 }
 
 func loadTokenState(
 	opts app.Options,
-) (tokenState, *configFile, error) {
-	sources, err := loadConfigSources(opts.Config)
+) (tokenState, configSources, error) {
+	sources, err := loadConfigSources(opts)
 	if err != nil {
-		return tokenState{}, nil, err
+		return tokenState{}, configSources{}, err
 	}
 
 	state := buildTokenState(sources.Project, sources.User)
 
-	return state, sources.User, nil
+	return state, sources, nil
 }
 
-func usableAccessToken(state tokenState) string {
+// usableAccessToken returns the decrypted access token, or "" when there
+// is none or it is due for a refresh.
+func usableAccessToken(state tokenState) (string, error) {
 	if state.AccessToken == emptyString {
-		return emptyString
+		return emptyString, nil
 	}
 
 	if shouldRefresh(state.ExpiresAt) {
-		return emptyString
+		return emptyString, nil
 	}
 
-	return state.AccessToken
+	return decryptToken(state.AccessToken)
 }
 
 func refreshAccessToken(
 	ctx context.Context,
 	opts app.Options,
-	userConfig *configFile,
+	sources configSources,
 	state tokenState,
-) (string, error) {
+) (tokenBody, error) {
 	if state.RefreshToken == emptyString {
-		return emptyString, app.NewExitError(app.ExitCodeAuth, errAuthRequired)
+		return tokenBody{}, app.NewExitError(app.ExitCodeAuth, errAuthRequired)
 	}
 
-	authConfig := resolveAuthConfig(emptyString)
+	authConfig := resolveAuthConfig(emptyString, sources)
+	publicClient := sources.User.Value(configKeyPKCE) == pkceEnabledValue
+
 	if authConfig.ClientID == emptyString ||
-		authConfig.ClientSecret == emptyString {
-		return emptyString, app.NewExitError(
+		(authConfig.ClientSecret == emptyString && !publicClient) {
+		return tokenBody{}, app.NewExitError(
 			app.ExitCodeAuth,
 			errClientCredentialsMissing,
 		)
 	}
 
+	storedRefresh, err := decryptToken(state.RefreshToken)
+	if err != nil {
+		return tokenBody{}, err
+	}
+
 	tokenURL := tokenEndpoint(withings.APIBaseURL(opts.BaseURL, opts.Cloud))
 
 	token, err := refreshToken(
 		ctx,
+		opts,
 		tokenURL,
 		authConfig.ClientID,
 		authConfig.ClientSecret,
-		state.RefreshToken,
+		storedRefresh,
 	)
 	if err != nil {
-		return emptyString, classifyRefreshError(err)
+		return tokenBody{}, classifyRefreshError(err)
 	}
 
 	if shouldPersistRefreshedTokens(state.RefreshSource) {
-		err = persistTokens(userConfig, token)
+		err = persistTokens(sources.User, token)
 		if err != nil {
-			return emptyString, err
+			return tokenBody{}, err
 		}
 	}
 
-	return token.AccessToken, nil
+	return token, nil
 }
 
 func buildTokenState(projectConfig, userConfig *configFile) tokenState {
@@ -59,7 +59,7 @@ func TestUsableAccessTokenEmpty(t *testing.T) {
 		ExpiresAt:     future,
 	}
 
-	got := usableAccessToken(state)
+	got, _ := usableAccessToken(state)
 	if got != emptyString {
 		t.Fatalf(testGotWantFormat, got, emptyString)
 	}
@@ -86,11 +86,11 @@ func TestUsableAccessTokenProject(t *testing.T) {
 		ExpiresAt:     past,
 	}
 
-	if got := usableAccessToken(valid); got != testTokenProject {
+	if got, _ := usableAccessToken(valid); got != testTokenProject {
 		t.Fatalf(testGotWantFormat, got, testTokenProject)
 	}
 
-	if got := usableAccessToken(expired); got != emptyString {
+	if got, _ := usableAccessToken(expired); got != emptyString {
 		t.Fatalf(testGotWantFormat, got, emptyString)
 	}
 }
@@ -116,11 +116,11 @@ func TestUsableAccessTokenUser(t *testing.T) {
 		ExpiresAt:     past,
 	}
 
-	if got := usableAccessToken(valid); got != testTokenUser {
+	if got, _ := usableAccessToken(valid); got != testTokenUser {
 		t.Fatalf(testGotWantFormat, got, testTokenUser)
 	}
 
-	if got := usableAccessToken(expired); got != emptyString {
+	if got, _ := usableAccessToken(expired); got != emptyString {
 		t.Fatalf(testGotWantFormat, got, emptyString)
 	}
 }
@@ -246,15 +246,36 @@ func TestClassifyRefreshError(t *testing.T) {
 
 func testAppOptions(configPath string) app.Options {
 	return app.Options{
-		Verbose: defaultInt,
-		Quiet:   false,
-		JSON:    false,
-		Plain:   false,
-		NoColor: false,
-		NoInput: false,
-		Config:  configPath,
-		Cloud:   emptyString,
-		BaseURL: emptyString,
+		Verbose:       defaultInt,
+		Quiet:         false,
+		JSON:          false,
+		Plain:         false,
+		NDJSON:        false,
+		Output:        emptyString,
+		NoColor:       false,
+		NoInput:       false,
+		Config:        configPath,
+		Cloud:         emptyString,
+		BaseURL:       emptyString,
+		Profile:       emptyString,
+		OutputVersion: defaultInt,
+		Retries:       defaultInt,
+		RetryBackoff:  defaultInt,
+		Timeout:       defaultInt,
+		CacheTTL:      defaultInt,
+		NoCache:       false,
+		RateLimit:     defaultInt,
+		PageSize:      defaultInt,
+		Units:         emptyString,
+		DryRun:        false,
+		ReadOnly:      false,
+		Timezone:      emptyString,
+		Fields:        emptyString,
+		Columns:       emptyString,
+		Template:      emptyString,
+		Thresholds:    nil,
+		Stdout:        nil,
+		Stderr:        nil,
 	}
 }
 
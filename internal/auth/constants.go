@@ -7,6 +7,9 @@ const (
 
 	envClientID     = "WITHINGS_CLIENT_ID"
 	envClientSecret = "WITHINGS_CLIENT_SECRET"
+	// envConfigPassphrase and envConfigKeyFile enable token encryption.
+	envConfigPassphrase = "WITHINGS_CONFIG_PASSPHRASE"
+	envConfigKeyFile    = "WITHINGS_CONFIG_KEY_FILE"
 
 	statusUnknownText = "unknown"
 )
@@ -1,13 +1,26 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/params"
 	"github.com/mreimbold/withings-cli/internal/services/activity"
+	"github.com/mreimbold/withings-cli/internal/services/goals"
 	"github.com/spf13/cobra"
 )
 
+const (
+	flagMetric          = "metric"
+	flagGoal            = "goal"
+	flagGoalBar         = "bar"
+	configKeyGoalPrefix = "goal_"
+	goalFloatBits       = 64
+)
+
 func newActivityCommand() *cobra.Command {
 	var opts activity.Options
 
@@ -21,27 +34,273 @@ func newActivityCommand() *cobra.Command {
 		Use:   "get",
 		Short: "Fetch activity summaries",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyComputedColumns(cmd, &appOpts)
+			if err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceMeasureV2,
+				actionGetActivity,
+			)
 			if err != nil {
 				return err
 			}
 
-			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			err = applyActivityGoal(cmd, appOpts, &opts.Goal)
 			if err != nil {
-				return fmt.Errorf("ensure access token: %w", err)
+				return err
 			}
 
-			return activity.Run(cmd.Context(), opts, appOpts, accessToken)
+			return runWatched(
+				cmd,
+				appOpts,
+				watchTarget{
+					LastUpdate: &opts.LastUpdate,
+					TimeRange:  &opts.TimeRange,
+					Date:       &opts.Date,
+					UpdateTime: nil,
+				},
+				func(
+					ctx context.Context,
+					appOpts app.Options,
+					accessToken string,
+				) error {
+					err := fetchStepsGoal(
+						ctx,
+						appOpts,
+						accessToken,
+						opts.User,
+						&opts.Goal,
+					)
+					if err != nil {
+						return err
+					}
+
+					return activity.Run(ctx, opts, appOpts, accessToken)
+				},
+			)
 		},
 	}
 
 	activityCmd.AddCommand(activityGetCmd)
+	activityCmd.AddCommand(newActivityIntradayCommand())
 
 	addTimeRangeFlags(activityGetCmd, &opts.TimeRange)
 	addDateFlag(activityGetCmd, &opts.Date)
-	addPaginationFlags(activityGetCmd, &opts.Pagination)
+	addPaginationFlags(
+		activityGetCmd,
+		&opts.Pagination,
+		serviceMeasureV2,
+		actionGetActivity,
+	)
 	addUserIDFlag(activityGetCmd, &opts.User)
+	addDryRunFlag(activityGetCmd)
 	addLastUpdateFlag(activityGetCmd, &opts.LastUpdate)
+	addWatchFlag(activityGetCmd)
+	addProfileFlags(activityGetCmd, &opts.Profile)
+	addGoalFlags(activityGetCmd, &opts.Goal)
 
 	return activityCmd
 }
+
+func addGoalFlags(cmd *cobra.Command, goal *activity.Goal) {
+	cmd.Flags().StringVar(
+		&goal.Metric,
+		flagMetric,
+		emptyString,
+		"metric for goal progress: steps or calories (default steps)",
+	)
+	cmd.Flags().Float64Var(
+		&goal.Target,
+		flagGoal,
+		defaultFloat,
+		"daily goal to show progress against "+
+			"(default goal_<metric> in config, or the steps goal)",
+	)
+	cmd.Flags().BoolVar(
+		&goal.Bar,
+		flagGoalBar,
+		false,
+		"draw a goal progress bar in tables",
+	)
+	_ = cmd.RegisterFlagCompletionFunc(
+		flagMetric,
+		completeValues(activity.MetricChoices...),
+	)
+}
+
+// applyActivityGoal validates the goal flags and, when progress was asked
+// for without --goal, reads goal_<metric> from config. A steps goal that
+// is still missing is fetched with the data (fetchStepsGoal).
+func applyActivityGoal(
+	cmd *cobra.Command,
+	appOpts app.Options,
+	goal *activity.Goal,
+) error {
+	flags := cmd.Flags()
+	if !flags.Changed(flagMetric) && !flags.Changed(flagGoal) && !goal.Bar {
+		return nil
+	}
+
+	metric, err := activity.ParseMetric(goal.Metric)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	goal.Metric = metric
+
+	if flags.Changed(flagGoal) {
+		if goal.Target <= defaultFloat {
+			return app.NewExitError(app.ExitCodeUsage, errInvalidGoal)
+		}
+
+		return nil
+	}
+
+	key := configKeyGoalPrefix + metric
+
+	settings, err := auth.ConfigValues(appOpts, key)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	raw, ok := settings[key]
+	if !ok {
+		if metric != activity.MetricSteps {
+			return app.NewExitError(app.ExitCodeUsage, errNoGoal)
+		}
+
+		return nil
+	}
+
+	target, err := strconv.ParseFloat(raw, goalFloatBits)
+	if err != nil || target <= defaultFloat {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w %s: %q", errInvalidGoalConfig, key, raw),
+		)
+	}
+
+	goal.Target = target
+
+	return nil
+}
+
+// fetchStepsGoal fills a missing steps goal from user goals once.
+// Dry runs skip it so the activity request is the one printed.
+func fetchStepsGoal(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	user params.User,
+	goal *activity.Goal,
+) error {
+	if goal.Metric == emptyString || goal.Target > defaultFloat ||
+		appOpts.DryRun {
+		return nil
+	}
+
+	steps, ok, err := goals.StepsGoal(
+		ctx,
+		goals.Options{User: user},
+		appOpts,
+		accessToken,
+	)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return app.NewExitError(app.ExitCodeUsage, errNoGoal)
+	}
+
+	goal.Target = float64(steps)
+
+	return nil
+}
+
+func newActivityIntradayCommand() *cobra.Command {
+	var opts activity.IntradayOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	activityIntradayCmd := &cobra.Command{
+		Use:   "intraday",
+		Short: "Fetch high-frequency activity samples",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return activity.Intraday(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(activityIntradayCmd, &opts.TimeRange)
+	addUserIDFlag(activityIntradayCmd, &opts.User)
+	addDryRunFlag(activityIntradayCmd)
+
+	activityIntradayCmd.Flags().StringVar(
+		&opts.Fields,
+		"data-fields",
+		emptyString,
+		"data fields (comma-separated, default "+
+			"steps,calories,heart_rate,duration)",
+	)
+	activityIntradayCmd.Flags().StringVar(
+		&opts.Output,
+		"dest",
+		emptyString,
+		"export samples to a CSV file (fetched in 24h windows)",
+	)
+	activityIntradayCmd.Flags().StringVar(
+		&opts.Rotate,
+		"rotate",
+		emptyString,
+		"rotate --dest files at a size or row count "+
+			"(e.g., 100MB, 1000000rows)",
+	)
+
+	return activityIntradayCmd
+}
+
+func addProfileFlags(cmd *cobra.Command, opts *activity.Profile) {
+	cmd.Flags().IntVar(
+		&opts.Age,
+		"age",
+		defaultInt,
+		"age in years (for BMR estimate)",
+	)
+	cmd.Flags().Float64Var(
+		&opts.HeightCm,
+		"height",
+		defaultFloat,
+		"height in cm (for BMR estimate)",
+	)
+	cmd.Flags().Float64Var(
+		&opts.WeightKg,
+		"weight",
+		defaultFloat,
+		"weight in kg (for BMR estimate)",
+	)
+	cmd.Flags().StringVar(
+		&opts.Sex,
+		"sex",
+		emptyString,
+		"sex: male or female (for BMR estimate)",
+	)
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/mreimbold/withings-cli/internal/auth"
 	"github.com/mreimbold/withings-cli/internal/services/api"
@@ -21,11 +22,20 @@ func newAPICommand() *cobra.Command {
 		Use:   "call",
 		Short: "Call a Withings API service/action",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readCommandOptions(cmd)
 			if err != nil {
 				return err
 			}
 
+			if opts.Sign {
+				opts.Credentials, err = auth.ClientCredentials(appOpts)
+				if err != nil {
+					return fmt.Errorf("resolve client credentials: %w", err)
+				}
+
+				return api.Run(cmd.Context(), opts, appOpts, emptyString)
+			}
+
 			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
 			if err != nil {
 				return fmt.Errorf("ensure access token: %w", err)
@@ -55,6 +65,20 @@ func newAPICommand() *cobra.Command {
 		emptyString,
 		"JSON params, @file.json, or - for stdin",
 	)
+	apiCallCmd.Flags().StringVarP(
+		&opts.Method,
+		"method",
+		"X",
+		http.MethodPost,
+		"HTTP method; GET sends params in the query string",
+	)
+	apiCallCmd.Flags().StringArrayVarP(
+		&opts.Headers,
+		"header",
+		"H",
+		nil,
+		`extra request header "Name: value" (repeatable)`,
+	)
 	apiCallCmd.Flags().BoolVar(
 		&opts.DryRun,
 		"dry-run",
@@ -62,6 +86,26 @@ func newAPICommand() *cobra.Command {
 		"print request without executing",
 	)
 
+	apiCallCmd.Flags().BoolVar(
+		&opts.Sign,
+		"sign",
+		false,
+		"sign with client_id, a fresh nonce, and signature instead of "+
+			"sending the access token",
+	)
+	apiCallCmd.Flags().IntVar(
+		&opts.Repeat,
+		"repeat",
+		1,
+		"send the call N times and print latency/error statistics",
+	)
+	apiCallCmd.Flags().DurationVar(
+		&opts.Interval,
+		"interval",
+		defaultDuration,
+		"delay between repeated calls (e.g., 500ms)",
+	)
+
 	_ = apiCallCmd.MarkFlagRequired("service")
 	_ = apiCallCmd.MarkFlagRequired("action")
 
@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/services/scopes"
 	"github.com/spf13/cobra"
 )
 
+const configKeyScope = "scope"
+
 func newAuthCommand() *cobra.Command {
 	//nolint:exhaustruct // Cobra command defaults are intentional.
 	authCmd := &cobra.Command{
@@ -13,7 +18,10 @@ func newAuthCommand() *cobra.Command {
 	}
 
 	authCmd.AddCommand(newAuthLoginCommand())
+	authCmd.AddCommand(newAuthSetClientCommand())
+	authCmd.AddCommand(newAuthRefreshCommand())
 	authCmd.AddCommand(newAuthStatusCommand())
+	authCmd.AddCommand(newAuthScopesCommand())
 	authCmd.AddCommand(newAuthLogoutCommand())
 
 	return authCmd
@@ -27,7 +35,7 @@ func newAuthLoginCommand() *cobra.Command {
 		Use:   "login",
 		Short: "Start browser OAuth flow and store tokens",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readCommandOptions(cmd)
 			if err != nil {
 				return err
 			}
@@ -54,22 +62,181 @@ func newAuthLoginCommand() *cobra.Command {
 		defaultListenAddr,
 		"callback listen address",
 	)
+	cmd.Flags().BoolVar(
+		&opts.PKCE,
+		"pkce",
+		false,
+		"use PKCE (S256) for public clients without a secret",
+	)
+	cmd.Flags().BoolVar(
+		&opts.Manual,
+		"manual",
+		false,
+		"paste the redirect URL instead of using the local callback "+
+			"(headless machines)",
+	)
+	cmd.Flags().StringVar(
+		&opts.Scope,
+		"scope",
+		emptyString,
+		"comma-separated OAuth scopes to request "+
+			"(user.info,user.metrics,user.activity,user.sleepevents; "+
+			"default: config login_scope or user.metrics,user.activity)",
+	)
+
+	return cmd
+}
+
+func newAuthSetClientCommand() *cobra.Command {
+	var opts auth.SetClientOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "set-client",
+		Short: "Set up the Withings app credentials interactively",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			opts.PromptLogin = !cmd.Flags().Changed("login")
+
+			return auth.SetClient(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&opts.ClientID,
+		"client-id",
+		emptyString,
+		"Withings client ID",
+	)
+	cmd.Flags().StringVar(
+		&opts.ClientSecret,
+		"client-secret",
+		emptyString,
+		"Withings client secret (prefer the prompt; flags end up in "+
+			"shell history)",
+	)
+	cmd.Flags().StringVar(
+		&opts.RedirectURI,
+		"redirect-uri",
+		emptyString,
+		"callback URL registered for the app (default: "+
+			"http://<listen>/callback)",
+	)
+	cmd.Flags().BoolVar(
+		&opts.Login,
+		"login",
+		false,
+		"run auth login after saving (asked when omitted)",
+	)
+	cmd.Flags().StringVar(
+		&opts.Scope,
+		"scope",
+		auth.ConfigScopeUser,
+		"config to write the client ID and redirect URI to: user or "+
+			"project (the secret always stays in user)",
+	)
+	cmd.Flags().StringVar(
+		&opts.Listen,
+		"listen",
+		defaultListenAddr,
+		"callback listen address for the default redirect URI",
+	)
+
+	return cmd
+}
+
+func newAuthRefreshCommand() *cobra.Command {
+	var opts auth.RefreshOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Refresh the access token using the stored refresh token",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.Refresh(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	cmd.Flags().DurationVar(
+		&opts.IfExpiring,
+		"if-expiring",
+		defaultDuration,
+		"only refresh when the token expires within this window (exit 6 "+
+			"otherwise)",
+	)
+	cmd.Flags().BoolVar(
+		&opts.AllProfiles,
+		"all-profiles",
+		false,
+		"refresh every configured profile and report each result",
+	)
 
 	return cmd
 }
 
 func newAuthStatusCommand() *cobra.Command {
+	var opts auth.StatusOptions
+
 	//nolint:exhaustruct // Cobra command defaults are intentional.
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show token scopes and expiry",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readCommandOptions(cmd)
 			if err != nil {
 				return err
 			}
 
-			return auth.Status(appOpts)
+			return auth.Status(opts, appOpts)
+		},
+	}
+
+	cmd.Flags().BoolVar(
+		&opts.Check,
+		"check",
+		false,
+		"print nothing; exit 0 with a valid token, 3 when absent or expired",
+	)
+
+	return cmd
+}
+
+func newAuthScopesCommand() *cobra.Command {
+	var opts scopes.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	return &cobra.Command{
+		Use:   "scopes",
+		Short: "Probe which granted scopes the token can actually use",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			settings, err := auth.ConfigValues(appOpts, configKeyScope)
+			if err != nil {
+				return fmt.Errorf("load config: %w", err)
+			}
+
+			opts.Granted = settings[configKeyScope]
+
+			return scopes.Run(cmd.Context(), opts, appOpts, accessToken)
 		},
 	}
 }
@@ -82,7 +249,7 @@ func newAuthLogoutCommand() *cobra.Command {
 		Use:   "logout",
 		Short: "Delete stored tokens",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readCommandOptions(cmd)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/spf13/cobra"
+)
+
+func newCardioCommand() *cobra.Command {
+	var opts measures.CardioOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cardioCmd := &cobra.Command{
+		Use:   "cardio",
+		Short: "Pulse wave velocity and vascular age trend",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Query.Pagination,
+				serviceMeasure,
+				actionGetMeas,
+			)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return measures.Cardio(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(cardioCmd, &opts.Query.TimeRange)
+	addPaginationFlags(
+		cardioCmd,
+		&opts.Query.Pagination,
+		serviceMeasure,
+		actionGetMeas,
+	)
+	addUserIDFlag(cardioCmd, &opts.Query.User)
+	addDryRunFlag(cardioCmd)
+	addLastUpdateFlag(cardioCmd, &opts.Query.LastUpdate)
+
+	cardioCmd.Flags().IntVar(
+		&opts.Age,
+		"age",
+		defaultInt,
+		"age in years (compares vascular age against it)",
+	)
+
+	return cardioCmd
+}
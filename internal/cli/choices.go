@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// choiceFlag pairs an enum-like flag value with its accepted values.
+type choiceFlag struct {
+	Value   string
+	Choices func() []output.Choice
+}
+
+// writeRequestedChoices prints the accepted values of the first flag set
+// to "list" or "?" and reports whether it did, so the command can stop
+// before authenticating.
+func writeRequestedChoices(
+	appOpts app.Options,
+	flags ...choiceFlag,
+) (bool, error) {
+	for _, flag := range flags {
+		if !output.IsChoiceList(flag.Value) {
+			continue
+		}
+
+		err := output.WriteChoices(appOpts, flag.Choices())
+		if err != nil {
+			return true, fmt.Errorf("write choices: %w", err)
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
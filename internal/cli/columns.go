@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configKeyColumnsPrefix = "columns_"
+	commandPathSeparator   = "_"
+	rootCommandWords       = 1
+)
+
+// applyComputedColumns loads the computed columns configured for cmd
+// under columns_<command>, e.g. columns_activity_get.
+func applyComputedColumns(cmd *cobra.Command, opts *app.Options) error {
+	key := columnsConfigKey(cmd)
+
+	settings, err := auth.ConfigValues(*opts, key)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	spec, ok := settings[key]
+	if !ok {
+		return nil
+	}
+
+	_, err = output.ParseColumns(spec)
+	if err != nil {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w %s: %w", errInvalidColumnsConfig, key, err),
+		)
+	}
+
+	opts.Columns = spec
+
+	return nil
+}
+
+func columnsConfigKey(cmd *cobra.Command) string {
+	words := strings.Fields(cmd.CommandPath())
+	if len(words) > rootCommandWords {
+		words = words[rootCommandWords:]
+	}
+
+	return configKeyColumnsPrefix +
+		strings.Join(words, commandPathSeparator)
+}
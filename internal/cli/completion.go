@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+const choiceListSeparator = ","
+
+// completeValues completes a flag from a fixed set of values.
+func completeValues(values ...string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// completeChoices completes a single-value enum flag with the names of
+// choices, described by their API values.
+func completeChoices(choices func() []output.Choice) cobra.CompletionFunc {
+	return func(
+		_ *cobra.Command,
+		_ []string,
+		toComplete string,
+	) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return choiceCompletions(choices(), emptyString, toComplete),
+			cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeChoiceList completes the last element of a comma-separated
+// enum flag such as --type weight,fat_ratio and skips names already in
+// the list.
+func completeChoiceList(choices func() []output.Choice) cobra.CompletionFunc {
+	return func(
+		_ *cobra.Command,
+		_ []string,
+		toComplete string,
+	) ([]cobra.Completion, cobra.ShellCompDirective) {
+		prefix := emptyString
+		current := toComplete
+
+		index := strings.LastIndex(toComplete, choiceListSeparator)
+		if index >= 0 {
+			prefix = toComplete[:index+1]
+			current = toComplete[index+1:]
+		}
+
+		used := strings.Split(prefix, choiceListSeparator)
+		available := slices.DeleteFunc(
+			slices.Clone(choices()),
+			func(choice output.Choice) bool {
+				return slices.Contains(used, choice.Name)
+			},
+		)
+
+		return choiceCompletions(available, prefix, current),
+			cobra.ShellCompDirectiveNoFileComp |
+				cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// completeProfiles completes --profile with the profiles next to the
+// config selected by --config.
+func completeProfiles(
+	cmd *cobra.Command,
+	_ []string,
+	_ string,
+) ([]cobra.Completion, cobra.ShellCompDirective) {
+	configPath, _ := getFlagString(cmd.Root().PersistentFlags(), "config")
+
+	names, err := auth.ProfileNames(configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func choiceCompletions(
+	choices []output.Choice,
+	prefix string,
+	toComplete string,
+) []cobra.Completion {
+	completions := make([]cobra.Completion, defaultInt, len(choices))
+
+	for _, choice := range choices {
+		if !strings.HasPrefix(choice.Name, strings.ToLower(toComplete)) {
+			continue
+		}
+
+		completions = append(completions, cobra.CompletionWithDesc(
+			prefix+choice.Name,
+			choice.Value,
+		))
+	}
+
+	slices.Sort(completions)
+
+	return completions
+}
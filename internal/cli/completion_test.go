@@ -0,0 +1,77 @@
+//nolint:testpackage // test unexported helpers.
+package cli
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+func testCompletionChoices() []output.Choice {
+	return []output.Choice{
+		{Name: "weight", Value: "1"},
+		{Name: "fat_ratio", Value: "6"},
+		{Name: "fat_mass", Value: "8"},
+	}
+}
+
+// TestCompleteChoiceList completes the last list element and skips names
+// already given.
+func TestCompleteChoiceList(t *testing.T) {
+	t.Parallel()
+
+	complete := completeChoiceList(testCompletionChoices)
+
+	got, directive := complete(nil, nil, "fat_ratio,")
+	want := []cobra.Completion{"fat_ratio,fat_mass\t8", "fat_ratio,weight\t1"}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	if directive&cobra.ShellCompDirectiveNoSpace == 0 {
+		t.Fatal("list completion adds a space after the element")
+	}
+
+	got, _ = complete(nil, nil, "weight,FAT_m")
+	if !slices.Equal(got, []cobra.Completion{"weight,fat_mass\t8"}) {
+		t.Fatalf("prefix got %q", got)
+	}
+}
+
+// TestRootCompletions completes --cloud and --date.
+func TestRootCompletions(t *testing.T) {
+	t.Parallel()
+
+	root := newRootCommand()
+
+	for _, test := range []struct {
+		args []string
+		flag string
+		want []cobra.Completion
+	}{
+		{args: nil, flag: "cloud", want: []cobra.Completion{"eu", "us"}},
+		{
+			args: []string{"sleep", "get"},
+			flag: "date",
+			want: []cobra.Completion{"today", "yesterday"},
+		},
+	} {
+		cmd, _, err := root.Find(test.args)
+		if err != nil {
+			t.Fatalf("find %v: %v", test.args, err)
+		}
+
+		complete, ok := cmd.GetFlagCompletionFunc(test.flag)
+		if !ok {
+			t.Fatalf("%s: no completion for --%s", cmd.Name(), test.flag)
+		}
+
+		got, _ := complete(cmd, nil, emptyString)
+		if !slices.Equal(got, test.want) {
+			t.Fatalf("--%s got %q want %q", test.flag, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configKeyArgs      = 1
+	configKeyValueArgs = 2
+)
+
+func newConfigCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Read and write config settings",
+	}
+
+	configCmd.AddCommand(newConfigSetCommand())
+	configCmd.AddCommand(newConfigGetCommand())
+	configCmd.AddCommand(newConfigListCommand())
+	configCmd.AddCommand(newConfigUnsetCommand())
+	configCmd.AddCommand(newConfigPathCommand())
+
+	return configCmd
+}
+
+func newConfigSetCommand() *cobra.Command {
+	var scope string
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a config value",
+		Args:  cobra.ExactArgs(configKeyValueArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.SetConfig(appOpts, scope, args[0], args[1])
+		},
+	}
+
+	addConfigScopeFlag(
+		cmd,
+		&scope,
+		"config to write: user (default) or project",
+	)
+
+	return cmd
+}
+
+func newConfigGetCommand() *cobra.Command {
+	var scope string
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a config value",
+		Args:  cobra.ExactArgs(configKeyArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.GetConfig(appOpts, scope, args[0])
+		},
+	}
+
+	addConfigScopeFlag(
+		cmd,
+		&scope,
+		"config to read: user or project (default: effective value)",
+	)
+
+	return cmd
+}
+
+func newConfigListCommand() *cobra.Command {
+	var scope string
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List config values (secrets masked)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.ListConfig(appOpts, scope)
+		},
+	}
+
+	addConfigScopeFlag(
+		cmd,
+		&scope,
+		"config to list: user or project (default: effective values)",
+	)
+
+	return cmd
+}
+
+func newConfigUnsetCommand() *cobra.Command {
+	var scope string
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a config value",
+		Args:  cobra.ExactArgs(configKeyArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.UnsetConfig(appOpts, scope, args[0])
+		},
+	}
+
+	addConfigScopeFlag(
+		cmd,
+		&scope,
+		"config to change: user (default) or project",
+	)
+
+	return cmd
+}
+
+func newConfigPathCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Show where the config files live",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.ConfigPaths(appOpts)
+		},
+	}
+}
+
+func addConfigScopeFlag(cmd *cobra.Command, scope *string, usage string) {
+	cmd.Flags().StringVar(scope, "scope", emptyString, usage)
+}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+// addYesFlag adds --yes, which skips the confirmation prompt of a write.
+func addYesFlag(cmd *cobra.Command, yes *bool) {
+	cmd.Flags().BoolVarP(yes, "yes", "y", false, "skip the confirmation prompt")
+}
+
+// writeConfirm returns the prompt asked before a write, or nil with --yes.
+// Without a terminal or with --no-input the prompt fails and names --yes.
+func writeConfirm(appOpts app.Options, yes bool) func(string) (bool, error) {
+	if yes {
+		return nil
+	}
+
+	return func(prompt string) (bool, error) {
+		return auth.ConfirmWrite(prompt, appOpts)
+	}
+}
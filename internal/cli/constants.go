@@ -4,7 +4,27 @@ const (
 	emptyString       = ""
 	defaultInt        = 0
 	defaultInt64      = 0
+	defaultFloat      = 0
+	defaultDuration   = 0
 	defaultCloud      = "eu"
 	defaultListenAddr = "127.0.0.1:9876"
+	defaultServeAddr  = "127.0.0.1:9877"
+	defaultMockAddr   = "127.0.0.1:9878"
 	noVerbosity       = 0
+	flagPage          = "page"
+	flagPerPage       = "per-page"
+	firstPage         = 1
+)
+
+// Endpoints of the paginated commands, used to look up --limit maxima.
+const (
+	serviceMeasure    = "measure"
+	serviceMeasureV2  = "v2/measure"
+	serviceSleep      = "v2/sleep"
+	serviceHeart      = "v2/heart"
+	actionGetMeas     = "getmeas"
+	actionGetActivity = "getactivity"
+	actionGetWorkouts = "getworkouts"
+	actionGetSummary  = "getsummary"
+	actionList        = "list"
 )
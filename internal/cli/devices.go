@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/services/devices"
+	"github.com/spf13/cobra"
+)
+
+func newDevicesCommand() *cobra.Command {
+	var opts devices.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	devicesCmd := &cobra.Command{
+		Use:   "devices",
+		Short: "Linked devices",
+	}
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	devicesListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List linked devices",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyComputedColumns(cmd, &appOpts)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return devices.Run(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	devicesCmd.AddCommand(devicesListCmd)
+
+	addUserIDFlag(devicesListCmd, &opts.User)
+	addDryRunFlag(devicesListCmd)
+
+	devicesListCmd.Flags().IntVar(
+		&opts.BatteryBelow,
+		"battery-below",
+		defaultInt,
+		"only show devices with battery below this percentage",
+	)
+
+	return devicesCmd
+}
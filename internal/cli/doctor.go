@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/services/doctor"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCommand() *cobra.Command {
+	var opts doctor.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check config, tokens, credentials, and connectivity",
+		Long: "Check config file permissions, token presence and expiry, " +
+			"client credentials, API and account host reachability, the " +
+			"local clock, and the OAuth callback port. Every problem comes " +
+			"with a hint; exits 1 when a check fails.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return doctor.Run(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&opts.Listen,
+		"listen",
+		defaultListenAddr,
+		"callback listen address to check (as passed to auth login)",
+	)
+
+	return cmd
+}
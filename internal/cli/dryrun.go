@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	flagDryRun = "dry-run"
+	// dryRunToken stands in for the access token: dry runs never print it,
+	// so they work without logging in.
+	dryRunToken = "dry-run"
+)
+
+// addDryRunFlag lets a data command print its request instead of sending
+// it.
+func addDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool(
+		flagDryRun,
+		false,
+		"print the resolved endpoint and form body without sending",
+	)
+}
+
+// readDataOptions reads the global options plus --dry-run of a data
+// command.
+func readDataOptions(cmd *cobra.Command) (app.Options, error) {
+	opts, err := readCommandOptions(cmd)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.DryRun, err = getFlagBool(cmd.Flags(), flagDryRun)
+
+	return opts, err
+}
+
+// dataAccessToken resolves the access token, or a placeholder for dry
+// runs.
+func dataAccessToken(ctx context.Context, opts app.Options) (string, error) {
+	if opts.DryRun {
+		return dryRunToken, nil
+	}
+
+	token, err := auth.EnsureAccessToken(ctx, opts)
+	if err != nil {
+		return emptyString, fmt.Errorf("ensure access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// flagTokenSource reads the global options of the running command only
+// when the API rejects its token, then refreshes that profile's tokens.
+type flagTokenSource struct {
+	flags *pflag.FlagSet
+}
+
+// Refresh implements withings.TokenSource.
+func (s flagTokenSource) Refresh(ctx context.Context) (string, error) {
+	opts, err := readGlobalOptions(s.flags)
+	if err != nil {
+		return emptyString, err
+	}
+
+	//nolint:wrapcheck // Keep the exit code of the auth error.
+	return auth.TokenSource{Options: opts}.Refresh(ctx)
+}
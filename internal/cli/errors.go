@@ -10,7 +10,67 @@ func (e staticError) Error() string {
 const (
 	errJSONPlainConflict staticError = "--json and --plain are " +
 		"mutually exclusive"
+	errNDJSONConflict staticError = "--ndjson cannot be combined with " +
+		"--json or --plain"
+	errFormatJSONConflict staticError = "--format cannot be combined " +
+		"with --json or --ndjson"
 	errQuietVerboseConflict staticError = "--quiet and --verbose cannot be " +
 		"combined"
-	errInvalidCloud staticError = "invalid --cloud (expected eu or us)"
+	errInvalidCloud      staticError = "invalid --cloud (expected eu or us)"
+	errSinceLastConflict staticError = "--since-last cannot be combined " +
+		"with --last-update, --start, or --end"
+	errInvalidRetries       staticError = "--retries must not be negative"
+	errInvalidRetryBackoff  staticError = "--retry-backoff must not be negative"
+	errInvalidTimeout       staticError = "--timeout must not be negative"
+	errInvalidRetriesConfig staticError = "invalid retries in config " +
+		"(expected a non-negative integer)"
+	errInvalidBackoffConfig staticError = "invalid retry_backoff in config " +
+		"(expected a duration such as 500ms)"
+	errInvalidTimeoutConfig staticError = "invalid timeout in config " +
+		"(expected a duration such as 30s)"
+	errInvalidCacheTTL       staticError = "--cache-ttl must not be negative"
+	errInvalidCacheTTLConfig staticError = "invalid cache_ttl in config " +
+		"(expected a duration such as 10m)"
+	errInvalidRateLimitConfig staticError = "invalid rate_limit_per_minute " +
+		"in config (expected a non-negative integer)"
+	errInvalidPageSizeConfig staticError = "invalid page_size in config " +
+		"(expected a non-negative integer)"
+	errPageOffsetConflict staticError = "--page/--per-page cannot be " +
+		"combined with --limit/--offset"
+	errInvalidUnits staticError = "invalid --units (expected metric or " +
+		"imperial)"
+	errInvalidUnitsConfig staticError = "invalid units in config " +
+		"(expected metric or imperial)"
+	errInvalidReadOnlyConfig staticError = "invalid read_only in config " +
+		"(expected true or false)"
+	errInvalidThresholdConfig staticError = "invalid threshold in config"
+	errInvalidBaseURLConfig   staticError = "invalid base_url in config " +
+		"(expected an absolute http or https URL)"
+	errInvalidColumnsConfig staticError = "invalid computed columns in " +
+		"config"
+	errInvalidTimezone staticError = "invalid --tz (expected local, utc, " +
+		"or an IANA name such as Europe/Berlin)"
+	errInvalidStatusStyle staticError = "invalid --style (expected " +
+		"table or oneline)"
+	errInvalidCheckInterval staticError = "--check-interval must be " +
+		"positive"
+	errSubscriptionAppli staticError = "--appli must name at least one " +
+		"appli type"
+	errInvalidStatusASCII staticError = "invalid status_ascii in config " +
+		"(expected true or false)"
+	errInvalidWatch  staticError = "--watch must not be negative"
+	errWatchConflict staticError = "--watch cannot be combined with " +
+		"--json or --dry-run"
+	errSummaryPeriod staticError = "--week and --month cannot be " +
+		"combined"
+	errSummaryMarkdown staticError = "--markdown cannot be combined " +
+		"with --json, --plain, --ndjson, or --format"
+	errDescWithoutSort staticError = "--desc requires --sort"
+	errReplayConflict  staticError = "--fixtures, --record, and --replay " +
+		"are mutually exclusive"
+	errInvalidGoal staticError = "--goal must be positive"
+	errNoGoal      staticError = "no daily goal set (pass --goal, set " +
+		"goal_steps or goal_calories in config, or a steps goal in the " +
+		"Withings app)"
+	errInvalidGoalConfig staticError = "invalid goal in config"
 )
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/services/export"
+	"github.com/spf13/cobra"
+)
+
+func newExportCommand() *cobra.Command {
+	var opts export.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all data for a range to files or a database",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			return export.Run(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(exportCmd, &opts.TimeRange)
+
+	exportCmd.Flags().StringVar(
+		&opts.Output,
+		"dest",
+		emptyString,
+		"output directory (json, csv) or file (other formats)",
+	)
+	exportCmd.Flags().StringVar(
+		&opts.Format,
+		"file-format",
+		emptyString,
+		"export format: json, csv, sql, sqlite, apple-health-xml, or "+
+			"gfit-csv (default json)",
+	)
+
+	exportCmd.Flags().IntVar(
+		&opts.Concurrency,
+		"concurrency",
+		export.DefaultConcurrency,
+		"endpoints fetched in parallel (1 fetches one at a time)",
+	)
+
+	_ = exportCmd.MarkFlagRequired("start")
+	_ = exportCmd.MarkFlagRequired("dest")
+
+	return exportCmd
+}
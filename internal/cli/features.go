@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/features"
+	"github.com/spf13/cobra"
+)
+
+func newFeaturesCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	return &cobra.Command{
+		Use:   "features",
+		Short: "List experimental features and whether they are enabled",
+		Long: "List experimental features. Enable one with `withings config " +
+			"set experimental.<name> true` or " + features.EnvExperimental +
+			"=<name>[,<name>...] (all enables every feature; a leading - " +
+			"disables one).",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return features.Run(appOpts)
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/spf13/cobra"
+)
+
+const fitnessMeasureTypes = "vo2max"
+
+func newFitnessCommand() *cobra.Command {
+	var opts measures.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	fitnessCmd := &cobra.Command{
+		Use:   "fitness",
+		Short: "VO2max trend over time",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceMeasure,
+				actionGetMeas,
+			)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			opts.Types = fitnessMeasureTypes
+			opts.Category = "real"
+
+			return measures.Trend(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(fitnessCmd, &opts.TimeRange)
+	addPaginationFlags(
+		fitnessCmd,
+		&opts.Pagination,
+		serviceMeasure,
+		actionGetMeas,
+	)
+	addUserIDFlag(fitnessCmd, &opts.User)
+	addDryRunFlag(fitnessCmd)
+	addLastUpdateFlag(fitnessCmd, &opts.LastUpdate)
+
+	return fitnessCmd
+}
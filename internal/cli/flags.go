@@ -1,7 +1,12 @@
 package cli
 
 import (
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
 	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
 	"github.com/spf13/cobra"
 )
 
@@ -10,13 +15,14 @@ func addTimeRangeFlags(cmd *cobra.Command, opts *params.TimeRange) {
 		&opts.Start,
 		"start",
 		emptyString,
-		"start time (RFC3339, YYYY-MM-DD, or epoch)",
+		"start time (RFC3339, YYYY-MM-DD, epoch, or relative: 7d, "+
+			"yesterday, last-week, 2024-01)",
 	)
 	cmd.Flags().StringVar(
 		&opts.End,
 		"end",
 		emptyString,
-		"end time (RFC3339, YYYY-MM-DD, or epoch)",
+		"end time (RFC3339, YYYY-MM-DD, epoch, or relative)",
 	)
 }
 
@@ -25,16 +31,31 @@ func addDateFlag(cmd *cobra.Command, opts *params.Date) {
 		&opts.Date,
 		"date",
 		emptyString,
-		"date (YYYY-MM-DD)",
+		"date (YYYY-MM-DD, today, or yesterday)",
+	)
+
+	_ = cmd.RegisterFlagCompletionFunc(
+		"date",
+		completeValues("today", "yesterday"),
 	)
 }
 
-func addPaginationFlags(cmd *cobra.Command, opts *params.Pagination) {
+func addPaginationFlags(
+	cmd *cobra.Command,
+	opts *params.Pagination,
+	service string,
+	action string,
+) {
+	limitUsage := "limit number of results (not supported, ignored)"
+	if maxLimit := withings.MaxLimit(service, action); maxLimit > defaultInt {
+		limitUsage = fmt.Sprintf("limit number of results (max %d)", maxLimit)
+	}
+
 	cmd.Flags().IntVar(
 		&opts.Limit,
 		"limit",
 		defaultInt,
-		"limit number of results",
+		limitUsage,
 	)
 	cmd.Flags().IntVar(
 		&opts.Offset,
@@ -42,6 +63,99 @@ func addPaginationFlags(cmd *cobra.Command, opts *params.Pagination) {
 		defaultInt,
 		"offset into result set",
 	)
+	cmd.Flags().Int(
+		flagPage,
+		firstPage,
+		"page number, starting at 1 (sets --limit/--offset)",
+	)
+	cmd.Flags().Int(
+		flagPerPage,
+		defaultInt,
+		"results per page (default page_size config or endpoint max)",
+	)
+}
+
+// applyPagination resolves --page/--per-page into limit and offset, fills
+// in the page_size config default, and clamps --limit to the endpoint
+// maximum, warning on stderr when a value changed.
+func applyPagination(
+	flags flagReader,
+	appOpts app.Options,
+	opts *params.Pagination,
+	service string,
+	action string,
+) error {
+	paged := flags.Changed(flagPage) || flags.Changed(flagPerPage)
+	if paged && (flags.Changed("limit") || flags.Changed("offset")) {
+		return app.NewExitError(app.ExitCodeUsage, errPageOffsetConflict)
+	}
+
+	if paged {
+		return applyPage(flags, appOpts, opts, service, action)
+	}
+
+	if opts.Limit == defaultInt &&
+		withings.MaxLimit(service, action) != withings.NoLimit {
+		opts.Limit = appOpts.PageSize
+	}
+
+	limit, warning, err := withings.CheckLimit(service, action, opts.Limit)
+	if err != nil {
+		return fmt.Errorf("check --limit: %w", err)
+	}
+
+	opts.Limit = limit
+
+	return writePaginationWarning(appOpts, warning)
+}
+
+func applyPage(
+	flags flagReader,
+	appOpts app.Options,
+	opts *params.Pagination,
+	service string,
+	action string,
+) error {
+	page, err := getFlagInt(flags, flagPage)
+	if err != nil {
+		return err
+	}
+
+	perPage := appOpts.PageSize
+	if flags.Changed(flagPerPage) {
+		perPage, err = getFlagInt(flags, flagPerPage)
+		if err != nil {
+			return err
+		}
+	}
+
+	limit, offset, warning, err := withings.PageWindow(
+		service,
+		action,
+		page,
+		perPage,
+	)
+	if err != nil {
+		return fmt.Errorf("check --page: %w", err)
+	}
+
+	opts.Limit = limit
+	opts.Offset = offset
+
+	return writePaginationWarning(appOpts, warning)
+}
+
+func writePaginationWarning(appOpts app.Options, warning string) error {
+	if warning == emptyString {
+		return nil
+	}
+
+	err := output.WriteWarning(appOpts, warning)
+	if err != nil {
+		return fmt.Errorf("write limit warning: %w", err)
+	}
+
+	return nil
 }
 
 func addUserIDFlag(cmd *cobra.Command, opts *params.User) {
@@ -1,9 +1,9 @@
 package cli
 
 import (
-	"fmt"
+	"context"
 
-	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/services/heart"
 	"github.com/spf13/cobra"
 )
@@ -21,26 +21,62 @@ func newHeartCommand() *cobra.Command {
 		Use:   "get",
 		Short: "Fetch heart data",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readDataOptions(cmd)
 			if err != nil {
 				return err
 			}
 
-			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			err = applyComputedColumns(cmd, &appOpts)
 			if err != nil {
-				return fmt.Errorf("ensure access token: %w", err)
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceHeart,
+				actionList,
+			)
+			if err != nil {
+				return err
 			}
 
-			return heart.Run(cmd.Context(), opts, appOpts, accessToken)
+			return runWatched(
+				cmd,
+				appOpts,
+				watchTarget{
+					LastUpdate: &opts.LastUpdate,
+					TimeRange:  &opts.TimeRange,
+					Date:       nil,
+					UpdateTime: nil,
+				},
+				func(
+					ctx context.Context,
+					appOpts app.Options,
+					accessToken string,
+				) error {
+					return heart.Run(ctx, opts, appOpts, accessToken)
+				},
+			)
 		},
 	}
 
 	heartCmd.AddCommand(heartGetCmd)
+	heartCmd.AddCommand(newHeartSignalCommand())
+	heartCmd.AddCommand(newHeartZonesCommand())
 
 	addTimeRangeFlags(heartGetCmd, &opts.TimeRange)
-	addPaginationFlags(heartGetCmd, &opts.Pagination)
+	addPaginationFlags(
+		heartGetCmd,
+		&opts.Pagination,
+		serviceHeart,
+		actionList,
+	)
 	addUserIDFlag(heartGetCmd, &opts.User)
+	addDryRunFlag(heartGetCmd)
 	addLastUpdateFlag(heartGetCmd, &opts.LastUpdate)
+	addWatchFlag(heartGetCmd)
 
 	heartGetCmd.Flags().BoolVar(
 		&opts.Signal,
@@ -51,3 +87,100 @@ func newHeartCommand() *cobra.Command {
 
 	return heartCmd
 }
+
+func newHeartSignalCommand() *cobra.Command {
+	var opts heart.SignalOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	heartSignalCmd := &cobra.Command{
+		Use:     "signal",
+		Aliases: []string{"ecg"},
+		Short:   "Download a full ECG waveform",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return heart.Signal(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addUserIDFlag(heartSignalCmd, &opts.User)
+	addDryRunFlag(heartSignalCmd)
+
+	heartSignalCmd.Flags().Int64Var(
+		&opts.SignalID,
+		"signal-id",
+		defaultInt64,
+		"signal ID (see the signal_id column of heart get)",
+	)
+	heartSignalCmd.Flags().StringVar(
+		&opts.Output,
+		"dest",
+		emptyString,
+		"write to file instead of stdout",
+	)
+	heartSignalCmd.Flags().StringVar(
+		&opts.Format,
+		"file-format",
+		emptyString,
+		"export format: csv, json, edf, or wfdb "+
+			"(default from --dest extension)",
+	)
+	heartSignalCmd.Flags().StringVar(
+		&opts.Plot,
+		"plot",
+		emptyString,
+		"render the waveform to a .png or .svg file (25 mm/s, 10 mm/mV)",
+	)
+
+	_ = heartSignalCmd.MarkFlagRequired("signal-id")
+
+	return heartSignalCmd
+}
+
+func newHeartZonesCommand() *cobra.Command {
+	var opts heart.ZonesOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	heartZonesCmd := &cobra.Command{
+		Use:   "zones --max-hr <bpm>",
+		Short: "Time in heart-rate zones per day",
+		Long: "Bucket the intraday heart-rate series into zones Z1 " +
+			"(50-60% of --max-hr) to Z5 (90% and above) and print the " +
+			"seconds per zone and day. Each sample counts until the " +
+			"next one, at most 15 minutes.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return heart.Zones(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(heartZonesCmd, &opts.TimeRange)
+	addUserIDFlag(heartZonesCmd, &opts.User)
+	addDryRunFlag(heartZonesCmd)
+
+	heartZonesCmd.Flags().IntVar(
+		&opts.MaxHR,
+		"max-hr",
+		defaultInt,
+		"maximum heart rate in bpm the zones are based on (required)",
+	)
+
+	return heartZonesCmd
+}
@@ -0,0 +1,426 @@
+//nolint:testpackage // test unexported helpers.
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/services/schema"
+	"github.com/mreimbold/withings-cli/internal/withings/mockserver"
+)
+
+// The integration suite runs read-only commands end-to-end against the
+// live API with a token of the Withings demo account, so upstream schema
+// changes surface as decoding failures. It is skipped unless
+// WITHINGS_INTEGRATION_TOKEN is set (see `make integration`). The same
+// commands always run against the embedded mock server.
+const (
+	envIntegrationToken = "WITHINGS_INTEGRATION_TOKEN"
+	envIntegrationCloud = "WITHINGS_INTEGRATION_CLOUD"
+	envIntegrationExec  = "WITHINGS_INTEGRATION_EXEC"
+	integrationTokenTTL = time.Hour
+	integrationConfig   = "access_token = %q\nexpires_at = %q\n"
+	integrationFileMode = 0o600
+)
+
+// TestMain lets the integration suite run the CLI as a subprocess of the
+// test binary, with its own stdout, stderr, and exit code.
+func TestMain(m *testing.M) {
+	if os.Getenv(envIntegrationExec) != emptyString {
+		os.Exit(Execute())
+	}
+
+	os.Exit(m.Run())
+}
+
+// TestIntegrationReadCommands decodes every read command's response.
+func TestIntegrationReadCommands(t *testing.T) {
+	t.Parallel()
+
+	token := os.Getenv(envIntegrationToken)
+	if token == emptyString {
+		t.Skipf("set %s to run against the live API", envIntegrationToken)
+	}
+
+	runReadCommands(t, writeIntegrationConfig(t, token))
+}
+
+// TestMockReadCommands runs the integration suite hermetically against
+// the embedded mock server.
+func TestMockReadCommands(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(mockserver.NewHandler(time.Now))
+	t.Cleanup(server.Close)
+
+	runReadCommands(
+		t,
+		writeIntegrationConfig(t, "mock"),
+		"--base-url",
+		server.URL,
+	)
+}
+
+// runReadCommands decodes every read command's response.
+func runReadCommands(t *testing.T, config string, global ...string) {
+	t.Helper()
+
+	commands := [][]string{
+		{"measures", "get", "--start", "30d"},
+		{"measures", "sources", "--start", "30d"},
+		{"activity", "get", "--start", "7d"},
+		{"activity", "intraday"},
+		{"sleep", "get", "--start", "7d"},
+		{"sleep", "detail", "--start", "2d"},
+		{"heart", "get", "--start", "30d"},
+		{"heart", "zones", "--max-hr", "185", "--start", "2d"},
+		{"workouts", "list", "--start", "30d"},
+		{"devices", "list"},
+		{"user", "goals"},
+		{"status"},
+	}
+
+	for _, command := range commands {
+		name := strings.Join(command, " ")
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// --json checks the decoded payload, --plain the row mapping.
+			for _, format := range []string{"--json", "--plain"} {
+				args := slices.Concat(global, command, []string{format})
+
+				stdout := runIntegration(t, config, args...)
+				if format == "--json" && !json.Valid(stdout) {
+					t.Fatalf("%s --json: invalid JSON:\n%s", name, stdout)
+				}
+			}
+		})
+	}
+}
+
+// schemaCommandArgs runs every command of withings schema so its --plain
+// header shows all listed columns; nil marks commands the mock server
+// does not serve.
+//
+//nolint:gochecknoglobals // Read-only test table.
+var schemaCommandArgs = map[string][]string{
+	"activity get":      {"activity", "get", "--goal", "10000"},
+	"activity intraday": {"activity", "intraday"},
+	"cardio":            {"cardio"},
+	"devices list":      {"devices", "list"},
+	"fitness":           {"fitness"},
+	"heart get":         {"heart", "get"},
+	"heart zones":       {"heart", "zones", "--max-hr", "185"},
+	"measures get":      {"measures", "get"},
+	"measures sources":  {"measures", "sources"},
+	"notify list":       nil,
+	"sleep detail":      {"sleep", "detail"},
+	"sleep get":         {"sleep", "get"},
+	"sleep report":      {"sleep", "report"},
+	"sleep score":       {"sleep", "score"},
+	"status":            {"status"},
+	"summary":           {"summary"},
+	"user goals":        {"user", "goals"},
+	"workouts list":     {"workouts", "list"},
+	"workouts summary":  {"workouts", "summary"},
+}
+
+// TestMockSchemaHeaders compares every withings schema entry with the
+// --plain header its command prints, so the schema cannot drift.
+func TestMockSchemaHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(mockserver.NewHandler(time.Now))
+	t.Cleanup(server.Close)
+
+	config := writeIntegrationConfig(t, "mock")
+
+	var document schema.Document
+
+	err := json.Unmarshal(runIntegration(t, config, "schema", "--json"),
+		&document)
+	if err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+
+	if len(document.Commands) != len(schemaCommandArgs) {
+		t.Fatalf("schema lists %d commands, test table %d",
+			len(document.Commands), len(schemaCommandArgs))
+	}
+
+	for _, command := range document.Commands {
+		args, ok := schemaCommandArgs[command.Command]
+		if !ok {
+			t.Fatalf("no test args for %q", command.Command)
+		}
+
+		if args == nil {
+			continue
+		}
+
+		t.Run(command.Command, func(t *testing.T) {
+			t.Parallel()
+
+			stdout := runIntegration(t, config, slices.Concat(
+				[]string{"--base-url", server.URL, "--plain"},
+				args,
+			)...)
+			header, _, _ := strings.Cut(string(stdout), "\n")
+
+			names := make([]string, 0, len(command.Columns))
+			for _, column := range command.Columns {
+				names = append(names, column.Name)
+			}
+
+			if header != strings.Join(names, "\t") {
+				t.Fatalf("header %q, schema %q", header, names)
+			}
+		})
+	}
+}
+
+// TestMockRedactCommands checks that --redact moves every health value
+// each command prints, whichever output path formats it.
+func TestMockRedactCommands(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(mockserver.NewHandler(time.Now))
+	t.Cleanup(server.Close)
+
+	config := writeIntegrationConfig(t, "mock")
+	commands := [][]string{
+		{"status", "--style", "oneline"},
+		{"sleep", "report", "--plain"},
+		{"measures", "get", "--latest", "--quiet", "--plain", "--type=weight"},
+		{"summary", "--plain"},
+	}
+
+	for _, command := range commands {
+		name := strings.Join(command, " ")
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			args := slices.Concat([]string{"--base-url", server.URL}, command)
+			raw := runIntegration(t, config, args...)
+			redacted := runIntegration(
+				t,
+				config,
+				slices.Concat([]string{"--redact"}, args)...,
+			)
+
+			leaks := redactLeaks(string(raw), string(redacted))
+			if len(leaks) > 0 {
+				t.Fatalf("raw values %q leaked:\n%s", leaks, redacted)
+			}
+
+			if command[0] == "summary" {
+				assertDerivedChange(t, string(redacted))
+			}
+		})
+	}
+}
+
+// TestMockOutputAndDest writes an export to --dest and the command's own
+// output to the global -o, which no command shadows.
+func TestMockOutputAndDest(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(mockserver.NewHandler(time.Now))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	summary := filepath.Join(dir, "summary.txt")
+	samples := filepath.Join(dir, "samples.csv")
+
+	stdout := runIntegration(
+		t,
+		writeIntegrationConfig(t, "mock"),
+		"--base-url", server.URL,
+		"activity", "intraday", "--dest", samples, "-o", summary,
+	)
+	if len(stdout) != 0 {
+		t.Fatalf("stdout not redirected: %q", stdout)
+	}
+
+	written, err := os.ReadFile(summary)
+	if err != nil || !strings.HasPrefix(string(written), "wrote ") {
+		t.Fatalf("-o file: %q, %v", written, err)
+	}
+
+	csv, err := os.ReadFile(samples)
+	if err != nil || !strings.HasPrefix(string(csv), "time,") {
+		t.Fatalf("--dest file: %q, %v", csv, err)
+	}
+}
+
+// redactMinJitter is the smallest relative change --redact applies.
+const redactMinJitter = 0.005
+
+// redactTokenPattern finds the numbers and 7h56m durations of an output.
+//
+//nolint:gochecknoglobals // Compiled once.
+var redactTokenPattern = regexp.MustCompile(`\d+h\d{2}m|\d[\d,]*(?:\.\d+)?`)
+
+// redactLeaks pairs the numbers of raw and redacted output by position
+// and returns those --redact must have moved but did not. The change
+// column is skipped: it is derived from the redacted values and may
+// round back to its raw value.
+func redactLeaks(raw, redacted string) []string {
+	rawTokens := redactTokens(raw)
+	redactedTokens := redactTokens(redacted)
+
+	var leaks []string
+
+	for index, token := range rawTokens {
+		if index < len(redactedTokens) && redactedTokens[index] == token &&
+			mustMove(token) {
+			leaks = append(leaks, token)
+		}
+	}
+
+	return leaks
+}
+
+func redactTokens(output string) []string {
+	var tokens []string
+
+	change := -1
+
+	for index, line := range strings.Split(output, "\n") {
+		cells := strings.Split(line, "\t")
+		if index == 0 {
+			change = slices.Index(cells, "change")
+		}
+
+		if change >= 0 && change < len(cells) {
+			cells[change] = emptyString
+		}
+
+		tokens = append(
+			tokens,
+			redactTokenPattern.FindAllString(strings.Join(cells, "\t"), -1)...,
+		)
+	}
+
+	return tokens
+}
+
+// mustMove reports whether the smallest jitter changes token's last digit.
+func mustMove(token string) bool {
+	var hours, minutes int
+
+	_, err := fmt.Sscanf(token, "%dh%dm", &hours, &minutes)
+	if err == nil {
+		return float64(hours*60+minutes)*redactMinJitter >= 1
+	}
+
+	token = strings.ReplaceAll(token, ",", emptyString)
+
+	value, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return false
+	}
+
+	unit := 1.0
+	if _, decimals, found := strings.Cut(token, "."); found {
+		unit = math.Pow10(-len(decimals))
+	}
+
+	return value*redactMinJitter >= unit
+}
+
+// assertDerivedChange checks value - previous = change on every row of a
+// plain summary table.
+func assertDerivedChange(t *testing.T, output string) {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines[1:] {
+		cells := strings.Split(line, "\t")
+
+		value, errValue := strconv.ParseFloat(cells[1], 64)
+		previous, errPrevious := strconv.ParseFloat(cells[2], 64)
+		change, errChange := strconv.ParseFloat(cells[3], 64)
+
+		if errValue != nil || errPrevious != nil || errChange != nil {
+			continue
+		}
+
+		if math.Abs(value-previous-change) > 0.051 {
+			t.Fatalf("change is not value - previous: %q", line)
+		}
+	}
+}
+
+func writeIntegrationConfig(t *testing.T, token string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	expires := time.Now().Add(integrationTokenTTL).UTC().Format(time.RFC3339)
+
+	err := os.WriteFile(
+		path,
+		fmt.Appendf(nil, integrationConfig, token, expires),
+		integrationFileMode,
+	)
+	if err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	return path
+}
+
+// runIntegration runs the CLI with --read-only against an isolated
+// config and fails the test on a non-zero exit.
+func runIntegration(t *testing.T, config string, args ...string) []byte {
+	t.Helper()
+
+	global := []string{"--config", config, "--read-only", "--no-input"}
+	if cloud := os.Getenv(envIntegrationCloud); cloud != emptyString {
+		global = append(global, "--cloud", cloud)
+	}
+
+	//nolint:gosec // Re-executes the test binary itself.
+	cmd := exec.CommandContext(
+		t.Context(),
+		os.Args[0],
+		append(global, args...)...,
+	)
+	cmd.Env = append(
+		os.Environ(),
+		envIntegrationExec+"=1",
+		"XDG_CACHE_HOME="+t.TempDir(),
+	)
+	cmd.Dir = t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		t.Fatalf(
+			"withings %s: %v\nstderr:\n%s",
+			strings.Join(args, " "),
+			err,
+			stderr.String(),
+		)
+	}
+
+	return stdout.Bytes()
+}
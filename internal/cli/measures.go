@@ -1,15 +1,29 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/auth"
 	"github.com/mreimbold/withings-cli/internal/services/measures"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	measuresBookmarkName = "measures"
+	measureCategoryReal  = "real"
+	measureCategoryGoal  = "goal"
+	configKeyBPGuideline = "bp_guideline"
+	flagGuideline        = "guideline"
 )
 
 func newMeasuresCommand() *cobra.Command {
-	var opts measures.Options
+	var (
+		opts      measures.Options
+		sinceLast bool
+	)
 
 	//nolint:exhaustruct // Cobra command defaults are intentional.
 	measuresCmd := &cobra.Command{
@@ -21,39 +35,484 @@ func newMeasuresCommand() *cobra.Command {
 		Use:   "get",
 		Short: "Fetch body measures",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyComputedColumns(cmd, &appOpts)
 			if err != nil {
 				return err
 			}
 
-			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			err = applyGuideline(cmd, appOpts, &opts.Guideline)
 			if err != nil {
-				return fmt.Errorf("ensure access token: %w", err)
+				return err
+			}
+
+			listed, err := writeRequestedChoices(
+				appOpts,
+				choiceFlag{Value: opts.Types, Choices: measures.TypeChoices},
+				choiceFlag{
+					Value:   opts.Category,
+					Choices: measures.CategoryChoices,
+				},
+				choiceFlag{
+					Value:   opts.Sources,
+					Choices: measures.SourceChoices,
+				},
+				choiceFlag{Value: opts.Modes, Choices: measures.ModeChoices},
+			)
+			if listed || err != nil {
+				return err
 			}
 
-			return measures.Run(cmd.Context(), opts, appOpts, accessToken)
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceMeasure,
+				actionGetMeas,
+			)
+			if err != nil {
+				return err
+			}
+
+			if sinceLast {
+				err = applyMeasuresBookmark(appOpts, &opts)
+				if err != nil {
+					return err
+				}
+			}
+
+			var updateTime int64
+
+			opts.RecordUpdate = recordUpdateTime(
+				&updateTime,
+				opts.RecordUpdate,
+			)
+
+			return runWatched(
+				cmd,
+				appOpts,
+				watchTarget{
+					LastUpdate: &opts.LastUpdate,
+					TimeRange:  &opts.TimeRange,
+					Date:       nil,
+					UpdateTime: &updateTime,
+				},
+				func(
+					ctx context.Context,
+					appOpts app.Options,
+					accessToken string,
+				) error {
+					return measures.Run(ctx, opts, appOpts, accessToken)
+				},
+			)
 		},
 	}
 
 	measuresCmd.AddCommand(measuresGetCmd)
+	measuresCmd.AddCommand(newMeasuresSourcesCommand())
+	measuresCmd.AddCommand(newMeasuresSetCommand())
+	measuresCmd.AddCommand(newMeasuresGoalCommand())
+	measuresCmd.AddCommand(newMeasuresBackfillCommand())
 
 	addTimeRangeFlags(measuresGetCmd, &opts.TimeRange)
-	addPaginationFlags(measuresGetCmd, &opts.Pagination)
+	addPaginationFlags(
+		measuresGetCmd,
+		&opts.Pagination,
+		serviceMeasure,
+		actionGetMeas,
+	)
 	addUserIDFlag(measuresGetCmd, &opts.User)
+	addDryRunFlag(measuresGetCmd)
 	addLastUpdateFlag(measuresGetCmd, &opts.LastUpdate)
+	addWatchFlag(measuresGetCmd)
+	addGuidelineFlag(measuresGetCmd, &opts.Guideline)
 
 	measuresGetCmd.Flags().StringVar(
 		&opts.Types,
 		"type",
 		emptyString,
-		"measure types (comma-separated)",
+		"measure types (comma-separated; list shows them)",
 	)
 	measuresGetCmd.Flags().StringVar(
 		&opts.Category,
 		"category",
 		emptyString,
-		"category: real or goal",
+		"category: real or goal (list shows them)",
+	)
+	measuresGetCmd.Flags().StringVar(
+		&opts.Sources,
+		"source",
+		emptyString,
+		"measure sources (e.g., device,manual, attrib IDs, or list)",
+	)
+	measuresGetCmd.Flags().StringVar(
+		&opts.Modes,
+		"mode",
+		emptyString,
+		"body-composition modes (standard, athlete, pregnancy, fm "+
+			"values, or list)",
+	)
+	measuresGetCmd.Flags().StringArrayVar(
+		&opts.Where,
+		"where",
+		nil,
+		"keep measures matching <column><op><value>, e.g. 'value>100' "+
+			"(repeatable; all must match)",
+	)
+	measuresGetCmd.Flags().StringVar(
+		&opts.Min,
+		"min",
+		emptyString,
+		"keep measures with value >= min (after --units)",
+	)
+	measuresGetCmd.Flags().StringVar(
+		&opts.Max,
+		"max",
+		emptyString,
+		"keep measures with value <= max (after --units)",
+	)
+	addMeasureCompletions(measuresGetCmd)
+	_ = measuresGetCmd.RegisterFlagCompletionFunc(
+		"source",
+		completeChoiceList(measures.SourceChoices),
+	)
+	_ = measuresGetCmd.RegisterFlagCompletionFunc(
+		"mode",
+		completeChoiceList(measures.ModeChoices),
+	)
+
+	measuresGetCmd.Flags().BoolVar(
+		&sinceLast,
+		"since-last",
+		false,
+		"only fetch changes since the last --since-last run "+
+			"(implies --all)",
+	)
+	measuresGetCmd.Flags().BoolVar(
+		&opts.All,
+		"all",
+		false,
+		"follow more/offset and fetch every page",
+	)
+	measuresGetCmd.Flags().BoolVar(
+		&opts.Distinct,
+		"distinct",
+		false,
+		"collapse equal measurements synced twice (device + app)",
+	)
+	measuresGetCmd.Flags().BoolVar(
+		&opts.Latest,
+		"latest",
+		false,
+		"only the most recent measurement per type "+
+			"(with --quiet --plain: values only)",
 	)
 
 	return measuresCmd
 }
+
+func newMeasuresSourcesCommand() *cobra.Command {
+	var opts measures.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	sourcesCmd := &cobra.Command{
+		Use:   "sources",
+		Short: "Break down data points by source (device, manual, third party)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return measures.Sources(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(sourcesCmd, &opts.TimeRange)
+	addUserIDFlag(sourcesCmd, &opts.User)
+	addDryRunFlag(sourcesCmd)
+
+	sourcesCmd.Flags().StringVar(
+		&opts.Types,
+		"type",
+		emptyString,
+		"measure types (comma-separated; default all)",
+	)
+	sourcesCmd.Flags().StringVar(
+		&opts.Category,
+		"category",
+		emptyString,
+		"category: real or goal",
+	)
+	addMeasureCompletions(sourcesCmd)
+
+	return sourcesCmd
+}
+
+func newMeasuresBackfillCommand() *cobra.Command {
+	var opts measures.BackfillOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	backfillCmd := &cobra.Command{
+		Use:   "backfill --since <time>",
+		Short: "Fetch a long history in chunked date windows",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyGuideline(cmd, appOpts, &opts.Guideline)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return measures.Backfill(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(backfillCmd, &opts.TimeRange)
+	backfillCmd.Flags().SetNormalizeFunc(normalizeSinceFlag)
+	addUserIDFlag(backfillCmd, &opts.User)
+	addDryRunFlag(backfillCmd)
+
+	backfillCmd.Flags().StringVar(
+		&opts.Types,
+		"type",
+		emptyString,
+		"measure types (comma-separated; default all)",
+	)
+	backfillCmd.Flags().StringVar(
+		&opts.Category,
+		"category",
+		emptyString,
+		"category: real or goal",
+	)
+	backfillCmd.Flags().StringVar(
+		&opts.Chunk,
+		"chunk",
+		measures.DefaultChunk,
+		"window length per request (e.g., 30d, 12w)",
+	)
+	addMeasureCompletions(backfillCmd)
+	addGuidelineFlag(backfillCmd, &opts.Guideline)
+
+	return backfillCmd
+}
+
+// normalizeSinceFlag accepts --since and --types as aliases of --start
+// and --type.
+func normalizeSinceFlag(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+	switch name {
+	case "since":
+		name = "start"
+	case "types":
+		name = "type"
+	}
+
+	return pflag.NormalizedName(name)
+}
+
+func newMeasuresSetCommand() *cobra.Command {
+	var (
+		opts measures.SetOptions
+		yes  bool
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "set --type <type> --value <value>",
+		Short: "Record a manual measure (weight, height)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Category = measureCategoryReal
+
+			return runMeasuresSet(cmd, opts, yes)
+		},
+	}
+
+	addMeasuresSetFlags(cmd, &opts, &yes)
+
+	cmd.Flags().StringVar(
+		&opts.Date,
+		"date",
+		emptyString,
+		"when it was measured (RFC3339, YYYY-MM-DD, epoch, or relative)",
+	)
+
+	return cmd
+}
+
+func newMeasuresGoalCommand() *cobra.Command {
+	var (
+		opts measures.SetOptions
+		yes  bool
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	goalCmd := &cobra.Command{
+		Use:   "goal",
+		Short: "Measure goals",
+	}
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	setCmd := &cobra.Command{
+		Use:   "set --type <type> --value <value>",
+		Short: "Set a measure goal (e.g. target weight)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Category = measureCategoryGoal
+
+			return runMeasuresSet(cmd, opts, yes)
+		},
+	}
+
+	addMeasuresSetFlags(setCmd, &opts, &yes)
+	goalCmd.AddCommand(setCmd)
+
+	return goalCmd
+}
+
+func addMeasuresSetFlags(
+	cmd *cobra.Command,
+	opts *measures.SetOptions,
+	yes *bool,
+) {
+	addUserIDFlag(cmd, &opts.User)
+	addDryRunFlag(cmd)
+	addYesFlag(cmd, yes)
+
+	cmd.Flags().StringVar(
+		&opts.Type,
+		"type",
+		emptyString,
+		"measure type: weight or height (list shows them)",
+	)
+	cmd.Flags().StringVar(
+		&opts.Value,
+		"value",
+		emptyString,
+		"value in kg or m; suffixes lb and cm convert",
+	)
+
+	_ = cmd.RegisterFlagCompletionFunc(
+		"type",
+		completeChoices(measures.SettableTypeChoices),
+	)
+}
+
+func runMeasuresSet(
+	cmd *cobra.Command,
+	opts measures.SetOptions,
+	yes bool,
+) error {
+	appOpts, err := readDataOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	listed, err := writeRequestedChoices(
+		appOpts,
+		choiceFlag{Value: opts.Type, Choices: measures.SettableTypeChoices},
+	)
+	if listed || err != nil {
+		return err
+	}
+
+	accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+	if err != nil {
+		return err
+	}
+
+	opts.Confirm = writeConfirm(appOpts, yes)
+
+	return measures.SetMeasure(cmd.Context(), opts, appOpts, accessToken)
+}
+
+// applyMeasuresBookmark turns the stored server updatetime into a
+// --last-update filter and records the new one after a successful fetch.
+func applyMeasuresBookmark(appOpts app.Options, opts *measures.Options) error {
+	if opts.LastUpdate.LastUpdate != defaultInt64 ||
+		opts.TimeRange.Start != emptyString ||
+		opts.TimeRange.End != emptyString {
+		return app.NewExitError(app.ExitCodeUsage, errSinceLastConflict)
+	}
+
+	key := auth.BookmarkKey(measuresBookmarkName, opts.User.UserID)
+
+	bookmark, err := auth.LoadBookmark(appOpts, key)
+	if err != nil {
+		return fmt.Errorf("load bookmark: %w", err)
+	}
+
+	opts.LastUpdate.LastUpdate = bookmark
+	opts.RecordUpdate = func(updateTime int64) error {
+		return auth.SaveBookmark(appOpts, key, updateTime)
+	}
+
+	return nil
+}
+
+// addMeasureCompletions completes --type and --category of a measures
+// command.
+func addMeasureCompletions(cmd *cobra.Command) {
+	_ = cmd.RegisterFlagCompletionFunc(
+		"type",
+		completeChoiceList(measures.TypeChoices),
+	)
+	_ = cmd.RegisterFlagCompletionFunc(
+		"category",
+		completeChoices(measures.CategoryChoices),
+	)
+}
+
+func addGuidelineFlag(cmd *cobra.Command, guideline *string) {
+	cmd.Flags().StringVar(
+		guideline,
+		flagGuideline,
+		emptyString,
+		"blood pressure classification guideline: aha or esc "+
+			"(default aha)",
+	)
+	_ = cmd.RegisterFlagCompletionFunc(
+		flagGuideline,
+		completeValues(measures.GuidelineChoices...),
+	)
+}
+
+// applyGuideline validates --guideline and falls back to bp_guideline in
+// config when it is unset.
+func applyGuideline(
+	cmd *cobra.Command,
+	appOpts app.Options,
+	guideline *string,
+) error {
+	if !cmd.Flags().Changed(flagGuideline) {
+		settings, err := auth.ConfigValues(appOpts, configKeyBPGuideline)
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		*guideline = settings[configKeyBPGuideline]
+	}
+
+	parsed, err := measures.ParseGuideline(*guideline)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	*guideline = parsed
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/withings/mockserver"
+	"github.com/spf13/cobra"
+)
+
+func newMockServerCommand() *cobra.Command {
+	var opts mockserver.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	mockCmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Serve a mock Withings API for tests and demos",
+		Long: "Run a local HTTP server answering measure, activity, sleep, " +
+			"workout, heart, device, and token requests with generated " +
+			"data. Point --base-url at it to run commands without an " +
+			"account.",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return mockserver.Run(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	mockCmd.Flags().StringVar(
+		&opts.Listen,
+		"listen",
+		defaultMockAddr,
+		"address to listen on",
+	)
+
+	return mockCmd
+}
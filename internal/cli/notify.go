@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/services/notify"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configKeyUserID   = "user_id"
+	defaultNotifyHost = "127.0.0.1"
+	defaultNotifyPort = 8844
+	defaultNotifyPath = "/"
+	envNotifySecret   = "WITHINGS_NOTIFY_SECRET"
+)
+
+type notifyRunner func(
+	ctx context.Context,
+	opts notify.Options,
+	appOpts app.Options,
+	accessToken string,
+) error
+
+func newNotifyCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	notifyCmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Manage notification (webhook) subscriptions",
+	}
+
+	subscribeCmd, subscribeOpts := newNotifySubcommand(
+		"subscribe",
+		"Subscribe a callback URL to notifications",
+		notify.Subscribe,
+	)
+	addNotifyTargetFlags(subscribeCmd, subscribeOpts)
+	subscribeCmd.Flags().StringVar(
+		&subscribeOpts.Comment,
+		"comment",
+		emptyString,
+		"subscription comment",
+	)
+
+	listCmd, listOpts := newNotifySubcommand(
+		"list",
+		"List notification subscriptions",
+		notify.List,
+	)
+	addNotifyAppliFlag(listCmd, listOpts)
+
+	getCmd, getOpts := newNotifySubcommand(
+		"get",
+		"Show a notification subscription",
+		notify.Get,
+	)
+	addNotifyTargetFlags(getCmd, getOpts)
+
+	revokeCmd, revokeOpts := newNotifySubcommand(
+		"revoke",
+		"Revoke a notification subscription",
+		notify.Revoke,
+	)
+	addNotifyTargetFlags(revokeCmd, revokeOpts)
+
+	notifyCmd.AddCommand(
+		subscribeCmd,
+		listCmd,
+		getCmd,
+		revokeCmd,
+		newNotifyTestCommand(),
+		newNotifyListenCommand(),
+	)
+
+	return notifyCmd
+}
+
+func newNotifySubcommand(
+	use string,
+	short string,
+	run notifyRunner,
+) (*cobra.Command, *notify.Options) {
+	var opts notify.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			listed, err := writeRequestedChoices(appOpts, choiceFlag{
+				Value:   opts.Appli,
+				Choices: notify.AppliChoices,
+			})
+			if listed || err != nil {
+				return err
+			}
+
+			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			return run(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	return cmd, &opts
+}
+
+func addNotifyAppliFlag(cmd *cobra.Command, opts *notify.Options) {
+	cmd.Flags().StringVar(
+		&opts.Appli,
+		"appli",
+		emptyString,
+		"appli type (e.g. weight, sleep, numeric ID, or list)",
+	)
+	addNotifyAppliCompletion(cmd)
+}
+
+func addNotifyAppliCompletion(cmd *cobra.Command) {
+	_ = cmd.RegisterFlagCompletionFunc(
+		"appli",
+		completeChoices(notify.AppliChoices),
+	)
+}
+
+func addNotifyTargetFlags(cmd *cobra.Command, opts *notify.Options) {
+	addNotifyAppliFlag(cmd, opts)
+	cmd.Flags().StringVar(
+		&opts.CallbackURL,
+		"callback-url",
+		emptyString,
+		"notification callback URL",
+	)
+
+	_ = cmd.MarkFlagRequired("callback-url")
+	_ = cmd.MarkFlagRequired("appli")
+}
+
+// newNotifyTestCommand sends a synthetic callback; it needs no token, but
+// defaults --user-id to the logged-in account.
+func newNotifyTestCommand() *cobra.Command {
+	var opts notify.TestOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Send a synthetic notification to a callback URL",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			listed, err := writeRequestedChoices(appOpts, choiceFlag{
+				Value:   opts.Appli,
+				Choices: notify.AppliChoices,
+			})
+			if listed || err != nil {
+				return err
+			}
+
+			if opts.UserID == emptyString {
+				opts.UserID, err = loggedInUserID(appOpts)
+				if err != nil {
+					return err
+				}
+			}
+
+			return notify.SendTest(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	testCmd.Flags().StringVar(&opts.URL, "url", emptyString, "callback URL")
+	testCmd.Flags().StringVar(
+		&opts.Appli,
+		"appli",
+		"weight",
+		"appli type (e.g. weight, sleep, numeric ID, or list)",
+	)
+	addNotifyAppliCompletion(testCmd)
+	testCmd.Flags().StringVar(
+		&opts.UserID,
+		"user-id",
+		emptyString,
+		"user ID to send (default: the logged-in account)",
+	)
+
+	_ = testCmd.MarkFlagRequired("url")
+
+	return testCmd
+}
+
+func loggedInUserID(appOpts app.Options) (string, error) {
+	settings, err := auth.ConfigValues(appOpts, configKeyUserID)
+	if err != nil {
+		return emptyString, fmt.Errorf("load config: %w", err)
+	}
+
+	return settings[configKeyUserID], nil
+}
+
+func newNotifyListenCommand() *cobra.Command {
+	var (
+		opts notify.ListenOptions
+		host string
+		port int
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	listenCmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Receive notification callbacks as NDJSON or run a command",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			opts.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+			if opts.Secret == emptyString {
+				opts.Secret = os.Getenv(envNotifySecret)
+			}
+
+			return notify.Listen(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	listenCmd.Flags().StringVar(
+		&host,
+		"host",
+		defaultNotifyHost,
+		"interface to listen on (0.0.0.0 for all)",
+	)
+	listenCmd.Flags().IntVar(
+		&port,
+		"port",
+		defaultNotifyPort,
+		"port to listen on",
+	)
+	listenCmd.Flags().StringVar(
+		&opts.Path,
+		"path",
+		defaultNotifyPath,
+		"callback path",
+	)
+	listenCmd.Flags().StringVar(
+		&opts.Exec,
+		"exec",
+		emptyString,
+		"command to run per event (event JSON on stdin)",
+	)
+	listenCmd.Flags().StringVar(
+		&opts.Secret,
+		"secret",
+		emptyString,
+		"require ?secret=<value> on callbacks (or "+envNotifySecret+")",
+	)
+
+	return listenCmd
+}
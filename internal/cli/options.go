@@ -2,20 +2,81 @@ package cli
 
 import (
 	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/units"
+	"github.com/mreimbold/withings-cli/internal/withings"
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 type flagReader interface {
 	GetBool(name string) (bool, error)
 	GetCount(name string) (int, error)
+	GetInt(name string) (int, error)
+	GetDuration(name string) (time.Duration, error)
 	GetString(name string) (string, error)
+	Changed(name string) bool
 }
 
-const flagReadErrorFormat = "read --%s: %w"
+const (
+	flagReadErrorFormat   = "read --%s: %w"
+	configKeyCloud        = "cloud"
+	configKeyRetries      = "retries"
+	configKeyRetryBackoff = "retry_backoff"
+	configKeyTimeout      = "timeout"
+	configKeyCacheTTL     = "cache_ttl"
+	configKeyRateLimit    = "rate_limit_per_minute"
+	configKeyPageSize     = "page_size"
+	configKeyUnits        = "units"
+	configKeyReadOnly     = "read_only"
+	configKeyBaseURL      = "base_url"
+
+	configSectionThresholds = "thresholds"
+
+	envFixtures = "WITHINGS_FIXTURES"
+)
+
+// readCommandOptions reads the global options of cmd, with output going
+// where --output points.
+func readCommandOptions(cmd *cobra.Command) (app.Options, error) {
+	opts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+	opts.Stdout = cmd.Root().OutOrStdout()
+
+	return opts, err
+}
+
+// readCommandFlags is readCommandOptions without the active profile.
+func readCommandFlags(cmd *cobra.Command) (app.Options, error) {
+	opts, err := readGlobalFlags(cmd.Root().PersistentFlags())
+	opts.Stdout = cmd.Root().OutOrStdout()
+
+	return opts, err
+}
 
 func readGlobalOptions(flags *pflag.FlagSet) (app.Options, error) {
+	opts, err := readGlobalFlags(flags)
+	if err != nil {
+		return opts, err
+	}
+
+	err = applyConfigDefaults(flags, &opts)
+	if err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// readGlobalFlags reads global flags without consulting the active
+// profile, so profile commands keep working when it is missing.
+func readGlobalFlags(flags *pflag.FlagSet) (app.Options, error) {
 	opts := defaultGlobalOptions()
 
 	err := applyOutputFlags(flags, &opts)
@@ -33,15 +94,43 @@ func readGlobalOptions(flags *pflag.FlagSet) (app.Options, error) {
 
 func defaultGlobalOptions() app.Options {
 	return app.Options{
-		Verbose: defaultInt,
-		Quiet:   false,
-		JSON:    false,
-		Plain:   false,
-		NoColor: false,
-		NoInput: false,
-		Config:  emptyString,
-		Cloud:   emptyString,
-		BaseURL: emptyString,
+		Verbose:       defaultInt,
+		Quiet:         false,
+		JSON:          false,
+		Plain:         false,
+		NDJSON:        false,
+		Output:        emptyString,
+		NoColor:       false,
+		NoInput:       false,
+		Config:        emptyString,
+		Cloud:         emptyString,
+		BaseURL:       emptyString,
+		Profile:       emptyString,
+		OutputVersion: output.LatestContract,
+		Retries:       withings.DefaultRetries,
+		RetryBackoff:  withings.DefaultRetryBackoff,
+		Timeout:       withings.DefaultTimeout,
+		CacheTTL:      defaultDuration,
+		NoCache:       false,
+		RateLimit:     withings.DefaultRateLimit,
+		PageSize:      defaultInt,
+		Units:         units.Metric,
+		ReadOnly:      false,
+		DryRun:        false,
+		Timezone:      emptyString,
+		Fields:        emptyString,
+		Columns:       emptyString,
+		Template:      emptyString,
+		Markdown:      false,
+		Fixtures:      emptyString,
+		Record:        emptyString,
+		Replay:        emptyString,
+		Sort:          emptyString,
+		SortDesc:      false,
+		Redact:        false,
+		Thresholds:    nil,
+		Stdout:        nil,
+		Stderr:        nil,
 	}
 }
 
@@ -74,6 +163,13 @@ func applyOutputFlags(flags flagReader, opts *app.Options) error {
 
 	opts.Plain = plainOutput
 
+	ndjsonOutput, err := getFlagBool(flags, "ndjson")
+	if err != nil {
+		return err
+	}
+
+	opts.NDJSON = ndjsonOutput
+
 	noColor, err := getFlagBool(flags, "no-color")
 	if err != nil {
 		return err
@@ -88,6 +184,117 @@ func applyOutputFlags(flags flagReader, opts *app.Options) error {
 
 	opts.NoInput = noInput
 
+	redact, err := getFlagBool(flags, "redact")
+	if err != nil {
+		return err
+	}
+
+	opts.Redact = redact
+
+	outputVersion, err := getFlagInt(flags, "output-version")
+	if err != nil {
+		return err
+	}
+
+	opts.OutputVersion, err = output.ResolveContractVersion(outputVersion)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	unitSystem, err := getFlagString(flags, "units")
+	if err != nil {
+		return err
+	}
+
+	if !units.Valid(unitSystem) {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidUnits, unitSystem),
+		)
+	}
+
+	opts.Units = unitSystem
+
+	timezone, err := getFlagString(flags, "tz")
+	if err != nil {
+		return err
+	}
+
+	if timezone != emptyString {
+		_, err = output.ParseTimezone(timezone)
+		if err != nil {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidTimezone, timezone),
+			)
+		}
+	}
+
+	opts.Timezone = timezone
+
+	fields, err := getFlagString(flags, "fields")
+	if err != nil {
+		return err
+	}
+
+	opts.Fields = fields
+
+	err = applySortFlags(flags, opts)
+	if err != nil {
+		return err
+	}
+
+	return applyTemplateFlag(flags, opts)
+}
+
+func applySortFlags(flags flagReader, opts *app.Options) error {
+	sortColumn, err := getFlagString(flags, "sort")
+	if err != nil {
+		return err
+	}
+
+	desc, err := getFlagBool(flags, "desc")
+	if err != nil {
+		return err
+	}
+
+	if desc && sortColumn == emptyString {
+		return app.NewExitError(app.ExitCodeUsage, errDescWithoutSort)
+	}
+
+	opts.Sort = sortColumn
+	opts.SortDesc = desc
+
+	return nil
+}
+
+func applyTemplateFlag(flags flagReader, opts *app.Options) error {
+	spec, err := getFlagString(flags, "format")
+	if err != nil {
+		return err
+	}
+
+	if spec == emptyString {
+		return nil
+	}
+
+	if opts.JSON || opts.NDJSON {
+		return app.NewExitError(app.ExitCodeUsage, errFormatJSONConflict)
+	}
+
+	if spec == output.FormatMarkdown {
+		opts.Markdown = true
+
+		return nil
+	}
+
+	_, err = output.ParseTemplate(spec)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	opts.Template = spec
+
 	return nil
 }
 
@@ -113,6 +320,272 @@ func applyConfigFlags(flags flagReader, opts *app.Options) error {
 
 	opts.BaseURL = baseURL
 
+	err = applyReplayFlags(flags, opts)
+	if err != nil {
+		return err
+	}
+
+	profile, err := getFlagString(flags, "profile")
+	if err != nil {
+		return err
+	}
+
+	opts.Profile = profile
+
+	readOnly, err := getFlagBool(flags, "read-only")
+	if err != nil {
+		return err
+	}
+
+	opts.ReadOnly = readOnly
+
+	return applyRetryFlags(flags, opts)
+}
+
+// applyConfigDefaults fills settings that were not passed as flags from
+// the project config or the active profile.
+func applyConfigDefaults(flags flagReader, opts *app.Options) error {
+	settings, err := auth.ConfigValues(
+		*opts,
+		configKeyCloud,
+		configKeyRetries,
+		configKeyRetryBackoff,
+		configKeyTimeout,
+		configKeyCacheTTL,
+		configKeyRateLimit,
+		configKeyPageSize,
+		configKeyUnits,
+		configKeyReadOnly,
+		configKeyBaseURL,
+	)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	if cloud, ok := settings[configKeyCloud]; ok && !flags.Changed("cloud") {
+		opts.Cloud = cloud
+	}
+
+	if raw, ok := settings[configKeyRetries]; ok && !flags.Changed("retries") {
+		retries, err := strconv.Atoi(raw)
+		if err != nil || retries < defaultInt {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidRetriesConfig, raw),
+			)
+		}
+
+		opts.Retries = retries
+	}
+
+	raw, ok := settings[configKeyRetryBackoff]
+	if ok && !flags.Changed("retry-backoff") {
+		backoff, err := time.ParseDuration(raw)
+		if err != nil || backoff < defaultDuration {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidBackoffConfig, raw),
+			)
+		}
+
+		opts.RetryBackoff = backoff
+	}
+
+	raw, ok = settings[configKeyTimeout]
+	if ok && !flags.Changed("timeout") {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil || timeout < defaultDuration {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidTimeoutConfig, raw),
+			)
+		}
+
+		opts.Timeout = timeout
+	}
+
+	raw, ok = settings[configKeyCacheTTL]
+	if ok && !flags.Changed("cache-ttl") {
+		ttl, err := time.ParseDuration(raw)
+		if err != nil || ttl < defaultDuration {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidCacheTTLConfig, raw),
+			)
+		}
+
+		opts.CacheTTL = ttl
+	}
+
+	if raw, ok := settings[configKeyRateLimit]; ok {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < withings.NoRateLimit {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidRateLimitConfig, raw),
+			)
+		}
+
+		opts.RateLimit = limit
+	}
+
+	if raw, ok := settings[configKeyPageSize]; ok {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < defaultInt {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidPageSizeConfig, raw),
+			)
+		}
+
+		opts.PageSize = size
+	}
+
+	err = applyUnitsConfig(flags, settings, opts)
+	if err != nil {
+		return err
+	}
+
+	err = applyBaseURLConfig(flags, settings, opts)
+	if err != nil {
+		return err
+	}
+
+	err = applyThresholdsConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	return applyReadOnlyConfig(flags, settings, opts)
+}
+
+func applyUnitsConfig(
+	flags flagReader,
+	settings map[string]string,
+	opts *app.Options,
+) error {
+	raw, ok := settings[configKeyUnits]
+	if !ok || flags.Changed("units") {
+		return nil
+	}
+
+	if !units.Valid(raw) {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidUnitsConfig, raw),
+		)
+	}
+
+	opts.Units = raw
+
+	return nil
+}
+
+// applyBaseURLConfig points the client at the config base_url, e.g. a
+// team's shared mock server committed in the project config.
+func applyBaseURLConfig(
+	flags flagReader,
+	settings map[string]string,
+	opts *app.Options,
+) error {
+	raw, ok := settings[configKeyBaseURL]
+	if !ok || flags.Changed("base-url") {
+		return nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == emptyString ||
+		(parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidBaseURLConfig, raw),
+		)
+	}
+
+	opts.BaseURL = raw
+
+	return nil
+}
+
+// applyThresholdsConfig loads the [thresholds] rules that color table
+// cells and checks them up front.
+func applyThresholdsConfig(opts *app.Options) error {
+	thresholds, err := auth.ConfigSection(*opts, configSectionThresholds)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	for name, spec := range thresholds {
+		_, err = output.ParseThreshold(spec)
+		if err != nil {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w %s: %w", errInvalidThresholdConfig, name, err),
+			)
+		}
+	}
+
+	opts.Thresholds = thresholds
+
+	return nil
+}
+
+func applyRetryFlags(flags flagReader, opts *app.Options) error {
+	retries, err := getFlagInt(flags, "retries")
+	if err != nil {
+		return err
+	}
+
+	if retries < defaultInt {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidRetries)
+	}
+
+	opts.Retries = retries
+
+	backoff, err := flags.GetDuration("retry-backoff")
+	if err != nil {
+		return fmt.Errorf(flagReadErrorFormat, "retry-backoff", err)
+	}
+
+	if backoff < defaultDuration {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidRetryBackoff)
+	}
+
+	opts.RetryBackoff = backoff
+
+	timeout, err := flags.GetDuration("timeout")
+	if err != nil {
+		return fmt.Errorf(flagReadErrorFormat, "timeout", err)
+	}
+
+	if timeout < defaultDuration {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidTimeout)
+	}
+
+	opts.Timeout = timeout
+
+	return applyCacheFlags(flags, opts)
+}
+
+func applyCacheFlags(flags flagReader, opts *app.Options) error {
+	ttl, err := flags.GetDuration("cache-ttl")
+	if err != nil {
+		return fmt.Errorf(flagReadErrorFormat, "cache-ttl", err)
+	}
+
+	if ttl < defaultDuration {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidCacheTTL)
+	}
+
+	opts.CacheTTL = ttl
+
+	noCache, err := getFlagBool(flags, "no-cache")
+	if err != nil {
+		return err
+	}
+
+	opts.NoCache = noCache
+
 	return nil
 }
 
@@ -125,6 +598,15 @@ func getFlagCount(flags flagReader, name string) (int, error) {
 	return value, nil
 }
 
+func getFlagInt(flags flagReader, name string) (int, error) {
+	value, err := flags.GetInt(name)
+	if err != nil {
+		return defaultInt, fmt.Errorf(flagReadErrorFormat, name, err)
+	}
+
+	return value, nil
+}
+
 func getFlagBool(flags flagReader, name string) (bool, error) {
 	value, err := flags.GetBool(name)
 	if err != nil {
@@ -142,3 +624,73 @@ func getFlagString(flags flagReader, name string) (string, error) {
 
 	return value, nil
 }
+
+func applyReadOnlyConfig(
+	flags flagReader,
+	settings map[string]string,
+	opts *app.Options,
+) error {
+	raw, ok := settings[configKeyReadOnly]
+	if !ok || flags.Changed("read-only") {
+		return nil
+	}
+
+	readOnly, err := strconv.ParseBool(raw)
+	if err != nil {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidReadOnlyConfig, raw),
+		)
+	}
+
+	opts.ReadOnly = readOnly
+
+	return nil
+}
+
+// applyReplayFlags reads --fixtures, --record, and --replay, which each
+// replace the network and so exclude each other. A --replay cassette is
+// loaded up front so a bad file is a usage error.
+func applyReplayFlags(flags flagReader, opts *app.Options) error {
+	var (
+		err    error
+		values = map[string]*string{
+			"fixtures": &opts.Fixtures,
+			"record":   &opts.Record,
+			"replay":   &opts.Replay,
+		}
+		set int
+	)
+
+	for name, target := range values {
+		*target, err = getFlagString(flags, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Fixtures == emptyString {
+		opts.Fixtures = os.Getenv(envFixtures)
+	}
+
+	for _, value := range values {
+		if *value != emptyString {
+			set++
+		}
+	}
+
+	if set > 1 {
+		return app.NewExitError(app.ExitCodeUsage, errReplayConflict)
+	}
+
+	if opts.Replay == emptyString {
+		return nil
+	}
+
+	err = withings.CheckReplay(opts.Replay)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+const profileNameArgs = 1
+
+func newProfileCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	profileCmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Manage named config profiles",
+	}
+
+	profileCmd.AddCommand(newProfileListCommand())
+	profileCmd.AddCommand(newProfileCreateCommand())
+	profileCmd.AddCommand(newProfileUseCommand())
+
+	return profileCmd
+}
+
+func newProfileListCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List profiles and mark the active one",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.ListProfiles(appOpts)
+		},
+	}
+}
+
+func newProfileCreateCommand() *cobra.Command {
+	var opts auth.ProfileOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a named profile",
+		Args:  cobra.ExactArgs(profileNameArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			opts.Name = args[0]
+
+			return auth.CreateProfile(opts, appOpts)
+		},
+	}
+
+	cmd.Flags().StringVar(
+		&opts.Cloud,
+		"cloud",
+		emptyString,
+		"API cloud for this profile: eu or us",
+	)
+	cmd.Flags().StringVar(
+		&opts.ClientID,
+		"client-id",
+		emptyString,
+		"Withings client ID for this profile",
+	)
+	cmd.Flags().BoolVar(
+		&opts.Use,
+		"use",
+		false,
+		"make the new profile active",
+	)
+
+	return cmd
+}
+
+func newProfileUseCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Make a profile active (use 'default' to reset)",
+		Args:  cobra.ExactArgs(profileNameArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readCommandFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			return auth.UseProfile(args[0], appOpts)
+		},
+	}
+}
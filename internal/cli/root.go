@@ -6,6 +6,9 @@ import (
 	"os"
 
 	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/units"
+	"github.com/mreimbold/withings-cli/internal/withings"
 	"github.com/spf13/cobra"
 )
 
@@ -13,9 +16,20 @@ var version = "dev"
 
 // Execute runs the CLI and returns the exit code.
 func Execute() int {
-	rootCmd := newRootCommand()
+	var stdout output.StdoutFile
+
+	rootCmd := newStdoutRootCommand(&stdout)
 
 	err := rootCmd.Execute()
+	if errors.Is(err, withings.ErrDryRun) {
+		err = nil
+	}
+
+	closeErr := stdout.Close(err == nil)
+	if err == nil {
+		err = closeErr
+	}
+
 	if err == nil {
 		return app.ExitCodeSuccess
 	}
@@ -29,27 +43,70 @@ func Execute() int {
 		err = exitErr.Err
 	}
 
+	var apiErr *withings.APIError
+
+	if code == app.ExitCodeAPI && errors.As(err, &apiErr) {
+		code = apiErr.ExitCode()
+	}
+
+	if errors.Is(err, app.ErrSilent) {
+		return code
+	}
+
 	_, writeErr := fmt.Fprintln(os.Stderr, err)
 	if writeErr != nil {
 		return app.ExitCodeFailure
 	}
 
+	hint := cloudHint(rootCmd, err)
+	if hint != emptyString {
+		_, _ = fmt.Fprintln(os.Stderr, hint)
+	}
+
 	return code
 }
 
+// cloudHint resolves the cloud the failed command used; a --base-url
+// override means the cloud was not in play, so no hint is given.
+func cloudHint(rootCmd *cobra.Command, err error) string {
+	if !errors.Is(err, withings.ErrAPI) {
+		return emptyString
+	}
+
+	opts, optsErr := readGlobalOptions(rootCmd.PersistentFlags())
+	if optsErr != nil || opts.BaseURL != emptyString {
+		return emptyString
+	}
+
+	return withings.CloudHint(err, opts.Cloud)
+}
+
 func newRootCommand() *cobra.Command {
+	var stdout output.StdoutFile
+
+	return newStdoutRootCommand(&stdout)
+}
+
+// newStdoutRootCommand builds the root command; --output sends the
+// command's output into the given file, which the caller closes once the
+// command is done.
+func newStdoutRootCommand(stdout *output.StdoutFile) *cobra.Command {
 	var opts app.Options
 
-	rootCmd := buildRootCommand(&opts)
+	rootCmd := buildRootCommand(&opts, stdout)
 	rootCmd.Version = version
 
 	addRootCommands(rootCmd)
 	addRootFlags(rootCmd, &opts)
+	addRootCompletions(rootCmd)
 
 	return rootCmd
 }
 
-func buildRootCommand(opts *app.Options) *cobra.Command {
+func buildRootCommand(
+	opts *app.Options,
+	stdout *output.StdoutFile,
+) *cobra.Command {
 	//nolint:exhaustruct // Cobra command defaults are intentional.
 	return &cobra.Command{
 		Use: "withings",
@@ -57,8 +114,27 @@ func buildRootCommand(opts *app.Options) *cobra.Command {
 			"data and OAuth tokens from Withings CLI.",
 		SilenceUsage:  true,
 		SilenceErrors: true,
-		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
-			return validateGlobalOptions(opts)
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			err := validateGlobalOptions(opts)
+			if err != nil {
+				return err
+			}
+
+			cmd.SetContext(withings.WithTokenSource(
+				cmd.Context(),
+				flagTokenSource{flags: cmd.Flags()},
+			))
+
+			err = stdout.Open(opts.Output)
+			if err != nil {
+				return app.NewExitError(app.ExitCodeUsage, err)
+			}
+
+			if writer := stdout.Writer(); writer != nil {
+				cmd.Root().SetOut(writer)
+			}
+
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			return cmd.Help()
@@ -71,11 +147,15 @@ func validateGlobalOptions(opts *app.Options) error {
 		return app.NewExitError(app.ExitCodeUsage, errJSONPlainConflict)
 	}
 
+	if opts.NDJSON && (opts.JSON || opts.Plain) {
+		return app.NewExitError(app.ExitCodeUsage, errNDJSONConflict)
+	}
+
 	if opts.Quiet && opts.Verbose > noVerbosity {
 		return app.NewExitError(app.ExitCodeUsage, errQuietVerboseConflict)
 	}
 
-	if opts.Plain {
+	if opts.Plain || opts.NDJSON {
 		opts.NoColor = true
 	}
 
@@ -94,9 +174,28 @@ func addRootCommands(rootCmd *cobra.Command) {
 	rootCmd.AddCommand(newActivityCommand())
 	rootCmd.AddCommand(newAPICommand())
 	rootCmd.AddCommand(newAuthCommand())
+	rootCmd.AddCommand(newCardioCommand())
+	rootCmd.AddCommand(newConfigCommand())
+	rootCmd.AddCommand(newDevicesCommand())
+	rootCmd.AddCommand(newDoctorCommand())
+	rootCmd.AddCommand(newExportCommand())
+	rootCmd.AddCommand(newFeaturesCommand())
+	rootCmd.AddCommand(newFitnessCommand())
 	rootCmd.AddCommand(newHeartCommand())
 	rootCmd.AddCommand(newMeasuresCommand())
+	rootCmd.AddCommand(newMockServerCommand())
+	rootCmd.AddCommand(newNotifyCommand())
+	rootCmd.AddCommand(newProfileCommand())
+	rootCmd.AddCommand(newSchemaCommand())
+	rootCmd.AddCommand(newScheduleCommand())
+	rootCmd.AddCommand(newServeCommand())
+	rootCmd.AddCommand(newSignatureCommand())
 	rootCmd.AddCommand(newSleepCommand())
+	rootCmd.AddCommand(newStatusCommand())
+	rootCmd.AddCommand(newSummaryCommand())
+	rootCmd.AddCommand(newTUICommand())
+	rootCmd.AddCommand(newUserCommand())
+	rootCmd.AddCommand(newWorkoutsCommand())
 }
 
 func addRootFlags(rootCmd *cobra.Command, opts *app.Options) {
@@ -125,6 +224,69 @@ func addRootFlags(rootCmd *cobra.Command, opts *app.Options) {
 		false,
 		"stable line-based output (no tables, no colors)",
 	)
+	rootCmd.PersistentFlags().BoolVar(
+		&opts.NDJSON,
+		"ndjson",
+		false,
+		"one JSON object per row (newline-delimited)",
+	)
+	rootCmd.PersistentFlags().StringVarP(
+		&opts.Output,
+		"output",
+		"o",
+		emptyString,
+		"write rendered output to a file atomically (- for stdout)",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&opts.OutputVersion,
+		"output-version",
+		output.LatestContract,
+		"row output contract version (default: latest)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Units,
+		"units",
+		units.Metric,
+		"unit system for measures and activity: metric or imperial",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Timezone,
+		"tz",
+		emptyString,
+		"timezone for table timestamps: local, utc, or an IANA name",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Fields,
+		"fields",
+		emptyString,
+		"columns to show, in order (e.g., time,value,unit)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Sort,
+		"sort",
+		emptyString,
+		"sort rows by a column (e.g., time, value, type)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&opts.SortDesc,
+		"desc",
+		false,
+		"sort in descending order (with --sort)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Template,
+		"format",
+		emptyString,
+		"render each row with a Go template "+
+			"(e.g., '{{.Time}} {{.Value}}{{.Unit}}') or as markdown",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&opts.Redact,
+		"redact",
+		false,
+		"hash user/device IDs and emails and jitter values, "+
+			"for sharing output",
+	)
 	rootCmd.PersistentFlags().BoolVar(
 		&opts.NoColor,
 		"no-color",
@@ -155,4 +317,76 @@ func addRootFlags(rootCmd *cobra.Command, opts *app.Options) {
 		emptyString,
 		"override API base URL",
 	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Fixtures,
+		"fixtures",
+		emptyString,
+		"answer API requests from canned JSON files in this directory "+
+			"(env WITHINGS_FIXTURES)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Record,
+		"record",
+		emptyString,
+		"save sanitized API requests and responses to this cassette file",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Replay,
+		"replay",
+		emptyString,
+		"answer API requests from a cassette saved with --record",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&opts.Profile,
+		"profile",
+		emptyString,
+		"config profile (tokens, credentials, cloud)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&opts.ReadOnly,
+		"read-only",
+		false,
+		"refuse API calls that change data (reads still work)",
+	)
+	rootCmd.PersistentFlags().IntVar(
+		&opts.Retries,
+		"retries",
+		withings.DefaultRetries,
+		"retries for 5xx, 429, and network errors",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&opts.RetryBackoff,
+		"retry-backoff",
+		withings.DefaultRetryBackoff,
+		"initial retry delay (doubles per attempt)",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&opts.Timeout,
+		"timeout",
+		withings.DefaultTimeout,
+		"deadline per API request attempt (0 disables)",
+	)
+	rootCmd.PersistentFlags().DurationVar(
+		&opts.CacheTTL,
+		"cache-ttl",
+		defaultDuration,
+		"reuse cached API responses younger than this (0 disables)",
+	)
+	rootCmd.PersistentFlags().BoolVar(
+		&opts.NoCache,
+		"no-cache",
+		false,
+		"bypass the response cache for this run",
+	)
+}
+
+// addRootCompletions completes the values of enum-like global flags.
+func addRootCompletions(rootCmd *cobra.Command) {
+	_ = rootCmd.RegisterFlagCompletionFunc("cloud", completeValues("eu", "us"))
+	_ = rootCmd.RegisterFlagCompletionFunc(
+		"units",
+		completeValues(units.Metric, units.Imperial),
+	)
+	_ = rootCmd.RegisterFlagCompletionFunc("tz", completeValues("local", "utc"))
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
 }
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/services/schedule"
+	"github.com/spf13/cobra"
+)
+
+const scheduleNameArgs = 1
+
+func newScheduleCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run CLI commands on a schedule (systemd, cron, launchd)",
+	}
+
+	scheduleCmd.AddCommand(newScheduleInstallCommand())
+	scheduleCmd.AddCommand(newScheduleListCommand())
+	scheduleCmd.AddCommand(newScheduleRemoveCommand())
+
+	return scheduleCmd
+}
+
+func newScheduleInstallCommand() *cobra.Command {
+	var opts schedule.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "install (--daily HH:MM | --hourly) -- <command> [args...]",
+		Short: "Install a recurring run of a withings command",
+		Example: "  withings schedule install --daily 06:30 -- " +
+			"export --start 2d --dest ~/withings.sqlite --file-format sqlite",
+		Args: cobra.MinimumNArgs(scheduleNameArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			opts.Args = args
+
+			return schedule.Install(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	addScheduleFlags(
+		cmd,
+		&opts,
+		"print the files and commands without installing",
+	)
+
+	cmd.Flags().StringVar(
+		&opts.Daily,
+		"daily",
+		emptyString,
+		"run every day at HH:MM (local time)",
+	)
+	cmd.Flags().BoolVar(&opts.Hourly, "hourly", false, "run every hour")
+	cmd.Flags().StringVar(
+		&opts.Name,
+		"name",
+		emptyString,
+		"schedule name (default: the command, e.g. export)",
+	)
+	cmd.Flags().BoolVar(
+		&opts.Force,
+		"force",
+		false,
+		"replace a schedule with the same name",
+	)
+
+	return cmd
+}
+
+func newScheduleListCommand() *cobra.Command {
+	var opts schedule.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List schedules installed by withings",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			return schedule.List(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	addScheduleBackendFlag(cmd, &opts)
+
+	return cmd
+}
+
+func newScheduleRemoveCommand() *cobra.Command {
+	var opts schedule.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Disable and delete a schedule",
+		Args:  cobra.ExactArgs(scheduleNameArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			opts.Name = args[0]
+
+			return schedule.Remove(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	addScheduleFlags(
+		cmd,
+		&opts,
+		"print the files and commands without removing",
+	)
+
+	return cmd
+}
+
+func addScheduleFlags(cmd *cobra.Command, opts *schedule.Options, dry string) {
+	addScheduleBackendFlag(cmd, opts)
+	cmd.Flags().Bool(flagDryRun, false, dry)
+}
+
+func addScheduleBackendFlag(cmd *cobra.Command, opts *schedule.Options) {
+	cmd.Flags().StringVar(
+		&opts.Backend,
+		"backend",
+		schedule.BackendAuto,
+		"scheduler: auto, systemd, cron, or launchd",
+	)
+	_ = cmd.RegisterFlagCompletionFunc("backend", completeValues(
+		schedule.BackendAuto,
+		schedule.BackendSystemd,
+		schedule.BackendCron,
+		schedule.BackendLaunchd,
+	))
+}
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/services/schema"
+	"github.com/spf13/cobra"
+)
+
+func newSchemaCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	return &cobra.Command{
+		Use:   "schema [command]",
+		Short: "Describe row output columns (versioned contract)",
+		Example: "  withings schema\n" +
+			"  withings schema measures get --json",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			opts := schema.Options{Command: strings.Join(args, " ")}
+
+			return schema.Run(opts, appOpts)
+		},
+	}
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/services/notify"
+	"github.com/mreimbold/withings-cli/internal/services/serve"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultSubscriptionAppli = "weight"
+	defaultCheckInterval     = time.Hour
+	appliSeparator           = ","
+)
+
+func newServeCommand() *cobra.Command {
+	var (
+		opts   serve.Options
+		applis string
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve data commands as a local JSON HTTP API",
+		Long: "Run a local HTTP server exposing measures, activity, sleep, " +
+			"heart, workouts, devices, and status as JSON endpoints. " +
+			"Tokens are refreshed as needed.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applySubscriptionOptions(&opts.Subscriptions, applis)
+			if err != nil {
+				return err
+			}
+
+			_, err = auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			opts.Token = func(ctx context.Context) (string, error) {
+				return auth.EnsureAccessToken(ctx, appOpts)
+			}
+
+			return serve.Run(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	serveCmd.Flags().StringVar(
+		&opts.Listen,
+		"listen",
+		defaultServeAddr,
+		"address to listen on",
+	)
+	serveCmd.Flags().StringVar(
+		&opts.Subscriptions.CallbackURL,
+		"callback-url",
+		emptyString,
+		"keep this notification callback URL subscribed",
+	)
+	serveCmd.Flags().StringVar(
+		&applis,
+		"appli",
+		defaultSubscriptionAppli,
+		"comma-separated appli types to keep subscribed",
+	)
+	_ = serveCmd.RegisterFlagCompletionFunc(
+		"appli",
+		completeChoiceList(notify.AppliChoices),
+	)
+	serveCmd.Flags().DurationVar(
+		&opts.Subscriptions.Interval,
+		"check-interval",
+		defaultCheckInterval,
+		"how often to verify subscriptions",
+	)
+
+	return serveCmd
+}
+
+// applySubscriptionOptions validates the subscription monitor flags; they
+// only apply together with --callback-url.
+func applySubscriptionOptions(
+	opts *serve.SubscriptionOptions,
+	applis string,
+) error {
+	if opts.CallbackURL == emptyString {
+		return nil
+	}
+
+	callback, err := notify.ParseCallbackURL(opts.CallbackURL)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	opts.CallbackURL = callback
+
+	if opts.Interval <= defaultDuration {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidCheckInterval)
+	}
+
+	opts.Applis, err = parseApplis(applis)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	if len(opts.Applis) == defaultInt {
+		return app.NewExitError(app.ExitCodeUsage, errSubscriptionAppli)
+	}
+
+	return nil
+}
+
+func parseApplis(value string) ([]int, error) {
+	var applis []int
+
+	for raw := range strings.SplitSeq(value, appliSeparator) {
+		if strings.TrimSpace(raw) == emptyString {
+			continue
+		}
+
+		resolved, err := notify.ResolveAppli(raw)
+		if err != nil {
+			return nil, fmt.Errorf("resolve appli: %w", err)
+		}
+
+		appli, _ := strconv.Atoi(resolved)
+		applis = append(applis, appli)
+	}
+
+	return applis, nil
+}
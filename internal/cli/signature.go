@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+	"github.com/spf13/cobra"
+)
+
+func newSignatureCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	signatureCmd := &cobra.Command{
+		Use:   "signature",
+		Short: "Helpers for signed Withings services",
+	}
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	getNonceCmd := &cobra.Command{
+		Use:   "getnonce",
+		Short: "Request a nonce for one signed call",
+		Long: "Request a single-use nonce from the signature service, " +
+			"signed with the client ID and secret. `api call --sign` " +
+			"fetches one by itself.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			creds, err := auth.ClientCredentials(appOpts)
+			if err != nil {
+				return fmt.Errorf("resolve client credentials: %w", err)
+			}
+
+			nonce, err := withings.GetNonce(cmd.Context(), appOpts, creds)
+			if err != nil {
+				return fmt.Errorf("get nonce: %w", err)
+			}
+
+			if appOpts.JSON {
+				return output.WriteOutput(
+					appOpts,
+					map[string]string{"nonce": nonce},
+				)
+			}
+
+			return output.WriteOutput(appOpts, nonce)
+		},
+	}
+
+	signatureCmd.AddCommand(getNonceCmd)
+
+	return signatureCmd
+}
@@ -1,54 +1,242 @@
 package cli
 
 import (
-	"fmt"
+	"context"
 
-	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/services/sleep"
 	"github.com/spf13/cobra"
 )
 
 func newSleepCommand() *cobra.Command {
-	var opts sleep.Options
-
 	//nolint:exhaustruct // Cobra command defaults are intentional.
 	sleepCmd := &cobra.Command{
 		Use:   "sleep",
 		Short: "Sleep summaries",
 	}
+
+	sleepCmd.AddCommand(newSleepGetCommand())
+	sleepCmd.AddCommand(newSleepReportCommand())
+	sleepCmd.AddCommand(newSleepDetailCommand())
+	sleepCmd.AddCommand(newSleepScoreCommand())
+
+	return sleepCmd
+}
+
+func newSleepGetCommand() *cobra.Command {
+	var opts sleep.Options
+
 	//nolint:exhaustruct // Cobra command defaults are intentional.
 	sleepGetCmd := &cobra.Command{
 		Use:   "get",
 		Short: "Fetch sleep summaries",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			appOpts, err := readGlobalOptions(cmd.Root().PersistentFlags())
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyComputedColumns(cmd, &appOpts)
+			if err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceSleep,
+				actionGetSummary,
+			)
+			if err != nil {
+				return err
+			}
+
+			return runWatched(
+				cmd,
+				appOpts,
+				watchTarget{
+					LastUpdate: &opts.LastUpdate,
+					TimeRange:  &opts.TimeRange,
+					Date:       &opts.Date,
+					UpdateTime: nil,
+				},
+				func(
+					ctx context.Context,
+					appOpts app.Options,
+					accessToken string,
+				) error {
+					return sleep.Run(ctx, opts, appOpts, accessToken)
+				},
+			)
+		},
+	}
+
+	addSleepQueryFlags(sleepGetCmd, &opts)
+	addWatchFlag(sleepGetCmd)
+
+	sleepGetCmd.Flags().BoolVar(
+		&opts.IncludeNaps,
+		"include-naps",
+		false,
+		"include naps alongside main sleep",
+	)
+	sleepGetCmd.Flags().BoolVar(
+		&opts.NapsOnly,
+		"naps-only",
+		false,
+		"only show naps",
+	)
+
+	return sleepGetCmd
+}
+
+func newSleepReportCommand() *cobra.Command {
+	var opts sleep.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	sleepReportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Summarize sleep timing and regularity across a range",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceSleep,
+				actionGetSummary,
+			)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return sleep.Report(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addSleepQueryFlags(sleepReportCmd, &opts)
+
+	return sleepReportCmd
+}
+
+func newSleepScoreCommand() *cobra.Command {
+	var opts sleep.ScoreOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	sleepScoreCmd := &cobra.Command{
+		Use:   "score",
+		Short: "Trend sleep score, duration, wakeups, and efficiency by period",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceSleep,
+				actionGetSummary,
+			)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return sleep.Score(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addSleepQueryFlags(sleepScoreCmd, &opts.Options)
+
+	sleepScoreCmd.Flags().StringVar(
+		&opts.Last,
+		"last",
+		emptyString,
+		"span ending now instead of --start/--end (e.g., 30d, 12w)",
+	)
+	sleepScoreCmd.Flags().StringVar(
+		&opts.GroupBy,
+		"group-by",
+		sleep.GroupByWeek,
+		"period: day, week, or month",
+	)
+	_ = sleepScoreCmd.RegisterFlagCompletionFunc(
+		"group-by",
+		completeChoices(sleep.GroupByChoices),
+	)
+
+	return sleepScoreCmd
+}
+
+func newSleepDetailCommand() *cobra.Command {
+	var opts sleep.DetailOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	sleepDetailCmd := &cobra.Command{
+		Use:   "detail",
+		Short: "Fetch per-epoch sleep stages and vitals",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
 			if err != nil {
 				return err
 			}
 
-			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
 			if err != nil {
-				return fmt.Errorf("ensure access token: %w", err)
+				return err
 			}
 
-			return sleep.Run(cmd.Context(), opts, appOpts, accessToken)
+			return sleep.Detail(cmd.Context(), opts, appOpts, accessToken)
 		},
 	}
 
-	sleepCmd.AddCommand(sleepGetCmd)
+	addTimeRangeFlags(sleepDetailCmd, &opts.TimeRange)
+	addUserIDFlag(sleepDetailCmd, &opts.User)
+	addDryRunFlag(sleepDetailCmd)
 
-	addTimeRangeFlags(sleepGetCmd, &opts.TimeRange)
-	addDateFlag(sleepGetCmd, &opts.Date)
-	addPaginationFlags(sleepGetCmd, &opts.Pagination)
-	addUserIDFlag(sleepGetCmd, &opts.User)
-	addLastUpdateFlag(sleepGetCmd, &opts.LastUpdate)
+	sleepDetailCmd.Flags().StringVar(
+		&opts.Fields,
+		"data-fields",
+		emptyString,
+		"data fields (comma-separated, default hr,rr)",
+	)
 
-	sleepGetCmd.Flags().IntVar(
+	return sleepDetailCmd
+}
+
+func addSleepQueryFlags(cmd *cobra.Command, opts *sleep.Options) {
+	addTimeRangeFlags(cmd, &opts.TimeRange)
+	addDateFlag(cmd, &opts.Date)
+	addPaginationFlags(
+		cmd,
+		&opts.Pagination,
+		serviceSleep,
+		actionGetSummary,
+	)
+	addUserIDFlag(cmd, &opts.User)
+	addDryRunFlag(cmd)
+	addLastUpdateFlag(cmd, &opts.LastUpdate)
+
+	cmd.Flags().IntVar(
 		&opts.Model,
 		"model",
 		defaultInt,
 		"sleep model (if supported)",
 	)
-
-	return sleepCmd
 }
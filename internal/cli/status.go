@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/services/status"
+	"github.com/spf13/cobra"
+)
+
+const (
+	configKeyStatusSegments = "status_segments"
+	configKeyStatusASCII    = "status_ascii"
+)
+
+func newStatusCommand() *cobra.Command {
+	var (
+		opts     status.Options
+		segments string
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Latest weight, sleep score, and steps at a glance",
+		Long: "Show the latest weight, sleep score, and today's steps. " +
+			"Use --style oneline for tmux, waybar, or polybar segments.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyStatusOptions(cmd, appOpts, segments, &opts)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			return status.Run(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	statusCmd.Flags().StringVar(
+		&opts.Style,
+		"style",
+		status.StyleTable,
+		"output style: table or oneline",
+	)
+	statusCmd.Flags().StringVar(
+		&segments,
+		"segments",
+		emptyString,
+		"comma-separated segments to show (default "+
+			status.DefaultSegments+")",
+	)
+	statusCmd.Flags().BoolVar(
+		&opts.ASCII,
+		"ascii",
+		false,
+		"use segment names instead of glyphs in oneline output",
+	)
+
+	return statusCmd
+}
+
+// applyStatusOptions validates the status flags and fills --segments and
+// --ascii from status_segments and status_ascii in config when unset.
+func applyStatusOptions(
+	cmd *cobra.Command,
+	appOpts app.Options,
+	segments string,
+	opts *status.Options,
+) error {
+	if !status.ValidStyle(opts.Style) {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidStatusStyle, opts.Style),
+		)
+	}
+
+	settings, err := auth.ConfigValues(
+		appOpts,
+		configKeyStatusSegments,
+		configKeyStatusASCII,
+	)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	raw, ok := settings[configKeyStatusASCII]
+	if ok && !cmd.Flags().Changed("ascii") {
+		opts.ASCII, err = strconv.ParseBool(raw)
+		if err != nil {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %q", errInvalidStatusASCII, raw),
+			)
+		}
+	}
+
+	if segments == emptyString {
+		segments = status.DefaultSegments
+		if configured, ok := settings[configKeyStatusSegments]; ok {
+			segments = configured
+		}
+	}
+
+	opts.Segments, err = status.ParseSegments(segments)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/services/summary"
+	"github.com/spf13/cobra"
+)
+
+func newSummaryCommand() *cobra.Command {
+	var (
+		opts  summary.Options
+		week  bool
+		month bool
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	summaryCmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Weekly or monthly report of activity, sleep, and weight",
+		Long: "Summarize the last complete week (Monday to Sunday) or " +
+			"calendar month with totals and averages, each compared " +
+			"with the period before.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applySummaryOptions(appOpts, week, month, &opts)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			return summary.Run(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	summaryCmd.Flags().BoolVar(
+		&week,
+		"week",
+		false,
+		"summarize the last complete week (default)",
+	)
+	summaryCmd.Flags().BoolVar(
+		&month,
+		"month",
+		false,
+		"summarize the last complete calendar month",
+	)
+	summaryCmd.Flags().BoolVar(
+		&opts.Markdown,
+		"markdown",
+		false,
+		"print the report as a markdown table",
+	)
+
+	return summaryCmd
+}
+
+// applySummaryOptions picks the period and rejects --markdown alongside
+// the other output modes.
+func applySummaryOptions(
+	appOpts app.Options,
+	week bool,
+	month bool,
+	opts *summary.Options,
+) error {
+	if week && month {
+		return app.NewExitError(app.ExitCodeUsage, errSummaryPeriod)
+	}
+
+	opts.Period = summary.PeriodWeek
+	if month {
+		opts.Period = summary.PeriodMonth
+	}
+
+	if opts.Markdown && (appOpts.JSON || appOpts.Plain || appOpts.NDJSON ||
+		appOpts.Template != emptyString) {
+		return app.NewExitError(app.ExitCodeUsage, errSummaryMarkdown)
+	}
+
+	return nil
+}
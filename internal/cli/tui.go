@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/features"
+	"github.com/mreimbold/withings-cli/internal/services/tui"
+	"github.com/spf13/cobra"
+)
+
+func newTUICommand() *cobra.Command {
+	var opts tui.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	tuiCmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Interactive dashboard of weight, sleep, activity, and heart",
+		Long: "Show an interactive terminal dashboard with tabs for the " +
+			"weight trend, last night's sleep, today's activity, and " +
+			"recent heart data. Press 1-4 or tab to switch tabs, +/- to " +
+			"change the range, r to refresh, and q to quit. Experimental: " +
+			"enable it with `withings config set experimental.tui true` " +
+			"or " + features.EnvExperimental + "=tui.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readCommandOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = features.Require(appOpts, features.TUI)
+			if err != nil {
+				return err
+			}
+
+			_, err = auth.EnsureAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return fmt.Errorf("ensure access token: %w", err)
+			}
+
+			opts.Token = func(ctx context.Context) (string, error) {
+				return auth.EnsureAccessToken(ctx, appOpts)
+			}
+
+			return tui.Run(cmd.Context(), opts, appOpts)
+		},
+	}
+
+	tuiCmd.Flags().StringVar(
+		&opts.Range,
+		"range",
+		tui.DefaultRange,
+		"initial range of the weight and heart tabs ("+
+			strings.Join(tui.Ranges, ", ")+")",
+	)
+	_ = tuiCmd.RegisterFlagCompletionFunc(
+		"range",
+		completeValues(tui.Ranges...),
+	)
+
+	return tuiCmd
+}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"github.com/mreimbold/withings-cli/internal/services/goals"
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/spf13/cobra"
+)
+
+const (
+	heightValueArgs   = 1
+	measureTypeHeight = "height"
+)
+
+func newUserCommand() *cobra.Command {
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "User profile data (height, goals)",
+	}
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	heightCmd := &cobra.Command{
+		Use:   "height",
+		Short: "Read or record body height (used for BMI)",
+	}
+
+	heightCmd.AddCommand(newUserHeightGetCommand())
+	heightCmd.AddCommand(newUserHeightSetCommand())
+	userCmd.AddCommand(heightCmd)
+	userCmd.AddCommand(newUserGoalsCommand())
+
+	return userCmd
+}
+
+func newUserGoalsCommand() *cobra.Command {
+	var opts goals.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "goals",
+		Short: "Show the steps, sleep, and weight goals",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return goals.Run(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addUserIDFlag(cmd, &opts.User)
+	addDryRunFlag(cmd)
+
+	return cmd
+}
+
+func newUserHeightGetCommand() *cobra.Command {
+	var opts measures.HeightOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "get",
+		Short: "Show the most recent recorded height",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return measures.GetHeight(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addUserIDFlag(cmd, &opts.User)
+	addDryRunFlag(cmd)
+
+	return cmd
+}
+
+func newUserHeightSetCommand() *cobra.Command {
+	var (
+		opts measures.SetOptions
+		yes  bool
+	)
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	cmd := &cobra.Command{
+		Use:   "set <height>",
+		Short: "Record a height in m (1.80) or cm (180cm)",
+		Args:  cobra.ExactArgs(heightValueArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			opts.Type = measureTypeHeight
+			opts.Category = measureCategoryReal
+			opts.Value = args[0]
+			opts.Confirm = writeConfirm(appOpts, yes)
+
+			return measures.SetMeasure(
+				cmd.Context(),
+				opts,
+				appOpts,
+				accessToken,
+			)
+		},
+	}
+
+	addUserIDFlag(cmd, &opts.User)
+	addDryRunFlag(cmd)
+	addYesFlag(cmd, &yes)
+
+	return cmd
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+	"github.com/spf13/cobra"
+)
+
+const flagWatch = "watch"
+
+// watchTarget points at the filters of a data command that --watch
+// rewrites between polls. Date is nil for commands without --date.
+// UpdateTime receives the server updatetime of each poll; it is nil for
+// commands whose responses carry none.
+type watchTarget struct {
+	LastUpdate *params.LastUpdate
+	TimeRange  *params.TimeRange
+	Date       *params.Date
+	UpdateTime *int64
+}
+
+// fetchFunc runs one request of a data command and writes its output.
+type fetchFunc func(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+) error
+
+// watchFeed prints the rows of a poll that the previous poll with rows
+// did not print; the plain header is printed once.
+type watchFeed struct {
+	header bool
+	shown  bool
+	seen   map[string]struct{}
+}
+
+func addWatchFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration(
+		flagWatch,
+		defaultDuration,
+		"poll every interval (e.g. 5m) and print only new rows",
+	)
+}
+
+// runWatched runs fetch once, or with --watch polls it until interrupted,
+// asking each later poll only for data updated since the previous one.
+func runWatched(
+	cmd *cobra.Command,
+	appOpts app.Options,
+	target watchTarget,
+	fetch fetchFunc,
+) error {
+	interval, err := cmd.Flags().GetDuration(flagWatch)
+	if err != nil {
+		return fmt.Errorf(flagReadErrorFormat, flagWatch, err)
+	}
+
+	if interval == defaultDuration {
+		accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+		if err != nil {
+			return err
+		}
+
+		return fetch(cmd.Context(), appOpts, accessToken)
+	}
+
+	err = validateWatch(appOpts, interval)
+	if err != nil {
+		return err
+	}
+
+	if !appOpts.NDJSON && appOpts.Template == emptyString {
+		// Table columns cannot stay aligned across polls.
+		appOpts.Plain = true
+	}
+
+	ctx, stop := signal.NotifyContext(
+		cmd.Context(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
+	feed := newWatchFeed(appOpts)
+
+	for {
+		started := time.Now()
+
+		err = pollOnce(ctx, appOpts, feed, fetch)
+
+		var exitErr *app.ExitError
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.As(err, &exitErr) && exitErr.Code == app.ExitCodeNetwork:
+			// Keep the filters so the next poll covers the missed one.
+			err = output.WriteWarning(appOpts, "warning: "+err.Error())
+		case err == nil:
+			target.advance(started)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// advance narrows the filters to data updated since the poll: the
+// response updatetime, else the server clock when the poll started.
+// Network failures do not advance them, so a feed survives a dropped
+// connection without losing rows.
+func (t watchTarget) advance(started time.Time) {
+	since := serverTime(started).Unix()
+	if t.UpdateTime != nil && *t.UpdateTime != defaultInt64 {
+		since = *t.UpdateTime
+		*t.UpdateTime = defaultInt64
+	}
+
+	t.LastUpdate.LastUpdate = since
+	t.TimeRange.Start = emptyString
+	t.TimeRange.End = emptyString
+
+	if t.Date != nil {
+		t.Date.Date = emptyString
+	}
+}
+
+// serverTime shifts local by the measured skew of the API clock, so
+// --last-update compares against server time.
+func serverTime(local time.Time) time.Time {
+	skew, ok := withings.ClockSkew()
+	if !ok {
+		return local
+	}
+
+	return local.Add(skew)
+}
+
+// recordUpdateTime stores each server updatetime in target for the next
+// poll before handing it to next, if any.
+func recordUpdateTime(
+	target *int64,
+	next func(updateTime int64) error,
+) func(updateTime int64) error {
+	return func(updateTime int64) error {
+		*target = updateTime
+		if next == nil {
+			return nil
+		}
+
+		return next(updateTime)
+	}
+}
+
+func validateWatch(appOpts app.Options, interval time.Duration) error {
+	if interval < defaultDuration {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidWatch)
+	}
+
+	if appOpts.JSON || appOpts.DryRun {
+		return app.NewExitError(app.ExitCodeUsage, errWatchConflict)
+	}
+
+	return nil
+}
+
+// pollOnce fetches and prints the new rows.
+func pollOnce(
+	ctx context.Context,
+	appOpts app.Options,
+	feed *watchFeed,
+	fetch fetchFunc,
+) error {
+	accessToken, err := dataAccessToken(ctx, appOpts)
+	if err != nil {
+		return err
+	}
+
+	lines, err := output.CaptureLines(
+		appOpts,
+		func(captured app.Options) error {
+			return fetch(ctx, captured, accessToken)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return writeLines(appOpts, feed.filter(lines))
+}
+
+func newWatchFeed(appOpts app.Options) *watchFeed {
+	return &watchFeed{
+		header: appOpts.Plain && !appOpts.NDJSON &&
+			appOpts.Template == emptyString,
+		shown: false,
+		seen:  map[string]struct{}{},
+	}
+}
+
+// filter drops the rows the previous poll with rows already printed; a
+// row updated on the server differs from its old line and is printed
+// again. Only that poll is remembered, since later polls only return
+// data updated after it.
+func (f *watchFeed) filter(lines []string) []string {
+	var fresh []string
+
+	if f.header && len(lines) > 0 {
+		if !f.shown {
+			fresh = append(fresh, lines[0])
+			f.shown = true
+		}
+
+		lines = lines[1:]
+	}
+
+	if len(lines) == 0 {
+		return fresh
+	}
+
+	current := make(map[string]struct{}, len(lines))
+
+	for _, line := range lines {
+		_, printed := f.seen[line]
+		_, repeated := current[line]
+		current[line] = struct{}{}
+
+		if !printed && !repeated {
+			fresh = append(fresh, line)
+		}
+	}
+
+	f.seen = current
+
+	return fresh
+}
+
+func writeLines(appOpts app.Options, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	err := output.WriteLines(appOpts, lines)
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	return nil
+}
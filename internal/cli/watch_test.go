@@ -0,0 +1,83 @@
+//nolint:testpackage // test unexported helpers.
+package cli
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const watchTestUpdateTime = 1791536400
+
+// TestWatchFeedFilter prints the header once and only the rows the
+// previous poll with rows did not print.
+func TestWatchFeedFilter(t *testing.T) {
+	t.Parallel()
+
+	//nolint:exhaustruct // Only the output mode matters.
+	feed := newWatchFeed(app.Options{Plain: true})
+
+	polls := [][]string{
+		{"time\tvalue", "t1\t80"},
+		{"time\tvalue"},
+		{"time\tvalue", "t1\t80", "t2\t81", "t1\t79.8"},
+		{"time\tvalue", "t3\t82"},
+		{"time\tvalue", "t1\t80", "t3\t82"},
+	}
+	want := [][]string{
+		{"time\tvalue", "t1\t80"},
+		nil,
+		{"t2\t81", "t1\t79.8"},
+		{"t3\t82"},
+		{"t1\t80"},
+	}
+
+	for index, poll := range polls {
+		got := feed.filter(poll)
+		if !slices.Equal(got, want[index]) {
+			t.Fatalf("poll %d got %q want %q", index, got, want[index])
+		}
+	}
+
+	//nolint:exhaustruct // Only the output mode matters.
+	ndjson := newWatchFeed(app.Options{NDJSON: true})
+
+	got := ndjson.filter([]string{`{"value":80}`, `{"value":80}`})
+	if !slices.Equal(got, []string{`{"value":80}`}) {
+		t.Fatalf("ndjson got %q", got)
+	}
+}
+
+// TestWatchTargetAdvance prefers the response updatetime over the poll
+// start and clears the range filters.
+func TestWatchTargetAdvance(t *testing.T) {
+	t.Parallel()
+
+	lastUpdate := params.LastUpdate{LastUpdate: defaultInt64}
+	timeRange := params.TimeRange{Start: "7d", End: "now"}
+	updateTime := int64(watchTestUpdateTime)
+	target := watchTarget{
+		LastUpdate: &lastUpdate,
+		TimeRange:  &timeRange,
+		Date:       nil,
+		UpdateTime: &updateTime,
+	}
+
+	target.advance(time.Now())
+
+	if lastUpdate.LastUpdate != watchTestUpdateTime ||
+		timeRange.Start != emptyString || timeRange.End != emptyString ||
+		updateTime != defaultInt64 {
+		t.Fatalf("got %d, %+v, %d", lastUpdate.LastUpdate, timeRange,
+			updateTime)
+	}
+
+	target.advance(time.Now())
+
+	if lastUpdate.LastUpdate == watchTestUpdateTime {
+		t.Fatal("advance kept the previous updatetime")
+	}
+}
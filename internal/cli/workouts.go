@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/services/workouts"
+	"github.com/spf13/cobra"
+)
+
+func newWorkoutsCommand() *cobra.Command {
+	var opts workouts.Options
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	workoutsCmd := &cobra.Command{
+		Use:   "workouts",
+		Short: "Workout sessions",
+	}
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	workoutsListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List workout sessions",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			err = applyComputedColumns(cmd, &appOpts)
+			if err != nil {
+				return err
+			}
+
+			listed, err := writeRequestedChoices(appOpts, choiceFlag{
+				Value:   opts.Category,
+				Choices: workouts.CategoryChoices,
+			})
+			if listed || err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Pagination,
+				serviceMeasureV2,
+				actionGetWorkouts,
+			)
+			if err != nil {
+				return err
+			}
+
+			return runWatched(
+				cmd,
+				appOpts,
+				watchTarget{
+					LastUpdate: &opts.LastUpdate,
+					TimeRange:  &opts.TimeRange,
+					Date:       &opts.Date,
+					UpdateTime: nil,
+				},
+				func(
+					ctx context.Context,
+					appOpts app.Options,
+					accessToken string,
+				) error {
+					return workouts.Run(ctx, opts, appOpts, accessToken)
+				},
+			)
+		},
+	}
+
+	workoutsCmd.AddCommand(workoutsListCmd)
+	workoutsCmd.AddCommand(newWorkoutsSummaryCommand())
+	workoutsCmd.AddCommand(newWorkoutsExportCommand())
+
+	addWorkoutsQueryFlags(workoutsListCmd, &opts)
+	addWatchFlag(workoutsListCmd)
+
+	return workoutsCmd
+}
+
+func newWorkoutsSummaryCommand() *cobra.Command {
+	var opts workouts.SummaryOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	summaryCmd := &cobra.Command{
+		Use:   "summary",
+		Short: "Aggregate workout counts, duration, and calories",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			listed, err := writeRequestedChoices(appOpts, choiceFlag{
+				Value:   opts.Query.Category,
+				Choices: workouts.CategoryChoices,
+			})
+			if listed || err != nil {
+				return err
+			}
+
+			err = applyPagination(
+				cmd.Flags(),
+				appOpts,
+				&opts.Query.Pagination,
+				serviceMeasureV2,
+				actionGetWorkouts,
+			)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return workouts.Summary(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addWorkoutsQueryFlags(summaryCmd, &opts.Query)
+
+	summaryCmd.Flags().BoolVar(
+		&opts.ByType,
+		"by-type",
+		false,
+		"group totals by workout category",
+	)
+
+	return summaryCmd
+}
+
+func newWorkoutsExportCommand() *cobra.Command {
+	var opts workouts.ExportOptions
+
+	//nolint:exhaustruct // Cobra command defaults are intentional.
+	exportCmd := &cobra.Command{
+		Use:   "export --id <id> --file-format <fit|tcx|gpx>",
+		Short: "Export a workout with its heart rate as FIT, TCX, or GPX",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			appOpts, err := readDataOptions(cmd)
+			if err != nil {
+				return err
+			}
+
+			accessToken, err := dataAccessToken(cmd.Context(), appOpts)
+			if err != nil {
+				return err
+			}
+
+			return workouts.Export(cmd.Context(), opts, appOpts, accessToken)
+		},
+	}
+
+	addTimeRangeFlags(exportCmd, &opts.Query.TimeRange)
+	addDateFlag(exportCmd, &opts.Query.Date)
+	addUserIDFlag(exportCmd, &opts.Query.User)
+	addDryRunFlag(exportCmd)
+
+	exportCmd.Flags().Int64Var(
+		&opts.ID,
+		"id",
+		defaultInt64,
+		"workout ID (see the id column of workouts list)",
+	)
+	exportCmd.Flags().StringVar(
+		&opts.Format,
+		"file-format",
+		emptyString,
+		"export format: fit, tcx, or gpx (default from --dest extension)",
+	)
+	exportCmd.Flags().StringVar(
+		&opts.Output,
+		"dest",
+		emptyString,
+		"write to file instead of stdout",
+	)
+	_ = exportCmd.RegisterFlagCompletionFunc(
+		"file-format",
+		completeChoices(workouts.FormatChoices),
+	)
+	_ = exportCmd.MarkFlagRequired("id")
+
+	return exportCmd
+}
+
+func addWorkoutsQueryFlags(cmd *cobra.Command, opts *workouts.Options) {
+	addTimeRangeFlags(cmd, &opts.TimeRange)
+	addDateFlag(cmd, &opts.Date)
+	addPaginationFlags(
+		cmd,
+		&opts.Pagination,
+		serviceMeasureV2,
+		actionGetWorkouts,
+	)
+	addUserIDFlag(cmd, &opts.User)
+	addDryRunFlag(cmd)
+	addLastUpdateFlag(cmd, &opts.LastUpdate)
+
+	cmd.Flags().StringVar(
+		&opts.Category,
+		"category",
+		emptyString,
+		"workout categories (e.g., run,bicycling, numeric IDs, or list)",
+	)
+	_ = cmd.RegisterFlagCompletionFunc(
+		"category",
+		completeChoiceList(workouts.CategoryChoices),
+	)
+}
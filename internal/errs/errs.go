@@ -6,11 +6,13 @@ import "errors"
 var (
 	// ErrInvalidStartTime indicates an invalid start time argument.
 	ErrInvalidStartTime = errors.New(
-		"invalid --start (expected RFC3339, YYYY-MM-DD, or epoch)",
+		"invalid --start (expected RFC3339, YYYY-MM-DD, epoch, or " +
+			"relative time such as 7d)",
 	)
 	// ErrInvalidEndTime indicates an invalid end time argument.
 	ErrInvalidEndTime = errors.New(
-		"invalid --end (expected RFC3339, YYYY-MM-DD, or epoch)",
+		"invalid --end (expected RFC3339, YYYY-MM-DD, epoch, or " +
+			"relative time such as 7d)",
 	)
 	// ErrInvalidLastUpdate indicates an invalid last-update argument.
 	ErrInvalidLastUpdate = errors.New(
@@ -21,13 +23,25 @@ var (
 		"--last-update cannot be combined with --start or --end",
 	)
 	// ErrInvalidDate indicates an invalid date argument.
-	ErrInvalidDate = errors.New("invalid --date (expected YYYY-MM-DD)")
-	// ErrInvalidTimeFormat indicates a time parse failure.
-	ErrInvalidTimeFormat = errors.New("expected RFC3339 or YYYY-MM-DD")
+	ErrInvalidDate = errors.New(
+		"invalid --date (expected YYYY-MM-DD or relative day such as " +
+			"yesterday)",
+	)
+	// ErrInvalidTimeFormat indicates a time parse failure. Callers wrap
+	// it in a flag error that already lists the accepted formats.
+	ErrInvalidTimeFormat = errors.New("unrecognized time value")
 	// ErrDateRangeConflict indicates --date used with --start or --end.
 	ErrDateRangeConflict = errors.New(
 		"--date cannot be combined with --start or --end",
 	)
+	// ErrInvalidSpan indicates an invalid window length such as --chunk.
+	ErrInvalidSpan = errors.New(
+		"expected a positive span such as 12h, 90d, or 2w",
+	)
 	// ErrEmptyTimeValue indicates a required time value is empty.
 	ErrEmptyTimeValue = errors.New("empty time value")
+	// ErrEndBeforeStart indicates an --end that is not after --start.
+	ErrEndBeforeStart = errors.New("--end must be after --start")
+	// ErrInvalidDataField indicates an unknown --data-fields entry.
+	ErrInvalidDataField = errors.New("invalid --data-fields entry")
 )
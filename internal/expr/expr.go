@@ -0,0 +1,319 @@
+// Package expr evaluates small arithmetic expressions over named values,
+// such as "weight / (height ^ 2)".
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrSyntax indicates an expression that cannot be parsed.
+	ErrSyntax = errors.New("invalid expression")
+	// ErrUnknownName indicates a name without a value.
+	ErrUnknownName = errors.New("unknown name")
+	// ErrNotNumber indicates a value that is not a number.
+	ErrNotNumber = errors.New("not a number")
+	// ErrUndefined indicates a result that is not a finite number, such
+	// as a division by zero.
+	ErrUndefined = errors.New("undefined result")
+
+	errUnexpectedEnd   = errors.New("unexpected end")
+	errMissingParen    = errors.New("missing )")
+	errUnexpectedToken = errors.New("unexpected")
+)
+
+const floatBitSize = 64
+
+// Lookup returns the raw value bound to name.
+type Lookup func(name string) (string, bool)
+
+// Expr is a parsed expression.
+type Expr struct {
+	root  node
+	names []string
+}
+
+type node func(values Lookup) (float64, error)
+
+// Parse parses an expression of numbers, names, parentheses, unary minus,
+// and the operators + - * / ^ (right-associative power).
+func Parse(source string) (*Expr, error) {
+	parser := &parser{
+		tokens:   tokenize(source),
+		position: 0,
+		names:    nil,
+	}
+
+	root, err := parser.parseSum()
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrSyntax, source, err)
+	}
+
+	if !parser.done() {
+		return nil, fmt.Errorf(
+			"%w %q: %w %q",
+			ErrSyntax,
+			source,
+			errUnexpectedToken,
+			parser.peek(),
+		)
+	}
+
+	return &Expr{root: root, names: parser.names}, nil
+}
+
+// Names returns the names the expression refers to, in order of first use.
+func (e *Expr) Names() []string {
+	return e.names
+}
+
+// Eval evaluates the expression with names resolved by values.
+func (e *Expr) Eval(values Lookup) (float64, error) {
+	result, err := e.root(values)
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, ErrUndefined
+	}
+
+	return result, nil
+}
+
+type parser struct {
+	tokens   []string
+	position int
+	names    []string
+}
+
+func (p *parser) done() bool {
+	return p.position >= len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	if p.done() {
+		return ""
+	}
+
+	return p.tokens[p.position]
+}
+
+func (p *parser) next() string {
+	token := p.peek()
+	p.position++
+
+	return token
+}
+
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "+" || p.peek() == "-" {
+		operator := p.next()
+
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary(operator, left, right)
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "*" || p.peek() == "/" {
+		operator := p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = binary(operator, left, right)
+	}
+
+	return left, nil
+}
+
+// parsePower binds tighter than unary minus, so -2^2 is -4 and 2^-1 is
+// 0.5.
+func (p *parser) parsePower() (node, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek() != "^" {
+		return base, nil
+	}
+
+	p.next()
+
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return binary("^", base, exponent), nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek() != "-" {
+		return p.parsePower()
+	}
+
+	p.next()
+
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(values Lookup) (float64, error) {
+		value, err := operand(values)
+
+		return -value, err
+	}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	token := p.next()
+
+	switch {
+	case token == "":
+		return nil, errUnexpectedEnd
+	case token == "(":
+		inner, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.next() != ")" {
+			return nil, errMissingParen
+		}
+
+		return inner, nil
+	case isNameStart(rune(token[0])):
+		p.names = append(p.names, token)
+
+		return name(token), nil
+	}
+
+	number, err := strconv.ParseFloat(token, floatBitSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", errUnexpectedToken, token)
+	}
+
+	return func(Lookup) (float64, error) { return number, nil }, nil
+}
+
+func name(token string) node {
+	return func(values Lookup) (float64, error) {
+		raw, ok := values(token)
+		if !ok {
+			return 0, fmt.Errorf("%w %q", ErrUnknownName, token)
+		}
+
+		value, err := strconv.ParseFloat(
+			strings.TrimSpace(raw),
+			floatBitSize,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s=%q", ErrNotNumber, token, raw)
+		}
+
+		return value, nil
+	}
+}
+
+func binary(operator string, left, right node) node {
+	apply := operations[operator]
+
+	return func(values Lookup) (float64, error) {
+		leftValue, err := left(values)
+		if err != nil {
+			return 0, err
+		}
+
+		rightValue, err := right(values)
+		if err != nil {
+			return 0, err
+		}
+
+		return apply(leftValue, rightValue), nil
+	}
+}
+
+//nolint:gochecknoglobals // Static operator table.
+var operations = map[string]func(float64, float64) float64{
+	"+": func(a, b float64) float64 { return a + b },
+	"-": func(a, b float64) float64 { return a - b },
+	"*": func(a, b float64) float64 { return a * b },
+	"/": func(a, b float64) float64 { return a / b },
+	"^": math.Pow,
+}
+
+// tokenize splits source into numbers, names, and single-rune operators.
+func tokenize(source string) []string {
+	var tokens []string
+
+	runes := []rune(source)
+	for index := 0; index < len(runes); {
+		current := runes[index]
+
+		switch {
+		case unicode.IsSpace(current):
+			index++
+		case isNameStart(current):
+			end := scan(runes, index, isNamePart)
+			tokens = append(tokens, string(runes[index:end]))
+			index = end
+		case isNumberPart(current):
+			end := scan(runes, index, isNumberPart)
+			tokens = append(tokens, string(runes[index:end]))
+			index = end
+		default:
+			tokens = append(tokens, string(current))
+			index++
+		}
+	}
+
+	return tokens
+}
+
+func scan(runes []rune, start int, part func(rune) bool) int {
+	end := start
+	for end < len(runes) && part(runes[end]) {
+		end++
+	}
+
+	return end
+}
+
+func isNameStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isNamePart(r rune) bool {
+	return isNameStart(r) || unicode.IsDigit(r)
+}
+
+func isNumberPart(r rune) bool {
+	return unicode.IsDigit(r) || r == '.'
+}
@@ -0,0 +1,103 @@
+//nolint:testpackage // test unexported helpers.
+package expr
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const testTolerance = 1e-9
+
+// TestEval honors precedence, power associativity, and unary minus.
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	values := testLookup(map[string]string{
+		"weight": "81",
+		"height": "1.8",
+	})
+
+	cases := map[string]float64{
+		"1 + 2 * 3":              7,
+		"(1 + 2) * 3":            9,
+		"2 ^ 3 ^ 2":              512,
+		"-2 ^ 2":                 -4,
+		"2 ^ -1":                 0.5,
+		"10 - 4 - 3":             3,
+		"weight / (height ^ 2)":  25,
+		"weight / height/height": 25,
+	}
+
+	for source, want := range cases {
+		parsed, err := Parse(source)
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+
+		got, err := parsed.Eval(values)
+		if err != nil || got-want > testTolerance || want-got > testTolerance {
+			t.Fatalf("%s: got %v (%v) want %v", source, got, err, want)
+		}
+	}
+}
+
+// TestParseErrors rejects malformed expressions.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, source := range []string{"", "1 +", "(1", "1 2", "a $ b"} {
+		_, err := Parse(source)
+		if !errors.Is(err, ErrSyntax) {
+			t.Fatalf("%q: got %v want %v", source, err, ErrSyntax)
+		}
+	}
+}
+
+// TestEvalErrors reports missing names, non-numbers, and division by zero.
+func TestEvalErrors(t *testing.T) {
+	t.Parallel()
+
+	values := testLookup(map[string]string{"zero": "0", "blank": ""})
+
+	cases := map[string]error{
+		"missing + 1": ErrUnknownName,
+		"blank * 2":   ErrNotNumber,
+		"1 / zero":    ErrUndefined,
+	}
+
+	for source, want := range cases {
+		parsed, err := Parse(source)
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+
+		_, err = parsed.Eval(values)
+		if !errors.Is(err, want) {
+			t.Fatalf("%s: got %v want %v", source, err, want)
+		}
+	}
+}
+
+// TestNames lists referenced names in order of use.
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := Parse("duration / distance * duration_2")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []string{"duration", "distance", "duration_2"}
+	if !slices.Equal(parsed.Names(), want) {
+		t.Fatalf("got %q want %q", parsed.Names(), want)
+	}
+}
+
+func testLookup(values map[string]string) Lookup {
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+
+		return value, ok
+	}
+}
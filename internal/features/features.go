@@ -0,0 +1,250 @@
+// Package features gates experimental subsystems behind opt-in flags, so
+// they can ship in stable releases without changing the default
+// experience.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	// Sync is local sync of Withings data into a store.
+	Sync = "sync"
+	// Daemon is the background sync daemon.
+	Daemon = "daemon"
+	// TUI is the interactive terminal UI.
+	TUI = "tui"
+
+	// EnvExperimental lists features to enable (or, prefixed with "-",
+	// disable) on top of the config, e.g. "sync,tui" or "all".
+	EnvExperimental = "WITHINGS_EXPERIMENTAL"
+
+	configSection  = "experimental."
+	envAll         = "all"
+	envDisable     = "-"
+	listSeparator  = ","
+	valueEnabled   = "true"
+	valueDisabled  = "false"
+	sourceDefault  = "default"
+	sourceConfig   = "config"
+	sourceEnv      = "env"
+	tableHeader    = "Feature\tEnabled\tSource\tDescription"
+	plainHeader    = "feature\tenabled\tsource\tdescription"
+	enableHintText = "enable it with `withings config set experimental.%s " +
+		"true` or %s=%s"
+)
+
+var (
+	errUnknownFeature = errors.New("unknown experimental feature")
+	errInvalidValue   = errors.New(
+		"invalid experimental value in config (expected true or false)",
+	)
+	errDisabled = errors.New("experimental feature is not enabled")
+)
+
+// Feature describes one gated subsystem and whether it is enabled.
+type Feature struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source"`
+}
+
+// registry lists every experimental feature. Graduating a feature means
+// removing it here and its gate at the call site.
+//
+//nolint:gochecknoglobals // Static feature registry.
+var registry = []Feature{
+	{
+		Name:        Sync,
+		Description: "sync Withings data into a local store",
+		Enabled:     false,
+		Source:      sourceDefault,
+	},
+	{
+		Name:        Daemon,
+		Description: "background sync daemon",
+		Enabled:     false,
+		Source:      sourceDefault,
+	},
+	{
+		Name:        TUI,
+		Description: "interactive terminal UI",
+		Enabled:     false,
+		Source:      sourceDefault,
+	},
+}
+
+// List resolves every feature from the config ([experimental] table) and
+// WITHINGS_EXPERIMENTAL, which wins. Unknown names in either are ignored
+// so old settings keep working after a feature graduates.
+func List(opts app.Options) ([]Feature, error) {
+	keys := make([]string, 0, len(registry))
+	for _, feature := range registry {
+		keys = append(keys, configSection+feature.Name)
+	}
+
+	settings, err := auth.ConfigValues(opts, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	env := parseEnv(os.Getenv(EnvExperimental))
+	features := make([]Feature, 0, len(registry))
+
+	for _, feature := range registry {
+		feature, err = resolve(feature, settings, env)
+		if err != nil {
+			return nil, err
+		}
+
+		features = append(features, feature)
+	}
+
+	return features, nil
+}
+
+// Require returns a usage error naming how to opt in unless name is
+// enabled.
+func Require(opts app.Options, name string) error {
+	features, err := List(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, feature := range features {
+		if feature.Name != name {
+			continue
+		}
+
+		if feature.Enabled {
+			return nil
+		}
+
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf(
+				"%w: %s; "+enableHintText,
+				errDisabled,
+				name,
+				name,
+				EnvExperimental,
+				name,
+			),
+		)
+	}
+
+	return fmt.Errorf("%w: %s", errUnknownFeature, name)
+}
+
+// Run writes every experimental feature and whether it is enabled.
+func Run(opts app.Options) error {
+	features, err := List(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		return output.WriteRawJSON(opts, features)
+	}
+
+	lines := make([]string, 0, len(features)+1)
+	lines = append(lines, plainHeader)
+
+	for _, feature := range features {
+		lines = append(lines, strings.Join([]string{
+			feature.Name,
+			strconv.FormatBool(feature.Enabled),
+			feature.Source,
+			feature.Description,
+		}, "\t"))
+	}
+
+	if opts.Plain || opts.NDJSON {
+		return output.WritePlain(opts, lines)
+	}
+
+	lines[0] = tableHeader
+
+	table, err := output.RenderTable(opts, plainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render features table: %w", err)
+	}
+
+	return output.WriteLine(opts, table)
+}
+
+func resolve(
+	feature Feature,
+	settings map[string]string,
+	env map[string]bool,
+) (Feature, error) {
+	if raw, ok := settings[configSection+feature.Name]; ok {
+		switch strings.ToLower(raw) {
+		case valueEnabled:
+			feature.Enabled = true
+		case valueDisabled:
+			feature.Enabled = false
+		default:
+			return feature, app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf(
+					"%w: %s%s = %q",
+					errInvalidValue,
+					configSection,
+					feature.Name,
+					raw,
+				),
+			)
+		}
+
+		feature.Source = sourceConfig
+	}
+
+	enabled, ok := env[feature.Name]
+	if !ok {
+		enabled, ok = env[envAll]
+	}
+
+	if ok {
+		feature.Enabled = enabled
+		feature.Source = sourceEnv
+	}
+
+	return feature, nil
+}
+
+// parseEnv maps each listed name to true, or to false when prefixed with
+// "-".
+func parseEnv(raw string) map[string]bool {
+	entries := map[string]bool{}
+
+	for entry := range strings.SplitSeq(raw, listSeparator) {
+		name := strings.ToLower(strings.TrimSpace(entry))
+		if name == "" {
+			continue
+		}
+
+		if disabled, ok := strings.CutPrefix(name, envDisable); ok {
+			entries[disabled] = false
+
+			continue
+		}
+
+		entries[name] = true
+	}
+
+	return entries
+}
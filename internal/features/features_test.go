@@ -0,0 +1,69 @@
+//nolint:testpackage // test unexported helpers.
+package features
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestResolvePrecedence lets the environment override the config.
+func TestResolvePrecedence(t *testing.T) {
+	t.Parallel()
+
+	base := Feature{
+		Name:        Sync,
+		Description: "",
+		Enabled:     false,
+		Source:      sourceDefault,
+	}
+
+	tests := []struct {
+		name     string
+		config   map[string]string
+		env      string
+		enabled  bool
+		source   string
+		usageErr bool
+	}{
+		{"default", nil, "", false, sourceDefault, false},
+		{"config", map[string]string{"experimental.sync": "true"}, "", true,
+			sourceConfig, false},
+		{"env", nil, "tui, sync", true, sourceEnv, false},
+		{"all", nil, "all", true, sourceEnv, false},
+		{"env-disables", map[string]string{"experimental.sync": "TRUE"},
+			"all,-sync", false, sourceEnv, false},
+		{"invalid", map[string]string{"experimental.sync": "yes"}, "", false,
+			sourceDefault, true},
+	}
+
+	for _, test := range tests {
+		got, err := resolve(base, test.config, parseEnv(test.env))
+
+		var exitErr *app.ExitError
+		if test.usageErr {
+			if !errors.As(err, &exitErr) ||
+				exitErr.Code != app.ExitCodeUsage {
+				t.Fatalf("%s: got %v, want usage error", test.name, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if got.Enabled != test.enabled || got.Source != test.source {
+			t.Fatalf(
+				"%s: got %t/%s, want %t/%s",
+				test.name,
+				got.Enabled,
+				got.Source,
+				test.enabled,
+				test.source,
+			)
+		}
+	}
+}
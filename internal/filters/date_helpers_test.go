@@ -175,6 +175,111 @@ func TestParseEpochDate(t *testing.T) {
 	}
 }
 
+// TestParseTimeAtRelative resolves relative expressions in now's zone.
+func TestParseTimeAtRelative(t *testing.T) {
+	t.Parallel()
+
+	zone := time.FixedZone("CET", int(time.Hour/time.Second))
+	now := time.Date(2025, 12, 31, 10, 0, 0, 0, zone)
+
+	cases := []struct {
+		value string
+		want  time.Time
+	}{
+		{"7d", time.Date(2025, 12, 24, 10, 0, 0, 0, zone)},
+		{"-7d", time.Date(2025, 12, 24, 10, 0, 0, 0, zone)},
+		{"-12h", time.Date(2025, 12, 30, 22, 0, 0, 0, zone)},
+		{"12h", time.Date(2025, 12, 30, 22, 0, 0, 0, zone)},
+		{"2w", time.Date(2025, 12, 17, 10, 0, 0, 0, zone)},
+		{"Yesterday", time.Date(2025, 12, 30, 0, 0, 0, 0, zone)},
+		{"this-week", time.Date(2025, 12, 29, 0, 0, 0, 0, zone)},
+		{"last-week", time.Date(2025, 12, 22, 0, 0, 0, 0, zone)},
+		{"last-month", time.Date(2025, 11, 1, 0, 0, 0, 0, zone)},
+		{"last-year", time.Date(2024, 1, 1, 0, 0, 0, 0, zone)},
+		{"2024-01", time.Date(2024, 1, 1, 0, 0, 0, 0, zone)},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseTimeAt(tc.value, now)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.value, err)
+		}
+
+		if !got.Equal(tc.want) {
+			t.Fatalf("%s got %s want %s", tc.value, got, tc.want)
+		}
+	}
+
+	for _, value := range []string{"7x", "--7d", "+7d"} {
+		_, err := ParseTimeAt(value, now)
+		if !errors.Is(err, errs.ErrInvalidTimeFormat) {
+			t.Fatalf(testErrFmt, err, errs.ErrInvalidTimeFormat)
+		}
+	}
+}
+
+// TestTimeErrorNamesValueOnce keeps the accepted formats to the flag error
+// and adds only the rejected value.
+func TestTimeErrorNamesValueOnce(t *testing.T) {
+	t.Parallel()
+
+	_, err := DateFromTimeValue("soon", errs.ErrInvalidStartTime)
+	if !errors.Is(err, errs.ErrInvalidStartTime) ||
+		!errors.Is(err, errs.ErrInvalidTimeFormat) {
+		t.Fatalf(testErrFmt, err, errs.ErrInvalidStartTime)
+	}
+
+	want := errs.ErrInvalidStartTime.Error() + `: unrecognized time value "soon"`
+	if err.Error() != want {
+		t.Fatalf("message got %q want %q", err.Error(), want)
+	}
+}
+
+// TestParseDateValueRelative uses the local date of relative days.
+func TestParseDateValueRelative(t *testing.T) {
+	t.Parallel()
+
+	zone := time.FixedZone("CET", int(time.Hour/time.Second))
+	now := time.Date(2025, 12, 31, 0, 30, 0, 0, zone)
+
+	got, err := parseDateValueAt("yesterday", now)
+	if err != nil || got != testDateValue {
+		t.Fatalf("date got %q, %v want %q", got, err, testDateValue)
+	}
+}
+
 func strconvFormatInt(value int64) string {
 	return strconv.FormatInt(value, testNumberBase10)
 }
+
+// TestParseSpan parses hour, day, and week spans and rejects the rest.
+func TestParseSpan(t *testing.T) {
+	t.Parallel()
+
+	valid := map[string]time.Duration{
+		"15250w": 15250 * 7 * 24 * time.Hour,
+		"12h":    12 * time.Hour,
+		"90d":    90 * 24 * time.Hour,
+		" 2W ":   14 * 24 * time.Hour,
+	}
+
+	for value, want := range valid {
+		got, err := ParseSpan(value)
+		if err != nil || got != want {
+			t.Fatalf("%q got %v, %v want %v", value, got, err, want)
+		}
+	}
+
+	invalid := []string{
+		"0d", "90", "3m", "-1d", testEmptyString,
+		// Spans past the Duration range would wrap to negative.
+		"15251w", "106752d", "2562048h", "99999999999999999999d",
+	}
+
+	for _, value := range invalid {
+		_, err := ParseSpan(value)
+		if !errors.Is(err, errs.ErrInvalidSpan) {
+			t.Fatalf(testErrFmt, err, errs.ErrInvalidSpan)
+		}
+	}
+}
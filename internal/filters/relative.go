@@ -0,0 +1,166 @@
+package filters
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+)
+
+const (
+	monthLayout      = "2006-01"
+	daysPerWeek      = 7
+	hoursPerDay      = 24
+	noOffset         = 0
+	firstDay         = 1
+	midnight         = 0
+	previousUnit     = -1
+	unitHours        = "h"
+	unitDays         = "d"
+	unitWeeks        = "w"
+	offsetCountGroup = 1
+	offsetUnitGroup  = 2
+	offsetGroups     = 3
+	offsetPastSign   = "-"
+)
+
+//nolint:gochecknoglobals // Static pattern for "<n>h|d|w" offsets.
+var relativeOffsetPattern = regexp.MustCompile(`^(\d+)([hdw])$`)
+
+// parseRelative resolves relative expressions against now, in the
+// location of now. Calendar expressions start at local midnight; offsets
+// such as 7d count back from now.
+func parseRelative(value string, now time.Time) (time.Time, bool) {
+	lowered := strings.ToLower(value)
+	today := startOfDay(now)
+
+	switch lowered {
+	case "now":
+		return now, true
+	case "today":
+		return today, true
+	case "yesterday":
+		return addDays(today, previousUnit), true
+	case "this-week":
+		return startOfWeek(today), true
+	case "last-week":
+		return addDays(startOfWeek(today), -daysPerWeek), true
+	case "this-month":
+		return startOfMonth(today), true
+	case "last-month":
+		return startOfMonth(today).AddDate(noOffset, previousUnit, noOffset),
+			true
+	case "this-year":
+		return startOfYear(today), true
+	case "last-year":
+		return startOfYear(today).AddDate(previousUnit, noOffset, noOffset),
+			true
+	}
+
+	month, err := time.ParseInLocation(monthLayout, lowered, now.Location())
+	if err == nil {
+		return month, true
+	}
+
+	return parseRelativeOffset(lowered, now)
+}
+
+// ParseSpan parses a window length in the offset syntax of relative
+// times (12h, 90d, 2w). Days and weeks count 24 hours each.
+func ParseSpan(value string) (time.Duration, error) {
+	match := relativeOffsetPattern.FindStringSubmatch(
+		strings.ToLower(strings.TrimSpace(value)),
+	)
+	if len(match) != offsetGroups {
+		return 0, fmt.Errorf("%w: %q", errs.ErrInvalidSpan, value)
+	}
+
+	unit := time.Hour
+
+	switch match[offsetUnitGroup] {
+	case unitDays:
+		unit = hoursPerDay * time.Hour
+	case unitWeeks:
+		unit = daysPerWeek * hoursPerDay * time.Hour
+	}
+
+	// A count past the Duration range would wrap around to a negative
+	// span.
+	count, err := strconv.ParseInt(
+		match[offsetCountGroup],
+		numberBase10,
+		epochBitSize,
+	)
+	if err != nil || count <= noOffset || count > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("%w: %q", errs.ErrInvalidSpan, value)
+	}
+
+	return time.Duration(count) * unit, nil
+}
+
+// parseRelativeOffset counts <n>h|d|w back from now. A leading minus
+// (-30d) means the same, since the offset always points into the past.
+func parseRelativeOffset(value string, now time.Time) (time.Time, bool) {
+	match := relativeOffsetPattern.FindStringSubmatch(
+		strings.TrimPrefix(value, offsetPastSign),
+	)
+	if len(match) != offsetGroups {
+		return time.Time{}, false
+	}
+
+	count, err := strconv.Atoi(match[offsetCountGroup])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	switch match[offsetUnitGroup] {
+	case unitHours:
+		return now.Add(-time.Duration(count) * time.Hour), true
+	case unitDays:
+		return addDays(now, -count), true
+	case unitWeeks:
+		return addDays(now, -count*daysPerWeek), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func startOfDay(value time.Time) time.Time {
+	year, month, day := value.Date()
+
+	return time.Date(
+		year,
+		month,
+		day,
+		midnight,
+		midnight,
+		midnight,
+		midnight,
+		value.Location(),
+	)
+}
+
+// startOfWeek returns the Monday of the week containing day.
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + daysPerWeek - int(time.Monday)) %
+		daysPerWeek
+
+	return addDays(day, -offset)
+}
+
+func startOfMonth(day time.Time) time.Time {
+	return addDays(day, firstDay-day.Day())
+}
+
+func startOfYear(day time.Time) time.Time {
+	return addDays(day, firstDay-day.YearDay())
+}
+
+// addDays moves by calendar days, keeping the wall clock across DST.
+func addDays(value time.Time, days int) time.Time {
+	return value.AddDate(noOffset, noOffset, days)
+}
@@ -26,33 +26,44 @@ type DateRange struct {
 	End   string
 }
 
-// ParseDateValue parses a YYYY-MM-DD value into a normalized date string.
+// ParseDateValue parses a YYYY-MM-DD value, or a relative expression such
+// as yesterday resolved in the local timezone, into a date string.
 func ParseDateValue(raw string) (string, error) {
+	return parseDateValueAt(raw, time.Now())
+}
+
+func parseDateValueAt(raw string, now time.Time) (string, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == emptyString {
 		return emptyString, errs.ErrInvalidDate
 	}
 
 	parsed, err := time.Parse(dateLayout, trimmed)
-	if err != nil {
+	if err == nil {
+		return parsed.Format(dateLayout), nil
+	}
+
+	relative, ok := parseRelative(trimmed, now)
+	if !ok {
 		return emptyString, errs.ErrInvalidDate
 	}
 
-	return parsed.Format(dateLayout), nil
+	return relative.Format(dateLayout), nil
 }
 
-// DateFromTimeValue resolves a start/end time into a date string.
+// DateFromTimeValue resolves a start/end time into a date string. Absolute
+// values use the UTC date; relative ones the local date.
 func DateFromTimeValue(raw string, errInvalid error) (string, error) {
 	if raw == emptyString {
 		return emptyString, nil
 	}
 
-	epoch, err := ParseEpoch(raw)
+	parsed, err := ParseTimeAt(raw, time.Now())
 	if err != nil {
 		return emptyString, fmt.Errorf("%w: %w", errInvalid, err)
 	}
 
-	return time.Unix(epoch, defaultInt64).UTC().Format(dateLayout), nil
+	return parsed.Format(dateLayout), nil
 }
 
 // ResolveDateRange derives a DateRange from date or time-range filters.
@@ -100,29 +111,51 @@ func HasTimeRange(timeRange params.TimeRange) bool {
 	return timeRange.Start != emptyString || timeRange.End != emptyString
 }
 
-// ParseEpoch parses RFC3339, YYYY-MM-DD, or epoch timestamp strings.
+// ParseEpoch parses RFC3339, YYYY-MM-DD, epoch, or relative time strings.
 func ParseEpoch(value string) (int64, error) {
+	parsed, err := ParseTimeAt(value, time.Now())
+	if err != nil {
+		return defaultInt64, err
+	}
+
+	return parsed.Unix(), nil
+}
+
+// ParseTimeAt parses RFC3339, YYYY-MM-DD, or epoch values as UTC times and
+// relative expressions (7d or -7d, 12h, 2w, now, today, yesterday,
+// this-week, last-week, this-month, last-month, this-year, last-year,
+// YYYY-MM) against now, in the location of now.
+func ParseTimeAt(value string, now time.Time) (time.Time, error) {
 	trimmed := strings.TrimSpace(value)
 	if trimmed == emptyString {
-		return defaultInt64, errs.ErrEmptyTimeValue
+		return time.Time{}, errs.ErrEmptyTimeValue
 	}
 
 	epoch, err := strconv.ParseInt(trimmed, numberBase10, epochBitSize)
 	if err == nil {
-		return epoch, nil
+		return time.Unix(epoch, defaultInt64).UTC(), nil
 	}
 
 	parsed, err := time.Parse(time.RFC3339, trimmed)
-	if err != nil {
-		parsedDate, dateErr := time.Parse(dateLayout, trimmed)
-		if dateErr != nil {
-			return defaultInt64, errs.ErrInvalidTimeFormat
-		}
+	if err == nil {
+		return parsed.UTC(), nil
+	}
 
-		return parsedDate.Unix(), nil
+	parsed, err = time.Parse(dateLayout, trimmed)
+	if err == nil {
+		return parsed, nil
 	}
 
-	return parsed.Unix(), nil
+	relative, ok := parseRelative(trimmed, now)
+	if !ok {
+		return time.Time{}, fmt.Errorf(
+			"%w %q",
+			errs.ErrInvalidTimeFormat,
+			trimmed,
+		)
+	}
+
+	return relative, nil
 }
 
 // ApplyLastUpdateFilter enforces last-update and range conflicts.
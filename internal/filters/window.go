@@ -0,0 +1,90 @@
+package filters
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const fieldListSeparator = ","
+
+// ResolveEpochWindow resolves --start and --end to epoch seconds for the
+// intraday endpoints. --end defaults to now and --start to window before
+// --end.
+func ResolveEpochWindow(
+	timeRange params.TimeRange,
+	window time.Duration,
+	now time.Time,
+) (int64, int64, error) {
+	end := now.Unix()
+
+	if timeRange.End != emptyString {
+		parsed, err := ParseEpoch(timeRange.End)
+		if err != nil {
+			return defaultInt64, defaultInt64, fmt.Errorf(
+				"%w: %w",
+				errs.ErrInvalidEndTime,
+				err,
+			)
+		}
+
+		end = parsed
+	}
+
+	start := end - int64(window.Seconds())
+
+	if timeRange.Start != emptyString {
+		parsed, err := ParseEpoch(timeRange.Start)
+		if err != nil {
+			return defaultInt64, defaultInt64, fmt.Errorf(
+				"%w: %w",
+				errs.ErrInvalidStartTime,
+				err,
+			)
+		}
+
+		start = parsed
+	}
+
+	if end <= start {
+		return defaultInt64, defaultInt64, errs.ErrEndBeforeStart
+	}
+
+	return start, end, nil
+}
+
+// ParseDataFields parses a comma-separated --data-fields list into
+// lowercase, de-duplicated names, using defaults when raw is empty. Names
+// missing from allowed are rejected.
+func ParseDataFields(
+	raw string,
+	defaults string,
+	allowed map[string]bool,
+) ([]string, error) {
+	if strings.TrimSpace(raw) == emptyString {
+		raw = defaults
+	}
+
+	parts := strings.Split(raw, fieldListSeparator)
+	fields := make([]string, 0, len(parts))
+	seen := map[string]bool{}
+
+	for _, part := range parts {
+		field := strings.ToLower(strings.TrimSpace(part))
+		if field == emptyString || seen[field] {
+			continue
+		}
+
+		if !allowed[field] {
+			return nil, fmt.Errorf("%w: %q", errs.ErrInvalidDataField, part)
+		}
+
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
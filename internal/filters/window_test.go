@@ -0,0 +1,81 @@
+//nolint:testpackage // test unexported helpers.
+package filters
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	testWindowNow   = 1767139200
+	testWindowStart = 1767052800
+	testWindow      = 24 * time.Hour
+)
+
+// TestResolveEpochWindow defaults to the window before now and rejects
+// inverted or unparsable ranges.
+func TestResolveEpochWindow(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(testWindowNow, testDefaultInt)
+
+	start, end, err := ResolveEpochWindow(params.TimeRange{}, testWindow, now)
+	if err != nil || start != testWindowNow-int64(testWindow.Seconds()) ||
+		end != testWindowNow {
+		t.Fatalf("default got %d-%d, %v", start, end, err)
+	}
+
+	for _, test := range []struct {
+		timeRange params.TimeRange
+		want      error
+	}{
+		{
+			timeRange: params.TimeRange{
+				Start: strconv.Itoa(testWindowNow),
+				End:   strconv.Itoa(testWindowStart),
+			},
+			want: errs.ErrEndBeforeStart,
+		},
+		{
+			timeRange: params.TimeRange{Start: "soon", End: testEmptyString},
+			want:      errs.ErrInvalidStartTime,
+		},
+		{
+			timeRange: params.TimeRange{Start: testEmptyString, End: "later"},
+			want:      errs.ErrInvalidEndTime,
+		},
+	} {
+		_, _, err = ResolveEpochWindow(test.timeRange, testWindow, now)
+		if !errors.Is(err, test.want) {
+			t.Fatalf(testErrFmt, err, test.want)
+		}
+	}
+}
+
+// TestParseDataFields lowercases, dedupes, and validates fields.
+func TestParseDataFields(t *testing.T) {
+	t.Parallel()
+
+	allowed := map[string]bool{"hr": true, "rr": true}
+
+	fields, err := ParseDataFields(" HR,rr,hr,", "hr", allowed)
+	if err != nil || strings.Join(fields, ",") != "hr,rr" {
+		t.Fatalf("got %q, %v", fields, err)
+	}
+
+	fields, err = ParseDataFields(testEmptyString, "rr", allowed)
+	if err != nil || strings.Join(fields, ",") != "rr" {
+		t.Fatalf("default got %q, %v", fields, err)
+	}
+
+	_, err = ParseDataFields("hr,pulse", "hr", allowed)
+	if !errors.Is(err, errs.ErrInvalidDataField) {
+		t.Fatalf(testErrFmt, err, errs.ErrInvalidDataField)
+	}
+}
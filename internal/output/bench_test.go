@@ -0,0 +1,59 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const benchRows = 10000
+
+// benchIntradayLines mirrors `activity intraday --plain` for a week of
+// minutes: timestamps, numbers, blanks, and a device name.
+func benchIntradayLines() []string {
+	lines := make([]string, 0, benchRows+headerRows)
+	lines = append(lines, "time\tsteps\theart_rate\tcalories\tdistance\tmodel")
+
+	for index := range benchRows {
+		lines = append(lines, strings.Join([]string{
+			"2025-01-01T00:00:00Z",
+			strconv.Itoa(index % 120),
+			strconv.Itoa(60 + index%40),
+			strconv.Itoa(index%9) + ".5",
+			"",
+			"ScanWatch 2",
+		}, plainSeparator))
+	}
+
+	return lines
+}
+
+// BenchmarkWriteLines measures --plain output.
+func BenchmarkWriteLines(b *testing.B) {
+	lines := benchIntradayLines()
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		err := writeLines(io.Discard, lines)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWriteNDJSON measures --ndjson output.
+func BenchmarkWriteNDJSON(b *testing.B) {
+	lines := benchIntradayLines()
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		err := writeNDJSON(io.Discard, lines)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	choicesTableHeader = "Name\tValue"
+	choicesPlainHeader = "name\tvalue"
+)
+
+// Choice is one accepted value of an enum-like flag: the name users type
+// and the API value it maps to.
+type Choice struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// IsChoiceList reports whether a flag value asks for the accepted values
+// ("list" or "?") instead of naming one.
+func IsChoiceList(value string) bool {
+	trimmed := strings.ToLower(strings.TrimSpace(value))
+
+	return trimmed == "list" || trimmed == "?"
+}
+
+// WriteChoices prints accepted flag values ordered by API value, so
+// aliases of the same value are listed together.
+func WriteChoices(opts app.Options, choices []Choice) error {
+	sorted := slices.Clone(choices)
+	slices.SortFunc(sorted, compareChoices)
+
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		return WriteOutput(opts, sorted)
+	}
+
+	lines := make([]string, 0, len(sorted)+headerRows)
+	lines = append(lines, choicesPlainHeader)
+
+	for _, choice := range sorted {
+		lines = append(lines, choice.Name+plainSeparator+choice.Value)
+	}
+
+	if opts.Plain || opts.NDJSON {
+		return WritePlain(opts, lines)
+	}
+
+	lines[0] = choicesTableHeader
+
+	table, err := RenderTable(opts, choicesPlainHeader, lines)
+	if err != nil {
+		return err
+	}
+
+	return WriteLine(opts, table)
+}
+
+func compareChoices(left, right Choice) int {
+	leftValue, leftErr := strconv.Atoi(left.Value)
+	rightValue, rightErr := strconv.Atoi(right.Value)
+
+	byValue := cmp.Compare(left.Value, right.Value)
+	if leftErr == nil && rightErr == nil {
+		byValue = cmp.Compare(leftValue, rightValue)
+	}
+
+	if byValue != 0 {
+		return byValue
+	}
+
+	return cmp.Compare(left.Name, right.Name)
+}
@@ -0,0 +1,44 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestIsChoiceList accepts list and ? only.
+func TestIsChoiceList(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[string]bool{
+		"list":   true,
+		" LIST ": true,
+		"?":      true,
+		"weight": false,
+		"":       false,
+	} {
+		if got := IsChoiceList(value); got != want {
+			t.Fatalf("%q: got %t want %t", value, got, want)
+		}
+	}
+}
+
+// TestCompareChoices orders numeric values numerically, then by name.
+func TestCompareChoices(t *testing.T) {
+	t.Parallel()
+
+	choices := []Choice{
+		{Name: "vo2max", Value: "123"},
+		{Name: "weight", Value: "1"},
+		{Name: "bodyweight", Value: "1"},
+		{Name: "bp_dia", Value: "9"},
+	}
+	slices.SortFunc(choices, compareChoices)
+
+	want := []string{"bodyweight", "weight", "bp_dia", "vo2max"}
+	for index, choice := range choices {
+		if choice.Name != want[index] {
+			t.Fatalf("%d: got %q want %q", index, choice.Name, want[index])
+		}
+	}
+}
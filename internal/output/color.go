@@ -0,0 +1,52 @@
+package output
+
+import (
+	"os"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// Color is an ANSI foreground color escape sequence.
+type Color string
+
+// ANSI colors used to highlight table cells.
+const (
+	ColorRed    Color = "\x1b[31m"
+	ColorGreen  Color = "\x1b[32m"
+	ColorYellow Color = "\x1b[33m"
+	colorReset        = "\x1b[0m"
+	noColorEnv        = "NO_COLOR"
+)
+
+// ColorEnabled reports whether ANSI colors should be written to stdout.
+func ColorEnabled(opts app.Options) bool {
+	if opts.NoColor || opts.Plain || opts.JSON || opts.NDJSON {
+		return false
+	}
+
+	if _, ok := os.LookupEnv(noColorEnv); ok {
+		return false
+	}
+
+	// Only a terminal takes colors; files and buffers do not.
+	file, ok := Stdout(opts).(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Colorize wraps text in the given color when colors are enabled.
+func Colorize(enabled bool, color Color, text string) string {
+	if !enabled || text == "" {
+		return text
+	}
+
+	return string(color) + text + colorReset
+}
@@ -0,0 +1,205 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/expr"
+)
+
+// ErrInvalidColumn indicates a computed column definition that cannot be
+// used.
+var ErrInvalidColumn = errors.New("invalid computed column")
+
+const (
+	columnSeparator  = ";"
+	columnAssign     = "="
+	columnParts      = 2
+	computedDigits   = 2
+	computedBitSize  = 64
+	computedFormat   = 'f'
+	computedShortest = -1
+)
+
+// Column is a computed column appended to row output.
+type Column struct {
+	Name string
+	Expr *expr.Expr
+}
+
+// ParseColumns parses "name = expression" definitions separated by ";",
+// such as "pace = duration / distance; kcal_h = calories / duration * 3600".
+func ParseColumns(spec string) ([]Column, error) {
+	var columns []Column
+
+	for definition := range strings.SplitSeq(spec, columnSeparator) {
+		if strings.TrimSpace(definition) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(definition, columnAssign, columnParts)
+		if len(parts) != columnParts || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf(
+				"%w %q (expected name = expression)",
+				ErrInvalidColumn,
+				strings.TrimSpace(definition),
+			)
+		}
+
+		parsed, err := expr.Parse(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidColumn, err)
+		}
+
+		columns = append(columns, Column{
+			Name: strings.ToLower(strings.TrimSpace(parts[0])),
+			Expr: parsed,
+		})
+	}
+
+	return columns, nil
+}
+
+// addComputedColumns appends the configured computed columns to rows whose
+// column names are keys. Cells the expression cannot evaluate, such as
+// empty values or a division by zero, stay empty.
+func addComputedColumns(opts app.Options, keys string, lines []string) (
+	string,
+	[]string,
+	error,
+) {
+	if opts.Columns == "" || len(lines) == 0 {
+		return keys, lines, nil
+	}
+
+	columns, err := ParseColumns(opts.Columns)
+	if err != nil {
+		return "", nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	names := strings.Split(keys, plainSeparator)
+
+	err = checkColumnNames(columns, names)
+	if err != nil {
+		return "", nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	extended := make([]string, 0, len(lines))
+	extended = append(extended, lines[0]+columnHeader(columns))
+
+	for _, line := range lines[headerRows:] {
+		extended = append(extended, line+computeCells(columns, names, line))
+	}
+
+	return keys + columnHeader(columns), extended, nil
+}
+
+// checkColumnNames rejects expressions naming neither a row column nor an
+// earlier computed column.
+func checkColumnNames(columns []Column, names []string) error {
+	known := make(map[string]bool, len(names)+len(columns))
+	for _, name := range names {
+		known[name] = true
+	}
+
+	for _, column := range columns {
+		for _, name := range column.Expr.Names() {
+			if !known[name] {
+				return fmt.Errorf(
+					"%w %s: %w %q (available: %s)",
+					ErrInvalidColumn,
+					column.Name,
+					expr.ErrUnknownName,
+					name,
+					strings.Join(names, fieldSeparator),
+				)
+			}
+		}
+
+		known[column.Name] = true
+	}
+
+	return nil
+}
+
+func columnHeader(columns []Column) string {
+	var header strings.Builder
+
+	for _, column := range columns {
+		header.WriteString(plainSeparator + column.Name)
+	}
+
+	return header.String()
+}
+
+func computeCells(columns []Column, names []string, line string) string {
+	values := map[string]string{}
+
+	cells := strings.Split(line, plainSeparator)
+	for index, name := range names {
+		if index < len(cells) {
+			values[name] = cells[index]
+		}
+	}
+
+	lookup := func(name string) (string, bool) {
+		value, ok := values[name]
+
+		return value, ok
+	}
+
+	var computed strings.Builder
+
+	for _, column := range columns {
+		cell := ""
+
+		result, err := column.Expr.Eval(lookup)
+		if err == nil {
+			cell = formatComputed(result)
+		}
+
+		values[column.Name] = cell
+
+		computed.WriteString(plainSeparator + cell)
+	}
+
+	return computed.String()
+}
+
+func formatComputed(value float64) string {
+	scale := math.Pow10(computedDigits)
+
+	return strconv.FormatFloat(
+		math.Round(value*scale)/scale,
+		computedFormat,
+		computedShortest,
+		computedBitSize,
+	)
+}
+
+// prepareRows applies --redact, computed columns, --sort, and then
+// --fields to rows whose column names are keys.
+func prepareRows(opts app.Options, keys string, lines []string) (
+	[]string,
+	error,
+) {
+	if opts.Redact {
+		lines = redactRows(keys, lines)
+	}
+
+	keys, lines, err := addComputedColumns(opts, keys, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err = sortRows(opts, keys, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	return SelectFields(opts, keys, lines)
+}
@@ -0,0 +1,79 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const testComputedKeys = "date\tsteps\tdistance"
+
+// TestAddComputedColumns appends columns and leaves bad cells empty.
+func TestAddComputedColumns(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Columns = "km = distance / 1000; per_km = steps / km"
+
+	keys, got, err := addComputedColumns(opts, testComputedKeys, []string{
+		"Date\tSteps\tDistance (m)",
+		"2025-12-30\t9000\t6000",
+		"2025-12-31\t0\t0",
+	})
+	if err != nil {
+		t.Fatalf("addComputedColumns: %v", err)
+	}
+
+	if keys != testComputedKeys+"\tkm\tper_km" {
+		t.Fatalf("keys got %q", keys)
+	}
+
+	want := []string{
+		"Date\tSteps\tDistance (m)\tkm\tper_km",
+		"2025-12-30\t9000\t6000\t6\t1500",
+		"2025-12-31\t0\t0\t0\t",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestAddComputedColumnsUnknownName rejects names missing from the rows.
+func TestAddComputedColumnsUnknownName(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Columns = "pace = duration / distance"
+
+	_, _, err := addComputedColumns(
+		opts,
+		testComputedKeys,
+		[]string{testComputedKeys},
+	)
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+		t.Fatalf("got %v want usage error", err)
+	}
+
+	if !errors.Is(err, ErrInvalidColumn) {
+		t.Fatalf("got %v want %v", err, ErrInvalidColumn)
+	}
+}
+
+// TestParseColumnsInvalid rejects definitions without a name.
+func TestParseColumnsInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, spec := range []string{"steps * 2", " = steps", "x = (steps"} {
+		_, err := ParseColumns(spec)
+		if !errors.Is(err, ErrInvalidColumn) {
+			t.Fatalf("%q: got %v want %v", spec, err, ErrInvalidColumn)
+		}
+	}
+}
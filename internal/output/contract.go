@@ -0,0 +1,41 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// ContractVersion is the current row output contract version. It is
+	// bumped whenever a plain/table column is removed, renamed, reordered,
+	// or changes type; appending columns does not bump it.
+	ContractVersion = 1
+	// MinContractVersion is the oldest version still selectable with
+	// --output-version. Older shapes are kept for a deprecation window of
+	// at least one minor release after a bump.
+	MinContractVersion = 1
+	// LatestContract requests the current contract version.
+	LatestContract = 0
+)
+
+var errUnsupportedContract = errors.New("unsupported --output-version")
+
+// ResolveContractVersion validates a requested contract version and maps
+// LatestContract to ContractVersion.
+func ResolveContractVersion(requested int) (int, error) {
+	if requested == LatestContract {
+		return ContractVersion, nil
+	}
+
+	if requested < MinContractVersion || requested > ContractVersion {
+		return LatestContract, fmt.Errorf(
+			"%w %d (supported: %d-%d)",
+			errUnsupportedContract,
+			requested,
+			MinContractVersion,
+			ContractVersion,
+		)
+	}
+
+	return requested, nil
+}
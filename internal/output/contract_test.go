@@ -0,0 +1,27 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestResolveContractVersion maps latest and rejects unknown versions.
+func TestResolveContractVersion(t *testing.T) {
+	t.Parallel()
+
+	got, err := ResolveContractVersion(LatestContract)
+	if err != nil || got != ContractVersion {
+		t.Fatalf("latest got %d, %v want %d", got, err, ContractVersion)
+	}
+
+	got, err = ResolveContractVersion(MinContractVersion)
+	if err != nil || got != MinContractVersion {
+		t.Fatalf("min got %d, %v want %d", got, err, MinContractVersion)
+	}
+
+	_, err = ResolveContractVersion(ContractVersion + 1)
+	if !errors.Is(err, errUnsupportedContract) {
+		t.Fatalf("next got %v want %v", err, errUnsupportedContract)
+	}
+}
@@ -0,0 +1,206 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// ErrUnknownField indicates a --fields entry that names no column.
+var ErrUnknownField = errors.New("unknown --fields column")
+
+const (
+	fieldSeparator = ","
+	tableMinWidth  = 0
+	tableTabWidth  = 0
+	tablePadding   = 2
+	tablePadChar   = ' '
+	tableFlags     = 0
+)
+
+// SelectFields keeps the --fields columns of tab-separated rows in the
+// requested order. keys is the plain header the names are matched
+// against; it may differ from lines[0] when the lines carry a table
+// header with the same column order.
+func SelectFields(opts app.Options, keys string, lines []string) (
+	[]string,
+	error,
+) {
+	if opts.Fields == "" || len(lines) == 0 {
+		return lines, nil
+	}
+
+	indexes, err := fieldIndexes(opts.Fields, strings.Split(keys, "\t"))
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	selected := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		cells := strings.Split(line, plainSeparator)
+		picked := make([]string, 0, len(indexes))
+
+		for _, index := range indexes {
+			cell := ""
+			if index < len(cells) {
+				cell = cells[index]
+			}
+
+			picked = append(picked, cell)
+		}
+
+		selected = append(selected, strings.Join(picked, plainSeparator))
+	}
+
+	return selected, nil
+}
+
+func fieldIndexes(spec string, keys []string) ([]int, error) {
+	positions := make(map[string]int, len(keys))
+	for index, key := range keys {
+		positions[key] = index
+	}
+
+	var indexes []int
+
+	for field := range strings.SplitSeq(spec, fieldSeparator) {
+		name := strings.ToLower(strings.TrimSpace(field))
+		if name == "" {
+			continue
+		}
+
+		index, ok := positions[name]
+		if !ok {
+			return nil, fmt.Errorf(
+				"%w %q (available: %s)",
+				ErrUnknownField,
+				name,
+				strings.Join(keys, fieldSeparator),
+			)
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+// RenderTable applies computed columns and --fields to tab-separated rows
+// (table header first) and aligns them as a table. keys is the matching
+// plain header. With --format the rows are rendered through the template
+// instead; with --format markdown they form a markdown table.
+func RenderTable(opts app.Options, keys string, lines []string) (
+	string,
+	error,
+) {
+	if opts.Template != "" && len(lines) > 0 {
+		return renderTableTemplate(opts, keys, lines)
+	}
+
+	selected, err := prepareRows(opts, keys, lines)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Markdown {
+		return strings.Join(MarkdownTable(selected), "\n"), nil
+	}
+
+	if ColorEnabled(opts) {
+		selected, err = colorizeThresholds(opts, keys, selected)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(
+		&buffer,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableFlags,
+	)
+
+	for _, line := range selected {
+		_, _ = fmt.Fprintln(writer, line)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return "", fmt.Errorf("render table: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+// colorizeThresholds colors table cells by the threshold rules. keys is
+// the plain header before computed columns and --fields apply.
+func colorizeThresholds(opts app.Options, keys string, lines []string) (
+	[]string,
+	error,
+) {
+	thresholds, err := resolveThresholds(opts.Thresholds)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	header, err := prepareRows(opts, keys, []string{keys})
+	if err != nil {
+		return nil, err
+	}
+
+	return colorizeTable(thresholds, header[0], lines), nil
+}
+
+// renderTableTemplate swaps the table header for keys so the template
+// sees the plain column names.
+func renderTableTemplate(opts app.Options, keys string, lines []string) (
+	string,
+	error,
+) {
+	selected, err := prepareRows(
+		opts,
+		keys,
+		append([]string{keys}, lines[headerRows:]...),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	rendered, err := renderTemplate(opts, selected)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(rendered, "\n"), nil
+}
+
+// writeJSONRows writes plain rows as a pretty JSON array of objects keyed
+// by the header, the --json shape used when --fields projects rows.
+func writeJSONRows(opts app.Options, lines []string) error {
+	objects := ndjsonLines(lines)
+
+	rows := make([]json.RawMessage, 0, len(objects))
+	for _, object := range objects {
+		rows = append(rows, json.RawMessage(object))
+	}
+
+	encoder := json.NewEncoder(Stdout(opts))
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(rows)
+	if err != nil {
+		return fmt.Errorf("encode json output: %w", err)
+	}
+
+	return nil
+}
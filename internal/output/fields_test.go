@@ -0,0 +1,80 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const testFieldsKeys = "time\theart_rate\tmodel"
+
+// TestSelectFields reorders and drops columns by plain header name.
+func TestSelectFields(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	lines := []string{"Time\tHeart Rate\tModel", "t1\t60\t44"}
+
+	got, err := SelectFields(opts, testFieldsKeys, lines)
+	if err != nil || !slices.Equal(got, lines) {
+		t.Fatalf("unset got %q, %v want unchanged", got, err)
+	}
+
+	opts.Fields = "model, Heart_Rate"
+
+	got, err = SelectFields(opts, testFieldsKeys, lines)
+	if err != nil {
+		t.Fatalf("SelectFields: %v", err)
+	}
+
+	want := []string{"Model\tHeart Rate", "44\t60"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestSelectFieldsUnknown rejects names missing from the header.
+func TestSelectFieldsUnknown(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Fields = "time,bpm"
+
+	_, err := SelectFields(opts, testFieldsKeys, []string{testFieldsKeys})
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+		t.Fatalf("got %v want usage error", err)
+	}
+
+	if !errors.Is(err, ErrUnknownField) {
+		t.Fatalf("got %v want %v", err, ErrUnknownField)
+	}
+}
+
+// TestRenderTable aligns the selected columns.
+func TestRenderTable(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Fields = "model,time"
+
+	got, err := RenderTable(opts, testFieldsKeys, []string{
+		"Time\tHeart Rate\tModel",
+		"2025-12-30\t60\t44",
+	})
+	if err != nil {
+		t.Fatalf("RenderTable: %v", err)
+	}
+
+	want := "Model  Time\n44     2025-12-30"
+	if got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
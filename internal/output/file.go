@@ -0,0 +1,175 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	randv2 "math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	stdoutPath = "-"
+	// outputFileMode is the mode a new --output file is created with,
+	// before the umask.
+	outputFileMode = 0o666
+	// outputTempTries bounds the attempts to find an unused temporary
+	// file name.
+	outputTempTries = 100
+	tempNameBase    = 36
+)
+
+var errOutputIsDir = errors.New("--output is a directory")
+
+// StdoutFile collects rendered output in a temporary file next to the
+// target path, so the target only ever holds the complete output of a
+// successful run. The zero value leaves output on stdout.
+type StdoutFile struct {
+	path string
+	temp *os.File
+}
+
+// Open creates the temporary file for path; an empty path or "-" keeps
+// stdout.
+func (f *StdoutFile) Open(path string) error {
+	if path == "" || path == stdoutPath {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err == nil && info.IsDir() {
+		return fmt.Errorf("%w: %s", errOutputIsDir, path)
+	}
+
+	temp, err := createOutputTemp(path)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+
+	f.path = path
+	f.temp = temp
+
+	return nil
+}
+
+// Writer returns the temporary file output goes to, or nil when output
+// stays on stdout.
+func (f *StdoutFile) Writer() io.Writer {
+	if f.temp == nil {
+		return nil
+	}
+
+	return f.temp
+}
+
+// Close ends the output. With commit the temporary file replaces the
+// target path; otherwise it is removed and the target is left untouched.
+func (f *StdoutFile) Close(commit bool) error {
+	if f.temp == nil {
+		return nil
+	}
+
+	temp := f.temp
+	f.temp = nil
+
+	if !commit {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+
+		return nil
+	}
+
+	err := keepMode(temp, f.path)
+	if err == nil {
+		err = temp.Sync()
+	}
+
+	if err == nil {
+		err = temp.Close()
+	} else {
+		_ = temp.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), f.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+
+		return fmt.Errorf("write output file: %w", err)
+	}
+
+	return nil
+}
+
+// createOutputTemp creates a hidden temporary file next to path. Unlike
+// os.CreateTemp, which always uses mode 0600, it creates the file with
+// outputFileMode so the umask applies as for a plain shell redirect.
+func createOutputTemp(path string) (*os.File, error) {
+	dir, base := filepath.Split(path)
+
+	var err error
+
+	for range outputTempTries {
+		//nolint:gosec // Temporary file name suffix, not a secret.
+		suffix := strconv.FormatUint(randv2.Uint64(), tempNameBase)
+		name := filepath.Join(dir, "."+base+"."+suffix+".tmp")
+
+		var temp *os.File
+
+		//nolint:gosec // The path is the user's --output target.
+		temp, err = os.OpenFile(
+			name,
+			os.O_RDWR|os.O_CREATE|os.O_EXCL,
+			outputFileMode,
+		)
+		if !errors.Is(err, fs.ErrExist) {
+			return temp, err //nolint:wrapcheck // Wrapped by Open.
+		}
+	}
+
+	return nil, err //nolint:wrapcheck // Wrapped by Open.
+}
+
+// keepMode gives the temporary file the mode of the file it replaces, so
+// overwriting a target does not change its permissions.
+func keepMode(temp *os.File, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil //nolint:nilerr // A new target keeps the umask mode.
+	}
+
+	err = temp.Chmod(info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("chmod output file: %w", err)
+	}
+
+	return nil
+}
+
+// CaptureLines runs fn with output sent to a buffer and returns the lines
+// it wrote, for callers that post-process a command's output.
+func CaptureLines(
+	opts app.Options,
+	fn func(app.Options) error,
+) ([]string, error) {
+	var buffer bytes.Buffer
+
+	opts.Stdout = &buffer
+	runErr := fn(opts)
+
+	text := strings.TrimRight(buffer.String(), "\n")
+	if text == "" {
+		return nil, runErr
+	}
+
+	return strings.Split(text, "\n"), runErr
+}
@@ -0,0 +1,139 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestStdoutFileCommit replaces the target only on commit and keeps the
+// mode of the file it replaces.
+func TestStdoutFileCommit(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "out.txt")
+
+	err := os.WriteFile(path, []byte("old\n"), rotateFileMode)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	var file StdoutFile
+
+	for _, commit := range []bool{false, true} {
+		err = file.Open(path)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+
+		err = WriteLine(app.Options{Stdout: file.Writer()}, "new")
+		if err != nil {
+			t.Fatalf("WriteLine: %v", err)
+		}
+
+		err = file.Close(commit)
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+
+		want := "old\n"
+		if commit {
+			want = "new\n"
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+
+		if string(got) != want {
+			t.Fatalf("commit %t got %q want %q", commit, got, want)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != rotateFileMode {
+		t.Fatalf("mode got %v want %v", info.Mode().Perm(), rotateFileMode)
+	}
+}
+
+// TestStdoutFileNewMode creates a new target like a shell redirect: with
+// outputFileMode less the umask, not os.CreateTemp's 0600.
+func TestStdoutFileNewMode(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.txt")
+
+	var file StdoutFile
+
+	err := file.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	err = file.Close(true)
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A file created directly shows what the umask leaves of the mode.
+	probe := filepath.Join(dir, "probe")
+
+	err = os.WriteFile(probe, nil, outputFileMode)
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+
+	got, gotErr := os.Stat(path)
+	want, wantErr := os.Stat(probe)
+
+	if gotErr != nil || wantErr != nil ||
+		got.Mode().Perm() != want.Mode().Perm() {
+		t.Fatalf("mode got %v want %v", got.Mode(), want.Mode())
+	}
+}
+
+// TestStdoutFileDash keeps stdout for "-" and rejects directories.
+func TestStdoutFileDash(t *testing.T) {
+	t.Parallel()
+
+	var file StdoutFile
+
+	err := file.Open(stdoutPath)
+	if err != nil || file.Writer() != nil {
+		t.Fatalf("Open(-) redirected stdout: %v", err)
+	}
+
+	err = file.Open(t.TempDir())
+	if err == nil {
+		t.Fatal("Open(dir) succeeded")
+	}
+}
+
+// TestCaptureLines returns what the function printed.
+func TestCaptureLines(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	lines, err := CaptureLines(opts, func(captured app.Options) error {
+		return WriteLines(captured, []string{"a\tb", "1\t2"})
+	})
+	if err != nil || len(lines) != 2 || lines[1] != "1\t2" {
+		t.Fatalf("got %q, %v", lines, err)
+	}
+
+	lines, err = CaptureLines(opts, func(app.Options) error { return nil })
+	if err != nil || lines != nil {
+		t.Fatalf("empty got %q, %v", lines, err)
+	}
+}
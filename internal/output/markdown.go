@@ -0,0 +1,87 @@
+package output
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FormatMarkdown is the --format value that renders rows as a
+// GitHub-flavored markdown table instead of a template.
+const FormatMarkdown = "markdown"
+
+const (
+	markdownLeft  = "---"
+	markdownRight = "---:"
+	markdownPipe  = `\|`
+	markdownRows  = 2
+	floatBits     = 64
+)
+
+// MarkdownTable formats tab-separated rows (header first) as a
+// GitHub-flavored markdown table. Columns whose cells are all numbers are
+// right-aligned; pipes in cells are escaped.
+func MarkdownTable(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	header := strings.Split(lines[0], plainSeparator)
+	rows := make([][]string, 0, len(lines)-headerRows)
+
+	for _, line := range lines[headerRows:] {
+		cells := strings.Split(line, plainSeparator)
+		for len(cells) < len(header) {
+			cells = append(cells, "")
+		}
+
+		rows = append(rows, cells)
+	}
+
+	rule := make([]string, len(header))
+	for index := range header {
+		rule[index] = markdownLeft
+		if numericColumn(rows, index) {
+			rule[index] = markdownRight
+		}
+	}
+
+	table := make([]string, 0, len(rows)+markdownRows)
+	table = append(table, markdownRow(header), markdownRow(rule))
+
+	for _, cells := range rows {
+		table = append(table, markdownRow(cells))
+	}
+
+	return table
+}
+
+func markdownRow(cells []string) string {
+	escaped := make([]string, len(cells))
+	for index, cell := range cells {
+		escaped[index] = strings.ReplaceAll(cell, "|", markdownPipe)
+	}
+
+	return "| " + strings.Join(escaped, " | ") + " |"
+}
+
+// numericColumn reports whether every non-empty cell of the column is a
+// number, and at least one is.
+func numericColumn(rows [][]string, index int) bool {
+	found := false
+
+	for _, cells := range rows {
+		cell := cells[index]
+		if cell == "" {
+			continue
+		}
+
+		_, err := strconv.ParseFloat(cell, floatBits)
+		if err != nil {
+			return false
+		}
+
+		found = true
+	}
+
+	return found
+}
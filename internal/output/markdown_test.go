@@ -0,0 +1,53 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestMarkdownTable right-aligns numeric columns and escapes pipes.
+func TestMarkdownTable(t *testing.T) {
+	t.Parallel()
+
+	got := MarkdownTable([]string{
+		"Time\tValue\tNote",
+		"t1\t80\ta|b",
+		"t2\t\t",
+		"t3\t+1.5",
+	})
+	want := []string{
+		"| Time | Value | Note |",
+		"| --- | ---: | --- |",
+		`| t1 | 80 | a\|b |`,
+		"| t2 |  |  |",
+		"| t3 | +1.5 |  |",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestRenderTableMarkdown applies --fields before formatting.
+func TestRenderTableMarkdown(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Markdown = true
+	opts.Fields = "heart_rate,time"
+	lines := []string{"Time\tHeart Rate\tModel", "t1\t60\t44"}
+
+	got, err := RenderTable(opts, testFieldsKeys, lines)
+	if err != nil {
+		t.Fatalf("RenderTable: %v", err)
+	}
+
+	want := "| Heart Rate | Time |\n| ---: | --- |\n| 60 | t1 |"
+	if got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	plainSeparator = "\t"
+	jsonNull       = "null"
+	headerRows     = 1
+	// writeBufferSize batches rows into large writes so exports are bound
+	// by I/O rather than by one syscall per line.
+	writeBufferSize = 64 * 1024
+	rowBufferSize   = 256
+)
+
+// WritePlain writes tab-separated rows whose first line is the header,
+// appending computed columns and keeping only the --fields columns when
+// set. With --format each row is rendered through the template and the
+// header is dropped; with --format markdown the rows form a markdown
+// table. With --ndjson each row is
+// written as one compact JSON object keyed by the header columns instead;
+// with --json (used together with --fields) as a JSON array of them.
+func WritePlain(opts app.Options, lines []string) error {
+	if len(lines) > 0 {
+		selected, err := prepareRows(opts, lines[0], lines)
+		if err != nil {
+			return err
+		}
+
+		lines = selected
+	}
+
+	if opts.Template != "" {
+		rendered, err := renderTemplate(opts, lines)
+		if err != nil {
+			return err
+		}
+
+		return WriteLines(opts, rendered)
+	}
+
+	if opts.Markdown {
+		return WriteLines(opts, MarkdownTable(lines))
+	}
+
+	if opts.JSON {
+		return writeJSONRows(opts, lines)
+	}
+
+	if !opts.NDJSON {
+		return WriteLines(opts, lines)
+	}
+
+	return writeNDJSON(Stdout(opts), lines)
+}
+
+// writeLines writes each line followed by a newline through one buffer.
+func writeLines(target io.Writer, lines []string) error {
+	writer := bufio.NewWriterSize(target, writeBufferSize)
+
+	for _, line := range lines {
+		_, _ = writer.WriteString(line)
+		_ = writer.WriteByte('\n')
+	}
+
+	// bufio keeps the first write error, so checking Flush is enough.
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	return nil
+}
+
+// writeNDJSON streams the rows after the header as JSON objects.
+func writeNDJSON(target io.Writer, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	writer := bufio.NewWriterSize(target, writeBufferSize)
+	encoder := newNDJSONEncoder(lines[0])
+
+	for _, line := range lines[headerRows:] {
+		_, _ = writer.Write(encoder.encode(line))
+		_ = writer.WriteByte('\n')
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	return nil
+}
+
+// ndjsonLines converts plain rows to JSON objects, keeping the column
+// order. Numeric cells become JSON numbers and empty cells become null so
+// loaders can infer column types.
+func ndjsonLines(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	encoder := newNDJSONEncoder(lines[0])
+	objects := make([]string, 0, len(lines)-headerRows)
+
+	for _, line := range lines[headerRows:] {
+		objects = append(objects, string(encoder.encode(line)))
+	}
+
+	return objects
+}
+
+// ndjsonEncoder encodes the header keys once and reuses one row buffer.
+type ndjsonEncoder struct {
+	keys   [][]byte
+	buffer []byte
+}
+
+func newNDJSONEncoder(header string) *ndjsonEncoder {
+	names := strings.Split(header, plainSeparator)
+	keys := make([][]byte, 0, len(names))
+
+	for _, name := range names {
+		key := appendJSONString(nil, name)
+		keys = append(keys, append(key, ':'))
+	}
+
+	return &ndjsonEncoder{
+		keys:   keys,
+		buffer: make([]byte, 0, rowBufferSize),
+	}
+}
+
+// encode returns the object for line; the slice is only valid until the
+// next call.
+func (e *ndjsonEncoder) encode(line string) []byte {
+	buffer := append(e.buffer[:0], '{')
+	rest, more := line, true
+
+	for index, key := range e.keys {
+		if index > 0 {
+			buffer = append(buffer, ',')
+		}
+
+		buffer = append(buffer, key...)
+
+		var cell string
+		if more {
+			cell, rest, more = strings.Cut(rest, plainSeparator)
+		}
+
+		buffer = appendNDJSONValue(buffer, cell)
+	}
+
+	e.buffer = append(buffer, '}')
+
+	return e.buffer
+}
+
+func appendNDJSONValue(dst []byte, cell string) []byte {
+	if cell == "" {
+		return append(dst, jsonNull...)
+	}
+
+	if isJSONNumber(cell) {
+		return append(dst, cell...)
+	}
+
+	return appendJSONString(dst, cell)
+}
+
+// isJSONNumber reports whether s is a JSON number literal, so it can be
+// written as is: -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?.
+func isJSONNumber(value string) bool {
+	index := 0
+	if index < len(value) && value[index] == '-' {
+		index++
+	}
+
+	switch {
+	case index < len(value) && value[index] == '0':
+		index++
+	case index < len(value) && isDigit(value[index]):
+		index = skipDigits(value, index)
+	default:
+		return false
+	}
+
+	if index < len(value) && value[index] == '.' {
+		next := skipDigits(value, index+1)
+		if next == index+1 {
+			return false
+		}
+
+		index = next
+	}
+
+	if index < len(value) && (value[index] == 'e' || value[index] == 'E') {
+		index++
+		if index < len(value) && (value[index] == '+' || value[index] == '-') {
+			index++
+		}
+
+		next := skipDigits(value, index)
+		if next == index {
+			return false
+		}
+
+		index = next
+	}
+
+	return index == len(value)
+}
+
+func skipDigits(value string, index int) int {
+	for index < len(value) && isDigit(value[index]) {
+		index++
+	}
+
+	return index
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+// appendJSONString quotes value like encoding/json. Plain ASCII, the
+// common case, is copied directly; anything that needs escaping goes
+// through json.Marshal.
+func appendJSONString(dst []byte, value string) []byte {
+	for index := range len(value) {
+		char := value[index]
+		if char < ' ' || char >= utf8.RuneSelf || char == '"' ||
+			char == '\\' || char == '<' || char == '>' || char == '&' {
+			// Marshaling a string cannot fail.
+			encoded, _ := json.Marshal(value) //nolint:errchkjson // See above.
+
+			return append(dst, encoded...)
+		}
+	}
+
+	dst = append(dst, '"')
+	dst = append(dst, value...)
+
+	return append(dst, '"')
+}
@@ -0,0 +1,98 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// TestNDJSONLines keys rows by header and types numbers and blanks.
+func TestNDJSONLines(t *testing.T) {
+	t.Parallel()
+
+	got := ndjsonLines([]string{
+		"date\tsteps\tnote\tbmr",
+		"2025-12-01\t1200\t\"quoted\"\t",
+		"2025-12-02\t-3.5e2\t007\t1500.5",
+	})
+
+	want := []string{
+		`{"date":"2025-12-01","steps":1200,"note":"\"quoted\"","bmr":null}`,
+		`{"date":"2025-12-02","steps":-3.5e2,"note":"007","bmr":1500.5}`,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestNDJSONLinesHeaderOnly writes nothing for an empty result.
+func TestNDJSONLinesHeaderOnly(t *testing.T) {
+	t.Parallel()
+
+	got := ndjsonLines([]string{"date\tsteps"})
+	if len(got) != 0 {
+		t.Fatalf("got %q want no rows", got)
+	}
+}
+
+// TestIsJSONNumberMatchesDecoder agrees with encoding/json on literals.
+func TestIsJSONNumberMatchesDecoder(t *testing.T) {
+	t.Parallel()
+
+	cells := []string{
+		"0", "-0", "12", "007", "1.", ".5", "1.50", "-3.5e2", "1E+9",
+		"1e", "1e-", "+1", "--1", "0x10", "1 ", "NaN", "2025-12-01", "-",
+	}
+
+	for _, cell := range cells {
+		var number json.Number
+
+		err := json.Unmarshal([]byte(cell), &number)
+		want := err == nil && number.String() == cell
+
+		if got := isJSONNumber(cell); got != want {
+			t.Fatalf("%q: got %v want %v", cell, got, want)
+		}
+	}
+}
+
+// TestAppendJSONStringMatchesMarshal escapes like encoding/json.
+func TestAppendJSONStringMatchesMarshal(t *testing.T) {
+	t.Parallel()
+
+	values := []string{
+		"", "plain", `say "hi"`, `back\slash`, "tab\there", "<b>&",
+		"Zürich", "line\u2028sep", "bad\xffutf8",
+	}
+
+	for _, value := range values {
+		want, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+
+		if got := appendJSONString(nil, value); !bytes.Equal(got, want) {
+			t.Fatalf("%q: got %s want %s", value, got, want)
+		}
+	}
+}
+
+// TestWriteNDJSONShortRows pads missing cells with null.
+func TestWriteNDJSONShortRows(t *testing.T) {
+	t.Parallel()
+
+	var buffer bytes.Buffer
+
+	err := writeNDJSON(&buffer, []string{"a\tb\tc", "1", "x\ty\tz\textra"})
+	if err != nil {
+		t.Fatalf("writeNDJSON: %v", err)
+	}
+
+	want := `{"a":1,"b":null,"c":null}` + "\n" +
+		`{"a":"x","b":"y","c":"z"}` + "\n"
+	if buffer.String() != want {
+		t.Fatalf("got %q want %q", buffer.String(), want)
+	}
+}
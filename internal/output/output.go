@@ -4,6 +4,7 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/mreimbold/withings-cli/internal/app"
@@ -22,26 +23,54 @@ func WriteOutput(opts app.Options, data any) error {
 	}
 
 	if opts.JSON {
-		return writeJSONEnvelope(data)
+		return writeJSONEnvelope(opts, data)
 	}
 
 	switch value := data.(type) {
 	case []string:
-		return WriteLines(value)
+		return WriteLines(opts, value)
 	case string:
-		return WriteLine(value)
+		return WriteLine(opts, value)
 	default:
-		return WriteFormatted("%v\n", value)
+		return WriteFormatted(opts, "%v\n", value)
 	}
 }
 
-// WriteRawJSON writes data as pretty JSON.
+// Stdout returns where rendered output goes: opts.Stdout, else os.Stdout.
+func Stdout(opts app.Options) io.Writer {
+	if opts.Stdout != nil {
+		return opts.Stdout
+	}
+
+	return os.Stdout
+}
+
+// Stderr returns where progress and warnings go: opts.Stderr, else
+// os.Stderr.
+func Stderr(opts app.Options) io.Writer {
+	if opts.Stderr != nil {
+		return opts.Stderr
+	}
+
+	return os.Stderr
+}
+
+// WriteRawJSON writes data as pretty JSON, redacted with --redact.
 func WriteRawJSON(opts app.Options, data any) error {
 	if opts.Quiet {
 		return nil
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
+	if opts.Redact {
+		redacted, err := redactJSON(data)
+		if err != nil {
+			return err
+		}
+
+		data = redacted
+	}
+
+	encoder := json.NewEncoder(Stdout(opts))
 	encoder.SetIndent("", "  ")
 
 	err := encoder.Encode(data)
@@ -52,8 +81,17 @@ func WriteRawJSON(opts app.Options, data any) error {
 	return nil
 }
 
-func writeJSONEnvelope(data any) error {
-	encoder := json.NewEncoder(os.Stdout)
+func writeJSONEnvelope(opts app.Options, data any) error {
+	if opts.Redact {
+		redacted, err := redactJSON(data)
+		if err != nil {
+			return err
+		}
+
+		data = redacted
+	}
+
+	encoder := json.NewEncoder(Stdout(opts))
 	encoder.SetIndent("", "  ")
 
 	err := encoder.Encode(envelope{Ok: true, Data: data, Meta: nil})
@@ -65,8 +103,8 @@ func writeJSONEnvelope(data any) error {
 }
 
 // WriteLine writes a single line to stdout.
-func WriteLine(value string) error {
-	_, err := fmt.Fprintln(os.Stdout, value)
+func WriteLine(opts app.Options, value string) error {
+	_, err := fmt.Fprintln(Stdout(opts), value)
 	if err != nil {
 		return fmt.Errorf("write output: %w", err)
 	}
@@ -75,23 +113,49 @@ func WriteLine(value string) error {
 }
 
 // WriteLines writes a list of lines to stdout.
-func WriteLines(lines []string) error {
-	for _, line := range lines {
-		err := WriteLine(line)
-		if err != nil {
-			return err
-		}
+func WriteLines(opts app.Options, lines []string) error {
+	return writeLines(Stdout(opts), lines)
+}
+
+// WriteRaw writes bytes to stdout unchanged.
+func WriteRaw(opts app.Options, data []byte) error {
+	_, err := Stdout(opts).Write(data)
+	if err != nil {
+		return fmt.Errorf("write output: %w", err)
 	}
 
 	return nil
 }
 
 // WriteFormatted writes a formatted line to stdout.
-func WriteFormatted(format string, value any) error {
-	_, err := fmt.Fprintf(os.Stdout, format, value)
+func WriteFormatted(opts app.Options, format string, value any) error {
+	_, err := fmt.Fprintf(Stdout(opts), format, value)
 	if err != nil {
 		return fmt.Errorf("write output: %w", err)
 	}
 
 	return nil
 }
+
+// WriteProgress writes a progress line to stderr unless quiet.
+func WriteProgress(opts app.Options, value string) error {
+	return writeStderr(opts, value)
+}
+
+// WriteWarning writes a warning line to stderr unless quiet.
+func WriteWarning(opts app.Options, value string) error {
+	return writeStderr(opts, value)
+}
+
+func writeStderr(opts app.Options, value string) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(Stderr(opts), value)
+	if err != nil {
+		return fmt.Errorf("write stderr: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,601 @@
+package output
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	randv2 "math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	redactPrefix      = "anon-"
+	redactEmailDomain = "@example.invalid"
+	redactHashBytes   = 4
+	redactKeyBytes    = 32
+	redactExponent    = "eE"
+	redactDecimal     = "."
+	redactPlus        = "+"
+	redactUnitSplit   = " "
+	redactHalf        = 0.5
+	// redactJitterMin and redactJitter bound the relative change --redact
+	// applies to a value: enough that exact readings never survive, small
+	// enough that trends stay recognizable.
+	redactJitterMin = 0.005
+	redactJitter    = 0.03
+	// The columns and JSON keys redacted together (see redactChange).
+	relatedValue         = "value"
+	relatedPrevious      = "previous"
+	relatedChange        = "change"
+	relatedValueIndex    = 0
+	relatedPreviousIndex = 1
+	relatedChangeIndex   = 2
+	relatedCount         = 3
+	noColumn             = -1
+	durationHoursGroup   = 1
+	durationMinutesGroup = 2
+	minutesPerHour       = 60
+)
+
+// redactedKeys are the columns and JSON keys --redact replaces with a
+// hash.
+//
+//nolint:gochecknoglobals // Static lookup table.
+var redactedKeys = map[string]bool{
+	"device":        true,
+	"device_id":     true,
+	"deviceid":      true,
+	"email":         true,
+	"hash_deviceid": true,
+	"mac":           true,
+	"mac_address":   true,
+	"signal_id":     true,
+	"signalid":      true,
+	"user_id":       true,
+	"userid":        true,
+}
+
+// keptKeys are the columns and JSON keys whose numbers are timestamps,
+// codes, or enums, which --redact leaves unchanged. Keys ending in "date"
+// are kept as well.
+//
+//nolint:gochecknoglobals // Static lookup table.
+var keptKeys = map[string]bool{
+	"algo":         true,
+	"appli":        true,
+	"attrib":       true,
+	"category":     true,
+	"created":      true,
+	"end":          true,
+	"expires":      true,
+	"firmware":     true,
+	"fm":           true,
+	"grpid":        true,
+	"id":           true,
+	"kind":         true,
+	"last_session": true,
+	"lastupdate":   true,
+	"mode":         true,
+	"model":        true,
+	"model_id":     true,
+	"modified":     true,
+	"more":         true,
+	"offset":       true,
+	"start":        true,
+	"status":       true,
+	"time":         true,
+	"timestamp":    true,
+	"timezone":     true,
+	"type":         true,
+	"unit":         true,
+	"updatetime":   true,
+}
+
+// durationPattern matches durations written as 7h56m.
+//
+//nolint:gochecknoglobals // Compiled once.
+var durationPattern = regexp.MustCompile(`^(\d+)h(\d{2})m$`)
+
+// emailPattern finds email addresses in free text.
+//
+//nolint:gochecknoglobals // Compiled once.
+var emailPattern = regexp.MustCompile(
+	`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`,
+)
+
+// redactKey keys the identifier hashes. It is random per run, so hashes
+// of short identifiers such as user IDs cannot be reversed by trying
+// every value, while the same ID still maps to the same hash within one
+// output.
+//
+//nolint:gochecknoglobals // One key per process.
+var redactKey = sync.OnceValue(func() []byte {
+	key := make([]byte, redactKeyBytes)
+	_, _ = rand.Read(key)
+
+	return key
+})
+
+// RedactValue returns value with --redact applied as for --json output,
+// for commands that format typed data themselves. Without --redact value
+// is returned unchanged.
+func RedactValue[T any](opts app.Options, value T) (T, error) {
+	if !opts.Redact {
+		return value, nil
+	}
+
+	redacted, err := redactJSON(value)
+	if err != nil {
+		return value, err
+	}
+
+	encoded, err := json.Marshal(redacted)
+	if err != nil {
+		return value, fmt.Errorf("encode redacted output: %w", err)
+	}
+
+	var decoded T
+
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		return value, fmt.Errorf("decode redacted output: %w", err)
+	}
+
+	return decoded, nil
+}
+
+// RedactCells returns the cells of column key with --redact applied, for
+// output that prints bare values without a header.
+func RedactCells(opts app.Options, key string, cells []string) []string {
+	if !opts.Redact {
+		return cells
+	}
+
+	redacted := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		redacted = append(redacted, redactCell(key, cell))
+	}
+
+	return redacted
+}
+
+// redactRows hashes identifiers and jitters numbers in the rows after the
+// header, whose column names are keys. A change column is derived from
+// the redacted value and previous columns (see redactChange).
+func redactRows(keys string, lines []string) []string {
+	if len(lines) <= headerRows {
+		return lines
+	}
+
+	columns := strings.Split(keys, plainSeparator)
+	related := relatedColumns(columns)
+	redacted := make([]string, 0, len(lines))
+	redacted = append(redacted, lines[0])
+
+	for _, line := range lines[headerRows:] {
+		cells := strings.Split(line, plainSeparator)
+		done := redactRelatedCells(related, cells)
+
+		for index, cell := range cells {
+			if done[index] {
+				continue
+			}
+
+			column := ""
+			if index < len(columns) {
+				column = columns[index]
+			}
+
+			cells[index] = redactCell(column, cell)
+		}
+
+		redacted = append(redacted, strings.Join(cells, plainSeparator))
+	}
+
+	return redacted
+}
+
+// relatedColumns returns the indexes of the value, previous, and change
+// columns, -1 when absent.
+func relatedColumns(columns []string) [relatedCount]int {
+	related := [relatedCount]int{noColumn, noColumn, noColumn}
+
+	for index, column := range columns {
+		switch strings.ToLower(column) {
+		case relatedValue:
+			related[relatedValueIndex] = index
+		case relatedPrevious:
+			related[relatedPreviousIndex] = index
+		case relatedChange:
+			related[relatedChangeIndex] = index
+		}
+	}
+
+	return related
+}
+
+// redactRelatedCells redacts the value, previous, and change cells of a
+// row together and reports which cells it handled.
+func redactRelatedCells(
+	related [relatedCount]int,
+	cells []string,
+) map[int]bool {
+	valueIndex := related[relatedValueIndex]
+	previousIndex := related[relatedPreviousIndex]
+	changeIndex := related[relatedChangeIndex]
+
+	if valueIndex == noColumn || changeIndex == noColumn ||
+		valueIndex >= len(cells) || changeIndex >= len(cells) ||
+		previousIndex >= len(cells) {
+		return nil
+	}
+
+	previous := ""
+	if previousIndex != noColumn {
+		previous = cells[previousIndex]
+	}
+
+	value, previous, change, ok := redactChange(
+		cells[valueIndex],
+		previous,
+		cells[changeIndex],
+	)
+	if !ok {
+		return nil
+	}
+
+	cells[valueIndex], cells[changeIndex] = value, change
+	done := map[int]bool{valueIndex: true, changeIndex: true}
+
+	if previousIndex != noColumn {
+		cells[previousIndex] = previous
+		done[previousIndex] = true
+	}
+
+	return done
+}
+
+// redactCell redacts one table or plain cell. A number followed by a
+// unit, such as "72.4 kg", keeps the unit.
+func redactCell(key, cell string) string {
+	key = strings.ToLower(key)
+
+	switch {
+	case cell == "":
+		return cell
+	case redactedKeys[key]:
+		return redactIdentifier(cell)
+	case keptKey(key):
+		return redactEmails(cell)
+	}
+
+	if jittered, ok := jitterDuration(cell); ok {
+		return jittered
+	}
+
+	head, rest, found := strings.Cut(cell, redactUnitSplit)
+
+	jittered, ok := jitterNumber(head)
+	if !ok {
+		return redactEmails(cell)
+	}
+
+	if found {
+		return jittered + redactUnitSplit + rest
+	}
+
+	return jittered
+}
+
+// redactJSON returns data with identifiers hashed and numbers jittered,
+// decoded into generic JSON so every command's shape is covered.
+func redactJSON(data any) (any, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode json output: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var decoded any
+
+	err = decoder.Decode(&decoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode json output: %w", err)
+	}
+
+	return redactJSONValue("", decoded), nil
+}
+
+func redactJSONValue(key string, value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		done := redactJSONChange(typed)
+
+		for name, child := range typed {
+			if done && relatedKey(name) {
+				continue
+			}
+
+			typed[name] = redactJSONValue(name, child)
+		}
+
+		return typed
+	case []any:
+		for index, child := range typed {
+			typed[index] = redactJSONValue(key, child)
+		}
+
+		return typed
+	case json.Number:
+		return redactJSONNumber(key, typed)
+	case string:
+		return redactJSONString(key, typed)
+	default:
+		return value
+	}
+}
+
+// redactJSONChange redacts the value, previous, and change numbers of an
+// object together, as redactRelatedCells does for rows.
+func redactJSONChange(object map[string]any) bool {
+	value, okValue := object[relatedValue].(json.Number)
+	change, okChange := object[relatedChange].(json.Number)
+
+	if !okValue || !okChange {
+		return false
+	}
+
+	previous, hasPrevious := object[relatedPrevious].(json.Number)
+
+	redactedValue, redactedPrevious, redactedChange, ok := redactChange(
+		value.String(),
+		previous.String(),
+		change.String(),
+	)
+	if !ok {
+		return false
+	}
+
+	object[relatedValue] = json.Number(redactedValue)
+	object[relatedChange] = json.Number(
+		strings.TrimPrefix(redactedChange, redactPlus),
+	)
+
+	if hasPrevious {
+		object[relatedPrevious] = json.Number(redactedPrevious)
+	}
+
+	return true
+}
+
+func redactJSONNumber(key string, number json.Number) any {
+	key = strings.ToLower(key)
+
+	switch {
+	case redactedKeys[key]:
+		return redactIdentifier(number.String())
+	case keptKey(key):
+		return number
+	}
+
+	jittered, ok := jitterNumber(number.String())
+	if !ok {
+		return number
+	}
+
+	return json.Number(strings.TrimPrefix(jittered, redactPlus))
+}
+
+func redactJSONString(key, value string) string {
+	if value != "" && redactedKeys[strings.ToLower(key)] {
+		return redactIdentifier(value)
+	}
+
+	return redactEmails(value)
+}
+
+func relatedKey(key string) bool {
+	return key == relatedValue || key == relatedPrevious ||
+		key == relatedChange
+}
+
+func keptKey(key string) bool {
+	return keptKeys[key] || strings.HasSuffix(key, "date")
+}
+
+// redactIdentifier replaces value with a short keyed hash; emails stay
+// recognizable as emails.
+func redactIdentifier(value string) string {
+	if emailPattern.MatchString(value) {
+		return emailPattern.ReplaceAllStringFunc(value, hashEmail)
+	}
+
+	return redactPrefix + hashValue(value)
+}
+
+func redactEmails(value string) string {
+	return emailPattern.ReplaceAllStringFunc(value, hashEmail)
+}
+
+func hashEmail(email string) string {
+	return redactPrefix +
+		hashValue(strings.ToLower(email)) +
+		redactEmailDomain
+}
+
+func hashValue(value string) string {
+	mac := hmac.New(sha256.New, redactKey())
+	_, _ = mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil)[:redactHashBytes])
+}
+
+// jitterFactor draws the factor --redact multiplies a value by: a change
+// of redactJitterMin to redactJitter, up or down.
+func jitterFactor() float64 {
+	//nolint:gosec // Display jitter, not a secret.
+	change := redactJitterMin +
+		(redactJitter-redactJitterMin)*randv2.Float64()
+	//nolint:gosec // Display jitter, not a secret.
+	if randv2.Float64() < redactHalf {
+		change = -change
+	}
+
+	return 1 + change
+}
+
+// jitterNumber moves the number in text by a fresh jitterFactor.
+func jitterNumber(text string) (string, bool) {
+	return scaleNumber(text, jitterFactor())
+}
+
+// scaleNumber multiplies the number in text by factor, keeping its
+// decimals and an explicit sign. Integers stay integers and zero stays
+// zero.
+func scaleNumber(text string, factor float64) (string, bool) {
+	value, decimals, ok := parseRedactNumber(text)
+	if !ok {
+		return "", false
+	}
+
+	return formatRedactNumber(text, value*factor, decimals), true
+}
+
+// parseRedactNumber reads a plain decimal number and its decimal places;
+// exponents, infinities, and NaN are left alone.
+func parseRedactNumber(text string) (float64, int, bool) {
+	if strings.ContainsAny(text, redactExponent) {
+		return 0, 0, false
+	}
+
+	value, err := strconv.ParseFloat(text, computedBitSize)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, 0, false
+	}
+
+	decimals := 0
+	if _, fraction, ok := strings.Cut(text, redactDecimal); ok {
+		decimals = len(fraction)
+	}
+
+	return value, decimals, true
+}
+
+// formatRedactNumber formats value with decimals places, keeping the
+// explicit plus sign of original.
+func formatRedactNumber(original string, value float64, decimals int) string {
+	formatted := strconv.FormatFloat(
+		value,
+		computedFormat,
+		decimals,
+		computedBitSize,
+	)
+
+	if strings.HasPrefix(original, redactPlus) && value >= 0 {
+		formatted = redactPlus + formatted
+	}
+
+	return formatted
+}
+
+// jitterDuration moves a duration written as 7h56m by a fresh
+// jitterFactor, to the minute.
+func jitterDuration(text string) (string, bool) {
+	match := durationPattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+
+	hours, _ := strconv.Atoi(match[durationHoursGroup])
+	minutes, _ := strconv.Atoi(match[durationMinutesGroup])
+	total := int(math.Round(
+		float64(hours*minutesPerHour+minutes) * jitterFactor(),
+	))
+
+	return fmt.Sprintf(
+		"%dh%02dm",
+		total/minutesPerHour,
+		total%minutesPerHour,
+	), true
+}
+
+// redactChange redacts the value, previous, and change cells of one row
+// with a single factor and derives the change from the redacted value and
+// previous, so the three still agree. previous is empty when the output
+// only shows the change; it is then taken as value minus change. ok is
+// false when the cells are not numbers, and they are redacted one by one
+// instead.
+func redactChange(value, previous, change string) (
+	string,
+	string,
+	string,
+	bool,
+) {
+	valueHead, valueUnit := cutUnit(value)
+	previousHead, previousUnit := cutUnit(previous)
+	changeHead, changeUnit := cutUnit(change)
+
+	rawValue, valueDecimals, okValue := parseRedactNumber(valueHead)
+	rawChange, _, okChange := parseRedactNumber(changeHead)
+	rawPrevious, previousDecimals := rawValue-rawChange, valueDecimals
+
+	okPrevious := true
+	if previous != "" {
+		rawPrevious, previousDecimals, okPrevious = parseRedactNumber(
+			previousHead,
+		)
+	}
+
+	if !okValue || !okChange || !okPrevious {
+		return "", "", "", false
+	}
+
+	factor := jitterFactor()
+	decimals := max(valueDecimals, previousDecimals)
+	redactedValue := roundTo(rawValue*factor, valueDecimals)
+	redactedPrevious := roundTo(rawPrevious*factor, previousDecimals)
+	redactedChange := roundTo(redactedValue-redactedPrevious, decimals)
+
+	if previous != "" {
+		previous = formatRedactNumber(
+			previousHead,
+			redactedPrevious,
+			previousDecimals,
+		) + previousUnit
+	}
+
+	value = formatRedactNumber(valueHead, redactedValue, valueDecimals) +
+		valueUnit
+	change = formatRedactNumber(changeHead, redactedChange, decimals) +
+		changeUnit
+
+	return value, previous, change, true
+}
+
+// cutUnit splits "72.4 kg" into the number and " kg".
+func cutUnit(cell string) (string, string) {
+	head, rest, found := strings.Cut(cell, redactUnitSplit)
+	if !found {
+		return cell, ""
+	}
+
+	return head, redactUnitSplit + rest
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,214 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const redactTestEmail = "jane.doe@example.com"
+
+// TestRedactRows hashes identifiers consistently, keeps times, and
+// jitters values within bounds without changing their precision.
+func TestRedactRows(t *testing.T) {
+	t.Parallel()
+
+	keys := "time\tvalue\tunit\tdevice_id\tcomment"
+	lines := []string{
+		keys,
+		"2025-01-01T07:00:00Z\t72.40\tkg\tabc123\tby " + redactTestEmail,
+		"2025-01-02T07:00:00Z\t80 kg\tkg\tabc123\t",
+	}
+
+	got := redactRows(keys, lines)
+	first := strings.Split(got[1], plainSeparator)
+	second := strings.Split(got[2], plainSeparator)
+
+	if got[0] != keys || first[0] != "2025-01-01T07:00:00Z" ||
+		first[2] != "kg" {
+		t.Fatalf("kept cells changed: %q", got)
+	}
+
+	if !strings.HasPrefix(first[3], redactPrefix) || first[3] != second[3] {
+		t.Fatalf("device ids %q and %q", first[3], second[3])
+	}
+
+	if strings.Contains(got[1], redactTestEmail) ||
+		!strings.HasSuffix(first[4], redactEmailDomain) {
+		t.Fatalf("email not hashed: %q", first[4])
+	}
+
+	assertJittered(t, first[1], 72.40, 2)
+
+	value, unit, _ := strings.Cut(second[1], " ")
+	if unit != "kg" {
+		t.Fatalf("unit dropped: %q", second[1])
+	}
+
+	assertJittered(t, value, 80, 0)
+}
+
+// TestRedactJSON walks generic JSON, keeping dates and codes.
+func TestRedactJSON(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"userid": 1234567,
+		"measuregrps": []any{map[string]any{
+			"date":     1735714800,
+			"deviceid": "abc123",
+			"measures": []any{map[string]any{
+				"value": 72400,
+				"type":  1,
+				"unit":  -3,
+			}},
+		}},
+	}
+
+	redacted, err := redactJSON(data)
+	if err != nil {
+		t.Fatalf("redactJSON: %v", err)
+	}
+
+	encoded, err := json.Marshal(redacted)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	text := string(encoded)
+	for _, leaked := range []string{"1234567", "abc123", "72400"} {
+		if strings.Contains(text, leaked) {
+			t.Fatalf("%s leaked in %s", leaked, text)
+		}
+	}
+
+	for _, kept := range []string{
+		`"date":1735714800`,
+		`"type":1`,
+		`"unit":-3`,
+	} {
+		if !strings.Contains(text, kept) {
+			t.Fatalf("%s missing in %s", kept, text)
+		}
+	}
+}
+
+// TestRedactRowsChange derives the change column from the redacted value
+// and previous columns, with or without a previous column.
+func TestRedactRowsChange(t *testing.T) {
+	t.Parallel()
+
+	const summaryKeys = "metric\tvalue\tprevious\tchange"
+
+	for _, test := range []struct {
+		keys string
+		line string
+	}{
+		{keys: summaryKeys, line: "steps\t61416\t61209\t207"},
+		{keys: summaryKeys, line: "weight\t80.6\t79.6\t+1"},
+		{keys: "segment\tvalue\tchange", line: "weight\t78.6\t-0.4"},
+	} {
+		got := redactRows(test.keys, []string{test.keys, test.line})
+		cells := strings.Split(got[1], plainSeparator)
+		columns := strings.Split(test.keys, plainSeparator)
+		raw := strings.Split(test.line, plainSeparator)
+
+		value := parseTestFloat(t, cells[1])
+		change := parseTestFloat(t, cells[len(cells)-1])
+		rawValue := parseTestFloat(t, raw[1])
+		rawPrevious := rawValue - parseTestFloat(t, raw[len(raw)-1])
+		previous := value - change
+
+		if len(columns) == len(raw) && len(columns) == 4 {
+			previous = parseTestFloat(t, cells[2])
+		}
+
+		// One factor per row scales the implied previous value like value.
+		if cells[1] == raw[1] || math.Abs(value-previous-change) > 0.051 ||
+			math.Abs(previous/rawPrevious-value/rawValue) > 0.01 {
+			t.Fatalf("%q: value %v previous %v change %v", got[1], value,
+				previous, change)
+		}
+	}
+}
+
+// TestRedactJSONChange keeps value - previous = change in JSON objects.
+func TestRedactJSONChange(t *testing.T) {
+	t.Parallel()
+
+	redacted, err := redactJSON(map[string]any{
+		"name": "steps", "value": 61416, "previous": 61209, "change": 207,
+	})
+	if err != nil {
+		t.Fatalf("redactJSON: %v", err)
+	}
+
+	object, _ := redacted.(map[string]any)
+	value, _ := object["value"].(json.Number).Int64()
+	previous, _ := object["previous"].(json.Number).Int64()
+	change, _ := object["change"].(json.Number).Int64()
+
+	if value == 61416 || value-previous != change {
+		t.Fatalf("got %v", object)
+	}
+}
+
+// TestRedactDurationsAndCells jitters 7h56m durations and bare cells.
+func TestRedactDurationsAndCells(t *testing.T) {
+	t.Parallel()
+
+	if got := redactCell("value", "7h56m"); got == "7h56m" ||
+		!durationPattern.MatchString(got) {
+		t.Fatalf("duration got %q", got)
+	}
+
+	var opts app.Options
+
+	got := RedactCells(opts, "value", []string{"78.571"})
+	if got[0] != "78.571" {
+		t.Fatalf("without --redact got %q", got)
+	}
+
+	opts.Redact = true
+
+	got = RedactCells(opts, "value", []string{"78.571"})
+	assertJittered(t, got[0], 78.571, 3)
+
+	if got[0] == "78.571" {
+		t.Fatalf("value leaked: %q", got)
+	}
+}
+
+func parseTestFloat(t *testing.T, text string) float64 {
+	t.Helper()
+
+	value, err := strconv.ParseFloat(text, computedBitSize)
+	if err != nil {
+		t.Fatalf("parse %q: %v", text, err)
+	}
+
+	return value
+}
+
+func assertJittered(t *testing.T, got string, want float64, decimals int) {
+	t.Helper()
+
+	_, fraction, _ := strings.Cut(got, redactDecimal)
+	if len(fraction) != decimals {
+		t.Fatalf("%q lost its precision", got)
+	}
+
+	value, err := strconv.ParseFloat(got, computedBitSize)
+	if err != nil {
+		t.Fatalf("parse %q: %v", got, err)
+	}
+
+	if value < want*(1-redactJitter)-1 || value > want*(1+redactJitter)+1 {
+		t.Fatalf("%v jittered too far from %v", value, want)
+	}
+}
@@ -0,0 +1,305 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	rotateRowsSuffix = "rows"
+	rotateByteSuffix = "b"
+	rotateKilo       = 1000
+	rotateFileMode   = 0o600
+	rotateIndexWidth = 4
+	rotateFirstIndex = 1
+	manifestSuffix   = ".manifest.json"
+	rotateBase10     = 10
+	rotateBitSize    = 64
+)
+
+var errInvalidRotation = errors.New("invalid --rotate")
+
+// rotateSizeUnits are decimal (SI), so 10MB is 10,000,000 bytes.
+//
+//nolint:gochecknoglobals // Static lookup table for size suffixes.
+var rotateSizeUnits = []struct {
+	Suffix string
+	Scale  int64
+}{
+	{Suffix: "gb", Scale: rotateKilo * rotateKilo * rotateKilo},
+	{Suffix: "mb", Scale: rotateKilo * rotateKilo},
+	{Suffix: "kb", Scale: rotateKilo},
+	{Suffix: rotateByteSuffix, Scale: 1},
+}
+
+// Rotation limits how large a single output file may grow.
+type Rotation struct {
+	MaxBytes int64
+	MaxRows  int64
+}
+
+// Enabled reports whether any rotation limit is set.
+func (r Rotation) Enabled() bool {
+	return r.MaxBytes > 0 || r.MaxRows > 0
+}
+
+// ParseRotation parses specs like "100MB", "512KB", or "1000000rows".
+func ParseRotation(spec string) (Rotation, error) {
+	normalized := strings.ToLower(strings.TrimSpace(spec))
+	if normalized == "" {
+		return Rotation{MaxBytes: 0, MaxRows: 0}, nil
+	}
+
+	if count, ok := strings.CutSuffix(normalized, rotateRowsSuffix); ok {
+		rows, err := parsePositive(count, spec)
+		if err != nil {
+			return Rotation{}, err
+		}
+
+		return Rotation{MaxBytes: 0, MaxRows: rows}, nil
+	}
+
+	for _, unit := range rotateSizeUnits {
+		size, ok := strings.CutSuffix(normalized, unit.Suffix)
+		if !ok {
+			continue
+		}
+
+		value, err := parsePositive(size, spec)
+		if err != nil {
+			return Rotation{}, err
+		}
+
+		return Rotation{MaxBytes: value * unit.Scale, MaxRows: 0}, nil
+	}
+
+	return Rotation{}, fmt.Errorf("%w: %q", errInvalidRotation, spec)
+}
+
+func parsePositive(raw, spec string) (int64, error) {
+	value, err := strconv.ParseInt(
+		strings.TrimSpace(raw),
+		rotateBase10,
+		rotateBitSize,
+	)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("%w: %q", errInvalidRotation, spec)
+	}
+
+	return value, nil
+}
+
+// ManifestFile describes one file written by a RotatingCSV.
+type ManifestFile struct {
+	Path  string `json:"path"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Manifest lists the files written by a RotatingCSV in order.
+type Manifest struct {
+	Header []string       `json:"header"`
+	Rows   int64          `json:"rows"`
+	Files  []ManifestFile `json:"files"`
+}
+
+// RotatingCSV writes CSV records to numbered files, starting a new file
+// (with the header repeated) whenever a rotation limit would be exceeded.
+// Without rotation it writes a single file at the given path.
+type RotatingCSV struct {
+	path     string
+	header   []string
+	rotation Rotation
+	file     *os.File
+	current  ManifestFile
+	manifest Manifest
+}
+
+// NewRotatingCSV prepares a writer; files are created on first write.
+func NewRotatingCSV(
+	path string,
+	header []string,
+	rotation Rotation,
+) *RotatingCSV {
+	return &RotatingCSV{
+		path:     path,
+		header:   header,
+		rotation: rotation,
+		file:     nil,
+		current:  ManifestFile{Path: "", Rows: 0, Bytes: 0},
+		manifest: Manifest{Header: header, Rows: 0, Files: []ManifestFile{}},
+	}
+}
+
+// Write appends one record, rotating first if needed.
+func (w *RotatingCSV) Write(record []string) error {
+	encoded, err := encodeCSV(record)
+	if err != nil {
+		return err
+	}
+
+	if w.file == nil || w.shouldRotate(int64(len(encoded))) {
+		err = w.open()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.writeBytes(encoded)
+	if err != nil {
+		return err
+	}
+
+	w.current.Rows++
+	w.manifest.Rows++
+
+	return nil
+}
+
+// Close finishes the current file and, when rotating, writes the manifest
+// next to the output files. It returns the manifest for reporting.
+func (w *RotatingCSV) Close() (Manifest, error) {
+	if w.file == nil && len(w.manifest.Files) == 0 {
+		err := w.open()
+		if err != nil {
+			return w.manifest, err
+		}
+	}
+
+	err := w.closeFile()
+	if err != nil {
+		return w.manifest, err
+	}
+
+	if !w.rotation.Enabled() {
+		return w.manifest, nil
+	}
+
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return w.manifest, fmt.Errorf("encode manifest: %w", err)
+	}
+
+	err = os.WriteFile(
+		ManifestPath(w.path),
+		append(data, '\n'),
+		rotateFileMode,
+	)
+	if err != nil {
+		return w.manifest, fmt.Errorf("write manifest: %w", err)
+	}
+
+	return w.manifest, nil
+}
+
+// ManifestPath returns the manifest location for an output path.
+func ManifestPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + manifestSuffix
+}
+
+// RotatedPath returns the numbered file name for a rotation index.
+func RotatedPath(path string, index int) string {
+	ext := filepath.Ext(path)
+
+	return fmt.Sprintf(
+		"%s-%0*d%s",
+		strings.TrimSuffix(path, ext),
+		rotateIndexWidth,
+		index,
+		ext,
+	)
+}
+
+func (w *RotatingCSV) shouldRotate(size int64) bool {
+	if !w.rotation.Enabled() || w.current.Rows == 0 {
+		return false
+	}
+
+	if w.rotation.MaxRows > 0 && w.current.Rows >= w.rotation.MaxRows {
+		return true
+	}
+
+	return w.rotation.MaxBytes > 0 &&
+		w.current.Bytes+size > w.rotation.MaxBytes
+}
+
+func (w *RotatingCSV) open() error {
+	err := w.closeFile()
+	if err != nil {
+		return err
+	}
+
+	path := w.path
+	if w.rotation.Enabled() {
+		path = RotatedPath(w.path, len(w.manifest.Files)+rotateFirstIndex)
+	}
+
+	//nolint:gosec // Output path is user-controlled by design.
+	file, err := os.OpenFile(
+		path,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		rotateFileMode,
+	)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+
+	w.file = file
+	w.current = ManifestFile{Path: path, Rows: 0, Bytes: 0}
+
+	header, err := encodeCSV(w.header)
+	if err != nil {
+		return err
+	}
+
+	return w.writeBytes(header)
+}
+
+func (w *RotatingCSV) writeBytes(data []byte) error {
+	written, err := w.file.Write(data)
+	w.current.Bytes += int64(written)
+
+	if err != nil {
+		return fmt.Errorf("write output file: %w", err)
+	}
+
+	return nil
+}
+
+func (w *RotatingCSV) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+	w.manifest.Files = append(w.manifest.Files, w.current)
+
+	if err != nil {
+		return fmt.Errorf("close output file: %w", err)
+	}
+
+	return nil
+}
+
+func encodeCSV(record []string) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write(record)
+	writer.Flush()
+
+	err := writer.Error()
+	if err != nil {
+		return nil, fmt.Errorf("encode csv: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,158 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	rotateTestRows    = 5
+	rotateTestMaxRows = 2
+	rotateTestFiles   = 3
+	rotateTestGotFmt  = "%s got %v want %v"
+)
+
+// TestParseRotation accepts size and row-count specs.
+func TestParseRotation(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		spec string
+		want Rotation
+	}{
+		{spec: "", want: Rotation{MaxBytes: 0, MaxRows: 0}},
+		{spec: "100MB", want: Rotation{MaxBytes: 100e6, MaxRows: 0}},
+		{spec: "2kb", want: Rotation{MaxBytes: 2e3, MaxRows: 0}},
+		{spec: "1GB", want: Rotation{MaxBytes: 1e9, MaxRows: 0}},
+		{spec: "512b", want: Rotation{MaxBytes: 512, MaxRows: 0}},
+		{spec: "1000000rows", want: Rotation{MaxBytes: 0, MaxRows: 1000000}},
+	}
+
+	for _, test := range cases {
+		got, err := ParseRotation(test.spec)
+		if err != nil {
+			t.Fatalf("ParseRotation(%q): %v", test.spec, err)
+		}
+
+		if got != test.want {
+			t.Fatalf(rotateTestGotFmt, test.spec, got, test.want)
+		}
+	}
+
+	for _, spec := range []string{"10", "0rows", "-5MB", "fastMB", "3TB"} {
+		_, err := ParseRotation(spec)
+		if !errors.Is(err, errInvalidRotation) {
+			t.Fatalf("spec %q err got %v", spec, err)
+		}
+	}
+}
+
+// TestRotatingCSVRows starts a new numbered file every N rows.
+func TestRotatingCSVRows(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	writer := NewRotatingCSV(
+		path,
+		[]string{"time", "steps"},
+		Rotation{MaxBytes: 0, MaxRows: rotateTestMaxRows},
+	)
+
+	for index := range rotateTestRows {
+		err := writer.Write([]string{"t", strings.Repeat("1", index+1)})
+		if err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	manifest, err := writer.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if len(manifest.Files) != rotateTestFiles {
+		t.Fatalf(
+			rotateTestGotFmt,
+			"files",
+			len(manifest.Files),
+			rotateTestFiles,
+		)
+	}
+
+	if manifest.Rows != rotateTestRows {
+		t.Fatalf(rotateTestGotFmt, "rows", manifest.Rows, rotateTestRows)
+	}
+
+	last, err := os.ReadFile(RotatedPath(path, rotateTestFiles))
+	if err != nil {
+		t.Fatalf("read last file: %v", err)
+	}
+
+	if string(last) != "time,steps\nt,11111\n" {
+		t.Fatalf("last file got %q", last)
+	}
+
+	_, err = os.Stat(ManifestPath(path))
+	if err != nil {
+		t.Fatalf("manifest missing: %v", err)
+	}
+}
+
+// TestRotatingCSVBytes rotates before a row would exceed the size limit.
+func TestRotatingCSVBytes(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	writer := NewRotatingCSV(
+		path,
+		[]string{"a"},
+		Rotation{MaxBytes: 5, MaxRows: 0},
+	)
+
+	for range rotateTestMaxRows {
+		err := writer.Write([]string{"1"})
+		if err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	manifest, err := writer.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if len(manifest.Files) != rotateTestMaxRows {
+		t.Fatalf(rotateTestGotFmt, "files", len(manifest.Files), 2)
+	}
+}
+
+// TestRotatingCSVSingleFile writes the exact path without a manifest.
+func TestRotatingCSVSingleFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "export.csv")
+	writer := NewRotatingCSV(
+		path,
+		[]string{"a"},
+		Rotation{MaxBytes: 0, MaxRows: 0},
+	)
+
+	_, err := writer.Close()
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "a\n" {
+		t.Fatalf("file got %q, %v", data, err)
+	}
+
+	_, err = os.Stat(ManifestPath(path))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("unexpected manifest: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package output
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// ErrUnknownSort indicates a --sort value that names no column.
+var ErrUnknownSort = errors.New("unknown --sort column")
+
+// sortTime is the --sort name for a command's time column.
+const sortTime = "time"
+
+// sortTimeColumns are the columns --sort time falls back to, in order,
+// for commands whose rows have no time column.
+//
+//nolint:gochecknoglobals // Static fallback list.
+var sortTimeColumns = []string{"date", "start"}
+
+// sortRows orders the rows after the header by the --sort column of keys,
+// numerically when both cells are numbers and as text otherwise. Empty
+// cells go last; equal rows keep the API order.
+func sortRows(opts app.Options, keys string, lines []string) (
+	[]string,
+	error,
+) {
+	if opts.Sort == "" || len(lines) <= headerRows {
+		return lines, nil
+	}
+
+	columns := strings.Split(keys, plainSeparator)
+
+	index, err := sortIndex(opts.Sort, columns)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	rows := slices.Clone(lines[headerRows:])
+	slices.SortStableFunc(rows, func(left, right string) int {
+		return compareCells(
+			cellAt(left, index),
+			cellAt(right, index),
+			opts.SortDesc,
+		)
+	})
+
+	return append([]string{lines[0]}, rows...), nil
+}
+
+func sortIndex(name string, columns []string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	candidates := []string{name}
+	if name == sortTime {
+		candidates = append(candidates, sortTimeColumns...)
+	}
+
+	for _, candidate := range candidates {
+		if index := slices.Index(columns, candidate); index >= 0 {
+			return index, nil
+		}
+	}
+
+	return 0, fmt.Errorf(
+		"%w %q (available: %s)",
+		ErrUnknownSort,
+		name,
+		strings.Join(columns, fieldSeparator),
+	)
+}
+
+func cellAt(line string, index int) string {
+	cells := strings.Split(line, plainSeparator)
+	if index < len(cells) {
+		return cells[index]
+	}
+
+	return ""
+}
+
+func compareCells(left, right string, desc bool) int {
+	switch {
+	case left == "" || right == "":
+		// Empty cells go last in both directions.
+		return strings.Compare(right, left)
+	case desc:
+		left, right = right, left
+	}
+
+	leftNumber, leftErr := strconv.ParseFloat(left, floatBits)
+	rightNumber, rightErr := strconv.ParseFloat(right, floatBits)
+
+	if leftErr == nil && rightErr == nil {
+		return cmp.Compare(leftNumber, rightNumber)
+	}
+
+	return strings.Compare(left, right)
+}
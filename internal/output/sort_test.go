@@ -0,0 +1,63 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestSortRows sorts numbers numerically and keeps empty cells last.
+func TestSortRows(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	lines := []string{
+		"date\tvalue",
+		"2025-01-02\t9",
+		"2025-01-01\t",
+		"2025-01-03\t10",
+	}
+
+	opts.Sort = "value"
+
+	got, err := sortRows(opts, lines[0], lines)
+	if err != nil {
+		t.Fatalf("sortRows: %v", err)
+	}
+
+	want := []string{lines[0], lines[1], lines[3], lines[2]}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	opts.Sort = "time"
+	opts.SortDesc = true
+
+	got, err = sortRows(opts, lines[0], lines)
+	if err != nil {
+		t.Fatalf("sortRows: %v", err)
+	}
+
+	want = []string{lines[0], lines[3], lines[1], lines[2]}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestSortRowsUnknownColumn lists the columns.
+func TestSortRowsUnknownColumn(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Sort = "pulse"
+
+	_, err := sortRows(opts, "time\tvalue", []string{"time\tvalue", "t1\t1"})
+	if !errors.Is(err, ErrUnknownSort) {
+		t.Fatalf("got %v, want %v", err, ErrUnknownSort)
+	}
+}
@@ -0,0 +1,164 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// ErrInvalidTemplate indicates a --format template that does not parse
+// or names no column.
+var ErrInvalidTemplate = errors.New("invalid --format template")
+
+const (
+	// templatePrefix is the optional kubectl-style prefix of a template.
+	templatePrefix = "go-template="
+	// templateKeyForms is the number of names each column is keyed by.
+	templateKeyForms = 3
+	floatBitSize     = 64
+	emptyCell        = ""
+)
+
+// templateInitialisms are the column name parts goName upper-cases.
+//
+//nolint:gochecknoglobals // Static lookup table.
+var templateInitialisms = map[string]bool{
+	"ahi": true,
+	"api": true,
+	"bmi": true,
+	"bmr": true,
+	"bp":  true,
+	"hr":  true,
+	"id":  true,
+	"mac": true,
+	"rr":  true,
+	"url": true,
+}
+
+// ParseTemplate parses a --format Go template, with or without the
+// "go-template=" prefix. Unknown fields fail instead of printing
+// "<no value>".
+func ParseTemplate(spec string) (*template.Template, error) {
+	parsed, err := template.New("format").
+		Option("missingkey=error").
+		Parse(strings.TrimPrefix(spec, templatePrefix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	return parsed, nil
+}
+
+// renderTemplate formats each tab-separated row after the header through
+// the --format template, with the row built by templateRow.
+func renderTemplate(opts app.Options, lines []string) ([]string, error) {
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := ParseTemplate(opts.Template)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	keys := strings.Split(lines[0], plainSeparator)
+	rendered := make([]string, 0, len(lines)-headerRows)
+
+	var buffer strings.Builder
+
+	for _, line := range lines[headerRows:] {
+		buffer.Reset()
+
+		err = parsed.Execute(&buffer, templateRow(keys, line))
+		if err != nil {
+			return nil, app.NewExitError(app.ExitCodeUsage, fmt.Errorf(
+				"%w: %w (available: %s)",
+				ErrInvalidTemplate,
+				err,
+				strings.Join(keys, fieldSeparator),
+			))
+		}
+
+		rendered = append(rendered, buffer.String())
+	}
+
+	return rendered, nil
+}
+
+// templateRow keys each cell by its plain column name (.bp_sys), its
+// CamelCase form (.BpSys), and its Go name with initialisms (.BPSys,
+// .DeviceID), so one template works for every command that shares the
+// column. Numeric cells, the ones --ndjson writes as numbers, become
+// templateNumber values; the rest stay strings.
+func templateRow(keys []string, line string) map[string]any {
+	cells := strings.Split(line, plainSeparator)
+	row := make(map[string]any, len(keys)*templateKeyForms)
+
+	for index, key := range keys {
+		var cell any = emptyCell
+
+		if index < len(cells) {
+			cell = cells[index]
+			if isJSONNumber(cells[index]) {
+				parsed, _ := strconv.ParseFloat(cells[index], floatBitSize)
+				cell = templateNumber(parsed)
+			}
+		}
+
+		row[key] = cell
+		row[camelCase(key)] = cell
+		row[goName(key)] = cell
+	}
+
+	return row
+}
+
+// templateNumber is a numeric cell. It compares and takes printf verbs
+// like a float64 ({{if gt .Value 77.0}}, {{printf "%.1f" .Value}}) but
+// prints as a plain decimal, so IDs and epochs keep all their digits.
+type templateNumber float64
+
+// String formats the number without an exponent.
+func (n templateNumber) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, floatBitSize)
+}
+
+// camelCase turns a snake_case column name into its exported Go form.
+func camelCase(key string) string {
+	var builder strings.Builder
+
+	for part := range strings.SplitSeq(key, "_") {
+		runes := []rune(part)
+		if len(runes) == 0 {
+			continue
+		}
+
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+
+	return builder.String()
+}
+
+// goName is camelCase with Go initialisms upper-cased, as in a struct
+// field: device_id becomes DeviceID and bp_sys BPSys.
+func goName(key string) string {
+	var builder strings.Builder
+
+	for part := range strings.SplitSeq(key, "_") {
+		if templateInitialisms[part] {
+			builder.WriteString(strings.ToUpper(part))
+
+			continue
+		}
+
+		builder.WriteString(camelCase(part))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,95 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestRenderTableTemplate renders rows by plain and CamelCase names.
+func TestRenderTableTemplate(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Template = "go-template={{.Time}} {{.heart_rate}}/{{.HeartRate}}"
+	lines := []string{"Time\tHeart Rate\tModel", "t1\t60\t44", "t2\t61\t44"}
+
+	got, err := RenderTable(opts, testFieldsKeys, lines)
+	if err != nil {
+		t.Fatalf("RenderTable: %v", err)
+	}
+
+	if want := "t1 60/60\nt2 61/61"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestRenderTemplateUnknownField lists the columns on a typo.
+func TestRenderTemplateUnknownField(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Template = "{{.Pulse}}"
+
+	_, err := renderTemplate(opts, []string{testFieldsKeys, "t1\t60\t44"})
+	if !errors.Is(err, ErrInvalidTemplate) {
+		t.Fatalf("got %v, want invalid template", err)
+	}
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+		t.Fatalf("got %v, want usage error", err)
+	}
+}
+
+// TestRenderTemplateTypedCells compares and formats numeric cells as
+// numbers and keys them by their Go names.
+func TestRenderTemplateTypedCells(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	opts.Template = `{{if gt .Value 77.0}}{{printf "%.1f" .Value}}{{end}}` +
+		` {{.DeviceID}} {{.device_id}} {{.DeviceId}} {{.Type}}`
+	lines := []string{
+		"type\tvalue\tdevice_id",
+		"weight\t78.25\t1234567890",
+		"weight\t76\t1234567890",
+	}
+
+	got, err := renderTemplate(opts, lines)
+	if err != nil {
+		t.Fatalf("renderTemplate: %v", err)
+	}
+
+	want := []string{
+		"78.2 1234567890 1234567890 1234567890 weight",
+		" 1234567890 1234567890 1234567890 weight",
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
+
+// TestGoName upper-cases initialisms like a Go struct field.
+func TestGoName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"device_id": "DeviceID",
+		"model_id":  "ModelID",
+		"bp_sys":    "BPSys",
+		"mac":       "MAC",
+		"time":      "Time",
+	}
+
+	for key, want := range cases {
+		if got := goName(key); got != want {
+			t.Fatalf("%s got %q want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidThreshold indicates a threshold rule that does not parse.
+var ErrInvalidThreshold = errors.New(
+	"invalid threshold (expected rules such as \">=140:red,>=130:yellow\")",
+)
+
+const (
+	// ThresholdsOff disables the rules of one name, default ones included.
+	ThresholdsOff        = "none"
+	thresholdSeparator   = ","
+	thresholdColorMarker = ":"
+	thresholdFloatBits   = 64
+	typeColumn           = "type"
+	valueColumn          = "value"
+	// colorDefault pads uncolored cells of a colored column so every cell
+	// carries escapes of the same length and tabwriter keeps the column
+	// aligned.
+	colorDefault Color = "\x1b[39m"
+)
+
+// DefaultThresholds color blood pressure, SpO2, and sleep score cells.
+// Names are measure types (matched by the row's type column, coloring its
+// value) or plain column names.
+//
+//nolint:gochecknoglobals // Static default rules.
+var DefaultThresholds = map[string]string{
+	"bp_sys": ">=140:red,>=130:yellow",
+	"bp_dia": ">=90:red,>=80:yellow",
+	"spo2":   "<92:red,<95:yellow",
+	"score":  "<60:red,<80:yellow,>=80:green",
+}
+
+//nolint:gochecknoglobals // Static color names.
+var colorNames = map[string]Color{
+	"red":    ColorRed,
+	"yellow": ColorYellow,
+	"green":  ColorGreen,
+}
+
+// thresholdOperators are tried in order, so ">=" wins over ">".
+//
+//nolint:gochecknoglobals // Static operator list.
+var thresholdOperators = []string{">=", "<=", ">", "<"}
+
+type thresholdRule struct {
+	Operator string
+	Limit    float64
+	Color    Color
+}
+
+// ParseThreshold parses comma-separated "<op><number>:<color>" rules; the
+// first matching rule colors a cell. "none" yields no rules.
+func ParseThreshold(spec string) ([]thresholdRule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == ThresholdsOff {
+		return nil, nil
+	}
+
+	var rules []thresholdRule
+
+	for part := range strings.SplitSeq(spec, thresholdSeparator) {
+		rule, err := parseThresholdRule(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func parseThresholdRule(part string) (thresholdRule, error) {
+	condition, name, ok := strings.Cut(part, thresholdColorMarker)
+	color, known := colorNames[strings.TrimSpace(name)]
+
+	if !ok || !known {
+		return thresholdRule{}, fmt.Errorf("%w: %q", ErrInvalidThreshold, part)
+	}
+
+	for _, operator := range thresholdOperators {
+		raw, found := strings.CutPrefix(condition, operator)
+		if !found {
+			continue
+		}
+
+		limit, err := strconv.ParseFloat(
+			strings.TrimSpace(raw),
+			thresholdFloatBits,
+		)
+		if err != nil {
+			break
+		}
+
+		return thresholdRule{
+			Operator: operator,
+			Limit:    limit,
+			Color:    color,
+		}, nil
+	}
+
+	return thresholdRule{}, fmt.Errorf("%w: %q", ErrInvalidThreshold, part)
+}
+
+func (r thresholdRule) matches(value float64) bool {
+	switch r.Operator {
+	case ">=":
+		return value >= r.Limit
+	case "<=":
+		return value <= r.Limit
+	case ">":
+		return value > r.Limit
+	default:
+		return value < r.Limit
+	}
+}
+
+// resolveThresholds merges the configured rules over the defaults.
+func resolveThresholds(overrides map[string]string) (
+	map[string][]thresholdRule,
+	error,
+) {
+	resolved := make(map[string][]thresholdRule, len(DefaultThresholds))
+
+	for _, specs := range []map[string]string{DefaultThresholds, overrides} {
+		for name, spec := range specs {
+			rules, err := ParseThreshold(spec)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+
+			resolved[name] = rules
+		}
+	}
+
+	return resolved, nil
+}
+
+func thresholdColor(rules []thresholdRule, cell string) (Color, bool) {
+	value, err := strconv.ParseFloat(cell, thresholdFloatBits)
+	if err != nil {
+		return "", false
+	}
+
+	for _, rule := range rules {
+		if rule.matches(value) {
+			return rule.Color, true
+		}
+	}
+
+	return "", false
+}
+
+// colorizeTable colors the cells of tab-separated rows (table header
+// first, columns named by keys) that match a threshold. Every cell of a
+// column holding a colored cell gets escapes of the same length, so the
+// aligned widths stay right.
+func colorizeTable(
+	thresholds map[string][]thresholdRule,
+	keys string,
+	lines []string,
+) []string {
+	names := strings.Split(keys, plainSeparator)
+	typeIndex := columnIndex(names, typeColumn)
+	valueIndex := columnIndex(names, valueColumn)
+
+	rows := make([][]string, len(lines))
+	colors := make([][]Color, len(lines))
+	colored := make([]bool, len(names))
+
+	for index, line := range lines {
+		rows[index] = strings.Split(line, plainSeparator)
+		colors[index] = make([]Color, len(rows[index]))
+
+		if index < headerRows {
+			continue
+		}
+
+		for column, cell := range rows[index] {
+			if column >= len(names) {
+				break
+			}
+
+			name := names[column]
+			if column == valueIndex && typeIndex >= 0 &&
+				typeIndex < len(rows[index]) {
+				name = rows[index][typeIndex]
+			}
+
+			color, ok := thresholdColor(thresholds[name], cell)
+			if ok {
+				colors[index][column] = color
+				colored[column] = true
+			}
+		}
+	}
+
+	colorized := make([]string, 0, len(lines))
+
+	for index, cells := range rows {
+		for column := range cells {
+			if column >= len(colored) || !colored[column] {
+				continue
+			}
+
+			color := colors[index][column]
+			if color == "" {
+				color = colorDefault
+			}
+
+			cells[column] = string(color) + cells[column] + colorReset
+		}
+
+		colorized = append(colorized, strings.Join(cells, plainSeparator))
+	}
+
+	return colorized
+}
+
+func columnIndex(names []string, name string) int {
+	for index, candidate := range names {
+		if candidate == name {
+			return index
+		}
+	}
+
+	return -1
+}
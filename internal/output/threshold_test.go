@@ -0,0 +1,75 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseThreshold accepts rule lists and "none" and rejects typos.
+func TestParseThreshold(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseThreshold(" >=140:red, >130 : yellow,<=90:green")
+	if err != nil || len(rules) != 3 {
+		t.Fatalf("got %+v, %v", rules, err)
+	}
+
+	if rules[0].Operator != ">=" || rules[1].Operator != ">" ||
+		rules[1].Color != ColorYellow || rules[2].Limit != 90 {
+		t.Fatalf("got %+v", rules)
+	}
+
+	rules, err = ParseThreshold(ThresholdsOff)
+	if err != nil || rules != nil {
+		t.Fatalf("none got %+v, %v", rules, err)
+	}
+
+	invalid := []string{"140:red", ">=x:red", ">=140:purple", ">=140"}
+	for _, spec := range invalid {
+		_, err = ParseThreshold(spec)
+		if !errors.Is(err, ErrInvalidThreshold) {
+			t.Fatalf("%q: got %v, want invalid threshold", spec, err)
+		}
+	}
+}
+
+// TestColorizeTable colors values by row type and pads the column.
+func TestColorizeTable(t *testing.T) {
+	t.Parallel()
+
+	thresholds, err := resolveThresholds(map[string]string{
+		"heart_rate": ">100:red",
+	})
+	if err != nil {
+		t.Fatalf("resolveThresholds: %v", err)
+	}
+
+	got := colorizeTable(thresholds, "type\tvalue", []string{
+		"Type\tValue",
+		"bp_sys\t145",
+		"bp_sys\t120",
+		"heart_rate\t101",
+	})
+
+	want := []string{
+		"Type\t\x1b[39mValue\x1b[0m",
+		"bp_sys\t\x1b[31m145\x1b[0m",
+		"bp_sys\t\x1b[39m120\x1b[0m",
+		"heart_rate\t\x1b[31m101\x1b[0m",
+	}
+
+	for index := range want {
+		if got[index] != want[index] {
+			t.Fatalf("row %d got %q want %q", index, got[index], want[index])
+		}
+	}
+
+	plain := colorizeTable(thresholds, "time\tscore", []string{
+		"Time\tScore",
+		"t1\t",
+	})
+	if plain[1] != "t1\t" {
+		t.Fatalf("uncolored table got %q", plain)
+	}
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	// TimezoneLocal renders timestamps in the system timezone.
+	TimezoneLocal = "local"
+	// TimezoneUTC renders timestamps in UTC.
+	TimezoneUTC = "utc"
+)
+
+// ParseTimezone resolves a --tz value: local, utc, or an IANA name such
+// as Europe/Berlin.
+func ParseTimezone(value string) (*time.Location, error) {
+	switch strings.ToLower(value) {
+	case TimezoneLocal:
+		return time.Local, nil
+	case TimezoneUTC:
+		return time.UTC, nil
+	}
+
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("load timezone: %w", err)
+	}
+
+	return location, nil
+}
+
+// DisplayLocation returns the timezone used to render timestamps: --tz
+// when set, otherwise the API-provided timezone, falling back to UTC.
+func DisplayLocation(opts app.Options, apiTimezone string) *time.Location {
+	for _, name := range []string{opts.Timezone, apiTimezone} {
+		if name == "" {
+			continue
+		}
+
+		location, err := ParseTimezone(name)
+		if err == nil {
+			return location
+		}
+	}
+
+	return time.UTC
+}
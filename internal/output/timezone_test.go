@@ -0,0 +1,51 @@
+//nolint:testpackage // test unexported helpers.
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const testBerlin = "Europe/Berlin"
+
+// TestDisplayLocation prefers --tz over the API timezone.
+func TestDisplayLocation(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	cases := []struct {
+		name     string
+		override string
+		api      string
+		want     string
+	}{
+		{"api", "", testBerlin, testBerlin},
+		{"fallback", "", "", "UTC"},
+		{"invalid api", "", "Mars/Base", "UTC"},
+		{"override", "utc", testBerlin, "UTC"},
+		{"local", "LOCAL", testBerlin, time.Local.String()},
+		{"iana", "America/New_York", testBerlin, "America/New_York"},
+	}
+
+	for _, tc := range cases {
+		opts.Timezone = tc.override
+
+		got := DisplayLocation(opts, tc.api).String()
+		if got != tc.want {
+			t.Fatalf("%s: got %q want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestParseTimezoneInvalid rejects unknown zone names.
+func TestParseTimezoneInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseTimezone("Mars/Base")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
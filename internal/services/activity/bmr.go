@@ -0,0 +1,108 @@
+package activity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	bmrWeightFactor = 10
+	bmrHeightFactor = 6.25
+	bmrAgeFactor    = 5
+	bmrMaleOffset   = 5
+	bmrFemaleOffset = -161
+	sexMale         = "male"
+	sexFemale       = "female"
+	sexMaleShort    = "m"
+	sexFemaleShort  = "f"
+)
+
+var (
+	errProfileIncomplete = errors.New(
+		"BMR estimate requires --age, --height, --weight, and --sex",
+	)
+	errInvalidSex     = errors.New("invalid --sex (expected male or female)")
+	errInvalidProfile = errors.New(
+		"--age, --height, and --weight must be positive",
+	)
+)
+
+// Profile captures body data used to estimate basal metabolic rate.
+type Profile struct {
+	Age      int
+	HeightCm float64
+	WeightKg float64
+	Sex      string
+}
+
+func (profile Profile) isEmpty() bool {
+	return profile.Age == defaultInt &&
+		profile.HeightCm == defaultInt &&
+		profile.WeightKg == defaultInt &&
+		profile.Sex == emptyString
+}
+
+// estimateBMR returns the Mifflin-St Jeor daily BMR in kcal, or nil when no
+// profile data was supplied.
+func estimateBMR(profile Profile) (*float64, error) {
+	if profile.isEmpty() {
+		//nolint:nilnil // No profile data means no estimate.
+		return nil, nil
+	}
+
+	if profile.Age == defaultInt ||
+		profile.HeightCm == defaultInt ||
+		profile.WeightKg == defaultInt ||
+		profile.Sex == emptyString {
+		return nil, errProfileIncomplete
+	}
+
+	if profile.Age < defaultInt ||
+		profile.HeightCm < defaultInt ||
+		profile.WeightKg < defaultInt {
+		return nil, errInvalidProfile
+	}
+
+	offset, err := sexOffset(profile.Sex)
+	if err != nil {
+		return nil, err
+	}
+
+	bmr := bmrWeightFactor*profile.WeightKg +
+		bmrHeightFactor*profile.HeightCm -
+		bmrAgeFactor*float64(profile.Age) +
+		offset
+	bmr = math.Round(bmr)
+
+	return &bmr, nil
+}
+
+func sexOffset(raw string) (float64, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case sexMale, sexMaleShort:
+		return bmrMaleOffset, nil
+	case sexFemale, sexFemaleShort:
+		return bmrFemaleOffset, nil
+	default:
+		return defaultInt, fmt.Errorf("%w: %q", errInvalidSex, raw)
+	}
+}
+
+// activityOnlyCalories derives calories burned above BMR for a day.
+func activityOnlyCalories(totalCalories float64, bmr *float64) string {
+	if bmr == nil {
+		return emptyString
+	}
+
+	return formatFloat(math.Max(defaultInt, totalCalories-*bmr))
+}
+
+func formatBMR(bmr *float64) string {
+	if bmr == nil {
+		return emptyString
+	}
+
+	return formatFloat(*bmr)
+}
@@ -0,0 +1,99 @@
+package activity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// MetricSteps and MetricCalories are the values a daily goal can
+	// apply to; calories are the active calories of the day.
+	MetricSteps    = "steps"
+	MetricCalories = "calories"
+
+	goalPercent       = 100
+	goalDigits        = 10
+	goalBarWidth      = 20
+	goalBarDone       = "#"
+	goalBarLeft       = "-"
+	goalTableColumns  = "\tGoal\tProgress (%)"
+	goalPlainColumns  = "\tgoal\tprogress"
+	goalBarTableTitle = "\tBar"
+	goalBarKey        = "\tbar"
+)
+
+// ErrInvalidMetric indicates an unknown --metric.
+var ErrInvalidMetric = errors.New(
+	"invalid --metric (expected steps or calories)",
+)
+
+// MetricChoices lists the values of --metric.
+//
+//nolint:gochecknoglobals // Static completion list.
+var MetricChoices = []string{MetricSteps, MetricCalories}
+
+// Goal is the daily target activity rows report progress against. A
+// zero Target leaves the goal columns out.
+type Goal struct {
+	Metric string
+	Target float64
+	Bar    bool
+}
+
+func (goal Goal) enabled() bool {
+	return goal.Target > defaultInt
+}
+
+// ParseMetric normalizes a --metric value; empty means steps.
+func ParseMetric(value string) (string, error) {
+	metric := strings.ToLower(strings.TrimSpace(value))
+
+	switch metric {
+	case emptyString:
+		return MetricSteps, nil
+	case MetricSteps, MetricCalories:
+		return metric, nil
+	default:
+		return emptyString, fmt.Errorf("%w: %q", ErrInvalidMetric, value)
+	}
+}
+
+// goalProgress returns the share of the goal reached by item, in percent.
+func goalProgress(item item, goal Goal) float64 {
+	value := item.Steps
+	if goal.Metric == MetricCalories {
+		value = item.Calories
+	}
+
+	return value / goal.Target * goalPercent
+}
+
+func formatProgress(progress float64) string {
+	return formatFloat(math.Round(progress*goalDigits) / goalDigits)
+}
+
+// goalBar draws progress as a fixed-width bar that fills up only once
+// the goal is reached.
+func goalBar(progress float64) string {
+	done := int(math.Floor(
+		math.Min(progress, goalPercent) / goalPercent * goalBarWidth,
+	))
+
+	return strings.Repeat(goalBarDone, done) +
+		strings.Repeat(goalBarLeft, goalBarWidth-done)
+}
+
+// goalColumns returns the plain keys and table titles appended for goal.
+func goalColumns(goal Goal) (string, string) {
+	if !goal.enabled() {
+		return emptyString, emptyString
+	}
+
+	if !goal.Bar {
+		return goalPlainColumns, goalTableColumns
+	}
+
+	return goalPlainColumns + goalBarKey, goalTableColumns + goalBarTableTitle
+}
@@ -0,0 +1,392 @@
+package activity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	actionIntraday        = "getintradayactivity"
+	intradayStartParam    = "startdate"
+	intradayEndParam      = "enddate"
+	intradayFieldsParam   = "data_fields"
+	intradayDefaultFields = "steps,calories,heart_rate,duration"
+	intradayDefaultWindow = 24 * time.Hour
+	intradayFieldSep      = ","
+	intradayTableHeader   = "Time"
+	intradayPlainHeader   = "time"
+	numberBase10          = 10
+	int64BitSize          = 64
+	defaultInt64          = 0
+)
+
+var (
+	errRotateNoOutput = errors.New("--rotate requires --dest")
+)
+
+//nolint:gochecknoglobals // Static lookup table for intraday data fields.
+var intradayFieldSet = map[string]bool{
+	"steps":       true,
+	"elevation":   true,
+	"calories":    true,
+	"distance":    true,
+	"stroke":      true,
+	"pool_lap":    true,
+	"duration":    true,
+	"heart_rate":  true,
+	"spo2_auto":   true,
+	"rmssd":       true,
+	"sdnn1":       true,
+	"hrv_quality": true,
+}
+
+// IntradayOptions captures intraday activity query parameters.
+type IntradayOptions struct {
+	TimeRange params.TimeRange
+	User      params.User
+	Fields    string
+	Output    string
+	Rotate    string
+	Now       func() time.Time
+}
+
+// Intraday fetches high-frequency activity samples and writes output.
+func Intraday(
+	ctx context.Context,
+	opts IntradayOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	values, fields, err := buildIntradayParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	if opts.Output != emptyString {
+		return exportIntraday(ctx, opts, appOpts, accessToken, fields)
+	}
+
+	if opts.Rotate != emptyString {
+		return app.NewExitError(app.ExitCodeUsage, errRotateNoOutput)
+	}
+
+	payload, err := callAction(
+		ctx,
+		appOpts,
+		accessToken,
+		actionIntraday,
+		values,
+	)
+	if err != nil {
+		return err
+	}
+
+	raw, samples, err := decodeIntradayResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	return writeIntraday(appOpts, raw, samples, fields)
+}
+
+func buildIntradayParams(opts IntradayOptions) (url.Values, []string, error) {
+	fields, err := filters.ParseDataFields(
+		opts.Fields,
+		intradayDefaultFields,
+		intradayFieldSet,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse data fields: %w", err)
+	}
+
+	start, end, err := resolveIntradayRange(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return intradayParams(opts.User, fields, start, end), fields, nil
+}
+
+func intradayParams(
+	user params.User,
+	fields []string,
+	start int64,
+	end int64,
+) url.Values {
+	values := url.Values{}
+	values.Set(intradayStartParam, strconv.FormatInt(start, numberBase10))
+	values.Set(intradayEndParam, strconv.FormatInt(end, numberBase10))
+	values.Set(intradayFieldsParam, strings.Join(fields, intradayFieldSep))
+	applyUser(&values, user)
+
+	return values
+}
+
+// resolveIntradayRange defaults to the 24 hours before --end (or now).
+func resolveIntradayRange(opts IntradayOptions) (int64, int64, error) {
+	nowFunc := opts.Now
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	start, end, err := filters.ResolveEpochWindow(
+		opts.TimeRange,
+		intradayDefaultWindow,
+		nowFunc(),
+	)
+	if err != nil {
+		return defaultInt64, defaultInt64, fmt.Errorf("resolve range: %w", err)
+	}
+
+	return start, end, nil
+}
+
+type intradayResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+	Error  string          `json:"error"`
+	Detail string          `json:"detail"`
+}
+
+type intradayBody struct {
+	Series json.RawMessage `json:"series"`
+}
+
+type intradaySample struct {
+	Time   int64
+	Values map[string]json.RawMessage
+}
+
+func decodeIntradayResponse(
+	payload []byte,
+) (json.RawMessage, []intradaySample, error) {
+	var decoded intradayResponse
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, nil, intradayDecodeError(err)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var body intradayBody
+
+	err = json.Unmarshal(decoded.Body, &body)
+	if err != nil {
+		return nil, nil, intradayDecodeError(err)
+	}
+
+	samples, err := decodeIntradaySeries(body.Series)
+	if err != nil {
+		return nil, nil, intradayDecodeError(err)
+	}
+
+	return decoded.Body, samples, nil
+}
+
+// decodeIntradaySeries reads the epoch-keyed series map into sorted samples.
+// Withings returns an empty array instead of an object when there is no data.
+func decodeIntradaySeries(raw json.RawMessage) ([]intradaySample, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == defaultInt || trimmed[0] != '{' {
+		return nil, nil
+	}
+
+	var series map[string]map[string]json.RawMessage
+
+	err := json.Unmarshal(trimmed, &series)
+	if err != nil {
+		return nil, fmt.Errorf("decode intraday series: %w", err)
+	}
+
+	samples := make([]intradaySample, defaultInt, len(series))
+
+	for key, values := range series {
+		epoch, err := strconv.ParseInt(key, numberBase10, int64BitSize)
+		if err != nil {
+			return nil, fmt.Errorf("decode intraday timestamp %q: %w", key, err)
+		}
+
+		samples = append(samples, intradaySample{Time: epoch, Values: values})
+	}
+
+	sort.Slice(samples, func(left, right int) bool {
+		return samples[left].Time < samples[right].Time
+	})
+
+	return samples, nil
+}
+
+func intradayDecodeError(err error) error {
+	return app.NewExitError(
+		app.ExitCodeFailure,
+		fmt.Errorf("decode api response: %w", err),
+	)
+}
+
+func buildIntradayRows(samples []intradaySample, fields []string) [][]string {
+	rows := make([][]string, defaultInt, len(samples))
+
+	for _, sample := range samples {
+		cells := make([]string, defaultInt, len(fields)+rowsHeaderCount)
+		cells = append(cells, time.Unix(sample.Time, defaultInt64).
+			UTC().
+			Format(time.RFC3339))
+
+		for _, field := range fields {
+			cells = append(cells, formatIntradayValue(sample.Values[field]))
+		}
+
+		rows = append(rows, cells)
+	}
+
+	return rows
+}
+
+func formatIntradayValue(raw json.RawMessage) string {
+	if len(raw) == defaultInt || string(raw) == "null" {
+		return emptyString
+	}
+
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	return string(raw)
+}
+
+func writeIntraday(
+	opts app.Options,
+	raw json.RawMessage,
+	samples []intradaySample,
+	fields []string,
+) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		err := output.WriteRawJSON(opts, raw)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	rows := buildIntradayRows(samples, fields)
+
+	if opts.Plain || opts.NDJSON {
+		return writeIntradayPlain(opts, rows, fields)
+	}
+
+	table, err := formatIntradayTable(rows, fields)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func writeIntradayPlain(
+	opts app.Options,
+	rows [][]string,
+	fields []string,
+) error {
+	header := append([]string{intradayPlainHeader}, fields...)
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+
+	err := output.WritePlain(opts, lines)
+	if err != nil {
+		return fmt.Errorf("write plain output: %w", err)
+	}
+
+	return nil
+}
+
+func formatIntradayTable(rows [][]string, fields []string) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(
+		&buffer,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableFlags,
+	)
+	header := append([]string{intradayTableHeader}, intradayTitles(fields)...)
+	_, _ = fmt.Fprintln(writer, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render intraday table: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+func intradayTitles(fields []string) []string {
+	titles := make([]string, defaultInt, len(fields))
+	for _, field := range fields {
+		titles = append(titles, intradayTitle(field))
+	}
+
+	return titles
+}
+
+func intradayTitle(field string) string {
+	switch field {
+	case "heart_rate":
+		return "HR"
+	case "spo2_auto":
+		return "SpO2"
+	default:
+		words := strings.Split(field, "_")
+		for index, word := range words {
+			if word != emptyString {
+				words[index] = strings.ToUpper(word[:1]) + word[1:]
+			}
+		}
+
+		return strings.Join(words, " ")
+	}
+}
@@ -0,0 +1,163 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// exportIntraday writes intraday samples to CSV, fetching the range in
+// 24-hour windows because the API truncates longer requests.
+func exportIntraday(
+	ctx context.Context,
+	opts IntradayOptions,
+	appOpts app.Options,
+	accessToken string,
+	fields []string,
+) error {
+	rotation, err := output.ParseRotation(opts.Rotate)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	start, end, err := resolveIntradayRange(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	header := append([]string{intradayPlainHeader}, fields...)
+	writer := output.NewRotatingCSV(opts.Output, header, rotation)
+
+	err = writeIntradayWindows(
+		ctx,
+		appOpts,
+		accessToken,
+		intradayExport{
+			Opts:   opts,
+			Fields: fields,
+			Start:  start,
+			End:    end,
+		},
+		writer,
+	)
+
+	manifest, closeErr := writer.Close()
+	if err != nil {
+		return err
+	}
+
+	if closeErr != nil {
+		return fmt.Errorf("finish intraday export: %w", closeErr)
+	}
+
+	return writeExportSummary(appOpts, opts.Output, rotation, manifest)
+}
+
+type intradayExport struct {
+	Opts   IntradayOptions
+	Fields []string
+	Start  int64
+	End    int64
+}
+
+func writeIntradayWindows(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	export intradayExport,
+	writer *output.RotatingCSV,
+) error {
+	window := int64(intradayDefaultWindow.Seconds())
+	lastWritten := export.Start - 1
+
+	for windowStart := export.Start; windowStart < export.End; {
+		windowEnd := min(windowStart+window, export.End)
+
+		payload, err := callAction(
+			ctx,
+			appOpts,
+			accessToken,
+			actionIntraday,
+			intradayParams(
+				export.Opts.User,
+				export.Fields,
+				windowStart,
+				windowEnd,
+			),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, samples, err := decodeIntradayResponse(payload)
+		if err != nil {
+			return err
+		}
+
+		samples = samplesAfter(samples, lastWritten)
+
+		for index, cells := range buildIntradayRows(samples, export.Fields) {
+			err = writer.Write(cells)
+			if err != nil {
+				return fmt.Errorf("write intraday export: %w", err)
+			}
+
+			lastWritten = samples[index].Time
+		}
+
+		windowStart = windowEnd
+	}
+
+	return nil
+}
+
+// samplesAfter drops samples already written by the previous window, since
+// window boundaries are inclusive on both ends.
+func samplesAfter(samples []intradaySample, after int64) []intradaySample {
+	for index, sample := range samples {
+		if sample.Time > after {
+			return samples[index:]
+		}
+	}
+
+	return nil
+}
+
+func writeExportSummary(
+	appOpts app.Options,
+	path string,
+	rotation output.Rotation,
+	manifest output.Manifest,
+) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		err := output.WriteRawJSON(appOpts, manifest)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	message := fmt.Sprintf("wrote %d rows to %s", manifest.Rows, path)
+	if rotation.Enabled() {
+		message = fmt.Sprintf(
+			"wrote %d rows to %d files (manifest: %s)",
+			manifest.Rows,
+			len(manifest.Files),
+			output.ManifestPath(path),
+		)
+	}
+
+	err := output.WriteLine(appOpts, message)
+	if err != nil {
+		return fmt.Errorf("write export summary: %w", err)
+	}
+
+	return nil
+}
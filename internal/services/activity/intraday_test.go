@@ -0,0 +1,180 @@
+//nolint:testpackage // test unexported helpers.
+package activity
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	intradayTestNow      = 1767081600
+	intradayTestStart    = "1767000000"
+	intradayTestEnd      = "1766990000"
+	intradayTestDayStart = "1766995200"
+	intradayTestSeries   = `{"1767081660":{"steps":12,"heart_rate":88},` +
+		`"1767081600":{"steps":40,"calories":2.5,"deviceid":"abc"}}`
+	intradayTestFieldsErr = "ParseDataFields: %v"
+)
+
+// TestBuildIntradayParamsDefaults uses a 24h window and default fields.
+func TestBuildIntradayParamsDefaults(t *testing.T) {
+	t.Parallel()
+
+	opts := intradayTestOptions()
+
+	values, fields, err := buildIntradayParams(opts)
+	if err != nil {
+		t.Fatalf("buildIntradayParams: %v", err)
+	}
+
+	assertParam(
+		t,
+		values.Get(intradayStartParam),
+		intradayTestDayStart,
+		"start",
+	)
+	assertParam(t, values.Get(intradayEndParam), "1767081600", "end")
+	assertParam(
+		t,
+		values.Get(intradayFieldsParam),
+		intradayDefaultFields,
+		"data_fields",
+	)
+	assertParam(
+		t,
+		strings.Join(fields, intradayFieldSep),
+		intradayDefaultFields,
+		"fields",
+	)
+}
+
+// TestBuildIntradayParamsInvertedRange rejects start after end.
+func TestBuildIntradayParamsInvertedRange(t *testing.T) {
+	t.Parallel()
+
+	opts := intradayTestOptions()
+	opts.TimeRange = params.TimeRange{
+		Start: intradayTestStart,
+		End:   intradayTestEnd,
+	}
+
+	_, _, err := buildIntradayParams(opts)
+	if !errors.Is(err, errs.ErrEndBeforeStart) {
+		t.Fatalf(activityTestErrFmt, err, errs.ErrEndBeforeStart)
+	}
+}
+
+// TestParseIntradayFields normalizes, dedupes, and validates fields.
+func TestParseIntradayFields(t *testing.T) {
+	t.Parallel()
+
+	fields, err := filters.ParseDataFields(
+		" Steps,heart_rate,steps,",
+		intradayDefaultFields,
+		intradayFieldSet,
+	)
+	if err != nil {
+		t.Fatalf(intradayTestFieldsErr, err)
+	}
+
+	assertParam(
+		t,
+		strings.Join(fields, intradayFieldSep),
+		"steps,heart_rate",
+		"fields",
+	)
+
+	_, err = filters.ParseDataFields(
+		"steps,weight",
+		intradayDefaultFields,
+		intradayFieldSet,
+	)
+	if !errors.Is(err, errs.ErrInvalidDataField) {
+		t.Fatalf(activityTestErrFmt, err, errs.ErrInvalidDataField)
+	}
+}
+
+// TestDecodeIntradaySeries sorts timestamp-keyed samples.
+func TestDecodeIntradaySeries(t *testing.T) {
+	t.Parallel()
+
+	samples, err := decodeIntradaySeries(json.RawMessage(intradayTestSeries))
+	if err != nil {
+		t.Fatalf("decodeIntradaySeries: %v", err)
+	}
+
+	rows := buildIntradayRows(
+		samples,
+		[]string{"steps", "calories", "heart_rate"},
+	)
+
+	if len(rows) != 2 {
+		t.Fatalf("rows got %d want 2", len(rows))
+	}
+
+	assertParam(t, rows[0][0], "2025-12-30T08:00:00Z", "time")
+	assertParam(t, rows[0][1], "40", "steps")
+	assertParam(t, rows[0][2], "2.5", "calories")
+	assertParam(t, rows[0][3], activityTestEmpty, "heart_rate")
+	assertParam(t, rows[1][3], "88", "heart_rate")
+}
+
+// TestDecodeIntradaySeriesEmptyArray accepts the empty-array form.
+func TestDecodeIntradaySeriesEmptyArray(t *testing.T) {
+	t.Parallel()
+
+	samples, err := decodeIntradaySeries(json.RawMessage(`[]`))
+	if err != nil || len(samples) != activityTestDefaultInt {
+		t.Fatalf("empty series got %v, %v", samples, err)
+	}
+}
+
+// TestIntradayTitle formats table column titles.
+func TestIntradayTitle(t *testing.T) {
+	t.Parallel()
+
+	assertParam(t, intradayTitle("heart_rate"), "HR", "heart_rate")
+	assertParam(t, intradayTitle("pool_lap"), "Pool Lap", "pool_lap")
+}
+
+func intradayTestOptions() IntradayOptions {
+	return IntradayOptions{
+		TimeRange: params.TimeRange{
+			Start: activityTestEmpty,
+			End:   activityTestEmpty,
+		},
+		User:   params.User{UserID: activityTestEmpty},
+		Fields: activityTestEmpty,
+		Output: activityTestEmpty,
+		Rotate: activityTestEmpty,
+		Now: func() time.Time {
+			return time.Unix(intradayTestNow, activityTestDefaultInt)
+		},
+	}
+}
+
+// TestSamplesAfter skips samples already written by a previous window.
+func TestSamplesAfter(t *testing.T) {
+	t.Parallel()
+
+	samples, err := decodeIntradaySeries(json.RawMessage(intradayTestSeries))
+	if err != nil {
+		t.Fatalf("decodeIntradaySeries: %v", err)
+	}
+
+	kept := samplesAfter(samples, intradayTestNow)
+	if len(kept) != 1 || kept[0].Time != intradayTestNow+60 {
+		t.Fatalf("kept got %v", kept)
+	}
+
+	if got := samplesAfter(samples, intradayTestNow+60); len(got) != 0 {
+		t.Fatalf("expected no samples, got %v", got)
+	}
+}
@@ -2,15 +2,12 @@
 package activity
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/mreimbold/withings-cli/internal/app"
@@ -18,6 +15,7 @@ import (
 	"github.com/mreimbold/withings-cli/internal/filters"
 	"github.com/mreimbold/withings-cli/internal/output"
 	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/units"
 	"github.com/mreimbold/withings-cli/internal/withings"
 )
 
@@ -39,10 +37,12 @@ const (
 	tablePadding    = 2
 	tablePadChar    = ' '
 	tableFlags      = 0
-	tableHeader     = "Date\tSteps\tDistance\tCalories\t" +
-		"Total Calories\tActive\tElevation\tSoft\tModerate\tIntense"
+	tableHeader     = "Date\tSteps\tDistance\tActive Calories\t" +
+		"Total Calories\tActive\tElevation\tSoft\tModerate\tIntense\t" +
+		"BMR\tActivity Calories"
 	plainHeader = "date\tsteps\tdistance\tcalories\t" +
-		"total_calories\tactive\televation\tsoft\tmoderate\tintense"
+		"total_calories\tactive\televation\tsoft\tmoderate\tintense\t" +
+		"bmr\tactivity_calories"
 	defaultInt  = 0
 	emptyString = ""
 )
@@ -54,6 +54,8 @@ type Options struct {
 	Pagination params.Pagination
 	User       params.User
 	LastUpdate params.LastUpdate
+	Profile    Profile
+	Goal       Goal
 	Now        func() time.Time
 }
 
@@ -64,35 +66,57 @@ func Run(
 	appOpts app.Options,
 	accessToken string,
 ) error {
+	bmr, err := estimateBMR(opts.Profile)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
 	values, err := buildParams(opts)
 	if err != nil {
 		return app.NewExitError(app.ExitCodeUsage, err)
 	}
 
+	payload, err := callAction(ctx, appOpts, accessToken, actionGet, values)
+	if err != nil {
+		return err
+	}
+
+	return writeResponse(appOpts, bmr, opts.Goal, payload)
+}
+
+func callAction(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	action string,
+	values url.Values,
+) ([]byte, error) {
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
 	req, _, err := withings.BuildRequest(
 		ctx,
-		withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud),
-		serviceForBase(withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)),
-		actionGet,
+		baseURL,
+		serviceForBase(baseURL),
+		action,
 		accessToken,
 		values,
 	)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return nil, fmt.Errorf("build request: %w", err)
 	}
 
 	//nolint:bodyclose // ReadPayload closes the response body.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := withings.NewClient(appOpts).Do(req)
 	if err != nil {
-		return app.NewExitError(app.ExitCodeNetwork, err)
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
 	}
 
 	payload, err := withings.ReadPayload(resp)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
-	return writeResponse(appOpts, payload)
+	return payload, nil
 }
 
 func serviceForBase(baseURL string) string {
@@ -227,38 +251,48 @@ type row struct {
 	Distance      string
 	Calories      string
 	TotalCalories string
+	BMR           string
+	ActivityOnly  string
 	Active        string
 	Elevation     string
 	Soft          string
 	Moderate      string
 	Intense       string
+	Goal          string
+	Progress      string
+	Bar           string
 }
 
-func writeResponse(opts app.Options, payload []byte) error {
+func writeResponse(
+	opts app.Options,
+	bmr *float64,
+	goal Goal,
+	payload []byte,
+) error {
 	decoded, err := decodeResponse(payload)
 	if err != nil {
 		return err
 	}
 
-	return writeBody(opts, decoded.Body)
+	return writeBody(opts, bmr, goal, decoded.Body)
 }
 
-func writeBody(opts app.Options, body body) error {
+func writeBody(opts app.Options, bmr *float64, goal Goal, body body) error {
 	if opts.Quiet {
 		return nil
 	}
 
-	if opts.JSON {
+	if opts.JSON && opts.Fields == emptyString {
 		return writeJSONOutput(opts, body)
 	}
 
-	rows := buildRows(body)
+	rows := buildRows(body, bmr, opts.Units, goal)
 
-	if opts.Plain {
-		return writePlainOutput(rows)
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		return writePlainOutput(opts, rows, goal)
 	}
 
-	return writeTableOutput(rows)
+	return writeTableOutput(opts, rows, opts.Units, goal)
 }
 
 func writeJSONOutput(opts app.Options, body body) error {
@@ -270,8 +304,8 @@ func writeJSONOutput(opts app.Options, body body) error {
 	return nil
 }
 
-func writePlainOutput(rows []row) error {
-	err := output.WriteLines(formatLines(rows))
+func writePlainOutput(opts app.Options, rows []row, goal Goal) error {
+	err := output.WritePlain(opts, formatLines(rows, goal))
 	if err != nil {
 		return fmt.Errorf("write plain output: %w", err)
 	}
@@ -279,13 +313,18 @@ func writePlainOutput(rows []row) error {
 	return nil
 }
 
-func writeTableOutput(rows []row) error {
-	table, err := formatTable(rows)
+func writeTableOutput(
+	opts app.Options,
+	rows []row,
+	system string,
+	goal Goal,
+) error {
+	table, err := formatTable(opts, rows, system, goal)
 	if err != nil {
 		return err
 	}
 
-	err = output.WriteLine(table)
+	err = output.WriteLine(opts, table)
 	if err != nil {
 		return fmt.Errorf("write table output: %w", err)
 	}
@@ -304,41 +343,53 @@ func decodeResponse(payload []byte) (response, error) {
 		)
 	}
 
-	if decoded.Status != withings.StatusOK {
-		message := decoded.Error
-		if message == emptyString {
-			message = decoded.Detail
-		}
-
-		if message == emptyString {
-			message = strings.TrimSpace(string(payload))
-		}
-
-		return response{}, app.NewExitError(
-			app.ExitCodeAPI,
-			fmt.Errorf("%w: %d: %s", withings.ErrAPI, decoded.Status, message),
-		)
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return response{}, err
 	}
 
 	return decoded, nil
 }
 
-func buildRows(body body) []row {
+// buildRows formats the activities; with a goal each row also carries
+// its progress.
+func buildRows(body body, bmr *float64, system string, goal Goal) []row {
 	rows := make([]row, defaultInt, len(body.Activities))
 
 	for _, item := range body.Activities {
-		rows = append(rows, row{
+		distance := units.Convert(system, units.Distance, item.Distance)
+		elevation := units.Convert(system, units.Elevation, item.Elevation)
+		entry := row{
 			Date:          item.Date,
 			Steps:         formatFloat(item.Steps),
-			Distance:      formatFloat(item.Distance),
+			Distance:      formatFloat(distance),
 			Calories:      formatFloat(item.Calories),
 			TotalCalories: formatFloat(item.TotalCalories),
+			BMR:           formatBMR(bmr),
+			ActivityOnly:  activityOnlyCalories(item.TotalCalories, bmr),
 			Active:        formatFloat(item.Active),
-			Elevation:     formatFloat(item.Elevation),
+			Elevation:     formatFloat(elevation),
 			Soft:          formatFloat(item.Soft),
 			Moderate:      formatFloat(item.Moderate),
 			Intense:       formatFloat(item.Intense),
-		})
+			Goal:          emptyString,
+			Progress:      emptyString,
+			Bar:           emptyString,
+		}
+
+		if goal.enabled() {
+			progress := goalProgress(item, goal)
+			entry.Goal = formatFloat(goal.Target)
+			entry.Progress = formatProgress(progress)
+			entry.Bar = goalBar(progress)
+		}
+
+		rows = append(rows, entry)
 	}
 
 	return rows
@@ -348,62 +399,79 @@ func formatFloat(value float64) string {
 	return strconv.FormatFloat(value, 'f', -1, floatBitSize)
 }
 
-func formatTable(rows []row) (string, error) {
-	var buffer bytes.Buffer
+// tableHeaderFor labels the distance columns when they are not metric.
+func tableHeaderFor(system string) string {
+	if system != units.Imperial {
+		return tableHeader
+	}
 
-	writer := tabwriter.NewWriter(
-		&buffer,
-		tableMinWidth,
-		tableTabWidth,
-		tablePadding,
-		tablePadChar,
-		tableFlags,
-	)
-	_, _ = fmt.Fprintln(writer, tableHeader)
+	return strings.NewReplacer(
+		"\tDistance\t",
+		"\tDistance ("+units.Label(system, units.Distance)+")\t",
+		"\tElevation\t",
+		"\tElevation ("+units.Label(system, units.Elevation)+")\t",
+	).Replace(tableHeader)
+}
+
+func formatTable(
+	opts app.Options,
+	rows []row,
+	system string,
+	goal Goal,
+) (string, error) {
+	keys, titles := goalColumns(goal)
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, tableHeaderFor(system)+titles)
 
 	for _, row := range rows {
-		_, _ = fmt.Fprintf(
-			writer,
-			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			row.Date,
-			row.Steps,
-			row.Distance,
-			row.Calories,
-			row.TotalCalories,
-			row.Active,
-			row.Elevation,
-			row.Soft,
-			row.Moderate,
-			row.Intense,
-		)
+		lines = append(lines, formatRow(row, goal))
 	}
 
-	err := writer.Flush()
+	table, err := output.RenderTable(opts, plainHeader+keys, lines)
 	if err != nil {
 		return emptyString, fmt.Errorf("render activity table: %w", err)
 	}
 
-	return strings.TrimRight(buffer.String(), "\n"), nil
+	return table, nil
 }
 
-func formatLines(rows []row) []string {
+// formatLines returns the plain rows; the progress bar is table-only.
+func formatLines(rows []row, goal Goal) []string {
+	goal.Bar = false
+	keys, _ := goalColumns(goal)
 	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
-	lines = append(lines, plainHeader)
+	lines = append(lines, plainHeader+keys)
 
 	for _, row := range rows {
-		lines = append(lines, strings.Join([]string{
-			row.Date,
-			row.Steps,
-			row.Distance,
-			row.Calories,
-			row.TotalCalories,
-			row.Active,
-			row.Elevation,
-			row.Soft,
-			row.Moderate,
-			row.Intense,
-		}, "\t"))
+		lines = append(lines, formatRow(row, goal))
 	}
 
 	return lines
 }
+
+func formatRow(row row, goal Goal) string {
+	cells := []string{
+		row.Date,
+		row.Steps,
+		row.Distance,
+		row.Calories,
+		row.TotalCalories,
+		row.Active,
+		row.Elevation,
+		row.Soft,
+		row.Moderate,
+		row.Intense,
+		row.BMR,
+		row.ActivityOnly,
+	}
+
+	if goal.enabled() {
+		cells = append(cells, row.Goal, row.Progress)
+	}
+
+	if goal.enabled() && goal.Bar {
+		cells = append(cells, row.Bar)
+	}
+
+	return strings.Join(cells, "\t")
+}
@@ -3,12 +3,15 @@ package activity
 
 import (
 	"errors"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/mreimbold/withings-cli/internal/errs"
 	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/units"
 )
 
 const (
@@ -36,6 +39,11 @@ const (
 	activityTestEmpty      = ""
 	activityTestDefaultInt = 0
 	activityTestBase10     = 10
+	activityTestAge        = 40
+	activityTestHeight     = 180
+	activityTestWeight     = 80
+	activityTestBMR        = 1730
+	activityTestTotal      = 2180
 )
 
 // TestActivityServiceForBase handles base URLs with and without /v2.
@@ -74,6 +82,7 @@ func TestBuildParamsDate(t *testing.T) {
 		},
 		User:       params.User{UserID: activityTestUserID},
 		LastUpdate: params.LastUpdate{LastUpdate: activityTestDefaultInt},
+		Profile:    emptyProfile(),
 		Now:        nil,
 	}
 
@@ -141,6 +150,7 @@ func TestBuildParamsTimeRange(t *testing.T) {
 		},
 		User:       params.User{UserID: activityTestEmpty},
 		LastUpdate: params.LastUpdate{LastUpdate: activityTestDefaultInt},
+		Profile:    emptyProfile(),
 		Now:        nil,
 	}
 
@@ -190,6 +200,7 @@ func TestBuildParamsDefaultEnd(t *testing.T) {
 		},
 		User:       params.User{UserID: activityTestEmpty},
 		LastUpdate: params.LastUpdate{LastUpdate: activityTestDefaultInt},
+		Profile:    emptyProfile(),
 		Now:        func() time.Time { return fixedNow },
 	}
 
@@ -224,6 +235,7 @@ func TestBuildParamsLastUpdateConflict(t *testing.T) {
 		},
 		User:       params.User{UserID: activityTestEmpty},
 		LastUpdate: params.LastUpdate{LastUpdate: activityTestLastUpdate},
+		Profile:    emptyProfile(),
 		Now:        nil,
 	}
 
@@ -253,6 +265,7 @@ func TestBuildParamsDateConflict(t *testing.T) {
 		},
 		User:       params.User{UserID: activityTestEmpty},
 		LastUpdate: params.LastUpdate{LastUpdate: activityTestDefaultInt},
+		Profile:    emptyProfile(),
 		Now:        nil,
 	}
 
@@ -282,6 +295,7 @@ func TestBuildParamsInvalidDate(t *testing.T) {
 		},
 		User:       params.User{UserID: activityTestEmpty},
 		LastUpdate: params.LastUpdate{LastUpdate: activityTestDefaultInt},
+		Profile:    emptyProfile(),
 		Now:        nil,
 	}
 
@@ -295,6 +309,179 @@ func TestBuildParamsInvalidDate(t *testing.T) {
 	}
 }
 
+// TestEstimateBMR applies Mifflin-St Jeor for both sexes.
+func TestEstimateBMR(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		sex  string
+		want float64
+	}{
+		{sex: "male", want: activityTestBMR},
+		{sex: "F", want: 1564},
+	}
+
+	for _, test := range cases {
+		bmr, err := estimateBMR(Profile{
+			Age:      activityTestAge,
+			HeightCm: activityTestHeight,
+			WeightKg: activityTestWeight,
+			Sex:      test.sex,
+		})
+		if err != nil {
+			t.Fatalf("estimateBMR: %v", err)
+		}
+
+		if bmr == nil || *bmr != test.want {
+			t.Fatalf("bmr %s got %v want %v", test.sex, bmr, test.want)
+		}
+	}
+}
+
+// TestEstimateBMRRequiresFullProfile rejects partial profiles.
+func TestEstimateBMRRequiresFullProfile(t *testing.T) {
+	t.Parallel()
+
+	profile := emptyProfile()
+	profile.Age = activityTestAge
+
+	_, err := estimateBMR(profile)
+	if !errors.Is(err, errProfileIncomplete) {
+		t.Fatalf(activityTestErrFmt, err, errProfileIncomplete)
+	}
+
+	bmr, err := estimateBMR(emptyProfile())
+	if err != nil || bmr != nil {
+		t.Fatalf("empty profile got %v, %v want nil, nil", bmr, err)
+	}
+}
+
+// TestActivityOnlyCalories subtracts BMR from total calories.
+func TestActivityOnlyCalories(t *testing.T) {
+	t.Parallel()
+
+	bmr := float64(activityTestBMR)
+
+	assertParam(t, activityOnlyCalories(activityTestTotal, &bmr), "450", "kcal")
+	assertParam(t, activityOnlyCalories(activityTestBMR-1, &bmr), "0", "floor")
+	assertParam(t, activityOnlyCalories(activityTestTotal, nil), "", "none")
+}
+
+// TestBuildRowsImperial converts distances and labels the table header.
+func TestBuildRowsImperial(t *testing.T) {
+	t.Parallel()
+
+	var day item
+
+	day.Date = activityTestDate
+	day.Distance = 8046.72
+	day.Elevation = 30.48
+
+	var activityBody body
+
+	activityBody.Activities = []item{day}
+
+	rows := buildRows(activityBody, nil, units.Imperial, Goal{})
+	assertParam(t, rows[0].Distance, "5", "distance")
+	assertParam(t, rows[0].Elevation, "100", "elevation")
+
+	header := tableHeaderFor(units.Imperial)
+	if !strings.Contains(header, "\tDistance (mi)\t") ||
+		!strings.Contains(header, "\tElevation (ft)\t") {
+		t.Fatalf("header got %q", header)
+	}
+}
+
+// TestPlainColumnsKeepPositions keeps the original columns where scripts
+// expect them and appends the BMR columns after them.
+func TestPlainColumnsKeepPositions(t *testing.T) {
+	t.Parallel()
+
+	var day item
+
+	day.Date = activityTestDate
+	day.Steps = 8046
+	day.TotalCalories = activityTestTotal
+	day.Intense = 600
+
+	var activityBody body
+
+	activityBody.Activities = []item{day}
+
+	bmr := float64(activityTestBMR)
+	lines := formatLines(buildRows(activityBody, &bmr, units.Metric, Goal{}),
+		Goal{})
+	header := strings.Split(lines[0], "\t")
+	cells := strings.Split(lines[1], "\t")
+	want := []string{
+		"date", "steps", "distance", "calories", "total_calories", "active",
+		"elevation", "soft", "moderate", "intense", "bmr",
+		"activity_calories",
+	}
+
+	if !slices.Equal(header, want) {
+		t.Fatalf("header got %q want %q", header, want)
+	}
+
+	assertParam(t, cells[1], "8046", "steps")
+	assertParam(t, cells[9], "600", "intense")
+	assertParam(t, cells[10], "1730", "bmr")
+	assertParam(t, cells[11], "450", "activity_calories")
+}
+
+// TestGoalProgress appends goal and progress to rows and draws the bar in
+// tables only.
+func TestGoalProgress(t *testing.T) {
+	t.Parallel()
+
+	var day item
+
+	day.Date = activityTestDate
+	day.Steps = 8046
+	day.Calories = 600
+
+	var activityBody body
+
+	activityBody.Activities = []item{day}
+
+	goal := Goal{Metric: MetricSteps, Target: 10000, Bar: true}
+	rows := buildRows(activityBody, nil, units.Metric, goal)
+	assertParam(t, rows[0].Progress, "80.5", "steps progress")
+	assertParam(t, rows[0].Bar, "################----", "bar")
+
+	lines := formatLines(rows, goal)
+	if !strings.HasSuffix(lines[0], "\tgoal\tprogress") ||
+		!strings.HasSuffix(lines[1], "\t10000\t80.5") {
+		t.Fatalf("plain lines %q", lines)
+	}
+
+	calories := Goal{Metric: MetricCalories, Target: 500, Bar: true}
+	rows = buildRows(activityBody, nil, units.Metric, calories)
+	assertParam(t, rows[0].Progress, "120", "calories progress")
+	assertParam(t, rows[0].Bar, strings.Repeat("#", goalBarWidth), "full")
+
+	metric, err := ParseMetric("")
+	assertParam(t, metric, MetricSteps, "default metric")
+
+	if err != nil {
+		t.Fatalf("ParseMetric: %v", err)
+	}
+
+	_, err = ParseMetric("floors")
+	if !errors.Is(err, ErrInvalidMetric) {
+		t.Fatalf(activityTestErrFmt, err, ErrInvalidMetric)
+	}
+}
+
+func emptyProfile() Profile {
+	return Profile{
+		Age:      activityTestDefaultInt,
+		HeightCm: activityTestDefaultInt,
+		WeightKg: activityTestDefaultInt,
+		Sex:      activityTestEmpty,
+	}
+}
+
 func assertParam(t *testing.T, got, want, label string) {
 	t.Helper()
 
@@ -0,0 +1,329 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	repeatTableHeader = "Call\tHTTP\tStatus\tLatency\tError"
+	repeatPlainHeader = "call\thttp\tstatus\tlatency_ms\terror"
+	percentMedian     = 50
+	percentTail       = 95
+	percentAll        = 100
+	noHTTPStatus      = 0
+	outcomeOK         = "ok"
+	outcomeNetwork    = "network"
+)
+
+var errRepeatFailed = errors.New("repeated calls failed")
+
+// Call is the outcome of one repeated request.
+type Call struct {
+	Index      int    `json:"call"`
+	HTTPStatus int    `json:"http_status,omitempty"`
+	Status     *int   `json:"status,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+
+	latency time.Duration
+	outcome string
+}
+
+// RepeatSummary aggregates the outcomes of a --repeat run.
+type RepeatSummary struct {
+	Calls    int            `json:"calls"`
+	OK       int            `json:"ok"`
+	Failed   int            `json:"failed"`
+	Outcomes map[string]int `json:"outcomes"`
+	MinMS    int64          `json:"min_ms"`
+	AvgMS    int64          `json:"avg_ms"`
+	P50MS    int64          `json:"p50_ms"`
+	P95MS    int64          `json:"p95_ms"`
+	MaxMS    int64          `json:"max_ms"`
+}
+
+type repeatOutput struct {
+	Calls   []Call        `json:"calls"`
+	Summary RepeatSummary `json:"summary"`
+}
+
+// runRepeat sends the same request opts.Repeat times, opts.Interval
+// apart, and reports per-call outcomes plus latency statistics. An
+// interrupt stops the loop early; the calls made so far are reported.
+func runRepeat(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	spec requestSpec,
+) error {
+	client := withings.NewClient(appOpts)
+	// Every call must reach the server to be measured.
+	client.Cache = nil
+	calls := make([]Call, 0, opts.Repeat)
+
+	for index := range opts.Repeat {
+		if index > 0 && opts.Interval > 0 {
+			if client.Sleep(ctx, opts.Interval) != nil {
+				break
+			}
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		calls = append(calls, doCall(ctx, client, spec, index+1))
+	}
+
+	summary := summarize(calls)
+
+	err := writeRepeat(appOpts, calls, summary)
+	if err != nil {
+		return err
+	}
+
+	if summary.Failed > 0 {
+		return app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf(
+				"%w: %d of %d",
+				errRepeatFailed,
+				summary.Failed,
+				summary.Calls,
+			),
+		)
+	}
+
+	return nil
+}
+
+func doCall(
+	ctx context.Context,
+	client *withings.Client,
+	spec requestSpec,
+	index int,
+) Call {
+	call := Call{
+		Index:      index,
+		HTTPStatus: noHTTPStatus,
+		Status:     nil,
+		LatencyMS:  0,
+		Error:      "",
+		latency:    0,
+		outcome:    outcomeOK,
+	}
+
+	req, _, err := spec.build(ctx)
+	if err != nil {
+		call.outcome = outcomeNetwork
+		call.Error = err.Error()
+
+		return call
+	}
+
+	started := time.Now()
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := client.Do(req)
+	if err != nil {
+		call.setLatency(time.Since(started))
+		call.outcome = outcomeNetwork
+		call.Error = err.Error()
+
+		return call
+	}
+
+	call.HTTPStatus = resp.StatusCode
+
+	payload, err := withings.ReadPayload(resp)
+	call.setLatency(time.Since(started))
+
+	if err != nil {
+		call.outcome = "http " + strconv.Itoa(resp.StatusCode)
+		call.Error = err.Error()
+
+		return call
+	}
+
+	classifyPayload(&call, payload)
+
+	return call
+}
+
+// classifyPayload reads the Withings status; any non-zero status is a
+// failed call even though HTTP said 200.
+func classifyPayload(call *Call, payload []byte) {
+	var envelope struct {
+		Status *int   `json:"status"`
+		Error  string `json:"error"`
+	}
+
+	err := json.Unmarshal(payload, &envelope)
+	if err != nil || envelope.Status == nil {
+		call.outcome = "invalid response"
+		call.Error = "response has no status"
+
+		return
+	}
+
+	call.Status = envelope.Status
+	if *envelope.Status == withings.StatusOK {
+		return
+	}
+
+	call.outcome = "status " + strconv.Itoa(*envelope.Status)
+	call.Error = envelope.Error
+}
+
+func (c *Call) setLatency(latency time.Duration) {
+	c.latency = latency
+	c.LatencyMS = latency.Milliseconds()
+}
+
+func summarize(calls []Call) RepeatSummary {
+	summary := RepeatSummary{
+		Calls:    len(calls),
+		OK:       0,
+		Failed:   0,
+		Outcomes: map[string]int{},
+		MinMS:    0,
+		AvgMS:    0,
+		P50MS:    0,
+		P95MS:    0,
+		MaxMS:    0,
+	}
+
+	latencies := make([]time.Duration, 0, len(calls))
+
+	var total time.Duration
+
+	for _, call := range calls {
+		summary.Outcomes[call.outcome]++
+
+		if call.outcome == outcomeOK {
+			summary.OK++
+		} else {
+			summary.Failed++
+		}
+
+		// Requests that never left (or never came back) have no latency.
+		if call.latency > 0 {
+			latencies = append(latencies, call.latency)
+			total += call.latency
+		}
+	}
+
+	if len(latencies) == 0 {
+		return summary
+	}
+
+	slices.Sort(latencies)
+
+	summary.MinMS = latencies[0].Milliseconds()
+	summary.AvgMS = (total / time.Duration(len(latencies))).Milliseconds()
+	summary.P50MS = percentile(latencies, percentMedian).Milliseconds()
+	summary.P95MS = percentile(latencies, percentTail).Milliseconds()
+	summary.MaxMS = latencies[len(latencies)-1].Milliseconds()
+
+	return summary
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []time.Duration, percent int) time.Duration {
+	rank := (percent*len(sorted) + percentAll - 1) / percentAll
+
+	return sorted[max(rank, 1)-1]
+}
+
+func writeRepeat(
+	appOpts app.Options,
+	calls []Call,
+	summary RepeatSummary,
+) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		return output.WriteRawJSON(
+			appOpts,
+			repeatOutput{Calls: calls, Summary: summary},
+		)
+	}
+
+	lines := make([]string, 0, len(calls)+1)
+	lines = append(lines, repeatPlainHeader)
+
+	for _, call := range calls {
+		status := ""
+		if call.Status != nil {
+			status = strconv.Itoa(*call.Status)
+		}
+
+		httpStatus := ""
+		if call.HTTPStatus != noHTTPStatus {
+			httpStatus = strconv.Itoa(call.HTTPStatus)
+		}
+
+		lines = append(lines, strings.Join([]string{
+			strconv.Itoa(call.Index),
+			httpStatus,
+			status,
+			strconv.FormatInt(call.LatencyMS, 10),
+			call.Error,
+		}, "\t"))
+	}
+
+	if appOpts.Plain || appOpts.NDJSON {
+		err := output.WritePlain(appOpts, lines)
+		if err != nil {
+			return fmt.Errorf("write repeat output: %w", err)
+		}
+
+		// Keep stdout parseable; the summary goes to stderr.
+		return output.WriteWarning(appOpts, formatSummary(summary))
+	}
+
+	lines[0] = repeatTableHeader
+
+	table, err := output.RenderTable(appOpts, repeatPlainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render repeat table: %w", err)
+	}
+
+	return output.WriteLine(appOpts, table+"\n\n"+formatSummary(summary))
+}
+
+func formatSummary(summary RepeatSummary) string {
+	outcomes := make([]string, 0, len(summary.Outcomes))
+	for outcome, count := range summary.Outcomes {
+		outcomes = append(outcomes, fmt.Sprintf("%s: %d", outcome, count))
+	}
+
+	slices.Sort(outcomes)
+
+	return fmt.Sprintf(
+		"calls: %d, ok: %d, failed: %d (%s)\n"+
+			"latency: min %dms, avg %dms, p50 %dms, p95 %dms, max %dms",
+		summary.Calls,
+		summary.OK,
+		summary.Failed,
+		strings.Join(outcomes, ", "),
+		summary.MinMS,
+		summary.AvgMS,
+		summary.P50MS,
+		summary.P95MS,
+		summary.MaxMS,
+	)
+}
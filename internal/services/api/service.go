@@ -11,8 +11,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/output"
@@ -22,19 +25,46 @@ import (
 const (
 	floatBitSize    = 64
 	paramFilePrefix = "@"
+	dryRunNonce     = "<nonce>"
+	headerSeparator = ":"
 )
 
 var (
 	errParamsNotObject      = errors.New("params must be a JSON object")
 	errUnsupportedParamType = errors.New("param has unsupported type")
+	errInvalidRepeat        = errors.New("--repeat must be at least 1")
+	errInvalidInterval      = errors.New("--interval must not be negative")
+	errInvalidMethod        = errors.New("invalid --method")
+	errInvalidHeader        = errors.New(
+		"invalid --header (expected \"Name: value\")",
+	)
+	errSignRepeat = errors.New(
+		"--sign cannot be combined with --repeat (nonces are single-use)",
+	)
 )
 
+//nolint:gochecknoglobals // Compiled once; HTTP method tokens.
+var methodPattern = regexp.MustCompile(`^[A-Z]+$`)
+
 // Options captures API call parameters.
 type Options struct {
 	Service string
 	Action  string
 	Params  string
 	DryRun  bool
+	// Method is the HTTP method; GET and HEAD send the action and params
+	// in the query string instead of a form body.
+	Method string
+	// Headers are extra "Name: value" request headers.
+	Headers []string
+	// Repeat sends the request this many times and reports statistics
+	// instead of the response when above 1.
+	Repeat   int
+	Interval time.Duration
+	// Sign adds client_id, a fresh nonce, and the signature to the params
+	// and sends no access token, for actions of signed services.
+	Sign        bool
+	Credentials withings.Credentials
 }
 
 // Run executes an API call and writes output.
@@ -49,24 +79,43 @@ func Run(
 		return app.NewExitError(app.ExitCodeUsage, err)
 	}
 
-	req, body, err := withings.BuildRequest(
-		ctx,
-		withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud),
-		opts.Service,
-		opts.Action,
-		accessToken,
-		params,
-	)
+	err = validateRepeat(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	spec, err := newRequestSpec(opts, appOpts, accessToken, params)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	if opts.Sign {
+		err = signSpec(ctx, opts, appOpts, spec)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, body, err := spec.build(ctx)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return err
 	}
 
 	if opts.DryRun {
-		return writeDryRun(appOpts, req.URL.String(), body)
+		return writeDryRun(appOpts, req, spec.header, body)
+	}
+
+	err = checkWrite(appOpts, spec)
+	if err != nil {
+		return err
+	}
+
+	if opts.Repeat > 1 {
+		return runRepeat(ctx, opts, appOpts, spec)
 	}
 
 	//nolint:bodyclose // ReadPayload closes the response body.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := withings.NewClient(appOpts).Do(req)
 	if err != nil {
 		return app.NewExitError(app.ExitCodeNetwork, err)
 	}
@@ -79,6 +128,166 @@ func Run(
 	return writeResponse(appOpts, payload)
 }
 
+// requestSpec is everything needed to build the call again, once per
+// --repeat iteration.
+type requestSpec struct {
+	baseURL     string
+	method      string
+	service     string
+	action      string
+	accessToken string
+	params      url.Values
+	header      http.Header
+}
+
+func newRequestSpec(
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+	params url.Values,
+) (requestSpec, error) {
+	method := strings.ToUpper(strings.TrimSpace(opts.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	if !methodPattern.MatchString(method) {
+		return requestSpec{}, fmt.Errorf(
+			"%w: %q",
+			errInvalidMethod,
+			opts.Method,
+		)
+	}
+
+	header, err := parseHeaders(opts.Headers)
+	if err != nil {
+		return requestSpec{}, err
+	}
+
+	return requestSpec{
+		baseURL:     withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud),
+		method:      method,
+		service:     opts.Service,
+		action:      opts.Action,
+		accessToken: accessToken,
+		params:      params,
+		header:      header,
+	}, nil
+}
+
+func (s requestSpec) build(ctx context.Context) (*http.Request, string, error) {
+	req, body, err := withings.NewRequest(
+		ctx,
+		s.method,
+		s.baseURL,
+		s.service,
+		s.action,
+		s.accessToken,
+		s.params,
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("build request: %w", err)
+	}
+
+	if s.accessToken == "" {
+		req.Header.Del("Authorization")
+	}
+
+	for name, values := range s.header {
+		req.Header[name] = values
+	}
+
+	return req, body, nil
+}
+
+// signSpec signs the params with a fresh nonce; --dry-run shows a
+// placeholder instead of spending one. The read-only check runs first so
+// refused calls never request a nonce.
+func signSpec(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	spec requestSpec,
+) error {
+	nonce := dryRunNonce
+
+	if !opts.DryRun {
+		err := checkWrite(appOpts, spec)
+		if err != nil {
+			return err
+		}
+
+		nonce, err = withings.GetNonce(ctx, appOpts, opts.Credentials)
+		if err != nil {
+			return fmt.Errorf("get nonce: %w", err)
+		}
+	}
+
+	withings.SignParams(spec.params, spec.action, opts.Credentials, nonce)
+
+	return nil
+}
+
+// parseHeaders reads repeated "Name: value" flags; a later flag for the
+// same name replaces the earlier one, as do defaults such as
+// Authorization.
+func parseHeaders(raw []string) (http.Header, error) {
+	header := http.Header{}
+
+	for _, entry := range raw {
+		name, value, ok := strings.Cut(entry, headerSeparator)
+		name = strings.TrimSpace(name)
+
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("%w: %q", errInvalidHeader, entry)
+		}
+
+		header.Set(name, strings.TrimSpace(value))
+	}
+
+	return header, nil
+}
+
+// checkWrite applies --read-only: besides write actions, methods other
+// than GET, HEAD, and POST are refused.
+func checkWrite(appOpts app.Options, spec requestSpec) error {
+	switch spec.method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		return withings.CheckWrite(appOpts, spec.service, spec.action)
+	}
+
+	if !appOpts.ReadOnly {
+		return nil
+	}
+
+	return app.NewExitError(
+		app.ExitCodeUsage,
+		fmt.Errorf(
+			"%w (%s %s %s)",
+			withings.ErrReadOnly,
+			spec.method,
+			spec.service,
+			spec.action,
+		),
+	)
+}
+
+func validateRepeat(opts Options) error {
+	if opts.Repeat < 1 {
+		return fmt.Errorf("%w: %d", errInvalidRepeat, opts.Repeat)
+	}
+
+	if opts.Interval < 0 {
+		return fmt.Errorf("%w: %s", errInvalidInterval, opts.Interval)
+	}
+
+	if opts.Sign && opts.Repeat > 1 {
+		return errSignRepeat
+	}
+
+	return nil
+}
+
 func parseParams(raw string) (url.Values, error) {
 	if raw == "" {
 		return url.Values{}, nil
@@ -172,10 +381,27 @@ func encodeParamValue(key string, value any) (string, error) {
 	}
 }
 
-func writeDryRun(opts app.Options, endpoint, body string) error {
-	lines := []string{
-		"POST " + endpoint,
-		body,
+func writeDryRun(
+	opts app.Options,
+	req *http.Request,
+	header http.Header,
+	body string,
+) error {
+	lines := []string{req.Method + " " + req.URL.String()}
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		lines = append(lines, name+headerSeparator+" "+header.Get(name))
+	}
+
+	if body != "" {
+		lines = append(lines, body)
 	}
 
 	err := output.WriteOutput(opts, lines)
@@ -206,7 +432,7 @@ func writeResponse(opts app.Options, payload []byte) error {
 		return nil
 	}
 
-	err := output.WriteLine(string(payload))
+	err := output.WriteLine(opts, string(payload))
 	if err != nil {
 		return fmt.Errorf("write output: %w", err)
 	}
@@ -2,11 +2,19 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/mreimbold/withings-cli/internal/app"
 	"github.com/mreimbold/withings-cli/internal/withings"
 )
 
@@ -21,6 +29,7 @@ const (
 	apiParamValueTest    = "test"
 	apiParamValueFile    = "file"
 	apiParamValueStdin   = "stdin"
+	apiRepeatCalls       = 20
 )
 
 // TestServiceEndpoint covers endpoint composition with and without /v2.
@@ -178,3 +187,167 @@ func TestEncodeParamValueUnsupported(t *testing.T) {
 		t.Fatalf("expected errUnsupportedParamType, got %v", err)
 	}
 }
+
+// TestRequestSpecGET moves the action and params into the query string
+// and applies extra headers over the defaults.
+func TestRequestSpecGET(t *testing.T) {
+	t.Parallel()
+
+	spec, err := newRequestSpec(
+		Options{
+			Service: apiMeasureService,
+			Action:  "getmeas",
+			Method:  "get",
+			Headers: []string{"Accept: application/json", "X-Trace:a:b"},
+		},
+		app.Options{},
+		"token",
+		url.Values{apiParamNameKey: {apiParamValueTest}},
+	)
+	if err != nil {
+		t.Fatalf("newRequestSpec: %v", err)
+	}
+
+	req, body, err := spec.build(context.Background())
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	if req.Method != http.MethodGet || body != "" || req.Body != http.NoBody {
+		t.Fatalf("got %s with body %q", req.Method, body)
+	}
+
+	want := apiMeasureEndpoint + "?action=getmeas&name=test"
+	if req.URL.String() != want {
+		t.Fatalf("url got %q want %q", req.URL, want)
+	}
+
+	if req.Header.Get("X-Trace") != "a:b" ||
+		req.Header.Get("Accept") != "application/json" ||
+		req.Header.Get("Authorization") != "Bearer token" {
+		t.Fatalf("unexpected headers %v", req.Header)
+	}
+}
+
+// TestRequestSpecInvalid rejects malformed methods and headers.
+func TestRequestSpecInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		opts Options
+		want error
+	}{
+		{opts: Options{Method: "GE T"}, want: errInvalidMethod},
+		{opts: Options{Headers: []string{"NoColon"}}, want: errInvalidHeader},
+		{opts: Options{Headers: []string{": value"}}, want: errInvalidHeader},
+	}
+
+	for _, test := range tests {
+		_, err := newRequestSpec(test.opts, app.Options{}, "token", nil)
+		if !errors.Is(err, test.want) {
+			t.Fatalf("%+v: got %v want %v", test.opts, err, test.want)
+		}
+	}
+}
+
+// TestDoCallClassifiesOutcomes separates HTTP, Withings, and OK results.
+func TestDoCallClassifiesOutcomes(t *testing.T) {
+	t.Parallel()
+
+	responses := map[string]struct {
+		code int
+		body string
+	}{
+		"/ok":        {http.StatusOK, `{"status":0,"body":{}}`},
+		"/api-error": {http.StatusOK, `{"status":601,"error":"Too many"}`},
+		"/http":      {http.StatusTooManyRequests, `{}`},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			response := responses[req.URL.Path]
+			writer.WriteHeader(response.code)
+			_, _ = io.WriteString(writer, response.body)
+		},
+	))
+	defer server.Close()
+
+	client := withings.NewClient(app.Options{})
+	client.Retries = 0
+
+	want := map[string]string{
+		"ok":        outcomeOK,
+		"api-error": "status 601",
+		"http":      "http 429",
+	}
+
+	for service, outcome := range want {
+		spec := requestSpec{
+			baseURL:     server.URL,
+			method:      http.MethodPost,
+			service:     service,
+			action:      "getmeas",
+			accessToken: "token",
+			params:      url.Values{},
+			header:      http.Header{},
+		}
+
+		call := doCall(context.Background(), client, spec, 1)
+		if call.outcome != outcome {
+			t.Fatalf("%s: got %q want %q", service, call.outcome, outcome)
+		}
+	}
+}
+
+// TestSummarizeLatencies uses nearest-rank percentiles over the calls
+// that got an answer.
+func TestSummarizeLatencies(t *testing.T) {
+	t.Parallel()
+
+	calls := make([]Call, 0, apiRepeatCalls+1)
+
+	for index := range apiRepeatCalls {
+		call := Call{Index: index + 1, outcome: outcomeOK}
+		call.setLatency(time.Duration(index+1) * time.Millisecond)
+		calls = append(calls, call)
+	}
+
+	calls = append(calls, Call{Index: apiRepeatCalls + 1, outcome: "network"})
+
+	summary := summarize(calls)
+
+	want := RepeatSummary{
+		Calls:    apiRepeatCalls + 1,
+		OK:       apiRepeatCalls,
+		Failed:   1,
+		Outcomes: map[string]int{outcomeOK: apiRepeatCalls, "network": 1},
+		MinMS:    1,
+		AvgMS:    10,
+		P50MS:    10,
+		P95MS:    19,
+		MaxMS:    20,
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Fatalf("summary got %+v want %+v", summary, want)
+	}
+}
+
+// TestValidateRepeat rejects counts below one and negative intervals.
+func TestValidateRepeat(t *testing.T) {
+	t.Parallel()
+
+	err := validateRepeat(Options{Repeat: 0})
+	if !errors.Is(err, errInvalidRepeat) {
+		t.Fatalf("repeat 0: got %v", err)
+	}
+
+	err = validateRepeat(Options{Repeat: 2, Interval: -time.Second})
+	if !errors.Is(err, errInvalidInterval) {
+		t.Fatalf("negative interval: got %v", err)
+	}
+
+	err = validateRepeat(Options{Repeat: 2, Sign: true})
+	if !errors.Is(err, errSignRepeat) {
+		t.Fatalf("signed repeat: got %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+// Package devices handles Withings device endpoints.
+package devices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	serviceName       = "v2/user"
+	serviceShort      = "user"
+	serviceV2Suffix   = "/v2"
+	actionGet         = "getdevice"
+	userIDParam       = "userid"
+	batteryLow        = "low"
+	batteryMedium     = "medium"
+	batteryHigh       = "high"
+	batteryLowMax     = 30
+	batteryMediumMax  = 75
+	batteryHighMax    = 100
+	batteryUnknown    = -1
+	batteryPercentMax = 100
+	rowsHeaderCount   = 1
+	tableHeader       = "Model\tModel ID\tType\tBattery\tLast Session\t" +
+		"Firmware\tMAC\tDevice ID"
+	plainHeader = "model\tmodel_id\ttype\tbattery\tlast_session\t" +
+		"firmware\tmac\tdevice_id"
+	defaultInt   = 0
+	defaultInt64 = 0
+	emptyString  = ""
+)
+
+var errInvalidBatteryBelow = errors.New(
+	"--battery-below must be between 0 and 100",
+)
+
+// Options captures device query parameters.
+type Options struct {
+	User         params.User
+	BatteryBelow int
+}
+
+// Run fetches linked devices and writes output.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	if opts.BatteryBelow < defaultInt ||
+		opts.BatteryBelow > batteryPercentMax {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidBatteryBelow)
+	}
+
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		serviceForBase(baseURL),
+		actionGet,
+		accessToken,
+		buildParams(opts),
+	)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return writeResponse(appOpts, opts.BatteryBelow, payload)
+}
+
+func serviceForBase(baseURL string) string {
+	trimmed := strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(trimmed, serviceV2Suffix) {
+		return serviceShort
+	}
+
+	return serviceName
+}
+
+func buildParams(opts Options) url.Values {
+	values := url.Values{}
+
+	if opts.User.UserID != emptyString {
+		values.Set(userIDParam, opts.User.UserID)
+	}
+
+	return values
+}
+
+type response struct {
+	Status int    `json:"status"`
+	Body   body   `json:"body"`
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+}
+
+type body struct {
+	Devices []device `json:"devices"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type device struct {
+	Type             string          `json:"type"`
+	Model            string          `json:"model"`
+	ModelID          int             `json:"model_id"`
+	Battery          json.RawMessage `json:"battery"`
+	DeviceID         string          `json:"deviceid"`
+	HashDeviceID     string          `json:"hash_deviceid"`
+	Timezone         string          `json:"timezone"`
+	LastSessionDate  int64           `json:"last_session_date"`
+	FirstSessionDate int64           `json:"first_session_date"`
+	Firmware         string          `json:"fw"`
+	MACAddress       string          `json:"mac_address"`
+}
+
+type row struct {
+	Model       string
+	ModelID     string
+	Type        string
+	Battery     string
+	LastSession string
+	Firmware    string
+	MAC         string
+	DeviceID    string
+}
+
+func writeResponse(opts app.Options, below int, payload []byte) error {
+	decoded, err := decodeResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	return writeBody(opts, filterBattery(decoded.Body, below))
+}
+
+// filterBattery keeps devices whose battery is known to be below the given
+// percentage. Withings reports levels as low (<30%), medium (30-75%), or
+// high (>75%), so a level matches when its upper bound is at or below it.
+func filterBattery(body body, below int) body {
+	if below == defaultInt {
+		return body
+	}
+
+	kept := make([]device, defaultInt, len(body.Devices))
+
+	for _, item := range body.Devices {
+		level := batteryUpperBound(item.Battery)
+		if level != batteryUnknown && level <= below {
+			kept = append(kept, item)
+		}
+	}
+
+	body.Devices = kept
+
+	return body
+}
+
+func batteryUpperBound(raw json.RawMessage) int {
+	var percent int
+	if json.Unmarshal(raw, &percent) == nil {
+		return percent
+	}
+
+	switch strings.ToLower(batteryText(raw)) {
+	case batteryLow:
+		return batteryLowMax
+	case batteryMedium:
+		return batteryMediumMax
+	case batteryHigh:
+		return batteryHighMax
+	default:
+		return batteryUnknown
+	}
+}
+
+func batteryText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+
+	var percent int
+	if json.Unmarshal(raw, &percent) == nil {
+		return strconv.Itoa(percent) + "%"
+	}
+
+	return emptyString
+}
+
+func writeBody(opts app.Options, body body) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON && opts.Fields == emptyString {
+		return writeJSONOutput(opts, body)
+	}
+
+	rows := buildRows(body)
+
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		return writePlainOutput(opts, rows)
+	}
+
+	return writeTableOutput(opts, rows)
+}
+
+func writeJSONOutput(opts app.Options, body body) error {
+	err := output.WriteRawJSON(opts, body)
+	if err != nil {
+		return fmt.Errorf("write json output: %w", err)
+	}
+
+	return nil
+}
+
+func writePlainOutput(opts app.Options, rows []row) error {
+	err := output.WritePlain(opts, formatLines(rows))
+	if err != nil {
+		return fmt.Errorf("write plain output: %w", err)
+	}
+
+	return nil
+}
+
+func writeTableOutput(opts app.Options, rows []row) error {
+	table, err := formatTable(opts, rows)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func decodeResponse(payload []byte) (response, error) {
+	var decoded response
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return response{}, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	if decoded.Status != withings.StatusOK {
+		message := decoded.Error
+		if message == emptyString {
+			message = decoded.Detail
+		}
+
+		if message == emptyString {
+			message = strings.TrimSpace(string(payload))
+		}
+
+		return response{}, app.NewExitError(
+			app.ExitCodeAPI,
+			&withings.APIError{Status: decoded.Status, Message: message},
+		)
+	}
+
+	return decoded, nil
+}
+
+func buildRows(body body) []row {
+	rows := make([]row, defaultInt, len(body.Devices))
+
+	for _, item := range body.Devices {
+		rows = append(rows, row{
+			Model:       item.Model,
+			ModelID:     strconv.Itoa(item.ModelID),
+			Type:        item.Type,
+			Battery:     batteryText(item.Battery),
+			LastSession: formatTime(item.LastSessionDate, item.Timezone),
+			Firmware:    item.Firmware,
+			MAC:         item.MACAddress,
+			DeviceID:    item.DeviceID,
+		})
+	}
+
+	return rows
+}
+
+func formatTime(epoch int64, timezone string) string {
+	if epoch == defaultInt64 {
+		return emptyString
+	}
+
+	location := time.UTC
+
+	if timezone != emptyString {
+		loaded, err := time.LoadLocation(timezone)
+		if err == nil {
+			location = loaded
+		}
+	}
+
+	return time.Unix(epoch, defaultInt64).In(location).Format(time.RFC3339)
+}
+
+func formatTable(opts app.Options, rows []row) (string, error) {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, tableHeader)
+
+	for _, row := range rows {
+		lines = append(lines, formatRow(row))
+	}
+
+	table, err := output.RenderTable(opts, plainHeader, lines)
+	if err != nil {
+		return emptyString, fmt.Errorf("render devices table: %w", err)
+	}
+
+	return table, nil
+}
+
+func formatLines(rows []row) []string {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, plainHeader)
+
+	for _, row := range rows {
+		lines = append(lines, formatRow(row))
+	}
+
+	return lines
+}
+
+func formatRow(row row) string {
+	return strings.Join([]string{
+		row.Model,
+		row.ModelID,
+		row.Type,
+		row.Battery,
+		row.LastSession,
+		row.Firmware,
+		row.MAC,
+		row.DeviceID,
+	}, "\t")
+}
@@ -0,0 +1,120 @@
+//nolint:testpackage // test unexported helpers.
+package devices
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	devicesTestBaseNoV2  = "https://wbsapi.withings.net"
+	devicesTestBaseV2    = "https://wbsapi.withings.net/v2/"
+	devicesTestUserID    = "user-123"
+	devicesTestLastEpoch = 1767081600
+	devicesTestModelID   = 13
+	devicesTestBelowLow  = 30
+	devicesTestBelowMid  = 50
+	devicesTestBelowHigh = 80
+	devicesTestGotFmt    = "%s got %q want %q"
+)
+
+// TestDevicesServiceForBase handles base URLs with and without /v2.
+func TestDevicesServiceForBase(t *testing.T) {
+	t.Parallel()
+
+	got := serviceForBase(devicesTestBaseNoV2)
+	assertDevices(t, "service", got, serviceName)
+
+	got = serviceForBase(devicesTestBaseV2)
+	assertDevices(t, "service v2", got, serviceShort)
+}
+
+// TestBuildParams sets the user ID when provided.
+func TestBuildParams(t *testing.T) {
+	t.Parallel()
+
+	values := buildParams(Options{
+		User:         params.User{UserID: devicesTestUserID},
+		BatteryBelow: defaultInt,
+	})
+	assertDevices(t, "userid", values.Get(userIDParam), devicesTestUserID)
+}
+
+// TestFilterBattery keeps devices whose battery level is below the cut-off.
+func TestFilterBattery(t *testing.T) {
+	t.Parallel()
+
+	input := body{Devices: []device{
+		testDevice(`"low"`),
+		testDevice(`"medium"`),
+		testDevice(`"high"`),
+		testDevice(`"unknown"`),
+		testDevice(`42`),
+	}}
+
+	cases := []struct {
+		below int
+		want  int
+	}{
+		{below: defaultInt, want: len(input.Devices)},
+		{below: devicesTestBelowLow, want: 1},
+		{below: devicesTestBelowMid, want: 2},
+		{below: devicesTestBelowHigh, want: 3},
+	}
+
+	for _, test := range cases {
+		got := filterBattery(input, test.below)
+		if len(got.Devices) != test.want {
+			t.Fatalf(
+				"below %d got %d devices want %d",
+				test.below,
+				len(got.Devices),
+				test.want,
+			)
+		}
+	}
+}
+
+// TestBuildRows formats device columns.
+func TestBuildRows(t *testing.T) {
+	t.Parallel()
+
+	rows := buildRows(body{Devices: []device{testDevice(`42`)}})
+
+	assertDevices(t, "model", rows[0].Model, "Body Cardio")
+	assertDevices(t, "model_id", rows[0].ModelID, "13")
+	assertDevices(t, "battery", rows[0].Battery, "42%")
+	assertDevices(
+		t,
+		"last_session",
+		rows[0].LastSession,
+		"2025-12-30T08:00:00Z",
+	)
+	assertDevices(t, "mac", rows[0].MAC, "00:24:e4:00:00:01")
+}
+
+func testDevice(battery string) device {
+	return device{
+		Type:             "Scale",
+		Model:            "Body Cardio",
+		ModelID:          devicesTestModelID,
+		Battery:          json.RawMessage(battery),
+		DeviceID:         "abc123",
+		HashDeviceID:     emptyString,
+		Timezone:         "UTC",
+		LastSessionDate:  devicesTestLastEpoch,
+		FirstSessionDate: defaultInt64,
+		Firmware:         "1.2.3",
+		MACAddress:       "00:24:e4:00:00:01",
+	}
+}
+
+func assertDevices(t *testing.T, label, got, want string) {
+	t.Helper()
+
+	if got != want {
+		t.Fatalf(devicesTestGotFmt, label, got, want)
+	}
+}
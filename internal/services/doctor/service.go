@@ -0,0 +1,396 @@
+// Package doctor checks the local setup (config files, tokens, client
+// credentials, network reachability, clock, and the OAuth callback port)
+// and suggests a fix for every problem it finds.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	// StatusPass marks a check without findings.
+	StatusPass = "pass"
+	// StatusWarn marks a finding that does not block commands yet.
+	StatusWarn = "warn"
+	// StatusFail marks a finding that breaks commands.
+	StatusFail = "fail"
+
+	// DefaultTimeout bounds each network probe.
+	DefaultTimeout = 5 * time.Second
+
+	groupOtherPerm = 0o077
+	tableHeader    = "Check\tStatus\tDetail\tHint"
+	plainHeader    = "check\tstatus\tdetail\thint"
+	emptyString    = ""
+	osWindows      = "windows"
+)
+
+var errChecksFailed = errors.New("doctor found problems")
+
+// Options configures the checks.
+type Options struct {
+	// Listen is the callback address `auth login` would use.
+	Listen  string
+	Timeout time.Duration
+	Now     func() time.Time
+}
+
+// Check is one diagnostic result.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// Run performs every check, writes the results, and exits with code 1
+// when any check failed. Warnings keep exit code 0.
+func Run(ctx context.Context, opts Options, appOpts app.Options) error {
+	checks := Collect(ctx, opts, appOpts)
+
+	err := writeOutput(appOpts, checks)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, check := range checks {
+		if check.Status == StatusFail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("%w: %d check(s) failed", errChecksFailed, failed),
+		)
+	}
+
+	return nil
+}
+
+// Collect runs the checks in a fixed order. The network probes run before
+// the clock check, which reads the skew they measured.
+func Collect(ctx context.Context, opts Options, appOpts app.Options) []Check {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	report := auth.Diagnose(appOpts)
+
+	checks := make([]Check, 0, len(report.Configs))
+	for _, info := range report.Configs {
+		checks = append(checks, configCheck(info))
+	}
+
+	apiURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+	apiCheck := reachCheck(ctx, appOpts, opts.Timeout, "api host", apiURL)
+
+	return append(
+		checks,
+		tokenCheck(report, now()),
+		credentialsCheck(report),
+		apiCheck,
+		reachCheck(
+			ctx,
+			appOpts,
+			opts.Timeout,
+			"account host",
+			report.AccountBaseURL,
+		),
+		clockCheck(apiCheck),
+		callbackCheck(ctx, opts.Listen),
+	)
+}
+
+func configCheck(info auth.ConfigFileInfo) Check {
+	check := Check{
+		Name:   "config " + info.Scope,
+		Status: StatusPass,
+		Detail: info.Path,
+		Hint:   emptyString,
+	}
+
+	switch {
+	case info.Err != nil:
+		check.Status = StatusFail
+		check.Detail = info.Err.Error()
+		check.Hint = "make the file readable, or point --config/--profile " +
+			"at the right one"
+	case !info.Exists && info.Scope == auth.ConfigScopeProject:
+		check.Detail = "not present (optional): " + info.Path
+	case !info.Exists:
+		check.Status = StatusWarn
+		check.Detail = "not found: " + info.Path
+		check.Hint = "run `withings auth set-client` to create it"
+	case runtime.GOOS != osWindows && info.Mode&groupOtherPerm != 0:
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf(
+			"%s is accessible to group or others (mode %04o)",
+			info.Path,
+			info.Mode,
+		)
+		check.Hint = fmt.Sprintf("chmod 600 %s", info.Path)
+	default:
+		check.Detail = fmt.Sprintf("%s (mode %04o)", info.Path, info.Mode)
+	}
+
+	return check
+}
+
+func tokenCheck(report auth.Diagnostics, now time.Time) Check {
+	check := Check{
+		Name:   "token",
+		Status: StatusPass,
+		Detail: emptyString,
+		Hint:   emptyString,
+	}
+
+	expired := !report.ExpiresAt.IsZero() && now.After(report.ExpiresAt)
+
+	switch {
+	case report.ConfigLoadError != nil:
+		check.Status = StatusFail
+		check.Detail = report.ConfigLoadError.Error()
+		check.Hint = "fix the config file first"
+	case !report.AccessToken && !report.RefreshToken:
+		check.Status = StatusFail
+		check.Detail = "no tokens stored"
+		check.Hint = "run `withings auth login`"
+	case expired && report.RefreshToken:
+		check.Status = StatusWarn
+		check.Detail = fmt.Sprintf(
+			"access token expired at %s; it is refreshed on the next command",
+			formatTime(report.ExpiresAt),
+		)
+		check.Hint = "run `withings auth refresh` to refresh it now"
+	case expired:
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf(
+			"access token expired at %s and there is no refresh token",
+			formatTime(report.ExpiresAt),
+		)
+		check.Hint = "run `withings auth login`"
+	case !report.RefreshToken:
+		check.Status = StatusWarn
+		check.Detail = "no refresh token; the access token cannot be renewed"
+		check.Hint = "run `withings auth login` before it expires"
+	case report.ExpiresAt.IsZero():
+		check.Detail = "present (expiry unknown)"
+	default:
+		check.Detail = fmt.Sprintf(
+			"valid until %s (in %s)",
+			formatTime(report.ExpiresAt),
+			report.ExpiresAt.Sub(now).Round(time.Minute),
+		)
+	}
+
+	return check
+}
+
+func credentialsCheck(report auth.Diagnostics) Check {
+	check := Check{
+		Name:   "client credentials",
+		Status: StatusPass,
+		Detail: emptyString,
+		Hint:   emptyString,
+	}
+
+	switch {
+	case !report.ClientID:
+		check.Status = StatusFail
+		check.Detail = "no client ID"
+	case !report.ClientSecret && !report.PublicClient:
+		check.Status = StatusFail
+		check.Detail = "client ID from " + report.ClientIDSource +
+			", but no client secret"
+	case report.PublicClient:
+		check.Detail = "client ID from " + report.ClientIDSource + " (PKCE)"
+	default:
+		check.Detail = "client ID from " + report.ClientIDSource +
+			", client secret set"
+	}
+
+	if check.Status == StatusFail {
+		check.Hint = "run `withings auth set-client`, or export " +
+			"WITHINGS_CLIENT_ID and WITHINGS_CLIENT_SECRET"
+	}
+
+	return check
+}
+
+// reachCheck sends one GET to target. Any HTTP answer counts as reachable;
+// only transport errors fail.
+func reachCheck(
+	ctx context.Context,
+	appOpts app.Options,
+	timeout time.Duration,
+	name, target string,
+) Check {
+	check := Check{
+		Name:   name,
+		Status: StatusPass,
+		Detail: emptyString,
+		Hint:   emptyString,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf("invalid URL %s: %v", target, err)
+		check.Hint = "check --base-url"
+
+		return check
+	}
+
+	client := withings.NewClient(appOpts)
+	client.Retries = 0
+	// The clock check reports the skew instead of a warning.
+	client.Warn = func(string) {}
+
+	started := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf("%s unreachable: %v", target, err)
+		check.Hint = "check the network connection, proxy settings " +
+			"(HTTPS_PROXY), and --cloud"
+
+		return check
+	}
+
+	_ = resp.Body.Close()
+
+	check.Detail = fmt.Sprintf(
+		"%s reachable (HTTP %d in %s)",
+		target,
+		resp.StatusCode,
+		time.Since(started).Round(time.Millisecond),
+	)
+
+	return check
+}
+
+func clockCheck(apiCheck Check) Check {
+	check := Check{
+		Name:   "clock",
+		Status: StatusPass,
+		Detail: emptyString,
+		Hint:   emptyString,
+	}
+
+	skew, ok := withings.ClockSkew()
+
+	switch {
+	case !ok || apiCheck.Status == StatusFail:
+		check.Status = StatusWarn
+		check.Detail = "not measured (no answer from the API)"
+	case skew >= withings.ClockSkewThreshold ||
+		-skew >= withings.ClockSkewThreshold:
+		check.Status = StatusWarn
+		check.Detail = fmt.Sprintf("local clock is off by %s", absolute(skew))
+		check.Hint = "enable time synchronization (NTP); token expiry and " +
+			"time filters depend on it"
+	default:
+		check.Detail = fmt.Sprintf(
+			"within %s of the API",
+			absolute(skew),
+		)
+	}
+
+	return check
+}
+
+func absolute(value time.Duration) time.Duration {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+// callbackCheck verifies that `auth login` can bind its callback server.
+func callbackCheck(ctx context.Context, addr string) Check {
+	check := Check{
+		Name:   "callback port",
+		Status: StatusPass,
+		Detail: addr + " is free",
+		Hint:   emptyString,
+	}
+
+	var listenConfig net.ListenConfig
+
+	listener, err := listenConfig.Listen(ctx, "tcp", addr)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf("cannot listen on %s: %v", addr, err)
+		check.Hint = "stop the process using the port, or pass another " +
+			"address with --listen (to both doctor and auth login)"
+
+		return check
+	}
+
+	_ = listener.Close()
+
+	return check
+}
+
+func formatTime(value time.Time) string {
+	return value.Format(time.RFC3339)
+}
+
+func writeOutput(appOpts app.Options, checks []Check) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		return output.WriteRawJSON(appOpts, checks)
+	}
+
+	lines := make([]string, 0, len(checks)+1)
+	lines = append(lines, plainHeader)
+
+	for _, check := range checks {
+		lines = append(lines, strings.Join(
+			[]string{check.Name, check.Status, check.Detail, check.Hint},
+			"\t",
+		))
+	}
+
+	if appOpts.Plain || appOpts.NDJSON {
+		return output.WritePlain(appOpts, lines)
+	}
+
+	lines[0] = tableHeader
+
+	table, err := output.RenderTable(appOpts, plainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render doctor table: %w", err)
+	}
+
+	return output.WriteLine(appOpts, table)
+}
@@ -0,0 +1,199 @@
+//nolint:testpackage // test unexported helpers.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/auth"
+)
+
+const (
+	testConfigPath = "/home/user/.config/withings-cli/config.toml"
+	testTimeout    = time.Second
+)
+
+var errTestUnreadable = errors.New("permission denied")
+
+//nolint:gochecknoglobals // Fixed clock for expiry checks.
+var testNow = time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+// TestConfigCheck flags missing, unreadable, and too-open files.
+func TestConfigCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		info auth.ConfigFileInfo
+		want string
+	}{
+		{"private", configInfo(auth.ConfigScopeUser, true, 0o600), StatusPass},
+		{"missing", configInfo(auth.ConfigScopeUser, false, 0), StatusWarn},
+		{"optional", configInfo(auth.ConfigScopeProject, false, 0), StatusPass},
+		{"readable", configInfo(auth.ConfigScopeUser, true, 0o644), StatusFail},
+	}
+
+	unreadable := configInfo(auth.ConfigScopeUser, true, 0o600)
+	unreadable.Err = errTestUnreadable
+
+	if got := configCheck(unreadable); got.Status != StatusFail {
+		t.Fatalf("unreadable: got %+v, want status %s", got, StatusFail)
+	}
+
+	for _, test := range tests {
+		if got := configCheck(test.info); got.Status != test.want {
+			t.Fatalf("%s: got %+v, want status %s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestTokenCheck distinguishes refreshable and dead tokens.
+func TestTokenCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		access  bool
+		refresh bool
+		expires time.Time
+		want    string
+	}{
+		{"valid", true, true, testNow.Add(time.Hour), StatusPass},
+		{"unknown-expiry", true, true, time.Time{}, StatusPass},
+		{"refreshable", true, true, testNow.Add(-time.Hour), StatusWarn},
+		{"no-refresh", true, false, testNow.Add(time.Hour), StatusWarn},
+		{"dead", true, false, testNow.Add(-time.Hour), StatusFail},
+		{"missing", false, false, time.Time{}, StatusFail},
+	}
+
+	for _, test := range tests {
+		report := auth.Diagnostics{
+			AccessToken:  test.access,
+			RefreshToken: test.refresh,
+			ExpiresAt:    test.expires,
+		}
+
+		if got := tokenCheck(report, testNow); got.Status != test.want {
+			t.Fatalf("%s: got %+v, want status %s", test.name, got, test.want)
+		}
+	}
+}
+
+// TestCredentialsCheck accepts PKCE clients without a secret.
+func TestCredentialsCheck(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		report auth.Diagnostics
+		want   string
+	}{
+		{
+			"complete",
+			auth.Diagnostics{ClientID: true, ClientSecret: true},
+			StatusPass,
+		},
+		{
+			"pkce",
+			auth.Diagnostics{ClientID: true, PublicClient: true},
+			StatusPass,
+		},
+		{"no-secret", auth.Diagnostics{ClientID: true}, StatusFail},
+		{"nothing", auth.Diagnostics{}, StatusFail},
+	}
+
+	for _, test := range tests {
+		got := credentialsCheck(test.report)
+		if got.Status != test.want {
+			t.Fatalf("%s: got %+v, want status %s", test.name, got, test.want)
+		}
+
+		if got.Status == StatusFail && got.Hint == emptyString {
+			t.Fatalf("%s: failure without hint", test.name)
+		}
+	}
+}
+
+// TestReachCheck treats any HTTP answer as reachable.
+func TestReachCheck(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	got := reachCheck(
+		context.Background(),
+		app.Options{},
+		testTimeout,
+		"api host",
+		server.URL,
+	)
+	if got.Status != StatusPass {
+		t.Fatalf("got %+v, want pass", got)
+	}
+
+	server.Close()
+
+	got = reachCheck(
+		context.Background(),
+		app.Options{},
+		testTimeout,
+		"api host",
+		server.URL,
+	)
+	if got.Status != StatusFail || got.Hint == emptyString {
+		t.Fatalf("got %+v, want fail with hint", got)
+	}
+}
+
+// TestCallbackCheckPortInUse fails while another listener holds the port.
+func TestCallbackCheckPortInUse(t *testing.T) {
+	t.Parallel()
+
+	var listenConfig net.ListenConfig
+
+	listener, err := listenConfig.Listen(
+		context.Background(),
+		"tcp",
+		"127.0.0.1:0",
+	)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	addr := listener.Addr().String()
+
+	got := callbackCheck(context.Background(), addr)
+	if got.Status != StatusFail {
+		t.Fatalf("got %+v, want fail", got)
+	}
+
+	_ = listener.Close()
+
+	got = callbackCheck(context.Background(), addr)
+	if got.Status != StatusPass {
+		t.Fatalf("got %+v, want pass", got)
+	}
+}
+
+func configInfo(
+	scope string,
+	exists bool,
+	mode os.FileMode,
+) auth.ConfigFileInfo {
+	return auth.ConfigFileInfo{
+		Scope:  scope,
+		Path:   testConfigPath,
+		Exists: exists,
+		Mode:   mode,
+		Err:    nil,
+	}
+}
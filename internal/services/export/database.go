@@ -0,0 +1,345 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// The sqlite format pipes the generated script into the sqlite3 shell so
+// the CLI needs no database driver.
+const (
+	sqliteCommand = "sqlite3"
+	sqliteBail    = "-bail"
+	sqlInteger    = "INTEGER"
+	sqlReal       = "REAL"
+	sqlText       = "TEXT"
+	sqlNull       = "NULL"
+	sqlTrue       = "1"
+	sqlFalse      = "0"
+	dataPrefix    = "data."
+	fieldSplit    = ","
+)
+
+var errSQLiteMissing = errors.New(
+	"sqlite3 not found in PATH (install it or use --file-format sql)",
+)
+
+// column maps a flattened record key to a typed table column.
+type column struct {
+	Name string
+	Type string
+	Key  string
+}
+
+// table describes how one endpoint is stored in the database.
+type table struct {
+	Name     string
+	Endpoint string
+	Columns  []column
+	Indexes  [][]string
+}
+
+//nolint:gochecknoglobals // Static schema for database exports.
+var tables = []table{
+	{
+		Name:     "measures",
+		Endpoint: "measures",
+		Columns: []column{
+			{Name: "grpid", Type: sqlInteger, Key: "grpid"},
+			{Name: "date", Type: sqlInteger, Key: "date"},
+			{Name: "category", Type: sqlInteger, Key: "category"},
+			{Name: "deviceid", Type: sqlText, Key: "deviceid"},
+			{Name: "type", Type: sqlInteger, Key: "type"},
+			{Name: "type_name", Type: sqlText, Key: "type_name"},
+			{Name: "value", Type: sqlReal, Key: "value"},
+			{Name: "unit", Type: sqlText, Key: "unit"},
+		},
+		Indexes: [][]string{{"type", "date"}, {"grpid"}},
+	},
+	{
+		Name:     "activities",
+		Endpoint: "activity",
+		Columns: append([]column{
+			{Name: "date", Type: sqlText, Key: "date"},
+			{Name: "timezone", Type: sqlText, Key: "timezone"},
+			{Name: "deviceid", Type: sqlText, Key: "deviceid"},
+			{Name: "is_tracker", Type: sqlInteger, Key: "is_tracker"},
+		}, fieldColumns(ActivityFields, emptyString)...),
+		Indexes: [][]string{{"date"}},
+	},
+	{
+		Name:     "sleep_summaries",
+		Endpoint: "sleep",
+		Columns: append([]column{
+			{Name: "id", Type: sqlInteger, Key: "id"},
+			{Name: "date", Type: sqlText, Key: "date"},
+			{Name: "startdate", Type: sqlInteger, Key: "startdate"},
+			{Name: "enddate", Type: sqlInteger, Key: "enddate"},
+			{Name: "timezone", Type: sqlText, Key: "timezone"},
+			{Name: "model", Type: sqlInteger, Key: "model"},
+		}, fieldColumns(SleepFields, dataPrefix)...),
+		Indexes: [][]string{{"date"}, {"startdate"}},
+	},
+	{
+		Name:     "workouts",
+		Endpoint: "workouts",
+		Columns: append([]column{
+			{Name: "id", Type: sqlInteger, Key: "id"},
+			{Name: "category", Type: sqlInteger, Key: "category"},
+			{Name: "date", Type: sqlText, Key: "date"},
+			{Name: "startdate", Type: sqlInteger, Key: "startdate"},
+			{Name: "enddate", Type: sqlInteger, Key: "enddate"},
+			{Name: "timezone", Type: sqlText, Key: "timezone"},
+			{Name: "deviceid", Type: sqlText, Key: "deviceid"},
+		}, fieldColumns(WorkoutFields, dataPrefix)...),
+		Indexes: [][]string{{"startdate"}, {"category"}},
+	},
+	{
+		Name:     "heart_series",
+		Endpoint: "heart",
+		Columns: []column{
+			{Name: "timestamp", Type: sqlInteger, Key: "timestamp"},
+			{Name: "heart_rate", Type: sqlInteger, Key: "heart_rate"},
+			{Name: "signalid", Type: sqlInteger, Key: "ecg.signalid"},
+			{Name: "afib", Type: sqlInteger, Key: "ecg.afib"},
+			{Name: "systole", Type: sqlInteger, Key: "bloodpressure.systole"},
+			{
+				Name: "diastole",
+				Type: sqlInteger,
+				Key:  "bloodpressure.diastole",
+			},
+			{Name: "deviceid", Type: sqlText, Key: "deviceid"},
+			{Name: "model", Type: sqlInteger, Key: "model"},
+			{Name: "timezone", Type: sqlText, Key: "timezone"},
+		},
+		Indexes: [][]string{{"timestamp"}},
+	},
+}
+
+//nolint:gochecknoglobals // Data fields that hold fractional values.
+var realFields = map[string]bool{
+	"distance":         true,
+	"elevation":        true,
+	"calories":         true,
+	"totalcalories":    true,
+	"manual_distance":  true,
+	"manual_calories":  true,
+	"sleep_efficiency": true,
+	"pool_length":      true,
+}
+
+func fieldColumns(fields, prefix string) []column {
+	names := strings.Split(fields, fieldSplit)
+	columns := make([]column, defaultInt, len(names))
+
+	for _, name := range names {
+		columnType := sqlInteger
+		if realFields[name] {
+			columnType = sqlReal
+		}
+
+		columns = append(columns, column{
+			Name: name,
+			Type: columnType,
+			Key:  prefix + name,
+		})
+	}
+
+	return columns
+}
+
+func runDatabase(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+	rng exportRange,
+	result manifest,
+) error {
+	if result.Format == formatSQLite {
+		_, err := exec.LookPath(sqliteCommand)
+		if err != nil {
+			return app.NewExitError(app.ExitCodeFailure, errSQLiteMissing)
+		}
+	}
+
+	fetched, err := fetchSingleFile(
+		ctx,
+		opts,
+		appOpts,
+		accessToken,
+		rng,
+		&result,
+	)
+	if err != nil {
+		return err
+	}
+
+	fetched[measuresEndpoint] = measureRows(fetched[measuresEndpoint])
+
+	script := renderSQL(fetched)
+
+	if result.Format == formatSQLite {
+		err = loadSQLite(ctx, opts.Output, script)
+	} else {
+		err = os.WriteFile(opts.Output, script, fileMode)
+	}
+
+	if err != nil {
+		return fmt.Errorf("write %s export: %w", result.Format, err)
+	}
+
+	return writeSummary(appOpts, opts.Output, result)
+}
+
+// fetchSingleFile fetches every endpoint for a format written to the one
+// --dest file, recording each in result.
+func fetchSingleFile(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+	rng exportRange,
+	result *manifest,
+) (map[string][]map[string]any, error) {
+	results, err := fetchEndpoints(ctx, opts, appOpts, accessToken, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched := map[string][]map[string]any{}
+
+	for _, item := range results {
+		if item.Skipped {
+			result.Skipped = append(result.Skipped, item.Target.Name)
+
+			continue
+		}
+
+		fetched[item.Target.Name] = item.Records
+		result.Records += len(item.Records)
+		result.Files = append(result.Files, manifestFile{
+			Endpoint: item.Target.Name,
+			Path:     opts.Output,
+			Records:  len(item.Records),
+		})
+	}
+
+	return fetched, nil
+}
+
+// measureRows flattens the per-type split back into one list.
+func measureRows(groups []map[string]any) []map[string]any {
+	byType, types := measureRowsByType(groups)
+
+	var rows []map[string]any
+
+	for _, typeID := range types {
+		rows = append(rows, byType[typeID]...)
+	}
+
+	return rows
+}
+
+// renderSQL recreates every table inside one transaction, so re-running
+// an export replaces the previous data instead of duplicating it.
+func renderSQL(fetched map[string][]map[string]any) []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("BEGIN;\n")
+
+	for _, spec := range tables {
+		writeSchema(&buffer, spec)
+
+		for _, record := range fetched[spec.Endpoint] {
+			row := map[string]string{}
+			flatten(row, emptyString, record)
+			writeInsert(&buffer, spec, row)
+		}
+	}
+
+	buffer.WriteString("COMMIT;\n")
+
+	return buffer.Bytes()
+}
+
+func writeSchema(buffer *bytes.Buffer, spec table) {
+	definitions := make([]string, defaultInt, len(spec.Columns))
+	for _, col := range spec.Columns {
+		definitions = append(definitions, col.Name+" "+col.Type)
+	}
+
+	buffer.WriteString("DROP TABLE IF EXISTS " + spec.Name + ";\n")
+	buffer.WriteString("CREATE TABLE " + spec.Name + " (" +
+		strings.Join(definitions, ", ") + ");\n")
+
+	for _, index := range spec.Indexes {
+		buffer.WriteString("CREATE INDEX " + spec.Name + "_" +
+			strings.Join(index, "_") + " ON " + spec.Name + " (" +
+			strings.Join(index, ", ") + ");\n")
+	}
+}
+
+func writeInsert(buffer *bytes.Buffer, spec table, row map[string]string) {
+	values := make([]string, defaultInt, len(spec.Columns))
+	for _, col := range spec.Columns {
+		value, ok := row[col.Key]
+		values = append(values, sqlLiteral(col.Type, value, ok))
+	}
+
+	buffer.WriteString("INSERT INTO " + spec.Name + " VALUES (" +
+		strings.Join(values, ", ") + ");\n")
+}
+
+// sqlLiteral renders a value for its column type; values that do not fit
+// a numeric column are stored as text so nothing is silently dropped.
+func sqlLiteral(columnType, value string, ok bool) string {
+	if !ok || (value == emptyString && columnType != sqlText) {
+		return sqlNull
+	}
+
+	if columnType != sqlText {
+		switch value {
+		case "true":
+			return sqlTrue
+		case "false":
+			return sqlFalse
+		}
+
+		_, err := strconv.ParseFloat(value, floatBitSize)
+		if err == nil {
+			return value
+		}
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func loadSQLite(ctx context.Context, path string, script []byte) error {
+	var stderr bytes.Buffer
+
+	//nolint:gosec // Output path is user-controlled by design.
+	command := exec.CommandContext(ctx, sqliteCommand, sqliteBail, path)
+	command.Stdin = bytes.NewReader(script)
+	command.Stderr = &stderr
+
+	err := command.Run()
+	if err != nil {
+		return fmt.Errorf(
+			"run %s: %w: %s",
+			sqliteCommand,
+			err,
+			strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+//nolint:testpackage // test unexported helpers.
+package export
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestSQLLiteral types values and quotes text.
+func TestSQLLiteral(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		columnType string
+		value      string
+		ok         bool
+		want       string
+	}{
+		{sqlInteger, "42", true, "42"},
+		{sqlInteger, "true", true, sqlTrue},
+		{sqlReal, "", true, sqlNull},
+		{sqlReal, "1.5", true, "1.5"},
+		{sqlText, "it's", true, "'it''s'"},
+		{sqlText, "", false, sqlNull},
+		{sqlInteger, "n/a", true, "'n/a'"},
+	}
+
+	for _, test := range cases {
+		got := sqlLiteral(test.columnType, test.value, test.ok)
+		if got != test.want {
+			t.Fatalf("literal %q got %q want %q", test.value, got, test.want)
+		}
+	}
+}
+
+// TestRunWritesSQL renders typed tables and indexes for every endpoint.
+func TestRunWritesSQL(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testHandler))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "health.sql")
+
+	err := Run(
+		t.Context(),
+		testOptions(path, formatSQL),
+		testAppOpts(server.URL),
+		"token",
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	script, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read script: %v", err)
+	}
+
+	for _, want := range []string{
+		"CREATE TABLE measures (grpid INTEGER, date INTEGER,",
+		"CREATE INDEX measures_type_date ON measures (type, date);",
+		"INSERT INTO measures VALUES (7, 1764547200, 1, 'd1', 1, " +
+			"'weight', 72.345, 'kg');",
+		"CREATE TABLE heart_series (",
+		"CREATE TABLE sleep_summaries (",
+		"INSERT INTO activities VALUES ('2025-12-02', NULL, NULL, NULL, 200,",
+	} {
+		if !strings.Contains(string(script), want) {
+			t.Fatalf("script missing %q", want)
+		}
+	}
+}
+
+// TestRunWritesSQLite loads the script with the sqlite3 shell.
+func TestRunWritesSQLite(t *testing.T) {
+	t.Parallel()
+
+	_, err := exec.LookPath(sqliteCommand)
+	if err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(testHandler))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "health.db")
+
+	err = Run(
+		t.Context(),
+		testOptions(path, formatSQLite),
+		testAppOpts(server.URL),
+		"token",
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	out, err := exec.CommandContext(
+		t.Context(),
+		sqliteCommand,
+		path,
+		"SELECT count(*), sum(steps) FROM activities;",
+	).Output()
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if strings.TrimSpace(string(out)) != "2|300" {
+		t.Fatalf("query got %q want 2|300", out)
+	}
+}
+
+func testAppOpts(baseURL string) app.Options {
+	var appOpts app.Options
+
+	appOpts.Quiet = true
+	appOpts.BaseURL = baseURL
+
+	return appOpts
+}
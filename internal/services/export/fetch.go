@@ -0,0 +1,131 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+// DefaultConcurrency is how many endpoints are fetched at the same time
+// when Options.Concurrency is zero.
+const DefaultConcurrency = 4
+
+var errInvalidConcurrency = errors.New("--concurrency must be at least 1")
+
+// fetchResult is the outcome of one endpoint.
+type fetchResult struct {
+	Target  endpoint
+	Records []map[string]any
+	Skipped bool
+}
+
+// fetchEndpoints fetches every endpoint on a bounded pool of workers. The
+// client's shared rate limiter still paces the requests across workers.
+// Progress is reported as each endpoint finishes; results keep endpoint
+// order. The first error cancels the endpoints still running.
+func fetchEndpoints(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+	rng exportRange,
+) ([]fetchResult, error) {
+	caps := withings.ProbeCapabilities(ctx, appOpts)
+	results := make([]fetchResult, len(endpoints))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wait     sync.WaitGroup
+	)
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if firstErr == nil {
+			firstErr = err
+
+			cancel()
+		}
+	}
+
+	jobs := make(chan int)
+
+	for range min(concurrency(opts), len(endpoints)) {
+		wait.Go(func() {
+			for index := range jobs {
+				target := endpoints[index]
+
+				records, skipped, err := fetchEndpoint(
+					ctx,
+					appOpts,
+					caps,
+					accessToken,
+					target,
+					rng,
+				)
+				if err != nil {
+					fail(err)
+
+					continue
+				}
+
+				results[index] = fetchResult{
+					Target:  target,
+					Records: records,
+					Skipped: skipped,
+				}
+
+				if skipped {
+					continue
+				}
+
+				mu.Lock()
+				err = output.WriteProgress(appOpts, fmt.Sprintf(
+					"%s: %d records",
+					target.Name,
+					len(records),
+				))
+				mu.Unlock()
+
+				if err != nil {
+					fail(err)
+				}
+			}
+		})
+	}
+
+	for index := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
+
+		jobs <- index
+	}
+
+	close(jobs)
+	wait.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return results, nil
+}
+
+func concurrency(opts Options) int {
+	if opts.Concurrency == defaultInt {
+		return DefaultConcurrency
+	}
+
+	return opts.Concurrency
+}
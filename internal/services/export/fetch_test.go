@@ -0,0 +1,83 @@
+//nolint:testpackage // test unexported helpers.
+package export
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFetchEndpointsConcurrent overlaps requests and keeps endpoint
+// order in the results.
+func TestFetchEndpointsConcurrent(t *testing.T) {
+	t.Parallel()
+
+	var inFlight, peak atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				seen := peak.Load()
+				if current <= seen || peak.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			testHandler(writer, req)
+		},
+	))
+	defer server.Close()
+
+	opts := testOptions(t.TempDir(), formatJSON)
+	opts.Concurrency = len(endpoints)
+
+	rng, err := resolveRange(opts)
+	if err != nil {
+		t.Fatalf("resolveRange: %v", err)
+	}
+
+	results, err := fetchEndpoints(
+		t.Context(),
+		opts,
+		testAppOpts(server.URL),
+		"token",
+		rng,
+	)
+	if err != nil {
+		t.Fatalf("fetchEndpoints: %v", err)
+	}
+
+	for index, item := range results {
+		if item.Target.Name != endpoints[index].Name {
+			t.Fatalf("result %d got %s", index, item.Target.Name)
+		}
+	}
+
+	if len(results[1].Records) != 2 {
+		t.Fatalf("activity records got %d want 2", len(results[1].Records))
+	}
+
+	if peak.Load() < 2 {
+		t.Fatalf("peak concurrency got %d want at least 2", peak.Load())
+	}
+}
+
+// TestValidateConcurrency rejects negative worker counts.
+func TestValidateConcurrency(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions(t.TempDir(), formatJSON)
+	opts.Concurrency = -1
+
+	_, _, err := validate(opts)
+	if !errors.Is(err, errInvalidConcurrency) {
+		t.Fatalf("validate got %v", err)
+	}
+}
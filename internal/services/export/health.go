@@ -0,0 +1,648 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/mreimbold/withings-cli/internal/services/workouts"
+)
+
+// The apple-health-xml and gfit-csv formats transform the fetched records
+// into the data types of another ecosystem; anything without a
+// counterpart there is left out.
+const (
+	formatAppleHealth = "apple-health-xml"
+	formatGoogleFit   = "gfit-csv"
+	healthSource      = "Withings"
+	healthLocale      = "en_US"
+	appleDateLayout   = "2006-01-02 15:04:05 -0700"
+	appleSleepType    = "HKCategoryTypeIdentifierSleepAnalysis"
+	appleSleepValue   = "HKCategoryValueSleepAnalysisAsleepUnspecified"
+	appleWorkoutOther = "HKWorkoutActivityTypeOther"
+	appleDurationUnit = "min"
+	appleDistanceUnit = "km"
+	appleEnergyUnit   = "kcal"
+	gfitSleepType     = "com.google.sleep.segment"
+	gfitSleepField    = "sleep_segment_type"
+	gfitSleepValue    = "2"
+	gfitActivityType  = "com.google.activity.segment"
+	gfitActivityField = "activity"
+	gfitActivityOther = "other"
+	secondsPerMinute  = 60
+	metersPerKm       = 1000
+	percentFraction   = 0.01
+	healthPrecision   = -1
+	recordDateKey     = "date"
+	recordStartKey    = "startdate"
+	recordEndKey      = "enddate"
+	recordZoneKey     = "timezone"
+	recordDataKey     = "data"
+)
+
+// healthType is one quantity in HealthKit and Google Fit. Scale converts
+// the Withings value to the HealthKit unit (e.g. percent to a fraction);
+// an empty identifier means there is no counterpart.
+type healthType struct {
+	HealthKit string
+	HKUnit    string
+	HKScale   float64
+	GoogleFit string
+	GFitField string
+	GFitUnit  string
+}
+
+// activityField maps a getactivity field to its health type.
+type activityField struct {
+	Field string
+	Type  healthType
+}
+
+//nolint:gochecknoglobals // Static mapping of Withings measure types.
+var measureHealthTypes = map[int]healthType{
+	1: {
+		HealthKit: "HKQuantityTypeIdentifierBodyMass", HKUnit: "kg",
+		HKScale: 1, GoogleFit: "com.google.weight", GFitField: "weight",
+		GFitUnit: "kg",
+	},
+	measures.TypeHeight: {
+		HealthKit: "HKQuantityTypeIdentifierHeight", HKUnit: "m",
+		HKScale: 1, GoogleFit: "com.google.height", GFitField: "height",
+		GFitUnit: "m",
+	},
+	5: {
+		HealthKit: "HKQuantityTypeIdentifierLeanBodyMass", HKUnit: "kg",
+		HKScale: 1, GoogleFit: emptyString, GFitField: emptyString,
+		GFitUnit: emptyString,
+	},
+	6: {
+		HealthKit: "HKQuantityTypeIdentifierBodyFatPercentage", HKUnit: "%",
+		HKScale: percentFraction, GoogleFit: "com.google.body.fat.percentage",
+		GFitField: "percentage", GFitUnit: "%",
+	},
+	9: {
+		HealthKit: "HKQuantityTypeIdentifierBloodPressureDiastolic",
+		HKUnit:    "mmHg", HKScale: 1, GoogleFit: "com.google.blood_pressure",
+		GFitField: "blood_pressure_diastolic", GFitUnit: "mmHg",
+	},
+	10: {
+		HealthKit: "HKQuantityTypeIdentifierBloodPressureSystolic",
+		HKUnit:    "mmHg", HKScale: 1, GoogleFit: "com.google.blood_pressure",
+		GFitField: "blood_pressure_systolic", GFitUnit: "mmHg",
+	},
+	11: heartRateType,
+	12: bodyTemperatureType,
+	54: {
+		HealthKit: "HKQuantityTypeIdentifierOxygenSaturation", HKUnit: "%",
+		HKScale: percentFraction, GoogleFit: "com.google.oxygen_saturation",
+		GFitField: "oxygen_saturation", GFitUnit: "%",
+	},
+	71: bodyTemperatureType,
+	123: {
+		HealthKit: "HKQuantityTypeIdentifierVO2Max", HKUnit: "mL/min·kg",
+		HKScale: 1, GoogleFit: emptyString, GFitField: emptyString,
+		GFitUnit: emptyString,
+	},
+}
+
+//nolint:gochecknoglobals // Shared entries of the mapping tables.
+var (
+	heartRateType = healthType{
+		HealthKit: "HKQuantityTypeIdentifierHeartRate", HKUnit: "count/min",
+		HKScale: 1, GoogleFit: "com.google.heart_rate.bpm", GFitField: "bpm",
+		GFitUnit: "bpm",
+	}
+	bodyTemperatureType = healthType{
+		HealthKit: "HKQuantityTypeIdentifierBodyTemperature", HKUnit: "degC",
+		HKScale: 1, GoogleFit: "com.google.body.temperature",
+		GFitField: "body_temperature", GFitUnit: "C",
+	}
+)
+
+// Withings calories are active energy, totalcalories include the basal
+// rate, which is what Google Fit's calories.expended counts.
+//
+//nolint:gochecknoglobals // Static mapping of daily activity fields.
+var activityHealthTypes = []activityField{
+	{Field: "steps", Type: healthType{
+		HealthKit: "HKQuantityTypeIdentifierStepCount", HKUnit: "count",
+		HKScale: 1, GoogleFit: "com.google.step_count.delta",
+		GFitField: "steps", GFitUnit: "count",
+	}},
+	{Field: "distance", Type: healthType{
+		HealthKit: "HKQuantityTypeIdentifierDistanceWalkingRunning",
+		HKUnit:    "m", HKScale: 1, GoogleFit: "com.google.distance.delta",
+		GFitField: "distance", GFitUnit: "m",
+	}},
+	{Field: "calories", Type: healthType{
+		HealthKit: "HKQuantityTypeIdentifierActiveEnergyBurned",
+		HKUnit:    "kcal", HKScale: 1, GoogleFit: emptyString,
+		GFitField: emptyString, GFitUnit: emptyString,
+	}},
+	{Field: "totalcalories", Type: healthType{
+		HealthKit: emptyString, HKUnit: emptyString, HKScale: 1,
+		GoogleFit: "com.google.calories.expended", GFitField: "calories",
+		GFitUnit: "kcal",
+	}},
+}
+
+// workoutActivities maps workout category names to a HealthKit workout
+// activity type suffix and a Google Fit activity name.
+//
+//nolint:gochecknoglobals // Static mapping of workout categories.
+var workoutActivities = map[string][2]string{
+	"walk":           {"Walking", "walking"},
+	"indoor_walk":    {"Walking", "walking.treadmill"},
+	"run":            {"Running", "running"},
+	"indoor_running": {"Running", "running.treadmill"},
+	"hiking":         {"Hiking", "hiking"},
+	"bicycling":      {"Cycling", "biking"},
+	"indoor_cycling": {"Cycling", "biking.stationary"},
+	"swimming":       {"Swimming", "swimming"},
+	"tennis":         {"Tennis", "tennis"},
+	"lift_weights":   {"TraditionalStrengthTraining", "strength_training"},
+	"elliptical":     {"Elliptical", "elliptical"},
+	"yoga":           {"Yoga", "yoga"},
+	"pilates":        {"Pilates", "pilates"},
+	"rowing":         {"Rowing", "rowing"},
+	"soccer":         {"Soccer", "football.soccer"},
+	"basketball":     {"Basketball", "basketball"},
+	"golf":           {"Golf", "golf"},
+	"skiing":         {"DownhillSkiing", "skiing.downhill"},
+	"snowboarding":   {"Snowboarding", "snowboarding"},
+	"boxing":         {"Boxing", "boxing"},
+	"climbing":       {"Climbing", "rock_climbing"},
+	"dancing":        {"SocialDance", "dancing"},
+	"zumba":          {"Dance", "zumba"},
+}
+
+// healthSample is one transformed quantity or sleep interval.
+type healthSample struct {
+	Type     healthType
+	Start    int64
+	End      int64
+	Location *time.Location
+	Value    float64
+	Sleep    bool
+}
+
+// healthWorkout is one transformed workout.
+type healthWorkout struct {
+	Category string
+	Start    int64
+	End      int64
+	Location *time.Location
+	Distance float64
+	Calories float64
+}
+
+// healthData is everything a health format writes, oldest first.
+type healthData struct {
+	Samples  []healthSample
+	Workouts []healthWorkout
+	// Unmapped counts measures whose type has no counterpart.
+	Unmapped int
+}
+
+func runHealth(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+	rng exportRange,
+	result manifest,
+) error {
+	fetched, err := fetchSingleFile(
+		ctx,
+		opts,
+		appOpts,
+		accessToken,
+		rng,
+		&result,
+	)
+	if err != nil {
+		return err
+	}
+
+	data := transformHealth(fetched)
+
+	var encoded []byte
+
+	if result.Format == formatAppleHealth {
+		encoded, err = encodeAppleHealth(data, now(opts))
+	} else {
+		encoded, err = encodeGoogleFit(data)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(opts.Output, encoded, fileMode)
+	if err != nil {
+		return fmt.Errorf("write %s export: %w", result.Format, err)
+	}
+
+	if data.Unmapped > defaultInt {
+		err = output.WriteProgress(appOpts, fmt.Sprintf(
+			"%s: skipped %d measures without a matching type",
+			result.Format,
+			data.Unmapped,
+		))
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeSummary(appOpts, opts.Output, result)
+}
+
+// transformHealth maps fetched records to health samples and workouts.
+func transformHealth(fetched map[string][]map[string]any) healthData {
+	var data healthData
+
+	for _, group := range fetched[measuresEndpoint] {
+		date := jsonInt(group[recordDateKey])
+		entries, _ := group[measuresKey].([]any)
+
+		for _, entry := range entries {
+			measure, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			kind, ok := measureHealthTypes[int(jsonInt(measure["type"]))]
+			if !ok {
+				data.Unmapped++
+
+				continue
+			}
+
+			value, err := strconv.ParseFloat(measures.ScaledValue(
+				jsonInt(measure["value"]),
+				int(jsonInt(measure["unit"])),
+			), floatBitSize)
+			if err != nil {
+				continue
+			}
+
+			data.Samples = append(data.Samples, healthSample{
+				Type:     kind,
+				Start:    date,
+				End:      date,
+				Location: time.UTC,
+				Value:    value,
+				Sleep:    false,
+			})
+		}
+	}
+
+	data.Samples = append(data.Samples, activitySamples(fetched)...)
+	data.Samples = append(data.Samples, sleepSamples(fetched)...)
+	data.Samples = append(data.Samples, heartSamples(fetched)...)
+	data.Workouts = healthWorkouts(fetched)
+
+	sort.SliceStable(data.Samples, func(left, right int) bool {
+		return data.Samples[left].Start < data.Samples[right].Start
+	})
+	sort.SliceStable(data.Workouts, func(left, right int) bool {
+		return data.Workouts[left].Start < data.Workouts[right].Start
+	})
+
+	return data
+}
+
+// activitySamples turns each day into samples spanning local midnight to
+// midnight.
+func activitySamples(fetched map[string][]map[string]any) []healthSample {
+	var samples []healthSample
+
+	for _, day := range fetched["activity"] {
+		location := recordLocation(day)
+
+		text, _ := day[recordDateKey].(string)
+
+		start, err := time.ParseInLocation(dateLayout, text, location)
+		if err != nil {
+			continue
+		}
+
+		for _, field := range activityHealthTypes {
+			value, ok := jsonFloat(day[field.Field])
+			if !ok {
+				continue
+			}
+
+			samples = append(samples, healthSample{
+				Type:     field.Type,
+				Start:    start.Unix(),
+				End:      start.AddDate(0, 0, 1).Unix(),
+				Location: location,
+				Value:    value,
+				Sleep:    false,
+			})
+		}
+	}
+
+	return samples
+}
+
+func sleepSamples(fetched map[string][]map[string]any) []healthSample {
+	var samples []healthSample
+
+	for _, night := range fetched["sleep"] {
+		start := jsonInt(night[recordStartKey])
+		end := jsonInt(night[recordEndKey])
+
+		if start == defaultInt64 || end <= start {
+			continue
+		}
+
+		samples = append(samples, healthSample{
+			Type: healthType{
+				HealthKit: appleSleepType,
+				HKUnit:    emptyString,
+				HKScale:   1,
+				GoogleFit: gfitSleepType,
+				GFitField: gfitSleepField,
+				GFitUnit:  emptyString,
+			},
+			Start:    start,
+			End:      end,
+			Location: recordLocation(night),
+			Value:    defaultInt,
+			Sleep:    true,
+		})
+	}
+
+	return samples
+}
+
+func heartSamples(fetched map[string][]map[string]any) []healthSample {
+	var samples []healthSample
+
+	for _, recording := range fetched["heart"] {
+		value, ok := jsonFloat(recording["heart_rate"])
+		if !ok || value <= defaultInt {
+			continue
+		}
+
+		timestamp := jsonInt(recording["timestamp"])
+
+		samples = append(samples, healthSample{
+			Type:     heartRateType,
+			Start:    timestamp,
+			End:      timestamp,
+			Location: recordLocation(recording),
+			Value:    value,
+			Sleep:    false,
+		})
+	}
+
+	return samples
+}
+
+func healthWorkouts(fetched map[string][]map[string]any) []healthWorkout {
+	var result []healthWorkout
+
+	for _, workout := range fetched["workouts"] {
+		start := jsonInt(workout[recordStartKey])
+		end := jsonInt(workout[recordEndKey])
+
+		if start == defaultInt64 || end <= start {
+			continue
+		}
+
+		data, _ := workout[recordDataKey].(map[string]any)
+		distance, _ := jsonFloat(data["distance"])
+		calories, _ := jsonFloat(data["calories"])
+
+		result = append(result, healthWorkout{
+			Category: workouts.CategoryName(int(jsonInt(workout["category"]))),
+			Start:    start,
+			End:      end,
+			Location: recordLocation(workout),
+			Distance: distance,
+			Calories: calories,
+		})
+	}
+
+	return result
+}
+
+func recordLocation(record map[string]any) *time.Location {
+	name, _ := record[recordZoneKey].(string)
+	if name == emptyString {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func jsonFloat(value any) (float64, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return defaultInt, false
+	}
+
+	parsed, err := number.Float64()
+	if err != nil {
+		return defaultInt, false
+	}
+
+	return parsed, true
+}
+
+type appleHealthData struct {
+	XMLName    xml.Name       `xml:"HealthData"`
+	Locale     string         `xml:"locale,attr"`
+	ExportDate appleValue     `xml:"ExportDate"`
+	Records    []appleRecord  `xml:"Record"`
+	Workouts   []appleWorkout `xml:"Workout"`
+}
+
+type appleValue struct {
+	Value string `xml:"value,attr"`
+}
+
+type appleRecord struct {
+	Type       string `xml:"type,attr"`
+	SourceName string `xml:"sourceName,attr"`
+	Unit       string `xml:"unit,attr,omitempty"`
+	StartDate  string `xml:"startDate,attr"`
+	EndDate    string `xml:"endDate,attr"`
+	Value      string `xml:"value,attr"`
+}
+
+type appleWorkout struct {
+	ActivityType    string `xml:"workoutActivityType,attr"`
+	Duration        string `xml:"duration,attr"`
+	DurationUnit    string `xml:"durationUnit,attr"`
+	TotalDistance   string `xml:"totalDistance,attr"`
+	DistanceUnit    string `xml:"totalDistanceUnit,attr"`
+	TotalEnergy     string `xml:"totalEnergyBurned,attr"`
+	TotalEnergyUnit string `xml:"totalEnergyBurnedUnit,attr"`
+	SourceName      string `xml:"sourceName,attr"`
+	StartDate       string `xml:"startDate,attr"`
+	EndDate         string `xml:"endDate,attr"`
+}
+
+// encodeAppleHealth writes data in the layout of the Health app's
+// export.xml: one Record per sample and one Workout per workout.
+func encodeAppleHealth(data healthData, exported time.Time) ([]byte, error) {
+	document := appleHealthData{
+		XMLName:    xml.Name{Space: emptyString, Local: emptyString},
+		Locale:     healthLocale,
+		ExportDate: appleValue{Value: exported.Format(appleDateLayout)},
+		Records:    make([]appleRecord, defaultInt, len(data.Samples)),
+		Workouts:   make([]appleWorkout, defaultInt, len(data.Workouts)),
+	}
+
+	for _, sample := range data.Samples {
+		if sample.Type.HealthKit == emptyString {
+			continue
+		}
+
+		value := formatHealthValue(sample.Value * sample.Type.HKScale)
+		if sample.Sleep {
+			value = appleSleepValue
+		}
+
+		document.Records = append(document.Records, appleRecord{
+			Type:       sample.Type.HealthKit,
+			SourceName: healthSource,
+			Unit:       sample.Type.HKUnit,
+			StartDate:  appleDate(sample.Start, sample.Location),
+			EndDate:    appleDate(sample.End, sample.Location),
+			Value:      value,
+		})
+	}
+
+	for _, workout := range data.Workouts {
+		activity := appleWorkoutOther
+		if names, ok := workoutActivities[workout.Category]; ok {
+			activity = "HKWorkoutActivityType" + names[0]
+		}
+
+		document.Workouts = append(document.Workouts, appleWorkout{
+			ActivityType: activity,
+			Duration: formatHealthValue(
+				float64(workout.End-workout.Start) / secondsPerMinute,
+			),
+			DurationUnit:    appleDurationUnit,
+			TotalDistance:   formatHealthValue(workout.Distance / metersPerKm),
+			DistanceUnit:    appleDistanceUnit,
+			TotalEnergy:     formatHealthValue(workout.Calories),
+			TotalEnergyUnit: appleEnergyUnit,
+			SourceName:      healthSource,
+			StartDate:       appleDate(workout.Start, workout.Location),
+			EndDate:         appleDate(workout.End, workout.Location),
+		})
+	}
+
+	encoded, err := xml.MarshalIndent(document, emptyString, " ")
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", formatAppleHealth, err)
+	}
+
+	return append(append([]byte(xml.Header), encoded...), '\n'), nil
+}
+
+//nolint:gochecknoglobals // Static Google Fit CSV header.
+var googleFitColumns = []string{
+	"data_type",
+	"field",
+	"start_time",
+	"end_time",
+	"value",
+	"unit",
+}
+
+// encodeGoogleFit writes one CSV row per data point, named by Google Fit
+// data type and field; workouts become activity segments.
+func encodeGoogleFit(data healthData) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write(googleFitColumns)
+
+	for _, sample := range data.Samples {
+		if sample.Type.GoogleFit == emptyString {
+			continue
+		}
+
+		value := formatHealthValue(sample.Value)
+		if sample.Sleep {
+			value = gfitSleepValue
+		}
+
+		_ = writer.Write([]string{
+			sample.Type.GoogleFit,
+			sample.Type.GFitField,
+			gfitTime(sample.Start, sample.Location),
+			gfitTime(sample.End, sample.Location),
+			value,
+			sample.Type.GFitUnit,
+		})
+	}
+
+	for _, workout := range data.Workouts {
+		activity := gfitActivityOther
+		if names, ok := workoutActivities[workout.Category]; ok {
+			activity = names[1]
+		}
+
+		_ = writer.Write([]string{
+			gfitActivityType,
+			gfitActivityField,
+			gfitTime(workout.Start, workout.Location),
+			gfitTime(workout.End, workout.Location),
+			activity,
+			emptyString,
+		})
+	}
+
+	writer.Flush()
+
+	err := writer.Error()
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", formatGoogleFit, err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func appleDate(epoch int64, location *time.Location) string {
+	return time.Unix(epoch, defaultInt64).In(location).Format(appleDateLayout)
+}
+
+func gfitTime(epoch int64, location *time.Location) string {
+	return time.Unix(epoch, defaultInt64).In(location).Format(time.RFC3339)
+}
+
+func formatHealthValue(value float64) string {
+	const roundTo = 1e6
+
+	return strconv.FormatFloat(
+		math.Round(value*roundTo)/roundTo,
+		'f',
+		healthPrecision,
+		floatBitSize,
+	)
+}
@@ -0,0 +1,147 @@
+//nolint:testpackage // test unexported helpers.
+package export
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTransformHealth maps measures, sleep, and workouts and counts
+// unmapped measure types.
+func TestTransformHealth(t *testing.T) {
+	t.Parallel()
+
+	fetched := map[string][]map[string]any{
+		measuresEndpoint: {{
+			"date": json.Number("100"),
+			"measures": []any{
+				map[string]any{
+					"value": json.Number("215"),
+					"type":  json.Number("6"),
+					"unit":  json.Number("-1"),
+				},
+				map[string]any{
+					"value": json.Number("5"),
+					"type":  json.Number("88"),
+					"unit":  json.Number("0"),
+				},
+			},
+		}},
+		"sleep": {{
+			"startdate": json.Number("50"),
+			"enddate":   json.Number("90"),
+		}},
+		"workouts": {{
+			"category":  json.Number("1"),
+			"startdate": json.Number("200"),
+			"enddate":   json.Number("800"),
+			"data":      map[string]any{"distance": json.Number("1500")},
+		}},
+	}
+
+	data := transformHealth(fetched)
+
+	if data.Unmapped != 1 {
+		t.Fatalf("unmapped got %d want 1", data.Unmapped)
+	}
+
+	if len(data.Samples) != 2 || !data.Samples[0].Sleep {
+		t.Fatalf("samples got %+v want sleep then fat ratio", data.Samples)
+	}
+
+	if data.Samples[1].Value != 21.5 {
+		t.Fatalf("fat ratio got %v want 21.5", data.Samples[1].Value)
+	}
+
+	if len(data.Workouts) != 1 || data.Workouts[0].Category != "walk" {
+		t.Fatalf("workouts got %+v want one walk", data.Workouts)
+	}
+}
+
+// TestEncodeAppleHealth scales percentages and names workout types.
+func TestEncodeAppleHealth(t *testing.T) {
+	t.Parallel()
+
+	data := healthData{
+		Samples: []healthSample{{
+			Type:     measureHealthTypes[6],
+			Start:    100,
+			End:      100,
+			Location: time.UTC,
+			Value:    21.5,
+			Sleep:    false,
+		}},
+		Workouts: []healthWorkout{{
+			Category: "run",
+			Start:    0,
+			End:      1800,
+			Location: time.UTC,
+			Distance: 5000,
+			Calories: 300,
+		}},
+		Unmapped: 0,
+	}
+
+	encoded, err := encodeAppleHealth(data, time.Unix(0, 0).UTC())
+	if err != nil {
+		t.Fatalf("encodeAppleHealth: %v", err)
+	}
+
+	for _, want := range []string{
+		`<HealthData locale="en_US">`,
+		`<ExportDate value="1970-01-01 00:00:00 +0000">`,
+		`type="HKQuantityTypeIdentifierBodyFatPercentage"`,
+		`unit="%" startDate="1970-01-01 00:01:40 +0000"`,
+		`value="0.215"`,
+		`workoutActivityType="HKWorkoutActivityTypeRunning" ` +
+			`duration="30" durationUnit="min" totalDistance="5"`,
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Fatalf("xml missing %q:\n%s", want, encoded)
+		}
+	}
+}
+
+// TestRunWritesGoogleFit writes data points the API returned.
+func TestRunWritesGoogleFit(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testHandler))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "health.csv")
+
+	err := Run(
+		t.Context(),
+		testOptions(path, formatGoogleFit),
+		testAppOpts(server.URL),
+		"token",
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	encoded, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+
+	want := "data_type,field,start_time,end_time,value,unit\n" +
+		"com.google.weight,weight,2025-12-01T00:00:00Z," +
+		"2025-12-01T00:00:00Z,72.345,kg\n" +
+		"com.google.blood_pressure,blood_pressure_systolic," +
+		"2025-12-01T00:00:00Z,2025-12-01T00:00:00Z,120,mmHg\n" +
+		"com.google.step_count.delta,steps,2025-12-01T00:00:00Z," +
+		"2025-12-02T00:00:00Z,100,count\n" +
+		"com.google.step_count.delta,steps,2025-12-02T00:00:00Z," +
+		"2025-12-03T00:00:00Z,200,count\n"
+	if string(encoded) != want {
+		t.Fatalf("csv got:\n%s\nwant:\n%s", encoded, want)
+	}
+}
@@ -0,0 +1,268 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+)
+
+const (
+	measuresEndpoint  = "measures"
+	measuresKey       = "measures"
+	fileNameSeparator = "_"
+	keySeparator      = "."
+	floatBitSize      = 64
+)
+
+//nolint:gochecknoglobals // Static column order for measure rows.
+var measureColumns = []string{
+	"grpid",
+	"date",
+	"category",
+	"deviceid",
+	"type",
+	"type_name",
+	"value",
+	"unit",
+}
+
+// writeEndpoint writes one endpoint; measures are split into one file per
+// measure type, every other endpoint into a single file.
+func writeEndpoint(
+	dir string,
+	format string,
+	target endpoint,
+	records []map[string]any,
+) ([]manifestFile, error) {
+	if target.Name != measuresEndpoint {
+		file, err := writeRecords(
+			dir,
+			format,
+			target.Name,
+			target.Name,
+			records,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return []manifestFile{file}, nil
+	}
+
+	byType, types := measureRowsByType(records)
+	files := make([]manifestFile, defaultInt, len(types))
+
+	for _, typeID := range types {
+		file, err := writeRecords(
+			dir,
+			format,
+			target.Name,
+			measuresEndpoint+fileNameSeparator+measures.TypeName(typeID),
+			byType[typeID],
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// measureRowsByType expands measure groups into one row per measure with
+// the value already scaled by its unit exponent.
+func measureRowsByType(
+	groups []map[string]any,
+) (map[int][]map[string]any, []int) {
+	byType := map[int][]map[string]any{}
+
+	var types []int
+
+	for _, group := range groups {
+		entries, _ := group[measuresKey].([]any)
+
+		for _, entry := range entries {
+			measure, ok := entry.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			typeID := int(jsonInt(measure["type"]))
+			if _, seen := byType[typeID]; !seen {
+				types = append(types, typeID)
+			}
+
+			byType[typeID] = append(byType[typeID], map[string]any{
+				"grpid":     group["grpid"],
+				"date":      group["date"],
+				"category":  group["category"],
+				"deviceid":  group["deviceid"],
+				"type":      measure["type"],
+				"type_name": measures.TypeName(typeID),
+				"value": json.Number(measures.ScaledValue(
+					jsonInt(measure["value"]),
+					int(jsonInt(measure["unit"])),
+				)),
+				"unit": measures.TypeUnit(typeID),
+			})
+		}
+	}
+
+	sort.Ints(types)
+
+	return byType, types
+}
+
+func jsonInt(value any) int64 {
+	number, ok := value.(json.Number)
+	if !ok {
+		return defaultInt64
+	}
+
+	parsed, err := number.Int64()
+	if err != nil {
+		return defaultInt64
+	}
+
+	return parsed
+}
+
+func writeRecords(
+	dir string,
+	format string,
+	name string,
+	stem string,
+	records []map[string]any,
+) (manifestFile, error) {
+	if records == nil {
+		records = []map[string]any{}
+	}
+
+	path := stem + "." + format
+
+	var err error
+
+	if format == formatCSV {
+		err = writeCSVFile(filepath.Join(dir, path), name, records)
+	} else {
+		err = writeJSONFile(filepath.Join(dir, path), records)
+	}
+
+	if err != nil {
+		return manifestFile{}, err
+	}
+
+	return manifestFile{Endpoint: name, Path: path, Records: len(records)}, nil
+}
+
+func writeJSONFile(path string, data any) error {
+	encoded, err := json.MarshalIndent(data, emptyString, "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+
+	err = os.WriteFile(path, append(encoded, '\n'), fileMode)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+func writeCSVFile(path, name string, records []map[string]any) error {
+	rows := make([]map[string]string, defaultInt, len(records))
+	for _, record := range records {
+		row := map[string]string{}
+		flatten(row, emptyString, record)
+		rows = append(rows, row)
+	}
+
+	columns := measureColumns
+	if name != measuresEndpoint {
+		columns = unionColumns(rows)
+	}
+
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write(columns)
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for index, column := range columns {
+			cells[index] = row[column]
+		}
+
+		_ = writer.Write(cells)
+	}
+
+	writer.Flush()
+
+	err := writer.Error()
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+
+	err = os.WriteFile(path, buffer.Bytes(), fileMode)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
+
+// flatten turns nested objects into dotted column names; arrays are kept
+// as JSON text in a single cell.
+func flatten(row map[string]string, prefix string, value any) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, nested := range typed {
+			name := key
+			if prefix != emptyString {
+				name = prefix + keySeparator + key
+			}
+
+			flatten(row, name, nested)
+		}
+	case nil:
+		row[prefix] = emptyString
+	case string:
+		row[prefix] = typed
+	case json.Number:
+		row[prefix] = typed.String()
+	case bool:
+		row[prefix] = strconv.FormatBool(typed)
+	case float64:
+		row[prefix] = strconv.FormatFloat(typed, 'f', -1, floatBitSize)
+	default:
+		encoded, _ := json.Marshal(typed)
+		row[prefix] = string(encoded)
+	}
+}
+
+func unionColumns(rows []map[string]string) []string {
+	seen := map[string]bool{}
+
+	var columns []string
+
+	for _, row := range rows {
+		for column := range row {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	sort.Strings(columns)
+
+	return columns
+}
@@ -0,0 +1,550 @@
+// Package export dumps every supported endpoint for a range to a directory.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	formatJSON      = "json"
+	formatCSV       = "csv"
+	formatSQL       = "sql"
+	formatSQLite    = "sqlite"
+	manifestName    = "manifest.json"
+	dirMode         = 0o700
+	fileMode        = 0o600
+	dateLayout      = "2006-01-02"
+	startDateParam  = "startdate"
+	endDateParam    = "enddate"
+	startYMDParam   = "startdateymd"
+	endYMDParam     = "enddateymd"
+	offsetParam     = "offset"
+	categoryParam   = "category"
+	categoryReal    = "1"
+	dataFieldsParam = "data_fields"
+	bodyMoreKey     = "more"
+	bodyOffsetKey   = "offset"
+	numberBase10    = 10
+	defaultInt      = 0
+	defaultInt64    = 0
+	emptyString     = ""
+)
+
+// The data fields requested for a complete export of each listing; serve
+// requests the same ones.
+const (
+	ActivityFields = "steps,distance,elevation,soft,moderate,intense," +
+		"active,calories,totalcalories,hr_average,hr_min,hr_max," +
+		"hr_zone_0,hr_zone_1,hr_zone_2,hr_zone_3"
+	SleepFields = "breathing_disturbances_intensity,deepsleepduration," +
+		"durationtosleep,durationtowakeup,hr_average,hr_max,hr_min," +
+		"lightsleepduration,remsleepduration,rr_average,rr_max,rr_min," +
+		"sleep_score,snoring,snoringepisodecount,wakeupcount," +
+		"wakeupduration,total_sleep_time,total_timeinbed," +
+		"sleep_efficiency,sleep_latency,wakeup_latency,waso," +
+		"apnea_hypopnea_index"
+	WorkoutFields = "calories,intensity,manual_distance,manual_calories," +
+		"hr_average,hr_min,hr_max,hr_zone_0,hr_zone_1,hr_zone_2," +
+		"hr_zone_3,pause_duration,spo2_average,steps,distance," +
+		"elevation,pool_laps,strokes,pool_length"
+)
+
+var (
+	errOutputRequired = errors.New("--dest is required")
+	errStartRequired  = errors.New("--start is required")
+	errInvalidFormat  = errors.New(
+		"invalid --file-format (expected json, csv, sql, sqlite, " +
+			"apple-health-xml, or gfit-csv)",
+	)
+	errRangeOrder = errors.New("--start must be before --end")
+)
+
+// Options captures export parameters.
+type Options struct {
+	TimeRange params.TimeRange
+	Output    string
+	Format    string
+	// Concurrency caps how many endpoints are fetched at once; zero
+	// means DefaultConcurrency.
+	Concurrency int
+	Now         func() time.Time
+}
+
+// endpoint describes one API listing that is exported in full.
+type endpoint struct {
+	Name    string
+	Service string
+	Action  string
+	ListKey string
+	YMD     bool
+	Params  map[string]string
+}
+
+//nolint:gochecknoglobals // Static list of exported endpoints.
+var endpoints = []endpoint{
+	{
+		Name:    "measures",
+		Service: "measure",
+		Action:  "getmeas",
+		ListKey: "measuregrps",
+		YMD:     false,
+		Params:  map[string]string{categoryParam: categoryReal},
+	},
+	{
+		Name:    "activity",
+		Service: "v2/measure",
+		Action:  "getactivity",
+		ListKey: "activities",
+		YMD:     true,
+		Params:  map[string]string{dataFieldsParam: ActivityFields},
+	},
+	{
+		Name:    "sleep",
+		Service: "v2/sleep",
+		Action:  "getsummary",
+		ListKey: "series",
+		YMD:     true,
+		Params:  map[string]string{dataFieldsParam: SleepFields},
+	},
+	{
+		Name:    "workouts",
+		Service: "v2/measure",
+		Action:  "getworkouts",
+		ListKey: "series",
+		YMD:     true,
+		Params:  map[string]string{dataFieldsParam: WorkoutFields},
+	},
+	{
+		Name:    "heart",
+		Service: "v2/heart",
+		Action:  "list",
+		ListKey: "series",
+		YMD:     false,
+		Params:  map[string]string{},
+	},
+}
+
+type exportRange struct {
+	Start int64
+	End   int64
+}
+
+//nolint:tagliatelle // Keep snake_case like the Withings API fields.
+type manifest struct {
+	Format    string         `json:"format"`
+	Start     string         `json:"start"`
+	End       string         `json:"end"`
+	CreatedAt string         `json:"created_at"`
+	Records   int            `json:"records"`
+	Files     []manifestFile `json:"files"`
+	// Skipped lists endpoints a --base-url server does not implement.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type manifestFile struct {
+	Endpoint string `json:"endpoint"`
+	Path     string `json:"path"`
+	Records  int    `json:"records"`
+}
+
+type pageResponse struct {
+	Status int                        `json:"status"`
+	Body   map[string]json.RawMessage `json:"body"`
+	Error  string                     `json:"error"`
+	Detail string                     `json:"detail"`
+}
+
+// Run exports measures, activity, sleep summaries, workouts, and heart
+// recordings into opts.Output and writes a manifest next to them. The sql
+// and sqlite formats write a single script or database file instead.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	format, rng, err := validate(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	result := manifest{
+		Format:    format,
+		Start:     formatEpoch(rng.Start),
+		End:       formatEpoch(rng.End),
+		CreatedAt: now(opts).UTC().Format(time.RFC3339),
+		Records:   defaultInt,
+		Files:     []manifestFile{},
+		Skipped:   nil,
+	}
+
+	if format == formatSQL || format == formatSQLite {
+		return runDatabase(ctx, opts, appOpts, accessToken, rng, result)
+	}
+
+	if format == formatAppleHealth || format == formatGoogleFit {
+		return runHealth(ctx, opts, appOpts, accessToken, rng, result)
+	}
+
+	err = os.MkdirAll(opts.Output, dirMode)
+	if err != nil {
+		return fmt.Errorf("create export directory: %w", err)
+	}
+
+	fetched, err := fetchEndpoints(ctx, opts, appOpts, accessToken, rng)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range fetched {
+		if item.Skipped {
+			result.Skipped = append(result.Skipped, item.Target.Name)
+
+			continue
+		}
+
+		files, err := writeEndpoint(
+			opts.Output,
+			format,
+			item.Target,
+			item.Records,
+		)
+		if err != nil {
+			return fmt.Errorf("export %s: %w", item.Target.Name, err)
+		}
+
+		result.Files = append(result.Files, files...)
+		result.Records += len(item.Records)
+	}
+
+	err = writeJSONFile(filepath.Join(opts.Output, manifestName), result)
+	if err != nil {
+		return err
+	}
+
+	return writeSummary(appOpts, opts.Output, result)
+}
+
+func validate(opts Options) (string, exportRange, error) {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == emptyString {
+		format = formatJSON
+	}
+
+	formats := []string{
+		formatJSON,
+		formatCSV,
+		formatSQL,
+		formatSQLite,
+		formatAppleHealth,
+		formatGoogleFit,
+	}
+	if !slices.Contains(formats, format) {
+		return emptyString, exportRange{}, fmt.Errorf(
+			"%w: %q",
+			errInvalidFormat,
+			opts.Format,
+		)
+	}
+
+	if opts.Output == emptyString {
+		return emptyString, exportRange{}, errOutputRequired
+	}
+
+	if opts.Concurrency < defaultInt {
+		return emptyString, exportRange{}, errInvalidConcurrency
+	}
+
+	rng, err := resolveRange(opts)
+	if err != nil {
+		return emptyString, exportRange{}, err
+	}
+
+	return format, rng, nil
+}
+
+func resolveRange(opts Options) (exportRange, error) {
+	if opts.TimeRange.Start == emptyString {
+		return exportRange{}, errStartRequired
+	}
+
+	start, err := filters.ParseEpoch(opts.TimeRange.Start)
+	if err != nil {
+		return exportRange{}, fmt.Errorf(
+			"%w: %w",
+			errs.ErrInvalidStartTime,
+			err,
+		)
+	}
+
+	end := now(opts).Unix()
+	if opts.TimeRange.End != emptyString {
+		end, err = filters.ParseEpoch(opts.TimeRange.End)
+		if err != nil {
+			return exportRange{}, fmt.Errorf(
+				"%w: %w",
+				errs.ErrInvalidEndTime,
+				err,
+			)
+		}
+	}
+
+	if start >= end {
+		return exportRange{}, errRangeOrder
+	}
+
+	return exportRange{Start: start, End: end}, nil
+}
+
+func now(opts Options) time.Time {
+	if opts.Now != nil {
+		return opts.Now()
+	}
+
+	return time.Now()
+}
+
+func formatEpoch(epoch int64) string {
+	return time.Unix(epoch, defaultInt64).UTC().Format(time.RFC3339)
+}
+
+func buildParams(target endpoint, rng exportRange) url.Values {
+	values := url.Values{}
+
+	if target.YMD {
+		values.Set(startYMDParam, formatDate(rng.Start))
+		values.Set(endYMDParam, formatDate(rng.End))
+	} else {
+		values.Set(startDateParam, strconv.FormatInt(rng.Start, numberBase10))
+		values.Set(endDateParam, strconv.FormatInt(rng.End, numberBase10))
+	}
+
+	for key, value := range target.Params {
+		values.Set(key, value)
+	}
+
+	return values
+}
+
+func formatDate(epoch int64) string {
+	return time.Unix(epoch, defaultInt64).UTC().Format(dateLayout)
+}
+
+// fetchEndpoint fetches one endpoint in full. Endpoints a --base-url
+// server does not implement are skipped with a warning, so a mock that
+// serves only some of them still yields a partial export.
+func fetchEndpoint(
+	ctx context.Context,
+	appOpts app.Options,
+	caps *withings.Capabilities,
+	accessToken string,
+	target endpoint,
+	rng exportRange,
+) ([]map[string]any, bool, error) {
+	reason := withings.ErrUnsupported
+
+	if caps.Supports(target.Service, target.Action) {
+		records, err := fetchAll(ctx, appOpts, accessToken, target, rng)
+		if err == nil {
+			return records, false, nil
+		}
+
+		if !withings.Degradable(appOpts, err) {
+			return nil, false, fmt.Errorf("export %s: %w", target.Name, err)
+		}
+
+		reason = err
+	}
+
+	err := output.WriteWarning(appOpts, fmt.Sprintf(
+		"warning: skipping %s: %s %s: %v",
+		target.Name,
+		target.Service,
+		target.Action,
+		reason,
+	))
+	if err != nil {
+		return nil, false, fmt.Errorf("write warning: %w", err)
+	}
+
+	return nil, true, nil
+}
+
+// fetchAll follows more/offset paging until the listing is exhausted.
+func fetchAll(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	target endpoint,
+	rng exportRange,
+) ([]map[string]any, error) {
+	values := buildParams(target, rng)
+
+	var records []map[string]any
+
+	for {
+		page, err := fetchPage(ctx, appOpts, accessToken, target, values)
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := decodeRecords(page.Body[target.ListKey])
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, items...)
+
+		next, more := nextOffset(page.Body)
+		if !more || !advanceOffset(values, next) {
+			return records, nil
+		}
+	}
+}
+
+func fetchPage(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	target endpoint,
+	values url.Values,
+) (pageResponse, error) {
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		withings.ServicePath(baseURL, target.Service),
+		target.Action,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return pageResponse{}, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return pageResponse{}, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return pageResponse{}, fmt.Errorf("read response: %w", err)
+	}
+
+	var decoded pageResponse
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return pageResponse{}, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return pageResponse{}, err
+	}
+
+	return decoded, nil
+}
+
+// decodeRecords keeps numbers as json.Number so IDs and values survive
+// the round trip unchanged.
+func decodeRecords(raw json.RawMessage) ([]map[string]any, error) {
+	if len(raw) == defaultInt {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var records []map[string]any
+
+	err := decoder.Decode(&records)
+	if err != nil {
+		return nil, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	return records, nil
+}
+
+// nextOffset reads more/offset; getmeas reports more as 0/1, the v2
+// endpoints as a boolean.
+func nextOffset(body map[string]json.RawMessage) (int, bool) {
+	more := strings.TrimSpace(string(body[bodyMoreKey]))
+	if more != "true" && more != "1" {
+		return defaultInt, false
+	}
+
+	offset, err := strconv.Atoi(strings.TrimSpace(string(body[bodyOffsetKey])))
+	if err != nil {
+		return defaultInt, false
+	}
+
+	return offset, true
+}
+
+// advanceOffset moves to the next page and reports whether it moved, so
+// a server that repeats an offset cannot loop forever.
+func advanceOffset(values url.Values, next int) bool {
+	current, _ := strconv.Atoi(values.Get(offsetParam))
+	if next <= current {
+		return false
+	}
+
+	values.Set(offsetParam, strconv.Itoa(next))
+
+	return true
+}
+
+func writeSummary(appOpts app.Options, dir string, result manifest) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		err := output.WriteRawJSON(appOpts, result)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	return output.WriteLine(appOpts, fmt.Sprintf(
+		"exported %d records in %d files to %s",
+		result.Records,
+		len(result.Files),
+		dir,
+	))
+}
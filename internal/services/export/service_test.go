@@ -0,0 +1,216 @@
+//nolint:testpackage // test unexported helpers.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	testStart    = "2025-12-01"
+	testEnd      = "2025-12-03"
+	testErrFmt   = "err got %v want %v"
+	testMeasures = `{"status":0,"body":{"more":0,"offset":0,"measuregrps":[` +
+		`{"grpid":7,"date":1764547200,"category":1,"deviceid":"d1",` +
+		`"measures":[{"value":72345,"type":1,"unit":-3},` +
+		`{"value":120,"type":10,"unit":0}]}]}}`
+	testActivityFirst = `{"status":0,"body":{"more":true,"offset":1,` +
+		`"activities":[{"date":"2025-12-01","steps":100}]}}`
+	testActivityLast = `{"status":0,"body":{"more":false,"offset":0,` +
+		`"activities":[{"date":"2025-12-02","steps":200}]}}`
+	testEmptySeries = `{"status":0,"body":{"more":false,"series":[]}}`
+)
+
+// TestResolveRange requires a start before the end.
+func TestResolveRange(t *testing.T) {
+	t.Parallel()
+
+	rng, err := resolveRange(testOptions(t.TempDir(), formatJSON))
+	if err != nil {
+		t.Fatalf("resolveRange: %v", err)
+	}
+
+	if formatDate(rng.Start) != testStart || formatDate(rng.End) != testEnd {
+		t.Fatalf("range got %v", rng)
+	}
+
+	opts := testOptions(t.TempDir(), formatJSON)
+	opts.TimeRange.Start = testEnd
+	opts.TimeRange.End = testStart
+
+	_, err = resolveRange(opts)
+	if !errors.Is(err, errRangeOrder) {
+		t.Fatalf(testErrFmt, err, errRangeOrder)
+	}
+
+	opts.TimeRange.Start = ""
+
+	_, err = resolveRange(opts)
+	if !errors.Is(err, errStartRequired) {
+		t.Fatalf(testErrFmt, err, errStartRequired)
+	}
+}
+
+// TestNextOffset accepts numeric and boolean more flags.
+func TestNextOffset(t *testing.T) {
+	t.Parallel()
+
+	for raw, want := range map[string]bool{
+		`{"more":1,"offset":5}`:    true,
+		`{"more":true,"offset":5}`: true,
+		`{"more":0,"offset":5}`:    false,
+		`{"more":false}`:           false,
+	} {
+		var body map[string]json.RawMessage
+
+		err := json.Unmarshal([]byte(raw), &body)
+		if err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+
+		_, more := nextOffset(body)
+		if more != want {
+			t.Fatalf("more for %s got %t want %t", raw, more, want)
+		}
+	}
+}
+
+// TestFlatten uses dotted names for nested objects.
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	records, err := decodeRecords(json.RawMessage(
+		`[{"id":1,"data":{"steps":12,"zones":[1,2]},"ok":true}]`,
+	))
+	if err != nil {
+		t.Fatalf("decodeRecords: %v", err)
+	}
+
+	row := map[string]string{}
+	flatten(row, "", records[0])
+
+	want := map[string]string{
+		"id":         "1",
+		"data.steps": "12",
+		"data.zones": "[1,2]",
+		"ok":         "true",
+	}
+	for key, value := range want {
+		if row[key] != value {
+			t.Fatalf("%s got %q want %q", key, row[key], value)
+		}
+	}
+
+	columns := strings.Join(unionColumns([]map[string]string{row}), ",")
+	if columns != "data.steps,data.zones,id,ok" {
+		t.Fatalf("columns got %q", columns)
+	}
+}
+
+// TestRunWritesFilesAndManifest pages through endpoints into a directory.
+func TestRunWritesFilesAndManifest(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testHandler))
+	defer server.Close()
+
+	dir := filepath.Join(t.TempDir(), "archive")
+
+	err := Run(
+		t.Context(),
+		testOptions(dir, formatCSV),
+		testAppOpts(server.URL),
+		"token",
+	)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	weight, err := os.ReadFile(filepath.Join(dir, "measures_weight.csv"))
+	if err != nil {
+		t.Fatalf("read weight: %v", err)
+	}
+
+	wantWeight := "grpid,date,category,deviceid,type,type_name,value,unit\n" +
+		"7,1764547200,1,d1,1,weight,72.345,kg\n"
+	if string(weight) != wantWeight {
+		t.Fatalf("weight got %q want %q", weight, wantWeight)
+	}
+
+	activity, err := os.ReadFile(filepath.Join(dir, "activity.csv"))
+	if err != nil {
+		t.Fatalf("read activity: %v", err)
+	}
+
+	wantActivity := "date,steps\n2025-12-01,100\n2025-12-02,200\n"
+	if string(activity) != wantActivity {
+		t.Fatalf("activity got %q want %q", activity, wantActivity)
+	}
+
+	assertManifest(t, dir)
+}
+
+func assertManifest(t *testing.T, dir string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+
+	var decoded manifest
+
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+
+	if decoded.Records != 3 || len(decoded.Files) != 6 {
+		t.Fatalf("manifest got %+v", decoded)
+	}
+
+	if decoded.CreatedAt != "2025-12-03T00:00:00Z" {
+		t.Fatalf("created_at got %q", decoded.CreatedAt)
+	}
+}
+
+func testHandler(writer http.ResponseWriter, req *http.Request) {
+	_ = req.ParseForm()
+
+	switch req.Form.Get("action") {
+	case "getmeas":
+		_, _ = writer.Write([]byte(testMeasures))
+	case "getactivity":
+		if req.Form.Get(offsetParam) == "1" {
+			_, _ = writer.Write([]byte(testActivityLast))
+
+			return
+		}
+
+		_, _ = writer.Write([]byte(testActivityFirst))
+	default:
+		_, _ = writer.Write([]byte(testEmptySeries))
+	}
+}
+
+func testOptions(dir, format string) Options {
+	return Options{
+		TimeRange: params.TimeRange{Start: testStart, End: testEnd},
+		Output:    dir,
+		Format:    format,
+		// Serial fetches keep the test server's request order stable.
+		Concurrency: 1,
+		Now: func() time.Time {
+			return time.Date(2025, 12, 3, 0, 0, 0, 0, time.UTC)
+		},
+	}
+}
@@ -0,0 +1,291 @@
+// Package goals handles the Withings user goals endpoint.
+package goals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/mreimbold/withings-cli/internal/units"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	serviceName      = "v2/user"
+	serviceShort     = "user"
+	serviceV2Suffix  = "/v2"
+	actionGet        = "getgoals"
+	userIDParam      = "userid"
+	goalSteps        = "steps"
+	goalSleep        = "sleep"
+	goalWeight       = "weight"
+	unitSteps        = "steps"
+	unitHours        = "h"
+	secondsPerHour   = 3600
+	decimalBase      = 10
+	floatBitSize     = 64
+	floatFormat      = 'f'
+	shortestPrec     = -1
+	rowsHeaderCount  = 1
+	tableHeader      = "Goal\tValue\tUnit"
+	plainHeader      = "goal\tvalue\tunit"
+	defaultInt       = 0
+	emptyString      = ""
+	columnSeparator  = "\t"
+	goalColumnsCount = 3
+)
+
+// Options captures goals query parameters.
+type Options struct {
+	User params.User
+}
+
+// Run fetches the user's goals and writes output.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	decoded, err := fetch(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return writeBody(appOpts, decoded.Body)
+}
+
+// StepsGoal fetches the daily steps goal; ok is false when none is set.
+func StepsGoal(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) (int64, bool, error) {
+	decoded, err := fetch(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return defaultInt, false, err
+	}
+
+	if decoded.Body.Goals.Steps == nil {
+		return defaultInt, false, nil
+	}
+
+	return *decoded.Body.Goals.Steps, true, nil
+}
+
+func fetch(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) (response, error) {
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		serviceForBase(baseURL),
+		actionGet,
+		accessToken,
+		buildParams(opts),
+	)
+	if err != nil {
+		return response{}, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return response{}, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return response{}, fmt.Errorf("read response: %w", err)
+	}
+
+	return decodeResponse(payload)
+}
+
+func serviceForBase(baseURL string) string {
+	trimmed := strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(trimmed, serviceV2Suffix) {
+		return serviceShort
+	}
+
+	return serviceName
+}
+
+func buildParams(opts Options) url.Values {
+	values := url.Values{}
+
+	if opts.User.UserID != emptyString {
+		values.Set(userIDParam, opts.User.UserID)
+	}
+
+	return values
+}
+
+type response struct {
+	Status int    `json:"status"`
+	Body   body   `json:"body"`
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+}
+
+type body struct {
+	Goals goals `json:"goals"`
+}
+
+// goals holds the daily step count, the nightly sleep duration in
+// seconds, and the target weight as a scaled measure.
+type goals struct {
+	Steps  *int64  `json:"steps,omitempty"`
+	Sleep  *int64  `json:"sleep,omitempty"`
+	Weight *weight `json:"weight,omitempty"`
+}
+
+type weight struct {
+	Value int64 `json:"value"`
+	Unit  int   `json:"unit"`
+}
+
+type row struct {
+	Goal  string
+	Value string
+	Unit  string
+}
+
+func decodeResponse(payload []byte) (response, error) {
+	var decoded response
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return response{}, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	if decoded.Status != withings.StatusOK {
+		message := decoded.Error
+		if message == emptyString {
+			message = decoded.Detail
+		}
+
+		if message == emptyString {
+			message = strings.TrimSpace(string(payload))
+		}
+
+		return response{}, app.NewExitError(
+			app.ExitCodeAPI,
+			&withings.APIError{Status: decoded.Status, Message: message},
+		)
+	}
+
+	return decoded, nil
+}
+
+func writeBody(opts app.Options, body body) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		err := output.WriteRawJSON(opts, body)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	rows := buildRows(body.Goals, opts.Units)
+
+	if opts.Plain || opts.NDJSON {
+		err := output.WritePlain(opts, formatLines(plainHeader, rows))
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	table, err := output.RenderTable(
+		opts,
+		plainHeader,
+		formatLines(tableHeader, rows),
+	)
+	if err != nil {
+		return fmt.Errorf("render goals table: %w", err)
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+// buildRows lists the goals that are set, in a fixed order; the weight
+// goal follows --units.
+func buildRows(goals goals, system string) []row {
+	rows := make([]row, defaultInt, goalColumnsCount)
+
+	if goals.Steps != nil {
+		rows = append(rows, row{
+			Goal:  goalSteps,
+			Value: strconv.FormatInt(*goals.Steps, decimalBase),
+			Unit:  unitSteps,
+		})
+	}
+
+	if goals.Sleep != nil {
+		rows = append(rows, row{
+			Goal:  goalSleep,
+			Value: formatFloat(float64(*goals.Sleep) / secondsPerHour),
+			Unit:  unitHours,
+		})
+	}
+
+	if goals.Weight != nil {
+		rows = append(rows, row{
+			Goal: goalWeight,
+			Value: units.FormatValue(
+				system,
+				units.Mass,
+				measures.ScaledValue(goals.Weight.Value, goals.Weight.Unit),
+			),
+			Unit: units.Label(system, units.Mass),
+		})
+	}
+
+	return rows
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, floatFormat, shortestPrec, floatBitSize)
+}
+
+func formatLines(header string, rows []row) []string {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, header)
+
+	for _, item := range rows {
+		lines = append(lines, strings.Join(
+			[]string{item.Goal, item.Value, item.Unit},
+			columnSeparator,
+		))
+	}
+
+	return lines
+}
@@ -0,0 +1,65 @@
+//nolint:testpackage // test unexported helpers.
+package goals
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/units"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const goalsTestPayload = `{"status":0,"body":{"goals":{"steps":10000,` +
+	`"sleep":27000,"weight":{"value":70500,"unit":-3}}}}`
+
+// TestBuildRows formats every goal and converts the weight goal.
+func TestBuildRows(t *testing.T) {
+	t.Parallel()
+
+	decoded, err := decodeResponse([]byte(goalsTestPayload))
+	if err != nil {
+		t.Fatalf("decodeResponse: %v", err)
+	}
+
+	cases := map[string]string{
+		units.Metric:   "steps\t10000\tsteps|sleep\t7.5\th|weight\t70.5\tkg",
+		units.Imperial: "steps\t10000\tsteps|sleep\t7.5\th|weight\t155.4\tlb",
+	}
+
+	for system, want := range cases {
+		lines := formatLines(plainHeader, buildRows(decoded.Body.Goals, system))
+
+		got := strings.Join(lines[rowsHeaderCount:], "|")
+		if got != want {
+			t.Fatalf("%s rows got %q want %q", system, got, want)
+		}
+	}
+}
+
+// TestBuildRowsSkipsUnset leaves out goals the account has not set.
+func TestBuildRowsSkipsUnset(t *testing.T) {
+	t.Parallel()
+
+	decoded, err := decodeResponse([]byte(
+		`{"status":0,"body":{"goals":{"steps":8000}}}`,
+	))
+	if err != nil {
+		t.Fatalf("decodeResponse: %v", err)
+	}
+
+	rows := buildRows(decoded.Body.Goals, units.Metric)
+	if len(rows) != 1 || rows[0].Goal != goalSteps {
+		t.Fatalf("rows got %+v want steps only", rows)
+	}
+}
+
+// TestDecodeResponseError surfaces the Withings status.
+func TestDecodeResponseError(t *testing.T) {
+	t.Parallel()
+
+	_, err := decodeResponse([]byte(`{"status":2554,"error":"unknown"}`))
+	if !errors.Is(err, withings.ErrAPI) {
+		t.Fatalf("decodeResponse got %v want API error", err)
+	}
+}
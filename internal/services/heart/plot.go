@@ -0,0 +1,346 @@
+package heart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// Plots follow standard ECG paper: 25 mm/s, 10 mm/mV, 1 mm minor and 5 mm
+// major grid boxes.
+const (
+	plotFormatPNG     = "png"
+	plotFormatSVG     = "svg"
+	plotPixelsPerMM   = 4
+	plotMMPerSecond   = 25
+	plotMMPerMV       = 10
+	plotMajorMM       = 5
+	plotMinHeightMM   = 30
+	plotMarginMM      = 5
+	microvoltsPerMV   = 1000
+	plotTraceWidth    = 2
+	plotFloatDigits   = 1
+	plotColorMax      = 0xff
+	plotMinorRed      = 0xfa
+	plotMinorGreenBlu = 0xdc
+	plotMajorRed      = 0xe8
+	plotMajorGreenBlu = 0x96
+	svgMinorStroke    = "#fadcdc"
+	svgMajorStroke    = "#e89696"
+	svgTraceStroke    = "#000000"
+)
+
+var errPlotFormat = errors.New("invalid --plot extension (use .png or .svg)")
+
+//nolint:gochecknoglobals // Static palette for ECG paper rendering.
+var (
+	plotBackground = color.RGBA{
+		R: plotColorMax,
+		G: plotColorMax,
+		B: plotColorMax,
+		A: plotColorMax,
+	}
+	plotMinorColor = color.RGBA{
+		R: plotMinorRed,
+		G: plotMinorGreenBlu,
+		B: plotMinorGreenBlu,
+		A: plotColorMax,
+	}
+	plotMajorColor = color.RGBA{
+		R: plotMajorRed,
+		G: plotMajorGreenBlu,
+		B: plotMajorGreenBlu,
+		A: plotColorMax,
+	}
+	plotTraceColor = color.RGBA{R: 0, G: 0, B: 0, A: plotColorMax}
+)
+
+// plotLayout maps samples to pixel coordinates on ECG paper.
+type plotLayout struct {
+	WidthMM   int
+	HeightMM  int
+	TopMV     float64
+	Frequency int
+}
+
+type plotPoint struct {
+	X float64
+	Y float64
+}
+
+func resolvePlotFormat(path string) (string, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+
+	switch format {
+	case plotFormatPNG, plotFormatSVG:
+		return format, nil
+	default:
+		return emptyString, fmt.Errorf("%w: %q", errPlotFormat, path)
+	}
+}
+
+func newPlotLayout(body signalBody) (plotLayout, error) {
+	if body.SamplingFrequency <= defaultInt {
+		return plotLayout{}, app.NewExitError(
+			app.ExitCodeFailure,
+			errSignalFrequency,
+		)
+	}
+
+	minMV, maxMV := signalRangeMV(body.Signal)
+	spanMM := roundUpToMajor((maxMV - minMV) * plotMMPerMV)
+	heightMM := max(spanMM+2*plotMarginMM, plotMinHeightMM)
+	padMM := float64(heightMM) - (maxMV-minMV)*plotMMPerMV
+
+	seconds := float64(len(body.Signal)) / float64(body.SamplingFrequency)
+	widthMM := roundUpToMajor(seconds * plotMMPerSecond)
+
+	return plotLayout{
+		WidthMM:   max(widthMM, plotMajorMM),
+		HeightMM:  heightMM,
+		TopMV:     maxMV + padMM/2/plotMMPerMV,
+		Frequency: body.SamplingFrequency,
+	}, nil
+}
+
+func signalRangeMV(samples []int) (float64, float64) {
+	if len(samples) == defaultInt {
+		return defaultInt, defaultInt
+	}
+
+	low, high := samples[0], samples[0]
+	for _, sample := range samples {
+		low = min(low, sample)
+		high = max(high, sample)
+	}
+
+	return float64(low) / microvoltsPerMV, float64(high) / microvoltsPerMV
+}
+
+func roundUpToMajor(mm float64) int {
+	boxes := int(math.Ceil(mm / plotMajorMM))
+
+	return boxes * plotMajorMM
+}
+
+func (layout plotLayout) widthPx() int {
+	return layout.WidthMM * plotPixelsPerMM
+}
+
+func (layout plotLayout) heightPx() int {
+	return layout.HeightMM * plotPixelsPerMM
+}
+
+func (layout plotLayout) point(index, sample int) plotPoint {
+	seconds := float64(index) / float64(layout.Frequency)
+	millivolts := float64(sample) / microvoltsPerMV
+
+	return plotPoint{
+		X: seconds * plotMMPerSecond * plotPixelsPerMM,
+		Y: (layout.TopMV - millivolts) * plotMMPerMV * plotPixelsPerMM,
+	}
+}
+
+func renderPlot(format string, signalID int64, body signalBody) (
+	[]byte,
+	error,
+) {
+	layout, err := newPlotLayout(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == plotFormatSVG {
+		return renderSVG(layout, signalID, body), nil
+	}
+
+	return renderPNG(layout, body)
+}
+
+func renderPNG(layout plotLayout, body signalBody) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(
+		defaultInt,
+		defaultInt,
+		layout.widthPx(),
+		layout.heightPx(),
+	))
+
+	fillRect(canvas, canvas.Bounds(), plotBackground)
+	drawPNGGrid(canvas, layout)
+
+	for index := 1; index < len(body.Signal); index++ {
+		drawLine(
+			canvas,
+			layout.point(index-1, body.Signal[index-1]),
+			layout.point(index, body.Signal[index]),
+		)
+	}
+
+	var buffer bytes.Buffer
+
+	err := png.Encode(&buffer, canvas)
+	if err != nil {
+		return nil, fmt.Errorf("encode plot png: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// drawPNGGrid draws minor lines first so major lines stay on top.
+func drawPNGGrid(canvas *image.RGBA, layout plotLayout) {
+	for _, major := range []bool{false, true} {
+		lineColor := plotMinorColor
+		if major {
+			lineColor = plotMajorColor
+		}
+
+		for mm := 0; mm <= layout.WidthMM; mm++ {
+			if (mm%plotMajorMM == defaultInt) == major {
+				x := min(mm*plotPixelsPerMM, layout.widthPx()-1)
+				fillRect(canvas, image.Rect(
+					x, defaultInt, x+1, layout.heightPx(),
+				), lineColor)
+			}
+		}
+
+		for mm := 0; mm <= layout.HeightMM; mm++ {
+			if (mm%plotMajorMM == defaultInt) == major {
+				y := min(mm*plotPixelsPerMM, layout.heightPx()-1)
+				fillRect(canvas, image.Rect(
+					defaultInt, y, layout.widthPx(), y+1,
+				), lineColor)
+			}
+		}
+	}
+}
+
+func fillRect(canvas *image.RGBA, rect image.Rectangle, fill color.RGBA) {
+	rect = rect.Intersect(canvas.Bounds())
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			canvas.SetRGBA(x, y, fill)
+		}
+	}
+}
+
+// drawLine steps along the longer axis and stamps a square pen.
+func drawLine(canvas *image.RGBA, from, to plotPoint) {
+	steps := int(math.Ceil(math.Max(
+		math.Abs(to.X-from.X),
+		math.Abs(to.Y-from.Y),
+	)))
+	steps = max(steps, 1)
+
+	for step := 0; step <= steps; step++ {
+		ratio := float64(step) / float64(steps)
+		x := int(math.Round(from.X + (to.X-from.X)*ratio))
+		y := int(math.Round(from.Y + (to.Y-from.Y)*ratio))
+
+		fillRect(canvas, image.Rect(
+			x, y, x+plotTraceWidth, y+plotTraceWidth,
+		), plotTraceColor)
+	}
+}
+
+func renderSVG(layout plotLayout, signalID int64, body signalBody) []byte {
+	var buffer bytes.Buffer
+
+	width := strconv.Itoa(layout.widthPx())
+	height := strconv.Itoa(layout.heightPx())
+
+	buffer.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" ` +
+		`width="` + width + `" height="` + height + `" ` +
+		`viewBox="0 0 ` + width + ` ` + height + `">` + "\n")
+	buffer.WriteString("<title>Withings ECG signal " +
+		strconv.FormatInt(signalID, numberBase10) +
+		" (25 mm/s, 10 mm/mV)</title>\n")
+	buffer.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` +
+		"\n")
+
+	for _, major := range []bool{false, true} {
+		stroke := svgMinorStroke
+		if major {
+			stroke = svgMajorStroke
+		}
+
+		buffer.WriteString(`<path fill="none" stroke="` + stroke +
+			`" stroke-width="1" d="`)
+		writeSVGGrid(&buffer, layout, major)
+		buffer.WriteString("\"/>\n")
+	}
+
+	buffer.WriteString(`<polyline fill="none" stroke="` + svgTraceStroke +
+		`" stroke-width="` + strconv.Itoa(plotTraceWidth) + `" points="`)
+
+	for index, sample := range body.Signal {
+		if index > defaultInt {
+			buffer.WriteString(" ")
+		}
+
+		point := layout.point(index, sample)
+		buffer.WriteString(formatPlotFloat(point.X) + "," +
+			formatPlotFloat(point.Y))
+	}
+
+	buffer.WriteString("\"/>\n</svg>\n")
+
+	return buffer.Bytes()
+}
+
+func writeSVGGrid(buffer *bytes.Buffer, layout plotLayout, major bool) {
+	for mm := 0; mm <= layout.WidthMM; mm++ {
+		if (mm%plotMajorMM == defaultInt) == major {
+			x := strconv.Itoa(mm * plotPixelsPerMM)
+			buffer.WriteString("M" + x + " 0V" +
+				strconv.Itoa(layout.heightPx()))
+		}
+	}
+
+	for mm := 0; mm <= layout.HeightMM; mm++ {
+		if (mm%plotMajorMM == defaultInt) == major {
+			y := strconv.Itoa(mm * plotPixelsPerMM)
+			buffer.WriteString("M0 " + y + "H" +
+				strconv.Itoa(layout.widthPx()))
+		}
+	}
+}
+
+func formatPlotFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', plotFloatDigits, signalFloatBits)
+}
+
+func writePlot(
+	appOpts app.Options,
+	opts SignalOptions,
+	body signalBody,
+	encoded []byte,
+) error {
+	err := os.WriteFile(opts.Plot, encoded, signalFileMode)
+	if err != nil {
+		return fmt.Errorf("write plot file: %w", err)
+	}
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	return output.WriteLine(appOpts, fmt.Sprintf(
+		"saved plot of signal %d (%d samples, %d Hz) to %s",
+		opts.SignalID,
+		len(body.Signal),
+		body.SamplingFrequency,
+		opts.Plot,
+	))
+}
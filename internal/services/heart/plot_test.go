@@ -0,0 +1,135 @@
+//nolint:testpackage // test unexported helpers.
+package heart
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+const (
+	plotTestSignalID  = 42
+	plotTestFrequency = 250
+	plotTestSamples   = 500
+	plotTestPeakUV    = 1500
+	plotTestWidthPx   = 200
+	plotTestHeightPx  = 120
+	plotTestErrFmt    = "err got %v want %v"
+	plotTestSizeFmt   = "size got %dx%d want %dx%d"
+)
+
+// TestResolvePlotFormat accepts png and svg extensions only.
+func TestResolvePlotFormat(t *testing.T) {
+	t.Parallel()
+
+	for path, want := range map[string]string{
+		"ecg.PNG":      plotFormatPNG,
+		"out/ecg.svg":  plotFormatSVG,
+		"ecg.plot.png": plotFormatPNG,
+	} {
+		got, err := resolvePlotFormat(path)
+		if err != nil {
+			t.Fatalf("resolvePlotFormat(%q): %v", path, err)
+		}
+
+		if got != want {
+			t.Fatalf("format got %q want %q", got, want)
+		}
+	}
+
+	_, err := resolvePlotFormat("ecg.pdf")
+	if !errors.Is(err, errPlotFormat) {
+		t.Fatalf(plotTestErrFmt, err, errPlotFormat)
+	}
+}
+
+// TestNewPlotLayout scales two seconds at 25 mm/s onto 5 mm boxes.
+func TestNewPlotLayout(t *testing.T) {
+	t.Parallel()
+
+	layout, err := newPlotLayout(plotTestBody())
+	if err != nil {
+		t.Fatalf("newPlotLayout: %v", err)
+	}
+
+	if layout.widthPx() != plotTestWidthPx ||
+		layout.heightPx() != plotTestHeightPx {
+		t.Fatalf(
+			plotTestSizeFmt,
+			layout.widthPx(),
+			layout.heightPx(),
+			plotTestWidthPx,
+			plotTestHeightPx,
+		)
+	}
+
+	_, err = newPlotLayout(signalBody{
+		Signal:            []int{testDefaultInt},
+		SamplingFrequency: testDefaultInt,
+		WearPosition:      testDefaultInt,
+	})
+	if !errors.Is(err, errSignalFrequency) {
+		t.Fatalf(plotTestErrFmt, err, errSignalFrequency)
+	}
+}
+
+// TestRenderPlotPNG encodes an image of the layout size.
+func TestRenderPlotPNG(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := renderPlot(plotFormatPNG, plotTestSignalID, plotTestBody())
+	if err != nil {
+		t.Fatalf("renderPlot: %v", err)
+	}
+
+	config, err := png.DecodeConfig(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+
+	if config.Width != plotTestWidthPx || config.Height != plotTestHeightPx {
+		t.Fatalf(
+			plotTestSizeFmt,
+			config.Width,
+			config.Height,
+			plotTestWidthPx,
+			plotTestHeightPx,
+		)
+	}
+}
+
+// TestRenderPlotSVG includes the grid, the trace, and the scale.
+func TestRenderPlotSVG(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := renderPlot(plotFormatSVG, plotTestSignalID, plotTestBody())
+	if err != nil {
+		t.Fatalf("renderPlot: %v", err)
+	}
+
+	svg := string(encoded)
+	for _, want := range []string{
+		`width="200" height="120"`,
+		"signal 42 (25 mm/s, 10 mm/mV)",
+		svgMajorStroke,
+		"<polyline",
+		"0.0,",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Fatalf("svg missing %q", want)
+		}
+	}
+}
+
+func plotTestBody() signalBody {
+	samples := make([]int, plotTestSamples)
+	samples[plotTestFrequency] = plotTestPeakUV
+
+	return signalBody{
+		Signal:            samples,
+		SamplingFrequency: plotTestFrequency,
+		WearPosition:      testDefaultInt,
+	}
+}
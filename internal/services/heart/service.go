@@ -6,11 +6,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-	"text/tabwriter"
 	"time"
 
 	"github.com/mreimbold/withings-cli/internal/app"
@@ -36,15 +34,14 @@ const (
 	signalEnabled   = "1"
 	numberBase10    = 10
 	rowsHeaderCount = 1
-	tableMinWidth   = 0
-	tableTabWidth   = 0
-	tablePadding    = 2
-	tablePadChar    = ' '
-	tableFlags      = 0
 	defaultInt      = 0
 	defaultInt64    = 0
 	signalYes       = "yes"
 	emptyString     = ""
+	tableHeader     = "Time\tHeart Rate\tModel\tDevice\tSignal ID\t" +
+		"ECG\tAFib\tSignal"
+	plainHeader = "time\theart_rate\tmodel\tdevice\tsignal_id\t" +
+		"ecg\tafib\tsignal"
 )
 
 // Options captures heart query parameters.
@@ -68,33 +65,67 @@ func Run(
 		return app.NewExitError(app.ExitCodeUsage, err)
 	}
 
+	payload, err := callAction(ctx, appOpts, accessToken, actionList, values)
+	if err != nil {
+		return err
+	}
+
+	return writeResponse(appOpts, payload)
+}
+
+func callAction(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	action string,
+	values url.Values,
+) ([]byte, error) {
+	return callService(
+		ctx,
+		appOpts,
+		accessToken,
+		serviceForBase,
+		action,
+		values,
+	)
+}
+
+// callService sends action to the service that service picks for the
+// base URL.
+func callService(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	service func(baseURL string) string,
+	action string,
+	values url.Values,
+) ([]byte, error) {
 	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
-	service := serviceForBase(baseURL)
 
 	req, _, err := withings.BuildRequest(
 		ctx,
 		baseURL,
-		service,
-		actionList,
+		service(baseURL),
+		action,
 		accessToken,
 		values,
 	)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return nil, fmt.Errorf("build request: %w", err)
 	}
 
 	//nolint:bodyclose // ReadPayload closes the response body.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := withings.NewClient(appOpts).Do(req)
 	if err != nil {
-		return app.NewExitError(app.ExitCodeNetwork, err)
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
 	}
 
 	payload, err := withings.ReadPayload(resp)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
-	return writeResponse(appOpts, payload)
+	return payload, nil
 }
 
 func serviceForBase(baseURL string) string {
@@ -250,17 +281,17 @@ func writeBody(opts app.Options, body body) error {
 		return nil
 	}
 
-	if opts.JSON {
+	if opts.JSON && opts.Fields == emptyString {
 		return writeJSONOutput(opts, body)
 	}
 
-	rows := buildRows(body)
+	rows := buildRows(body, output.DisplayLocation(opts, body.Timezone))
 
-	if opts.Plain {
-		return writePlainOutput(rows)
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		return writePlainOutput(opts, rows)
 	}
 
-	return writeTableOutput(rows)
+	return writeTableOutput(opts, rows)
 }
 
 func writeJSONOutput(opts app.Options, body body) error {
@@ -272,8 +303,8 @@ func writeJSONOutput(opts app.Options, body body) error {
 	return nil
 }
 
-func writePlainOutput(rows []row) error {
-	err := output.WriteLines(formatLines(rows))
+func writePlainOutput(opts app.Options, rows []row) error {
+	err := output.WritePlain(opts, formatLines(rows))
 	if err != nil {
 		return fmt.Errorf("write plain output: %w", err)
 	}
@@ -281,13 +312,13 @@ func writePlainOutput(rows []row) error {
 	return nil
 }
 
-func writeTableOutput(rows []row) error {
-	table, err := formatTable(rows)
+func writeTableOutput(opts app.Options, rows []row) error {
+	table, err := formatTable(opts, rows)
 	if err != nil {
 		return err
 	}
 
-	err = output.WriteLine(table)
+	err = output.WriteLine(opts, table)
 	if err != nil {
 		return fmt.Errorf("write table output: %w", err)
 	}
@@ -306,27 +337,20 @@ func decodeResponse(payload []byte) (response, error) {
 		)
 	}
 
-	if decoded.Status != withings.StatusOK {
-		message := decoded.Error
-		if message == emptyString {
-			message = decoded.Detail
-		}
-
-		if message == emptyString {
-			message = strings.TrimSpace(string(payload))
-		}
-
-		return response{}, app.NewExitError(
-			app.ExitCodeAPI,
-			fmt.Errorf("%w: %d: %s", withings.ErrAPI, decoded.Status, message),
-		)
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return response{}, err
 	}
 
 	return decoded, nil
 }
 
-func buildRows(body body) []row {
-	location := seriesLocation(body.Timezone)
+func buildRows(body body, location *time.Location) []row {
 	rows := make([]row, defaultInt, len(body.Series))
 
 	for _, series := range body.Series {
@@ -367,19 +391,6 @@ func seriesSignalID(series series) int64 {
 	return series.ID
 }
 
-func seriesLocation(timezone string) *time.Location {
-	if timezone == emptyString {
-		return time.UTC
-	}
-
-	location, err := time.LoadLocation(timezone)
-	if err != nil {
-		return time.UTC
-	}
-
-	return location
-}
-
 func formatTime(epoch int64, location *time.Location) string {
 	if epoch == defaultInt64 {
 		return emptyString
@@ -417,64 +428,42 @@ func formatSignal(signal json.RawMessage) string {
 	return signalYes
 }
 
-func formatTable(rows []row) (string, error) {
-	var buffer bytes.Buffer
-
-	writer := tabwriter.NewWriter(
-		&buffer,
-		tableMinWidth,
-		tableTabWidth,
-		tablePadding,
-		tablePadChar,
-		tableFlags,
-	)
-	_, _ = fmt.Fprintln(
-		writer,
-		"Time\tHeart Rate\tModel\tDevice\tSignal ID\tECG\tAFib\tSignal",
-	)
+func formatTable(opts app.Options, rows []row) (string, error) {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, tableHeader)
 
 	for _, row := range rows {
-		_, _ = fmt.Fprintf(
-			writer,
-			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-			row.Time,
-			row.HeartRate,
-			row.Model,
-			row.Device,
-			row.SignalID,
-			row.ECG,
-			row.AFib,
-			row.Signal,
-		)
+		lines = append(lines, formatRow(row))
 	}
 
-	err := writer.Flush()
+	table, err := output.RenderTable(opts, plainHeader, lines)
 	if err != nil {
 		return emptyString, fmt.Errorf("render heart table: %w", err)
 	}
 
-	return strings.TrimRight(buffer.String(), "\n"), nil
+	return table, nil
 }
 
 func formatLines(rows []row) []string {
 	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
-	lines = append(
-		lines,
-		"time\theart_rate\tmodel\tdevice\tsignal_id\tecg\tafib\tsignal",
-	)
+	lines = append(lines, plainHeader)
 
 	for _, row := range rows {
-		lines = append(lines, strings.Join([]string{
-			row.Time,
-			row.HeartRate,
-			row.Model,
-			row.Device,
-			row.SignalID,
-			row.ECG,
-			row.AFib,
-			row.Signal,
-		}, "\t"))
+		lines = append(lines, formatRow(row))
 	}
 
 	return lines
 }
+
+func formatRow(row row) string {
+	return strings.Join([]string{
+		row.Time,
+		row.HeartRate,
+		row.Model,
+		row.Device,
+		row.SignalID,
+		row.ECG,
+		row.AFib,
+		row.Signal,
+	}, "\t")
+}
@@ -0,0 +1,405 @@
+package heart
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	actionGet          = "get"
+	signalIDParam      = "signalid"
+	formatCSV          = "csv"
+	formatJSON         = "json"
+	formatEDF          = "edf"
+	signalUnit         = "uV"
+	signalFileMode     = 0o600
+	millisPerSecond    = 1000
+	signalFloatBits    = 64
+	edfVersion         = "0"
+	edfPatient         = "X X X X"
+	edfRecordingPrefix = "Withings ECG signal "
+	edfUnknownDate     = "01.01.85"
+	edfUnknownTime     = "00.00.00"
+	edfHeaderBytes     = 256
+	edfSignalCount     = 1
+	edfRecordSeconds   = 1
+	edfLabel           = "ECG"
+	edfDigitalMin      = math.MinInt16
+	edfDigitalMax      = math.MaxInt16
+	edfVersionWidth    = 8
+	edfIDWidth         = 80
+	edfFieldWidth      = 8
+	edfReservedWidth   = 44
+	edfCountWidth      = 4
+	edfLabelWidth      = 16
+	edfSignalReserved  = 32
+)
+
+var (
+	errSignalIDRequired = errors.New("--signal-id is required")
+	errSignalFormat     = errors.New("invalid --file-format")
+	errSignalFrequency  = errors.New("signal has no sampling frequency")
+)
+
+// SignalOptions captures ECG signal download parameters.
+type SignalOptions struct {
+	SignalID int64
+	User     params.User
+	Output   string
+	Format   string
+	Plot     string
+}
+
+// Signal downloads a full ECG waveform and writes it as CSV, JSON, EDF, or
+// a WFDB record.
+// With --plot only, the waveform is rendered to an image and the raw samples
+// are not written.
+func Signal(
+	ctx context.Context,
+	opts SignalOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	format, err := resolveSignalFormat(opts, appOpts.JSON)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	if format == formatWFDB {
+		_, _, _, err = wfdbRecord(opts.Output)
+		if err != nil {
+			return app.NewExitError(app.ExitCodeUsage, err)
+		}
+	}
+
+	plotFormat := emptyString
+	if opts.Plot != emptyString {
+		plotFormat, err = resolvePlotFormat(opts.Plot)
+		if err != nil {
+			return app.NewExitError(app.ExitCodeUsage, err)
+		}
+	}
+
+	values, err := buildSignalParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	payload, err := callAction(ctx, appOpts, accessToken, actionGet, values)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeSignalResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	if plotFormat != emptyString {
+		plotted, err := renderPlot(plotFormat, opts.SignalID, decoded.Body)
+		if err != nil {
+			return err
+		}
+
+		err = writePlot(appOpts, opts, decoded.Body, plotted)
+		if err != nil {
+			return err
+		}
+
+		if opts.Output == emptyString && opts.Format == emptyString {
+			return nil
+		}
+	}
+
+	if format == formatWFDB {
+		return writeSignalWFDB(appOpts, opts, decoded.Body)
+	}
+
+	encoded, err := encodeSignal(format, opts.SignalID, decoded.Body)
+	if err != nil {
+		return err
+	}
+
+	return writeSignal(appOpts, opts, decoded.Body, encoded)
+}
+
+func buildSignalParams(opts SignalOptions) (url.Values, error) {
+	if opts.SignalID <= defaultInt64 {
+		return nil, errSignalIDRequired
+	}
+
+	values := url.Values{}
+	values.Set(signalIDParam, strconv.FormatInt(opts.SignalID, numberBase10))
+	applyUser(&values, opts.User)
+
+	return values, nil
+}
+
+// resolveSignalFormat prefers --file-format, then the --dest extension, then
+// --json, and falls back to CSV. WFDB .hea and .dat outputs map to wfdb.
+func resolveSignalFormat(opts SignalOptions, jsonOutput bool) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+
+	if format == emptyString && opts.Output != emptyString {
+		format = outputFormat(opts.Output)
+	}
+
+	if format == emptyString && jsonOutput {
+		format = formatJSON
+	}
+
+	if format == emptyString {
+		format = formatCSV
+	}
+
+	switch format {
+	case formatCSV, formatJSON, formatEDF, formatWFDB:
+		return format, nil
+	default:
+		return emptyString, fmt.Errorf("%w: %q", errSignalFormat, format)
+	}
+}
+
+func outputFormat(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == wfdbHeaderExt || ext == wfdbSignalExt {
+		return formatWFDB
+	}
+
+	return strings.TrimPrefix(ext, ".")
+}
+
+type signalResponse struct {
+	Status int        `json:"status"`
+	Body   signalBody `json:"body"`
+	Error  string     `json:"error"`
+	Detail string     `json:"detail"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type signalBody struct {
+	Signal            []int `json:"signal"`
+	SamplingFrequency int   `json:"sampling_frequency"`
+	WearPosition      int   `json:"wearposition"`
+}
+
+//nolint:tagliatelle // Output mirrors Withings snake_case JSON fields.
+type signalExport struct {
+	SignalID          int64  `json:"signalid"`
+	SamplingFrequency int    `json:"sampling_frequency"`
+	WearPosition      int    `json:"wearposition"`
+	Unit              string `json:"unit"`
+	Samples           []int  `json:"samples"`
+}
+
+func decodeSignalResponse(payload []byte) (signalResponse, error) {
+	var decoded signalResponse
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return signalResponse{}, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return signalResponse{}, err
+	}
+
+	return decoded, nil
+}
+
+func encodeSignal(
+	format string,
+	signalID int64,
+	body signalBody,
+) ([]byte, error) {
+	switch format {
+	case formatJSON:
+		return encodeSignalJSON(signalID, body)
+	case formatEDF:
+		return encodeSignalEDF(signalID, body)
+	default:
+		return encodeSignalCSV(body)
+	}
+}
+
+func encodeSignalCSV(body signalBody) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	writer := csv.NewWriter(&buffer)
+	_ = writer.Write([]string{"index", "time_ms", "amplitude_uv"})
+
+	for index, sample := range body.Signal {
+		_ = writer.Write([]string{
+			strconv.Itoa(index),
+			sampleTime(index, body.SamplingFrequency),
+			strconv.Itoa(sample),
+		})
+	}
+
+	writer.Flush()
+
+	err := writer.Error()
+	if err != nil {
+		return nil, fmt.Errorf("encode signal csv: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func sampleTime(index, frequency int) string {
+	if frequency <= defaultInt {
+		return emptyString
+	}
+
+	millis := float64(index) * millisPerSecond / float64(frequency)
+
+	return strconv.FormatFloat(millis, 'f', -1, signalFloatBits)
+}
+
+func encodeSignalJSON(signalID int64, body signalBody) ([]byte, error) {
+	encoded, err := json.MarshalIndent(signalExport{
+		SignalID:          signalID,
+		SamplingFrequency: body.SamplingFrequency,
+		WearPosition:      body.WearPosition,
+		Unit:              signalUnit,
+		Samples:           body.Signal,
+	}, emptyString, "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode signal json: %w", err)
+	}
+
+	return append(encoded, '\n'), nil
+}
+
+// encodeSignalEDF writes a single-channel EDF file with one-second records.
+// Samples are stored 1:1 as 16-bit integers in microvolts; the start date is
+// the EDF "unknown" placeholder because the API does not return it.
+func encodeSignalEDF(signalID int64, body signalBody) ([]byte, error) {
+	frequency := body.SamplingFrequency
+	if frequency <= defaultInt {
+		return nil, app.NewExitError(app.ExitCodeFailure, errSignalFrequency)
+	}
+
+	records := (len(body.Signal) + frequency - 1) / frequency
+
+	var buffer bytes.Buffer
+
+	writeEDFField(&buffer, edfVersion, edfVersionWidth)
+	writeEDFField(&buffer, edfPatient, edfIDWidth)
+	writeEDFField(
+		&buffer,
+		edfRecordingPrefix+strconv.FormatInt(signalID, numberBase10),
+		edfIDWidth,
+	)
+	writeEDFField(&buffer, edfUnknownDate, edfFieldWidth)
+	writeEDFField(&buffer, edfUnknownTime, edfFieldWidth)
+	writeEDFField(
+		&buffer,
+		strconv.Itoa(edfHeaderBytes*(edfSignalCount+1)),
+		edfFieldWidth,
+	)
+	writeEDFField(&buffer, emptyString, edfReservedWidth)
+	writeEDFField(&buffer, strconv.Itoa(records), edfFieldWidth)
+	writeEDFField(&buffer, strconv.Itoa(edfRecordSeconds), edfFieldWidth)
+	writeEDFField(&buffer, strconv.Itoa(edfSignalCount), edfCountWidth)
+
+	writeEDFField(&buffer, edfLabel, edfLabelWidth)
+	writeEDFField(&buffer, emptyString, edfIDWidth)
+	writeEDFField(&buffer, signalUnit, edfFieldWidth)
+	writeEDFField(&buffer, strconv.Itoa(edfDigitalMin), edfFieldWidth)
+	writeEDFField(&buffer, strconv.Itoa(edfDigitalMax), edfFieldWidth)
+	writeEDFField(&buffer, strconv.Itoa(edfDigitalMin), edfFieldWidth)
+	writeEDFField(&buffer, strconv.Itoa(edfDigitalMax), edfFieldWidth)
+	writeEDFField(&buffer, emptyString, edfIDWidth)
+	writeEDFField(&buffer, strconv.Itoa(frequency), edfFieldWidth)
+	writeEDFField(&buffer, emptyString, edfSignalReserved)
+
+	samples := make([]int16, records*frequency)
+	for index, sample := range body.Signal {
+		samples[index] = clampInt16(sample)
+	}
+
+	err := binary.Write(&buffer, binary.LittleEndian, samples)
+	if err != nil {
+		return nil, fmt.Errorf("encode signal edf: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func writeEDFField(buffer *bytes.Buffer, value string, width int) {
+	if len(value) > width {
+		value = value[:width]
+	}
+
+	buffer.WriteString(value)
+	buffer.WriteString(strings.Repeat(" ", width-len(value)))
+}
+
+func clampInt16(value int) int16 {
+	switch {
+	case value < edfDigitalMin:
+		return edfDigitalMin
+	case value > edfDigitalMax:
+		return edfDigitalMax
+	default:
+		return int16(value)
+	}
+}
+
+func writeSignal(
+	appOpts app.Options,
+	opts SignalOptions,
+	body signalBody,
+	encoded []byte,
+) error {
+	if opts.Output == emptyString {
+		if appOpts.Quiet {
+			return nil
+		}
+
+		return output.WriteRaw(appOpts, encoded)
+	}
+
+	err := os.WriteFile(opts.Output, encoded, signalFileMode)
+	if err != nil {
+		return fmt.Errorf("write signal file: %w", err)
+	}
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	return output.WriteLine(appOpts, fmt.Sprintf(
+		"saved signal %d (%d samples, %d Hz) to %s",
+		opts.SignalID,
+		len(body.Signal),
+		body.SamplingFrequency,
+		opts.Output,
+	))
+}
@@ -0,0 +1,143 @@
+//nolint:testpackage // test unexported helpers.
+package heart
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	signalTestFrequency = 2
+	signalTestOverflow  = 40000
+	signalTestSample    = -120
+	signalTestCSV       = "index,time_ms,amplitude_uv\n" +
+		"0,0,-120\n1,500,40000\n2,1000,7\n"
+	signalTestFormatFmt = "format got %q want %q"
+	signalTestErrFmt    = "err got %v want %v"
+)
+
+// TestResolveSignalFormat prefers --file-format, then extension, then --json.
+func TestResolveSignalFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		format string
+		output string
+		json   bool
+		want   string
+	}{
+		{"EDF", "ecg.csv", false, formatEDF},
+		{testEmptyString, "ecg.json", false, formatJSON},
+		{testEmptyString, testEmptyString, true, formatJSON},
+		{testEmptyString, testEmptyString, false, formatCSV},
+	}
+
+	for _, test := range cases {
+		got, err := resolveSignalFormat(
+			signalTestOptions(test.format, test.output),
+			test.json,
+		)
+		if err != nil {
+			t.Fatalf("resolveSignalFormat: %v", err)
+		}
+
+		if got != test.want {
+			t.Fatalf(signalTestFormatFmt, got, test.want)
+		}
+	}
+
+	_, err := resolveSignalFormat(
+		signalTestOptions(testEmptyString, "ecg.txt"),
+		false,
+	)
+	if !errors.Is(err, errSignalFormat) {
+		t.Fatalf(signalTestErrFmt, err, errSignalFormat)
+	}
+}
+
+// TestBuildSignalParamsRequiresID rejects a missing signal ID.
+func TestBuildSignalParamsRequiresID(t *testing.T) {
+	t.Parallel()
+
+	_, err := buildSignalParams(
+		signalTestOptions(testEmptyString, testEmptyString),
+	)
+	if !errors.Is(err, errSignalIDRequired) {
+		t.Fatalf(signalTestErrFmt, err, errSignalIDRequired)
+	}
+}
+
+// TestEncodeSignalCSV writes sample index, offset, and amplitude.
+func TestEncodeSignalCSV(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := encodeSignalCSV(signalTestBody())
+	if err != nil {
+		t.Fatalf("encodeSignalCSV: %v", err)
+	}
+
+	if string(encoded) != signalTestCSV {
+		t.Fatalf("csv got %q want %q", encoded, signalTestCSV)
+	}
+}
+
+// TestEncodeSignalEDF pads records and clamps samples to 16 bits.
+func TestEncodeSignalEDF(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := encodeSignalEDF(testSignalID, signalTestBody())
+	if err != nil {
+		t.Fatalf("encodeSignalEDF: %v", err)
+	}
+
+	headerSize := edfHeaderBytes * (edfSignalCount + 1)
+	records := 2
+	wantSize := headerSize + records*signalTestFrequency*2
+
+	if len(encoded) != wantSize {
+		t.Fatalf("edf size got %d want %d", len(encoded), wantSize)
+	}
+
+	header := string(encoded[:headerSize])
+	if !strings.HasPrefix(header, edfVersion+"       X X X X") {
+		t.Fatalf("edf header got %q", header[:edfIDWidth])
+	}
+
+	first := int16(binary.LittleEndian.Uint16(encoded[headerSize:]))
+	second := int16(binary.LittleEndian.Uint16(encoded[headerSize+2:]))
+
+	if first != signalTestSample || second != edfDigitalMax {
+		t.Fatalf("edf samples got %d, %d", first, second)
+	}
+
+	_, err = encodeSignalEDF(testSignalID, signalBody{
+		Signal:            []int{signalTestSample},
+		SamplingFrequency: testDefaultInt,
+		WearPosition:      testDefaultInt,
+	})
+	if !errors.Is(err, errSignalFrequency) {
+		t.Fatalf(signalTestErrFmt, err, errSignalFrequency)
+	}
+}
+
+func signalTestOptions(format, output string) SignalOptions {
+	return SignalOptions{
+		SignalID: testDefaultInt64,
+		User:     params.User{UserID: testEmptyString},
+		Output:   output,
+		Format:   format,
+		Plot:     testEmptyString,
+	}
+}
+
+func signalTestBody() signalBody {
+	return signalBody{
+		Signal:            []int{signalTestSample, signalTestOverflow, 7},
+		SamplingFrequency: signalTestFrequency,
+		WearPosition:      testDefaultInt,
+	}
+}
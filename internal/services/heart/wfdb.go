@@ -0,0 +1,186 @@
+package heart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// WFDB records use format 16 (little-endian int16) with samples stored 1:1
+// in microvolts, i.e. a gain of 1000 ADC units per mV.
+const (
+	formatWFDB       = "wfdb"
+	wfdbHeaderExt    = ".hea"
+	wfdbSignalExt    = ".dat"
+	wfdbSignalFormat = "16"
+	wfdbGainPerMV    = "1000(0)/mV"
+	wfdbADCRes       = "16"
+	wfdbADCZero      = "0"
+	wfdbBlockSize    = "0"
+	wfdbChecksumMask = 0xffff
+)
+
+var (
+	errWFDBOutput = errors.New("--file-format wfdb requires --dest")
+	errWFDBRecord = errors.New(
+		"invalid WFDB record name (use letters, digits, or '_')",
+	)
+)
+
+//nolint:gochecknoglobals // Static pattern for WFDB record names.
+var wfdbRecordPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// wfdbRecord resolves the header and signal paths for --dest, which may
+// name the record itself or either of its files.
+func wfdbRecord(path string) (string, string, string, error) {
+	if path == emptyString {
+		return emptyString, emptyString, emptyString, errWFDBOutput
+	}
+
+	base := path
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case wfdbHeaderExt, wfdbSignalExt:
+		base = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+
+	name := filepath.Base(base)
+	if !wfdbRecordPattern.MatchString(name) {
+		return emptyString, emptyString, emptyString, fmt.Errorf(
+			"%w: %q",
+			errWFDBRecord,
+			name,
+		)
+	}
+
+	return name, base + wfdbHeaderExt, base + wfdbSignalExt, nil
+}
+
+func encodeWFDBHeader(
+	record string,
+	signalID int64,
+	body signalBody,
+) ([]byte, error) {
+	if body.SamplingFrequency <= defaultInt {
+		return nil, app.NewExitError(app.ExitCodeFailure, errSignalFrequency)
+	}
+
+	var buffer bytes.Buffer
+
+	_, _ = fmt.Fprintf(
+		&buffer,
+		"%s 1 %d %d\n",
+		record,
+		body.SamplingFrequency,
+		len(body.Signal),
+	)
+
+	fields := []string{
+		record + wfdbSignalExt,
+		wfdbSignalFormat,
+		wfdbGainPerMV,
+		wfdbADCRes,
+		wfdbADCZero,
+		strconv.Itoa(wfdbInitialValue(body.Signal)),
+		strconv.Itoa(wfdbChecksum(body.Signal)),
+		wfdbBlockSize,
+		edfLabel,
+	}
+	buffer.WriteString(strings.Join(fields, " ") + "\n")
+
+	_, _ = fmt.Fprintf(&buffer, "# withings signalid %d\n", signalID)
+	_, _ = fmt.Fprintf(
+		&buffer,
+		"# withings wearposition %d\n",
+		body.WearPosition,
+	)
+
+	return buffer.Bytes(), nil
+}
+
+func encodeWFDBSignal(body signalBody) ([]byte, error) {
+	samples := make([]int16, len(body.Signal))
+	for index, sample := range body.Signal {
+		samples[index] = clampInt16(sample)
+	}
+
+	var buffer bytes.Buffer
+
+	err := binary.Write(&buffer, binary.LittleEndian, samples)
+	if err != nil {
+		return nil, fmt.Errorf("encode signal wfdb: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func wfdbInitialValue(samples []int) int {
+	if len(samples) == defaultInt {
+		return defaultInt
+	}
+
+	return int(clampInt16(samples[0]))
+}
+
+// wfdbChecksum is the 16-bit signed sum of the stored samples.
+func wfdbChecksum(samples []int) int {
+	sum := defaultInt
+	for _, sample := range samples {
+		sum += int(clampInt16(sample))
+	}
+
+	return int(int16(uint16(sum & wfdbChecksumMask))) //nolint:gosec // Wraps.
+}
+
+func writeSignalWFDB(
+	appOpts app.Options,
+	opts SignalOptions,
+	body signalBody,
+) error {
+	record, headerPath, signalPath, err := wfdbRecord(opts.Output)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	header, err := encodeWFDBHeader(record, opts.SignalID, body)
+	if err != nil {
+		return err
+	}
+
+	samples, err := encodeWFDBSignal(body)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(signalPath, samples, signalFileMode)
+	if err != nil {
+		return fmt.Errorf("write signal file: %w", err)
+	}
+
+	err = os.WriteFile(headerPath, header, signalFileMode)
+	if err != nil {
+		return fmt.Errorf("write signal header: %w", err)
+	}
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	return output.WriteLine(appOpts, fmt.Sprintf(
+		"saved signal %d (%d samples, %d Hz) to %s and %s",
+		opts.SignalID,
+		len(body.Signal),
+		body.SamplingFrequency,
+		headerPath,
+		signalPath,
+	))
+}
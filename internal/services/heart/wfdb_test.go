@@ -0,0 +1,124 @@
+//nolint:testpackage // test unexported helpers.
+package heart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	wfdbTestRecord = "ecg_1"
+	wfdbTestHeader = "ecg_1 1 2 3\n" +
+		"ecg_1.dat 16 1000(0)/mV 16 0 -120 32654 0 ECG\n" +
+		"# withings signalid 99\n" +
+		"# withings wearposition 0\n"
+)
+
+// TestWFDBRecord accepts the record name or either file.
+func TestWFDBRecord(t *testing.T) {
+	t.Parallel()
+
+	for _, path := range []string{
+		filepath.Join("out", wfdbTestRecord),
+		filepath.Join("out", wfdbTestRecord+".hea"),
+		filepath.Join("out", wfdbTestRecord+".DAT"),
+	} {
+		record, header, _, err := wfdbRecord(path)
+		if err != nil {
+			t.Fatalf("wfdbRecord(%q): %v", path, err)
+		}
+
+		if record != wfdbTestRecord ||
+			header != filepath.Join("out", wfdbTestRecord+".hea") {
+			t.Fatalf("record got %q, %q for %q", record, header, path)
+		}
+	}
+
+	_, _, _, err := wfdbRecord(testEmptyString)
+	if !errors.Is(err, errWFDBOutput) {
+		t.Fatalf(signalTestErrFmt, err, errWFDBOutput)
+	}
+
+	_, _, _, err = wfdbRecord("ecg-1.hea")
+	if !errors.Is(err, errWFDBRecord) {
+		t.Fatalf(signalTestErrFmt, err, errWFDBRecord)
+	}
+}
+
+// TestEncodeWFDBHeader writes the record and signal specification lines.
+func TestEncodeWFDBHeader(t *testing.T) {
+	t.Parallel()
+
+	header, err := encodeWFDBHeader(
+		wfdbTestRecord,
+		testSignalID,
+		signalTestBody(),
+	)
+	if err != nil {
+		t.Fatalf("encodeWFDBHeader: %v", err)
+	}
+
+	if string(header) != wfdbTestHeader {
+		t.Fatalf("header got %q want %q", header, wfdbTestHeader)
+	}
+}
+
+// TestWriteSignalWFDB writes format 16 samples next to the header.
+func TestWriteSignalWFDB(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	opts := signalTestOptions(formatWFDB, filepath.Join(dir, wfdbTestRecord))
+	opts.SignalID = testSignalID
+
+	var appOpts app.Options
+
+	appOpts.Quiet = true
+
+	err := writeSignalWFDB(appOpts, opts, signalTestBody())
+	if err != nil {
+		t.Fatalf("writeSignalWFDB: %v", err)
+	}
+
+	samples, err := os.ReadFile(filepath.Join(dir, wfdbTestRecord+".dat"))
+	if err != nil {
+		t.Fatalf("read signal: %v", err)
+	}
+
+	got := make([]int16, len(samples)/2)
+
+	err = binary.Read(bytes.NewReader(samples), binary.LittleEndian, got)
+	if err != nil {
+		t.Fatalf("decode signal: %v", err)
+	}
+
+	if len(got) != 3 || got[0] != signalTestSample || got[1] != edfDigitalMax {
+		t.Fatalf("samples got %v", got)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, wfdbTestRecord+".hea"))
+	if err != nil {
+		t.Fatalf("stat header: %v", err)
+	}
+}
+
+// TestResolveSignalFormatWFDB maps WFDB file extensions to wfdb.
+func TestResolveSignalFormatWFDB(t *testing.T) {
+	t.Parallel()
+
+	for _, output := range []string{"ecg.hea", "ecg.dat"} {
+		got, err := resolveSignalFormat(
+			signalTestOptions(testEmptyString, output),
+			false,
+		)
+		if err != nil || got != formatWFDB {
+			t.Fatalf(signalTestFormatFmt, got, formatWFDB)
+		}
+	}
+}
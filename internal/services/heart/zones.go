@@ -0,0 +1,377 @@
+package heart
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	measureServiceName  = "v2/measure"
+	measureServiceShort = "measure"
+	actionIntraday      = "getintradayactivity"
+	dataFieldsParam     = "data_fields"
+	heartRateField      = "heart_rate"
+	int64BitSize        = 64
+	zoneCount           = 5
+	// zoneFloorPercent is the lower bound of Z1; samples below it are
+	// not counted.
+	zoneFloorPercent = 50
+	zoneStepPercent  = 10
+	percentScale     = 100
+	// zonesDefaultWindow is the range before --end (or now) without
+	// --start.
+	zonesDefaultWindow = 7 * 24 * time.Hour
+	// intradayChunk is the longest range getintradayactivity returns.
+	intradayChunk = 24 * time.Hour
+	// maxSampleGap caps the time one sample counts for, so a watch that
+	// was taken off does not add hours to the zone of its last sample.
+	maxSampleGap  = 15 * time.Minute
+	dateLayout    = "2006-01-02"
+	zoneTableHead = "Date\tZ1\tZ2\tZ3\tZ4\tZ5"
+	zonePlainHead = "date\tz1\tz2\tz3\tz4\tz5"
+)
+
+var (
+	errMaxHRRequired = errors.New("--max-hr must be positive")
+	errZonesRange    = errors.New("--end must be after --start")
+)
+
+// ZonesOptions captures a heart-rate zone aggregation.
+type ZonesOptions struct {
+	TimeRange params.TimeRange
+	User      params.User
+	// MaxHR is the maximum heart rate the zones are percentages of.
+	MaxHR int
+	Now   func() time.Time
+}
+
+// zoneDay holds the seconds spent in each zone on one local date.
+type zoneDay struct {
+	Date    string
+	Seconds [zoneCount]int64
+}
+
+// heartRateSample is one intraday heart-rate reading in bpm.
+type heartRateSample struct {
+	Time int64
+	BPM  int
+}
+
+type intradayResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+	Error  string          `json:"error"`
+	Detail string          `json:"detail"`
+}
+
+type intradayBody struct {
+	Series json.RawMessage `json:"series"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type intradayValues struct {
+	HeartRate int `json:"heart_rate"`
+}
+
+// Zones fetches the intraday heart-rate series of the range and writes
+// the time spent per day in zones Z1 (50-60% of --max-hr) to Z5 (90%
+// and above).
+func Zones(
+	ctx context.Context,
+	opts ZonesOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	if opts.MaxHR <= defaultInt {
+		return app.NewExitError(app.ExitCodeUsage, errMaxHRRequired)
+	}
+
+	start, end, err := resolveZonesRange(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	samples, err := fetchHeartRate(ctx, opts.User, appOpts, accessToken,
+		start, end)
+	if err != nil {
+		return err
+	}
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	location := output.DisplayLocation(appOpts, emptyString)
+	days := bucketZones(samples, end, opts.MaxHR, location)
+
+	if appOpts.Plain || appOpts.NDJSON || appOpts.JSON {
+		return output.WritePlain(appOpts, zoneLines(zonePlainHead, days))
+	}
+
+	table, err := output.RenderTable(
+		appOpts,
+		zonePlainHead,
+		zoneLines(zoneTableHead, days),
+	)
+	if err != nil {
+		return fmt.Errorf("render heart zones table: %w", err)
+	}
+
+	return output.WriteLine(appOpts, table)
+}
+
+// resolveZonesRange defaults to the 7 days before --end (or now).
+func resolveZonesRange(opts ZonesOptions) (int64, int64, error) {
+	nowFunc := opts.Now
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	end := nowFunc().Unix()
+
+	if opts.TimeRange.End != emptyString {
+		parsed, err := filters.ParseEpoch(opts.TimeRange.End)
+		if err != nil {
+			return defaultInt64, defaultInt64, fmt.Errorf(
+				"%w: %w",
+				errs.ErrInvalidEndTime,
+				err,
+			)
+		}
+
+		end = parsed
+	}
+
+	start := end - int64(zonesDefaultWindow.Seconds())
+
+	if opts.TimeRange.Start != emptyString {
+		parsed, err := filters.ParseEpoch(opts.TimeRange.Start)
+		if err != nil {
+			return defaultInt64, defaultInt64, fmt.Errorf(
+				"%w: %w",
+				errs.ErrInvalidStartTime,
+				err,
+			)
+		}
+
+		start = parsed
+	}
+
+	if end <= start {
+		return defaultInt64, defaultInt64, errZonesRange
+	}
+
+	return start, end, nil
+}
+
+// fetchHeartRate reads the intraday heart rate between start and end in
+// windows of one day, the most getintradayactivity returns per call.
+func fetchHeartRate(
+	ctx context.Context,
+	user params.User,
+	appOpts app.Options,
+	accessToken string,
+	start int64,
+	end int64,
+) ([]heartRateSample, error) {
+	byEpoch := map[int64]int{}
+	chunk := int64(intradayChunk.Seconds())
+
+	for from := start; from < end; from += chunk {
+		values := url.Values{}
+		values.Set(startDateParam, strconv.FormatInt(from, numberBase10))
+		values.Set(
+			endDateParam,
+			strconv.FormatInt(min(from+chunk, end), numberBase10),
+		)
+		values.Set(dataFieldsParam, heartRateField)
+		applyUser(&values, user)
+
+		payload, err := callService(
+			ctx,
+			appOpts,
+			accessToken,
+			measureServiceForBase,
+			actionIntraday,
+			values,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeHeartRate(payload, start, end, byEpoch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	samples := make([]heartRateSample, defaultInt, len(byEpoch))
+	for _, epoch := range slices.Sorted(maps.Keys(byEpoch)) {
+		samples = append(samples, heartRateSample{
+			Time: epoch,
+			BPM:  byEpoch[epoch],
+		})
+	}
+
+	return samples, nil
+}
+
+// decodeHeartRate adds the non-zero readings of the epoch-keyed intraday
+// series between start and end to byEpoch. Withings returns an empty
+// array instead of an object when there is no data.
+func decodeHeartRate(
+	payload []byte,
+	start int64,
+	end int64,
+	byEpoch map[int64]int,
+) error {
+	var decoded intradayResponse
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return zonesDecodeError(err)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+
+	var body intradayBody
+
+	err = json.Unmarshal(decoded.Body, &body)
+	if err != nil {
+		return zonesDecodeError(err)
+	}
+
+	trimmed := bytes.TrimSpace(body.Series)
+	if len(trimmed) == defaultInt || trimmed[0] != '{' {
+		return nil
+	}
+
+	var series map[string]intradayValues
+
+	err = json.Unmarshal(trimmed, &series)
+	if err != nil {
+		return zonesDecodeError(err)
+	}
+
+	for key, values := range series {
+		epoch, err := strconv.ParseInt(key, numberBase10, int64BitSize)
+		if err != nil || values.HeartRate <= defaultInt ||
+			epoch < start || epoch > end {
+			continue
+		}
+
+		byEpoch[epoch] = values.HeartRate
+	}
+
+	return nil
+}
+
+func zonesDecodeError(err error) error {
+	return app.NewExitError(
+		app.ExitCodeFailure,
+		fmt.Errorf("decode api response: %w", err),
+	)
+}
+
+// bucketZones sums the time per local date and zone, oldest date first.
+// Each sample counts until the next one (the last until end), at most
+// maxSampleGap. Samples below Z1 are skipped.
+func bucketZones(
+	samples []heartRateSample,
+	end int64,
+	maxHR int,
+	location *time.Location,
+) []zoneDay {
+	byDate := map[string]*zoneDay{}
+
+	for index, sample := range samples {
+		zone, ok := heartZone(sample.BPM, maxHR)
+		if !ok {
+			continue
+		}
+
+		next := end
+		if index+1 < len(samples) {
+			next = samples[index+1].Time
+		}
+
+		date := time.Unix(sample.Time, defaultInt64).In(location).
+			Format(dateLayout)
+
+		day, found := byDate[date]
+		if !found {
+			day = &zoneDay{Date: date, Seconds: [zoneCount]int64{}}
+			byDate[date] = day
+		}
+
+		day.Seconds[zone] += min(next-sample.Time,
+			int64(maxSampleGap.Seconds()))
+	}
+
+	days := make([]zoneDay, defaultInt, len(byDate))
+	for _, date := range slices.Sorted(maps.Keys(byDate)) {
+		days = append(days, *byDate[date])
+	}
+
+	return days
+}
+
+// heartZone returns the zero-based zone of a heart rate: Z1 from 50% of
+// maxHR, one zone per further 10%, and Z5 from 90% up.
+func heartZone(heartRate, maxHR int) (int, bool) {
+	percent := heartRate * percentScale / maxHR
+	if heartRate <= defaultInt || percent < zoneFloorPercent {
+		return defaultInt, false
+	}
+
+	return min((percent-zoneFloorPercent)/zoneStepPercent, zoneCount-1), true
+}
+
+func measureServiceForBase(baseURL string) string {
+	trimmed := strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(trimmed, serviceV2Suffix) {
+		return measureServiceShort
+	}
+
+	return measureServiceName
+}
+
+func zoneLines(header string, days []zoneDay) []string {
+	lines := make([]string, defaultInt, len(days)+rowsHeaderCount)
+	lines = append(lines, header)
+
+	for _, day := range days {
+		cells := []string{day.Date}
+		for _, seconds := range day.Seconds {
+			cells = append(cells, strconv.FormatInt(seconds, numberBase10))
+		}
+
+		lines = append(lines, strings.Join(cells, "\t"))
+	}
+
+	return lines
+}
@@ -0,0 +1,115 @@
+//nolint:testpackage // test unexported helpers.
+package heart
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const testMaxHR = 185
+
+// TestHeartZone maps percentages of the max heart rate to zones.
+func TestHeartZone(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]int{93: 0, 111: 1, 140: 2, 150: 3, 167: 4, 200: 4}
+
+	for heartRate, want := range cases {
+		got, ok := heartZone(heartRate, testMaxHR)
+		if !ok || got != want {
+			t.Fatalf("%d bpm: got %d, %t want %d", heartRate, got, ok, want)
+		}
+	}
+
+	if _, ok := heartZone(92, testMaxHR); ok {
+		t.Fatal("expected 92 bpm below Z1")
+	}
+}
+
+// TestBucketZones weights each sample by the gap to the next one,
+// capped, and sums per date, oldest first.
+func TestBucketZones(t *testing.T) {
+	t.Parallel()
+
+	samples := []heartRateSample{
+		{Time: 1700000000, BPM: 170}, // 60 s until the next sample.
+		{Time: 1700000060, BPM: 175}, // 10 min gap.
+		{Time: 1700000660, BPM: 90},  // Below Z1.
+		{Time: 1700000700, BPM: 140}, // 88 min gap, capped at 15 min.
+		{Time: 1700006000, BPM: 120}, // Capped at 15 min.
+		{Time: 1700100000, BPM: 120}, // 100 s until end.
+	}
+
+	got := bucketZones(samples, 1700100100, testMaxHR, time.UTC)
+	want := []zoneDay{
+		{Date: "2023-11-14", Seconds: [zoneCount]int64{0, 900, 900, 0, 660}},
+		{Date: "2023-11-16", Seconds: [zoneCount]int64{0, 100, 0, 0, 0}},
+	}
+
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %+v want %+v", got, want)
+	}
+}
+
+// TestFetchHeartRate reads getintradayactivity one day at a time and
+// merges the series, dropping zero readings.
+func TestFetchHeartRate(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			_ = request.ParseForm()
+
+			calls = append(calls, request.URL.Path+" "+
+				request.FormValue("action")+" "+
+				request.FormValue(dataFieldsParam))
+
+			start := request.FormValue(startDateParam)
+			series := map[string]map[string]int{
+				start:      {"heart_rate": 120},
+				"86400999": {"heart_rate": 0},
+			}
+
+			_ = json.NewEncoder(writer).Encode(map[string]any{
+				"status": 0,
+				"body":   map[string]any{"series": series},
+			})
+		},
+	))
+	defer server.Close()
+
+	var appOpts app.Options
+
+	appOpts.BaseURL = server.URL
+
+	samples, err := fetchHeartRate(
+		t.Context(), params.User{UserID: ""}, appOpts, "token",
+		0, 2*86400+60,
+	)
+	if err != nil {
+		t.Fatalf("fetchHeartRate: %v", err)
+	}
+
+	if len(calls) != 3 || calls[0] != "/v2/measure getintradayactivity heart_rate" {
+		t.Fatalf("calls %q", calls)
+	}
+
+	want := []heartRateSample{
+		{Time: 0, BPM: 120},
+		{Time: 86400, BPM: 120},
+		{Time: 2 * 86400, BPM: 120},
+	}
+
+	if !slices.Equal(samples, want) {
+		t.Fatalf("got %+v want %+v", samples, want)
+	}
+}
@@ -0,0 +1,229 @@
+package measures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+// DefaultChunk is the backfill window length when --chunk is not set.
+const DefaultChunk = "90d"
+
+var errBackfillStart = errors.New("backfill requires --since (or --start)")
+
+// BackfillOptions captures a chunked measure backfill.
+type BackfillOptions struct {
+	TimeRange params.TimeRange
+	User      params.User
+	Types     string
+	Category  string
+	// Chunk is the window length, e.g. 90d.
+	Chunk string
+	// Guideline classifies blood pressure readings.
+	Guideline string
+}
+
+// window is one [Start, End] slice of a backfill range in epoch seconds.
+type window struct {
+	Start int64
+	End   int64
+}
+
+// Backfill fetches a long range as consecutive windows, following every
+// page of each, and drops measure groups already seen by grpid. With
+// --ndjson rows stream window by window; other formats are written once
+// the last window is in.
+func Backfill(
+	ctx context.Context,
+	opts BackfillOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	windows, err := backfillWindows(opts, time.Now())
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	var combined body
+
+	seen := map[int64]bool{}
+
+	for index, current := range windows {
+		fetched, err := fetchBody(
+			ctx,
+			windowOptions(opts, current),
+			appOpts,
+			accessToken,
+		)
+		if err != nil {
+			return err
+		}
+
+		fetched.MeasureGroups = newGroups(fetched.MeasureGroups, seen)
+
+		err = writeWindowProgress(appOpts, index, len(windows), current,
+			len(fetched.MeasureGroups))
+		if err != nil {
+			return err
+		}
+
+		if appOpts.NDJSON {
+			err = streamWindow(appOpts, fetched, opts.Guideline)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		combined = mergeWindow(combined, fetched)
+	}
+
+	if appOpts.NDJSON {
+		return nil
+	}
+
+	return writeBody(appOpts, combined, opts.Guideline)
+}
+
+// backfillWindows splits the --start/--end range (end defaults to now)
+// into consecutive windows of --chunk, oldest first.
+func backfillWindows(opts BackfillOptions, now time.Time) ([]window, error) {
+	if opts.TimeRange.Start == emptyString {
+		return nil, errBackfillStart
+	}
+
+	start, err := filters.ParseTimeAt(opts.TimeRange.Start, now)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errs.ErrInvalidStartTime, err)
+	}
+
+	end := now
+	if opts.TimeRange.End != emptyString {
+		end, err = filters.ParseTimeAt(opts.TimeRange.End, now)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errs.ErrInvalidEndTime, err)
+		}
+	}
+
+	chunk := opts.Chunk
+	if chunk == emptyString {
+		chunk = DefaultChunk
+	}
+
+	span, err := filters.ParseSpan(chunk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --chunk: %w", err)
+	}
+
+	step := int64(span / time.Second)
+	if step <= defaultInt64 {
+		return nil, fmt.Errorf(
+			"invalid --chunk: %w: %q",
+			errs.ErrInvalidSpan,
+			chunk,
+		)
+	}
+
+	windows := []window{}
+
+	for from := start.Unix(); from < end.Unix(); {
+		to := min(from+step, end.Unix())
+		windows = append(windows, window{Start: from, End: to})
+		from = to
+	}
+
+	return windows, nil
+}
+
+func windowOptions(opts BackfillOptions, current window) Options {
+	return Options{
+		TimeRange: params.TimeRange{
+			Start: strconv.FormatInt(current.Start, numberBase10),
+			End:   strconv.FormatInt(current.End, numberBase10),
+		},
+		Pagination:   params.Pagination{Limit: defaultInt, Offset: defaultInt},
+		User:         opts.User,
+		LastUpdate:   params.LastUpdate{LastUpdate: defaultInt64},
+		Types:        opts.Types,
+		Category:     opts.Category,
+		Sources:      emptyString,
+		Modes:        emptyString,
+		All:          true,
+		Distinct:     false,
+		Latest:       false,
+		RecordUpdate: nil,
+	}
+}
+
+// newGroups drops groups already returned by an earlier window (a group
+// dated on a window boundary is returned by both) and orders the rest
+// oldest first.
+func newGroups(groups []group, seen map[int64]bool) []group {
+	kept := dedupeGroups(groups, seen)
+
+	sort.SliceStable(kept, func(left, right int) bool {
+		return kept[left].Date < kept[right].Date
+	})
+
+	return kept
+}
+
+// streamWindow writes the rows of one window as NDJSON.
+func streamWindow(appOpts app.Options, fetched body, guideline string) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	location := output.DisplayLocation(appOpts, fetched.Timezone)
+
+	return writePlainOutput(
+		appOpts,
+		convertRows(buildRows(fetched, location, guideline), appOpts.Units),
+	)
+}
+
+func mergeWindow(combined body, fetched body) body {
+	combined.MeasureGroups = append(
+		combined.MeasureGroups,
+		fetched.MeasureGroups...,
+	)
+	combined.UpdateTime = max(combined.UpdateTime, fetched.UpdateTime)
+
+	if fetched.Timezone != emptyString {
+		combined.Timezone = fetched.Timezone
+	}
+
+	return combined
+}
+
+func writeWindowProgress(
+	appOpts app.Options,
+	index int,
+	total int,
+	current window,
+	groups int,
+) error {
+	err := output.WriteProgress(appOpts, fmt.Sprintf(
+		"window %d/%d %s..%s: %d groups",
+		index+1,
+		total,
+		time.Unix(current.Start, defaultInt64).UTC().Format(time.DateOnly),
+		time.Unix(current.End, defaultInt64).UTC().Format(time.DateOnly),
+		groups,
+	))
+	if err != nil {
+		return fmt.Errorf("write progress: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+// TestBackfillWindows splits a range into chunks and clamps the last one.
+func TestBackfillWindows(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	end := now.Unix()
+	day := int64(24 * time.Hour / time.Second)
+
+	windows, err := backfillWindows(BackfillOptions{
+		TimeRange: params.TimeRange{Start: "2024-01-01", End: emptyString},
+		User:      params.User{UserID: emptyString},
+		Types:     emptyString,
+		Category:  emptyString,
+		Chunk:     "30d",
+	}, now)
+	if err != nil {
+		t.Fatalf("backfillWindows: %v", err)
+	}
+
+	want := []window{
+		{Start: start, End: start + 30*day},
+		{Start: start + 30*day, End: end},
+	}
+	if len(windows) != len(want) {
+		t.Fatalf("got %v want %v", windows, want)
+	}
+
+	for index := range want {
+		if windows[index] != want[index] {
+			t.Fatalf(
+				"window %d got %v want %v",
+				index,
+				windows[index],
+				want[index],
+			)
+		}
+	}
+}
+
+// TestBackfillWindowsErrors rejects a missing start and a bad chunk.
+func TestBackfillWindowsErrors(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	opts := BackfillOptions{
+		TimeRange: params.TimeRange{Start: emptyString, End: emptyString},
+		User:      params.User{UserID: emptyString},
+		Types:     emptyString,
+		Category:  emptyString,
+		Chunk:     emptyString,
+	}
+
+	_, err := backfillWindows(opts, now)
+	if !errors.Is(err, errBackfillStart) {
+		t.Fatalf("missing start got %v", err)
+	}
+
+	opts.TimeRange.Start = "2024-01-01"
+	opts.Chunk = "0d"
+
+	_, err = backfillWindows(opts, now)
+	if err == nil {
+		t.Fatal("expected an error for a zero chunk")
+	}
+
+	opts.Chunk = "15251w"
+
+	_, err = backfillWindows(opts, now)
+	if !errors.Is(err, errs.ErrInvalidSpan) {
+		t.Fatalf("overflowing chunk got %v", err)
+	}
+}
+
+// TestNewGroups drops groups seen in an earlier window and sorts by date.
+func TestNewGroups(t *testing.T) {
+	t.Parallel()
+
+	seen := map[int64]bool{1: true}
+	groups := []group{
+		backfillGroup(3, 30),
+		backfillGroup(1, 10),
+		backfillGroup(2, 20),
+	}
+
+	kept := newGroups(groups, seen)
+	if len(kept) != 2 || kept[0].GroupID != 2 || kept[1].GroupID != 3 {
+		t.Fatalf("got %v", kept)
+	}
+
+	if !seen[2] || !seen[3] {
+		t.Fatalf("seen not updated: %v", seen)
+	}
+}
+
+func backfillGroup(groupID int64, date int64) group {
+	return group{
+		GroupID:  groupID,
+		Attrib:   defaultInt,
+		Date:     date,
+		Category: 1,
+		DeviceID: emptyString,
+		Measures: nil,
+	}
+}
@@ -0,0 +1,112 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	benchGroups    = 10000
+	benchStartDate = int64(1735689600)
+	benchInterval  = int64(3600)
+)
+
+// benchScaledValues mirrors the value/unit pairs of a scale and a blood
+// pressure monitor, plus the edge cases that pad or trim zeros.
+//
+//nolint:gochecknoglobals // Static benchmark fixture.
+var benchScaledValues = []struct {
+	value int64
+	unit  int
+}{
+	{value: 81400, unit: -3},
+	{value: 2215, unit: -2},
+	{value: 63120, unit: -3},
+	{value: 120, unit: 0},
+	{value: 5, unit: -3},
+	{value: -123, unit: -2},
+	{value: 1000, unit: -3},
+	{value: 123, unit: 2},
+}
+
+// benchPayload renders a getmeas response with groups weigh-ins, each
+// carrying weight, fat ratio, fat-free mass, and heart pulse.
+func benchPayload(groups int) []byte {
+	var builder strings.Builder
+
+	builder.WriteString(`{"status":0,"body":{"updatetime":1735689600,`)
+	builder.WriteString(`"timezone":"Europe/Berlin","more":0,"offset":0,`)
+	builder.WriteString(`"measuregrps":[`)
+
+	for index := range groups {
+		if index > 0 {
+			builder.WriteByte(',')
+		}
+
+		fmt.Fprintf(
+			&builder,
+			`{"grpid":%d,"attrib":0,"date":%d,"category":1,"measures":[`+
+				`{"type":1,"value":%d,"unit":-3},`+
+				`{"type":6,"value":%d,"unit":-3},`+
+				`{"type":5,"value":%d,"unit":-3},`+
+				`{"type":11,"value":%d,"unit":0,"fm":3}]}`,
+			index,
+			benchStartDate+int64(index)*benchInterval,
+			80000+index%3000,
+			21000+index%900,
+			63000+index%2000,
+			55+index%30,
+		)
+	}
+
+	builder.WriteString(`]}}`)
+
+	return []byte(builder.String())
+}
+
+func benchBody(b *testing.B) body {
+	b.Helper()
+
+	decoded, err := decodeResponse(benchPayload(benchGroups))
+	if err != nil {
+		b.Fatalf("decodeResponse: %v", err)
+	}
+
+	return decoded.Body
+}
+
+// BenchmarkFormatScaledValue measures the scaling math alone.
+func BenchmarkFormatScaledValue(b *testing.B) {
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for _, test := range benchScaledValues {
+			_ = formatScaledValue(test.value, test.unit)
+		}
+	}
+}
+
+// BenchmarkBuildRows measures turning decoded groups into rows.
+func BenchmarkBuildRows(b *testing.B) {
+	decoded := benchBody(b)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_ = buildRows(decoded, time.UTC, DefaultGuideline)
+	}
+}
+
+// BenchmarkFormatLines measures rendering rows as --plain lines.
+func BenchmarkFormatLines(b *testing.B) {
+	rows := buildRows(benchBody(b), time.UTC, DefaultGuideline)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_ = formatLines(rows)
+	}
+}
@@ -0,0 +1,116 @@
+package measures
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// GuidelineAHA classifies blood pressure per the 2017 ACC/AHA
+	// guideline.
+	GuidelineAHA = "aha"
+	// GuidelineESC classifies blood pressure per the ESC/ESH guideline.
+	GuidelineESC = "esc"
+	// DefaultGuideline is used when neither --guideline nor config set one.
+	DefaultGuideline = GuidelineAHA
+
+	typeBPDiastolic = 9
+	typeBPSystolic  = 10
+	bpNormal        = "normal"
+	bpElevated      = "elevated"
+	bpStage1        = "stage1"
+	bpStage2        = "stage2"
+)
+
+// ErrInvalidGuideline indicates an unknown blood pressure guideline.
+var ErrInvalidGuideline = errors.New(
+	"invalid blood pressure guideline (expected aha or esc)",
+)
+
+// bpLimit is the lowest systolic or diastolic value (mmHg) of a class.
+type bpLimit struct {
+	Class     string
+	Systolic  float64
+	Diastolic float64
+}
+
+// bpLimits lists each guideline's classes from the highest down; the
+// first class whose systolic or diastolic limit is reached wins. ESC's
+// high-normal maps to elevated, grade 1 to stage1, and grades 2 and 3
+// to stage2.
+//
+//nolint:gochecknoglobals,mnd // Static guideline thresholds in mmHg.
+var bpLimits = map[string][]bpLimit{
+	GuidelineAHA: {
+		{Class: bpStage2, Systolic: 140, Diastolic: 90},
+		{Class: bpStage1, Systolic: 130, Diastolic: 80},
+		{Class: bpElevated, Systolic: 120, Diastolic: 0},
+	},
+	GuidelineESC: {
+		{Class: bpStage2, Systolic: 160, Diastolic: 100},
+		{Class: bpStage1, Systolic: 140, Diastolic: 90},
+		{Class: bpElevated, Systolic: 130, Diastolic: 85},
+	},
+}
+
+// GuidelineChoices lists the values of --guideline.
+//
+//nolint:gochecknoglobals // Static completion list.
+var GuidelineChoices = []string{GuidelineAHA, GuidelineESC}
+
+// ParseGuideline normalizes a --guideline value; empty means the default.
+func ParseGuideline(value string) (string, error) {
+	guideline := strings.ToLower(strings.TrimSpace(value))
+	if guideline == emptyString {
+		return DefaultGuideline, nil
+	}
+
+	if _, ok := bpLimits[guideline]; !ok {
+		return emptyString, fmt.Errorf("%w: %q", ErrInvalidGuideline, value)
+	}
+
+	return guideline, nil
+}
+
+// classifyBP returns the class of a reading under the guideline.
+func classifyBP(systolic, diastolic float64, guideline string) string {
+	for _, limit := range bpLimits[guideline] {
+		if systolic >= limit.Systolic ||
+			(limit.Diastolic > 0 && diastolic >= limit.Diastolic) {
+			return limit.Class
+		}
+	}
+
+	return bpNormal
+}
+
+// groupClassification classifies a group holding both bp_sys and bp_dia;
+// other groups get an empty class.
+func groupClassification(measureGroup group, guideline string) string {
+	var (
+		systolic, diastolic       float64
+		hasSystolic, hasDiastolic bool
+	)
+
+	for _, measure := range measureGroup.Measures {
+		switch measure.Type {
+		case typeBPSystolic:
+			systolic = scaledFloat(measure.Value, measure.Unit)
+			hasSystolic = true
+		case typeBPDiastolic:
+			diastolic = scaledFloat(measure.Value, measure.Unit)
+			hasDiastolic = true
+		}
+	}
+
+	if !hasSystolic || !hasDiastolic {
+		return emptyString
+	}
+
+	if guideline == emptyString {
+		guideline = DefaultGuideline
+	}
+
+	return classifyBP(systolic, diastolic, guideline)
+}
@@ -0,0 +1,80 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestClassifyBP uses the higher class of systolic and diastolic.
+func TestClassifyBP(t *testing.T) {
+	t.Parallel()
+
+	type reading struct {
+		Systolic  float64
+		Diastolic float64
+		Guideline string
+		Want      string
+	}
+
+	aha, esc := GuidelineAHA, GuidelineESC
+	cases := []reading{
+		{118, 76, aha, "normal"},
+		{124, 78, aha, "elevated"},
+		{118, 82, aha, "stage1"},
+		{145, 85, aha, "stage2"},
+		{124, 82, esc, "normal"},
+		{128, 86, esc, "elevated"},
+		{145, 85, esc, "stage1"},
+		{150, 102, esc, "stage2"},
+	}
+
+	for _, test := range cases {
+		got := classifyBP(test.Systolic, test.Diastolic, test.Guideline)
+		if got != test.Want {
+			t.Fatalf("%+v: got %q", test, got)
+		}
+	}
+}
+
+// TestGroupClassification needs both readings in the group.
+func TestGroupClassification(t *testing.T) {
+	t.Parallel()
+
+	var reading group
+
+	reading.Measures = []item{
+		{Type: typeBPSystolic, Value: 1350, Unit: -1, FM: nil},
+		{Type: typeBPDiastolic, Value: 78, Unit: 0, FM: nil},
+	}
+
+	if got := groupClassification(reading, emptyString); got != "stage1" {
+		t.Fatalf("got %q want stage1", got)
+	}
+
+	reading.Measures = reading.Measures[:1]
+
+	if got := groupClassification(reading, GuidelineAHA); got != emptyString {
+		t.Fatalf("got %q want empty", got)
+	}
+}
+
+// TestParseGuideline defaults to AHA and rejects unknown names.
+func TestParseGuideline(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseGuideline(" ESC ")
+	if err != nil || got != GuidelineESC {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	got, err = ParseGuideline(emptyString)
+	if err != nil || got != GuidelineAHA {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	_, err = ParseGuideline("who")
+	if !errors.Is(err, ErrInvalidGuideline) {
+		t.Fatalf("got %v, want ErrInvalidGuideline", err)
+	}
+}
@@ -0,0 +1,193 @@
+package measures
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	cardioTypes       = "pulse_wave_velocity,vascular_age"
+	cardioCategory    = "real"
+	cardioTypePWV     = "91"
+	cardioTypeVascAge = "155"
+	pwvBorderline     = 8.0
+	pwvHigh           = 10.0
+	vascAgeBorderline = 0.0
+	vascAgeHigh       = 5.0
+	statusNormal      = "normal"
+	statusElevated    = "elevated"
+	statusHigh        = "high"
+	cardioTableHeader = "Time\tType\tValue\tUnit\tChange\tTrend\tStatus"
+	cardioPlainHeader = "time\ttype\tvalue\tunit\tchange\tstatus"
+)
+
+var errInvalidAge = errors.New("--age must not be negative")
+
+// CardioOptions captures cardiovascular view parameters.
+type CardioOptions struct {
+	Query Options
+	Age   int
+}
+
+// Cardio fetches pulse wave velocity and vascular age measures and writes
+// them oldest first with change, trend, and a reference-range status.
+func Cardio(
+	ctx context.Context,
+	opts CardioOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	if opts.Age < defaultInt {
+		return app.NewExitError(app.ExitCodeUsage, errInvalidAge)
+	}
+
+	query := opts.Query
+	query.Types = cardioTypes
+	query.Category = cardioCategory
+
+	filter, err := parseContextFilter(query)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	payload, err := fetchMeasures(ctx, query, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	decoded.Body = filterContext(decoded.Body, filter)
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		return writeJSONOutput(appOpts, decoded.Body)
+	}
+
+	rows := buildTrendRows(
+		decoded.Body,
+		appOpts.Units,
+		output.DisplayLocation(appOpts, decoded.Body.Timezone),
+	)
+
+	if appOpts.Plain || appOpts.NDJSON {
+		return writePlainCardio(appOpts, rows, opts.Age)
+	}
+
+	return writeCardioTable(appOpts, rows, opts.Age)
+}
+
+// cardioStatus classifies a value against reference ranges. PWV uses the
+// 10 m/s threshold for arterial stiffness; vascular age is compared with
+// the chronological age when one is provided.
+func cardioStatus(typeID string, value float64, age int) string {
+	switch typeID {
+	case cardioTypePWV:
+		return classify(value, pwvBorderline, pwvHigh)
+	case cardioTypeVascAge:
+		if age == defaultInt {
+			return emptyString
+		}
+
+		return classify(value-float64(age), vascAgeBorderline, vascAgeHigh)
+	default:
+		return emptyString
+	}
+}
+
+func classify(value, borderline, high float64) string {
+	switch {
+	case value >= high:
+		return statusHigh
+	case value > borderline:
+		return statusElevated
+	default:
+		return statusNormal
+	}
+}
+
+func statusColor(status string) output.Color {
+	switch status {
+	case statusHigh:
+		return output.ColorRed
+	case statusElevated:
+		return output.ColorYellow
+	default:
+		return output.ColorGreen
+	}
+}
+
+func writePlainCardio(
+	appOpts app.Options,
+	rows []trendRow,
+	age int,
+) error {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, cardioPlainHeader)
+
+	for _, entry := range rows {
+		lines = append(lines, strings.Join([]string{
+			entry.Row.Time,
+			entry.Row.Type,
+			entry.Row.Value,
+			entry.Row.Unit,
+			entry.Change,
+			cardioStatus(entry.TypeID, entry.Value, age),
+		}, "\t"))
+	}
+
+	err := output.WritePlain(appOpts, lines)
+	if err != nil {
+		return fmt.Errorf("write plain output: %w", err)
+	}
+
+	return nil
+}
+
+func writeCardioTable(appOpts app.Options, rows []trendRow, age int) error {
+	color := output.ColorEnabled(appOpts)
+
+	var buffer bytes.Buffer
+
+	writer := newTableWriter(&buffer)
+	_, _ = fmt.Fprintln(writer, cardioTableHeader)
+
+	for _, entry := range rows {
+		status := cardioStatus(entry.TypeID, entry.Value, age)
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Row.Time,
+			entry.Row.Type,
+			entry.Row.Value,
+			entry.Row.Unit,
+			entry.Change,
+			entry.Bar,
+			output.Colorize(color, statusColor(status), status),
+		)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("render cardio table: %w", err)
+	}
+
+	err = output.WriteLine(appOpts, strings.TrimRight(buffer.String(), "\n"))
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import "testing"
+
+const (
+	cardioTestAge       = 45
+	cardioTestNoAge     = 0
+	cardioTestPWVNormal = 7.2
+	cardioTestPWVMid    = 8.6
+	cardioTestPWVHigh   = 10.4
+	cardioTestVascYoung = 41
+	cardioTestVascMid   = 48
+	cardioTestVascOld   = 52
+	cardioTestOtherType = "123"
+)
+
+// TestCardioStatusPWV classifies pulse wave velocity thresholds.
+func TestCardioStatusPWV(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		value float64
+		want  string
+	}{
+		{value: cardioTestPWVNormal, want: statusNormal},
+		{value: pwvBorderline, want: statusNormal},
+		{value: cardioTestPWVMid, want: statusElevated},
+		{value: pwvHigh, want: statusHigh},
+		{value: cardioTestPWVHigh, want: statusHigh},
+	}
+
+	for _, test := range cases {
+		got := cardioStatus(cardioTypePWV, test.value, cardioTestNoAge)
+		assertTrend(t, "pwv status", got, test.want)
+	}
+}
+
+// TestCardioStatusVascularAge compares vascular and chronological age.
+func TestCardioStatusVascularAge(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		value float64
+		want  string
+	}{
+		{value: cardioTestVascYoung, want: statusNormal},
+		{value: cardioTestAge, want: statusNormal},
+		{value: cardioTestVascMid, want: statusElevated},
+		{value: cardioTestVascOld, want: statusHigh},
+	}
+
+	for _, test := range cases {
+		got := cardioStatus(cardioTypeVascAge, test.value, cardioTestAge)
+		assertTrend(t, "vascular age status", got, test.want)
+	}
+
+	got := cardioStatus(cardioTypeVascAge, cardioTestVascOld, cardioTestNoAge)
+	assertTrend(t, "vascular age without age", got, emptyString)
+
+	got = cardioStatus(cardioTestOtherType, cardioTestPWVHigh, cardioTestAge)
+	assertTrend(t, "other type", got, emptyString)
+}
@@ -0,0 +1,58 @@
+package measures
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// TypeChoices lists the accepted --type names and their meastype IDs.
+func TypeChoices() []output.Choice {
+	choices := make([]output.Choice, defaultInt, len(typeMap))
+	for name, id := range typeMap {
+		choices = append(choices, output.Choice{Name: name, Value: id})
+	}
+
+	return choices
+}
+
+// CategoryChoices lists the accepted --category names.
+func CategoryChoices() []output.Choice {
+	return []output.Choice{
+		{Name: categoryRealText, Value: categoryReal},
+		{Name: categoryGoalText, Value: categoryGoal},
+	}
+}
+
+// SourceChoices lists the accepted --source names and their attrib IDs.
+func SourceChoices() []output.Choice {
+	choices := make([]output.Choice, defaultInt, len(sourceAttribsByName))
+
+	for name, attribs := range sourceAttribsByName {
+		ids := make([]string, defaultInt, len(attribs))
+		for _, attrib := range attribs {
+			ids = append(ids, strconv.Itoa(attrib))
+		}
+
+		choices = append(choices, output.Choice{
+			Name:  name,
+			Value: strings.Join(ids, typeDelimiter),
+		})
+	}
+
+	return choices
+}
+
+// ModeChoices lists the accepted --mode names and their fm values.
+func ModeChoices() []output.Choice {
+	choices := make([]output.Choice, defaultInt, len(modeNameByFM))
+	for mode, name := range modeNameByFM {
+		choices = append(choices, output.Choice{
+			Name:  name,
+			Value: strconv.Itoa(mode),
+		})
+	}
+
+	return choices
+}
@@ -0,0 +1,205 @@
+package measures
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	attribDeviceShared = 8
+	modePrefix         = "fm"
+	modeStandard       = 3
+	modePregnancy      = 4
+	modeAthlete        = 5
+)
+
+var (
+	errInvalidSource = errors.New("invalid measure source")
+	errInvalidMode   = errors.New("invalid body-composition mode")
+)
+
+//nolint:gochecknoglobals // Static lookup tables for measure group context.
+var (
+	sourceNameByAttrib = map[int]string{
+		0:                  "device",
+		1:                  "ambiguous",
+		2:                  "manual",
+		4:                  "manual_creation",
+		5:                  "auto",
+		7:                  "confirmed",
+		attribDeviceShared: "device",
+	}
+	modeNameByFM = map[int]string{
+		modeStandard:  "standard",
+		modePregnancy: "pregnancy",
+		modeAthlete:   "athlete",
+	}
+	sourceAttribsByName = map[string][]int{
+		"device":          {0, attribDeviceShared},
+		"ambiguous":       {1},
+		"manual":          {2},
+		"manual_creation": {4},
+		"auto":            {5},
+		"confirmed":       {7},
+	}
+)
+
+// contextFilter restricts measure groups by source (attrib) and measures by
+// body-composition mode (fm).
+type contextFilter struct {
+	Sources map[int]bool
+	Modes   map[int]bool
+}
+
+func parseContextFilter(opts Options) (contextFilter, error) {
+	sources, err := parseSources(opts.Sources)
+	if err != nil {
+		return contextFilter{}, err
+	}
+
+	modes, err := parseModes(opts.Modes)
+	if err != nil {
+		return contextFilter{}, err
+	}
+
+	return contextFilter{Sources: sources, Modes: modes}, nil
+}
+
+func parseSources(raw string) (map[int]bool, error) {
+	sources := map[int]bool{}
+
+	for _, value := range splitList(raw) {
+		if isDigits(value) {
+			attrib, _ := strconv.Atoi(value)
+			sources[attrib] = true
+
+			continue
+		}
+
+		attribs, ok := sourceAttribsByName[value]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errInvalidSource, value)
+		}
+
+		for _, attrib := range attribs {
+			sources[attrib] = true
+		}
+	}
+
+	return sources, nil
+}
+
+// parseModes accepts mode names (standard, athlete, pregnancy) and raw fm
+// values, with or without the fm prefix.
+func parseModes(raw string) (map[int]bool, error) {
+	modes := map[int]bool{}
+
+	for _, value := range splitList(raw) {
+		if mode, ok := modeByName(value); ok {
+			modes[mode] = true
+
+			continue
+		}
+
+		trimmed := strings.TrimPrefix(value, modePrefix)
+		if !isDigits(trimmed) {
+			return nil, fmt.Errorf("%w: %q", errInvalidMode, value)
+		}
+
+		mode, _ := strconv.Atoi(trimmed)
+		modes[mode] = true
+	}
+
+	return modes, nil
+}
+
+func modeByName(name string) (int, bool) {
+	for mode, known := range modeNameByFM {
+		if known == name {
+			return mode, true
+		}
+	}
+
+	return defaultInt, false
+}
+
+func splitList(raw string) []string {
+	parts := strings.Split(raw, typeDelimiter)
+	values := make([]string, defaultInt, len(parts))
+
+	for _, part := range parts {
+		trimmed := strings.ToLower(strings.TrimSpace(part))
+		if trimmed != emptyString {
+			values = append(values, trimmed)
+		}
+	}
+
+	return values
+}
+
+// filterContext drops groups from other sources and measures taken in other
+// modes; groups left without measures are removed.
+func filterContext(body body, filter contextFilter) body {
+	if len(filter.Sources) == defaultInt && len(filter.Modes) == defaultInt {
+		return body
+	}
+
+	groups := make([]group, defaultInt, len(body.MeasureGroups))
+
+	for _, measureGroup := range body.MeasureGroups {
+		if len(filter.Sources) > defaultInt &&
+			!filter.Sources[measureGroup.Attrib] {
+			continue
+		}
+
+		measureGroup.Measures = filterModes(measureGroup.Measures, filter)
+		if len(measureGroup.Measures) == defaultInt {
+			continue
+		}
+
+		groups = append(groups, measureGroup)
+	}
+
+	body.MeasureGroups = groups
+
+	return body
+}
+
+func filterModes(items []item, filter contextFilter) []item {
+	if len(filter.Modes) == defaultInt {
+		return items
+	}
+
+	kept := make([]item, defaultInt, len(items))
+
+	for _, measure := range items {
+		if measure.FM != nil && filter.Modes[*measure.FM] {
+			kept = append(kept, measure)
+		}
+	}
+
+	return kept
+}
+
+func formatSource(attrib int) string {
+	if name, ok := sourceNameByAttrib[attrib]; ok {
+		return name
+	}
+
+	return strconv.Itoa(attrib)
+}
+
+// formatMode names a known fm value and falls back to the number.
+func formatMode(mode *int) string {
+	if mode == nil {
+		return emptyString
+	}
+
+	if name, ok := modeNameByFM[*mode]; ok {
+		return name
+	}
+
+	return strconv.Itoa(*mode)
+}
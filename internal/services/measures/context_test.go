@@ -0,0 +1,158 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	contextTestStandard = modeStandard
+	contextTestAthlete  = modeAthlete
+	contextTestManual   = 2
+	contextTestErrFmt   = "err got %v want %v"
+)
+
+// TestParseContextFilter resolves source names, mode names, and mode
+// values.
+func TestParseContextFilter(t *testing.T) {
+	t.Parallel()
+
+	filter, err := parseContextFilter(
+		contextTestOptions("Device, 2", "fm3,Athlete,4"),
+	)
+	if err != nil {
+		t.Fatalf("parseContextFilter: %v", err)
+	}
+
+	for _, attrib := range []int{0, attribDeviceShared, contextTestManual} {
+		if !filter.Sources[attrib] {
+			t.Fatalf("source %d missing from %v", attrib, filter.Sources)
+		}
+	}
+
+	if !filter.Modes[contextTestStandard] || !filter.Modes[contextTestAthlete] ||
+		!filter.Modes[modePregnancy] {
+		t.Fatalf("modes got %v", filter.Modes)
+	}
+
+	_, err = parseContextFilter(contextTestOptions("scale", testEmptyString))
+	if !errors.Is(err, errInvalidSource) {
+		t.Fatalf(contextTestErrFmt, err, errInvalidSource)
+	}
+
+	_, err = parseContextFilter(contextTestOptions(testEmptyString, "sprint"))
+	if !errors.Is(err, errInvalidMode) {
+		t.Fatalf(contextTestErrFmt, err, errInvalidMode)
+	}
+}
+
+// TestFilterContext drops other sources, other modes, and empty groups.
+func TestFilterContext(t *testing.T) {
+	t.Parallel()
+
+	standard := contextTestStandard
+	athlete := contextTestAthlete
+	input := testBody()
+	input.MeasureGroups[0].Measures = []item{
+		contextTestItem(&standard),
+		contextTestItem(&athlete),
+		contextTestItem(nil),
+	}
+	manual := input.MeasureGroups[0]
+	manual.Attrib = contextTestManual
+	input.MeasureGroups = append(input.MeasureGroups, manual)
+
+	filtered := filterContext(input, contextFilter{
+		Sources: map[int]bool{0: true},
+		Modes:   map[int]bool{contextTestAthlete: true},
+	})
+
+	if len(filtered.MeasureGroups) != 1 {
+		t.Fatalf("groups got %d want 1", len(filtered.MeasureGroups))
+	}
+
+	rows := buildRows(filtered, time.UTC, DefaultGuideline)
+	if len(rows) != 1 {
+		t.Fatalf("rows got %d want 1", len(rows))
+	}
+
+	assertMeasureValue(t, "source", rows[0].Source, "device")
+	assertMeasureValue(t, "mode", rows[0].Mode, "athlete")
+
+	unfiltered := filterContext(input, contextFilter{
+		Sources: map[int]bool{},
+		Modes:   map[int]bool{},
+	})
+	if len(unfiltered.MeasureGroups) != len(input.MeasureGroups) {
+		t.Fatalf("unfiltered groups got %d", len(unfiltered.MeasureGroups))
+	}
+}
+
+// TestFormatMode names known fm values in rows and JSON and falls back
+// to the number.
+func TestFormatMode(t *testing.T) {
+	t.Parallel()
+
+	unknown := 99
+	assertMeasureValue(t, "unknown", formatMode(&unknown), "99")
+	assertMeasureValue(t, "missing", formatMode(nil), testEmptyString)
+
+	standard := contextTestStandard
+
+	data, err := json.Marshal(contextTestItem(&standard))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	if !strings.Contains(string(data), `"fm":3,"mode":"standard"`) {
+		t.Fatalf("json got %s", data)
+	}
+
+	data, _ = json.Marshal(contextTestItem(nil))
+	if strings.Contains(string(data), `"mode"`) {
+		t.Fatalf("json without fm got %s", data)
+	}
+}
+
+// TestFormatSource labels known attrib values and falls back to IDs.
+func TestFormatSource(t *testing.T) {
+	t.Parallel()
+
+	assertMeasureValue(t, "manual", formatSource(contextTestManual), "manual")
+	assertMeasureValue(t, "unknown", formatSource(99), "99")
+}
+
+func contextTestOptions(sources, modes string) Options {
+	return Options{
+		TimeRange: params.TimeRange{
+			Start: testEmptyString,
+			End:   testEmptyString,
+		},
+		Pagination: params.Pagination{
+			Limit:  testDefaultInt,
+			Offset: testDefaultInt,
+		},
+		User:         params.User{UserID: testEmptyString},
+		LastUpdate:   params.LastUpdate{LastUpdate: testDefaultInt64},
+		Types:        testEmptyString,
+		Category:     testEmptyString,
+		Sources:      sources,
+		Modes:        modes,
+		RecordUpdate: nil,
+	}
+}
+
+func contextTestItem(mode *int) item {
+	return item{
+		Type:  testMeasureType,
+		Value: testMeasureValue,
+		Unit:  testMeasureUnit,
+		FM:    mode,
+	}
+}
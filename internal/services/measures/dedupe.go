@@ -0,0 +1,103 @@
+package measures
+
+import (
+	"cmp"
+	"slices"
+)
+
+// distinctWindow is how far apart, in seconds, two equal measurements may
+// be dated and still count as one reading synced twice (e.g. by the
+// device and by the app).
+const distinctWindow = 60
+
+// dedupeGroups drops groups whose grpid is in seen and records the rest.
+// Overlapping pages or ranges return the same group more than once.
+// Groups without a grpid are always kept.
+func dedupeGroups(groups []group, seen map[int64]bool) []group {
+	kept := make([]group, defaultInt, len(groups))
+
+	for _, measureGroup := range groups {
+		if seen[measureGroup.GroupID] {
+			continue
+		}
+
+		if measureGroup.GroupID != defaultInt64 {
+			seen[measureGroup.GroupID] = true
+		}
+
+		kept = append(kept, measureGroup)
+	}
+
+	return kept
+}
+
+// distinctKey identifies a measurement regardless of the group carrying it.
+type distinctKey struct {
+	Category int
+	Type     int
+	Value    string
+}
+
+// distinctGroups collapses measurements of the same type, category, and
+// value dated within distinctWindow of each other into the earliest one,
+// preferring device readings (lower attrib) on a tie. Groups left without
+// measures are dropped; the order of the rest is kept.
+func distinctGroups(groups []group) []group {
+	order := make([]int, len(groups))
+	for index := range order {
+		order[index] = index
+	}
+
+	slices.SortStableFunc(order, func(left, right int) int {
+		return cmp.Or(
+			cmp.Compare(groups[left].Date, groups[right].Date),
+			cmp.Compare(groups[left].Attrib, groups[right].Attrib),
+		)
+	})
+
+	lastSeen := map[distinctKey]int64{}
+	dropped := make([][]bool, len(groups))
+
+	for _, index := range order {
+		measureGroup := groups[index]
+		dropped[index] = make([]bool, len(measureGroup.Measures))
+
+		for position, measure := range measureGroup.Measures {
+			key := distinctKey{
+				Category: measureGroup.Category,
+				Type:     measure.Type,
+				Value:    formatScaledValue(measure.Value, measure.Unit),
+			}
+
+			date, ok := lastSeen[key]
+			if ok && measureGroup.Date-date <= distinctWindow {
+				dropped[index][position] = true
+
+				continue
+			}
+
+			lastSeen[key] = measureGroup.Date
+		}
+	}
+
+	kept := make([]group, defaultInt, len(groups))
+
+	for index, measureGroup := range groups {
+		measures := make([]item, defaultInt, len(measureGroup.Measures))
+
+		for position, measure := range measureGroup.Measures {
+			if !dropped[index][position] {
+				measures = append(measures, measure)
+			}
+		}
+
+		if len(measures) == defaultInt {
+			continue
+		}
+
+		measureGroup.Measures = measures
+		kept = append(kept, measureGroup)
+	}
+
+	return kept
+}
@@ -0,0 +1,74 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import "testing"
+
+const typeFat = 8
+
+// TestDedupeGroups drops repeated grpids but keeps groups without one.
+func TestDedupeGroups(t *testing.T) {
+	t.Parallel()
+
+	seen := map[int64]bool{}
+	first := dedupeGroups([]group{
+		dedupeGroup(1, 10, 0, weightItem(80000)),
+		dedupeGroup(0, 10, 0, weightItem(80000)),
+	}, seen)
+	second := dedupeGroups([]group{
+		dedupeGroup(1, 10, 0, weightItem(80000)),
+		dedupeGroup(2, 20, 0, weightItem(81000)),
+		dedupeGroup(0, 10, 0, weightItem(80000)),
+	}, seen)
+
+	if len(first) != 2 || len(second) != 2 || second[0].GroupID != 2 {
+		t.Fatalf("got %v then %v", first, second)
+	}
+}
+
+// TestDistinctGroups collapses an app copy of a device reading.
+func TestDistinctGroups(t *testing.T) {
+	t.Parallel()
+
+	groups := []group{
+		dedupeGroup(3, 130, attribManual, weightItem(80000), fatItem(2100)),
+		dedupeGroup(2, 100, 0, weightItem(80000), fatItem(2000)),
+		dedupeGroup(4, 100, attribManual, weightItem(80000)),
+		dedupeGroup(5, 400, 0, weightItem(80000)),
+	}
+
+	kept := distinctGroups(groups)
+
+	want := []int64{3, 2, 5}
+	if len(kept) != len(want) {
+		t.Fatalf("got %v want grpids %v", kept, want)
+	}
+
+	for index, groupID := range want {
+		if kept[index].GroupID != groupID {
+			t.Fatalf("got %v want grpids %v", kept, want)
+		}
+	}
+
+	if len(kept[0].Measures) != 1 || kept[0].Measures[0].Type != typeFat {
+		t.Fatalf("group 3 kept %v", kept[0].Measures)
+	}
+}
+
+func dedupeGroup(groupID int64, date int64, attrib int, items ...item) group {
+	return group{
+		GroupID:  groupID,
+		Attrib:   attrib,
+		Date:     date,
+		Category: 1,
+		DeviceID: emptyString,
+		Measures: items,
+	}
+}
+
+func weightItem(grams int64) item {
+	return item{Type: typeWeight, Value: grams, Unit: -3, FM: nil}
+}
+
+func fatItem(grams int64) item {
+	return item{Type: typeFat, Value: grams, Unit: -3, FM: nil}
+}
@@ -0,0 +1,111 @@
+package measures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	// TypeHeight is the Withings measure type of body height in m.
+	TypeHeight = 4
+
+	heightUnit = "m"
+)
+
+var errHeightNotFound = errors.New("no height recorded")
+
+// HeightOptions captures height get parameters.
+type HeightOptions struct {
+	User params.User
+}
+
+// Height is the latest recorded body height.
+type Height struct {
+	Height float64 `json:"height"`
+	Unit   string  `json:"unit"`
+	Date   int64   `json:"date"`
+}
+
+// GetHeight fetches every height measure and writes the most recent one.
+func GetHeight(
+	ctx context.Context,
+	opts HeightOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	fetched, err := fetchBody(ctx, Options{
+		TimeRange:    params.TimeRange{Start: emptyString, End: emptyString},
+		Pagination:   params.Pagination{Limit: defaultInt, Offset: defaultInt},
+		User:         opts.User,
+		LastUpdate:   params.LastUpdate{LastUpdate: defaultInt64},
+		Types:        strconv.Itoa(TypeHeight),
+		Category:     categoryRealText,
+		Sources:      emptyString,
+		Modes:        emptyString,
+		All:          true,
+		Distinct:     false,
+		Latest:       false,
+		RecordUpdate: nil,
+	}, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	height, ok := latestHeight(fetched)
+	if !ok {
+		return app.NewExitError(app.ExitCodeFailure, errHeightNotFound)
+	}
+
+	if appOpts.JSON {
+		return output.WriteOutput(appOpts, height)
+	}
+
+	location := output.DisplayLocation(appOpts, fetched.Timezone)
+
+	return output.WriteOutput(appOpts, fmt.Sprintf(
+		"%s %s (%s)",
+		strconv.FormatFloat(height.Height, 'f', -1, trendFloatBits),
+		height.Unit,
+		formatTime(height.Date, location),
+	))
+}
+
+// latestHeight returns the most recent height measure in fetched.
+func latestHeight(fetched body) (Height, bool) {
+	var (
+		latest Height
+		found  bool
+	)
+
+	for _, measureGroup := range fetched.MeasureGroups {
+		for _, measure := range measureGroup.Measures {
+			if measure.Type != TypeHeight ||
+				found && measureGroup.Date < latest.Date {
+				continue
+			}
+
+			parsed, err := strconv.ParseFloat(
+				formatScaledValue(measure.Value, measure.Unit),
+				trendFloatBits,
+			)
+			if err != nil {
+				continue
+			}
+
+			latest = Height{
+				Height: parsed,
+				Unit:   heightUnit,
+				Date:   measureGroup.Date,
+			}
+			found = true
+		}
+	}
+
+	return latest, found
+}
@@ -0,0 +1,46 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"testing"
+)
+
+// TestLatestHeight picks the most recent height and skips other types.
+func TestLatestHeight(t *testing.T) {
+	t.Parallel()
+
+	fetched := body{
+		UpdateTime: testDefaultInt64,
+		Timezone:   "UTC",
+		MeasureGroups: []group{
+			heightGroup(200, TypeHeight, 178, -2),
+			heightGroup(300, sourcesTestWeight, 80, 0),
+			heightGroup(100, TypeHeight, 1750, -3),
+		},
+		More:   false,
+		Offset: testDefaultInt,
+	}
+
+	got, ok := latestHeight(fetched)
+	if !ok || got.Height != 1.78 || got.Unit != "m" || got.Date != 200 {
+		t.Fatalf("got %+v, %t", got, ok)
+	}
+
+	fetched.MeasureGroups = fetched.MeasureGroups[1:2]
+
+	_, ok = latestHeight(fetched)
+	if ok {
+		t.Fatal("found a height without height measures")
+	}
+}
+
+func heightGroup(date int64, typeID int, value int64, unit int) group {
+	return group{
+		GroupID:  testDefaultInt64,
+		Attrib:   testDefaultInt,
+		Date:     date,
+		Category: testMeasureCategory,
+		DeviceID: testEmptyString,
+		Measures: []item{{Type: typeID, Value: value, Unit: unit, FM: nil}},
+	}
+}
@@ -0,0 +1,82 @@
+package measures
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// latestGroups keeps, for each measure type, only the measurement of the
+// most recent group carrying it (the higher grpid wins a tie). Groups
+// keep just the measures they are the latest for and are returned newest
+// first, since the API does not promise an order.
+func latestGroups(groups []group) []group {
+	latest := map[int]int{}
+
+	for index, measureGroup := range groups {
+		for _, measure := range measureGroup.Measures {
+			current, ok := latest[measure.Type]
+			if !ok || newerGroup(measureGroup, groups[current]) {
+				latest[measure.Type] = index
+			}
+		}
+	}
+
+	kept := make([]group, defaultInt, len(latest))
+
+	for index, measureGroup := range groups {
+		var measures []item
+
+		for _, measure := range measureGroup.Measures {
+			if latest[measure.Type] == index {
+				measures = append(measures, measure)
+			}
+		}
+
+		if len(measures) == defaultInt {
+			continue
+		}
+
+		measureGroup.Measures = measures
+		kept = append(kept, measureGroup)
+	}
+
+	slices.SortStableFunc(kept, func(left, right group) int {
+		return cmp.Compare(right.Date, left.Date)
+	})
+
+	return kept
+}
+
+func newerGroup(candidate, current group) bool {
+	if candidate.Date != current.Date {
+		return candidate.Date > current.Date
+	}
+
+	return candidate.GroupID > current.GroupID
+}
+
+// writeLatestValues prints only the values, one per line, for
+// `--latest --quiet --plain` in status bars and prompts.
+func writeLatestValues(opts app.Options, fetched body) error {
+	location := output.DisplayLocation(opts, fetched.Timezone)
+	rows := convertRows(buildRows(fetched, location, emptyString), opts.Units)
+	lines := make([]string, defaultInt, len(rows))
+
+	for _, measureRow := range rows {
+		lines = append(lines, measureRow.Value)
+	}
+
+	err := output.WriteLines(
+		opts,
+		output.RedactCells(opts, whereValueColumn, lines),
+	)
+	if err != nil {
+		return fmt.Errorf("write latest values: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import "testing"
+
+// TestLatestGroups keeps the newest measurement of each type.
+func TestLatestGroups(t *testing.T) {
+	t.Parallel()
+
+	latest := latestGroups([]group{
+		dedupeGroup(1, 10, 0, weightItem(80000), fatItem(20000)),
+		dedupeGroup(3, 30, 0, weightItem(81400)),
+		dedupeGroup(2, 20, 0, weightItem(80500), fatItem(19500)),
+		dedupeGroup(4, 30, 0, weightItem(81300)),
+	})
+
+	if len(latest) != 2 {
+		t.Fatalf("got %d groups, want 2: %v", len(latest), latest)
+	}
+
+	newest := latest[0]
+	if newest.GroupID != 4 || len(newest.Measures) != 1 ||
+		newest.Measures[0].Value != 81300 {
+		t.Fatalf("weight got %+v", newest)
+	}
+
+	older := latest[1]
+	if older.GroupID != 2 || len(older.Measures) != 1 ||
+		older.Measures[0].Type != typeFat {
+		t.Fatalf("fat got %+v", older)
+	}
+}
@@ -0,0 +1,113 @@
+package measures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+var errInvalidMore = errors.New("invalid more flag")
+
+// moreFlag accepts the 0/1 getmeas sends as well as true/false.
+type moreFlag bool
+
+// UnmarshalJSON decodes a numeric or boolean more flag.
+func (m *moreFlag) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*m = true
+	case "false", "0", "null":
+		*m = false
+	default:
+		return fmt.Errorf("%w: %s", errInvalidMore, data)
+	}
+
+	return nil
+}
+
+// fetchBody fetches one page, or every page from the requested offset on
+// when opts.All is set.
+func fetchBody(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) (body, error) {
+	var combined body
+
+	seen := map[int64]bool{}
+
+	for {
+		payload, err := fetchMeasures(ctx, opts, appOpts, accessToken)
+		if err != nil {
+			return body{}, err
+		}
+
+		decoded, err := decodeResponse(payload)
+		if err != nil {
+			return body{}, err
+		}
+
+		groups := slices.Concat(
+			combined.MeasureGroups,
+			dedupeGroups(decoded.Body.MeasureGroups, seen),
+		)
+		combined = decoded.Body
+		combined.MeasureGroups = groups
+
+		if !opts.All || !nextPage(&opts.Pagination.Offset, combined) {
+			return combined, nil
+		}
+	}
+}
+
+// nextPage moves offset to the next page and reports whether it moved, so
+// a server that repeats an offset cannot loop forever.
+func nextPage(offset *int, page body) bool {
+	if !page.More || page.Offset <= *offset {
+		return false
+	}
+
+	*offset = page.Offset
+
+	return true
+}
+
+// pagingHint tells the user a query was truncated, or returns "".
+func pagingHint(page body) string {
+	if !page.More {
+		return emptyString
+	}
+
+	return fmt.Sprintf(
+		"more results available, use --offset %d or --all",
+		page.Offset,
+	)
+}
+
+func writePagingHint(opts app.Options, page body) error {
+	hint := pagingHint(page)
+	if hint == emptyString {
+		return nil
+	}
+
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		err := output.WriteWarning(opts, hint)
+		if err != nil {
+			return fmt.Errorf("write paging hint: %w", err)
+		}
+
+		return nil
+	}
+
+	err := output.WriteLine(opts, hint)
+	if err != nil {
+		return fmt.Errorf("write paging hint: %w", err)
+	}
+
+	return nil
+}
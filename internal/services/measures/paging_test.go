@@ -0,0 +1,149 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	testFirstPage = `{"status":0,"body":{"updatetime":1700000300,` +
+		`"measuregrps":[{"grpid":1}],"more":1,"offset":300}}`
+	testLastPage = `{"status":0,"body":{"updatetime":1700000300,` +
+		`"measuregrps":[{"grpid":2}],"more":false,"offset":0}}`
+	testPagedUpdate = 1700000300
+	testNextOffset  = 300
+)
+
+// TestMoreFlag decodes numeric and boolean more values.
+func TestMoreFlag(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{"1": true, "true": true, "0": false}
+
+	for raw, want := range cases {
+		var got moreFlag
+
+		err := json.Unmarshal([]byte(raw), &got)
+		if err != nil || bool(got) != want {
+			t.Fatalf("%s: got %t (%v) want %t", raw, got, err, want)
+		}
+	}
+
+	var invalid moreFlag
+
+	err := json.Unmarshal([]byte(`"yes"`), &invalid)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestFetchBodyAll follows more/offset and merges every page.
+func TestFetchBodyAll(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testPagingHandler))
+	defer server.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = server.URL
+
+	single, err := fetchBody(t.Context(), opts, appOpts, "token")
+	if err != nil {
+		t.Fatalf("fetchBody: %v", err)
+	}
+
+	want := "more results available, use --offset 300 or --all"
+	if got := pagingHint(single); got != want {
+		t.Fatalf("hint got %q want %q", got, want)
+	}
+
+	opts.All = true
+
+	all, err := fetchBody(t.Context(), opts, appOpts, "token")
+	if err != nil {
+		t.Fatalf("fetchBody all: %v", err)
+	}
+
+	if len(all.MeasureGroups) != 2 || all.More {
+		t.Fatalf("got %d groups, more=%t", len(all.MeasureGroups), all.More)
+	}
+
+	if got := pagingHint(all); got != emptyString {
+		t.Fatalf("hint got %q want none", got)
+	}
+}
+
+// TestRunSinceLastReadsEveryPage pages through the whole result before
+// moving the --since-last bookmark, so no page falls behind it.
+func TestRunSinceLastReadsEveryPage(t *testing.T) {
+	t.Parallel()
+
+	var pages atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			pages.Add(1)
+			testPagingHandler(writer, req)
+		},
+	))
+	defer server.Close()
+
+	var (
+		appOpts  app.Options
+		opts     Options
+		recorded int64
+	)
+
+	appOpts.BaseURL = server.URL
+	appOpts.Quiet = true
+	opts.RecordUpdate = func(updateTime int64) error {
+		recorded = updateTime
+
+		return nil
+	}
+
+	err := Run(t.Context(), opts, appOpts, "token")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if pages.Load() != 2 || recorded != testPagedUpdate {
+		t.Fatalf("pages %d recorded %d", pages.Load(), recorded)
+	}
+}
+
+// TestNextPageStopsOnRepeatedOffset guards against paging loops.
+func TestNextPageStopsOnRepeatedOffset(t *testing.T) {
+	t.Parallel()
+
+	offset := testNextOffset
+
+	var page body
+
+	page.More = true
+	page.Offset = testNextOffset
+
+	if nextPage(&offset, page) {
+		t.Fatal("expected repeated offset to stop paging")
+	}
+}
+
+func testPagingHandler(writer http.ResponseWriter, req *http.Request) {
+	if req.FormValue(offsetParam) == "300" {
+		_, _ = writer.Write([]byte(testLastPage))
+
+		return
+	}
+
+	_, _ = writer.Write([]byte(testFirstPage))
+}
@@ -2,12 +2,11 @@
 package measures
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"io"
 	"net/url"
 	"strconv"
 	"strings"
@@ -19,6 +18,7 @@ import (
 	"github.com/mreimbold/withings-cli/internal/filters"
 	"github.com/mreimbold/withings-cli/internal/output"
 	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/units"
 	"github.com/mreimbold/withings-cli/internal/withings"
 )
 
@@ -41,21 +41,25 @@ const (
 	aliasBodyWeight  = "bodyweight"
 	aliasTemperature = "temperature"
 	numberBase10     = 10
-	zeroString       = "0"
+	zeroDigit        = '0'
 	unitBase         = "1"
 	unitExponent     = "1e"
-	negativeSign     = "-"
-	decimalSeparator = "."
+	negativeSign     = '-'
+	decimalSeparator = '.'
 	rowsHeaderCount  = 1
 	tableMinWidth    = 0
 	tableTabWidth    = 0
 	tablePadding     = 2
 	tablePadChar     = ' '
 	tableFlags       = 0
-	scalePad         = 1
+	scaledBufferSize = 32
 	defaultInt       = 0
 	defaultInt64     = 0
 	emptyString      = ""
+	tableHeader      = "Time\tType\tValue\tUnit\tCategory\tSource\tMode\t" +
+		"Classification"
+	plainHeader = "time\ttype\tvalue\tunit\tcategory\tsource\tmode\t" +
+		"classification"
 )
 
 var (
@@ -76,6 +80,29 @@ type Options struct {
 	LastUpdate params.LastUpdate
 	Types      string
 	Category   string
+	Sources    string
+	Modes      string
+	// All follows more/offset until the last page and merges the groups.
+	All bool
+	// Distinct collapses equal measurements synced twice (see
+	// distinctGroups).
+	Distinct bool
+	// Latest keeps only the most recent measurement per type (see
+	// latestGroups).
+	Latest bool
+	// Guideline classifies blood pressure readings (GuidelineAHA or
+	// GuidelineESC).
+	Guideline string
+	// Where holds --where clauses; Min and Max bound the value column.
+	// All of them must hold (see filterValues).
+	Where []string
+	Min   string
+	Max   string
+	// RecordUpdate, when set, receives the server updatetime after a
+	// successful fetch so callers can use it as the next --last-update.
+	// Run then follows every page, since a bookmark past unread pages
+	// would skip them on the next run.
+	RecordUpdate func(updateTime int64) error
 }
 
 // Run fetches body measures and writes output.
@@ -85,11 +112,78 @@ func Run(
 	appOpts app.Options,
 	accessToken string,
 ) error {
-	values, err := buildParams(opts)
+	filter, err := parseContextFilter(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	conditions, err := parseConditions(opts)
 	if err != nil {
 		return app.NewExitError(app.ExitCodeUsage, err)
 	}
 
+	if opts.RecordUpdate != nil {
+		opts.All = true
+	}
+
+	fetched, err := fetchBody(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	filtered := filterValues(filterContext(fetched, filter), valueFilter{
+		Conditions: conditions,
+		Location:   output.DisplayLocation(appOpts, fetched.Timezone),
+		System:     appOpts.Units,
+		Guideline:  opts.Guideline,
+	})
+	if opts.Distinct {
+		filtered.MeasureGroups = distinctGroups(filtered.MeasureGroups)
+	}
+
+	if opts.Latest {
+		filtered.MeasureGroups = latestGroups(filtered.MeasureGroups)
+	}
+
+	if opts.Latest && appOpts.Quiet && appOpts.Plain {
+		err = writeLatestValues(appOpts, filtered)
+	} else {
+		err = writeBody(appOpts, filtered, opts.Guideline)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return recordUpdate(opts.RecordUpdate, fetched)
+}
+
+// recordUpdate hands the updatetime of a complete fetch to record. A
+// fetch that stopped with more pages left keeps the old bookmark.
+func recordUpdate(record func(int64) error, fetched body) error {
+	if record == nil || fetched.UpdateTime == defaultInt64 || fetched.More {
+		return nil
+	}
+
+	err := record(fetched.UpdateTime)
+	if err != nil {
+		return fmt.Errorf("record update time: %w", err)
+	}
+
+	return nil
+}
+
+func fetchMeasures(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) ([]byte, error) {
+	values, err := buildParams(opts)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
 	req, _, err := withings.BuildRequest(
 		ctx,
 		withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud),
@@ -99,21 +193,21 @@ func Run(
 		values,
 	)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return nil, fmt.Errorf("build request: %w", err)
 	}
 
 	//nolint:bodyclose // ReadPayload closes the response body.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := withings.NewClient(appOpts).Do(req)
 	if err != nil {
-		return app.NewExitError(app.ExitCodeNetwork, err)
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
 	}
 
 	payload, err := withings.ReadPayload(resp)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
-	return writeResponse(appOpts, payload)
+	return payload, nil
 }
 
 func buildParams(opts Options) (url.Values, error) {
@@ -145,7 +239,7 @@ func applyTypes(values *url.Values, raw string) error {
 		return nil
 	}
 
-	types, err := parseTypes(raw)
+	types, err := ParseTypes(raw)
 	if err != nil {
 		return err
 	}
@@ -265,7 +359,9 @@ func parseCategory(value string) (string, error) {
 	}
 }
 
-func parseTypes(value string) (string, error) {
+// ParseTypes resolves comma-separated measure type names or IDs into the
+// meastypes parameter value, dropping duplicates.
+func ParseTypes(value string) (string, error) {
 	parts := strings.Split(value, typeDelimiter)
 	types := make([]string, defaultInt, len(parts))
 	seen := map[string]bool{}
@@ -323,9 +419,11 @@ type response struct {
 }
 
 type body struct {
-	UpdateTime    int64   `json:"updatetime"`
-	Timezone      string  `json:"timezone"`
-	MeasureGroups []group `json:"measuregrps"`
+	UpdateTime    int64    `json:"updatetime"`
+	Timezone      string   `json:"timezone"`
+	MeasureGroups []group  `json:"measuregrps"`
+	More          moreFlag `json:"more"`
+	Offset        int      `json:"offset"`
 }
 
 type group struct {
@@ -333,26 +431,51 @@ type group struct {
 	Attrib   int    `json:"attrib"`
 	Date     int64  `json:"date"`
 	Category int    `json:"category"`
+	DeviceID string `json:"deviceid,omitempty"`
 	Measures []item `json:"measures"`
+	// Class keeps the blood pressure class of a group whose bp_sys or
+	// bp_dia was filtered out (see filterValues).
+	Class string `json:"-"`
 }
 
 type item struct {
 	Type  int   `json:"type"`
 	Value int64 `json:"value"`
 	Unit  int   `json:"unit"`
+	FM    *int  `json:"fm,omitempty"`
+}
+
+// MarshalJSON adds the mode name (see formatMode) next to fm.
+func (i item) MarshalJSON() ([]byte, error) {
+	type plainItem item
+
+	data, err := json.Marshal(struct {
+		plainItem
+
+		Mode string `json:"mode,omitempty"`
+	}{plainItem: plainItem(i), Mode: formatMode(i.FM)})
+	if err != nil {
+		return nil, fmt.Errorf("encode measure: %w", err)
+	}
+
+	return data, nil
 }
 
 type row struct {
-	Time     string
-	Type     string
-	Value    string
-	Unit     string
-	Category string
+	Time           string
+	Type           string
+	Value          string
+	Unit           string
+	Category       string
+	Source         string
+	Mode           string
+	Classification string
 }
 
 //nolint:gochecknoglobals // Static lookup table for CLI aliases.
 var typeMap = map[string]string{
 	"weight":              "1",
+	"height":              "4",
 	"fat_free_mass":       "5",
 	"fat_ratio":           "6",
 	"fat_mass":            "8",
@@ -368,6 +491,10 @@ var typeMap = map[string]string{
 	"hydration":           "77",
 	"bone_mass":           "88",
 	"pulse_wave_velocity": "91",
+	"vo2max":              "123",
+	"vascular_age":        "155",
+	"bmr":                 "226",
+	"metabolic_age":       "227",
 	aliasBodyWeight:       "1",
 	aliasTemperature:      "12",
 }
@@ -375,66 +502,102 @@ var typeMap = map[string]string{
 //nolint:gochecknoglobals // Static lookup tables for measure metadata.
 var (
 	typeNameByID = map[string]string{
-		"1":  "weight",
-		"5":  "fat_free_mass",
-		"6":  "fat_ratio",
-		"8":  "fat_mass",
-		"9":  "bp_dia",
-		"10": "bp_sys",
-		"11": "heart_rate",
-		"12": "temp",
-		"54": "spo2",
-		"71": "body_temp",
-		"73": "skin_temp",
-		"76": "muscle_mass",
-		"77": "hydration",
-		"88": "bone_mass",
-		"91": "pulse_wave_velocity",
+		"1":   "weight",
+		"4":   "height",
+		"5":   "fat_free_mass",
+		"6":   "fat_ratio",
+		"8":   "fat_mass",
+		"9":   "bp_dia",
+		"10":  "bp_sys",
+		"11":  "heart_rate",
+		"12":  "temp",
+		"54":  "spo2",
+		"71":  "body_temp",
+		"73":  "skin_temp",
+		"76":  "muscle_mass",
+		"77":  "hydration",
+		"88":  "bone_mass",
+		"91":  "pulse_wave_velocity",
+		"123": "vo2max",
+		"155": "vascular_age",
+		"226": "bmr",
+		"227": "metabolic_age",
 	}
 	unitByTypeID = map[string]string{
-		"1":  "kg",
-		"5":  "kg",
-		"6":  "%",
-		"8":  "kg",
-		"9":  "mmHg",
-		"10": "mmHg",
-		"11": "bpm",
-		"12": "C",
-		"54": "%",
-		"71": "C",
-		"73": "C",
-		"76": "kg",
-		"77": "%",
-		"88": "kg",
-		"91": "m/s",
+		"1":   "kg",
+		"4":   "m",
+		"5":   "kg",
+		"6":   "%",
+		"8":   "kg",
+		"9":   "mmHg",
+		"10":  "mmHg",
+		"11":  "bpm",
+		"12":  "C",
+		"54":  "%",
+		"71":  "C",
+		"73":  "C",
+		"76":  "kg",
+		"77":  "%",
+		"88":  "kg",
+		"91":  "m/s",
+		"123": "ml/min/kg",
+		"155": "years",
+		"226": "kcal",
+		"227": "years",
+	}
+	quantityByUnit = map[string]string{
+		"kg": units.Mass,
+		"C":  units.Temperature,
 	}
 )
 
-func writeResponse(opts app.Options, payload []byte) error {
-	decoded, err := decodeResponse(payload)
-	if err != nil {
-		return err
+// TypeName returns the measure type name for an ID.
+func TypeName(typeID int) string {
+	id := strconv.Itoa(typeID)
+	if name, ok := typeNameByID[id]; ok {
+		return name
 	}
 
-	return writeBody(opts, decoded.Body)
+	return id
 }
 
-func writeBody(opts app.Options, body body) error {
+// TypeUnit returns the display unit for a measure type ID, if known.
+func TypeUnit(typeID int) string {
+	return unitByTypeID[strconv.Itoa(typeID)]
+}
+
+// ScaledValue renders value * 10^unit without floating-point rounding.
+func ScaledValue(value int64, unit int) string {
+	return formatScaledValue(value, unit)
+}
+
+func writeBody(opts app.Options, body body, guideline string) error {
 	if opts.Quiet {
 		return nil
 	}
 
-	if opts.JSON {
+	if opts.JSON && opts.Fields == emptyString {
 		return writeJSONOutput(opts, body)
 	}
 
-	rows := buildRows(body)
+	location := output.DisplayLocation(opts, body.Timezone)
+	rows := convertRows(buildRows(body, location, guideline), opts.Units)
+
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		err := writePlainOutput(opts, rows)
+		if err != nil {
+			return err
+		}
+
+		return writePagingHint(opts, body)
+	}
 
-	if opts.Plain {
-		return writePlainOutput(rows)
+	err := writeTableOutput(opts, rows)
+	if err != nil {
+		return err
 	}
 
-	return writeTableOutput(rows)
+	return writePagingHint(opts, body)
 }
 
 func writeJSONOutput(opts app.Options, body body) error {
@@ -446,8 +609,8 @@ func writeJSONOutput(opts app.Options, body body) error {
 	return nil
 }
 
-func writePlainOutput(rows []row) error {
-	err := output.WriteLines(formatLines(rows))
+func writePlainOutput(opts app.Options, rows []row) error {
+	err := output.WritePlain(opts, formatLines(rows))
 	if err != nil {
 		return fmt.Errorf("write plain output: %w", err)
 	}
@@ -455,13 +618,13 @@ func writePlainOutput(rows []row) error {
 	return nil
 }
 
-func writeTableOutput(rows []row) error {
-	table, err := formatTable(rows)
+func writeTableOutput(opts app.Options, rows []row) error {
+	table, err := formatTable(opts, rows)
 	if err != nil {
 		return err
 	}
 
-	err = output.WriteLine(table)
+	err = output.WriteLine(opts, table)
 	if err != nil {
 		return fmt.Errorf("write table output: %w", err)
 	}
@@ -492,47 +655,75 @@ func decodeResponse(payload []byte) (response, error) {
 
 		return response{}, app.NewExitError(
 			app.ExitCodeAPI,
-			fmt.Errorf("%w: %d: %s", withings.ErrAPI, decoded.Status, message),
+			&withings.APIError{Status: decoded.Status, Message: message},
 		)
 	}
 
 	return decoded, nil
 }
 
-func buildRows(body body) []row {
-	location := measureLocation(body.Timezone)
-	rows := make([]row, defaultInt, len(body.MeasureGroups))
+// buildRows flattens the groups into one row per measure. The bp_sys and
+// bp_dia rows of a group holding both carry its blood pressure class.
+func buildRows(body body, location *time.Location, guideline string) []row {
+	count := defaultInt
+	for _, group := range body.MeasureGroups {
+		count += len(group.Measures)
+	}
+
+	rows := make([]row, defaultInt, count)
 
 	for _, group := range body.MeasureGroups {
 		timestamp := formatTime(group.Date, location)
 		category := formatCategory(group.Category)
+		source := formatSource(group.Attrib)
+		class := group.Class
+		if class == emptyString {
+			class = groupClassification(group, guideline)
+		}
 
 		for _, item := range group.Measures {
 			typeID := strconv.Itoa(item.Type)
-			rows = append(rows, row{
-				Time:     timestamp,
-				Type:     formatType(typeID),
-				Value:    formatScaledValue(item.Value, item.Unit),
-				Unit:     formatUnit(typeID, item.Unit),
-				Category: category,
-			})
+			measureRow := row{
+				Time:           timestamp,
+				Type:           formatType(typeID),
+				Value:          formatScaledValue(item.Value, item.Unit),
+				Unit:           formatUnit(typeID, item.Unit),
+				Category:       category,
+				Source:         source,
+				Mode:           formatMode(item.FM),
+				Classification: emptyString,
+			}
+
+			if item.Type == typeBPSystolic || item.Type == typeBPDiastolic {
+				measureRow.Classification = class
+			}
+
+			rows = append(rows, measureRow)
 		}
 	}
 
 	return rows
 }
 
-func measureLocation(timezone string) *time.Location {
-	if timezone == emptyString {
-		return time.UTC
+// convertRows converts mass and temperature rows to the unit system.
+func convertRows(rows []row, system string) []row {
+	for index := range rows {
+		rows[index] = convertRow(rows[index], system)
 	}
 
-	location, err := time.LoadLocation(timezone)
-	if err != nil {
-		return time.UTC
+	return rows
+}
+
+func convertRow(converted row, system string) row {
+	quantity, ok := quantityByUnit[converted.Unit]
+	if !ok {
+		return converted
 	}
 
-	return location
+	converted.Value = units.FormatValue(system, quantity, converted.Value)
+	converted.Unit = units.Label(system, quantity)
+
+	return converted
 }
 
 func formatTime(epoch int64, location *time.Location) string {
@@ -578,90 +769,120 @@ func formatUnitPower(unit int) string {
 	return unitExponent + strconv.Itoa(unit)
 }
 
+// formatScaledValue renders value * 10^unit without trailing fraction
+// zeros. It runs for every exported measure, so the digits are assembled
+// in stack buffers and only the result is allocated.
 func formatScaledValue(value int64, unit int) string {
+	var buffer [scaledBufferSize]byte
+
+	return string(appendScaledValue(buffer[:defaultInt], value, unit))
+}
+
+func appendScaledValue(dst []byte, value int64, unit int) []byte {
 	if unit == defaultInt {
-		return strconv.FormatInt(value, numberBase10)
+		return strconv.AppendInt(dst, value, numberBase10)
 	}
 
-	scaled := value
-	sign := emptyString
-
-	if scaled < defaultInt64 {
-		sign = negativeSign
-		scaled = -scaled
+	// Negating in uint64 keeps math.MinInt64 intact.
+	magnitude := uint64(value) //nolint:gosec // Sign is handled below.
+	if value < defaultInt64 {
+		dst = append(dst, negativeSign)
+		magnitude = -magnitude
 	}
 
-	digits := strconv.FormatInt(scaled, numberBase10)
+	var scratch [scaledBufferSize]byte
+
+	digits := strconv.AppendUint(scratch[:defaultInt], magnitude, numberBase10)
 
 	if unit > defaultInt {
-		return sign + digits + strings.Repeat(zeroString, unit)
+		dst = append(dst, digits...)
+		for range unit {
+			dst = append(dst, zeroDigit)
+		}
+
+		return dst
 	}
 
 	scale := -unit
 	if len(digits) <= scale {
-		digits = strings.Repeat(
-			zeroString,
-			scale-len(digits)+scalePad,
-		) + digits
+		dst = append(dst, zeroDigit)
+		digits = padDigits(scratch[len(digits):], digits, scale)
+	} else {
+		dst = append(dst, digits[:len(digits)-scale]...)
+		digits = digits[len(digits)-scale:]
 	}
 
-	point := len(digits) - scale
-	whole := digits[:point]
-	frac := strings.TrimRight(digits[point:], zeroString)
+	end := len(digits)
+	for end > defaultInt && digits[end-1] == zeroDigit {
+		end--
+	}
 
-	if frac == emptyString {
-		return sign + whole
+	if end == defaultInt {
+		return dst
 	}
 
-	return sign + whole + decimalSeparator + frac
+	dst = append(dst, decimalSeparator)
+
+	return append(dst, digits[:end]...)
 }
 
-func formatTable(rows []row) (string, error) {
-	var buffer bytes.Buffer
+// padDigits left-pads digits with zeros to width, using spare as storage.
+func padDigits(spare, digits []byte, width int) []byte {
+	padded := spare[:defaultInt]
+	for range width - len(digits) {
+		padded = append(padded, zeroDigit)
+	}
+
+	return append(padded, digits...)
+}
 
-	writer := tabwriter.NewWriter(
-		&buffer,
+func newTableWriter(target io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(
+		target,
 		tableMinWidth,
 		tableTabWidth,
 		tablePadding,
 		tablePadChar,
 		tableFlags,
 	)
-	_, _ = fmt.Fprintln(writer, "Time\tType\tValue\tUnit\tCategory")
+}
+
+func formatTable(opts app.Options, rows []row) (string, error) {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, tableHeader)
 
 	for _, row := range rows {
-		_, _ = fmt.Fprintf(
-			writer,
-			"%s\t%s\t%s\t%s\t%s\n",
-			row.Time,
-			row.Type,
-			row.Value,
-			row.Unit,
-			row.Category,
-		)
+		lines = append(lines, formatRow(row))
 	}
 
-	err := writer.Flush()
+	table, err := output.RenderTable(opts, plainHeader, lines)
 	if err != nil {
 		return emptyString, fmt.Errorf("render measures table: %w", err)
 	}
 
-	return strings.TrimRight(buffer.String(), "\n"), nil
+	return table, nil
 }
 
 func formatLines(rows []row) []string {
 	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
-	lines = append(lines, "time\ttype\tvalue\tunit\tcategory")
+	lines = append(lines, plainHeader)
 
 	for _, row := range rows {
-		lines = append(lines, strings.Join([]string{
-			row.Time,
-			row.Type,
-			row.Value,
-			row.Unit,
-			row.Category,
-		}, "\t"))
+		lines = append(lines, formatRow(row))
 	}
 
 	return lines
 }
+
+func formatRow(row row) string {
+	return strings.Join([]string{
+		row.Time,
+		row.Type,
+		row.Value,
+		row.Unit,
+		row.Category,
+		row.Source,
+		row.Mode,
+		row.Classification,
+	}, "\t")
+}
@@ -3,6 +3,7 @@ package measures
 
 import (
 	"errors"
+	"math"
 	"net/url"
 	"strconv"
 	"testing"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/mreimbold/withings-cli/internal/errs"
 	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/units"
 )
 
 const (
@@ -22,11 +24,12 @@ const (
 	measureCategoryGoalID   = "2"
 	testParseCategoryErrFmt = "parseCategory: %v"
 	testCategoryGotFmt      = "category got %q want %q"
-	testParseTypesErrFmt    = "parseTypes: %v"
+	testParseTypesErrFmt    = "ParseTypes: %v"
 	testTypesGotFmt         = "types got %q want %q"
 	testBuildParamsErrFmt   = "buildParams: %v"
 	testParamGotFmt         = "param %s got %v want %v"
 	testLastUpdateValue     = 123
+	testRecordedUpdate      = int64(1767139200)
 	testLimitValue          = 100
 	testOffsetValue         = 10
 	testFirstIndex          = 0
@@ -48,6 +51,15 @@ const (
 	testScaleNegValue       = int64(-123)
 	testScaleNegUnit        = -2
 	testScaleNegWant        = "-1.23"
+	testScaleZeroValue      = int64(0)
+	testScaleZeroUnit       = -3
+	testScaleZeroWant       = "0"
+	testScaleMinValue       = int64(math.MinInt64)
+	testScaleMinUnit        = -2
+	testScaleMinWant        = "-92233720368547758.08"
+	testScaleWideValue      = int64(-15)
+	testScaleWideUnit       = -40
+	testScaleWideWant       = "-0.0000000000000000000000000000000000000015"
 	testMeasureRowCount     = 1
 	testMeasureCategory     = 1
 	testMeasureType         = 10
@@ -110,7 +122,7 @@ func TestParseCategoryRejectsInvalid(t *testing.T) {
 func TestParseTypesMapsNames(t *testing.T) {
 	t.Parallel()
 
-	types, err := parseTypes(
+	types, err := ParseTypes(
 		measureTypeWeight + typeDelimiter + measureTypeBPSys,
 	)
 	if err != nil {
@@ -127,7 +139,7 @@ func TestParseTypesMapsNames(t *testing.T) {
 func TestParseTypesDedup(t *testing.T) {
 	t.Parallel()
 
-	types, err := parseTypes(
+	types, err := ParseTypes(
 		measureTypeWeight + typeDelimiter + measureTypeDedup,
 	)
 	if err != nil {
@@ -143,7 +155,7 @@ func TestParseTypesDedup(t *testing.T) {
 func TestParseTypesAllowsNumeric(t *testing.T) {
 	t.Parallel()
 
-	types, err := parseTypes(
+	types, err := ParseTypes(
 		measureTypeWeightID + typeDelimiter + measureTypeBPSysID,
 	)
 	if err != nil {
@@ -175,8 +187,11 @@ func TestBuildParamsLastUpdateConflict(t *testing.T) {
 		LastUpdate: params.LastUpdate{
 			LastUpdate: testLastUpdateValue,
 		},
-		Types:    testEmptyString,
-		Category: testEmptyString,
+		Types:        testEmptyString,
+		Category:     testEmptyString,
+		Sources:      testEmptyString,
+		Modes:        testEmptyString,
+		RecordUpdate: nil,
 	}
 
 	_, err := buildParams(opts)
@@ -208,8 +223,11 @@ func TestBuildParamsMapsFields(t *testing.T) {
 		LastUpdate: params.LastUpdate{
 			LastUpdate: testDefaultInt64,
 		},
-		Types:    measureTypeWeight,
-		Category: categoryRealText,
+		Types:        measureTypeWeight,
+		Category:     categoryRealText,
+		Sources:      testEmptyString,
+		Modes:        testEmptyString,
+		RecordUpdate: nil,
 	}
 
 	values, err := buildParams(opts)
@@ -287,6 +305,24 @@ func TestFormatScaledValue(t *testing.T) {
 			unit:  testScaleNegUnit,
 			want:  testScaleNegWant,
 		},
+		{
+			name:  "zero",
+			value: testScaleZeroValue,
+			unit:  testScaleZeroUnit,
+			want:  testScaleZeroWant,
+		},
+		{
+			name:  "min-int64",
+			value: testScaleMinValue,
+			unit:  testScaleMinUnit,
+			want:  testScaleMinWant,
+		},
+		{
+			name:  "wider-than-buffer",
+			value: testScaleWideValue,
+			unit:  testScaleWideUnit,
+			want:  testScaleWideWant,
+		},
 	}
 
 	for _, test := range cases {
@@ -300,10 +336,37 @@ func TestFormatScaledValue(t *testing.T) {
 func TestBuildRows(t *testing.T) {
 	t.Parallel()
 
-	rows := buildRows(testBody())
+	rows := buildRows(testBody(), time.UTC, DefaultGuideline)
 	assertSingleMeasureRow(t, rows)
 }
 
+// TestConvertRows switches weight and temperature rows to imperial.
+func TestConvertRows(t *testing.T) {
+	t.Parallel()
+
+	rows := convertRows([]row{
+		unitRow("72.345", "kg"),
+		unitRow("36.6", "C"),
+		unitRow("120", testMeasureExpectedUnit),
+	}, units.Imperial)
+
+	assertMeasureValue(t, "weight", rows[0].Value+" "+rows[0].Unit, "159.5 lb")
+	assertMeasureValue(t, "temp", rows[1].Value+" "+rows[1].Unit, "97.9 F")
+	assertMeasureValue(t, "bp", rows[2].Value+" "+rows[2].Unit, "120 mmHg")
+}
+
+func unitRow(value, unit string) row {
+	return row{
+		Time:     testMeasureExpectedTime,
+		Type:     emptyString,
+		Value:    value,
+		Unit:     unit,
+		Category: categoryRealText,
+		Source:   emptyString,
+		Mode:     emptyString,
+	}
+}
+
 func testBody() body {
 	epoch := time.Date(2025, 12, 30, 0, 0, 0, 0, time.UTC).Unix()
 
@@ -316,15 +379,19 @@ func testBody() body {
 				Attrib:   testDefaultInt,
 				Date:     epoch,
 				Category: testMeasureCategory,
+				DeviceID: testEmptyString,
 				Measures: []item{
 					{
 						Type:  testMeasureType,
 						Value: testMeasureValue,
 						Unit:  testMeasureUnit,
+						FM:    nil,
 					},
 				},
 			},
 		},
+		More:   false,
+		Offset: testDefaultInt,
 	}
 }
 
@@ -350,3 +417,37 @@ func assertMeasureValue(t *testing.T, label, got, want string) {
 		t.Fatalf("%s got %q want %q", label, got, want)
 	}
 }
+
+// TestRecordUpdate forwards the update time of a complete fetch to the
+// callback.
+func TestRecordUpdate(t *testing.T) {
+	t.Parallel()
+
+	var recorded int64
+
+	record := func(updateTime int64) error {
+		recorded = updateTime
+
+		return nil
+	}
+
+	err := recordUpdate(record, body{UpdateTime: testDefaultInt64})
+	if err != nil || recorded != testDefaultInt64 {
+		t.Fatalf("zero update time got %d, %v", recorded, err)
+	}
+
+	err = recordUpdate(record, body{UpdateTime: testRecordedUpdate, More: true})
+	if err != nil || recorded != testDefaultInt64 {
+		t.Fatalf("partial fetch got %d, %v", recorded, err)
+	}
+
+	err = recordUpdate(record, body{UpdateTime: testRecordedUpdate})
+	if err != nil || recorded != testRecordedUpdate {
+		t.Fatalf("update time got %d, %v", recorded, err)
+	}
+
+	err = recordUpdate(nil, body{UpdateTime: testRecordedUpdate})
+	if err != nil {
+		t.Fatalf("nil callback: %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package measures
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	typeWeight      = 1
+	actionSet       = "setmeas"
+	measuresParam   = "measures"
+	dateParam       = "date"
+	setExponent     = -3
+	setScale        = 1000
+	kilogramsPerLb  = 0.45359237
+	metersPerCM     = 0.01
+	setConfirmation = "%s %s%s %s %s? [y/N]: "
+)
+
+var (
+	errTypeNotSettable = errors.New("measure type cannot be set")
+	errInvalidValue    = errors.New("invalid measure value")
+	errValueRange      = errors.New("measure value out of range")
+	errInvalidDate     = errors.New("invalid date")
+)
+
+// SetOptions captures a measure write (setmeas).
+type SetOptions struct {
+	User params.User
+	// Type is a settable measure alias or ID (weight, height).
+	Type string
+	// Value is in the type's unit; a unit suffix such as "lb" or "cm"
+	// converts it.
+	Value string
+	// Date is when the measure was taken; empty means now.
+	Date string
+	// Category is real (a manual entry) or goal.
+	Category string
+	// Confirm, when set, is asked before sending; a false answer cancels
+	// the write without error.
+	Confirm func(prompt string) (bool, error)
+}
+
+// settable describes a measure type that can be written, with the range
+// of plausible values in its metric unit and the suffixes it accepts.
+type settable struct {
+	Min      float64
+	Max      float64
+	Unit     string
+	Suffixes []unitSuffix
+}
+
+// unitSuffix converts a value typed with Suffix to the metric unit.
+// Longer suffixes come first, so "cm" is not read as "m".
+type unitSuffix struct {
+	Suffix string
+	Factor float64
+}
+
+//nolint:gochecknoglobals // Static table of writable measure types.
+var settableTypes = map[int]settable{
+	typeWeight: {
+		Min:      2,
+		Max:      600,
+		Unit:     "kg",
+		Suffixes: []unitSuffix{{"kg", 1}, {"lb", kilogramsPerLb}},
+	},
+	TypeHeight: {
+		Min:      0.5,
+		Max:      2.5,
+		Unit:     "m",
+		Suffixes: []unitSuffix{{"cm", metersPerCM}, {"m", 1}},
+	},
+}
+
+// SetResult is the measure written by SetMeasure.
+type SetResult struct {
+	Type     string  `json:"type"`
+	TypeID   int     `json:"type_id"`
+	Value    float64 `json:"value"`
+	Unit     string  `json:"unit"`
+	Category string  `json:"category"`
+	Date     int64   `json:"date"`
+}
+
+// SetMeasure records a manual measure or a goal. It validates the value
+// against a plausible range and refuses read-only mode before asking for
+// confirmation, so a typo in kg vs. lb or m vs. cm cannot skew every
+// trend computed from it and no prompt is shown for a write that would
+// be rejected.
+func SetMeasure(
+	ctx context.Context,
+	opts SetOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	result, values, err := buildSet(opts, time.Now())
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	err = withings.CheckWrite(appOpts, serviceName, actionSet)
+	if err != nil {
+		return err
+	}
+
+	if opts.Confirm != nil && !appOpts.DryRun {
+		proceed, err := opts.Confirm(setPrompt(result))
+		if err != nil || !proceed {
+			return err
+		}
+	}
+
+	err = sendSet(ctx, appOpts, accessToken, values)
+	if err != nil {
+		return err
+	}
+
+	var data any = setMessage(result)
+	if appOpts.JSON {
+		data = result
+	}
+
+	return output.WriteOutput(appOpts, data)
+}
+
+func sendSet(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	values url.Values,
+) error {
+	req, _, err := withings.BuildRequest(
+		ctx,
+		withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud),
+		serviceName,
+		actionSet,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	_, err = decodeResponse(payload)
+
+	return err
+}
+
+// buildSet validates opts and returns the measure to write with its
+// setmeas parameters.
+func buildSet(opts SetOptions, now time.Time) (SetResult, url.Values, error) {
+	typeID, spec, err := settableType(opts.Type)
+	if err != nil {
+		return SetResult{}, nil, err
+	}
+
+	scaled, err := parseSetValue(spec, opts.Value)
+	if err != nil {
+		return SetResult{}, nil, err
+	}
+
+	category, err := parseCategory(opts.Category)
+	if err != nil {
+		return SetResult{}, nil, err
+	}
+
+	date := now.Unix()
+	if opts.Date != emptyString {
+		date, err = filters.ParseEpoch(opts.Date)
+		if err != nil {
+			return SetResult{}, nil, fmt.Errorf("%w: %w", errInvalidDate, err)
+		}
+	}
+
+	encoded, err := json.Marshal([]item{{
+		Type:  typeID,
+		Value: scaled,
+		Unit:  setExponent,
+		FM:    nil,
+	}})
+	if err != nil {
+		return SetResult{}, nil, fmt.Errorf("encode measure: %w", err)
+	}
+
+	values := url.Values{}
+	values.Set(measuresParam, string(encoded))
+	values.Set(categoryParam, category)
+	values.Set(dateParam, strconv.FormatInt(date, numberBase10))
+	applyUser(&values, opts.User)
+
+	categoryID, _ := strconv.Atoi(category)
+
+	return SetResult{
+		Type:     TypeName(typeID),
+		TypeID:   typeID,
+		Value:    float64(scaled) / setScale,
+		Unit:     spec.Unit,
+		Category: formatCategory(categoryID),
+		Date:     date,
+	}, values, nil
+}
+
+func settableType(raw string) (int, settable, error) {
+	resolved, err := resolveType(strings.ToLower(strings.TrimSpace(raw)))
+	if err != nil {
+		return defaultInt, settable{}, err
+	}
+
+	typeID, _ := strconv.Atoi(resolved)
+
+	spec, ok := settableTypes[typeID]
+	if !ok {
+		return defaultInt, settable{}, fmt.Errorf(
+			"%w: %q (settable: %s)",
+			errTypeNotSettable,
+			raw,
+			settableNames(),
+		)
+	}
+
+	return typeID, spec, nil
+}
+
+// parseSetValue reads a decimal value with an optional unit suffix and
+// returns it in thousandths of the metric unit (g, mm).
+func parseSetValue(spec settable, raw string) (int64, error) {
+	value := strings.ToLower(strings.TrimSpace(raw))
+	factor := 1.0
+
+	for _, suffix := range spec.Suffixes {
+		trimmed, ok := strings.CutSuffix(value, suffix.Suffix)
+		if ok {
+			value = strings.TrimSpace(trimmed)
+			factor = suffix.Factor
+
+			break
+		}
+	}
+
+	parsed, err := strconv.ParseFloat(value, trendFloatBits)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return defaultInt64, fmt.Errorf("%w: %q", errInvalidValue, raw)
+	}
+
+	metric := parsed * factor
+	if metric < spec.Min || metric > spec.Max {
+		return defaultInt64, fmt.Errorf(
+			"%w: %q (want %g-%g %s)",
+			errValueRange,
+			raw,
+			spec.Min,
+			spec.Max,
+			spec.Unit,
+		)
+	}
+
+	return int64(math.Round(metric * setScale)), nil
+}
+
+func settableNames() string {
+	names := make([]string, defaultInt, len(settableTypes))
+	for typeID := range settableTypes {
+		names = append(names, TypeName(typeID))
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, ", ")
+}
+
+// SettableTypeChoices lists the measure types SetMeasure accepts.
+func SettableTypeChoices() []output.Choice {
+	choices := make([]output.Choice, defaultInt, len(settableTypes))
+	for typeID := range settableTypes {
+		choices = append(choices, output.Choice{
+			Name:  TypeName(typeID),
+			Value: strconv.Itoa(typeID),
+		})
+	}
+
+	return choices
+}
+
+func setPrompt(result SetResult) string {
+	verb, suffix := "Record", emptyString
+	if result.Category == categoryGoalText {
+		verb, suffix = "Set", " goal"
+	}
+
+	return fmt.Sprintf(
+		setConfirmation,
+		verb,
+		result.Type,
+		suffix,
+		formatSetValue(result.Value),
+		result.Unit,
+	)
+}
+
+func setMessage(result SetResult) string {
+	if result.Category == categoryGoalText {
+		return fmt.Sprintf(
+			"Set %s goal to %s %s.",
+			result.Type,
+			formatSetValue(result.Value),
+			result.Unit,
+		)
+	}
+
+	return fmt.Sprintf(
+		"Recorded %s %s %s.",
+		result.Type,
+		formatSetValue(result.Value),
+		result.Unit,
+	)
+}
+
+func formatSetValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, trendFloatBits)
+}
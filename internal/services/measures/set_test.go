@@ -0,0 +1,123 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+// TestParseSetValue converts unit suffixes and rejects implausible values.
+func TestParseSetValue(t *testing.T) {
+	t.Parallel()
+
+	valid := []struct {
+		typeID int
+		raw    string
+		want   int64
+	}{
+		{typeID: typeWeight, raw: "80.5", want: 80500},
+		{typeID: typeWeight, raw: "80.5 KG", want: 80500},
+		{typeID: typeWeight, raw: "176lb", want: 79832},
+		{typeID: TypeHeight, raw: "1.80", want: 1800},
+		{typeID: TypeHeight, raw: "1.8m", want: 1800},
+		{typeID: TypeHeight, raw: " 175 CM", want: 1750},
+	}
+
+	for _, test := range valid {
+		got, err := parseSetValue(settableTypes[test.typeID], test.raw)
+		if err != nil || got != test.want {
+			t.Fatalf("%q got %d, %v want %d", test.raw, got, err, test.want)
+		}
+	}
+
+	invalid := []struct {
+		typeID int
+		raw    string
+		want   error
+	}{
+		{typeID: TypeHeight, raw: "180", want: errValueRange},
+		{typeID: TypeHeight, raw: "0.3m", want: errValueRange},
+		{typeID: typeWeight, raw: "8000g", want: errInvalidValue},
+		{typeID: typeWeight, raw: "", want: errInvalidValue},
+		{typeID: typeWeight, raw: "NaN", want: errInvalidValue},
+	}
+
+	for _, test := range invalid {
+		_, err := parseSetValue(settableTypes[test.typeID], test.raw)
+		if !errors.Is(err, test.want) {
+			t.Fatalf("%q got %v want %v", test.raw, err, test.want)
+		}
+	}
+}
+
+// TestBuildSet sends one measure in thousandths with category and date.
+func TestBuildSet(t *testing.T) {
+	t.Parallel()
+
+	result, values, err := buildSet(SetOptions{
+		User:     params.User{UserID: "42"},
+		Type:     "weight",
+		Value:    "80",
+		Date:     testEmptyString,
+		Category: "goal",
+		Confirm:  nil,
+	}, time.Unix(1_700_000_000, 0))
+	if err != nil {
+		t.Fatalf("buildSet: %v", err)
+	}
+
+	want := "category=2&date=1700000000" +
+		"&measures=%5B%7B%22type%22%3A1%2C%22value%22%3A80000" +
+		"%2C%22unit%22%3A-3%7D%5D&userid=42"
+	if values.Encode() != want {
+		t.Fatalf("params got %q want %q", values.Encode(), want)
+	}
+
+	if setPrompt(result) != "Set weight goal 80 kg? [y/N]: " ||
+		setMessage(result) != "Set weight goal to 80 kg." {
+		t.Fatalf("prompt %q message %q", setPrompt(result), setMessage(result))
+	}
+
+	_, _, err = buildSet(SetOptions{
+		User:     params.User{UserID: testEmptyString},
+		Type:     "fat_ratio",
+		Value:    "20",
+		Date:     testEmptyString,
+		Category: "real",
+		Confirm:  nil,
+	}, time.Now())
+	if !errors.Is(err, errTypeNotSettable) {
+		t.Fatalf("fat_ratio got %v", err)
+	}
+}
+
+// TestSetMeasureReadOnly refuses the write before asking for confirmation.
+func TestSetMeasureReadOnly(t *testing.T) {
+	t.Parallel()
+
+	for _, category := range []string{"real", "goal"} {
+		asked := false
+
+		err := SetMeasure(context.Background(), SetOptions{
+			User:     params.User{UserID: testEmptyString},
+			Type:     "weight",
+			Value:    "80",
+			Date:     testEmptyString,
+			Category: category,
+			Confirm: func(string) (bool, error) {
+				asked = true
+
+				return true, nil
+			},
+		}, app.Options{ReadOnly: true}, "token")
+		if !errors.Is(err, withings.ErrReadOnly) || asked {
+			t.Fatalf("%s got %v asked %t", category, err, asked)
+		}
+	}
+}
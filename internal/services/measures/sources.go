@@ -0,0 +1,199 @@
+package measures
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	// SourceDevice counts measures taken by a linked Withings device.
+	SourceDevice = "device"
+	// SourceManual counts measures typed in by the user.
+	SourceManual = "manual"
+	// SourceThirdParty counts measures without a device that were not
+	// entered manually, e.g. synced from a partner app.
+	SourceThirdParty = "third_party"
+
+	attribManual         = 2
+	attribManualCreation = 4
+	sharePercent         = 100
+	shareDigits          = 1
+	sourcesTableHeader   = "Type\tSource\tPoints\tShare\tDevices\tFirst\tLast"
+	sourcesPlainHeader   = "type\tsource\tpoints\tshare\tdevices\tfirst\tlast"
+)
+
+// SourceBreakdown counts the data points of one measure type from one
+// source.
+type SourceBreakdown struct {
+	Type    string  `json:"type"`
+	TypeID  int     `json:"type_id"`
+	Source  string  `json:"source"`
+	Points  int     `json:"points"`
+	Share   float64 `json:"share"`
+	Devices int     `json:"devices"`
+	First   int64   `json:"first"`
+	Last    int64   `json:"last"`
+
+	devices map[string]bool
+}
+
+// Sources fetches every measure group in the range and reports how many
+// data points of each type came from devices, manual entry, or third
+// parties, so users can audit data quality before trusting trends.
+func Sources(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	opts.All = true
+
+	fetched, err := fetchBody(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	breakdown := buildSourceBreakdown(fetched)
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		return output.WriteRawJSON(appOpts, breakdown)
+	}
+
+	location := output.DisplayLocation(appOpts, fetched.Timezone)
+
+	lines := make([]string, defaultInt, len(breakdown)+rowsHeaderCount)
+	lines = append(lines, sourcesPlainHeader)
+
+	for _, entry := range breakdown {
+		lines = append(lines, strings.Join([]string{
+			entry.Type,
+			entry.Source,
+			strconv.Itoa(entry.Points),
+			formatShare(entry.Share),
+			strconv.Itoa(entry.Devices),
+			formatTime(entry.First, location),
+			formatTime(entry.Last, location),
+		}, "\t"))
+	}
+
+	if appOpts.Plain || appOpts.NDJSON {
+		err = output.WritePlain(appOpts, lines)
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	lines[0] = sourcesTableHeader
+
+	table, err := output.RenderTable(appOpts, sourcesPlainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render sources table: %w", err)
+	}
+
+	return output.WriteLine(appOpts, table)
+}
+
+// sourceClass maps a measure group to device, manual, or third_party.
+// Manual attribs win; otherwise a group with a device ID came from that
+// device and one without came from another app.
+func sourceClass(measureGroup group) string {
+	switch measureGroup.Attrib {
+	case attribManual, attribManualCreation:
+		return SourceManual
+	}
+
+	if measureGroup.DeviceID != emptyString {
+		return SourceDevice
+	}
+
+	return SourceThirdParty
+}
+
+// buildSourceBreakdown groups data points by type and source, ordered by
+// type ID and then by points, most first.
+func buildSourceBreakdown(fetched body) []SourceBreakdown {
+	entries := map[string]*SourceBreakdown{}
+	totals := map[int]int{}
+
+	for _, measureGroup := range fetched.MeasureGroups {
+		source := sourceClass(measureGroup)
+
+		for _, measure := range measureGroup.Measures {
+			key := strconv.Itoa(measure.Type) + "\t" + source
+
+			entry, ok := entries[key]
+			if !ok {
+				entry = &SourceBreakdown{
+					Type:    formatType(strconv.Itoa(measure.Type)),
+					TypeID:  measure.Type,
+					Source:  source,
+					Points:  defaultInt,
+					Share:   defaultInt,
+					Devices: defaultInt,
+					First:   measureGroup.Date,
+					Last:    measureGroup.Date,
+					devices: map[string]bool{},
+				}
+				entries[key] = entry
+			}
+
+			entry.Points++
+			entry.First = min(entry.First, measureGroup.Date)
+			entry.Last = max(entry.Last, measureGroup.Date)
+
+			if measureGroup.DeviceID != emptyString {
+				entry.devices[measureGroup.DeviceID] = true
+			}
+
+			totals[measure.Type]++
+		}
+	}
+
+	breakdown := make([]SourceBreakdown, defaultInt, len(entries))
+
+	for _, entry := range entries {
+		entry.Devices = len(entry.devices)
+		entry.Share = roundShare(
+			float64(entry.Points) / float64(totals[entry.TypeID]),
+		)
+		breakdown = append(breakdown, *entry)
+	}
+
+	sort.Slice(breakdown, func(left, right int) bool {
+		if breakdown[left].TypeID != breakdown[right].TypeID {
+			return breakdown[left].TypeID < breakdown[right].TypeID
+		}
+
+		if breakdown[left].Points != breakdown[right].Points {
+			return breakdown[left].Points > breakdown[right].Points
+		}
+
+		return breakdown[left].Source < breakdown[right].Source
+	})
+
+	return breakdown
+}
+
+// roundShare turns a ratio into a percentage with one decimal.
+func roundShare(ratio float64) float64 {
+	scale := math.Pow10(shareDigits)
+
+	return math.Round(ratio*sharePercent*scale) / scale
+}
+
+func formatShare(share float64) string {
+	return strconv.FormatFloat(share, 'f', shareDigits, trendFloatBits) + "%"
+}
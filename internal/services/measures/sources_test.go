@@ -0,0 +1,118 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"testing"
+)
+
+const (
+	sourcesTestWeight = 1
+	sourcesTestFat    = 6
+	sourcesTestScale  = "scale-1"
+	sourcesTestOther  = "scale-2"
+)
+
+// TestBuildSourceBreakdown counts points per type and source with shares,
+// distinct devices, and the covered range.
+func TestBuildSourceBreakdown(t *testing.T) {
+	t.Parallel()
+
+	breakdown := buildSourceBreakdown(body{
+		UpdateTime: testDefaultInt64,
+		Timezone:   "UTC",
+		MeasureGroups: []group{
+			sourcesGroup(
+				0,
+				sourcesTestScale,
+				300,
+				sourcesTestWeight,
+				sourcesTestFat,
+			),
+			sourcesGroup(8, sourcesTestOther, 100, sourcesTestWeight),
+			sourcesGroup(2, testEmptyString, 200, sourcesTestWeight),
+			sourcesGroup(0, testEmptyString, 400, sourcesTestWeight),
+		},
+		More:   false,
+		Offset: testDefaultInt,
+	})
+
+	want := []SourceBreakdown{
+		sourcesEntry("weight", SourceDevice, 2, 50, 2, 100, 300),
+		sourcesEntry("weight", SourceManual, 1, 25, 0, 200, 200),
+		sourcesEntry("weight", SourceThirdParty, 1, 25, 0, 400, 400),
+		sourcesEntry("fat_ratio", SourceDevice, 1, 100, 1, 300, 300),
+	}
+
+	if len(breakdown) != len(want) {
+		t.Fatalf("entries got %d want %d: %+v", len(breakdown), len(want),
+			breakdown)
+	}
+
+	for index, entry := range want {
+		got := breakdown[index]
+		if got.Type != entry.Type || got.Source != entry.Source ||
+			got.Points != entry.Points || got.Share != entry.Share ||
+			got.Devices != entry.Devices || got.First != entry.First ||
+			got.Last != entry.Last {
+			t.Fatalf("entry %d got %+v want %+v", index, got, entry)
+		}
+	}
+}
+
+// TestSourceClassManualWins keeps manual entries manual even when the
+// group names a device.
+func TestSourceClassManualWins(t *testing.T) {
+	t.Parallel()
+
+	got := sourceClass(sourcesGroup(4, sourcesTestScale, 1, sourcesTestWeight))
+	if got != SourceManual {
+		t.Fatalf("got %q want %q", got, SourceManual)
+	}
+
+	if formatShare(roundShare(1.0/3)) != "33.3%" {
+		t.Fatalf("share got %q", formatShare(roundShare(1.0/3)))
+	}
+}
+
+func sourcesGroup(attrib int, deviceID string, date int64, types ...int) group {
+	measures := make([]item, 0, len(types))
+	for _, typeID := range types {
+		measures = append(measures, item{
+			Type:  typeID,
+			Value: 1,
+			Unit:  0,
+			FM:    nil,
+		})
+	}
+
+	return group{
+		GroupID:  testDefaultInt64,
+		Attrib:   attrib,
+		Date:     date,
+		Category: testMeasureCategory,
+		DeviceID: deviceID,
+		Measures: measures,
+	}
+}
+
+func sourcesEntry(
+	name string,
+	source string,
+	points int,
+	share float64,
+	devices int,
+	first int64,
+	last int64,
+) SourceBreakdown {
+	return SourceBreakdown{
+		Type:    name,
+		TypeID:  testDefaultInt,
+		Source:  source,
+		Points:  points,
+		Share:   share,
+		Devices: devices,
+		First:   first,
+		Last:    last,
+		devices: nil,
+	}
+}
@@ -0,0 +1,266 @@
+package measures
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/units"
+)
+
+const (
+	trendBarWidth    = 20
+	trendBarMin      = 1
+	trendBarChar     = "#"
+	trendPositive    = "+"
+	trendFloatBits   = 64
+	trendPrecision   = -1
+	trendDeltaDigits = 2
+	trendTableHeader = "Time\tType\tValue\tUnit\tChange\tTrend"
+	trendPlainHeader = "time\ttype\tvalue\tunit\tchange"
+)
+
+// Trend fetches measures and writes them oldest first with per-type change
+// and a bar scaled to the range of each type.
+func Trend(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	filter, err := parseContextFilter(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	payload, err := fetchMeasures(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	decoded.Body = filterContext(decoded.Body, filter)
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		return writeJSONOutput(appOpts, decoded.Body)
+	}
+
+	rows := buildTrendRows(
+		decoded.Body,
+		appOpts.Units,
+		output.DisplayLocation(appOpts, decoded.Body.Timezone),
+	)
+
+	if appOpts.Plain || appOpts.NDJSON {
+		return writePlainTrend(appOpts, rows)
+	}
+
+	return writeTrendTable(appOpts, rows)
+}
+
+type trendPoint struct {
+	Date   int64
+	TypeID string
+	Value  float64
+	Row    row
+}
+
+type trendRow struct {
+	Row    row
+	TypeID string
+	Value  float64
+	Change string
+	Bar    string
+}
+
+type valueRange struct {
+	Min float64
+	Max float64
+}
+
+func buildTrendRows(
+	body body,
+	system string,
+	location *time.Location,
+) []trendRow {
+	points := collectTrendPoints(body, system, location)
+	ranges := map[string]valueRange{}
+
+	for _, point := range points {
+		current, ok := ranges[point.TypeID]
+		if !ok {
+			ranges[point.TypeID] = valueRange{
+				Min: point.Value,
+				Max: point.Value,
+			}
+
+			continue
+		}
+
+		current.Min = math.Min(current.Min, point.Value)
+		current.Max = math.Max(current.Max, point.Value)
+		ranges[point.TypeID] = current
+	}
+
+	previous := map[string]float64{}
+	rows := make([]trendRow, defaultInt, len(points))
+
+	for _, point := range points {
+		change := emptyString
+		if last, ok := previous[point.TypeID]; ok {
+			change = formatChange(point.Value - last)
+		}
+
+		previous[point.TypeID] = point.Value
+		rows = append(rows, trendRow{
+			Row:    point.Row,
+			TypeID: point.TypeID,
+			Value:  point.Value,
+			Change: change,
+			Bar:    trendBar(point.Value, ranges[point.TypeID]),
+		})
+	}
+
+	return rows
+}
+
+func collectTrendPoints(
+	body body,
+	system string,
+	location *time.Location,
+) []trendPoint {
+	points := make([]trendPoint, defaultInt, len(body.MeasureGroups))
+
+	for _, group := range body.MeasureGroups {
+		for _, item := range group.Measures {
+			typeID := strconv.Itoa(item.Type)
+			unit := formatUnit(typeID, item.Unit)
+			points = append(points, trendPoint{
+				Date:   group.Date,
+				TypeID: typeID,
+				Value: units.Convert(
+					system,
+					quantityByUnit[unit],
+					scaledFloat(item.Value, item.Unit),
+				),
+				Row: convertRow(row{
+					Time:     formatTime(group.Date, location),
+					Type:     formatType(typeID),
+					Value:    formatScaledValue(item.Value, item.Unit),
+					Unit:     unit,
+					Category: formatCategory(group.Category),
+					Source:   formatSource(group.Attrib),
+					Mode:     formatMode(item.FM),
+				}, system),
+			})
+		}
+	}
+
+	sort.SliceStable(points, func(left, right int) bool {
+		return points[left].Date < points[right].Date
+	})
+
+	return points
+}
+
+func scaledFloat(value int64, unit int) float64 {
+	return float64(value) * math.Pow10(unit)
+}
+
+func formatChange(delta float64) string {
+	rounded := math.Round(delta*math.Pow10(trendDeltaDigits)) /
+		math.Pow10(trendDeltaDigits)
+	formatted := strconv.FormatFloat(
+		rounded,
+		'f',
+		trendPrecision,
+		trendFloatBits,
+	)
+
+	if rounded > defaultInt {
+		return trendPositive + formatted
+	}
+
+	return formatted
+}
+
+func trendBar(value float64, bounds valueRange) string {
+	width := trendBarWidth / 2
+
+	if spread := bounds.Max - bounds.Min; spread > defaultInt {
+		ratio := (value - bounds.Min) / spread
+		width = trendBarMin +
+			int(math.Round(ratio*float64(trendBarWidth-trendBarMin)))
+	}
+
+	return strings.Repeat(trendBarChar, width)
+}
+
+func writePlainTrend(appOpts app.Options, rows []trendRow) error {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, trendPlainHeader)
+
+	for _, entry := range rows {
+		lines = append(lines, strings.Join([]string{
+			entry.Row.Time,
+			entry.Row.Type,
+			entry.Row.Value,
+			entry.Row.Unit,
+			entry.Change,
+		}, "\t"))
+	}
+
+	err := output.WritePlain(appOpts, lines)
+	if err != nil {
+		return fmt.Errorf("write plain output: %w", err)
+	}
+
+	return nil
+}
+
+func writeTrendTable(appOpts app.Options, rows []trendRow) error {
+	var buffer bytes.Buffer
+
+	writer := newTableWriter(&buffer)
+	_, _ = fmt.Fprintln(writer, trendTableHeader)
+
+	for _, entry := range rows {
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.Row.Time,
+			entry.Row.Type,
+			entry.Row.Value,
+			entry.Row.Unit,
+			entry.Change,
+			entry.Bar,
+		)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return fmt.Errorf("render trend table: %w", err)
+	}
+
+	err = output.WriteLine(appOpts, strings.TrimRight(buffer.String(), "\n"))
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
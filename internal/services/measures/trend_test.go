@@ -0,0 +1,93 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/units"
+)
+
+const (
+	trendTestVO2Type  = 123
+	trendTestUnit     = -1
+	trendTestFirst    = int64(1767052800)
+	trendTestSecond   = int64(1767139200)
+	trendTestThird    = int64(1767225600)
+	trendTestLow      = int64(420)
+	trendTestMid      = int64(435)
+	trendTestHigh     = int64(450)
+	trendTestRowCount = 3
+	trendTestGotFmt   = "%s got %q want %q"
+)
+
+// TestBuildTrendRows orders points oldest first with change and bar width.
+func TestBuildTrendRows(t *testing.T) {
+	t.Parallel()
+
+	rows := buildTrendRows(body{
+		UpdateTime: testDefaultInt64,
+		Timezone:   "UTC",
+		MeasureGroups: []group{
+			trendGroup(trendTestThird, trendTestMid),
+			trendGroup(trendTestFirst, trendTestLow),
+			trendGroup(trendTestSecond, trendTestHigh),
+		},
+		More:   false,
+		Offset: testDefaultInt,
+	}, units.Metric, time.UTC)
+
+	if len(rows) != trendTestRowCount {
+		t.Fatalf("rows got %d want %d", len(rows), trendTestRowCount)
+	}
+
+	assertTrend(t, "type", rows[0].Row.Type, "vo2max")
+	assertTrend(t, "unit", rows[0].Row.Unit, "ml/min/kg")
+	assertTrend(t, "first value", rows[0].Row.Value, "42")
+	assertTrend(t, "first change", rows[0].Change, testEmptyString)
+	assertTrend(t, "first bar", rows[0].Bar, trendBarChar)
+	assertTrend(t, "second change", rows[1].Change, "+3")
+	assertTrend(
+		t,
+		"second bar",
+		rows[1].Bar,
+		strings.Repeat(trendBarChar, trendBarWidth),
+	)
+	assertTrend(t, "third change", rows[2].Change, "-1.5")
+}
+
+// TestTrendBarFlatRange uses a mid-width bar when values do not change.
+func TestTrendBarFlatRange(t *testing.T) {
+	t.Parallel()
+
+	got := trendBar(1, valueRange{Min: 1, Max: 1})
+	want := strings.Repeat(trendBarChar, trendBarWidth/2)
+	assertTrend(t, "bar", got, want)
+}
+
+func trendGroup(date, value int64) group {
+	return group{
+		GroupID:  testDefaultInt64,
+		Attrib:   testDefaultInt,
+		Date:     date,
+		Category: testMeasureCategory,
+		DeviceID: testEmptyString,
+		Measures: []item{
+			{
+				Type:  trendTestVO2Type,
+				Value: value,
+				Unit:  trendTestUnit,
+				FM:    nil,
+			},
+		},
+	}
+}
+
+func assertTrend(t *testing.T, label, got, want string) {
+	t.Helper()
+
+	if got != want {
+		t.Fatalf(trendTestGotFmt, label, got, want)
+	}
+}
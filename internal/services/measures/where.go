@@ -0,0 +1,200 @@
+package measures
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	whereValueColumn = "value"
+	whereAtLeast     = ">="
+	whereAtMost      = "<="
+	floatBitSize     = 64
+)
+
+var (
+	errInvalidWhere = errors.New(
+		"invalid --where (expected <column><op><value>, " +
+			"op one of = != > >= < <=)",
+	)
+	errUnknownWhereColumn = errors.New("unknown --where column")
+	errInvalidBound       = errors.New(
+		"invalid --min/--max (expected a number)",
+	)
+)
+
+// whereOperators lists the comparison operators, two-rune ones first so
+// ">=" is not read as ">".
+//
+//nolint:gochecknoglobals // Static operator table.
+var whereOperators = []string{"!=", ">=", "<=", "==", "=", ">", "<"}
+
+// condition is one parsed --where clause over a plain column.
+type condition struct {
+	Column   string
+	Operator string
+	Value    string
+}
+
+// parseConditions parses --where clauses and turns --min/--max into
+// value>= and value<= clauses. All of them must hold for a measure.
+func parseConditions(opts Options) ([]condition, error) {
+	conditions := make([]condition, defaultInt, len(opts.Where))
+
+	for _, clause := range opts.Where {
+		parsed, err := parseCondition(clause)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, parsed)
+	}
+
+	for _, bound := range []condition{
+		{Column: whereValueColumn, Operator: whereAtLeast, Value: opts.Min},
+		{Column: whereValueColumn, Operator: whereAtMost, Value: opts.Max},
+	} {
+		if bound.Value == emptyString {
+			continue
+		}
+
+		_, err := strconv.ParseFloat(bound.Value, floatBitSize)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errInvalidBound, bound.Value)
+		}
+
+		conditions = append(conditions, bound)
+	}
+
+	return conditions, nil
+}
+
+func parseCondition(clause string) (condition, error) {
+	for _, operator := range whereOperators {
+		column, value, found := strings.Cut(clause, operator)
+		if !found {
+			continue
+		}
+
+		column = strings.ToLower(strings.TrimSpace(column))
+		value = strings.TrimSpace(value)
+
+		if column == emptyString || value == emptyString {
+			break
+		}
+
+		if !slices.Contains(strings.Split(plainHeader, "\t"), column) {
+			return condition{}, fmt.Errorf(
+				"%w %q (available: %s)",
+				errUnknownWhereColumn,
+				column,
+				strings.ReplaceAll(plainHeader, "\t", ","),
+			)
+		}
+
+		return condition{Column: column, Operator: operator, Value: value},
+			nil
+	}
+
+	return condition{}, fmt.Errorf("%w: %q", errInvalidWhere, clause)
+}
+
+// match compares the cell with the clause value: as numbers when both
+// are numbers, otherwise = and != compare text and the ordering operators
+// do not match.
+func (c condition) match(cell string) bool {
+	left, leftErr := strconv.ParseFloat(cell, floatBitSize)
+	right, rightErr := strconv.ParseFloat(c.Value, floatBitSize)
+
+	if leftErr != nil || rightErr != nil {
+		switch c.Operator {
+		case "=", "==":
+			return strings.EqualFold(cell, c.Value)
+		case "!=":
+			return !strings.EqualFold(cell, c.Value)
+		default:
+			return false
+		}
+	}
+
+	switch c.Operator {
+	case ">":
+		return left > right
+	case whereAtLeast:
+		return left >= right
+	case "<":
+		return left < right
+	case whereAtMost:
+		return left <= right
+	case "!=":
+		return left != right
+	default:
+		return left == right
+	}
+}
+
+// valueFilter evaluates --where, --min, and --max on the output rows.
+type valueFilter struct {
+	Conditions []condition
+	Location   *time.Location
+	System     string
+	Guideline  string
+}
+
+// filterValues keeps the measures whose output row matches every
+// condition, with values in the --units system; groups left without
+// measures are removed.
+func filterValues(fetched body, filter valueFilter) body {
+	conditions := filter.Conditions
+	if len(conditions) == defaultInt {
+		return fetched
+	}
+
+	columns := strings.Split(plainHeader, "\t")
+	groups := make([]group, defaultInt, len(fetched.MeasureGroups))
+
+	for _, measureGroup := range fetched.MeasureGroups {
+		//nolint:exhaustruct // Only the groups are turned into rows.
+		single := body{MeasureGroups: []group{measureGroup}}
+		rows := convertRows(
+			buildRows(single, filter.Location, filter.Guideline),
+			filter.System,
+		)
+		kept := make([]item, defaultInt, len(measureGroup.Measures))
+
+		for index, measure := range measureGroup.Measures {
+			cells := strings.Split(formatRow(rows[index]), "\t")
+			if matchAll(conditions, columns, cells) {
+				kept = append(kept, measure)
+			}
+		}
+
+		if len(kept) > defaultInt {
+			measureGroup.Class = groupClassification(
+				measureGroup,
+				filter.Guideline,
+			)
+			measureGroup.Measures = kept
+			groups = append(groups, measureGroup)
+		}
+	}
+
+	fetched.MeasureGroups = groups
+
+	return fetched
+}
+
+func matchAll(conditions []condition, columns, cells []string) bool {
+	for _, clause := range conditions {
+		index := slices.Index(columns, clause.Column)
+		if !clause.match(cells[index]) {
+			return false
+		}
+	}
+
+	return true
+}
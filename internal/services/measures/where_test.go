@@ -0,0 +1,107 @@
+//nolint:testpackage // test unexported helpers.
+package measures
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/units"
+)
+
+// TestParseConditions reads operators and turns --min/--max into clauses.
+func TestParseConditions(t *testing.T) {
+	t.Parallel()
+
+	var opts Options
+
+	opts.Where = []string{"Value >= 100", "type!=weight"}
+	opts.Min = "50"
+
+	got, err := parseConditions(opts)
+	if err != nil {
+		t.Fatalf("parseConditions: %v", err)
+	}
+
+	want := []condition{
+		{Column: "value", Operator: ">=", Value: "100"},
+		{Column: "type", Operator: "!=", Value: "weight"},
+		{Column: "value", Operator: ">=", Value: "50"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %+v want %+v", got, want)
+	}
+
+	for index := range want {
+		if got[index] != want[index] {
+			t.Fatalf("got %+v want %+v", got, want)
+		}
+	}
+
+	opts.Where = []string{"pulse>3"}
+
+	_, err = parseConditions(opts)
+	if !errors.Is(err, errUnknownWhereColumn) {
+		t.Fatalf("got %v want %v", err, errUnknownWhereColumn)
+	}
+
+	opts.Where = nil
+	opts.Max = "high"
+
+	_, err = parseConditions(opts)
+	if !errors.Is(err, errInvalidBound) {
+		t.Fatalf("got %v want %v", err, errInvalidBound)
+	}
+}
+
+// TestFilterValues compares converted values and keeps the group class.
+func TestFilterValues(t *testing.T) {
+	t.Parallel()
+
+	var fetched body
+
+	//nolint:exhaustruct // Only dates and measures matter.
+	fetched.MeasureGroups = []group{
+		{
+			Date: 1700000000,
+			Measures: []item{
+				{Type: typeBPSystolic, Value: 145, Unit: 0, FM: nil},
+				{Type: typeBPDiastolic, Value: 85, Unit: 0, FM: nil},
+			},
+		},
+		{
+			Date:     1700086400,
+			Measures: []item{{Type: 1, Value: 80000, Unit: -3, FM: nil}},
+		},
+	}
+
+	var opts Options
+
+	opts.Where = []string{"value>100"}
+
+	conditions, err := parseConditions(opts)
+	if err != nil {
+		t.Fatalf("parseConditions: %v", err)
+	}
+
+	got := filterValues(fetched, valueFilter{
+		Conditions: conditions,
+		Location:   time.UTC,
+		System:     units.Imperial,
+		Guideline:  GuidelineAHA,
+	})
+
+	rows := buildRows(got, time.UTC, GuidelineAHA)
+	if len(rows) != 2 {
+		t.Fatalf("got %+v, want bp_sys and the weight in lb", rows)
+	}
+
+	if rows[0].Type != "bp_sys" || rows[0].Classification != "stage2" {
+		t.Fatalf("got %+v", rows[0])
+	}
+
+	if rows[1].Type != "weight" {
+		t.Fatalf("got %+v", rows[1])
+	}
+}
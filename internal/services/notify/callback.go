@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	userIDParam       = "userid"
+	startDateParam    = "startdate"
+	endDateParam      = "enddate"
+	contentTypeHeader = "Content-Type"
+	contentTypeForm   = "application/x-www-form-urlencoded"
+	testWindow        = time.Hour
+	testTimeout       = 10 * time.Second
+	// callbackDeadline is how long Withings waits for a callback to answer
+	// before it counts the delivery as failed.
+	callbackDeadline = 2 * time.Second
+	numberBase10     = 10
+)
+
+var (
+	errURLMissing = errors.New("--url is required")
+	errInvalidURL = errors.New(
+		"invalid --url (expected absolute http or https URL)",
+	)
+	errUserIDMissing = errors.New(
+		"--user-id is required when not logged in",
+	)
+	errCallbackStatus = errors.New("callback returned")
+)
+
+// TestOptions captures the synthetic callback parameters.
+type TestOptions struct {
+	URL    string
+	Appli  string
+	UserID string
+	Now    func() time.Time
+}
+
+//nolint:tagliatelle // Keep the Withings callback field names.
+type testResult struct {
+	URL        string `json:"url"`
+	Appli      int    `json:"appli"`
+	Type       string `json:"type"`
+	UserID     string `json:"userid"`
+	StartDate  int64  `json:"startdate"`
+	EndDate    int64  `json:"enddate"`
+	StatusCode int    `json:"status_code"`
+	Status     string `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// SendTest posts a synthetic notification to opts.URL the way Withings
+// delivers callbacks (a form-encoded POST with userid, appli, startdate,
+// and enddate covering the last hour) and reports the response. Responses
+// other than 2xx exit with code 1.
+func SendTest(
+	ctx context.Context,
+	opts TestOptions,
+	appOpts app.Options,
+) error {
+	result, err := buildTestResult(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	err = postCallback(ctx, &result)
+	if err != nil {
+		return err
+	}
+
+	err = writeTestResult(appOpts, result)
+	if err != nil {
+		return err
+	}
+
+	if result.StatusCode < http.StatusOK ||
+		result.StatusCode >= http.StatusMultipleChoices {
+		return app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("%w %s", errCallbackStatus, result.Status),
+		)
+	}
+
+	if time.Duration(result.DurationMS)*time.Millisecond > callbackDeadline {
+		return output.WriteWarning(appOpts, fmt.Sprintf(
+			"warning: callback took longer than %s; Withings treats "+
+				"slower answers as failed deliveries",
+			callbackDeadline,
+		))
+	}
+
+	return nil
+}
+
+func buildTestResult(opts TestOptions) (testResult, error) {
+	var result testResult
+
+	trimmed := strings.TrimSpace(opts.URL)
+	if trimmed == emptyString {
+		return result, errURLMissing
+	}
+
+	parsed, err := url.Parse(trimmed)
+	if err != nil || parsed.Host == emptyString ||
+		(parsed.Scheme != schemeHTTP && parsed.Scheme != schemeHTTPS) {
+		return result, fmt.Errorf("%w: %q", errInvalidURL, opts.URL)
+	}
+
+	if opts.Appli == emptyString {
+		return result, errAppliMissing
+	}
+
+	appli, err := ResolveAppli(opts.Appli)
+	if err != nil {
+		return result, err
+	}
+
+	if opts.UserID == emptyString {
+		return result, errUserIDMissing
+	}
+
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	end := now()
+
+	result.URL = trimmed
+	result.Appli, _ = strconv.Atoi(appli)
+	result.Type = AppliName(result.Appli)
+	result.UserID = opts.UserID
+	result.StartDate = end.Add(-testWindow).Unix()
+	result.EndDate = end.Unix()
+
+	return result, nil
+}
+
+// form encodes the fields Withings sends in a callback body.
+func (r testResult) form() url.Values {
+	values := url.Values{}
+	values.Set(userIDParam, r.UserID)
+	values.Set(appliParam, strconv.Itoa(r.Appli))
+	values.Set(startDateParam, strconv.FormatInt(r.StartDate, numberBase10))
+	values.Set(endDateParam, strconv.FormatInt(r.EndDate, numberBase10))
+
+	return values
+}
+
+func postCallback(ctx context.Context, result *testResult) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		result.URL,
+		strings.NewReader(result.form().Encode()),
+	)
+	if err != nil {
+		return fmt.Errorf("build callback request: %w", err)
+	}
+
+	req.Header.Set(contentTypeHeader, contentTypeForm)
+
+	//nolint:exhaustruct // Only the timeout differs from the defaults.
+	client := &http.Client{Timeout: testTimeout}
+	started := time.Now()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return app.NewExitError(
+			app.ExitCodeNetwork,
+			fmt.Errorf("post callback: %w", err),
+		)
+	}
+
+	_ = resp.Body.Close()
+
+	result.DurationMS = time.Since(started).Milliseconds()
+	result.StatusCode = resp.StatusCode
+	result.Status = resp.Status
+
+	return nil
+}
+
+func writeTestResult(appOpts app.Options, result testResult) error {
+	if appOpts.JSON {
+		return writeJSONOutput(appOpts, result)
+	}
+
+	err := output.WriteOutput(appOpts, fmt.Sprintf(
+		"Sent %s notification for user %s to %s: %s in %dms.",
+		result.Type,
+		result.UserID,
+		result.URL,
+		result.Status,
+		result.DurationMS,
+	))
+	if err != nil {
+		return fmt.Errorf("write notify output: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+//nolint:testpackage // test unexported helpers.
+package notify
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	testCallbackUserID = "12345"
+	testCallbackNow    = 1767225600
+)
+
+// TestBuildTestResult resolves the appli and covers the last hour.
+func TestBuildTestResult(t *testing.T) {
+	t.Parallel()
+
+	var opts TestOptions
+
+	opts.URL = " https://example.com/hook "
+	opts.Appli = "sleep"
+	opts.UserID = testCallbackUserID
+	opts.Now = func() time.Time { return time.Unix(testCallbackNow, 0) }
+
+	result, err := buildTestResult(opts)
+	if err != nil {
+		t.Fatalf("buildTestResult: %v", err)
+	}
+
+	form := result.form()
+	if form.Get(appliParam) != "44" ||
+		form.Get(userIDParam) != testCallbackUserID ||
+		form.Get(startDateParam) != "1767222000" ||
+		form.Get(endDateParam) != "1767225600" {
+		t.Fatalf("got %v", form)
+	}
+
+	if result.URL != "https://example.com/hook" || result.Type != "sleep" {
+		t.Fatalf("got %+v", result)
+	}
+}
+
+// TestBuildTestResultRejects checks the URL and user ID.
+func TestBuildTestResultRejects(t *testing.T) {
+	t.Parallel()
+
+	var opts TestOptions
+
+	opts.URL = "example.com/hook"
+	opts.Appli = "weight"
+
+	_, err := buildTestResult(opts)
+	if !errors.Is(err, errInvalidURL) {
+		t.Fatalf("got %v want errInvalidURL", err)
+	}
+
+	opts.URL = "http://localhost/hook"
+
+	_, err = buildTestResult(opts)
+	if !errors.Is(err, errUserIDMissing) {
+		t.Fatalf("got %v want errUserIDMissing", err)
+	}
+}
+
+// TestPostCallback sends the form like Withings and records the status.
+func TestPostCallback(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testCallbackHandler))
+	defer server.Close()
+
+	var result testResult
+
+	result.URL = server.URL
+	result.Appli = 1
+	result.UserID = testCallbackUserID
+
+	err := postCallback(t.Context(), &result)
+	if err != nil {
+		t.Fatalf("postCallback: %v", err)
+	}
+
+	if result.StatusCode != http.StatusOK {
+		t.Fatalf("got %d (%s)", result.StatusCode, result.Status)
+	}
+
+	result.UserID = "other"
+
+	err = postCallback(t.Context(), &result)
+	if err != nil || result.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %d (%v)", result.StatusCode, err)
+	}
+}
+
+func testCallbackHandler(writer http.ResponseWriter, request *http.Request) {
+	err := request.ParseForm()
+	if err != nil ||
+		request.Method != http.MethodPost ||
+		request.PostForm.Get(userIDParam) != testCallbackUserID ||
+		request.PostForm.Get(appliParam) != "1" {
+		writer.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
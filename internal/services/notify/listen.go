@@ -0,0 +1,394 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	secretParam         = "secret"
+	dateParam           = "date"
+	listenQueueSize     = 64
+	listenHeaderTimeout = 10 * time.Second
+	listenShutdown      = 5 * time.Second
+	envPrefix           = "WITHINGS_"
+	envEvent            = "WITHINGS_EVENT"
+)
+
+var (
+	errExecMissing    = errors.New("--exec is empty")
+	errInvalidPath    = errors.New("invalid --path (expected /...)")
+	errSecretMismatch = errors.New("secret mismatch")
+	errQueueFull      = errors.New("event queue full or closed")
+	errBadForm        = errors.New("invalid callback body")
+)
+
+// ListenOptions configures the notification receiver.
+type ListenOptions struct {
+	Addr   string
+	Path   string
+	Exec   string
+	Secret string
+}
+
+// Event is one received notification. Form fields other than the common
+// ones are kept in Extra.
+//
+//nolint:tagliatelle // Keep the Withings callback field names.
+type Event struct {
+	ReceivedAt string            `json:"received_at"`
+	UserID     string            `json:"userid"`
+	Appli      int               `json:"appli"`
+	Type       string            `json:"type"`
+	StartDate  int64             `json:"startdate,omitempty"`
+	EndDate    int64             `json:"enddate,omitempty"`
+	Date       string            `json:"date,omitempty"`
+	Extra      map[string]string `json:"extra,omitempty"`
+}
+
+// Listen receives Withings notification callbacks on opts.Addr until
+// SIGINT or SIGTERM. Every event is printed as one NDJSON line, or passed
+// to opts.Exec on stdin when set. Events are handled in arrival order
+// after the callback has been answered, since Withings only waits two
+// seconds.
+func Listen(
+	ctx context.Context,
+	opts ListenOptions,
+	appOpts app.Options,
+) error {
+	command, err := parseListenOptions(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var listenConfig net.ListenConfig
+
+	listener, err := listenConfig.Listen(ctx, "tcp", opts.Addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", opts.Addr, err)
+	}
+
+	receiver := newReceiver(opts, appOpts)
+
+	//nolint:exhaustruct // Optional server fields are omitted.
+	server := &http.Server{
+		Handler:           receiver,
+		ReadHeaderTimeout: listenHeaderTimeout,
+	}
+
+	err = output.WriteProgress(appOpts, fmt.Sprintf(
+		"listening for Withings notifications on http://%s%s",
+		listener.Addr(),
+		opts.Path,
+	))
+	if err != nil {
+		_ = listener.Close()
+
+		return err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		receiver.process(ctx, command)
+		close(done)
+	}()
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		err = shutdownReceiver(server)
+	}
+
+	// A failed or timed-out shutdown can leave handlers running, so the
+	// queue is closed under the lock they send with.
+	receiver.close()
+	<-done
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+func parseListenOptions(opts ListenOptions) ([]string, error) {
+	if !strings.HasPrefix(opts.Path, "/") {
+		return nil, fmt.Errorf("%w: %q", errInvalidPath, opts.Path)
+	}
+
+	if opts.Exec == emptyString {
+		return nil, nil
+	}
+
+	command := strings.Fields(opts.Exec)
+	if len(command) == defaultInt {
+		return nil, errExecMissing
+	}
+
+	return command, nil
+}
+
+//nolint:contextcheck // The parent context is already cancelled.
+func shutdownReceiver(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), listenShutdown)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("shutdown receiver: %w", err)
+	}
+
+	return nil
+}
+
+type receiver struct {
+	opts    ListenOptions
+	appOpts app.Options
+	events  chan Event
+	now     func() time.Time
+	// mu guards closed and every send on events.
+	mu     sync.Mutex
+	closed bool
+}
+
+func newReceiver(opts ListenOptions, appOpts app.Options) *receiver {
+	return &receiver{
+		opts:    opts,
+		appOpts: appOpts,
+		events:  make(chan Event, listenQueueSize),
+		now:     time.Now,
+		mu:      sync.Mutex{},
+		closed:  false,
+	}
+}
+
+// ServeHTTP answers Withings' HEAD/GET reachability checks and queues
+// POSTed notifications.
+func (r *receiver) ServeHTTP(
+	writer http.ResponseWriter,
+	request *http.Request,
+) {
+	if request.URL.Path != r.opts.Path {
+		http.NotFound(writer, request)
+
+		return
+	}
+
+	if !r.authorized(request) {
+		r.warn(fmt.Sprintf(
+			"rejected %s: %s",
+			request.RemoteAddr,
+			errSecretMismatch,
+		))
+		writer.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if request.Method != http.MethodPost {
+		writer.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	event, err := r.parseEvent(request)
+	if err != nil {
+		r.warn(err.Error())
+		writer.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	if !r.enqueue(event) {
+		// Withings retries failed deliveries, so ask it to come back.
+		r.warn(errQueueFull.Error())
+		writer.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+// enqueue queues event unless the queue is full or closed.
+func (r *receiver) enqueue(event Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return false
+	}
+
+	select {
+	case r.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops the queue; handlers still running afterwards answer 503
+// instead of sending on the closed channel.
+func (r *receiver) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+}
+
+// authorized checks the secret query parameter. Withings does not sign
+// callbacks, so a secret embedded in the subscribed callback URL is the
+// only way to tell its requests apart.
+func (r *receiver) authorized(request *http.Request) bool {
+	if r.opts.Secret == emptyString {
+		return true
+	}
+
+	got := request.URL.Query().Get(secretParam)
+
+	return subtle.ConstantTimeCompare([]byte(got), []byte(r.opts.Secret)) == 1
+}
+
+func (r *receiver) parseEvent(request *http.Request) (Event, error) {
+	err := request.ParseForm()
+	if err != nil {
+		return Event{}, fmt.Errorf("%w: %w", errBadForm, err)
+	}
+
+	form := request.PostForm
+	appli, _ := strconv.Atoi(form.Get(appliParam))
+	start, _ := strconv.ParseInt(form.Get(startDateParam), numberBase10, 64)
+	end, _ := strconv.ParseInt(form.Get(endDateParam), numberBase10, 64)
+
+	event := Event{
+		ReceivedAt: r.now().UTC().Format(time.RFC3339),
+		UserID:     form.Get(userIDParam),
+		Appli:      appli,
+		Type:       AppliName(appli),
+		StartDate:  start,
+		EndDate:    end,
+		Date:       form.Get(dateParam),
+		Extra:      nil,
+	}
+
+	for key := range form {
+		if eventFields[key] {
+			continue
+		}
+
+		if event.Extra == nil {
+			event.Extra = map[string]string{}
+		}
+
+		event.Extra[key] = form.Get(key)
+	}
+
+	return event, nil
+}
+
+//nolint:gochecknoglobals // Static set of fields mapped onto Event.
+var eventFields = map[string]bool{
+	userIDParam:    true,
+	appliParam:     true,
+	startDateParam: true,
+	endDateParam:   true,
+	dateParam:      true,
+}
+
+// process handles queued events until the queue is closed.
+func (r *receiver) process(ctx context.Context, command []string) {
+	for event := range r.events {
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			r.warn(fmt.Sprintf("encode event: %v", err))
+
+			continue
+		}
+
+		if command == nil {
+			_ = output.WriteLine(r.appOpts, string(encoded))
+
+			continue
+		}
+
+		err = runExec(ctx, r.appOpts, command, event, encoded)
+		if err != nil {
+			r.warn(fmt.Sprintf("%s: %v", command[0], err))
+		}
+	}
+}
+
+// runExec passes the event JSON on stdin and its main fields as
+// WITHINGS_* environment variables. Commands are not cancelled on
+// shutdown, so queued events still finish.
+func runExec(
+	ctx context.Context,
+	appOpts app.Options,
+	command []string,
+	event Event,
+	encoded []byte,
+) error {
+	//nolint:gosec // Running the user's --exec command is the feature.
+	cmd := exec.CommandContext(
+		context.WithoutCancel(ctx),
+		command[0],
+		command[1:]...,
+	)
+	cmd.Stdin = bytes.NewReader(encoded)
+	cmd.Stdout = output.Stdout(appOpts)
+	cmd.Stderr = output.Stderr(appOpts)
+	cmd.Env = append(
+		os.Environ(),
+		envEvent+"="+string(encoded),
+		envPrefix+"USERID="+event.UserID,
+		envPrefix+"APPLI="+strconv.Itoa(event.Appli),
+		envPrefix+"TYPE="+event.Type,
+		envPrefix+"STARTDATE="+formatEpoch(event.StartDate),
+		envPrefix+"ENDDATE="+formatEpoch(event.EndDate),
+	)
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+
+	return nil
+}
+
+func formatEpoch(epoch int64) string {
+	return strconv.FormatInt(epoch, numberBase10)
+}
+
+func (r *receiver) warn(message string) {
+	_ = output.WriteWarning(r.appOpts, "notify listen: "+message)
+}
@@ -0,0 +1,164 @@
+//nolint:testpackage // test unexported helpers.
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const testListenSecret = "s3cret"
+
+// TestReceiverQueuesEvents parses callbacks and checks the secret.
+func TestReceiverQueuesEvents(t *testing.T) {
+	t.Parallel()
+
+	var (
+		opts    ListenOptions
+		appOpts app.Options
+	)
+
+	opts.Path = "/hook"
+	opts.Secret = testListenSecret
+	appOpts.Quiet = true
+
+	receiver := newReceiver(opts, appOpts)
+	receiver.now = func() time.Time { return time.Unix(testCallbackNow, 0) }
+
+	form := url.Values{}
+	form.Set(userIDParam, testCallbackUserID)
+	form.Set(appliParam, "44")
+	form.Set(startDateParam, "1767222000")
+	form.Set(endDateParam, "1767225600")
+	form.Set("deviceid", "abc")
+
+	target := "/hook?secret=" + testListenSecret
+	cases := []struct {
+		Target string
+		Method string
+		Code   int
+	}{
+		{target, http.MethodHead, http.StatusOK},
+		{"/hook?secret=wrong", http.MethodPost, http.StatusUnauthorized},
+		{"/other", http.MethodPost, http.StatusNotFound},
+		{target, http.MethodPost, http.StatusOK},
+	}
+
+	for _, item := range cases {
+		request := httptest.NewRequestWithContext(
+			t.Context(),
+			item.Method,
+			item.Target,
+			strings.NewReader(form.Encode()),
+		)
+		request.Header.Set(contentTypeHeader, contentTypeForm)
+
+		recorder := httptest.NewRecorder()
+		receiver.ServeHTTP(recorder, request)
+
+		if recorder.Code != item.Code {
+			t.Fatalf("%s %s: got %d", item.Method, item.Target, recorder.Code)
+		}
+	}
+
+	if len(receiver.events) != 1 {
+		t.Fatalf("got %d queued events", len(receiver.events))
+	}
+
+	event := <-receiver.events
+	if event.Type != "sleep" || event.UserID != testCallbackUserID ||
+		event.StartDate != 1767222000 || event.Extra["deviceid"] != "abc" ||
+		event.ReceivedAt != "2026-01-01T00:00:00Z" {
+		t.Fatalf("got %+v", event)
+	}
+}
+
+// TestReceiverAfterClose answers late callbacks with 503 instead of
+// sending on the closed queue.
+func TestReceiverAfterClose(t *testing.T) {
+	t.Parallel()
+
+	var (
+		opts    ListenOptions
+		appOpts app.Options
+	)
+
+	opts.Path = "/hook"
+	appOpts.Quiet = true
+
+	receiver := newReceiver(opts, appOpts)
+	receiver.close()
+	receiver.close()
+
+	request := httptest.NewRequestWithContext(
+		t.Context(),
+		http.MethodPost,
+		"/hook",
+		strings.NewReader(userIDParam+"="+testCallbackUserID),
+	)
+	request.Header.Set(contentTypeHeader, contentTypeForm)
+
+	recorder := httptest.NewRecorder()
+	receiver.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d want 503", recorder.Code)
+	}
+}
+
+// TestRunExec passes the event on stdin and in the environment.
+func TestRunExec(t *testing.T) {
+	t.Parallel()
+
+	var event Event
+
+	event.Appli = 1
+	event.Type = "weight"
+
+	command := []string{
+		"sh",
+		"-c",
+		`test "$WITHINGS_TYPE" = weight && grep -q '"appli":1'`,
+	}
+
+	var opts app.Options
+
+	err := runExec(t.Context(), opts, command, event, []byte(`{"appli":1}`))
+	if err != nil {
+		t.Fatalf("runExec: %v", err)
+	}
+
+	event.Type = "sleep"
+
+	err = runExec(t.Context(), opts, command, event, []byte(`{"appli":1}`))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestParseListenOptions validates --path and --exec.
+func TestParseListenOptions(t *testing.T) {
+	t.Parallel()
+
+	var opts ListenOptions
+
+	opts.Path = "hook"
+
+	_, err := parseListenOptions(opts)
+	if err == nil {
+		t.Fatal("expected path error")
+	}
+
+	opts.Path = "/"
+	opts.Exec = "  ./notify.sh  --flag "
+
+	command, err := parseListenOptions(opts)
+	if err != nil || len(command) != 2 || command[1] != "--flag" {
+		t.Fatalf("got %q (%v)", command, err)
+	}
+}
@@ -0,0 +1,599 @@
+// Package notify handles Withings notification subscriptions.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	serviceName     = "notify"
+	actionSubscribe = "subscribe"
+	actionList      = "list"
+	actionGet       = "get"
+	actionRevoke    = "revoke"
+	callbackParam   = "callbackurl"
+	appliParam      = "appli"
+	commentParam    = "comment"
+	schemeHTTP      = "http"
+	schemeHTTPS     = "https"
+	rowsHeaderCount = 1
+	tableMinWidth   = 0
+	tableTabWidth   = 0
+	tablePadding    = 2
+	tablePadChar    = ' '
+	tableFlags      = 0
+	tableHeader     = "Appli\tType\tCallback URL\tComment\tExpires"
+	plainHeader     = "appli\ttype\tcallback_url\tcomment\texpires"
+	defaultInt      = 0
+	defaultInt64    = 0
+	emptyString     = ""
+)
+
+var (
+	errInvalidAppli    = errors.New("invalid --appli")
+	errInvalidCallback = errors.New(
+		"invalid --callback-url (expected absolute http or https URL)",
+	)
+	errCallbackMissing = errors.New("--callback-url is required")
+	errAppliMissing    = errors.New("--appli is required")
+)
+
+// Options captures notification subscription parameters.
+type Options struct {
+	CallbackURL string
+	Appli       string
+	Comment     string
+}
+
+// Subscribe registers a callback URL for an appli type.
+func Subscribe(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	values, err := buildTargetParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	if opts.Comment != emptyString {
+		values.Set(commentParam, opts.Comment)
+	}
+
+	_, err = call(ctx, appOpts, accessToken, actionSubscribe, values)
+	if err != nil {
+		return err
+	}
+
+	return writeConfirmation(appOpts, "Subscribed", values)
+}
+
+// List shows active subscriptions, optionally filtered by appli type.
+func List(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	values := url.Values{}
+
+	if opts.Appli != emptyString {
+		appli, err := ResolveAppli(opts.Appli)
+		if err != nil {
+			return app.NewExitError(app.ExitCodeUsage, err)
+		}
+
+		values.Set(appliParam, appli)
+	}
+
+	payload, err := call(ctx, appOpts, accessToken, actionList, values)
+	if err != nil {
+		return err
+	}
+
+	var decoded listBody
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	if appOpts.JSON {
+		return writeJSONOutput(appOpts, decoded)
+	}
+
+	return writeRows(appOpts, buildRows(decoded.Profiles))
+}
+
+// Get shows a single subscription for a callback URL and appli type.
+func Get(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	values, err := buildTargetParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	payload, err := call(ctx, appOpts, accessToken, actionGet, values)
+	if err != nil {
+		return err
+	}
+
+	var decoded profile
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	if decoded.Appli == defaultInt {
+		decoded.Appli, _ = strconv.Atoi(values.Get(appliParam))
+	}
+
+	if decoded.CallbackURL == emptyString {
+		decoded.CallbackURL = values.Get(callbackParam)
+	}
+
+	if appOpts.JSON {
+		return writeJSONOutput(appOpts, decoded)
+	}
+
+	return writeRows(appOpts, buildRows([]profile{decoded}))
+}
+
+// Revoke removes the subscription for a callback URL and appli type.
+func Revoke(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	values, err := buildTargetParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	_, err = call(ctx, appOpts, accessToken, actionRevoke, values)
+	if err != nil {
+		return err
+	}
+
+	return writeConfirmation(appOpts, "Revoked", values)
+}
+
+func buildTargetParams(opts Options) (url.Values, error) {
+	values := url.Values{}
+
+	callback, err := ParseCallbackURL(opts.CallbackURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Appli == emptyString {
+		return nil, errAppliMissing
+	}
+
+	appli, err := ResolveAppli(opts.Appli)
+	if err != nil {
+		return nil, err
+	}
+
+	values.Set(callbackParam, callback)
+	values.Set(appliParam, appli)
+
+	return values, nil
+}
+
+// ParseCallbackURL validates an absolute http or https callback URL.
+func ParseCallbackURL(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == emptyString {
+		return emptyString, errCallbackMissing
+	}
+
+	parsed, err := url.Parse(trimmed)
+	if err != nil {
+		return emptyString, fmt.Errorf("%w: %w", errInvalidCallback, err)
+	}
+
+	if parsed.Host == emptyString ||
+		(parsed.Scheme != schemeHTTP && parsed.Scheme != schemeHTTPS) {
+		return emptyString, fmt.Errorf("%w: %q", errInvalidCallback, raw)
+	}
+
+	return trimmed, nil
+}
+
+// ResolveAppli maps an appli alias or numeric ID to its numeric ID.
+func ResolveAppli(value string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(value))
+
+	if _, err := strconv.Atoi(normalized); err == nil {
+		return normalized, nil
+	}
+
+	appli, ok := appliByName[normalized]
+	if !ok {
+		return emptyString, fmt.Errorf("%w: %q", errInvalidAppli, value)
+	}
+
+	return strconv.Itoa(appli), nil
+}
+
+// AppliChoices lists the accepted --appli names and their IDs.
+func AppliChoices() []output.Choice {
+	choices := make([]output.Choice, defaultInt, len(appliByName))
+	for name, id := range appliByName {
+		choices = append(choices, output.Choice{
+			Name:  name,
+			Value: strconv.Itoa(id),
+		})
+	}
+
+	return choices
+}
+
+//nolint:gochecknoglobals // Static lookup tables for appli metadata.
+var (
+	appliByName = map[string]int{
+		"weight":       1,
+		"temperature":  2,
+		"pressure":     4,
+		"bp":           4,
+		"activity":     16,
+		"sleep":        44,
+		"user":         46,
+		"bed_in":       50,
+		"bed_out":      51,
+		"inflate_done": 52,
+		"no_account":   53,
+		"ecg":          54,
+		"ecg_failed":   55,
+		"glucose":      58,
+	}
+	appliNameByID = map[int]string{
+		1:  "weight",
+		2:  "temperature",
+		4:  "pressure",
+		16: "activity",
+		44: "sleep",
+		46: "user",
+		50: "bed_in",
+		51: "bed_out",
+		52: "inflate_done",
+		53: "no_account",
+		54: "ecg",
+		55: "ecg_failed",
+		58: "glucose",
+	}
+)
+
+func call(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	action string,
+	values url.Values,
+) (json.RawMessage, error) {
+	err := withings.CheckWrite(appOpts, serviceName, action)
+	if err != nil {
+		return nil, err
+	}
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud),
+		serviceName,
+		action,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	return decodeResponse(payload)
+}
+
+type response struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+	Error  string          `json:"error"`
+	Detail string          `json:"detail"`
+}
+
+type listBody struct {
+	Profiles []profile `json:"profiles"`
+}
+
+type profile struct {
+	Appli       int    `json:"appli"`
+	CallbackURL string `json:"callbackurl"`
+	Comment     string `json:"comment"`
+	Expires     int64  `json:"expires,omitempty"`
+}
+
+type row struct {
+	Appli       string
+	Type        string
+	CallbackURL string
+	Comment     string
+	Expires     string
+}
+
+func decodeResponse(payload []byte) (json.RawMessage, error) {
+	var decoded response
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	if decoded.Status != withings.StatusOK {
+		message := decoded.Error
+		if message == emptyString {
+			message = decoded.Detail
+		}
+
+		if message == emptyString {
+			message = strings.TrimSpace(string(payload))
+		}
+
+		return nil, app.NewExitError(
+			app.ExitCodeAPI,
+			&withings.APIError{Status: decoded.Status, Message: message},
+		)
+	}
+
+	if len(decoded.Body) == defaultInt {
+		return json.RawMessage("{}"), nil
+	}
+
+	return decoded.Body, nil
+}
+
+func decodeError(err error) error {
+	return app.NewExitError(
+		app.ExitCodeFailure,
+		fmt.Errorf("decode api response: %w", err),
+	)
+}
+
+func buildRows(profiles []profile) []row {
+	rows := make([]row, defaultInt, len(profiles))
+
+	for _, profile := range profiles {
+		rows = append(rows, row{
+			Appli:       strconv.Itoa(profile.Appli),
+			Type:        AppliName(profile.Appli),
+			CallbackURL: profile.CallbackURL,
+			Comment:     profile.Comment,
+			Expires:     formatExpires(profile.Expires),
+		})
+	}
+
+	return rows
+}
+
+// AppliName returns the alias of an appli ID, or the ID itself when it
+// has none.
+func AppliName(appli int) string {
+	if name, ok := appliNameByID[appli]; ok {
+		return name
+	}
+
+	return strconv.Itoa(appli)
+}
+
+func formatExpires(epoch int64) string {
+	if epoch == defaultInt64 {
+		return emptyString
+	}
+
+	return time.Unix(epoch, defaultInt64).UTC().Format(time.RFC3339)
+}
+
+func writeConfirmation(
+	appOpts app.Options,
+	verb string,
+	values url.Values,
+) error {
+	appli, _ := strconv.Atoi(values.Get(appliParam))
+	callback := values.Get(callbackParam)
+
+	var data any = fmt.Sprintf(
+		"%s %s for %s notifications.",
+		verb,
+		callback,
+		AppliName(appli),
+	)
+
+	if appOpts.JSON {
+		data = map[string]any{
+			"action":      strings.ToLower(verb),
+			"appli":       appli,
+			"type":        AppliName(appli),
+			"callbackurl": callback,
+		}
+	}
+
+	err := output.WriteOutput(appOpts, data)
+	if err != nil {
+		return fmt.Errorf("write notify output: %w", err)
+	}
+
+	return nil
+}
+
+func writeJSONOutput(opts app.Options, data any) error {
+	err := output.WriteRawJSON(opts, data)
+	if err != nil {
+		return fmt.Errorf("write json output: %w", err)
+	}
+
+	return nil
+}
+
+func writeRows(opts app.Options, rows []row) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.Plain || opts.NDJSON {
+		err := output.WritePlain(opts, formatLines(rows))
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	table, err := formatTable(rows)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func formatTable(rows []row) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(
+		&buffer,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableFlags,
+	)
+	_, _ = fmt.Fprintln(writer, tableHeader)
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\n",
+			row.Appli,
+			row.Type,
+			row.CallbackURL,
+			row.Comment,
+			row.Expires,
+		)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render notify table: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+func formatLines(rows []row) []string {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, plainHeader)
+
+	for _, row := range rows {
+		lines = append(lines, strings.Join([]string{
+			row.Appli,
+			row.Type,
+			row.CallbackURL,
+			row.Comment,
+			row.Expires,
+		}, "\t"))
+	}
+
+	return lines
+}
+
+// Subscription is one callback URL subscribed to one appli type.
+type Subscription struct {
+	Appli       int
+	CallbackURL string
+	Expires     int64
+}
+
+// ListSubscriptions returns the account's active subscriptions.
+func ListSubscriptions(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+) ([]Subscription, error) {
+	payload, err := call(ctx, appOpts, accessToken, actionList, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded listBody
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	subscriptions := make([]Subscription, defaultInt, len(decoded.Profiles))
+	for _, profile := range decoded.Profiles {
+		subscriptions = append(subscriptions, Subscription{
+			Appli:       profile.Appli,
+			CallbackURL: profile.CallbackURL,
+			Expires:     profile.Expires,
+		})
+	}
+
+	return subscriptions, nil
+}
+
+// SubscribeCallback subscribes callbackURL to appli without writing
+// output; --read-only refuses it like subscribe.
+func SubscribeCallback(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	target Subscription,
+	comment string,
+) error {
+	values := url.Values{}
+	values.Set(callbackParam, target.CallbackURL)
+	values.Set(appliParam, strconv.Itoa(target.Appli))
+
+	if comment != emptyString {
+		values.Set(commentParam, comment)
+	}
+
+	_, err := call(ctx, appOpts, accessToken, actionSubscribe, values)
+
+	return err
+}
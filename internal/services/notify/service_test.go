@@ -0,0 +1,123 @@
+//nolint:testpackage // test unexported helpers.
+package notify
+
+import (
+	"errors"
+	"testing"
+)
+
+const (
+	testCallback    = "https://example.com/withings"
+	testAppliWeight = "1"
+	testAppliSleep  = "44"
+	testExpires     = 1767052800
+	testRowCount    = 1
+	testEmpty       = ""
+	testParamFmt    = "%s got %q want %q"
+)
+
+// TestResolveAppli maps aliases and numeric IDs.
+func TestResolveAppli(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"weight":  testAppliWeight,
+		" Sleep ": testAppliSleep,
+		"bp":      "4",
+		"54":      "54",
+	}
+
+	for input, want := range cases {
+		got, err := ResolveAppli(input)
+		if err != nil {
+			t.Fatalf("ResolveAppli(%q): %v", input, err)
+		}
+
+		if got != want {
+			t.Fatalf(testParamFmt, input, got, want)
+		}
+	}
+}
+
+// TestResolveAppliRejectsUnknown rejects unknown aliases.
+func TestResolveAppliRejectsUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := ResolveAppli("steps")
+	if !errors.Is(err, errInvalidAppli) {
+		t.Fatalf("err got %v want %v", err, errInvalidAppli)
+	}
+}
+
+// TestBuildTargetParams sets callback and appli params.
+func TestBuildTargetParams(t *testing.T) {
+	t.Parallel()
+
+	values, err := buildTargetParams(Options{
+		CallbackURL: testCallback,
+		Appli:       "weight",
+		Comment:     testEmpty,
+	})
+	if err != nil {
+		t.Fatalf("buildTargetParams: %v", err)
+	}
+
+	assertValue(t, callbackParam, values.Get(callbackParam), testCallback)
+	assertValue(t, appliParam, values.Get(appliParam), testAppliWeight)
+}
+
+// TestBuildTargetParamsRejectsCallback rejects non-HTTP callback URLs.
+func TestBuildTargetParamsRejectsCallback(t *testing.T) {
+	t.Parallel()
+
+	for _, callback := range []string{"ftp://example.com", "/hook", testEmpty} {
+		_, err := buildTargetParams(Options{
+			CallbackURL: callback,
+			Appli:       "weight",
+			Comment:     testEmpty,
+		})
+		if err == nil {
+			t.Fatalf("callback %q: expected error", callback)
+		}
+	}
+}
+
+// TestBuildRows names appli types and formats expiry.
+func TestBuildRows(t *testing.T) {
+	t.Parallel()
+
+	rows := buildRows([]profile{{
+		Appli:       44,
+		CallbackURL: testCallback,
+		Comment:     "cli",
+		Expires:     testExpires,
+	}})
+
+	if len(rows) != testRowCount {
+		t.Fatalf("rows got %d want %d", len(rows), testRowCount)
+	}
+
+	assertValue(t, "appli", rows[0].Appli, testAppliSleep)
+	assertValue(t, "type", rows[0].Type, "sleep")
+	assertValue(t, "expires", rows[0].Expires, "2025-12-30T00:00:00Z")
+}
+
+// TestDecodeResponseEmptyBody tolerates missing bodies.
+func TestDecodeResponseEmptyBody(t *testing.T) {
+	t.Parallel()
+
+	body, err := decodeResponse([]byte(`{"status":0}`))
+	if err != nil {
+		t.Fatalf("decodeResponse: %v", err)
+	}
+
+	assertValue(t, "body", string(body), "{}")
+}
+
+func assertValue(t *testing.T, label, got, want string) {
+	t.Helper()
+
+	if got != want {
+		t.Fatalf(testParamFmt, label, got, want)
+	}
+}
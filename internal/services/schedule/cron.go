@@ -0,0 +1,158 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	crontabBinary  = "crontab"
+	crontabEmpty   = "no crontab"
+	cronComment    = "# "
+	cronHourly     = "0 * * * *"
+	cronDaily      = "%d %d * * *"
+	cronLineFields = 6
+)
+
+// cron keeps each schedule as a marker comment followed by its entry in
+// the user crontab and leaves every other line untouched.
+type cron struct {
+	env env
+}
+
+func (cron) Name() string {
+	return BackendCron
+}
+
+func (c cron) Install(ctx context.Context, entry job) (plan, error) {
+	lines, err := c.read(ctx)
+	if err != nil {
+		return plan{}, err
+	}
+
+	lines, _ = withoutCronJob(lines, entry.Name)
+
+	when := cronHourly
+	if !entry.When.Hourly {
+		when = fmt.Sprintf(cronDaily, entry.When.Minute, entry.When.Hour)
+	}
+
+	lines = append(
+		lines,
+		cronComment+entry.Marker,
+		when+" "+strings.ReplaceAll(shellJoin(entry.Argv), "%", `\%`),
+	)
+
+	return c.plan(lines), nil
+}
+
+func (c cron) Remove(ctx context.Context, name string) (plan, error) {
+	lines, err := c.read(ctx)
+	if err != nil {
+		return plan{}, err
+	}
+
+	lines, found := withoutCronJob(lines, name)
+	if !found {
+		return plan{}, fmt.Errorf("%w: %s", errScheduleMissing, name)
+	}
+
+	return c.plan(lines), nil
+}
+
+func (c cron) List(ctx context.Context) ([]Job, error) {
+	lines, err := c.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []Job{}
+
+	for index := 0; index+1 < len(lines); index++ {
+		comment, ok := strings.CutPrefix(lines[index], cronComment)
+		if !ok {
+			continue
+		}
+
+		name, when, ok := parseMarker(comment)
+		if !ok {
+			continue
+		}
+
+		fields := strings.SplitN(lines[index+1], " ", cronLineFields)
+
+		command := emptyString
+		if len(fields) == cronLineFields {
+			command = strings.ReplaceAll(fields[cronLineFields-1], `\%`, "%")
+		}
+
+		jobs = append(jobs, Job{
+			Name:     name,
+			Backend:  BackendCron,
+			Schedule: when,
+			Command:  command,
+		})
+	}
+
+	return jobs, nil
+}
+
+// read returns the current crontab lines. A missing crontab is empty;
+// any other failure stops the install, so a crontab that could not be
+// read is never overwritten.
+func (c cron) read(ctx context.Context) ([]string, error) {
+	out, err := c.env.Run(ctx, emptyString, crontabBinary, "-l")
+	if err != nil {
+		if strings.Contains(strings.ToLower(out), crontabEmpty) {
+			return []string{}, nil
+		}
+
+		return nil, fmt.Errorf("%w: %w", errNoCrontab, err)
+	}
+
+	trimmed := strings.TrimRight(out, "\n")
+	if trimmed == emptyString {
+		return []string{}, nil
+	}
+
+	return strings.Split(trimmed, "\n"), nil
+}
+
+func (cron) plan(lines []string) plan {
+	content := emptyString
+	if len(lines) > defaultInt {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+
+	return plan{
+		Write:  nil,
+		Remove: nil,
+		Run: []plannedCommand{
+			{Argv: []string{crontabBinary, "-"}, Stdin: content},
+		},
+	}
+}
+
+// withoutCronJob drops the marker line of name and the entry after it.
+func withoutCronJob(lines []string, name string) ([]string, bool) {
+	kept := make([]string, defaultInt, len(lines))
+	found := false
+
+	for index := 0; index < len(lines); index++ {
+		comment, ok := strings.CutPrefix(lines[index], cronComment)
+		if ok {
+			markerName, _, isMarker := parseMarker(comment)
+			if isMarker && markerName == name {
+				found = true
+				index++
+
+				continue
+			}
+		}
+
+		kept = append(kept, lines[index])
+	}
+
+	return kept, found
+}
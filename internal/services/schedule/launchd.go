@@ -0,0 +1,239 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	launchdAgentDir  = "Library/LaunchAgents"
+	launchdLogDir    = "Library/Logs"
+	launchdLabel     = "com.github.mreimbold.withings-cli."
+	launchdPlistExt  = ".plist"
+	launchdLogExt    = ".log"
+	launchctlBinary  = "launchctl"
+	launchdArgsKey   = "ProgramArguments"
+	launchdPlistHead = `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" ` +
+		`"http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n"
+)
+
+// launchd installs a user agent with StartCalendarInterval. Its output
+// goes to ~/Library/Logs/withings-cli-<name>.log.
+type launchd struct {
+	env env
+}
+
+func (launchd) Name() string {
+	return BackendLaunchd
+}
+
+func (l launchd) Install(_ context.Context, entry job) (plan, error) {
+	path := l.path(entry.Name)
+	logPath := filepath.Join(
+		l.env.Home,
+		launchdLogDir,
+		jobPrefix+entry.Name+launchdLogExt,
+	)
+
+	var buffer strings.Builder
+
+	buffer.WriteString(launchdPlistHead)
+	buffer.WriteString("<!-- " + entry.Marker + " -->\n")
+	buffer.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	plistKey(&buffer, "Label")
+	plistString(&buffer, launchdLabel+entry.Name)
+	plistKey(&buffer, launchdArgsKey)
+	buffer.WriteString("\t<array>\n")
+
+	for _, arg := range entry.Argv {
+		buffer.WriteString("\t")
+		plistString(&buffer, arg)
+	}
+
+	buffer.WriteString("\t</array>\n")
+	plistKey(&buffer, "StartCalendarInterval")
+	buffer.WriteString("\t<dict>\n")
+
+	if !entry.When.Hourly {
+		buffer.WriteString("\t")
+		plistKey(&buffer, "Hour")
+		buffer.WriteString("\t\t<integer>" + strconv.Itoa(entry.When.Hour) +
+			"</integer>\n")
+	}
+
+	buffer.WriteString("\t")
+	plistKey(&buffer, "Minute")
+	buffer.WriteString("\t\t<integer>" + strconv.Itoa(entry.When.Minute) +
+		"</integer>\n")
+	buffer.WriteString("\t</dict>\n")
+	plistKey(&buffer, "StandardOutPath")
+	plistString(&buffer, logPath)
+	plistKey(&buffer, "StandardErrorPath")
+	plistString(&buffer, logPath)
+	buffer.WriteString("</dict>\n</plist>\n")
+
+	return plan{
+		Write:  []plannedFile{{Path: path, Content: buffer.String()}},
+		Remove: nil,
+		Run: []plannedCommand{
+			{
+				Argv:  []string{launchctlBinary, "load", "-w", path},
+				Stdin: emptyString,
+			},
+		},
+	}, nil
+}
+
+func (l launchd) Remove(_ context.Context, name string) (plan, error) {
+	path := l.path(name)
+
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return plan{}, fmt.Errorf("%w: %s", errScheduleMissing, name)
+	}
+
+	return plan{
+		Write:  nil,
+		Remove: []string{path},
+		Run: []plannedCommand{
+			{
+				Argv:  []string{launchctlBinary, "unload", "-w", path},
+				Stdin: emptyString,
+			},
+		},
+	}, nil
+}
+
+func (l launchd) List(_ context.Context) ([]Job, error) {
+	plists, err := filepath.Glob(l.path("*"))
+	if err != nil {
+		return nil, fmt.Errorf("list launchd agents: %w", err)
+	}
+
+	jobs := []Job{}
+
+	for _, path := range plists {
+		//nolint:gosec // Path comes from a glob in the agent directory.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		name, when, argv, ok := parsePlist(data)
+		if !ok {
+			continue
+		}
+
+		jobs = append(jobs, Job{
+			Name:     name,
+			Backend:  BackendLaunchd,
+			Schedule: when,
+			Command:  shellJoin(argv),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (l launchd) path(name string) string {
+	return filepath.Join(
+		l.env.Home,
+		launchdAgentDir,
+		launchdLabel+name+launchdPlistExt,
+	)
+}
+
+func plistKey(buffer *strings.Builder, key string) {
+	buffer.WriteString("\t<key>" + key + "</key>\n")
+}
+
+func plistString(buffer *strings.Builder, value string) {
+	buffer.WriteString("\t<string>")
+	_ = xml.EscapeText(buffer, []byte(value))
+	buffer.WriteString("</string>\n")
+}
+
+// parsePlist reads the marker comment and ProgramArguments of a plist
+// written by Install.
+func parsePlist(data []byte) (string, string, []string, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var reader plistReader
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return emptyString, emptyString, nil, false
+		}
+
+		err = reader.read(decoder, token)
+		if err != nil {
+			return emptyString, emptyString, nil, false
+		}
+	}
+
+	return reader.name, reader.when, reader.argv, reader.marked
+}
+
+// plistReader collects what parsePlist needs while walking the tokens.
+type plistReader struct {
+	name    string
+	when    string
+	marked  bool
+	argv    []string
+	lastKey string
+	inArgs  bool
+}
+
+func (r *plistReader) read(decoder *xml.Decoder, token xml.Token) error {
+	switch value := token.(type) {
+	case xml.Comment:
+		if !r.marked {
+			r.name, r.when, r.marked = parseMarker(string(value))
+		}
+	case xml.StartElement:
+		return r.start(decoder, value)
+	case xml.EndElement:
+		r.inArgs = r.inArgs && value.Name.Local != "array"
+	}
+
+	return nil
+}
+
+func (r *plistReader) start(
+	decoder *xml.Decoder,
+	element xml.StartElement,
+) error {
+	switch element.Name.Local {
+	case "array":
+		r.inArgs = r.inArgs || r.lastKey == launchdArgsKey
+	case "key", "string":
+		var text string
+
+		err := decoder.DecodeElement(&text, &element)
+		if err != nil {
+			return fmt.Errorf("decode plist element: %w", err)
+		}
+
+		if element.Name.Local == "key" {
+			r.lastKey = text
+		} else if r.inArgs {
+			r.argv = append(r.argv, text)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,598 @@
+// Package schedule installs recurring CLI runs as systemd user timers,
+// crontab entries, or launchd agents.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	// BackendAuto picks launchd on macOS, systemd when systemctl is on the
+	// PATH, and cron otherwise.
+	BackendAuto = "auto"
+	// BackendSystemd installs a systemd user service and timer.
+	BackendSystemd = "systemd"
+	// BackendCron installs an entry in the user crontab.
+	BackendCron = "cron"
+	// BackendLaunchd installs a launchd user agent (macOS).
+	BackendLaunchd = "launchd"
+
+	markerPrefix    = "withings-cli schedule "
+	jobPrefix       = "withings-cli-"
+	noInputFlag     = "--no-input"
+	goosDarwin      = "darwin"
+	systemctlBinary = "systemctl"
+	fileMode        = 0o600
+	dirMode         = 0o755
+	timeParts       = 2
+	hoursPerDay     = 24
+	minutesPerHour  = 60
+	clockFormat     = "%02d:%02d"
+	presetDaily     = "daily "
+	presetHourly    = "hourly"
+	tableHeader     = "Name\tBackend\tSchedule\tCommand"
+	plainHeader     = "name\tbackend\tschedule\tcommand"
+	emptyString     = ""
+	defaultInt      = 0
+)
+
+var (
+	errInvalidName    = errors.New("invalid schedule name")
+	errInvalidBackend = errors.New("invalid schedule backend")
+	errInvalidDaily   = errors.New("invalid --daily time, want HH:MM")
+	errPresetMissing  = errors.New("set exactly one of --daily or --hourly")
+	errArgsMissing    = errors.New(
+		"missing command, e.g. schedule install --daily 06:30 -- export",
+	)
+	errScheduleExists = errors.New(
+		"schedule already exists; pass --force to replace it",
+	)
+	errScheduleMissing = errors.New("schedule not found")
+	errNoCrontab       = errors.New("read crontab")
+)
+
+//nolint:gochecknoglobals // Static name pattern.
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Options captures schedule install/list/remove parameters.
+type Options struct {
+	// Name identifies the schedule; it defaults to the first command
+	// argument that is not a flag.
+	Name    string
+	Daily   string
+	Hourly  bool
+	Backend string
+	Force   bool
+	// Args are the CLI arguments run on schedule, e.g. export ... .
+	Args []string
+}
+
+// Job is one installed schedule.
+type Job struct {
+	Name     string `json:"name"`
+	Backend  string `json:"backend"`
+	Schedule string `json:"schedule"`
+	Command  string `json:"command"`
+}
+
+// preset is when a job runs: daily at Hour:Minute, or hourly at Minute.
+type preset struct {
+	Hourly bool
+	Hour   int
+	Minute int
+}
+
+func (p preset) String() string {
+	if p.Hourly {
+		return presetHourly
+	}
+
+	return presetDaily + fmt.Sprintf(clockFormat, p.Hour, p.Minute)
+}
+
+// job is what a backend installs.
+type job struct {
+	Name   string
+	When   preset
+	Argv   []string
+	Marker string
+}
+
+// plan lists the file and command changes of an install or remove, so
+// --dry-run can print exactly what would happen.
+type plan struct {
+	Write  []plannedFile
+	Remove []string
+	Run    []plannedCommand
+}
+
+type plannedFile struct {
+	Path    string
+	Content string
+}
+
+type plannedCommand struct {
+	Argv  []string
+	Stdin string
+}
+
+// backend renders and inspects one scheduler.
+type backend interface {
+	Name() string
+	Install(ctx context.Context, entry job) (plan, error)
+	Remove(ctx context.Context, name string) (plan, error)
+	List(ctx context.Context) ([]Job, error)
+}
+
+// env is the host a backend works against; tests replace it.
+type env struct {
+	Home       string
+	ConfigHome string
+	Run        func(ctx context.Context, stdin string, argv ...string) (
+		string,
+		error,
+	)
+}
+
+// Install writes and enables a schedule that runs the CLI with opts.Args.
+func Install(ctx context.Context, opts Options, appOpts app.Options) error {
+	entry, err := newJob(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	target, err := resolveBackend(opts.Backend)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	err = checkExisting(ctx, target, entry.Name, opts.Force)
+	if err != nil {
+		return err
+	}
+
+	changes, err := target.Install(ctx, entry)
+	if err != nil {
+		return err
+	}
+
+	if appOpts.DryRun {
+		return output.WriteOutput(appOpts, describePlan(changes))
+	}
+
+	err = apply(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	return writeResult(appOpts, "installed", Job{
+		Name:     entry.Name,
+		Backend:  target.Name(),
+		Schedule: entry.When.String(),
+		Command:  shellJoin(entry.Argv),
+	})
+}
+
+// Remove disables and deletes the named schedule.
+func Remove(ctx context.Context, opts Options, appOpts app.Options) error {
+	if !namePattern.MatchString(opts.Name) {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %q", errInvalidName, opts.Name),
+		)
+	}
+
+	target, err := resolveBackend(opts.Backend)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	changes, err := target.Remove(ctx, opts.Name)
+	if err != nil {
+		return err
+	}
+
+	if appOpts.DryRun {
+		return output.WriteOutput(appOpts, describePlan(changes))
+	}
+
+	err = apply(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	return writeResult(appOpts, "removed", Job{
+		Name:     opts.Name,
+		Backend:  target.Name(),
+		Schedule: emptyString,
+		Command:  emptyString,
+	})
+}
+
+// List writes the schedules installed by this CLI.
+func List(ctx context.Context, opts Options, appOpts app.Options) error {
+	target, err := resolveBackend(opts.Backend)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	jobs, err := target.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(jobs, func(left, right int) bool {
+		return jobs[left].Name < jobs[right].Name
+	})
+
+	return writeJobs(appOpts, jobs)
+}
+
+func newJob(opts Options) (job, error) {
+	if len(opts.Args) == defaultInt {
+		return job{}, errArgsMissing
+	}
+
+	when, err := parsePreset(opts.Daily, opts.Hourly)
+	if err != nil {
+		return job{}, err
+	}
+
+	name := opts.Name
+	if name == emptyString {
+		name = defaultName(opts.Args)
+	}
+
+	if !namePattern.MatchString(name) {
+		return job{}, fmt.Errorf("%w: %q", errInvalidName, name)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return job{}, fmt.Errorf("resolve executable: %w", err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(executable)
+	if err == nil {
+		executable = resolved
+	}
+
+	argv := append([]string{executable, noInputFlag}, opts.Args...)
+
+	return job{
+		Name:   name,
+		When:   when,
+		Argv:   argv,
+		Marker: marker(name, when),
+	}, nil
+}
+
+// defaultName names a job after its first subcommand, e.g. "export".
+func defaultName(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return strings.ToLower(arg)
+		}
+	}
+
+	return emptyString
+}
+
+func parsePreset(daily string, hourly bool) (preset, error) {
+	if (daily == emptyString) == !hourly {
+		return preset{}, errPresetMissing
+	}
+
+	if hourly {
+		return preset{Hourly: true, Hour: defaultInt, Minute: defaultInt}, nil
+	}
+
+	parts := strings.Split(strings.TrimSpace(daily), ":")
+	if len(parts) != timeParts {
+		return preset{}, fmt.Errorf("%w: %q", errInvalidDaily, daily)
+	}
+
+	hour, hourErr := strconv.Atoi(parts[0])
+	minute, minuteErr := strconv.Atoi(parts[1])
+
+	if hourErr != nil || minuteErr != nil ||
+		hour < 0 || hour >= hoursPerDay ||
+		minute < 0 || minute >= minutesPerHour {
+		return preset{}, fmt.Errorf("%w: %q", errInvalidDaily, daily)
+	}
+
+	return preset{Hourly: false, Hour: hour, Minute: minute}, nil
+}
+
+// marker tags generated files and crontab lines so list and remove only
+// touch schedules this CLI created.
+func marker(name string, when preset) string {
+	return markerPrefix + name + " (" + when.String() + ")"
+}
+
+// parseMarker splits a marker back into name and schedule.
+func parseMarker(text string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(text), markerPrefix)
+	if !ok {
+		return emptyString, emptyString, false
+	}
+
+	name, when, ok := strings.Cut(rest, " (")
+	if !ok || !strings.HasSuffix(when, ")") {
+		return emptyString, emptyString, false
+	}
+
+	return name, strings.TrimSuffix(when, ")"), true
+}
+
+func resolveBackend(name string) (backend, error) {
+	host, err := hostEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == emptyString || name == BackendAuto {
+		name = detectBackend()
+	}
+
+	return newBackend(name, host)
+}
+
+func newBackend(name string, host env) (backend, error) {
+	switch name {
+	case BackendSystemd:
+		return systemd{env: host}, nil
+	case BackendCron:
+		return cron{env: host}, nil
+	case BackendLaunchd:
+		return launchd{env: host}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errInvalidBackend, name)
+	}
+}
+
+func detectBackend() string {
+	if runtime.GOOS == goosDarwin {
+		return BackendLaunchd
+	}
+
+	_, err := exec.LookPath(systemctlBinary)
+	if err == nil {
+		return BackendSystemd
+	}
+
+	return BackendCron
+}
+
+func hostEnv() (env, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return env{}, fmt.Errorf("resolve home directory: %w", err)
+	}
+
+	configHome, err := os.UserConfigDir()
+	if err != nil {
+		return env{}, fmt.Errorf("resolve config directory: %w", err)
+	}
+
+	return env{Home: home, ConfigHome: configHome, Run: runCommand}, nil
+}
+
+func runCommand(ctx context.Context, stdin string, argv ...string) (
+	string,
+	error,
+) {
+	//nolint:gosec // Runs fixed scheduler tools (systemctl, crontab, ...).
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	if stdin != emptyString {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		err = fmt.Errorf("%s: %w", strings.Join(argv, " "), err)
+
+		if detail := strings.TrimSpace(string(out)); detail != emptyString {
+			err = fmt.Errorf("%w: %s", err, detail)
+		}
+
+		return string(out), err
+	}
+
+	return string(out), nil
+}
+
+func checkExisting(
+	ctx context.Context,
+	target backend,
+	name string,
+	force bool,
+) error {
+	if force {
+		return nil
+	}
+
+	jobs, err := target.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, existing := range jobs {
+		if existing.Name == name {
+			return app.NewExitError(
+				app.ExitCodeUsage,
+				fmt.Errorf("%w: %s", errScheduleExists, name),
+			)
+		}
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, changes plan) error {
+	for _, file := range changes.Write {
+		err := os.MkdirAll(filepath.Dir(file.Path), dirMode)
+		if err != nil {
+			return fmt.Errorf("create schedule dir: %w", err)
+		}
+
+		err = os.WriteFile(file.Path, []byte(file.Content), fileMode)
+		if err != nil {
+			return fmt.Errorf("write schedule file: %w", err)
+		}
+	}
+
+	for _, path := range changes.Remove {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove schedule file: %w", err)
+		}
+	}
+
+	for _, command := range changes.Run {
+		_, err := runCommand(ctx, command.Stdin, command.Argv...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// describePlan renders a plan for --dry-run.
+func describePlan(changes plan) []string {
+	lines := []string{}
+
+	for _, file := range changes.Write {
+		lines = append(lines, "# write "+file.Path)
+		lines = append(lines, strings.TrimSuffix(file.Content, "\n"))
+	}
+
+	for _, path := range changes.Remove {
+		lines = append(lines, "# remove "+path)
+	}
+
+	for _, command := range changes.Run {
+		lines = append(lines, "$ "+shellJoin(command.Argv))
+		if command.Stdin != emptyString {
+			lines = append(lines, strings.TrimSuffix(command.Stdin, "\n"))
+		}
+	}
+
+	return lines
+}
+
+func writeResult(appOpts app.Options, action string, entry Job) error {
+	var data any = fmt.Sprintf(
+		"%s schedule %s (%s).",
+		capitalize(action),
+		entry.Name,
+		entry.Backend,
+	)
+
+	if appOpts.JSON {
+		data = map[string]any{
+			"action":   action,
+			"name":     entry.Name,
+			"backend":  entry.Backend,
+			"schedule": entry.Schedule,
+			"command":  entry.Command,
+		}
+	}
+
+	err := output.WriteOutput(appOpts, data)
+	if err != nil {
+		return fmt.Errorf("write schedule output: %w", err)
+	}
+
+	return nil
+}
+
+func writeJobs(appOpts app.Options, jobs []Job) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		return output.WriteRawJSON(appOpts, jobs)
+	}
+
+	lines := make([]string, defaultInt, len(jobs)+1)
+	lines = append(lines, plainHeader)
+
+	for _, entry := range jobs {
+		lines = append(lines, strings.Join([]string{
+			entry.Name,
+			entry.Backend,
+			entry.Schedule,
+			entry.Command,
+		}, "\t"))
+	}
+
+	if appOpts.Plain || appOpts.NDJSON {
+		err := output.WritePlain(appOpts, lines)
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	lines[0] = tableHeader
+
+	table, err := output.RenderTable(appOpts, plainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render schedule table: %w", err)
+	}
+
+	return output.WriteLine(appOpts, table)
+}
+
+func capitalize(value string) string {
+	if value == emptyString {
+		return value
+	}
+
+	return strings.ToUpper(value[:1]) + value[1:]
+}
+
+// shellJoin quotes argv for a POSIX shell.
+func shellJoin(argv []string) string {
+	quoted := make([]string, defaultInt, len(argv))
+	for _, arg := range argv {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(arg string) string {
+	if arg != emptyString && strings.IndexFunc(arg, unsafeShellRune) < 0 {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func unsafeShellRune(char rune) bool {
+	switch {
+	case char >= 'a' && char <= 'z', char >= 'A' && char <= 'Z',
+		char >= '0' && char <= '9':
+		return false
+	}
+
+	return !strings.ContainsRune("-_./:=,@+", char)
+}
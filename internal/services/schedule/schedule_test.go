@@ -0,0 +1,236 @@
+//nolint:testpackage // test unexported helpers.
+package schedule
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCrontab = "MAILTO=me@example.com\n" +
+	"# withings-cli schedule export (daily 06:30)\n" +
+	"30 6 * * * /usr/bin/withings --no-input export\n" +
+	"0 1 * * * backup.sh\n"
+
+// TestParsePreset accepts one of --daily HH:MM or --hourly.
+func TestParsePreset(t *testing.T) {
+	t.Parallel()
+
+	when, err := parsePreset("6:05", false)
+	if err != nil || when.String() != "daily 06:05" {
+		t.Fatalf("daily got %q, %v", when.String(), err)
+	}
+
+	when, err = parsePreset("", true)
+	if err != nil || when.String() != "hourly" {
+		t.Fatalf("hourly got %q, %v", when.String(), err)
+	}
+
+	for _, daily := range []string{"24:00", "06:60", "0630", "six"} {
+		_, err = parsePreset(daily, false)
+		if !errors.Is(err, errInvalidDaily) {
+			t.Fatalf("%q got %v", daily, err)
+		}
+	}
+
+	for _, hourly := range []bool{true, false} {
+		daily := ""
+		if hourly {
+			daily = "06:30"
+		}
+
+		_, err = parsePreset(daily, hourly)
+		if !errors.Is(err, errPresetMissing) {
+			t.Fatalf("daily %q hourly %t got %v", daily, hourly, err)
+		}
+	}
+}
+
+// TestSystemdRoundTrip writes a service and timer that list reads back.
+func TestSystemdRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	backend := systemd{env: testEnv(t, "")}
+
+	changes, err := backend.Install(context.Background(), testJob(false))
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	timer := changes.Write[1].Content
+	if !strings.Contains(timer, "OnCalendar=*-*-* 06:30:00\n") ||
+		!strings.Contains(timer, "Persistent=true\n") {
+		t.Fatalf("timer:\n%s", timer)
+	}
+
+	service := changes.Write[0].Content
+	if !strings.Contains(service, "ExecStart=/usr/bin/withings --no-input "+
+		`export --dest "/tmp/my data/%%Y.json"`+"\n") {
+		t.Fatalf("service:\n%s", service)
+	}
+
+	writePlan(t, changes)
+
+	jobs, err := backend.List(context.Background())
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("List got %+v, %v", jobs, err)
+	}
+
+	if jobs[0].Name != "export" || jobs[0].Schedule != "daily 06:30" {
+		t.Fatalf("job got %+v", jobs[0])
+	}
+
+	removal, err := backend.Remove(context.Background(), "export")
+	if err != nil || len(removal.Remove) != 2 {
+		t.Fatalf("Remove got %+v, %v", removal, err)
+	}
+
+	_, err = backend.Remove(context.Background(), "sync")
+	if !errors.Is(err, errScheduleMissing) {
+		t.Fatalf("Remove missing got %v", err)
+	}
+}
+
+// TestCronKeepsOtherEntries replaces only the tagged entry of a job.
+func TestCronKeepsOtherEntries(t *testing.T) {
+	t.Parallel()
+
+	backend := cron{env: testEnv(t, testCrontab)}
+
+	jobs, err := backend.List(context.Background())
+	if err != nil || len(jobs) != 1 ||
+		jobs[0].Command != "/usr/bin/withings --no-input export" {
+		t.Fatalf("List got %+v, %v", jobs, err)
+	}
+
+	changes, err := backend.Install(context.Background(), testJob(true))
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	want := "MAILTO=me@example.com\n" +
+		"0 1 * * * backup.sh\n" +
+		"# withings-cli schedule export (hourly)\n" +
+		"0 * * * * /usr/bin/withings --no-input export --dest " +
+		"'/tmp/my data/\\%Y.json'\n"
+	if changes.Run[0].Stdin != want {
+		t.Fatalf("crontab got\n%s\nwant\n%s", changes.Run[0].Stdin, want)
+	}
+
+	removal, err := backend.Remove(context.Background(), "export")
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+
+	if removal.Run[0].Stdin != "MAILTO=me@example.com\n0 1 * * * backup.sh\n" {
+		t.Fatalf("crontab after remove got\n%s", removal.Run[0].Stdin)
+	}
+}
+
+// TestCronRefusesUnreadableCrontab never overwrites a crontab it could
+// not read, but treats a missing one as empty.
+func TestCronRefusesUnreadableCrontab(t *testing.T) {
+	t.Parallel()
+
+	failing := testEnv(t, "")
+	failing.Run = func(context.Context, string, ...string) (string, error) {
+		return "permission denied", errNoCrontab
+	}
+
+	_, err := cron{env: failing}.Install(context.Background(), testJob(true))
+	if !errors.Is(err, errNoCrontab) {
+		t.Fatalf("Install got %v", err)
+	}
+
+	missing := testEnv(t, "")
+	missing.Run = func(context.Context, string, ...string) (string, error) {
+		return "no crontab for me", errNoCrontab
+	}
+
+	jobs, err := cron{env: missing}.List(context.Background())
+	if err != nil || len(jobs) != 0 {
+		t.Fatalf("List got %+v, %v", jobs, err)
+	}
+}
+
+// TestLaunchdRoundTrip writes a plist whose arguments list reads back.
+func TestLaunchdRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	backend := launchd{env: testEnv(t, "")}
+
+	changes, err := backend.Install(context.Background(), testJob(false))
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+
+	if !strings.Contains(changes.Write[0].Content,
+		"<key>Hour</key>\n\t\t<integer>6</integer>") {
+		t.Fatalf("plist:\n%s", changes.Write[0].Content)
+	}
+
+	writePlan(t, changes)
+
+	jobs, err := backend.List(context.Background())
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("List got %+v, %v", jobs, err)
+	}
+
+	want := "/usr/bin/withings --no-input export " +
+		"--dest '/tmp/my data/%Y.json'"
+	if jobs[0].Command != want || jobs[0].Schedule != "daily 06:30" {
+		t.Fatalf("job got %+v", jobs[0])
+	}
+}
+
+func testEnv(t *testing.T, crontab string) env {
+	t.Helper()
+
+	home := t.TempDir()
+
+	return env{
+		Home:       home,
+		ConfigHome: filepath.Join(home, ".config"),
+		Run: func(context.Context, string, ...string) (string, error) {
+			return crontab, nil
+		},
+	}
+}
+
+func testJob(hourly bool) job {
+	when := preset{Hourly: hourly, Hour: 6, Minute: 30}
+	if hourly {
+		when = preset{Hourly: true, Hour: 0, Minute: 0}
+	}
+
+	return job{
+		Name: "export",
+		When: when,
+		Argv: []string{
+			"/usr/bin/withings",
+			noInputFlag,
+			"export",
+			"--dest",
+			"/tmp/my data/%Y.json",
+		},
+		Marker: marker("export", when),
+	}
+}
+
+func writePlan(t *testing.T, changes plan) {
+	t.Helper()
+
+	for _, file := range changes.Write {
+		err := os.MkdirAll(filepath.Dir(file.Path), dirMode)
+		if err == nil {
+			err = os.WriteFile(file.Path, []byte(file.Content), fileMode)
+		}
+
+		if err != nil {
+			t.Fatalf("write %s: %v", file.Path, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	systemdUnitDir   = "systemd/user"
+	systemdService   = ".service"
+	systemdTimer     = ".timer"
+	systemdHourly    = "hourly"
+	systemdDaily     = "*-*-* %02d:%02d:00"
+	systemdDesc      = "Description="
+	systemdExecStart = "ExecStart="
+)
+
+// systemd installs a oneshot user service plus a persistent timer, so a
+// run missed while the machine was off happens at the next boot.
+type systemd struct {
+	env env
+}
+
+func (systemd) Name() string {
+	return BackendSystemd
+}
+
+func (s systemd) Install(_ context.Context, entry job) (plan, error) {
+	unit := jobPrefix + entry.Name
+
+	calendar := systemdHourly
+	if !entry.When.Hourly {
+		calendar = fmt.Sprintf(systemdDaily, entry.When.Hour, entry.When.Minute)
+	}
+
+	service := "[Unit]\n" +
+		systemdDesc + entry.Marker + "\n\n" +
+		"[Service]\n" +
+		"Type=oneshot\n" +
+		systemdExecStart + systemdJoin(entry.Argv) + "\n"
+	timer := "[Unit]\n" +
+		systemdDesc + entry.Marker + "\n\n" +
+		"[Timer]\n" +
+		"OnCalendar=" + calendar + "\n" +
+		"Persistent=true\n\n" +
+		"[Install]\n" +
+		"WantedBy=timers.target\n"
+
+	return plan{
+		Write: []plannedFile{
+			{Path: s.path(unit + systemdService), Content: service},
+			{Path: s.path(unit + systemdTimer), Content: timer},
+		},
+		Remove: nil,
+		Run: []plannedCommand{
+			systemctl("daemon-reload"),
+			systemctl("enable", "--now", unit+systemdTimer),
+		},
+	}, nil
+}
+
+func (s systemd) Remove(_ context.Context, name string) (plan, error) {
+	unit := jobPrefix + name
+
+	_, err := os.Stat(s.path(unit + systemdTimer))
+	if errors.Is(err, os.ErrNotExist) {
+		return plan{}, fmt.Errorf("%w: %s", errScheduleMissing, name)
+	}
+
+	return plan{
+		Write: nil,
+		Remove: []string{
+			s.path(unit + systemdTimer),
+			s.path(unit + systemdService),
+		},
+		Run: []plannedCommand{
+			systemctl("disable", "--now", unit+systemdTimer),
+			systemctl("daemon-reload"),
+		},
+	}, nil
+}
+
+func (s systemd) List(_ context.Context) ([]Job, error) {
+	timers, err := filepath.Glob(s.path(jobPrefix + "*" + systemdTimer))
+	if err != nil {
+		return nil, fmt.Errorf("list systemd timers: %w", err)
+	}
+
+	jobs := []Job{}
+
+	for _, timer := range timers {
+		description := unitValue(timer, systemdDesc)
+
+		name, when, ok := parseMarker(description)
+		if !ok {
+			continue
+		}
+
+		service := strings.TrimSuffix(timer, systemdTimer) + systemdService
+
+		jobs = append(jobs, Job{
+			Name:     name,
+			Backend:  BackendSystemd,
+			Schedule: when,
+			Command:  unitValue(service, systemdExecStart),
+		})
+	}
+
+	return jobs, nil
+}
+
+func (s systemd) path(file string) string {
+	return filepath.Join(s.env.ConfigHome, systemdUnitDir, file)
+}
+
+func systemctl(args ...string) plannedCommand {
+	return plannedCommand{
+		Argv:  append([]string{systemctlBinary, "--user"}, args...),
+		Stdin: emptyString,
+	}
+}
+
+// unitValue returns the first value of key in a unit file, or "".
+func unitValue(path string, key string) string {
+	//nolint:gosec // Path comes from a glob in the unit directory.
+	file, err := os.Open(path)
+	if err != nil {
+		return emptyString
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), key)
+		if ok {
+			return value
+		}
+	}
+
+	return emptyString
+}
+
+// systemdJoin quotes argv for ExecStart and escapes % specifiers and $
+// variable expansion.
+func systemdJoin(argv []string) string {
+	quoted := make([]string, defaultInt, len(argv))
+
+	for _, arg := range argv {
+		if arg == emptyString || strings.ContainsAny(arg, " \t\"'\\;") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) +
+				`"`
+		}
+
+		quoted = append(quoted, strings.NewReplacer("%", "%%", "$", "$$").
+			Replace(arg))
+	}
+
+	return strings.Join(quoted, " ")
+}
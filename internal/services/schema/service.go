@@ -0,0 +1,591 @@
+// Package schema describes the row output contract of data commands.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	typeString      = "string"
+	typeInteger     = "integer"
+	typeNumber      = "number"
+	typeDate        = "date"
+	typeDateTime    = "datetime"
+	unitSeconds     = "s"
+	unitMeters      = "m"
+	unitKilocalorie = "kcal"
+	unitBPM         = "bpm"
+	unitPerHour     = "events/h"
+	unitPerMinute   = "breaths/min"
+	rowsHeaderCount = 1
+	tableMinWidth   = 0
+	tableTabWidth   = 0
+	tablePadding    = 2
+	tablePadChar    = ' '
+	tableFlags      = 0
+	tableHeader     = "Command\tColumn\tType\tUnit\tDescription"
+	plainHeader     = "command\tcolumn\ttype\tunit\tdescription"
+	defaultInt      = 0
+	emptyString     = ""
+)
+
+var errUnknownCommand = errors.New("no schema for command")
+
+// Options captures schema lookup parameters.
+type Options struct {
+	Command string
+}
+
+// Document is the versioned schema written by --json.
+type Document struct {
+	Version  int       `json:"version"`
+	Commands []Command `json:"commands"`
+}
+
+// Command describes the row columns of one command, in output order.
+type Command struct {
+	Command string   `json:"command"`
+	Columns []Column `json:"columns"`
+	Dynamic string   `json:"dynamic,omitempty"`
+}
+
+// Column describes a single row column.
+type Column struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description"`
+}
+
+// Run writes the schema for all commands or the selected one.
+func Run(opts Options, appOpts app.Options) error {
+	commands, err := selectCommands(opts.Command)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	version, err := output.ResolveContractVersion(appOpts.OutputVersion)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	return writeBody(appOpts, Document{Version: version, Commands: commands})
+}
+
+// Commands returns the descriptors for every command with row output.
+func Commands() []Command {
+	return []Command{
+		activityGet(),
+		activityIntraday(),
+		cardio(),
+		devicesList(),
+		fitness(),
+		heartGet(),
+		heartZones(),
+		measuresGet(),
+		measuresSources(),
+		notifyList(),
+		sleepDetail(),
+		sleepGet(),
+		sleepReport(),
+		sleepScore(),
+		status(),
+		summary(),
+		userGoals(),
+		workoutsList(),
+		workoutsSummary(),
+	}
+}
+
+func selectCommands(name string) ([]Command, error) {
+	commands := Commands()
+
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	if name == emptyString {
+		return commands, nil
+	}
+
+	for _, command := range commands {
+		if command.Command == name {
+			return []Command{command}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", errUnknownCommand, name)
+}
+
+func activityGet() Command {
+	return Command{
+		Command: "activity get",
+		Columns: []Column{
+			col("date", typeDate, emptyString, "activity day (YYYY-MM-DD)"),
+			col("steps", typeInteger, emptyString, "step count"),
+			col("distance", typeNumber, unitMeters, "distance covered"),
+			col("calories", typeNumber, unitKilocalorie, "active calories"),
+			col(
+				"total_calories",
+				typeNumber,
+				unitKilocalorie,
+				"total calories",
+			),
+			col("active", typeNumber, unitSeconds, "active time"),
+			col("elevation", typeNumber, unitMeters, "elevation climbed"),
+			col("soft", typeNumber, unitSeconds, "light activity time"),
+			col("moderate", typeNumber, unitSeconds, "moderate activity time"),
+			col("intense", typeNumber, unitSeconds, "intense activity time"),
+			col("bmr", typeNumber, unitKilocalorie, "basal metabolic rate"),
+			col(
+				"activity_calories",
+				typeNumber,
+				unitKilocalorie,
+				"total calories minus BMR",
+			),
+			col("goal", typeNumber, emptyString, "daily goal of --metric"),
+			col("progress", typeNumber, emptyString, "percent of the goal"),
+		},
+		Dynamic: "goal and progress only appear with a goal " +
+			"(--goal or goal_<metric> in config)",
+	}
+}
+
+func activityIntraday() Command {
+	return Command{
+		Command: "activity intraday",
+		Columns: []Column{
+			col("time", typeDateTime, emptyString, "sample time (RFC3339)"),
+			col("steps", typeInteger, emptyString, "steps in the sample"),
+			col("calories", typeNumber, unitKilocalorie, "calories burned"),
+			col("heart_rate", typeInteger, unitBPM, "heart rate"),
+			col("duration", typeInteger, unitSeconds, "sample duration"),
+		},
+		Dynamic: "the columns after time are the default --data-fields; " +
+			"one column per entry, in the requested order",
+	}
+}
+
+func cardio() Command {
+	command := trendCommand("cardio")
+	command.Columns = append(command.Columns, col(
+		"status",
+		typeString,
+		emptyString,
+		"normal, elevated, or high (empty when unknown)",
+	))
+
+	return command
+}
+
+func devicesList() Command {
+	return Command{
+		Command: "devices list",
+		Columns: []Column{
+			col("model", typeString, emptyString, "device model name"),
+			col("model_id", typeInteger, emptyString, "device model ID"),
+			col("type", typeString, emptyString, "device type"),
+			col(
+				"battery",
+				typeString,
+				emptyString,
+				"low, medium, high, or a percentage",
+			),
+			col(
+				"last_session",
+				typeDateTime,
+				emptyString,
+				"last sync time (RFC3339)",
+			),
+			col("firmware", typeString, emptyString, "firmware version"),
+			col("mac", typeString, emptyString, "MAC address"),
+			col("device_id", typeString, emptyString, "device ID"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func fitness() Command {
+	return trendCommand("fitness")
+}
+
+func trendCommand(name string) Command {
+	return Command{
+		Command: name,
+		Columns: []Column{
+			col("time", typeDateTime, emptyString, "measure time (RFC3339)"),
+			col("type", typeString, emptyString, "measure type name"),
+			col("value", typeNumber, emptyString, "scaled measure value"),
+			col("unit", typeString, emptyString, "unit of value"),
+			col("change", typeNumber, emptyString, "signed delta vs previous"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func heartGet() Command {
+	return Command{
+		Command: "heart get",
+		Columns: []Column{
+			col("time", typeDateTime, emptyString, "recording time (RFC3339)"),
+			col("heart_rate", typeInteger, unitBPM, "average heart rate"),
+			col("model", typeString, emptyString, "recording device model"),
+			col("device", typeString, emptyString, "device ID"),
+			col("signal_id", typeInteger, emptyString, "ECG signal ID"),
+			col("ecg", typeString, emptyString, "ECG classification"),
+			col("afib", typeString, emptyString, "AFib classification"),
+			col("signal", typeString, emptyString, "raw signal summary"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func heartZones() Command {
+	return Command{
+		Command: "heart zones",
+		Columns: []Column{
+			col("date", typeDate, emptyString, "local day (YYYY-MM-DD)"),
+			col("z1", typeInteger, unitSeconds, "time in zone 1 (50-60%)"),
+			col("z2", typeInteger, unitSeconds, "time in zone 2 (60-70%)"),
+			col("z3", typeInteger, unitSeconds, "time in zone 3 (70-80%)"),
+			col("z4", typeInteger, unitSeconds, "time in zone 4 (80-90%)"),
+			col("z5", typeInteger, unitSeconds, "time in zone 5 (90%+)"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func measuresGet() Command {
+	return Command{
+		Command: "measures get",
+		Columns: []Column{
+			col("time", typeDateTime, emptyString, "measure time (RFC3339)"),
+			col("type", typeString, emptyString, "measure type name"),
+			col("value", typeNumber, emptyString, "scaled measure value"),
+			col("unit", typeString, emptyString, "unit of value"),
+			col("category", typeString, emptyString, "real or goal"),
+			col(
+				"source",
+				typeString,
+				emptyString,
+				"device, manual, auto, ... (from attrib)",
+			),
+			col(
+				"mode",
+				typeString,
+				emptyString,
+				"standard, athlete, pregnancy, or the raw fm value",
+			),
+			col(
+				"classification",
+				typeString,
+				emptyString,
+				"blood pressure class under --guideline (else empty)",
+			),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func measuresSources() Command {
+	return Command{
+		Command: "measures sources",
+		Columns: []Column{
+			col("type", typeString, emptyString, "measure type name"),
+			col(
+				"source",
+				typeString,
+				emptyString,
+				"device, manual, auto, ... (from attrib)",
+			),
+			col("points", typeInteger, emptyString, "number of data points"),
+			col("share", typeString, emptyString, "percent of the type"),
+			col("devices", typeInteger, emptyString, "distinct devices"),
+			col("first", typeDateTime, emptyString, "oldest point (RFC3339)"),
+			col("last", typeDateTime, emptyString, "newest point (RFC3339)"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func notifyList() Command {
+	return Command{
+		Command: "notify list",
+		Columns: []Column{
+			col("appli", typeInteger, emptyString, "notification category"),
+			col("type", typeString, emptyString, "category name"),
+			col("callback_url", typeString, emptyString, "callback URL"),
+			col("comment", typeString, emptyString, "subscription comment"),
+			col(
+				"expires",
+				typeDateTime,
+				emptyString,
+				"expiry time (RFC3339, UTC)",
+			),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func sleepDetail() Command {
+	return Command{
+		Command: "sleep detail",
+		Columns: []Column{
+			col("start", typeDateTime, emptyString, "segment start (RFC3339)"),
+			col("end", typeDateTime, emptyString, "segment end (RFC3339)"),
+			col("stage", typeString, emptyString, "sleep stage name"),
+			col("duration", typeInteger, unitSeconds, "segment duration"),
+			col("hr", typeNumber, unitBPM, "mean heart rate"),
+			col("rr", typeNumber, unitPerMinute, "mean respiration rate"),
+		},
+		Dynamic: "hr and rr are the default --data-fields; one column " +
+			"per entry after duration holding the segment mean",
+	}
+}
+
+func sleepGet() Command {
+	return Command{
+		Command: "sleep get",
+		Columns: []Column{
+			col("start", typeDateTime, emptyString, "sleep start (RFC3339)"),
+			col("end", typeDateTime, emptyString, "sleep end (RFC3339)"),
+			col("duration", typeInteger, unitSeconds, "total sleep duration"),
+			col("score", typeInteger, emptyString, "sleep score"),
+			col("wakeups", typeInteger, emptyString, "wake-up count"),
+			col("model", typeString, emptyString, "tracking device model"),
+			col("kind", typeString, emptyString, "sleep or nap"),
+			col(
+				"breathing",
+				typeInteger,
+				emptyString,
+				"breathing disturbances intensity",
+			),
+			col("ahi", typeNumber, unitPerHour, "apnea-hypopnea index"),
+			col(
+				"ahi_severity",
+				typeString,
+				emptyString,
+				"normal, mild, moderate, or severe",
+			),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func sleepReport() Command {
+	return Command{
+		Command: "sleep report",
+		Columns: []Column{
+			col("metric", typeString, emptyString, "report metric name"),
+			col("value", typeString, emptyString, "formatted metric value"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func sleepScore() Command {
+	return Command{
+		Command: "sleep score",
+		Columns: []Column{
+			col("period", typeString, emptyString, "day, ISO week, or month"),
+			col("nights", typeInteger, emptyString, "nights in the period"),
+			col("score", typeNumber, emptyString, "average sleep score"),
+			col("duration", typeString, emptyString, "average sleep (7h56m)"),
+			col("wakeups", typeNumber, emptyString, "average wake-ups"),
+			col("efficiency", typeString, emptyString, "average efficiency"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func status() Command {
+	return Command{
+		Command: "status",
+		Columns: []Column{
+			col("segment", typeString, emptyString, "weight, sleep, or steps"),
+			col(
+				"value",
+				typeNumber,
+				emptyString,
+				"latest value (empty without recent data)",
+			),
+			col("unit", typeString, emptyString, "unit of value and change"),
+			col(
+				"change",
+				typeNumber,
+				emptyString,
+				"change from the previous value (weight only)",
+			),
+			col("date", typeDate, emptyString, "day of the value (YYYY-MM-DD)"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func summary() Command {
+	return Command{
+		Command: "summary",
+		Columns: []Column{
+			col("metric", typeString, emptyString, "summary metric name"),
+			col("value", typeNumber, emptyString, "current period value"),
+			col("previous", typeNumber, emptyString, "previous period value"),
+			col("change", typeNumber, emptyString, "value minus previous"),
+			col("unit", typeString, emptyString, "unit of the values"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func userGoals() Command {
+	return Command{
+		Command: "user goals",
+		Columns: []Column{
+			col("goal", typeString, emptyString, "steps, sleep, or weight"),
+			col("value", typeNumber, emptyString, "goal value"),
+			col("unit", typeString, emptyString, "steps, h, kg, or lb"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func workoutsList() Command {
+	return Command{
+		Command: "workouts list",
+		Columns: []Column{
+			col("start", typeDateTime, emptyString, "workout start (RFC3339)"),
+			col("end", typeDateTime, emptyString, "workout end (RFC3339)"),
+			col("duration", typeInteger, unitSeconds, "workout duration"),
+			col("category", typeString, emptyString, "workout category name"),
+			col("calories", typeNumber, unitKilocalorie, "calories burned"),
+			col("distance", typeNumber, unitMeters, "distance covered"),
+			col("steps", typeInteger, emptyString, "step count"),
+			col("hr_average", typeInteger, unitBPM, "average heart rate"),
+			col("hr_max", typeInteger, unitBPM, "maximum heart rate"),
+			col("elevation", typeNumber, unitMeters, "elevation climbed"),
+			col("id", typeInteger, emptyString, "workout ID (workouts export)"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func workoutsSummary() Command {
+	return Command{
+		Command: "workouts summary",
+		Columns: []Column{
+			col(
+				"category",
+				typeString,
+				emptyString,
+				"workout category name, or all without --by-type",
+			),
+			col("count", typeInteger, emptyString, "number of workouts"),
+			col("duration", typeInteger, unitSeconds, "total duration"),
+			col("calories", typeNumber, unitKilocalorie, "total calories"),
+			col("distance", typeNumber, unitMeters, "total distance"),
+		},
+		Dynamic: emptyString,
+	}
+}
+
+func col(name, kind, unit, description string) Column {
+	return Column{
+		Name:        name,
+		Type:        kind,
+		Unit:        unit,
+		Description: description,
+	}
+}
+
+func writeBody(opts app.Options, document Document) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		err := output.WriteRawJSON(opts, document)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	if opts.Plain || opts.NDJSON {
+		err := output.WritePlain(opts, formatLines(document))
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	table, err := formatTable(document)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func formatTable(document Document) (string, error) {
+	var buffer bytes.Buffer
+
+	_, _ = fmt.Fprintf(&buffer, "Output contract version %d\n\n",
+		document.Version)
+
+	writer := tabwriter.NewWriter(
+		&buffer,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableFlags,
+	)
+	_, _ = fmt.Fprintln(writer, tableHeader)
+
+	for _, line := range columnLines(document) {
+		_, _ = fmt.Fprintln(writer, line)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render schema table: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+func formatLines(document Document) []string {
+	columns := columnLines(document)
+	lines := make([]string, defaultInt, len(columns)+rowsHeaderCount)
+	lines = append(lines, plainHeader)
+
+	return append(lines, columns...)
+}
+
+func columnLines(document Document) []string {
+	var lines []string
+
+	for _, command := range document.Commands {
+		for _, column := range command.Columns {
+			lines = append(lines, strings.Join([]string{
+				command.Command,
+				column.Name,
+				column.Type,
+				column.Unit,
+				column.Description,
+			}, "\t"))
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,85 @@
+//nolint:testpackage // test unexported helpers.
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const schemaTestMeasures = "measures get"
+
+// TestSelectCommandsNormalizesName matches case and spacing variants.
+func TestSelectCommandsNormalizesName(t *testing.T) {
+	t.Parallel()
+
+	commands, err := selectCommands("  Measures   GET ")
+	if err != nil {
+		t.Fatalf("selectCommands: %v", err)
+	}
+
+	if len(commands) != 1 || commands[0].Command != schemaTestMeasures {
+		t.Fatalf("commands got %+v want %q", commands, schemaTestMeasures)
+	}
+}
+
+// TestSelectCommandsUnknown rejects commands without row output.
+func TestSelectCommandsUnknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := selectCommands("auth status")
+	if !errors.Is(err, errUnknownCommand) {
+		t.Fatalf("error got %v want %v", err, errUnknownCommand)
+	}
+}
+
+// TestCommandsWellFormed keeps names unique and types from the known set.
+func TestCommandsWellFormed(t *testing.T) {
+	t.Parallel()
+
+	known := map[string]bool{
+		typeString:   true,
+		typeInteger:  true,
+		typeNumber:   true,
+		typeDate:     true,
+		typeDateTime: true,
+	}
+	seen := map[string]bool{}
+
+	for _, command := range Commands() {
+		if seen[command.Command] {
+			t.Fatalf("duplicate command %q", command.Command)
+		}
+
+		seen[command.Command] = true
+
+		for _, column := range command.Columns {
+			if !known[column.Type] {
+				t.Fatalf("%s.%s type %q", command.Command, column.Name,
+					column.Type)
+			}
+		}
+	}
+}
+
+// TestFormatLines prefixes each column with its command.
+func TestFormatLines(t *testing.T) {
+	t.Parallel()
+
+	lines := formatLines(Document{
+		Version:  output.ContractVersion,
+		Commands: []Command{sleepReport()},
+	})
+
+	want := []string{
+		plainHeader,
+		"sleep report\tmetric\tstring\t\treport metric name",
+		"sleep report\tvalue\tstring\t\tformatted metric value",
+	}
+
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("lines got %q want %q", lines, want)
+	}
+}
@@ -0,0 +1,367 @@
+// Package scopes probes which OAuth scopes a token can actually use.
+package scopes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	lastUpdateParam = "lastupdate"
+	scopeSeparator  = ","
+	statusOK        = "ok"
+	statusDenied    = "denied"
+	statusMissing   = "not granted"
+	statusExtra     = "usable, not granted"
+	statusNone      = "-"
+	numberBase10    = 10
+	rowsHeaderCount = 1
+	tableMinWidth   = 0
+	tableTabWidth   = 0
+	tablePadding    = 2
+	tablePadChar    = ' '
+	tableFlags      = 0
+	tableHeader     = "Scope\tGranted\tUsable\tStatus\tProbe\tDetail"
+	plainHeader     = "scope\tgranted\tusable\tstatus\tprobe\tdetail"
+	defaultInt      = 0
+	emptyString     = ""
+)
+
+// Options captures the scope audit inputs.
+type Options struct {
+	// Granted is the comma-separated scope list stored with the token.
+	Granted string
+	Now     func() time.Time
+}
+
+// probe is the cheapest read that needs a scope. lastupdate=now keeps
+// the responses empty.
+type probe struct {
+	Scope      string
+	Service    string
+	Action     string
+	LastUpdate bool
+}
+
+//nolint:gochecknoglobals // Static probe table.
+var probes = []probe{
+	{
+		Scope:      "user.info",
+		Service:    "v2/user",
+		Action:     "getdevice",
+		LastUpdate: false,
+	},
+	{
+		Scope:      "user.metrics",
+		Service:    "measure",
+		Action:     "getmeas",
+		LastUpdate: true,
+	},
+	{
+		Scope:      "user.activity",
+		Service:    "v2/measure",
+		Action:     "getactivity",
+		LastUpdate: true,
+	},
+	{
+		Scope:      "user.sleepevents",
+		Service:    "v2/sleep",
+		Action:     "getsummary",
+		LastUpdate: true,
+	},
+}
+
+type result struct {
+	Scope   string `json:"scope"`
+	Granted bool   `json:"granted"`
+	Usable  bool   `json:"usable"`
+	Status  string `json:"status"`
+	Probe   string `json:"probe"`
+	Error   string `json:"error,omitempty"`
+}
+
+type response struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+}
+
+// Run probes every known scope with the access token and writes a matrix
+// of granted versus usable scopes.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	results, err := probeAll(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return writeResults(appOpts, results)
+}
+
+func probeAll(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) ([]result, error) {
+	nowFunc := opts.Now
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	granted := parseScopes(opts.Granted)
+	results := make([]result, defaultInt, len(probes))
+
+	for _, target := range probes {
+		now := nowFunc()
+
+		rejection, err := runProbe(ctx, appOpts, accessToken, target, now)
+		if err != nil {
+			return nil, fmt.Errorf("probe %s: %w", target.Scope, err)
+		}
+
+		results = append(results, newResult(
+			target,
+			slices.Contains(granted, target.Scope),
+			rejection,
+		))
+	}
+
+	return results, nil
+}
+
+func parseScopes(raw string) []string {
+	var scopes []string
+
+	for scope := range strings.SplitSeq(raw, scopeSeparator) {
+		trimmed := strings.TrimSpace(scope)
+		if trimmed != emptyString {
+			scopes = append(scopes, trimmed)
+		}
+	}
+
+	return scopes
+}
+
+// runProbe returns why the API rejected the probe, or "" when it
+// succeeded. err is reserved for failures that say nothing about the
+// scope, such as network errors.
+func runProbe(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	target probe,
+	now time.Time,
+) (string, error) {
+	values := url.Values{}
+	if target.LastUpdate {
+		values.Set(
+			lastUpdateParam,
+			strconv.FormatInt(now.Unix(), numberBase10),
+		)
+	}
+
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		withings.ServicePath(baseURL, target.Service),
+		target.Action,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return emptyString, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return emptyString, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if errors.Is(err, withings.ErrAPI) {
+		return err.Error(), nil
+	}
+
+	if err != nil {
+		return emptyString, fmt.Errorf("read response: %w", err)
+	}
+
+	return decodeProbe(payload)
+}
+
+func decodeProbe(payload []byte) (string, error) {
+	var decoded response
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return emptyString, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	if decoded.Status == withings.StatusOK {
+		return emptyString, nil
+	}
+
+	message := decoded.Error
+	if message == emptyString {
+		message = decoded.Detail
+	}
+
+	if message == emptyString {
+		message = strings.TrimSpace(string(payload))
+	}
+
+	apiErr := &withings.APIError{Status: decoded.Status, Message: message}
+
+	return apiErr.Error(), nil
+}
+
+func newResult(target probe, granted bool, rejection string) result {
+	usable := rejection == emptyString
+	outcome := result{
+		Scope:   target.Scope,
+		Granted: granted,
+		Usable:  usable,
+		Status:  statusOK,
+		Probe:   target.Service + " " + target.Action,
+		Error:   rejection,
+	}
+
+	switch {
+	case granted && !usable:
+		outcome.Status = statusDenied
+	case !granted && usable:
+		outcome.Status = statusExtra
+	case !granted:
+		outcome.Status = statusMissing
+	}
+
+	return outcome
+}
+
+func writeResults(appOpts app.Options, results []result) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON {
+		err := output.WriteOutput(appOpts, results)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	if appOpts.Plain || appOpts.NDJSON {
+		err := output.WritePlain(appOpts, formatLines(results))
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	table, err := formatTable(results)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(appOpts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func formatLines(results []result) []string {
+	lines := make([]string, defaultInt, len(results)+rowsHeaderCount)
+	lines = append(lines, plainHeader)
+
+	for _, outcome := range results {
+		lines = append(lines, strings.Join([]string{
+			outcome.Scope,
+			strconv.FormatBool(outcome.Granted),
+			strconv.FormatBool(outcome.Usable),
+			outcome.Status,
+			outcome.Probe,
+			outcome.Error,
+		}, "\t"))
+	}
+
+	return lines
+}
+
+func formatTable(results []result) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(
+		&buffer,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableFlags,
+	)
+	_, _ = fmt.Fprintln(writer, tableHeader)
+
+	for _, outcome := range results {
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			outcome.Scope,
+			yesNo(outcome.Granted),
+			yesNo(outcome.Usable),
+			outcome.Status,
+			outcome.Probe,
+			defaultIfEmpty(outcome.Error, statusNone),
+		)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render scopes table: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
+
+func defaultIfEmpty(value, fallback string) string {
+	if value == emptyString {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,74 @@
+//nolint:testpackage // test unexported helpers.
+package scopes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	testGranted = "user.info, user.metrics,user.activity"
+	testDenied  = `{"status":401,"error":"insufficient scope"}`
+)
+
+// TestProbeAll reports granted scopes the API refuses as denied.
+func TestProbeAll(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testHandler))
+	defer server.Close()
+
+	var appOpts app.Options
+
+	appOpts.Quiet = true
+	appOpts.BaseURL = server.URL
+
+	results, err := probeAll(t.Context(), Options{
+		Granted: testGranted,
+		Now:     func() time.Time { return time.Unix(1767225600, 0) },
+	}, appOpts, "token")
+	if err != nil {
+		t.Fatalf("probeAll: %v", err)
+	}
+
+	want := map[string]string{
+		"user.info":        statusOK,
+		"user.metrics":     statusOK,
+		"user.activity":    statusDenied,
+		"user.sleepevents": statusMissing,
+	}
+
+	for _, outcome := range results {
+		if outcome.Status != want[outcome.Scope] {
+			t.Fatalf(
+				"%s got %q want %q (%s)",
+				outcome.Scope,
+				outcome.Status,
+				want[outcome.Scope],
+				outcome.Error,
+			)
+		}
+	}
+}
+
+func testHandler(writer http.ResponseWriter, req *http.Request) {
+	switch req.FormValue("action") {
+	case "getactivity":
+		_, _ = writer.Write([]byte(testDenied))
+	case "getsummary":
+		writer.WriteHeader(http.StatusForbidden)
+	default:
+		if req.FormValue("action") == "getmeas" &&
+			req.FormValue(lastUpdateParam) != "1767225600" {
+			writer.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = writer.Write([]byte(`{"status":0,"body":{}}`))
+	}
+}
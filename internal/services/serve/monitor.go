@@ -0,0 +1,263 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/services/notify"
+)
+
+const (
+	healthPath = "/health"
+	// Subscription states reported by /health.
+	statePending      = "pending"
+	stateActive       = "active"
+	stateResubscribed = "resubscribed"
+	stateFailed       = "error"
+	monitorComment    = "withings serve"
+)
+
+// SubscriptionOptions keeps CallbackURL subscribed to every appli in
+// Applis, checking every Interval. An empty CallbackURL disables the
+// monitor.
+type SubscriptionOptions struct {
+	CallbackURL string
+	Applis      []int
+	Interval    time.Duration
+}
+
+//nolint:tagliatelle // Keep snake_case like the Withings API fields.
+type subscriptionState struct {
+	Appli       int    `json:"appli"`
+	Type        string `json:"type"`
+	CallbackURL string `json:"callbackurl"`
+	State       string `json:"state"`
+	CheckedAt   string `json:"checked_at,omitempty"`
+	Expires     string `json:"expires,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+type health struct {
+	OK            bool                `json:"ok"`
+	Subscriptions []subscriptionState `json:"subscriptions"`
+}
+
+// monitor re-subscribes missing notify subscriptions, which Withings
+// expires without telling the client.
+type monitor struct {
+	opts    SubscriptionOptions
+	appOpts app.Options
+	token   TokenSource
+	now     func() time.Time
+	mu      sync.Mutex
+	states  []subscriptionState
+}
+
+func newMonitor(
+	opts SubscriptionOptions,
+	appOpts app.Options,
+	token TokenSource,
+) *monitor {
+	if opts.CallbackURL == emptyString {
+		return nil
+	}
+
+	states := make([]subscriptionState, 0, len(opts.Applis))
+	for _, appli := range opts.Applis {
+		states = append(states, pendingState(opts.CallbackURL, appli))
+	}
+
+	return &monitor{
+		opts:    opts,
+		appOpts: appOpts,
+		token:   token,
+		now:     time.Now,
+		mu:      sync.Mutex{},
+		states:  states,
+	}
+}
+
+func pendingState(callbackURL string, appli int) subscriptionState {
+	return subscriptionState{
+		Appli:       appli,
+		Type:        notify.AppliName(appli),
+		CallbackURL: callbackURL,
+		State:       statePending,
+		CheckedAt:   emptyString,
+		Expires:     emptyString,
+		Error:       emptyString,
+	}
+}
+
+// run checks immediately and then every interval until ctx is done.
+func (m *monitor) run(ctx context.Context) {
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *monitor) check(ctx context.Context) {
+	states := m.evaluate(ctx)
+
+	m.mu.Lock()
+	m.states = states
+	m.mu.Unlock()
+
+	for _, state := range states {
+		m.log(state)
+	}
+}
+
+func (m *monitor) evaluate(ctx context.Context) []subscriptionState {
+	checkedAt := m.now().UTC().Format(time.RFC3339)
+	states := make([]subscriptionState, 0, len(m.opts.Applis))
+
+	for _, appli := range m.opts.Applis {
+		state := pendingState(m.opts.CallbackURL, appli)
+		state.CheckedAt = checkedAt
+		states = append(states, state)
+	}
+
+	accessToken, err := m.token(ctx)
+	if err != nil {
+		return failAll(states, err)
+	}
+
+	active, err := notify.ListSubscriptions(ctx, m.appOpts, accessToken)
+	if err != nil {
+		return failAll(states, err)
+	}
+
+	for index := range states {
+		state := &states[index]
+
+		if existing, ok := findSubscription(active, *state); ok {
+			state.State = stateActive
+			state.Expires = formatExpires(existing.Expires)
+
+			continue
+		}
+
+		err = notify.SubscribeCallback(
+			ctx,
+			m.appOpts,
+			accessToken,
+			notify.Subscription{
+				Appli:       state.Appli,
+				CallbackURL: state.CallbackURL,
+				Expires:     0,
+			},
+			monitorComment,
+		)
+		if err != nil {
+			state.State = stateFailed
+			state.Error = err.Error()
+
+			continue
+		}
+
+		state.State = stateResubscribed
+	}
+
+	return states
+}
+
+func findSubscription(
+	active []notify.Subscription,
+	state subscriptionState,
+) (notify.Subscription, bool) {
+	for _, subscription := range active {
+		if subscription.Appli == state.Appli &&
+			subscription.CallbackURL == state.CallbackURL {
+			return subscription, true
+		}
+	}
+
+	return notify.Subscription{}, false
+}
+
+func failAll(states []subscriptionState, err error) []subscriptionState {
+	for index := range states {
+		states[index].State = stateFailed
+		states[index].Error = err.Error()
+	}
+
+	return states
+}
+
+func formatExpires(epoch int64) string {
+	if epoch == 0 {
+		return emptyString
+	}
+
+	return time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+}
+
+// log reports re-subscriptions and failures; active subscriptions are
+// only logged with -v.
+func (m *monitor) log(state subscriptionState) {
+	line := fmt.Sprintf(
+		"subscription %s -> %s: %s",
+		state.Type,
+		state.CallbackURL,
+		state.State,
+	)
+
+	switch state.State {
+	case stateFailed:
+		_ = output.WriteWarning(m.appOpts, line+" ("+state.Error+")")
+	case stateResubscribed:
+		_ = output.WriteWarning(m.appOpts, line)
+	default:
+		if m.appOpts.Verbose > 0 {
+			_ = output.WriteProgress(m.appOpts, line)
+		}
+	}
+}
+
+// health reports the last check; it is not OK while any subscription
+// failed to check or re-subscribe.
+func (m *monitor) health() health {
+	report := health{OK: true, Subscriptions: []subscriptionState{}}
+	if m == nil {
+		return report
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report.Subscriptions = append(report.Subscriptions, m.states...)
+
+	for _, state := range m.states {
+		if state.State == stateFailed {
+			report.OK = false
+		}
+	}
+
+	return report
+}
+
+func (h *handler) health(writer http.ResponseWriter, request *http.Request) {
+	report := h.monitor.health()
+
+	code := http.StatusOK
+	if !report.OK {
+		code = http.StatusServiceUnavailable
+	}
+
+	h.respond(writer, request, code, report)
+}
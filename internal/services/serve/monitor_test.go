@@ -0,0 +1,143 @@
+//nolint:testpackage // test unexported helpers.
+package serve
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	testCallbackURL = "https://example.com/hook"
+	testAppliWeight = 1
+	testAppliSleep  = 44
+	testSubscribed  = `{"status":0,"body":{"profiles":[{"appli":1,` +
+		`"callbackurl":"https://example.com/hook","expires":1767225600}]}}`
+)
+
+// TestMonitorResubscribes keeps existing subscriptions and restores
+// missing ones.
+func TestMonitorResubscribes(t *testing.T) {
+	t.Parallel()
+
+	var subscribed atomic.Int32
+
+	upstream := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			_ = request.ParseForm()
+
+			if request.FormValue("action") == "subscribe" {
+				if request.FormValue("appli") == "44" {
+					subscribed.Add(1)
+				}
+
+				_, _ = writer.Write([]byte(`{"status":0,"body":{}}`))
+
+				return
+			}
+
+			_, _ = writer.Write([]byte(testSubscribed))
+		},
+	))
+	defer upstream.Close()
+
+	monitor := testMonitor(upstream.URL)
+
+	states := monitor.evaluate(t.Context())
+	if states[0].State != stateActive ||
+		states[0].Expires != "2026-01-01T00:00:00Z" {
+		t.Fatalf("weight: got %+v", states[0])
+	}
+
+	if states[1].State != stateResubscribed || subscribed.Load() != 1 {
+		t.Fatalf("sleep: got %+v (%d)", states[1], subscribed.Load())
+	}
+}
+
+// TestMonitorHealth reports failures and serves them on /health.
+func TestMonitorHealth(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			_, _ = writer.Write([]byte(`{"status":401,"error":"invalid"}`))
+		},
+	))
+	defer upstream.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = upstream.URL
+	appOpts.Quiet = true
+	opts.Token = func(context.Context) (string, error) {
+		return testToken, nil
+	}
+	opts.Subscriptions.CallbackURL = testCallbackURL
+	opts.Subscriptions.Applis = []int{testAppliWeight}
+	opts.Subscriptions.Interval = time.Hour
+
+	handler := newHandler(opts, appOpts)
+
+	code, body := testServe(t, handler, healthPath)
+	if code != http.StatusOK || !strings.Contains(body, statePending) {
+		t.Fatalf("before check: got %d %q", code, body)
+	}
+
+	handler.monitor.check(t.Context())
+
+	code, body = testServe(t, handler, healthPath)
+	if code != http.StatusServiceUnavailable ||
+		!strings.Contains(body, stateFailed) {
+		t.Fatalf("after check: got %d %q", code, body)
+	}
+}
+
+// TestHealthWithoutMonitor is always OK.
+func TestHealthWithoutMonitor(t *testing.T) {
+	t.Parallel()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	code, body := testServe(t, newHandler(opts, appOpts), healthPath)
+	if code != http.StatusOK || !strings.Contains(body, `"ok":true`) {
+		t.Fatalf("got %d %q", code, body)
+	}
+}
+
+func testMonitor(baseURL string) *monitor {
+	var appOpts app.Options
+
+	appOpts.BaseURL = baseURL
+	appOpts.Quiet = true
+
+	return newMonitor(
+		SubscriptionOptions{
+			CallbackURL: testCallbackURL,
+			Applis:      []int{testAppliWeight, testAppliSleep},
+			Interval:    time.Hour,
+		},
+		appOpts,
+		func(context.Context) (string, error) { return testToken, nil },
+	)
+}
+
+func testServe(t *testing.T, handler *handler, path string) (int, string) {
+	t.Helper()
+
+	server := httptest.NewServer(handler.routes())
+	defer server.Close()
+
+	return testGet(t, server.URL+path)
+}
@@ -0,0 +1,573 @@
+// Package serve exposes the data endpoints as a local JSON HTTP API, so
+// dashboards such as Grafana or Home Assistant can read Withings data
+// without handling OAuth themselves.
+package serve
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/services/export"
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/mreimbold/withings-cli/internal/services/status"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	startParam        = "start"
+	endParam          = "end"
+	typesParam        = "types"
+	segmentsParam     = "segments"
+	startDateParam    = "startdate"
+	endDateParam      = "enddate"
+	startDateYMDParam = "startdateymd"
+	endDateYMDParam   = "enddateymd"
+	measTypesParam    = "meastypes"
+	categoryParam     = "category"
+	categoryReal      = "1"
+	dataFieldsParam   = "data_fields"
+	indexPath         = "/"
+	statusPath        = "/status"
+	contentType       = "Content-Type"
+	contentTypeJSON   = "application/json"
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+	numberBase10      = 10
+	emptyString       = ""
+)
+
+var (
+	errInvalidStart   = errors.New("invalid start")
+	errInvalidEnd     = errors.New("invalid end")
+	errTypesRoute     = errors.New("types is only supported by /measures")
+	errMethod         = errors.New("only GET is supported")
+	errNotFound       = errors.New("unknown endpoint")
+	errMissingToken   = errors.New("no token source configured")
+	errUnexpectedBody = errors.New("unexpected api response")
+)
+
+// TokenSource returns a usable access token, refreshing it when needed.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Options configures the server.
+type Options struct {
+	Listen        string
+	Token         TokenSource
+	Subscriptions SubscriptionOptions
+}
+
+// route maps an HTTP path to one Withings action.
+type route struct {
+	Path    string
+	Service string
+	Action  string
+	YMD     bool
+	Params  map[string]string
+}
+
+//nolint:gochecknoglobals // Static list of served endpoints.
+var routes = []route{
+	{
+		Path:    "/measures",
+		Service: "measure",
+		Action:  "getmeas",
+		YMD:     false,
+		Params:  map[string]string{categoryParam: categoryReal},
+	},
+	{
+		Path:    "/activity",
+		Service: "v2/measure",
+		Action:  "getactivity",
+		YMD:     true,
+		Params:  map[string]string{dataFieldsParam: export.ActivityFields},
+	},
+	{
+		Path:    "/sleep",
+		Service: "v2/sleep",
+		Action:  "getsummary",
+		YMD:     true,
+		Params:  map[string]string{dataFieldsParam: export.SleepFields},
+	},
+	{
+		Path:    "/workouts",
+		Service: "v2/measure",
+		Action:  "getworkouts",
+		YMD:     true,
+		Params:  map[string]string{dataFieldsParam: export.WorkoutFields},
+	},
+	{
+		Path:    "/heart",
+		Service: "v2/heart",
+		Action:  "list",
+		YMD:     false,
+		Params:  map[string]string{},
+	},
+	{
+		Path:    "/devices",
+		Service: "v2/user",
+		Action:  "getdevice",
+		YMD:     false,
+		Params:  map[string]string{},
+	},
+}
+
+// reserved query keys are never forwarded to the API.
+//
+//nolint:gochecknoglobals // Static set of reserved parameters.
+var reserved = map[string]bool{
+	"action":       true,
+	"access_token": true,
+	startParam:     true,
+	endParam:       true,
+	typesParam:     true,
+	segmentsParam:  true,
+}
+
+// Run serves the data endpoints on opts.Listen until ctx is done or the
+// process receives SIGINT or SIGTERM.
+func Run(ctx context.Context, opts Options, appOpts app.Options) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var listenConfig net.ListenConfig
+
+	listener, err := listenConfig.Listen(ctx, "tcp", opts.Listen)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", opts.Listen, err)
+	}
+
+	handler := newHandler(opts, appOpts)
+
+	//nolint:exhaustruct // Optional server fields are omitted.
+	server := &http.Server{
+		Handler:           handler.routes(),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	err = announce(appOpts, listener.Addr())
+	if err != nil {
+		_ = listener.Close()
+
+		return err
+	}
+
+	if handler.monitor != nil {
+		go handler.monitor.run(ctx)
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("serve: %w", err)
+		}
+
+		return nil
+	case <-ctx.Done():
+	}
+
+	//nolint:contextcheck // The parent context is already cancelled.
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		shutdownTimeout,
+	)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("shutdown server: %w", err)
+	}
+
+	return nil
+}
+
+func announce(appOpts app.Options, addr net.Addr) error {
+	err := output.WriteProgress(
+		appOpts,
+		fmt.Sprintf("serving Withings data on http://%s", addr),
+	)
+	if err != nil {
+		return err
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if ok && !tcpAddr.IP.IsLoopback() {
+		return output.WriteWarning(
+			appOpts,
+			"warning: the server has no authentication and is reachable "+
+				"beyond this machine",
+		)
+	}
+
+	return nil
+}
+
+// NewHandler returns the HTTP handler serving every route. The
+// subscription monitor only runs as part of Run.
+func NewHandler(opts Options, appOpts app.Options) http.Handler {
+	return newHandler(opts, appOpts).routes()
+}
+
+type handler struct {
+	appOpts app.Options
+	source  TokenSource
+	monitor *monitor
+	// mu serializes token lookups so concurrent requests trigger at most
+	// one refresh.
+	mu sync.Mutex
+}
+
+func newHandler(opts Options, appOpts app.Options) *handler {
+	handler := &handler{
+		appOpts: appOpts,
+		source:  opts.Token,
+		monitor: nil,
+		mu:      sync.Mutex{},
+	}
+
+	handler.monitor = newMonitor(opts.Subscriptions, appOpts, handler.token)
+
+	return handler
+}
+
+func (h *handler) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(indexPath, h.index)
+	mux.HandleFunc(statusPath, h.status)
+	mux.HandleFunc(healthPath, h.health)
+
+	for _, target := range routes {
+		mux.HandleFunc(target.Path, h.endpoint(target))
+	}
+
+	return mux
+}
+
+func (h *handler) token(ctx context.Context) (string, error) {
+	if h.source == nil {
+		return emptyString, errMissingToken
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.source(ctx)
+}
+
+func (h *handler) index(writer http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != indexPath {
+		h.fail(writer, request, http.StatusNotFound, errNotFound)
+
+		return
+	}
+
+	paths := make([]string, 0, len(routes))
+	for _, target := range routes {
+		paths = append(paths, target.Path)
+	}
+
+	paths = append(paths, statusPath, healthPath)
+
+	h.write(writer, request, map[string][]string{"endpoints": paths})
+}
+
+func (h *handler) endpoint(target route) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method != http.MethodGet {
+			h.fail(writer, request, http.StatusMethodNotAllowed, errMethod)
+
+			return
+		}
+
+		values, err := buildParams(target, request.URL.Query())
+		if err != nil {
+			h.fail(writer, request, http.StatusBadRequest, err)
+
+			return
+		}
+
+		accessToken, err := h.token(request.Context())
+		if err != nil {
+			h.fail(writer, request, httpStatus(err), err)
+
+			return
+		}
+
+		body, err := h.call(request.Context(), target, accessToken, values)
+		if err != nil {
+			h.fail(writer, request, httpStatus(err), err)
+
+			return
+		}
+
+		h.write(writer, request, body)
+	}
+}
+
+func (h *handler) status(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet {
+		h.fail(writer, request, http.StatusMethodNotAllowed, errMethod)
+
+		return
+	}
+
+	var opts status.Options
+
+	segments := request.URL.Query().Get(segmentsParam)
+	if segments == emptyString {
+		segments = status.DefaultSegments
+	}
+
+	parsed, err := status.ParseSegments(segments)
+	if err != nil {
+		h.fail(writer, request, http.StatusBadRequest, err)
+
+		return
+	}
+
+	opts.Segments = parsed
+
+	accessToken, err := h.token(request.Context())
+	if err != nil {
+		h.fail(writer, request, httpStatus(err), err)
+
+		return
+	}
+
+	collected, err := status.Collect(
+		request.Context(),
+		opts,
+		h.appOpts,
+		accessToken,
+	)
+	if err != nil {
+		h.fail(writer, request, httpStatus(err), err)
+
+		return
+	}
+
+	h.write(writer, request, collected)
+}
+
+// buildParams translates the query into API parameters: start/end accept
+// the same values as the CLI flags, types takes measure names or IDs, and
+// any other key is forwarded unchanged.
+func buildParams(target route, query url.Values) (url.Values, error) {
+	values := url.Values{}
+
+	for key, items := range query {
+		if !reserved[key] {
+			values[key] = items
+		}
+	}
+
+	for key, value := range target.Params {
+		if !values.Has(key) {
+			values.Set(key, value)
+		}
+	}
+
+	err := applyRange(target, query, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if !query.Has(typesParam) {
+		return values, nil
+	}
+
+	if target.Action != "getmeas" {
+		return nil, errTypesRoute
+	}
+
+	types, err := measures.ParseTypes(query.Get(typesParam))
+	if err != nil {
+		return nil, fmt.Errorf("invalid types: %w", err)
+	}
+
+	values.Set(measTypesParam, types)
+
+	return values, nil
+}
+
+func applyRange(target route, query, values url.Values) error {
+	bounds := []struct {
+		Param   string
+		Epoch   string
+		YMD     string
+		Invalid error
+	}{
+		{startParam, startDateParam, startDateYMDParam, errInvalidStart},
+		{endParam, endDateParam, endDateYMDParam, errInvalidEnd},
+	}
+
+	for _, bound := range bounds {
+		raw := query.Get(bound.Param)
+		if raw == emptyString {
+			continue
+		}
+
+		if target.YMD {
+			date, err := filters.DateFromTimeValue(raw, bound.Invalid)
+			if err != nil {
+				return err
+			}
+
+			values.Set(bound.YMD, date)
+
+			continue
+		}
+
+		epoch, err := filters.ParseEpoch(raw)
+		if err != nil {
+			return fmt.Errorf("%w: %w", bound.Invalid, err)
+		}
+
+		values.Set(bound.Epoch, strconv.FormatInt(epoch, numberBase10))
+	}
+
+	return nil
+}
+
+type apiResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+	Error  string          `json:"error"`
+}
+
+func (h *handler) call(
+	ctx context.Context,
+	target route,
+	accessToken string,
+	values url.Values,
+) (json.RawMessage, error) {
+	baseURL := withings.APIBaseURL(h.appOpts.BaseURL, h.appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		withings.ServicePath(baseURL, target.Service),
+		target.Action,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(h.appOpts).Do(req)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	var decoded apiResponse
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errUnexpectedBody, err)
+	}
+
+	if decoded.Status != withings.StatusOK {
+		message := decoded.Error
+		if message == emptyString {
+			message = strings.TrimSpace(string(payload))
+		}
+
+		return nil, app.NewExitError(
+			app.ExitCodeAPI,
+			&withings.APIError{Status: decoded.Status, Message: message},
+		)
+	}
+
+	return decoded.Body, nil
+}
+
+// httpStatus maps CLI exit codes to HTTP statuses: missing or rejected
+// tokens are 401, API and network failures 502, anything else 500.
+func httpStatus(err error) int {
+	var apiErr *withings.APIError
+	if errors.As(err, &apiErr) && apiErr.AuthRejected() {
+		return http.StatusUnauthorized
+	}
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) {
+		return http.StatusInternalServerError
+	}
+
+	switch exitErr.Code {
+	case app.ExitCodeAuth:
+		return http.StatusUnauthorized
+	case app.ExitCodeUsage:
+		return http.StatusBadRequest
+	case app.ExitCodeAPI, app.ExitCodeNetwork:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *handler) write(
+	writer http.ResponseWriter,
+	request *http.Request,
+	body any,
+) {
+	h.respond(writer, request, http.StatusOK, body)
+}
+
+func (h *handler) fail(
+	writer http.ResponseWriter,
+	request *http.Request,
+	code int,
+	err error,
+) {
+	h.respond(writer, request, code, map[string]string{"error": err.Error()})
+}
+
+func (h *handler) respond(
+	writer http.ResponseWriter,
+	request *http.Request,
+	code int,
+	body any,
+) {
+	writer.Header().Set(contentType, contentTypeJSON)
+	writer.WriteHeader(code)
+
+	err := json.NewEncoder(writer).Encode(body)
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+
+	if h.appOpts.Verbose > 0 {
+		_ = output.WriteProgress(
+			h.appOpts,
+			fmt.Sprintf("%s %s %d", request.Method, request.URL.Path, code),
+		)
+	}
+}
@@ -0,0 +1,194 @@
+//nolint:testpackage // test unexported helpers.
+package serve
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	testToken     = "token"
+	testBody      = `{"measuregrps":[]}`
+	testAPIError  = `{"status":503,"error":"Invalid params"}`
+	testWeightID  = "1"
+	testStartDate = "2025-12-01"
+)
+
+var errTestAuth = errors.New("not logged in")
+
+// TestBuildParamsMeasures resolves types and epoch dates and forwards
+// other keys.
+func TestBuildParamsMeasures(t *testing.T) {
+	t.Parallel()
+
+	query := url.Values{}
+	query.Set(typesParam, "weight")
+	query.Set(startParam, testStartDate)
+	query.Set("offset", "300")
+	query.Set("action", "delete")
+
+	values, err := buildParams(routes[0], query)
+	if err != nil {
+		t.Fatalf("buildParams: %v", err)
+	}
+
+	checks := map[string]string{
+		measTypesParam: testWeightID,
+		startDateParam: "1764547200",
+		categoryParam:  categoryReal,
+		"offset":       "300",
+		"action":       "",
+	}
+
+	for key, want := range checks {
+		if got := values.Get(key); got != want {
+			t.Fatalf("%s: got %q want %q", key, got, want)
+		}
+	}
+}
+
+// TestBuildParamsYMD uses date parameters and rejects types outside
+// /measures.
+func TestBuildParamsYMD(t *testing.T) {
+	t.Parallel()
+
+	query := url.Values{}
+	query.Set(startParam, testStartDate)
+	query.Set(dataFieldsParam, "steps")
+
+	values, err := buildParams(routes[1], query)
+	if err != nil {
+		t.Fatalf("buildParams: %v", err)
+	}
+
+	if values.Get(startDateYMDParam) != testStartDate {
+		t.Fatalf("got %q", values.Get(startDateYMDParam))
+	}
+
+	if values.Get(dataFieldsParam) != "steps" {
+		t.Fatalf("data_fields: got %q", values.Get(dataFieldsParam))
+	}
+
+	query.Set(typesParam, "weight")
+
+	_, err = buildParams(routes[1], query)
+	if !errors.Is(err, errTypesRoute) {
+		t.Fatalf("got %v want errTypesRoute", err)
+	}
+}
+
+// TestHandler serves API bodies and maps failures to HTTP statuses.
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	upstream := httptest.NewServer(http.HandlerFunc(testUpstream))
+	defer upstream.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = upstream.URL
+	opts.Token = func(context.Context) (string, error) {
+		return testToken, nil
+	}
+
+	server := httptest.NewServer(NewHandler(opts, appOpts))
+	defer server.Close()
+
+	cases := []struct {
+		Path string
+		Code int
+		Body string
+	}{
+		{"/measures?types=weight", http.StatusOK, testBody},
+		{"/measures?types=unknown", http.StatusBadRequest, "error"},
+		{"/heart", http.StatusBadGateway, "Invalid params"},
+		{"/nope", http.StatusNotFound, errNotFound.Error()},
+		{"/", http.StatusOK, "/measures"},
+	}
+
+	for _, item := range cases {
+		code, body := testGet(t, server.URL+item.Path)
+		if code != item.Code || !strings.Contains(body, item.Body) {
+			t.Fatalf("%s: got %d %q", item.Path, code, body)
+		}
+	}
+}
+
+// TestHandlerAuth reports token failures as 401.
+func TestHandlerAuth(t *testing.T) {
+	t.Parallel()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	opts.Token = func(context.Context) (string, error) {
+		return "", app.NewExitError(app.ExitCodeAuth, errTestAuth)
+	}
+
+	server := httptest.NewServer(NewHandler(opts, appOpts))
+	defer server.Close()
+
+	code, body := testGet(t, server.URL+"/devices")
+	if code != http.StatusUnauthorized || !strings.Contains(body, "logged") {
+		t.Fatalf("got %d %q", code, body)
+	}
+}
+
+func testUpstream(writer http.ResponseWriter, request *http.Request) {
+	_ = request.ParseForm()
+
+	if request.FormValue("action") != "getmeas" {
+		_, _ = writer.Write([]byte(testAPIError))
+
+		return
+	}
+
+	if request.FormValue(measTypesParam) != testWeightID {
+		_, _ = writer.Write([]byte(testAPIError))
+
+		return
+	}
+
+	_, _ = writer.Write([]byte(`{"status":0,"body":` + testBody + `}`))
+}
+
+func testGet(t *testing.T, target string) (int, string) {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		target,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("get %s: %v", target, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	return resp.StatusCode, string(body)
+}
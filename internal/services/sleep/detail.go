@@ -0,0 +1,465 @@
+package sleep
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	actionDetail        = "get"
+	detailStartParam    = "startdate"
+	detailEndParam      = "enddate"
+	detailDefaultFields = "hr,rr"
+	detailDefaultWindow = 24 * time.Hour
+	detailFieldSep      = ","
+	detailKeyStart      = "startdate"
+	detailKeyEnd        = "enddate"
+	detailKeyState      = "state"
+	detailTableHeader   = "Start\tEnd\tStage\tDuration"
+	detailPlainHeader   = "start\tend\tstage\tduration"
+	detailSummaryHeader = "Stage\tDuration\tPercent"
+	percentScale        = 100
+	stageUnknown        = "unknown"
+)
+
+var ()
+
+//nolint:gochecknoglobals // Static lookup tables for sleep detail metadata.
+var (
+	stageNames = map[int]string{
+		0: "awake",
+		1: "light",
+		2: "deep",
+		3: "rem",
+		4: "manual",
+		5: "unspecified",
+	}
+	detailFieldSet = map[string]bool{
+		"hr":                  true,
+		"rr":                  true,
+		"spo2":                true,
+		"snoring":             true,
+		"sdnn_1":              true,
+		"rmssd":               true,
+		"hrv_quality":         true,
+		"mvt_score":           true,
+		"chest_movement_rate": true,
+		"withings_index":      true,
+		"breathing_sounds":    true,
+	}
+)
+
+// DetailOptions captures sleep detail query parameters.
+type DetailOptions struct {
+	TimeRange params.TimeRange
+	User      params.User
+	Fields    string
+	Now       func() time.Time
+}
+
+// Detail fetches per-epoch sleep stages and vitals and writes output.
+func Detail(
+	ctx context.Context,
+	opts DetailOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	values, fields, err := buildDetailParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	payload, err := callAction(
+		ctx,
+		appOpts,
+		accessToken,
+		actionDetail,
+		values,
+	)
+	if err != nil {
+		return err
+	}
+
+	raw, decoded, err := decodeDetailResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	return writeDetail(appOpts, raw, decoded, fields)
+}
+
+func buildDetailParams(opts DetailOptions) (url.Values, []string, error) {
+	values := url.Values{}
+
+	fields, err := filters.ParseDataFields(
+		opts.Fields,
+		detailDefaultFields,
+		detailFieldSet,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse data fields: %w", err)
+	}
+
+	start, end, err := resolveDetailRange(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values.Set(detailStartParam, strconv.FormatInt(start, numberBase10))
+	values.Set(detailEndParam, strconv.FormatInt(end, numberBase10))
+	values.Set(dataFieldsParam, strings.Join(fields, detailFieldSep))
+	applyUser(&values, opts.User)
+
+	return values, fields, nil
+}
+
+// resolveDetailRange defaults to the 24 hours before --end (or now).
+func resolveDetailRange(opts DetailOptions) (int64, int64, error) {
+	nowFunc := opts.Now
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	start, end, err := filters.ResolveEpochWindow(
+		opts.TimeRange,
+		detailDefaultWindow,
+		nowFunc(),
+	)
+	if err != nil {
+		return defaultInt64, defaultInt64, fmt.Errorf("resolve range: %w", err)
+	}
+
+	return start, end, nil
+}
+
+type detailResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+	Error  string          `json:"error"`
+	Detail string          `json:"detail"`
+}
+
+type detailBody struct {
+	Series []detailSeries `json:"series"`
+}
+
+type detailSeries struct {
+	StartDate int64
+	EndDate   int64
+	State     int
+	Values    map[string]map[string]float64
+}
+
+// UnmarshalJSON reads the fixed segment fields and any epoch-keyed series.
+func (s *detailSeries) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return fmt.Errorf("decode sleep segment: %w", err)
+	}
+
+	s.Values = map[string]map[string]float64{}
+
+	for key, value := range raw {
+		switch key {
+		case detailKeyStart:
+			err = json.Unmarshal(value, &s.StartDate)
+		case detailKeyEnd:
+			err = json.Unmarshal(value, &s.EndDate)
+		case detailKeyState:
+			err = json.Unmarshal(value, &s.State)
+		default:
+			var series map[string]float64
+			if json.Unmarshal(value, &series) == nil {
+				s.Values[key] = series
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("decode sleep segment %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+type detailRow struct {
+	Start    string
+	End      string
+	Stage    string
+	Duration string
+	Values   []string
+}
+
+type stageTotal struct {
+	Stage    string
+	Duration int64
+}
+
+func decodeDetailResponse(
+	payload []byte,
+) (json.RawMessage, detailBody, error) {
+	var decoded detailResponse
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, detailBody{}, detailDecodeError(err)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return nil, detailBody{}, err
+	}
+
+	var body detailBody
+
+	err = json.Unmarshal(decoded.Body, &body)
+	if err != nil {
+		return nil, detailBody{}, detailDecodeError(err)
+	}
+
+	sort.Slice(body.Series, func(left, right int) bool {
+		return body.Series[left].StartDate < body.Series[right].StartDate
+	})
+
+	return decoded.Body, body, nil
+}
+
+func detailDecodeError(err error) error {
+	return app.NewExitError(
+		app.ExitCodeFailure,
+		fmt.Errorf("decode api response: %w", err),
+	)
+}
+
+func buildDetailRows(
+	body detailBody,
+	fields []string,
+	location *time.Location,
+) []detailRow {
+	rows := make([]detailRow, defaultInt, len(body.Series))
+
+	for _, segment := range body.Series {
+		values := make([]string, defaultInt, len(fields))
+		for _, field := range fields {
+			values = append(values, formatSeriesMean(segment.Values[field]))
+		}
+
+		rows = append(rows, detailRow{
+			Start:    formatTime(segment.StartDate, location),
+			End:      formatTime(segment.EndDate, location),
+			Stage:    stageName(segment.State),
+			Duration: formatInt64(segmentDuration(segment)),
+			Values:   values,
+		})
+	}
+
+	return rows
+}
+
+func buildStageTotals(body detailBody) []stageTotal {
+	durations := map[int]int64{}
+
+	for _, segment := range body.Series {
+		durations[segment.State] += segmentDuration(segment)
+	}
+
+	states := make([]int, defaultInt, len(durations))
+	for state := range durations {
+		states = append(states, state)
+	}
+
+	sort.Ints(states)
+
+	totals := make([]stageTotal, defaultInt, len(states))
+	for _, state := range states {
+		totals = append(totals, stageTotal{
+			Stage:    stageName(state),
+			Duration: durations[state],
+		})
+	}
+
+	return totals
+}
+
+func segmentDuration(segment detailSeries) int64 {
+	if segment.EndDate <= segment.StartDate {
+		return defaultInt64
+	}
+
+	return segment.EndDate - segment.StartDate
+}
+
+func stageName(state int) string {
+	if name, ok := stageNames[state]; ok {
+		return name
+	}
+
+	return stageUnknown
+}
+
+func formatSeriesMean(series map[string]float64) string {
+	if len(series) == defaultInt {
+		return emptyString
+	}
+
+	var total float64
+
+	for _, value := range series {
+		total += value
+	}
+
+	return formatFloat(total / float64(len(series)))
+}
+
+func writeDetail(
+	opts app.Options,
+	raw json.RawMessage,
+	body detailBody,
+	fields []string,
+) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		err := output.WriteRawJSON(opts, raw)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	rows := buildDetailRows(
+		body,
+		fields,
+		output.DisplayLocation(opts, emptyString),
+	)
+
+	if opts.Plain || opts.NDJSON {
+		return writeDetailPlain(opts, rows, fields)
+	}
+
+	table, err := formatDetailTable(rows, fields, buildStageTotals(body))
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func writeDetailPlain(
+	opts app.Options,
+	rows []detailRow,
+	fields []string,
+) error {
+	header := append([]string{detailPlainHeader}, fields...)
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		lines = append(lines, strings.Join(detailRowCells(row), "\t"))
+	}
+
+	err := output.WritePlain(opts, lines)
+	if err != nil {
+		return fmt.Errorf("write plain output: %w", err)
+	}
+
+	return nil
+}
+
+func detailRowCells(row detailRow) []string {
+	return append([]string{row.Start, row.End, row.Stage, row.Duration},
+		row.Values...)
+}
+
+func formatDetailTable(
+	rows []detailRow,
+	fields []string,
+	totals []stageTotal,
+) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := newTableWriter(&buffer)
+	header := append([]string{detailTableHeader}, upperFields(fields)...)
+	_, _ = fmt.Fprintln(writer, strings.Join(header, "\t"))
+
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(writer, strings.Join(detailRowCells(row), "\t"))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render sleep detail table: %w", err)
+	}
+
+	buffer.WriteString("\n")
+
+	writer = newTableWriter(&buffer)
+	_, _ = fmt.Fprintln(writer, detailSummaryHeader)
+
+	var total int64
+	for _, entry := range totals {
+		total += entry.Duration
+	}
+
+	for _, entry := range totals {
+		_, _ = fmt.Fprintf(
+			writer,
+			"%s\t%s\t%s%%\n",
+			entry.Stage,
+			formatInt64(entry.Duration),
+			formatFloat(percentOf(entry.Duration, total)),
+		)
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render sleep stage table: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
+
+func upperFields(fields []string) []string {
+	upper := make([]string, defaultInt, len(fields))
+	for _, field := range fields {
+		upper = append(upper, strings.ToUpper(field))
+	}
+
+	return upper
+}
+
+func percentOf(part, total int64) float64 {
+	if total == defaultInt64 {
+		return defaultInt
+	}
+
+	return float64(part) * percentScale / float64(total)
+}
@@ -0,0 +1,128 @@
+//nolint:testpackage // test unexported helpers.
+package sleep
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	detailTestStart   = 1767052800
+	detailTestEnd     = 1767056400
+	detailTestNow     = 1767139200
+	detailTestWindow  = 86400
+	detailTestFields  = "HR, rr ,hr,spo2"
+	detailTestPayload = `{"status":0,"body":{"series":[` +
+		`{"startdate":1767053400,"enddate":1767054000,"state":2,` +
+		`"hr":{"1767053400":50,"1767053460":54},"model":"Sleep Analyzer"},` +
+		`{"startdate":1767052800,"enddate":1767053400,"state":1,` +
+		`"hr":{"1767052800":60}}]}}`
+	detailTestRows  = 2
+	detailTestEmpty = ""
+)
+
+// TestBuildDetailParamsDefaults uses the 24 hours before now.
+func TestBuildDetailParamsDefaults(t *testing.T) {
+	t.Parallel()
+
+	values, fields, err := buildDetailParams(DetailOptions{
+		TimeRange: params.TimeRange{
+			Start: detailTestEmpty,
+			End:   detailTestEmpty,
+		},
+		User:   params.User{UserID: detailTestEmpty},
+		Fields: detailTestEmpty,
+		Now:    func() time.Time { return time.Unix(detailTestNow, 0) },
+	})
+	if err != nil {
+		t.Fatalf("buildDetailParams: %v", err)
+	}
+
+	assertParam(
+		t,
+		values.Get(detailStartParam),
+		strconv.Itoa(detailTestNow-detailTestWindow),
+		detailStartParam,
+	)
+	assertParam(
+		t,
+		values.Get(detailEndParam),
+		strconv.Itoa(detailTestNow),
+		detailEndParam,
+	)
+	assertParam(t, values.Get(dataFieldsParam), detailDefaultFields, "fields")
+	assertReport(t, "field count", len(fields), detailTestRows)
+}
+
+// TestParseDetailFields normalizes and dedupes fields.
+func TestParseDetailFields(t *testing.T) {
+	t.Parallel()
+
+	fields, err := filters.ParseDataFields(
+		detailTestFields,
+		detailDefaultFields,
+		detailFieldSet,
+	)
+	if err != nil {
+		t.Fatalf("ParseDataFields: %v", err)
+	}
+
+	assertParam(t, strings.Join(fields, ","), "hr,rr,spo2", "fields")
+
+	_, err = filters.ParseDataFields(
+		"hr,pulse",
+		detailDefaultFields,
+		detailFieldSet,
+	)
+	if !errors.Is(err, errs.ErrInvalidDataField) {
+		t.Fatalf(sleepTestErrFmt, err, errs.ErrInvalidDataField)
+	}
+}
+
+// TestResolveDetailRangeRejectsInverted rejects end before start.
+func TestResolveDetailRangeRejectsInverted(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := resolveDetailRange(DetailOptions{
+		TimeRange: params.TimeRange{
+			Start: strconv.Itoa(detailTestEnd),
+			End:   strconv.Itoa(detailTestStart),
+		},
+		User:   params.User{UserID: detailTestEmpty},
+		Fields: detailTestEmpty,
+		Now:    nil,
+	})
+	if !errors.Is(err, errs.ErrEndBeforeStart) {
+		t.Fatalf(sleepTestErrFmt, err, errs.ErrEndBeforeStart)
+	}
+}
+
+// TestDecodeDetailRowsAndTotals averages series and sums stages.
+func TestDecodeDetailRowsAndTotals(t *testing.T) {
+	t.Parallel()
+
+	_, decoded, err := decodeDetailResponse([]byte(detailTestPayload))
+	if err != nil {
+		t.Fatalf("decodeDetailResponse: %v", err)
+	}
+
+	rows := buildDetailRows(decoded, []string{"hr", "rr"}, time.UTC)
+	assertReport(t, "rows", len(rows), detailTestRows)
+	assertParam(t, rows[0].Stage, "light", "stage")
+	assertParam(t, rows[0].Values[0], "60.0", "hr")
+	assertParam(t, rows[1].Stage, "deep", "stage")
+	assertParam(t, rows[1].Values[0], "52.0", "hr")
+	assertParam(t, rows[1].Values[1], detailTestEmpty, "rr")
+
+	totals := buildStageTotals(decoded)
+	assertReport(t, "totals", len(totals), detailTestRows)
+	assertParam(t, totals[0].Stage, "light", "stage")
+	assertReport(t, "light", totals[0].Duration, int64(600))
+}
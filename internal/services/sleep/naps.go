@@ -0,0 +1,108 @@
+package sleep
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	napMaxDuration    = 2 * time.Hour
+	napDaytimeMax     = 4 * time.Hour
+	napDaytimeFirstHr = 9
+	napDaytimeLastHr  = 20
+	kindNight         = "night"
+	kindNap           = "nap"
+)
+
+var errNapFilterConflict = errors.New(
+	"--include-naps and --naps-only cannot be combined",
+)
+
+type napFilter int
+
+const (
+	napsExclude napFilter = iota
+	napsInclude
+	napsOnly
+)
+
+func resolveNapFilter(opts Options) (napFilter, error) {
+	switch {
+	case opts.IncludeNaps && opts.NapsOnly:
+		return napsExclude, errNapFilterConflict
+	case opts.NapsOnly:
+		return napsOnly, nil
+	case opts.IncludeNaps:
+		return napsInclude, nil
+	default:
+		return napsExclude, nil
+	}
+}
+
+// isNap classifies a sleep session as a nap when it is short, or when it is
+// a moderately short session that starts during the day.
+func isNap(series series, location *time.Location) bool {
+	duration := seriesDuration(series)
+	if duration <= defaultInt64 {
+		return false
+	}
+
+	length := time.Duration(duration) * time.Second
+	if length < napMaxDuration {
+		return true
+	}
+
+	if series.StartDate == defaultInt64 || length >= napDaytimeMax {
+		return false
+	}
+
+	hour := time.Unix(series.StartDate, defaultInt64).In(location).Hour()
+
+	return hour >= napDaytimeFirstHr && hour < napDaytimeLastHr
+}
+
+func seriesDuration(series series) int64 {
+	if series.Duration != defaultInt64 {
+		return series.Duration
+	}
+
+	if !hasBounds(series) {
+		return defaultInt64
+	}
+
+	return series.EndDate - series.StartDate
+}
+
+func seriesKind(series series, location *time.Location) string {
+	if isNap(series, location) {
+		return kindNap
+	}
+
+	return kindNight
+}
+
+func filterNaps(body body, filter napFilter) body {
+	if filter == napsInclude {
+		return body
+	}
+
+	location := sleepLocation(body.Timezone)
+	kept := make([]series, defaultInt, len(body.Series))
+
+	for _, series := range body.Series {
+		if isNap(series, location) == (filter == napsOnly) {
+			kept = append(kept, series)
+		}
+	}
+
+	body.Series = kept
+
+	return body
+}
+
+// IsNap reports whether a session from start to end (epoch seconds)
+// counts as a nap, by the rule behind the `kind` column of `sleep get`.
+func IsNap(start, end int64, location *time.Location) bool {
+	//nolint:exhaustruct // Only the session bounds matter.
+	return isNap(series{StartDate: start, EndDate: end}, location)
+}
@@ -0,0 +1,155 @@
+//nolint:testpackage // test unexported helpers.
+package sleep
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	napTestYear        = 2025
+	napTestMonth       = 12
+	napTestDay         = 30
+	napTestNightHour   = 23
+	napTestAfternoonHr = 14
+	napTestShortSecs   = 1800
+	napTestDaySecs     = 10800
+	napTestNightSecs   = 27000
+	napTestDefaultInt  = 0
+	napTestKindFmt     = "kind got %q want %q"
+)
+
+// TestIsNap classifies short and daytime sessions as naps.
+func TestIsNap(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		hour  int
+		secs  int64
+		isNap bool
+	}{
+		{
+			name:  "night",
+			hour:  napTestNightHour,
+			secs:  napTestNightSecs,
+			isNap: false,
+		},
+		{
+			name:  "short night",
+			hour:  napTestNightHour,
+			secs:  napTestShortSecs,
+			isNap: true,
+		},
+		{
+			name:  "afternoon",
+			hour:  napTestAfternoonHr,
+			secs:  napTestDaySecs,
+			isNap: true,
+		},
+		{
+			name:  "long afternoon",
+			hour:  napTestAfternoonHr,
+			secs:  napTestNightSecs,
+			isNap: false,
+		},
+	}
+
+	for _, test := range cases {
+		got := isNap(napSeries(test.hour, test.secs), time.UTC)
+		if got != test.isNap {
+			t.Fatalf("%s got %t want %t", test.name, got, test.isNap)
+		}
+	}
+}
+
+// TestSeriesDurationFallback derives duration from start/end.
+func TestSeriesDurationFallback(t *testing.T) {
+	t.Parallel()
+
+	entry := napSeries(napTestNightHour, napTestNightSecs)
+	entry.Duration = napTestDefaultInt
+
+	if got := seriesDuration(entry); got != napTestNightSecs {
+		t.Fatalf("duration got %d want %d", got, napTestNightSecs)
+	}
+}
+
+// TestFilterNaps separates naps from main sleep.
+func TestFilterNaps(t *testing.T) {
+	t.Parallel()
+
+	input := body{
+		Timezone: "UTC",
+		Series: []series{
+			napSeries(napTestNightHour, napTestNightSecs),
+			napSeries(napTestAfternoonHr, napTestDaySecs),
+		},
+		More:   false,
+		Offset: napTestDefaultInt,
+	}
+
+	assertSeriesKinds(t, filterNaps(input, napsExclude), kindNight)
+	assertSeriesKinds(t, filterNaps(input, napsOnly), kindNap)
+	assertSeriesKinds(t, filterNaps(input, napsInclude), kindNight, kindNap)
+}
+
+// TestResolveNapFilterConflict rejects combined nap flags.
+func TestResolveNapFilterConflict(t *testing.T) {
+	t.Parallel()
+
+	var opts Options
+
+	opts.IncludeNaps = true
+	opts.NapsOnly = true
+
+	_, err := resolveNapFilter(opts)
+	if !errors.Is(err, errNapFilterConflict) {
+		t.Fatalf("err got %v want %v", err, errNapFilterConflict)
+	}
+}
+
+func napSeries(hour int, secs int64) series {
+	start := time.Date(
+		napTestYear,
+		time.Month(napTestMonth),
+		napTestDay,
+		hour,
+		napTestDefaultInt,
+		napTestDefaultInt,
+		napTestDefaultInt,
+		time.UTC,
+	).Unix()
+
+	return series{
+		Date:      sleepTestDate,
+		StartDate: start,
+		EndDate:   start + secs,
+		Duration:  secs,
+		Score:     napTestDefaultInt,
+		Wakeups:   napTestDefaultInt,
+		Model:     napTestDefaultInt,
+		Data: data{
+			Score:     napTestDefaultInt,
+			Wakeups:   napTestDefaultInt,
+			Breathing: nil,
+			AHI:       nil,
+		},
+	}
+}
+
+func assertSeriesKinds(t *testing.T, filtered body, kinds ...string) {
+	t.Helper()
+
+	if len(filtered.Series) != len(kinds) {
+		t.Fatalf("series got %d want %d", len(filtered.Series), len(kinds))
+	}
+
+	for index, entry := range filtered.Series {
+		got := seriesKind(entry, time.UTC)
+		if got != kinds[index] {
+			t.Fatalf(napTestKindFmt, got, kinds[index])
+		}
+	}
+}
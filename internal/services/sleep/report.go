@@ -0,0 +1,372 @@
+package sleep
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	minutesPerHour       = 60
+	minutesPerDay        = 24 * minutesPerHour
+	noonMinutes          = 12 * minutesPerHour
+	regularityWindowMins = 120
+	regularityMax        = 100
+	regularityMin        = 0
+	regularityMinNights  = 2
+	halfDivisor          = 2
+	floatBitSize         = 64
+	floatPrecision       = 1
+	clockFormat          = "%02d:%02d"
+	durationFormat       = "%dh%02dm"
+	reportTableHeader    = "Metric\tValue"
+	reportPlainHeader    = "metric\tvalue"
+)
+
+// Report fetches sleep summaries and writes aggregate regularity metrics.
+func Report(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	payload, err := fetchSummary(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	return writeReport(appOpts, buildReport(decoded.Body))
+}
+
+//nolint:tagliatelle // Report JSON uses snake_case like the CLI columns.
+type report struct {
+	Nights      int      `json:"nights"`
+	AvgDuration int64    `json:"avg_duration"`
+	AvgScore    float64  `json:"avg_score"`
+	AvgBedtime  string   `json:"avg_bedtime"`
+	AvgWake     string   `json:"avg_wake"`
+	AvgMidpoint string   `json:"avg_midpoint"`
+	BedtimeSD   float64  `json:"bedtime_sd_minutes"`
+	Regularity  *float64 `json:"regularity"`
+	Naps        int      `json:"naps"`
+	NapDuration int64    `json:"nap_duration"`
+	// The breathing aggregates are nil when no night reported them.
+	AvgAHI       *float64 `json:"avg_ahi"`
+	MaxAHI       *int     `json:"max_ahi"`
+	AHISeverity  string   `json:"ahi_severity"`
+	AvgBreathing *float64 `json:"avg_breathing"`
+}
+
+// breathingTotals sums the optional breathing fields of the nights that
+// reported them.
+type breathingTotals struct {
+	ahi       int
+	ahiNights int
+	maxAHI    *int
+	breathing int
+	breathed  int
+}
+
+type nightClock struct {
+	Start    int64
+	Bedtime  float64
+	Wake     float64
+	Midpoint float64
+}
+
+func buildReport(body body) report {
+	location := sleepLocation(body.Timezone)
+	nights := make([]nightClock, defaultInt, len(body.Series))
+	summary := report{
+		Nights:      defaultInt,
+		AvgDuration: defaultInt64,
+		AvgScore:    defaultInt,
+		AvgBedtime:  emptyString,
+		AvgWake:     emptyString,
+		AvgMidpoint: emptyString,
+		BedtimeSD:   defaultInt,
+		Regularity:  nil,
+		Naps:        defaultInt,
+		NapDuration: defaultInt64,
+
+		AvgAHI:       nil,
+		MaxAHI:       nil,
+		AHISeverity:  emptyString,
+		AvgBreathing: nil,
+	}
+
+	var totalDuration int64
+
+	var breathing breathingTotals
+
+	var totalScore, scored int
+
+	for _, series := range body.Series {
+		if isNap(series, location) {
+			summary.Naps++
+			summary.NapDuration += seriesDuration(series)
+
+			continue
+		}
+
+		totalDuration += seriesDuration(series)
+
+		if score := seriesScore(series); score != defaultInt {
+			totalScore += score
+			scored++
+		}
+
+		if hasBounds(series) {
+			nights = append(nights, newNightClock(series, location))
+		}
+
+		breathing.add(series.Data)
+	}
+
+	summary.Nights = len(body.Series) - summary.Naps
+	if summary.Nights > defaultInt {
+		summary.AvgDuration = totalDuration / int64(summary.Nights)
+	}
+
+	if scored > defaultInt {
+		summary.AvgScore = float64(totalScore) / float64(scored)
+	}
+
+	applyClockMetrics(&summary, nights)
+	breathing.apply(&summary)
+
+	return summary
+}
+
+func (totals *breathingTotals) add(night data) {
+	if night.AHI != nil {
+		totals.ahi += *night.AHI
+		totals.ahiNights++
+
+		if totals.maxAHI == nil || *night.AHI > *totals.maxAHI {
+			totals.maxAHI = night.AHI
+		}
+	}
+
+	if night.Breathing != nil {
+		totals.breathing += *night.Breathing
+		totals.breathed++
+	}
+}
+
+// apply sets the breathing aggregates; the severity labels the average
+// AHI with the same cut-offs as sleep get.
+func (totals *breathingTotals) apply(summary *report) {
+	if totals.ahiNights > defaultInt {
+		average := float64(totals.ahi) / float64(totals.ahiNights)
+		summary.AvgAHI = &average
+		summary.MaxAHI = totals.maxAHI
+		summary.AHISeverity = ahiLevel(average)
+	}
+
+	if totals.breathed > defaultInt {
+		average := float64(totals.breathing) / float64(totals.breathed)
+		summary.AvgBreathing = &average
+	}
+}
+
+func hasBounds(series series) bool {
+	return series.StartDate != defaultInt64 &&
+		series.EndDate > series.StartDate
+}
+
+func newNightClock(series series, location *time.Location) nightClock {
+	midpoint := series.StartDate + (series.EndDate-series.StartDate)/halfDivisor
+
+	return nightClock{
+		Start:    series.StartDate,
+		Bedtime:  noonRelativeMinutes(series.StartDate, location),
+		Wake:     noonRelativeMinutes(series.EndDate, location),
+		Midpoint: noonRelativeMinutes(midpoint, location),
+	}
+}
+
+func applyClockMetrics(summary *report, nights []nightClock) {
+	if len(nights) == defaultInt {
+		return
+	}
+
+	sort.Slice(nights, func(left, right int) bool {
+		return nights[left].Start < nights[right].Start
+	})
+
+	bedtimes := make([]float64, defaultInt, len(nights))
+	wakes := make([]float64, defaultInt, len(nights))
+	midpoints := make([]float64, defaultInt, len(nights))
+
+	for _, night := range nights {
+		bedtimes = append(bedtimes, night.Bedtime)
+		wakes = append(wakes, night.Wake)
+		midpoints = append(midpoints, night.Midpoint)
+	}
+
+	summary.AvgBedtime = formatClock(mean(bedtimes))
+	summary.AvgWake = formatClock(mean(wakes))
+	summary.AvgMidpoint = formatClock(mean(midpoints))
+	summary.BedtimeSD = standardDeviation(bedtimes)
+	summary.Regularity = regularityIndex(midpoints)
+}
+
+// noonRelativeMinutes returns minutes since the preceding local noon, so
+// times around midnight average without wrapping.
+func noonRelativeMinutes(epoch int64, location *time.Location) float64 {
+	local := time.Unix(epoch, defaultInt64).In(location)
+	minutes := local.Hour()*minutesPerHour + local.Minute()
+
+	return float64((minutes - noonMinutes + minutesPerDay) % minutesPerDay)
+}
+
+// regularityIndex scores night-to-night midpoint stability from 0 to 100,
+// where an average shift of two hours or more scores 0.
+func regularityIndex(midpoints []float64) *float64 {
+	if len(midpoints) < regularityMinNights {
+		return nil
+	}
+
+	var shift float64
+
+	for index := 1; index < len(midpoints); index++ {
+		shift += math.Abs(midpoints[index] - midpoints[index-1])
+	}
+
+	shift /= float64(len(midpoints) - 1)
+
+	score := regularityMax - shift*regularityMax/regularityWindowMins
+	score = math.Max(regularityMin, math.Min(regularityMax, score))
+
+	return &score
+}
+
+func mean(values []float64) float64 {
+	var total float64
+
+	for _, value := range values {
+		total += value
+	}
+
+	return total / float64(len(values))
+}
+
+func standardDeviation(values []float64) float64 {
+	average := mean(values)
+
+	var variance float64
+
+	for _, value := range values {
+		variance += (value - average) * (value - average)
+	}
+
+	return math.Sqrt(variance / float64(len(values)))
+}
+
+func formatClock(noonMinutesValue float64) string {
+	rounded := int(math.Round(noonMinutesValue))
+	minutes := (rounded + noonMinutes) % minutesPerDay
+
+	return fmt.Sprintf(
+		clockFormat,
+		minutes/minutesPerHour,
+		minutes%minutesPerHour,
+	)
+}
+
+func formatDuration(seconds int64) string {
+	minutes := seconds / minutesPerHour
+
+	return fmt.Sprintf(
+		durationFormat,
+		minutes/minutesPerHour,
+		minutes%minutesPerHour,
+	)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', floatPrecision, floatBitSize)
+}
+
+func (summary report) toRows() [][]string {
+	maxAHI := emptyString
+	if summary.MaxAHI != nil {
+		maxAHI = strconv.Itoa(*summary.MaxAHI)
+	}
+
+	return [][]string{
+		{"nights", strconv.Itoa(summary.Nights)},
+		{"avg_duration", formatDuration(summary.AvgDuration)},
+		{"avg_score", formatFloat(summary.AvgScore)},
+		{"avg_bedtime", summary.AvgBedtime},
+		{"avg_wake", summary.AvgWake},
+		{"avg_midpoint", summary.AvgMidpoint},
+		{"bedtime_sd_minutes", formatFloat(summary.BedtimeSD)},
+		{"regularity", formatOptionalFloat(summary.Regularity)},
+		{"naps", strconv.Itoa(summary.Naps)},
+		{"nap_duration", formatDuration(summary.NapDuration)},
+		{"avg_ahi", formatOptionalFloat(summary.AvgAHI)},
+		{"max_ahi", maxAHI},
+		{"ahi_severity", summary.AHISeverity},
+		{"avg_breathing", formatOptionalFloat(summary.AvgBreathing)},
+	}
+}
+
+func writeReport(opts app.Options, summary report) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		err := output.WriteRawJSON(opts, summary)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	rows := summary.toRows()
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, reportPlainHeader)
+
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+
+	if opts.Plain || opts.NDJSON {
+		err := output.WritePlain(opts, lines)
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	lines[0] = reportTableHeader
+
+	table, err := output.RenderTable(opts, reportPlainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render sleep report table: %w", err)
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
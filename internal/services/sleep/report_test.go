@@ -0,0 +1,173 @@
+//nolint:testpackage // test unexported helpers.
+package sleep
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	reportTestNightSecs   = 28800
+	reportTestBedHour     = 23
+	reportTestLateHour    = 1
+	reportTestNapHour     = 14
+	reportTestNapSecs     = 1800
+	reportTestDefaultInt  = 0
+	reportTestNights      = 2
+	reportTestNaps        = 1
+	reportTestSD          = 60
+	reportTestFmt         = "%s got %v want %v"
+	reportTestMildAHI     = 6
+	reportTestModerateAHI = 20
+	reportTestSevereAHI   = 40
+	reportTestAvgAHI      = 13
+	reportTestBreathing   = 9
+)
+
+// TestBuildReport aggregates nights and reports naps separately.
+func TestBuildReport(t *testing.T) {
+	t.Parallel()
+
+	first := reportSeries(napTestDay, reportTestBedHour, reportTestNightSecs)
+	second := reportSeries(
+		napTestDay+1,
+		reportTestLateHour,
+		reportTestNightSecs,
+	)
+	nap := reportSeries(napTestDay, reportTestNapHour, reportTestNapSecs)
+
+	summary := buildReport(body{
+		Timezone: "UTC",
+		Series:   []series{second, nap, first},
+		More:     false,
+		Offset:   reportTestDefaultInt,
+	})
+
+	assertReport(t, "nights", summary.Nights, reportTestNights)
+	assertReport(t, "naps", summary.Naps, reportTestNaps)
+	assertReport(t, "nap_duration", summary.NapDuration, reportTestNapSecs)
+	assertReport(t, "avg_duration", summary.AvgDuration, reportTestNightSecs)
+	assertReport(t, "avg_bedtime", summary.AvgBedtime, "00:00")
+	assertReport(t, "avg_wake", summary.AvgWake, "08:00")
+	assertReport(t, "avg_midpoint", summary.AvgMidpoint, "04:00")
+	assertReport(t, "bedtime_sd", summary.BedtimeSD, float64(reportTestSD))
+
+	if summary.Regularity == nil {
+		t.Fatal("expected regularity")
+	}
+
+	assertReport(t, "regularity", *summary.Regularity, float64(0))
+}
+
+// TestBuildReportBreathing averages AHI over the nights that report it
+// and labels the average.
+func TestBuildReportBreathing(t *testing.T) {
+	t.Parallel()
+
+	first := reportSeries(napTestDay, reportTestBedHour, reportTestNightSecs)
+	first.Data.AHI = intPointer(reportTestMildAHI)
+	first.Data.Breathing = intPointer(reportTestBreathing)
+	second := reportSeries(
+		napTestDay+1,
+		reportTestBedHour,
+		reportTestNightSecs,
+	)
+	second.Data.AHI = intPointer(reportTestModerateAHI)
+	third := reportSeries(napTestDay+2, reportTestBedHour, reportTestNightSecs)
+	nap := reportSeries(napTestDay, reportTestNapHour, reportTestNapSecs)
+	nap.Data.AHI = intPointer(reportTestSevereAHI)
+
+	summary := buildReport(body{
+		Timezone: "UTC",
+		Series:   []series{first, second, third, nap},
+		More:     false,
+		Offset:   reportTestDefaultInt,
+	})
+
+	if summary.AvgAHI == nil || summary.MaxAHI == nil ||
+		summary.AvgBreathing == nil {
+		t.Fatalf("expected breathing aggregates, got %+v", summary)
+	}
+
+	assertReport(t, "avg_ahi", *summary.AvgAHI, reportTestAvgAHI)
+	assertReport(t, "max_ahi", *summary.MaxAHI, reportTestModerateAHI)
+	assertReport(t, "ahi_severity", summary.AHISeverity, severityMild)
+	assertReport(
+		t,
+		"avg_breathing",
+		*summary.AvgBreathing,
+		float64(reportTestBreathing),
+	)
+
+	for _, row := range summary.toRows() {
+		if row[0] == "ahi_severity" {
+			assertReport(t, "ahi_severity row", row[1], severityMild)
+		}
+	}
+}
+
+// TestBuildReportNoBreathing leaves the aggregates empty without AHI data.
+func TestBuildReportNoBreathing(t *testing.T) {
+	t.Parallel()
+
+	summary := buildReport(body{
+		Timezone: "UTC",
+		Series: []series{
+			reportSeries(napTestDay, reportTestBedHour, reportTestNightSecs),
+		},
+		More:   false,
+		Offset: reportTestDefaultInt,
+	})
+
+	if summary.AvgAHI != nil || summary.MaxAHI != nil ||
+		summary.AvgBreathing != nil || summary.AHISeverity != emptyString {
+		t.Fatalf("expected no breathing aggregates, got %+v", summary)
+	}
+}
+
+// TestRegularityIndexSingleNight omits regularity for a single night.
+func TestRegularityIndexSingleNight(t *testing.T) {
+	t.Parallel()
+
+	if got := regularityIndex([]float64{reportTestSD}); got != nil {
+		t.Fatalf("regularity got %v want nil", *got)
+	}
+}
+
+// TestFormatClockWraps converts noon-relative minutes back to clock time.
+func TestFormatClockWraps(t *testing.T) {
+	t.Parallel()
+
+	assertReport(t, "noon", formatClock(reportTestDefaultInt), "12:00")
+	assertReport(t, "midnight", formatClock(noonMinutes), "00:00")
+	assertReport(t, "duration", formatDuration(reportTestNightSecs), "8h00m")
+}
+
+func reportSeries(day, hour int, secs int64) series {
+	entry := napSeries(hour, secs)
+	entry.StartDate = time.Date(
+		napTestYear,
+		time.Month(napTestMonth),
+		day,
+		hour,
+		reportTestDefaultInt,
+		reportTestDefaultInt,
+		reportTestDefaultInt,
+		time.UTC,
+	).Unix()
+	entry.EndDate = entry.StartDate + secs
+
+	return entry
+}
+
+func intPointer(value int) *int {
+	return &value
+}
+
+func assertReport[T comparable](t *testing.T, label string, got, want T) {
+	t.Helper()
+
+	if got != want {
+		t.Fatalf(reportTestFmt, label, got, want)
+	}
+}
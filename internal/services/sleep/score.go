@@ -0,0 +1,412 @@
+package sleep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// Score period granularities for --group-by.
+const (
+	GroupByDay   = "day"
+	GroupByWeek  = "week"
+	GroupByMonth = "month"
+)
+
+const (
+	scoreDataFields = dataFields +
+		",sleep_efficiency,total_sleep_time,total_timeinbed"
+	monthFormat      = "2006-01"
+	arrowUp          = "↑"
+	arrowDown        = "↓"
+	arrowFlat        = "→"
+	scoreTableHead   = "Period\tNights\tScore\tDuration\tWakeups\tEfficiency"
+	scorePlainHead   = "period\tnights\tscore\tduration\twakeups\tefficiency"
+	daysPerWeek      = 7
+	scoreColumn      = 2
+	durationColumn   = 3
+	wakeupsColumn    = 4
+	efficiencyColumn = 5
+	isoWeekStartDay  = int(time.Monday)
+)
+
+var (
+	errInvalidGroupBy = errors.New(
+		"invalid --group-by (want day, week, or month)",
+	)
+	errLastConflict = errors.New("--last cannot be combined with --start, " +
+		"--end, or --date")
+)
+
+// ScoreOptions captures sleep score trend parameters.
+type ScoreOptions struct {
+	Options
+	// Last is a span ending now, e.g. 30d; it replaces --start/--end.
+	Last string
+	// GroupBy is day, week, or month.
+	GroupBy string
+}
+
+//nolint:tagliatelle // Score JSON uses snake_case like the CLI columns.
+type scorePeriod struct {
+	Period     string   `json:"period"`
+	Nights     int      `json:"nights"`
+	Score      *float64 `json:"avg_score"`
+	Duration   int64    `json:"avg_duration"`
+	Wakeups    float64  `json:"avg_wakeups"`
+	Efficiency *float64 `json:"avg_efficiency"`
+}
+
+// periodTotals accumulates the nights of one period.
+type periodTotals struct {
+	Nights      int
+	Duration    int64
+	Wakeups     int
+	ScoreSum    int
+	Scored      int
+	Efficiency  float64
+	WithEffRate int
+}
+
+// Score fetches sleep summaries and writes per-period averages of score,
+// duration, wakeups, and efficiency. Naps are left out.
+func Score(
+	ctx context.Context,
+	opts ScoreOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	err := applyLast(&opts, time.Now)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	period, err := periodFunc(opts.GroupBy)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	values, err := buildParams(opts.Options)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	values.Set(dataFieldsParam, scoreDataFields)
+
+	payload, err := callAction(ctx, appOpts, accessToken, actionGet, values)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeResponse(payload)
+	if err != nil {
+		return err
+	}
+
+	return writeScore(appOpts, buildScorePeriods(decoded.Body, period))
+}
+
+// applyLast turns --last into a start time relative to now.
+func applyLast(opts *ScoreOptions, now func() time.Time) error {
+	if opts.Last == emptyString {
+		return nil
+	}
+
+	if opts.TimeRange.Start != emptyString ||
+		opts.TimeRange.End != emptyString ||
+		opts.Date.Date != emptyString {
+		return errLastConflict
+	}
+
+	span, err := filters.ParseSpan(opts.Last)
+	if err != nil {
+		return fmt.Errorf("invalid --last: %w", err)
+	}
+
+	opts.TimeRange.Start = now().Add(-span).UTC().Format(time.RFC3339)
+
+	return nil
+}
+
+// periodFunc returns the labeler for a --group-by value: a night's local
+// date, its ISO week (Monday start, labeled by that Monday), or its month.
+func periodFunc(groupBy string) (func(time.Time) string, error) {
+	switch strings.ToLower(groupBy) {
+	case GroupByDay:
+		return func(night time.Time) string {
+			return night.Format(time.DateOnly)
+		}, nil
+	case emptyString, GroupByWeek:
+		return weekStart, nil
+	case GroupByMonth:
+		return func(night time.Time) string {
+			return night.Format(monthFormat)
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errInvalidGroupBy, groupBy)
+	}
+}
+
+func weekStart(night time.Time) string {
+	offset := (int(night.Weekday()) - isoWeekStartDay + daysPerWeek) %
+		daysPerWeek
+
+	return night.AddDate(0, 0, -offset).Format(time.DateOnly)
+}
+
+// GroupByChoices lists the --group-by values.
+func GroupByChoices() []output.Choice {
+	return []output.Choice{
+		{Name: GroupByDay, Value: GroupByDay},
+		{Name: GroupByWeek, Value: GroupByWeek},
+		{Name: GroupByMonth, Value: GroupByMonth},
+	}
+}
+
+func buildScorePeriods(
+	body body,
+	period func(time.Time) string,
+) []scorePeriod {
+	location := sleepLocation(body.Timezone)
+	totals := map[string]*periodTotals{}
+
+	for _, series := range body.Series {
+		if isNap(series, location) {
+			continue
+		}
+
+		night, ok := nightDate(series, location)
+		if !ok {
+			continue
+		}
+
+		key := period(night)
+
+		current := totals[key]
+		if current == nil {
+			current = &periodTotals{}
+			totals[key] = current
+		}
+
+		current.add(series)
+	}
+
+	keys := make([]string, defaultInt, len(totals))
+	for key := range totals {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	periods := make([]scorePeriod, defaultInt, len(keys))
+	for _, key := range keys {
+		periods = append(periods, totals[key].summary(key))
+	}
+
+	return periods
+}
+
+// nightDate is the API date of a night, or the local date it started.
+func nightDate(series series, location *time.Location) (time.Time, bool) {
+	if series.Date != emptyString {
+		parsed, err := time.ParseInLocation(
+			time.DateOnly,
+			series.Date,
+			location,
+		)
+		if err == nil {
+			return parsed, true
+		}
+	}
+
+	if series.StartDate == defaultInt64 {
+		return time.Time{}, false
+	}
+
+	return time.Unix(series.StartDate, defaultInt64).In(location), true
+}
+
+func (totals *periodTotals) add(series series) {
+	totals.Nights++
+	totals.Duration += seriesDuration(series)
+	totals.Wakeups += seriesWakeups(series)
+
+	if score := seriesScore(series); score != defaultInt {
+		totals.ScoreSum += score
+		totals.Scored++
+	}
+
+	if efficiency, ok := seriesEfficiency(series); ok {
+		totals.Efficiency += efficiency
+		totals.WithEffRate++
+	}
+}
+
+func (totals *periodTotals) summary(key string) scorePeriod {
+	period := scorePeriod{
+		Period:     key,
+		Nights:     totals.Nights,
+		Score:      nil,
+		Duration:   totals.Duration / int64(totals.Nights),
+		Wakeups:    float64(totals.Wakeups) / float64(totals.Nights),
+		Efficiency: nil,
+	}
+
+	if totals.Scored > defaultInt {
+		score := float64(totals.ScoreSum) / float64(totals.Scored)
+		period.Score = &score
+	}
+
+	if totals.WithEffRate > defaultInt {
+		efficiency := totals.Efficiency / float64(totals.WithEffRate)
+		period.Efficiency = &efficiency
+	}
+
+	return period
+}
+
+// seriesEfficiency is the share of time in bed spent asleep (0-1), from
+// sleep_efficiency or else total_sleep_time / total_timeinbed.
+func seriesEfficiency(series series) (float64, bool) {
+	if series.Data.Efficiency != nil {
+		return *series.Data.Efficiency, true
+	}
+
+	if series.Data.TotalSleep == nil || series.Data.TimeInBed == nil ||
+		*series.Data.TimeInBed <= defaultInt64 {
+		return defaultInt, false
+	}
+
+	return float64(*series.Data.TotalSleep) /
+		float64(*series.Data.TimeInBed), true
+}
+
+// scoreCells formats one period; with previous set each metric is
+// followed by its direction against that period.
+func scoreCells(current scorePeriod, previous *scorePeriod) []string {
+	cells := []string{
+		current.Period,
+		strconv.Itoa(current.Nights),
+		formatOptionalFloat(current.Score),
+		formatDuration(current.Duration),
+		formatFloat(current.Wakeups),
+		formatEfficiency(current.Efficiency),
+	}
+
+	if previous == nil {
+		return cells
+	}
+
+	cells[scoreColumn] += trendArrow(current.Score, previous.Score, formatFloat)
+	cells[durationColumn] += trendArrow(
+		floatPointer(float64(current.Duration)),
+		floatPointer(float64(previous.Duration)),
+		func(value float64) string { return formatDuration(int64(value)) },
+	)
+	cells[wakeupsColumn] += trendArrow(
+		floatPointer(current.Wakeups),
+		floatPointer(previous.Wakeups),
+		formatFloat,
+	)
+	cells[efficiencyColumn] += trendArrow(
+		current.Efficiency,
+		previous.Efficiency,
+		func(value float64) string { return formatEfficiency(&value) },
+	)
+
+	return cells
+}
+
+// trendArrow returns an up or down arrow, a flat one when both values
+// display the same, or "" when either is missing.
+func trendArrow(
+	current *float64,
+	previous *float64,
+	format func(float64) string,
+) string {
+	switch {
+	case current == nil || previous == nil:
+		return emptyString
+	case format(*current) == format(*previous):
+		return " " + arrowFlat
+	case *current > *previous:
+		return " " + arrowUp
+	default:
+		return " " + arrowDown
+	}
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return emptyString
+	}
+
+	return formatFloat(*value)
+}
+
+func formatEfficiency(value *float64) string {
+	if value == nil {
+		return emptyString
+	}
+
+	return formatFloat(*value*percentScale) + "%"
+}
+
+func writeScore(opts app.Options, periods []scorePeriod) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON && opts.Fields == emptyString {
+		err := output.WriteRawJSON(opts, periods)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	arrows := !opts.Plain && !opts.NDJSON && !opts.JSON
+	lines := make([]string, defaultInt, len(periods)+rowsHeaderCount)
+	lines = append(lines, scorePlainHead)
+
+	for index, period := range periods {
+		var previous *scorePeriod
+		if arrows && index > defaultInt {
+			previous = &periods[index-1]
+		}
+
+		cells := scoreCells(period, previous)
+		lines = append(lines, strings.Join(cells, "\t"))
+	}
+
+	if !arrows {
+		err := output.WritePlain(opts, lines)
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	lines[0] = scoreTableHead
+
+	table, err := output.RenderTable(opts, scorePlainHead, lines)
+	if err != nil {
+		return fmt.Errorf("render sleep score table: %w", err)
+	}
+
+	return output.WriteLine(opts, table)
+}
@@ -0,0 +1,136 @@
+//nolint:testpackage // test unexported helpers.
+package sleep
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestBuildScorePeriods averages nights per ISO week and skips naps.
+func TestBuildScorePeriods(t *testing.T) {
+	t.Parallel()
+
+	period, err := periodFunc(GroupByWeek)
+	if err != nil {
+		t.Fatalf("periodFunc: %v", err)
+	}
+
+	efficiency := 0.9
+	napSeries := scoreSeries("2024-01-08", 90, 0, nil, nil, nil)
+	napSeries.Duration = 1800
+	asleep, inBed := int64(27000), int64(30000)
+
+	periods := buildScorePeriods(body{
+		Timezone: "UTC",
+		Series: []series{
+			scoreSeries("2024-01-07", 60, 2, &efficiency, nil, nil),
+			scoreSeries("2024-01-08", 80, 1, nil, &asleep, &inBed),
+			scoreSeries("2024-01-09", 0, 3, nil, nil, nil),
+			scoreSeries("2024-01-01", 70, 0, &efficiency, nil, nil),
+			napSeries,
+		},
+		More:   false,
+		Offset: defaultInt,
+	}, period)
+
+	if len(periods) != 2 {
+		t.Fatalf("got %d periods want 2: %+v", len(periods), periods)
+	}
+
+	first, second := periods[0], periods[1]
+	if first.Period != "2024-01-01" || first.Nights != 2 ||
+		*first.Score != 65 || first.Wakeups != 1 {
+		t.Fatalf("first period %+v", first)
+	}
+
+	if second.Period != "2024-01-08" || second.Nights != 2 ||
+		*second.Score != 80 || second.Wakeups != 2 ||
+		*second.Efficiency != 0.9 || second.Duration != 28800 {
+		t.Fatalf("second period %+v", second)
+	}
+}
+
+// TestScoreCellsArrows marks each metric against the previous period.
+func TestScoreCellsArrows(t *testing.T) {
+	t.Parallel()
+
+	score, lower := 80.0, 70.0
+	previous := scorePeriod{
+		Period:     "2024-01",
+		Nights:     10,
+		Score:      &lower,
+		Duration:   3600,
+		Wakeups:    1,
+		Efficiency: nil,
+	}
+	current := previous
+	current.Period = "2024-02"
+	current.Score = &score
+	current.Wakeups = 0.5
+
+	cells := scoreCells(current, &previous)
+	want := []string{"2024-02", "10", "80.0 ↑", "1h00m →", "0.5 ↓", ""}
+
+	for index := range want {
+		if cells[index] != want[index] {
+			t.Fatalf("cells %q want %q", cells, want)
+		}
+	}
+}
+
+// TestApplyLast resolves --last and rejects explicit ranges.
+func TestApplyLast(t *testing.T) {
+	t.Parallel()
+
+	now := func() time.Time {
+		return time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	var opts ScoreOptions
+
+	opts.Last = "30d"
+
+	err := applyLast(&opts, now)
+	if err != nil || opts.TimeRange.Start != "2024-01-31T00:00:00Z" {
+		t.Fatalf("got %q, %v", opts.TimeRange.Start, err)
+	}
+
+	err = applyLast(&opts, now)
+	if !errors.Is(err, errLastConflict) {
+		t.Fatalf("got %v want %v", err, errLastConflict)
+	}
+
+	_, err = periodFunc("year")
+	if !errors.Is(err, errInvalidGroupBy) {
+		t.Fatalf("got %v want %v", err, errInvalidGroupBy)
+	}
+}
+
+func scoreSeries(
+	date string,
+	score int,
+	wakeups int,
+	efficiency *float64,
+	asleep *int64,
+	inBed *int64,
+) series {
+	return series{
+		Date:      date,
+		StartDate: defaultInt64,
+		EndDate:   defaultInt64,
+		Duration:  28800,
+		Score:     score,
+		Wakeups:   wakeups,
+		Model:     defaultInt,
+		Data: data{
+			Score:      defaultInt,
+			Wakeups:    defaultInt,
+			Breathing:  nil,
+			AHI:        nil,
+			Efficiency: efficiency,
+			TotalSleep: asleep,
+			TimeInBed:  inBed,
+		},
+	}
+}
@@ -2,11 +2,10 @@
 package sleep
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 	"net/url"
 	"strconv"
 	"strings"
@@ -33,6 +32,9 @@ const (
 	modelParam      = "model"
 	limitParam      = "limit"
 	offsetParam     = "offset"
+	dataFieldsParam = "data_fields"
+	dataFields      = "sleep_score,wakeupcount," +
+		"breathing_disturbances_intensity,apnea_hypopnea_index"
 	numberBase10    = 10
 	rowsHeaderCount = 1
 	tableMinWidth   = 0
@@ -40,22 +42,33 @@ const (
 	tablePadding    = 2
 	tablePadChar    = ' '
 	tableFlags      = 0
-	tableHeader     = "Start\tEnd\tDuration\tScore\tWakeups\tModel"
-	plainHeader     = "start\tend\tduration\tscore\twakeups\tmodel"
-	defaultInt      = 0
-	defaultInt64    = 0
-	emptyString     = ""
+	tableHeader     = "Start\tEnd\tDuration\tScore\tWakeups\tModel\t" +
+		"Kind\tBreathing\tAHI\tAHI Severity"
+	plainHeader = "start\tend\tduration\tscore\twakeups\tmodel\t" +
+		"kind\tbreathing\tahi\tahi_severity"
+	ahiMild        = 5
+	ahiModerate    = 15
+	ahiSevere      = 30
+	severityNormal = "normal"
+	severityMild   = "mild"
+	severityMod    = "moderate"
+	severitySevere = "severe"
+	defaultInt     = 0
+	defaultInt64   = 0
+	emptyString    = ""
 )
 
 // Options captures sleep query parameters.
 type Options struct {
-	TimeRange  params.TimeRange
-	Date       params.Date
-	Pagination params.Pagination
-	User       params.User
-	LastUpdate params.LastUpdate
-	Model      int
-	Now        func() time.Time
+	TimeRange   params.TimeRange
+	Date        params.Date
+	Pagination  params.Pagination
+	User        params.User
+	LastUpdate  params.LastUpdate
+	Model       int
+	IncludeNaps bool
+	NapsOnly    bool
+	Now         func() time.Time
 }
 
 // Run fetches sleep summaries and writes output.
@@ -65,11 +78,40 @@ func Run(
 	appOpts app.Options,
 	accessToken string,
 ) error {
-	values, err := buildParams(opts)
+	filter, err := resolveNapFilter(opts)
 	if err != nil {
 		return app.NewExitError(app.ExitCodeUsage, err)
 	}
 
+	payload, err := fetchSummary(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return writeResponse(appOpts, filter, payload)
+}
+
+func fetchSummary(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) ([]byte, error) {
+	values, err := buildParams(opts)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	return callAction(ctx, appOpts, accessToken, actionGet, values)
+}
+
+func callAction(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	action string,
+	values url.Values,
+) ([]byte, error) {
 	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
 	service := serviceForBase(baseURL)
 
@@ -77,26 +119,26 @@ func Run(
 		ctx,
 		baseURL,
 		service,
-		actionGet,
+		action,
 		accessToken,
 		values,
 	)
 	if err != nil {
-		return fmt.Errorf("build request: %w", err)
+		return nil, fmt.Errorf("build request: %w", err)
 	}
 
 	//nolint:bodyclose // ReadPayload closes the response body.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := withings.NewClient(appOpts).Do(req)
 	if err != nil {
-		return app.NewExitError(app.ExitCodeNetwork, err)
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
 	}
 
 	payload, err := withings.ReadPayload(resp)
 	if err != nil {
-		return fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
-	return writeResponse(appOpts, payload)
+	return payload, nil
 }
 
 func serviceForBase(baseURL string) string {
@@ -130,6 +172,7 @@ func buildParams(opts Options) (url.Values, error) {
 	applyUser(&values, opts.User)
 	applyPagination(&values, opts.Pagination)
 	applyModel(&values, opts.Model)
+	values.Set(dataFieldsParam, dataFields)
 
 	return values, nil
 }
@@ -229,24 +272,45 @@ type series struct {
 	Score     int    `json:"sleep_score"`
 	Wakeups   int    `json:"wakeupcount"`
 	Model     int    `json:"model"`
+	Data      data   `json:"data"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type data struct {
+	Score     int  `json:"sleep_score,omitempty"`
+	Wakeups   int  `json:"wakeupcount,omitempty"`
+	Breathing *int `json:"breathing_disturbances_intensity,omitempty"`
+	AHI       *int `json:"apnea_hypopnea_index,omitempty"`
+	// Efficiency, TotalSleep, and TimeInBed are requested by sleep score.
+	Efficiency *float64 `json:"sleep_efficiency,omitempty"`
+	TotalSleep *int64   `json:"total_sleep_time,omitempty"`
+	TimeInBed  *int64   `json:"total_timeinbed,omitempty"`
 }
 
 type row struct {
-	Start    string
-	End      string
-	Duration string
-	Score    string
-	Wakeups  string
-	Model    string
+	Start       string
+	End         string
+	Duration    string
+	Score       string
+	Wakeups     string
+	Model       string
+	Kind        string
+	Breathing   string
+	AHI         string
+	AHISeverity string
 }
 
-func writeResponse(opts app.Options, payload []byte) error {
+func writeResponse(
+	opts app.Options,
+	filter napFilter,
+	payload []byte,
+) error {
 	decoded, err := decodeResponse(payload)
 	if err != nil {
 		return err
 	}
 
-	return writeBody(opts, decoded.Body)
+	return writeBody(opts, filterNaps(decoded.Body, filter))
 }
 
 func writeBody(opts app.Options, body body) error {
@@ -254,17 +318,17 @@ func writeBody(opts app.Options, body body) error {
 		return nil
 	}
 
-	if opts.JSON {
+	if opts.JSON && opts.Fields == emptyString {
 		return writeJSONOutput(opts, body)
 	}
 
-	rows := buildRows(body)
+	rows := buildRows(body, output.DisplayLocation(opts, body.Timezone))
 
-	if opts.Plain {
-		return writePlainOutput(rows)
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		return writePlainOutput(opts, rows)
 	}
 
-	return writeTableOutput(rows)
+	return writeTableOutput(opts, rows)
 }
 
 func writeJSONOutput(opts app.Options, body body) error {
@@ -276,8 +340,8 @@ func writeJSONOutput(opts app.Options, body body) error {
 	return nil
 }
 
-func writePlainOutput(rows []row) error {
-	err := output.WriteLines(formatLines(rows))
+func writePlainOutput(opts app.Options, rows []row) error {
+	err := output.WritePlain(opts, formatLines(rows))
 	if err != nil {
 		return fmt.Errorf("write plain output: %w", err)
 	}
@@ -285,13 +349,13 @@ func writePlainOutput(rows []row) error {
 	return nil
 }
 
-func writeTableOutput(rows []row) error {
-	table, err := formatTable(rows)
+func writeTableOutput(opts app.Options, rows []row) error {
+	table, err := formatTable(opts, rows)
 	if err != nil {
 		return err
 	}
 
-	err = output.WriteLine(table)
+	err = output.WriteLine(opts, table)
 	if err != nil {
 		return fmt.Errorf("write table output: %w", err)
 	}
@@ -310,37 +374,39 @@ func decodeResponse(payload []byte) (response, error) {
 		)
 	}
 
-	if decoded.Status != withings.StatusOK {
-		message := decoded.Error
-		if message == emptyString {
-			message = decoded.Detail
-		}
-
-		if message == emptyString {
-			message = strings.TrimSpace(string(payload))
-		}
-
-		return response{}, app.NewExitError(
-			app.ExitCodeAPI,
-			fmt.Errorf("%w: %d: %s", withings.ErrAPI, decoded.Status, message),
-		)
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return response{}, err
 	}
 
 	return decoded, nil
 }
 
-func buildRows(body body) []row {
+// buildRows renders times in display; naps are still classified in the
+// API timezone.
+func buildRows(body body, display *time.Location) []row {
 	location := sleepLocation(body.Timezone)
 	rows := make([]row, defaultInt, len(body.Series))
 
 	for _, series := range body.Series {
 		rows = append(rows, row{
-			Start:    formatStart(series, location),
-			End:      formatEnd(series, location),
+			Start:    formatStart(series, display),
+			End:      formatEnd(series, display),
 			Duration: formatInt64(series.Duration),
-			Score:    formatInt(series.Score),
-			Wakeups:  formatInt(series.Wakeups),
+			Score:    formatInt(seriesScore(series)),
+			Wakeups:  formatInt(seriesWakeups(series)),
 			Model:    formatInt(series.Model),
+			Kind:     seriesKind(series, location),
+			Breathing: formatOptionalInt(
+				series.Data.Breathing,
+			),
+			AHI:         formatOptionalInt(series.Data.AHI),
+			AHISeverity: ahiSeverity(series.Data.AHI),
 		})
 	}
 
@@ -384,6 +450,53 @@ func formatTime(epoch int64, location *time.Location) string {
 	return time.Unix(epoch, defaultInt64).In(location).Format(time.RFC3339)
 }
 
+func seriesScore(series series) int {
+	if series.Data.Score != defaultInt {
+		return series.Data.Score
+	}
+
+	return series.Score
+}
+
+func seriesWakeups(series series) int {
+	if series.Data.Wakeups != defaultInt {
+		return series.Data.Wakeups
+	}
+
+	return series.Wakeups
+}
+
+// ahiSeverity labels an apnea-hypopnea index using the clinical cut-offs.
+func ahiSeverity(ahi *int) string {
+	if ahi == nil {
+		return emptyString
+	}
+
+	return ahiLevel(float64(*ahi))
+}
+
+// ahiLevel labels an apnea-hypopnea index, also an average over nights.
+func ahiLevel(ahi float64) string {
+	switch {
+	case ahi < ahiMild:
+		return severityNormal
+	case ahi < ahiModerate:
+		return severityMild
+	case ahi < ahiSevere:
+		return severityMod
+	default:
+		return severitySevere
+	}
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return emptyString
+	}
+
+	return strconv.Itoa(*value)
+}
+
 func formatInt(value int) string {
 	return strconv.Itoa(value)
 }
@@ -392,38 +505,31 @@ func formatInt64(value int64) string {
 	return strconv.FormatInt(value, numberBase10)
 }
 
-func formatTable(rows []row) (string, error) {
-	var buffer bytes.Buffer
-
-	writer := tabwriter.NewWriter(
-		&buffer,
+func newTableWriter(target io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(
+		target,
 		tableMinWidth,
 		tableTabWidth,
 		tablePadding,
 		tablePadChar,
 		tableFlags,
 	)
-	_, _ = fmt.Fprintln(writer, tableHeader)
+}
+
+func formatTable(opts app.Options, rows []row) (string, error) {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, tableHeader)
 
 	for _, row := range rows {
-		_, _ = fmt.Fprintf(
-			writer,
-			"%s\t%s\t%s\t%s\t%s\t%s\n",
-			row.Start,
-			row.End,
-			row.Duration,
-			row.Score,
-			row.Wakeups,
-			row.Model,
-		)
+		lines = append(lines, formatRow(row))
 	}
 
-	err := writer.Flush()
+	table, err := output.RenderTable(opts, plainHeader, lines)
 	if err != nil {
 		return emptyString, fmt.Errorf("render sleep table: %w", err)
 	}
 
-	return strings.TrimRight(buffer.String(), "\n"), nil
+	return table, nil
 }
 
 func formatLines(rows []row) []string {
@@ -431,15 +537,23 @@ func formatLines(rows []row) []string {
 	lines = append(lines, plainHeader)
 
 	for _, row := range rows {
-		lines = append(lines, strings.Join([]string{
-			row.Start,
-			row.End,
-			row.Duration,
-			row.Score,
-			row.Wakeups,
-			row.Model,
-		}, "\t"))
+		lines = append(lines, formatRow(row))
 	}
 
 	return lines
 }
+
+func formatRow(row row) string {
+	return strings.Join([]string{
+		row.Start,
+		row.End,
+		row.Duration,
+		row.Score,
+		row.Wakeups,
+		row.Model,
+		row.Kind,
+		row.Breathing,
+		row.AHI,
+		row.AHISeverity,
+	}, "\t")
+}
@@ -37,6 +37,10 @@ const (
 	sleepTestEmpty      = ""
 	sleepTestDefaultInt = 0
 	sleepTestBase10     = 10
+	sleepTestBreathing  = 22
+	sleepTestAHI        = 12
+	sleepTestScore      = 81
+	sleepTestRowCount   = 1
 )
 
 // TestSleepServiceForBase handles base URLs with and without /v2.
@@ -70,10 +74,12 @@ func TestBuildParamsDate(t *testing.T) {
 			Limit:  sleepTestLimit,
 			Offset: sleepTestOffset,
 		},
-		User:       params.User{UserID: sleepTestUserID},
-		LastUpdate: params.LastUpdate{LastUpdate: sleepTestDefaultInt},
-		Model:      sleepTestModel,
-		Now:        nil,
+		User:        params.User{UserID: sleepTestUserID},
+		LastUpdate:  params.LastUpdate{LastUpdate: sleepTestDefaultInt},
+		Model:       sleepTestModel,
+		IncludeNaps: false,
+		NapsOnly:    false,
+		Now:         nil,
 	}
 
 	values, err := buildParams(opts)
@@ -97,6 +103,7 @@ func TestBuildParamsDate(t *testing.T) {
 	assertParam(t, values.Get(offsetParam), "5", "offset")
 	assertParam(t, values.Get(userIDParam), sleepTestUserID, "userid")
 	assertParam(t, values.Get(modelParam), "2", "model")
+	assertParam(t, values.Get(dataFieldsParam), dataFields, "data_fields")
 }
 
 // TestBuildParamsTimeRange converts epoch range to dates.
@@ -134,10 +141,12 @@ func TestBuildParamsTimeRange(t *testing.T) {
 			Limit:  sleepTestDefaultInt,
 			Offset: sleepTestDefaultInt,
 		},
-		User:       params.User{UserID: sleepTestEmpty},
-		LastUpdate: params.LastUpdate{LastUpdate: sleepTestDefaultInt},
-		Model:      sleepTestDefaultInt,
-		Now:        nil,
+		User:        params.User{UserID: sleepTestEmpty},
+		LastUpdate:  params.LastUpdate{LastUpdate: sleepTestDefaultInt},
+		Model:       sleepTestDefaultInt,
+		IncludeNaps: false,
+		NapsOnly:    false,
+		Now:         nil,
 	}
 
 	values, err := buildParams(opts)
@@ -181,10 +190,12 @@ func TestBuildParamsDefaultEnd(t *testing.T) {
 			Limit:  sleepTestDefaultInt,
 			Offset: sleepTestDefaultInt,
 		},
-		User:       params.User{UserID: sleepTestEmpty},
-		LastUpdate: params.LastUpdate{LastUpdate: sleepTestDefaultInt},
-		Model:      sleepTestDefaultInt,
-		Now:        func() time.Time { return fixedNow },
+		User:        params.User{UserID: sleepTestEmpty},
+		LastUpdate:  params.LastUpdate{LastUpdate: sleepTestDefaultInt},
+		Model:       sleepTestDefaultInt,
+		IncludeNaps: false,
+		NapsOnly:    false,
+		Now:         func() time.Time { return fixedNow },
 	}
 
 	values, err := buildParams(opts)
@@ -216,10 +227,12 @@ func TestBuildParamsLastUpdateConflict(t *testing.T) {
 			Limit:  sleepTestDefaultInt,
 			Offset: sleepTestDefaultInt,
 		},
-		User:       params.User{UserID: sleepTestEmpty},
-		LastUpdate: params.LastUpdate{LastUpdate: sleepTestLastUpdate},
-		Model:      sleepTestDefaultInt,
-		Now:        nil,
+		User:        params.User{UserID: sleepTestEmpty},
+		LastUpdate:  params.LastUpdate{LastUpdate: sleepTestLastUpdate},
+		Model:       sleepTestDefaultInt,
+		IncludeNaps: false,
+		NapsOnly:    false,
+		Now:         nil,
 	}
 
 	_, err := buildParams(opts)
@@ -246,10 +259,12 @@ func TestBuildParamsDateConflict(t *testing.T) {
 			Limit:  sleepTestDefaultInt,
 			Offset: sleepTestDefaultInt,
 		},
-		User:       params.User{UserID: sleepTestEmpty},
-		LastUpdate: params.LastUpdate{LastUpdate: sleepTestDefaultInt},
-		Model:      sleepTestDefaultInt,
-		Now:        nil,
+		User:        params.User{UserID: sleepTestEmpty},
+		LastUpdate:  params.LastUpdate{LastUpdate: sleepTestDefaultInt},
+		Model:       sleepTestDefaultInt,
+		IncludeNaps: false,
+		NapsOnly:    false,
+		Now:         nil,
 	}
 
 	_, err := buildParams(opts)
@@ -276,10 +291,12 @@ func TestBuildParamsInvalidDate(t *testing.T) {
 			Limit:  sleepTestDefaultInt,
 			Offset: sleepTestDefaultInt,
 		},
-		User:       params.User{UserID: sleepTestEmpty},
-		LastUpdate: params.LastUpdate{LastUpdate: sleepTestDefaultInt},
-		Model:      sleepTestDefaultInt,
-		Now:        nil,
+		User:        params.User{UserID: sleepTestEmpty},
+		LastUpdate:  params.LastUpdate{LastUpdate: sleepTestDefaultInt},
+		Model:       sleepTestDefaultInt,
+		IncludeNaps: false,
+		NapsOnly:    false,
+		Now:         nil,
 	}
 
 	_, err := buildParams(opts)
@@ -292,6 +309,71 @@ func TestBuildParamsInvalidDate(t *testing.T) {
 	}
 }
 
+// TestAHISeverity labels AHI values with clinical cut-offs.
+func TestAHISeverity(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		ahi  int
+		want string
+	}{
+		{ahi: 0, want: severityNormal},
+		{ahi: 4, want: severityNormal},
+		{ahi: 5, want: severityMild},
+		{ahi: 15, want: severityMod},
+		{ahi: 29, want: severityMod},
+		{ahi: 30, want: severitySevere},
+	}
+
+	for _, test := range cases {
+		if got := ahiSeverity(&test.ahi); got != test.want {
+			t.Fatalf("ahi %d got %q want %q", test.ahi, got, test.want)
+		}
+	}
+
+	if got := ahiSeverity(nil); got != sleepTestEmpty {
+		t.Fatalf("nil ahi got %q want empty", got)
+	}
+}
+
+// TestBuildRowsBreathing reads breathing fields from the data object.
+func TestBuildRowsBreathing(t *testing.T) {
+	t.Parallel()
+
+	breathing := sleepTestBreathing
+	ahi := sleepTestAHI
+
+	rows := buildRows(body{
+		Timezone: "UTC",
+		Series: []series{{
+			Date:      sleepTestDate,
+			StartDate: sleepTestDefaultInt,
+			EndDate:   sleepTestDefaultInt,
+			Duration:  sleepTestDefaultInt,
+			Score:     sleepTestDefaultInt,
+			Wakeups:   sleepTestDefaultInt,
+			Model:     sleepTestModel,
+			Data: data{
+				Score:     sleepTestScore,
+				Wakeups:   sleepTestDefaultInt,
+				Breathing: &breathing,
+				AHI:       &ahi,
+			},
+		}},
+		More:   false,
+		Offset: sleepTestDefaultInt,
+	}, time.UTC)
+
+	if len(rows) != sleepTestRowCount {
+		t.Fatalf("rows got %d want %d", len(rows), sleepTestRowCount)
+	}
+
+	assertParam(t, rows[0].Score, "81", "score")
+	assertParam(t, rows[0].Breathing, "22", "breathing")
+	assertParam(t, rows[0].AHI, "12", "ahi")
+	assertParam(t, rows[0].AHISeverity, severityMild, "ahi_severity")
+}
+
 func assertParam(t *testing.T, got, want, label string) {
 	t.Helper()
 
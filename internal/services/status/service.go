@@ -0,0 +1,580 @@
+// Package status builds a snapshot of the latest weight, sleep score, and
+// step count, compact enough for tmux or waybar status segments.
+package status
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/units"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	// StyleTable writes one row per segment.
+	StyleTable = "table"
+	// StyleOneline writes all segments on a single line.
+	StyleOneline = "oneline"
+	// SegmentWeight is the latest weight and its change.
+	SegmentWeight = "weight"
+	// SegmentSleep is the latest sleep score.
+	SegmentSleep = "sleep"
+	// SegmentSteps is today's step count.
+	SegmentSteps = "steps"
+	// DefaultSegments lists the segments shown when none are selected.
+	DefaultSegments = SegmentWeight + "," + SegmentSleep + "," + SegmentSteps
+
+	serviceMeasure   = "measure"
+	serviceMeasureV2 = "v2/measure"
+	serviceSleep     = "v2/sleep"
+	actionGetMeas    = "getmeas"
+	actionActivity   = "getactivity"
+	actionSummary    = "getsummary"
+	weightType       = "1"
+	realCategory     = "1"
+	sleepScoreField  = "sleep_score"
+	stepsField       = "steps"
+	dateLayout       = "2006-01-02"
+	lookbackDays     = 30
+	segmentSeparator = ","
+	onelineSeparator = " | "
+	missingValue     = "-"
+	thousandsGroup   = 3
+	valueDigits      = 1
+	floatBitSize     = 64
+	tableHeader      = "Segment\tValue\tUnit\tChange\tDate"
+	plainHeader      = "segment\tvalue\tunit\tchange\tdate"
+	emptyString      = ""
+)
+
+var (
+	// ErrUnknownSegment indicates a --segments entry that is not supported.
+	ErrUnknownSegment = errors.New("unknown segment")
+	// ErrNoSegments indicates that the API server supports none of the
+	// selected segments.
+	ErrNoSegments = errors.New("no selected segment is supported by")
+)
+
+// Options configures the status snapshot.
+type Options struct {
+	Style    string
+	Segments []string
+	ASCII    bool
+	Now      func() time.Time
+}
+
+// Segment is one value of the snapshot. Value is nil when the account has
+// no recent data for it.
+type Segment struct {
+	Name   string   `json:"name"`
+	Value  *float64 `json:"value"`
+	Unit   string   `json:"unit,omitempty"`
+	Change *float64 `json:"change,omitempty"`
+	Date   string   `json:"date,omitempty"`
+}
+
+type glyphs struct {
+	Label string
+	ASCII string
+}
+
+//nolint:gochecknoglobals // Static segment labels.
+var labels = map[string]glyphs{
+	SegmentWeight: {Label: "⚖", ASCII: "weight"},
+	SegmentSleep:  {Label: "😴", ASCII: "sleep"},
+	SegmentSteps:  {Label: "👣", ASCII: "steps"},
+}
+
+type fetcher func(
+	ctx context.Context,
+	request withings.Caller,
+	today time.Time,
+) (Segment, error)
+
+type endpoint struct {
+	Service string
+	Action  string
+}
+
+// endpoints names the API action behind each segment, so segments a mock
+// --base-url does not implement can be skipped.
+//
+//nolint:gochecknoglobals // Static segment table.
+var endpoints = map[string]endpoint{
+	SegmentWeight: {Service: serviceMeasure, Action: actionGetMeas},
+	SegmentSleep:  {Service: serviceSleep, Action: actionSummary},
+	SegmentSteps:  {Service: serviceMeasureV2, Action: actionActivity},
+}
+
+//nolint:gochecknoglobals // Static segment table.
+var fetchers = map[string]fetcher{
+	SegmentWeight: fetchWeight,
+	SegmentSleep:  fetchSleep,
+	SegmentSteps:  fetchSteps,
+}
+
+// ParseSegments splits a comma-separated segment list, keeping its order.
+func ParseSegments(value string) ([]string, error) {
+	segments := make([]string, 0, len(fetchers))
+
+	for name := range strings.SplitSeq(value, segmentSeparator) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == emptyString || slices.Contains(segments, name) {
+			continue
+		}
+
+		if _, ok := fetchers[name]; !ok {
+			return nil, fmt.Errorf(
+				"%w %q (supported: %s)",
+				ErrUnknownSegment,
+				name,
+				DefaultSegments,
+			)
+		}
+
+		segments = append(segments, name)
+	}
+
+	return segments, nil
+}
+
+// ValidStyle reports whether style names a supported --style.
+func ValidStyle(style string) bool {
+	return style == StyleTable || style == StyleOneline
+}
+
+// Run fetches the selected segments and writes output.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	segments, err := Collect(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(opts, appOpts, segments)
+}
+
+// Collect fetches the selected segments in order. Against a --base-url
+// server that does not implement a segment's action, the segment is
+// skipped with a warning instead of failing the snapshot.
+func Collect(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) ([]Segment, error) {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	today := now().In(output.DisplayLocation(appOpts, output.TimezoneLocal))
+	request := withings.Caller{Options: appOpts, AccessToken: accessToken}
+	segments := make([]Segment, 0, len(opts.Segments))
+	caps := withings.ProbeCapabilities(ctx, appOpts)
+
+	for _, name := range opts.Segments {
+		target := endpoints[name]
+		if !caps.Supports(target.Service, target.Action) {
+			skipSegment(appOpts, name, target, withings.ErrUnsupported)
+
+			continue
+		}
+
+		segment, err := fetchers[name](ctx, request, today)
+		if withings.Degradable(appOpts, err) {
+			skipSegment(appOpts, name, target, err)
+
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", name, err)
+		}
+
+		segments = append(segments, segment)
+	}
+
+	if len(segments) == 0 && len(opts.Segments) > 0 {
+		return nil, app.NewExitError(
+			app.ExitCodeAPI,
+			fmt.Errorf("%w %s", ErrNoSegments, appOpts.BaseURL),
+		)
+	}
+
+	return segments, nil
+}
+
+func skipSegment(
+	appOpts app.Options,
+	name string,
+	target endpoint,
+	reason error,
+) {
+	_ = output.WriteWarning(appOpts, fmt.Sprintf(
+		"warning: skipping %s: %s %s: %v",
+		name,
+		target.Service,
+		target.Action,
+		reason,
+	))
+}
+
+func writeOutput(
+	opts Options,
+	appOpts app.Options,
+	segments []Segment,
+) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON && appOpts.Fields == emptyString {
+		return output.WriteRawJSON(appOpts, segments)
+	}
+
+	if opts.Style == StyleOneline {
+		redacted, err := output.RedactValue(appOpts, segments)
+		if err != nil {
+			return err
+		}
+
+		return output.WriteLine(appOpts, FormatLine(redacted, opts.ASCII))
+	}
+
+	lines := plainLines(segments)
+
+	if appOpts.Plain || appOpts.NDJSON || appOpts.JSON {
+		return output.WritePlain(appOpts, lines)
+	}
+
+	lines[0] = tableHeader
+
+	table, err := output.RenderTable(appOpts, plainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render status table: %w", err)
+	}
+
+	return output.WriteLine(appOpts, table)
+}
+
+// FormatLine joins segments into a single status line such as
+// "⚖ 81.4kg ↓0.3 | 😴 78 | 👣 9,412". With ascii the glyphs are replaced
+// by segment names and the arrows by signs.
+func FormatLine(segments []Segment, ascii bool) string {
+	parts := make([]string, 0, len(segments))
+
+	for _, segment := range segments {
+		label := labels[segment.Name].Label
+		if ascii {
+			label = labels[segment.Name].ASCII
+		}
+
+		parts = append(parts, label+" "+formatSegment(segment, ascii))
+	}
+
+	return strings.Join(parts, onelineSeparator)
+}
+
+func formatSegment(segment Segment, ascii bool) string {
+	if segment.Value == nil {
+		return missingValue
+	}
+
+	if segment.Name == SegmentSteps {
+		return groupThousands(int64(math.Round(*segment.Value)))
+	}
+
+	text := formatNumber(*segment.Value) + segment.Unit
+	if segment.Change == nil || *segment.Change == 0 {
+		return text
+	}
+
+	return text + " " + formatChange(*segment.Change, ascii)
+}
+
+func formatChange(change float64, ascii bool) string {
+	magnitude := formatNumber(math.Abs(change))
+
+	switch {
+	case ascii && change > 0:
+		return "+" + magnitude
+	case ascii:
+		return "-" + magnitude
+	case change > 0:
+		return "↑" + magnitude
+	default:
+		return "↓" + magnitude
+	}
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, floatBitSize)
+}
+
+func groupThousands(value int64) string {
+	digits := strconv.FormatInt(value, 10)
+
+	sign := emptyString
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	var builder strings.Builder
+
+	for index, digit := range digits {
+		if index > 0 && (len(digits)-index)%thousandsGroup == 0 {
+			builder.WriteByte(',')
+		}
+
+		builder.WriteRune(digit)
+	}
+
+	return sign + builder.String()
+}
+
+func plainLines(segments []Segment) []string {
+	lines := make([]string, 0, len(segments)+1)
+	lines = append(lines, plainHeader)
+
+	for _, segment := range segments {
+		value := emptyString
+		if segment.Value != nil {
+			value = formatNumber(*segment.Value)
+		}
+
+		change := emptyString
+		if segment.Change != nil {
+			change = formatNumber(*segment.Change)
+		}
+
+		lines = append(lines, strings.Join([]string{
+			segment.Name,
+			value,
+			segment.Unit,
+			change,
+			segment.Date,
+		}, "\t"))
+	}
+
+	return lines
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type measureBody struct {
+	MeasureGroups []measureGroup `json:"measuregrps"`
+}
+
+type measureGroup struct {
+	Date     int64     `json:"date"`
+	Measures []measure `json:"measures"`
+}
+
+type measure struct {
+	Value int64 `json:"value"`
+	Type  int   `json:"type"`
+	Unit  int   `json:"unit"`
+}
+
+type sleepBody struct {
+	Series []sleepSeries `json:"series"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type sleepSeries struct {
+	Date    string `json:"date"`
+	EndDate int64  `json:"enddate"`
+	Data    struct {
+		Score *float64 `json:"sleep_score"`
+	} `json:"data"`
+}
+
+type activityBody struct {
+	Activities []struct {
+		Date  string   `json:"date"`
+		Steps *float64 `json:"steps"`
+	} `json:"activities"`
+}
+
+// fetchWeight reads the last lookbackDays of weigh-ins and reports the
+// newest one with its change from the one before.
+func fetchWeight(
+	ctx context.Context,
+	request withings.Caller,
+	today time.Time,
+) (Segment, error) {
+	system := request.Options.Units
+	segment := Segment{
+		Name:   SegmentWeight,
+		Value:  nil,
+		Unit:   units.Label(system, units.Mass),
+		Change: nil,
+		Date:   emptyString,
+	}
+
+	values := url.Values{}
+	values.Set("meastype", weightType)
+	values.Set("category", realCategory)
+	values.Set(
+		"startdate",
+		strconv.FormatInt(today.AddDate(0, 0, -lookbackDays).Unix(), 10),
+	)
+
+	var body measureBody
+
+	err := request.Call(ctx, serviceMeasure, actionGetMeas, values, &body)
+	if err != nil {
+		return segment, err
+	}
+
+	weights := make([]measureGroup, 0, len(body.MeasureGroups))
+
+	for _, group := range body.MeasureGroups {
+		if _, ok := group.weight(); ok {
+			weights = append(weights, group)
+		}
+	}
+
+	if len(weights) == 0 {
+		return segment, nil
+	}
+
+	slices.SortStableFunc(weights, func(a, b measureGroup) int {
+		return cmp.Compare(b.Date, a.Date)
+	})
+
+	latest, _ := weights[0].weight()
+	latest = displayWeight(system, latest)
+	segment.Value = &latest
+	segment.Date = time.Unix(weights[0].Date, 0).
+		In(today.Location()).
+		Format(dateLayout)
+
+	if len(weights) > 1 {
+		previous, _ := weights[1].weight()
+		change := roundValue(latest - displayWeight(system, previous))
+		segment.Change = &change
+	}
+
+	return segment, nil
+}
+
+func (g measureGroup) weight() (float64, bool) {
+	for _, item := range g.Measures {
+		if strconv.Itoa(item.Type) == weightType {
+			return float64(item.Value) * math.Pow10(item.Unit), true
+		}
+	}
+
+	return 0, false
+}
+
+func displayWeight(system string, kg float64) float64 {
+	return roundValue(units.Convert(system, units.Mass, kg))
+}
+
+func roundValue(value float64) float64 {
+	scale := math.Pow10(valueDigits)
+
+	return math.Round(value*scale) / scale
+}
+
+// fetchSleep reports the score of the most recent night in the last
+// lookbackDays.
+func fetchSleep(
+	ctx context.Context,
+	request withings.Caller,
+	today time.Time,
+) (Segment, error) {
+	segment := Segment{
+		Name:   SegmentSleep,
+		Value:  nil,
+		Unit:   emptyString,
+		Change: nil,
+		Date:   emptyString,
+	}
+
+	values := url.Values{}
+	values.Set(
+		"startdateymd",
+		today.AddDate(0, 0, -lookbackDays).Format(dateLayout),
+	)
+	values.Set("enddateymd", today.Format(dateLayout))
+	values.Set("data_fields", sleepScoreField)
+
+	var body sleepBody
+
+	err := request.Call(ctx, serviceSleep, actionSummary, values, &body)
+	if err != nil {
+		return segment, err
+	}
+
+	var latest *sleepSeries
+
+	for index := range body.Series {
+		night := &body.Series[index]
+		if night.Data.Score == nil {
+			continue
+		}
+
+		if latest == nil || night.EndDate > latest.EndDate {
+			latest = night
+		}
+	}
+
+	if latest != nil {
+		segment.Value = latest.Data.Score
+		segment.Date = latest.Date
+	}
+
+	return segment, nil
+}
+
+// fetchSteps reports the step count of the current day.
+func fetchSteps(
+	ctx context.Context,
+	request withings.Caller,
+	today time.Time,
+) (Segment, error) {
+	segment := Segment{
+		Name:   SegmentSteps,
+		Value:  nil,
+		Unit:   emptyString,
+		Change: nil,
+		Date:   today.Format(dateLayout),
+	}
+
+	values := url.Values{}
+	values.Set("startdateymd", segment.Date)
+	values.Set("enddateymd", segment.Date)
+	values.Set("data_fields", stepsField)
+
+	var body activityBody
+
+	err := request.Call(ctx, serviceMeasureV2, actionActivity, values, &body)
+	if err != nil {
+		return segment, err
+	}
+
+	for _, day := range body.Activities {
+		if day.Date == segment.Date && day.Steps != nil {
+			segment.Value = day.Steps
+		}
+	}
+
+	return segment, nil
+}
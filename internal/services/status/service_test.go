@@ -0,0 +1,191 @@
+//nolint:testpackage // test unexported helpers.
+package status
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/units"
+)
+
+const (
+	testMeasures = `{"status":0,"body":{"measuregrps":[` +
+		`{"date":1760500000,"measures":[{"value":818,"type":1,"unit":-1}]},` +
+		`{"date":1760600000,"measures":[{"value":81400,"type":1,"unit":-3}]}` +
+		`]}}`
+	testSleep = `{"status":0,"body":{"series":[` +
+		`{"date":"2025-10-15","enddate":1760500000,` +
+		`"data":{"sleep_score":71}},` +
+		`{"date":"2025-10-16","enddate":1760590000,` +
+		`"data":{"sleep_score":78}}]}}`
+	testActivity = `{"status":0,"body":{"activities":[` +
+		`{"date":"2025-10-16","steps":9412}]}}`
+	testNow = 1760620000
+)
+
+// TestParseSegments keeps order, drops duplicates, and rejects unknowns.
+func TestParseSegments(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseSegments(" steps,Weight,steps ")
+	if err != nil {
+		t.Fatalf("ParseSegments: %v", err)
+	}
+
+	if !slices.Equal(got, []string{SegmentSteps, SegmentWeight}) {
+		t.Fatalf("got %v", got)
+	}
+
+	_, err = ParseSegments("weight,mood")
+	if !errors.Is(err, ErrUnknownSegment) {
+		t.Fatalf("got %v want ErrUnknownSegment", err)
+	}
+}
+
+// TestFormatLine renders glyph and ASCII status lines.
+func TestFormatLine(t *testing.T) {
+	t.Parallel()
+
+	weight, change, score, steps := 81.4, -0.3, 78.0, 9412.0
+	segments := []Segment{
+		{
+			Name:   SegmentWeight,
+			Value:  &weight,
+			Unit:   "kg",
+			Change: &change,
+			Date:   "",
+		},
+		{Name: SegmentSleep, Value: &score, Unit: "", Change: nil, Date: ""},
+		{Name: SegmentSteps, Value: &steps, Unit: "", Change: nil, Date: ""},
+	}
+
+	got := FormatLine(segments, false)
+	if got != "⚖ 81.4kg ↓0.3 | 😴 78 | 👣 9,412" {
+		t.Fatalf("got %q", got)
+	}
+
+	segments[1].Value = nil
+
+	got = FormatLine(segments, true)
+	if got != "weight 81.4kg -0.3 | sleep - | steps 9,412" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+// TestGroupThousands inserts separators every three digits.
+func TestGroupThousands(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int64]string{
+		0:        "0",
+		999:      "999",
+		1000:     "1,000",
+		1234567:  "1,234,567",
+		-12345:   "-12,345",
+		10000000: "10,000,000",
+	}
+
+	for value, want := range cases {
+		if got := groupThousands(value); got != want {
+			t.Fatalf("%d: got %q want %q", value, got, want)
+		}
+	}
+}
+
+// TestCollect reads each segment from its endpoint.
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testStatusHandler))
+	defer server.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = server.URL
+	appOpts.Timezone = "utc"
+	appOpts.Units = units.Metric
+	opts.Segments = []string{SegmentWeight, SegmentSleep, SegmentSteps}
+	opts.Now = func() time.Time { return time.Unix(testNow, 0) }
+
+	segments, err := Collect(t.Context(), opts, appOpts, "token")
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	got := FormatLine(segments, true)
+	if got != "weight 81.4kg -0.4 | sleep 78 | steps 9,412" {
+		t.Fatalf("got %q", got)
+	}
+
+	if segments[0].Date != "2025-10-16" {
+		t.Fatalf("weight date: got %q", segments[0].Date)
+	}
+}
+
+// TestCollectSkipsUnsupported drops segments a mock server does not
+// implement, whether it says so up front or only by failing.
+func TestCollectSkipsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			switch request.URL.Path {
+			case "/capabilities":
+				_, _ = writer.Write([]byte(
+					`{"actions":["measure getmeas","v2/measure getactivity"]}`,
+				))
+			case "/v2/measure":
+				http.NotFound(writer, request)
+			default:
+				testStatusHandler(writer, request)
+			}
+		},
+	))
+	defer server.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = server.URL
+	appOpts.Quiet = true
+	appOpts.Units = units.Metric
+	opts.Segments = []string{SegmentWeight, SegmentSleep, SegmentSteps}
+	opts.Now = func() time.Time { return time.Unix(testNow, 0) }
+
+	segments, err := Collect(t.Context(), opts, appOpts, "token")
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if len(segments) != 1 || segments[0].Name != SegmentWeight {
+		t.Fatalf("got %+v, want only weight", segments)
+	}
+
+	opts.Segments = []string{SegmentSleep}
+
+	_, err = Collect(t.Context(), opts, appOpts, "token")
+	if !errors.Is(err, ErrNoSegments) {
+		t.Fatalf("got %v, want %v", err, ErrNoSegments)
+	}
+}
+
+func testStatusHandler(writer http.ResponseWriter, request *http.Request) {
+	bodies := map[string]string{
+		actionGetMeas:  testMeasures,
+		actionSummary:  testSleep,
+		actionActivity: testActivity,
+	}
+
+	_ = request.ParseForm()
+	_, _ = writer.Write([]byte(bodies[request.FormValue("action")]))
+}
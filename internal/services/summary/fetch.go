@@ -0,0 +1,267 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/services/sleep"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	serviceMeasure   = "measure"
+	serviceMeasureV2 = "v2/measure"
+	serviceSleep     = "v2/sleep"
+	actionGetMeas    = "getmeas"
+	actionActivity   = "getactivity"
+	actionSummary    = "getsummary"
+	weightType       = 1
+	realCategory     = "1"
+	sleepDataFields  = "sleep_score,total_sleep_time"
+	numberBase10     = 10
+)
+
+// data holds the records of both periods; each section fills its own
+// field, so the fetches need no locking.
+type data struct {
+	Days    []activityDay
+	Nights  []night
+	Weights []weighIn
+}
+
+type activityDay struct {
+	Date     string  `json:"date"`
+	Steps    float64 `json:"steps"`
+	Distance float64 `json:"distance"`
+	Calories float64 `json:"calories"`
+	Moderate float64 `json:"moderate"`
+	Intense  float64 `json:"intense"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type night struct {
+	Date      string `json:"date"`
+	StartDate int64  `json:"startdate"`
+	EndDate   int64  `json:"enddate"`
+	Data      struct {
+		Score     *float64 `json:"sleep_score"`
+		TotalTime *float64 `json:"total_sleep_time"`
+	} `json:"data"`
+}
+
+type weighIn struct {
+	Time time.Time
+	Kg   float64
+}
+
+type section struct {
+	Name    string
+	Service string
+	Action  string
+	fetch   sectionFetch
+}
+
+type sectionFetch func(
+	ctx context.Context,
+	request withings.Caller,
+	rng span,
+	out *data,
+) error
+
+//nolint:gochecknoglobals // Static section table.
+var sections = []section{
+	{
+		Name:    "activity",
+		Service: serviceMeasureV2,
+		Action:  actionActivity,
+		fetch:   fetchActivity,
+	},
+	{
+		Name:    "sleep",
+		Service: serviceSleep,
+		Action:  actionSummary,
+		fetch:   fetchSleep,
+	},
+	{
+		Name:    "weight",
+		Service: serviceMeasure,
+		Action:  actionGetMeas,
+		fetch:   fetchWeight,
+	},
+}
+
+// fetchAll runs one request per section concurrently. Against a
+// --base-url server that does not implement a section, it is skipped with
+// a warning and its metrics stay empty.
+func fetchAll(ctx context.Context, request withings.Caller, rng span) (data, error) {
+	appOpts := request.Options
+	caps := withings.ProbeCapabilities(ctx, appOpts)
+
+	var (
+		out  data
+		wait sync.WaitGroup
+	)
+
+	errs := make([]error, len(sections))
+	supported := make([]bool, len(sections))
+
+	for index, target := range sections {
+		supported[index] = caps.Supports(target.Service, target.Action)
+		if !supported[index] {
+			skipSection(appOpts, target, withings.ErrUnsupported)
+
+			continue
+		}
+
+		wait.Go(func() {
+			errs[index] = target.fetch(ctx, request, rng, &out)
+		})
+	}
+
+	wait.Wait()
+
+	fetched := 0
+
+	for index, target := range sections {
+		err := errs[index]
+
+		switch {
+		case !supported[index]:
+		case withings.Degradable(appOpts, err):
+			skipSection(appOpts, target, err)
+		case err != nil:
+			return data{}, fmt.Errorf("fetch %s: %w", target.Name, err)
+		default:
+			fetched++
+		}
+	}
+
+	if fetched == 0 {
+		return data{}, app.NewExitError(
+			app.ExitCodeAPI,
+			fmt.Errorf("%w %s", ErrNoSections, appOpts.BaseURL),
+		)
+	}
+
+	return out, nil
+}
+
+func skipSection(appOpts app.Options, target section, reason error) {
+	_ = output.WriteWarning(appOpts, fmt.Sprintf(
+		"warning: skipping %s: %s %s: %v",
+		target.Name,
+		target.Service,
+		target.Action,
+		reason,
+	))
+}
+
+func fetchActivity(
+	ctx context.Context,
+	request withings.Caller,
+	rng span,
+	out *data,
+) error {
+	window := rng.window()
+	values := url.Values{}
+	values.Set("startdateymd", window.Start)
+	values.Set("enddateymd", window.End)
+
+	var body struct {
+		Activities []activityDay `json:"activities"`
+	}
+
+	err := request.Call(ctx, serviceMeasureV2, actionActivity, values, &body)
+	if err != nil {
+		return err
+	}
+
+	out.Days = body.Activities
+
+	return nil
+}
+
+// fetchSleep keeps main sleep only; naps would pull the averages down.
+func fetchSleep(
+	ctx context.Context,
+	request withings.Caller,
+	rng span,
+	out *data,
+) error {
+	window := rng.window()
+	values := url.Values{}
+	values.Set("startdateymd", window.Start)
+	values.Set("enddateymd", window.End)
+	values.Set("data_fields", sleepDataFields)
+
+	var body struct {
+		Series []night `json:"series"`
+	}
+
+	err := request.Call(ctx, serviceSleep, actionSummary, values, &body)
+	if err != nil {
+		return err
+	}
+
+	location := rng.Start.Location()
+
+	for _, session := range body.Series {
+		if !sleep.IsNap(session.StartDate, session.EndDate, location) {
+			out.Nights = append(out.Nights, session)
+		}
+	}
+
+	return nil
+}
+
+func fetchWeight(
+	ctx context.Context,
+	request withings.Caller,
+	rng span,
+	out *data,
+) error {
+	values := url.Values{}
+	values.Set("meastype", strconv.Itoa(weightType))
+	values.Set("category", realCategory)
+	values.Set("startdate", strconv.FormatInt(rng.Start.Unix(), numberBase10))
+	values.Set("enddate", strconv.FormatInt(rng.End.Unix(), numberBase10))
+
+	//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+	var body struct {
+		MeasureGroups []struct {
+			Date     int64 `json:"date"`
+			Measures []struct {
+				Value int64 `json:"value"`
+				Type  int   `json:"type"`
+				Unit  int   `json:"unit"`
+			} `json:"measures"`
+		} `json:"measuregrps"`
+	}
+
+	err := request.Call(ctx, serviceMeasure, actionGetMeas, values, &body)
+	if err != nil {
+		return err
+	}
+
+	for _, group := range body.MeasureGroups {
+		for _, item := range group.Measures {
+			if item.Type != weightType {
+				continue
+			}
+
+			out.Weights = append(out.Weights, weighIn{
+				Time: time.Unix(group.Date, 0).In(rng.Start.Location()),
+				Kg:   float64(item.Value) * math.Pow10(item.Unit),
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package summary
+
+import (
+	"math"
+	"slices"
+
+	"github.com/mreimbold/withings-cli/internal/units"
+)
+
+const (
+	metersPerKm    = 1000
+	secondsPerMin  = 60
+	secondsPerHour = 3600
+	unitKm         = "km"
+	wholeDigits    = 0
+	valueDigits    = 1
+)
+
+// periodData is the part of the fetched records inside one period.
+type periodData struct {
+	Days    []activityDay
+	Nights  []night
+	Weights []weighIn
+}
+
+type metricDef struct {
+	Name   string
+	Unit   func(system string) string
+	Digits int
+	Value  func(in periodData, system string) (float64, bool)
+}
+
+// metricDefs lists the summary rows in output order.
+//
+//nolint:gochecknoglobals // Static metric table.
+var metricDefs = []metricDef{
+	{
+		Name:   "steps",
+		Unit:   fixedUnit(emptyString),
+		Digits: wholeDigits,
+		Value: daySum(func(day activityDay) float64 {
+			return day.Steps
+		}),
+	},
+	{
+		Name:   "steps_per_day",
+		Unit:   fixedUnit(emptyString),
+		Digits: wholeDigits,
+		Value:  stepsPerDay,
+	},
+	{
+		Name:   "distance",
+		Unit:   distanceUnit,
+		Digits: valueDigits,
+		Value:  distance,
+	},
+	{
+		Name:   "active_calories",
+		Unit:   fixedUnit("kcal"),
+		Digits: wholeDigits,
+		Value: daySum(func(day activityDay) float64 {
+			return day.Calories
+		}),
+	},
+	{
+		Name:   "active_minutes",
+		Unit:   fixedUnit("min"),
+		Digits: wholeDigits,
+		Value: daySum(func(day activityDay) float64 {
+			return (day.Moderate + day.Intense) / secondsPerMin
+		}),
+	},
+	{
+		Name:   "nights",
+		Unit:   fixedUnit(emptyString),
+		Digits: wholeDigits,
+		Value:  nightCount,
+	},
+	{
+		Name:   "sleep_duration",
+		Unit:   fixedUnit("h"),
+		Digits: valueDigits,
+		Value:  sleepHours,
+	},
+	{
+		Name:   "sleep_score",
+		Unit:   fixedUnit(emptyString),
+		Digits: wholeDigits,
+		Value:  sleepScore,
+	},
+	{
+		Name:   "weight",
+		Unit:   massUnit,
+		Digits: valueDigits,
+		Value:  averageWeight,
+	},
+	{
+		Name:   "weight_change",
+		Unit:   massUnit,
+		Digits: valueDigits,
+		Value:  weightChange,
+	},
+}
+
+// buildMetrics computes every metric for both periods. Change is the
+// difference of the rounded values, so the columns add up.
+func buildMetrics(
+	fetched data,
+	current span,
+	previous span,
+	system string,
+) []Metric {
+	currentData := fetched.within(current)
+	previousData := fetched.within(previous)
+	metrics := make([]Metric, 0, len(metricDefs))
+
+	for _, def := range metricDefs {
+		metric := Metric{
+			Name:     def.Name,
+			Value:    def.compute(currentData, system),
+			Previous: def.compute(previousData, system),
+			Change:   nil,
+			Unit:     def.Unit(system),
+		}
+
+		if metric.Value != nil && metric.Previous != nil {
+			change := round(*metric.Value-*metric.Previous, def.Digits)
+			metric.Change = &change
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	return metrics
+}
+
+func (def metricDef) compute(in periodData, system string) *float64 {
+	value, ok := def.Value(in, system)
+	if !ok {
+		return nil
+	}
+
+	value = round(value, def.Digits)
+
+	return &value
+}
+
+func (d data) within(period span) periodData {
+	var in periodData
+
+	for _, day := range d.Days {
+		if period.containsDate(day.Date) {
+			in.Days = append(in.Days, day)
+		}
+	}
+
+	for _, session := range d.Nights {
+		if period.containsDate(session.Date) {
+			in.Nights = append(in.Nights, session)
+		}
+	}
+
+	for _, weight := range d.Weights {
+		if period.contains(weight.Time) {
+			in.Weights = append(in.Weights, weight)
+		}
+	}
+
+	return in
+}
+
+func fixedUnit(unit string) func(string) string {
+	return func(string) string { return unit }
+}
+
+func distanceUnit(system string) string {
+	if system == units.Imperial {
+		return units.Label(system, units.Distance)
+	}
+
+	return unitKm
+}
+
+func massUnit(system string) string {
+	return units.Label(system, units.Mass)
+}
+
+func daySum(
+	value func(day activityDay) float64,
+) func(periodData, string) (float64, bool) {
+	return func(in periodData, _ string) (float64, bool) {
+		total := 0.0
+		for _, day := range in.Days {
+			total += value(day)
+		}
+
+		return total, len(in.Days) > 0
+	}
+}
+
+// stepsPerDay averages over the days with activity data, so days before
+// a tracker was worn do not count as zero.
+func stepsPerDay(in periodData, _ string) (float64, bool) {
+	total, ok := daySum(func(day activityDay) float64 {
+		return day.Steps
+	})(in, emptyString)
+
+	return total / float64(max(len(in.Days), 1)), ok
+}
+
+func distance(in periodData, system string) (float64, bool) {
+	meters, ok := daySum(func(day activityDay) float64 {
+		return day.Distance
+	})(in, system)
+
+	if system == units.Imperial {
+		return units.Convert(system, units.Distance, meters), ok
+	}
+
+	return meters / metersPerKm, ok
+}
+
+func nightCount(in periodData, _ string) (float64, bool) {
+	return float64(len(in.Nights)), len(in.Nights) > 0
+}
+
+func sleepHours(in periodData, _ string) (float64, bool) {
+	return nightAverage(in, func(session night) *float64 {
+		return session.Data.TotalTime
+	}, secondsPerHour)
+}
+
+func sleepScore(in periodData, _ string) (float64, bool) {
+	return nightAverage(in, func(session night) *float64 {
+		return session.Data.Score
+	}, 1)
+}
+
+func nightAverage(
+	in periodData,
+	value func(night) *float64,
+	divisor float64,
+) (float64, bool) {
+	total, count := 0.0, 0
+
+	for _, session := range in.Nights {
+		if field := value(session); field != nil {
+			total += *field
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	return total / float64(count) / divisor, true
+}
+
+func averageWeight(in periodData, system string) (float64, bool) {
+	if len(in.Weights) == 0 {
+		return 0, false
+	}
+
+	total := 0.0
+	for _, weight := range in.Weights {
+		total += weight.Kg
+	}
+
+	return units.Convert(system, units.Mass, total/float64(len(in.Weights))),
+		true
+}
+
+// weightChange is the last weigh-in of the period minus the first one.
+func weightChange(in periodData, system string) (float64, bool) {
+	if len(in.Weights) < 2 {
+		return 0, false
+	}
+
+	weights := slices.SortedFunc(slices.Values(in.Weights),
+		func(a, b weighIn) int { return a.Time.Compare(b.Time) })
+	first := units.Convert(system, units.Mass, weights[0].Kg)
+	last := units.Convert(system, units.Mass, weights[len(weights)-1].Kg)
+
+	return last - first, true
+}
+
+func round(value float64, digits int) float64 {
+	scale := math.Pow10(digits)
+
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,276 @@
+// Package summary composes a week or month of activity, sleep, and weight
+// data into one report with totals and averages, each compared with the
+// period before.
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	// PeriodWeek summarizes the last complete calendar week (Monday to
+	// Sunday).
+	PeriodWeek = "week"
+	// PeriodMonth summarizes the last complete calendar month.
+	PeriodMonth = "month"
+
+	dateLayout    = "2006-01-02"
+	daysPerWeek   = 7
+	plainHeader   = "metric\tvalue\tprevious\tchange\tunit"
+	tableHeader   = "Metric\tValue\tPrevious\tChange\tUnit"
+	cellSeparator = "\t"
+	positiveSign  = "+"
+	floatBitSize  = 64
+	emptyString   = ""
+)
+
+// ErrNoSections indicates that the API server supports none of the
+// endpoints the summary is built from.
+var ErrNoSections = errors.New("no summary endpoint is supported by")
+
+// Options configures the summary.
+type Options struct {
+	Period   string
+	Markdown bool
+	Now      func() time.Time
+}
+
+// Window is an inclusive range of local dates.
+type Window struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Metric is one summary value with the value of the previous period.
+// Values are nil when a period has no data for them.
+type Metric struct {
+	Name     string   `json:"name"`
+	Value    *float64 `json:"value"`
+	Previous *float64 `json:"previous"`
+	Change   *float64 `json:"change"`
+	Unit     string   `json:"unit,omitempty"`
+}
+
+// Report is the summary of one period.
+type Report struct {
+	Period   string   `json:"period"`
+	Current  Window   `json:"current"`
+	Previous Window   `json:"previous"`
+	Metrics  []Metric `json:"metrics"`
+}
+
+// span is a half-open range of local midnights.
+type span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Run builds the summary and writes output.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	report, err := Collect(ctx, opts, appOpts, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(opts, appOpts, report)
+}
+
+// Collect fetches activity, sleep, and weight data of the period and the
+// one before concurrently and aggregates it.
+func Collect(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) (Report, error) {
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	today := now().In(output.DisplayLocation(appOpts, output.TimezoneLocal))
+	current, previous := periodSpans(opts.Period, today)
+
+	request := withings.Caller{Options: appOpts, AccessToken: accessToken}
+
+	data, err := fetchAll(
+		ctx,
+		request,
+		span{Start: previous.Start, End: current.End},
+	)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return Report{
+		Period:   opts.Period,
+		Current:  current.window(),
+		Previous: previous.window(),
+		Metrics:  buildMetrics(data, current, previous, appOpts.Units),
+	}, nil
+}
+
+// periodSpans returns the last complete week or month before today and
+// the one before it.
+func periodSpans(period string, today time.Time) (span, span) {
+	midnight := time.Date(
+		today.Year(),
+		today.Month(),
+		today.Day(),
+		0,
+		0,
+		0,
+		0,
+		today.Location(),
+	)
+
+	if period == PeriodMonth {
+		end := midnight.AddDate(0, 0, 1-midnight.Day())
+		start := end.AddDate(0, -1, 0)
+
+		return span{Start: start, End: end},
+			span{Start: start.AddDate(0, -1, 0), End: start}
+	}
+
+	// Weekday counts from Sunday; weeks start on Monday.
+	sinceMonday := (int(midnight.Weekday()) + daysPerWeek - 1) % daysPerWeek
+	end := midnight.AddDate(0, 0, -sinceMonday)
+	start := end.AddDate(0, 0, -daysPerWeek)
+
+	return span{Start: start, End: end},
+		span{Start: start.AddDate(0, 0, -daysPerWeek), End: start}
+}
+
+func (s span) window() Window {
+	return Window{
+		Start: s.Start.Format(dateLayout),
+		End:   s.End.AddDate(0, 0, -1).Format(dateLayout),
+	}
+}
+
+// containsDate reports whether a YYYY-MM-DD date lies in the span.
+func (s span) containsDate(date string) bool {
+	window := s.window()
+
+	return date >= window.Start && date <= window.End
+}
+
+func (s span) contains(moment time.Time) bool {
+	return !moment.Before(s.Start) && moment.Before(s.End)
+}
+
+func writeOutput(opts Options, appOpts app.Options, report Report) error {
+	if appOpts.Quiet {
+		return nil
+	}
+
+	if appOpts.JSON && appOpts.Fields == emptyString {
+		return output.WriteRawJSON(appOpts, report)
+	}
+
+	if appOpts.Plain || appOpts.NDJSON || appOpts.JSON ||
+		appOpts.Template != emptyString {
+		return output.WritePlain(appOpts, plainLines(report))
+	}
+
+	if opts.Markdown || appOpts.Markdown {
+		return output.WriteLines(appOpts, markdownLines(report))
+	}
+
+	lines := tableLines(report)
+
+	table, err := output.RenderTable(appOpts, plainHeader, lines)
+	if err != nil {
+		return fmt.Errorf("render summary table: %w", err)
+	}
+
+	return output.WriteLines(appOpts, []string{title(report), emptyString, table})
+}
+
+func title(report Report) string {
+	name := "Week"
+	if report.Period == PeriodMonth {
+		name = "Month"
+	}
+
+	return fmt.Sprintf(
+		"%s %s to %s (previous: %s to %s)",
+		name,
+		report.Current.Start,
+		report.Current.End,
+		report.Previous.Start,
+		report.Previous.End,
+	)
+}
+
+func plainLines(report Report) []string {
+	lines := make([]string, 0, len(report.Metrics)+1)
+	lines = append(lines, plainHeader)
+
+	for _, metric := range report.Metrics {
+		lines = append(lines, strings.Join([]string{
+			metric.Name,
+			formatValue(metric.Value),
+			formatValue(metric.Previous),
+			formatValue(metric.Change),
+			metric.Unit,
+		}, cellSeparator))
+	}
+
+	return lines
+}
+
+func tableLines(report Report) []string {
+	lines := make([]string, 0, len(report.Metrics)+1)
+	lines = append(lines, tableHeader)
+
+	for _, metric := range report.Metrics {
+		lines = append(lines, strings.Join(tableCells(metric), cellSeparator))
+	}
+
+	return lines
+}
+
+func markdownLines(report Report) []string {
+	return append(
+		[]string{"## " + title(report), emptyString},
+		output.MarkdownTable(tableLines(report))...,
+	)
+}
+
+func tableCells(metric Metric) []string {
+	change := formatValue(metric.Change)
+	if metric.Change != nil && *metric.Change > 0 {
+		change = positiveSign + change
+	}
+
+	return []string{
+		metric.Name,
+		formatValue(metric.Value),
+		formatValue(metric.Previous),
+		change,
+		metric.Unit,
+	}
+}
+
+func formatValue(value *float64) string {
+	if value == nil {
+		return emptyString
+	}
+
+	return strconv.FormatFloat(*value, 'f', -1, floatBitSize)
+}
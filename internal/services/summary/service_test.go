@@ -0,0 +1,162 @@
+//nolint:testpackage // test unexported helpers.
+package summary
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/units"
+)
+
+const (
+	testActivity = `{"status":0,"body":{"activities":[` +
+		`{"date":"2025-10-06","steps":8000,"distance":6000,` +
+		`"moderate":1200,"intense":600},` +
+		`{"date":"2025-10-07","steps":10000,"distance":7000},` +
+		`{"date":"2025-09-30","steps":7000,"distance":5000}]}}`
+	testSleep = `{"status":0,"body":{"series":[` +
+		`{"date":"2025-10-07","startdate":1759787400,"enddate":1759816800,` +
+		`"data":{"sleep_score":80,"total_sleep_time":27000}},` +
+		`{"date":"2025-10-07","startdate":1759845600,"enddate":1759849200,` +
+		`"data":{"sleep_score":40,"total_sleep_time":3600}}]}}`
+	testMeasures = `{"status":0,"body":{"measuregrps":[` +
+		`{"date":1759741200,"measures":[{"value":80000,"type":1,"unit":-3}]},` +
+		`{"date":1760259600,"measures":[{"value":794,"type":1,"unit":-1}]},` +
+		`{"date":1759222800,"measures":[{"value":81000,"type":1,"unit":-3}]}` +
+		`]}}`
+	// testNow is Thursday 2025-10-16 13:46 UTC.
+	testNow = 1760622400
+)
+
+// TestPeriodSpans picks the last complete week or month.
+func TestPeriodSpans(t *testing.T) {
+	t.Parallel()
+
+	today := time.Date(2025, time.October, 13, 9, 0, 0, 0, time.UTC)
+
+	cases := map[string][2]Window{
+		PeriodWeek: {
+			{Start: "2025-10-06", End: "2025-10-12"},
+			{Start: "2025-09-29", End: "2025-10-05"},
+		},
+		PeriodMonth: {
+			{Start: "2025-09-01", End: "2025-09-30"},
+			{Start: "2025-08-01", End: "2025-08-31"},
+		},
+	}
+
+	for period, want := range cases {
+		current, previous := periodSpans(period, today)
+		if current.window() != want[0] || previous.window() != want[1] {
+			t.Fatalf(
+				"%s: got %v %v want %v",
+				period,
+				current.window(),
+				previous.window(),
+				want,
+			)
+		}
+	}
+}
+
+// TestCollect aggregates both periods and skips naps.
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(testSummaryHandler))
+	defer server.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = server.URL
+	appOpts.Timezone = "utc"
+	appOpts.Units = units.Metric
+	opts.Period = PeriodWeek
+	opts.Now = func() time.Time { return time.Unix(testNow, 0) }
+
+	report, err := Collect(t.Context(), opts, appOpts, "token")
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	if report.Current.Start != "2025-10-06" {
+		t.Fatalf("current: got %v", report.Current)
+	}
+
+	want := map[string][3]string{
+		"steps":          {"18000", "7000", "11000"},
+		"steps_per_day":  {"9000", "7000", "2000"},
+		"distance":       {"13", "5", "8"},
+		"active_minutes": {"30", "0", "30"},
+		"nights":         {"1", "", ""},
+		"sleep_duration": {"7.5", "", ""},
+		"weight":         {"79.7", "81", "-1.3"},
+		"weight_change":  {"-0.6", "", ""},
+	}
+
+	for _, metric := range report.Metrics {
+		expected, ok := want[metric.Name]
+		if !ok {
+			continue
+		}
+
+		got := [3]string{
+			formatValue(metric.Value),
+			formatValue(metric.Previous),
+			formatValue(metric.Change),
+		}
+		if got != expected {
+			t.Fatalf("%s: got %q want %q", metric.Name, got, expected)
+		}
+	}
+}
+
+// TestCollectSkipsUnsupported fails only when no section is left.
+func TestCollectSkipsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			if request.URL.Path == "/capabilities" {
+				_, _ = writer.Write([]byte(`{"actions":["user getdevice"]}`))
+
+				return
+			}
+
+			testSummaryHandler(writer, request)
+		},
+	))
+	defer server.Close()
+
+	var (
+		appOpts app.Options
+		opts    Options
+	)
+
+	appOpts.BaseURL = server.URL
+	appOpts.Quiet = true
+	opts.Period = PeriodWeek
+
+	_, err := Collect(t.Context(), opts, appOpts, "token")
+	if !errors.Is(err, ErrNoSections) {
+		t.Fatalf("got %v, want %v", err, ErrNoSections)
+	}
+}
+
+func testSummaryHandler(writer http.ResponseWriter, request *http.Request) {
+	bodies := map[string]string{
+		actionGetMeas:  testMeasures,
+		actionSummary:  testSleep,
+		actionActivity: testActivity,
+	}
+
+	_ = request.ParseForm()
+	_, _ = writer.Write([]byte(bodies[request.FormValue("action")]))
+}
@@ -0,0 +1,444 @@
+// Package tui runs an interactive terminal dashboard with tabs for the
+// weight trend, last night's sleep, today's activity, and heart data. It
+// draws the tables of the data commands themselves, so the dashboard and
+// the CLI never disagree.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	// DefaultRange is the period ranged tabs cover at startup.
+	DefaultRange = "30d"
+
+	enterScreen  = "\x1b[?1049h\x1b[?25l"
+	leaveScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen  = "\x1b[H\x1b[2J"
+	reverseVideo = "\x1b[7m"
+	resetStyle   = "\x1b[0m"
+	lineBreak    = "\n"
+	clockLayout  = "15:04:05"
+	footerText   = "1-4/tab switch  +/- range  j/k scroll  r refresh  q quit"
+	chromeLines  = 4
+	inputBufSize = 16
+	fallbackCols = 80
+	fallbackRows = 24
+	firstTabKey  = '1'
+	emptyString  = ""
+	rangeSep     = ", "
+)
+
+// Keys understood by handleKey besides printable characters.
+const (
+	keyTab      = "tab"
+	keyShiftTab = "shift-tab"
+	keyUp       = "up"
+	keyDown     = "down"
+	keyLeft     = "left"
+	keyRight    = "right"
+	keyEscape   = "esc"
+	keyCtrlC    = "ctrl-c"
+)
+
+type command int
+
+const (
+	commandNone command = iota
+	commandDraw
+	commandLoad
+	commandRefresh
+	commandQuit
+)
+
+var (
+	// ErrInvalidRange indicates a --range outside Ranges.
+	ErrInvalidRange = errors.New("invalid range")
+	errNotTerminal  = errors.New(
+		"the dashboard needs an interactive terminal on stdin and stdout",
+	)
+)
+
+// Ranges lists the periods +/- step through on ranged tabs.
+//
+//nolint:gochecknoglobals // Static range steps.
+var Ranges = []string{"7d", DefaultRange, "90d", "365d"}
+
+//nolint:gochecknoglobals // Static escape sequence table.
+var escapeKeys = map[string]string{
+	"\t":     keyTab,
+	"\x1b[Z": keyShiftTab,
+	"\x1b[A": keyUp,
+	"\x1b[B": keyDown,
+	"\x1b[C": keyRight,
+	"\x1b[D": keyLeft,
+	"\x1b":   keyEscape,
+	"\x03":   keyCtrlC,
+}
+
+// TokenSource returns a usable access token, refreshing it when needed.
+type TokenSource func(ctx context.Context) (string, error)
+
+// Options configures the dashboard.
+type Options struct {
+	Range string
+	Token TokenSource
+}
+
+// view is the navigation state the keys change.
+type view struct {
+	Tab    int
+	Range  int
+	Scroll int
+}
+
+type cacheKey struct {
+	Tab   int
+	Range string
+}
+
+type content struct {
+	Lines   []string
+	Err     error
+	Updated time.Time
+}
+
+type dashboard struct {
+	out     *os.File
+	appOpts app.Options
+	token   TokenSource
+	view    view
+	cache   map[cacheKey]content
+	width   int
+	height  int
+}
+
+// Run shows the dashboard until q is pressed or ctx ends.
+func Run(ctx context.Context, opts Options, appOpts app.Options) error {
+	rangeIndex := slices.Index(Ranges, opts.Range)
+	if rangeIndex < 0 {
+		return app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf(
+				"%w %q (supported: %s)",
+				ErrInvalidRange,
+				opts.Range,
+				strings.Join(Ranges, rangeSep),
+			),
+		)
+	}
+
+	err := checkTerminal(os.Stdin, os.Stdout)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	board := &dashboard{
+		out:     os.Stdout,
+		appOpts: tableOptions(appOpts),
+		token:   opts.Token,
+		view:    view{Tab: 0, Range: rangeIndex, Scroll: 0},
+		cache:   map[cacheKey]content{},
+		width:   fallbackCols,
+		height:  fallbackRows,
+	}
+
+	board.write(enterScreen)
+	defer board.write(leaveScreen)
+
+	return board.loop(ctx)
+}
+
+func (d *dashboard) loop(ctx context.Context) error {
+	resized, stop := notifyResize()
+	defer stop()
+
+	keys := readKeys(os.Stdin)
+
+	d.resize()
+	d.load(ctx, false)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resized:
+			d.resize()
+			d.draw(false)
+		case input, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			switch d.view.handleKey(decodeKey(input)) {
+			case commandQuit:
+				return nil
+			case commandDraw:
+				d.draw(false)
+			case commandLoad:
+				d.load(ctx, false)
+			case commandRefresh:
+				d.load(ctx, true)
+			case commandNone:
+			}
+		}
+	}
+}
+
+// readKeys forwards raw reads from in; one read holds one key press or
+// escape sequence.
+func readKeys(in *os.File) <-chan []byte {
+	keys := make(chan []byte)
+
+	go func() {
+		defer close(keys)
+
+		buffer := make([]byte, inputBufSize)
+
+		for {
+			count, err := in.Read(buffer)
+			if err != nil {
+				return
+			}
+
+			keys <- slices.Clone(buffer[:count])
+		}
+	}()
+
+	return keys
+}
+
+// decodeKey names escape sequences and control keys; anything else is
+// returned as typed.
+func decodeKey(input []byte) string {
+	if name, ok := escapeKeys[string(input)]; ok {
+		return name
+	}
+
+	return string(input)
+}
+
+// handleKey applies a key press and says what the dashboard must do.
+func (v *view) handleKey(key string) command {
+	switch key {
+	case "q", "Q", keyEscape, keyCtrlC:
+		return commandQuit
+	case keyTab, keyRight, "l":
+		return v.selectTab((v.Tab + 1) % len(tabs))
+	case keyShiftTab, keyLeft, "h":
+		return v.selectTab((v.Tab + len(tabs) - 1) % len(tabs))
+	case "+", "]":
+		return v.stepRange(1)
+	case "-", "[":
+		return v.stepRange(-1)
+	case keyDown, "j":
+		v.Scroll++
+
+		return commandDraw
+	case keyUp, "k":
+		if v.Scroll == 0 {
+			return commandNone
+		}
+
+		v.Scroll--
+
+		return commandDraw
+	case "r", "R":
+		return commandRefresh
+	}
+
+	if len(key) == 1 && key[0] >= firstTabKey &&
+		int(key[0]-firstTabKey) < len(tabs) {
+		return v.selectTab(int(key[0] - firstTabKey))
+	}
+
+	return commandNone
+}
+
+func (v *view) selectTab(index int) command {
+	if index == v.Tab {
+		return commandNone
+	}
+
+	v.Tab = index
+	v.Scroll = 0
+
+	return commandLoad
+}
+
+// stepRange moves to the next wider (step 1) or narrower (step -1)
+// range; fixed-period tabs ignore it.
+func (v *view) stepRange(step int) command {
+	next := v.Range + step
+	if !tabs[v.Tab].Ranged || next < 0 || next >= len(Ranges) {
+		return commandNone
+	}
+
+	v.Range = next
+	v.Scroll = 0
+
+	return commandLoad
+}
+
+func (v view) cacheKey() cacheKey {
+	if !tabs[v.Tab].Ranged {
+		return cacheKey{Tab: v.Tab, Range: emptyString}
+	}
+
+	return cacheKey{Tab: v.Tab, Range: Ranges[v.Range]}
+}
+
+func (d *dashboard) resize() {
+	width, height, err := terminalSize(d.out)
+	if err != nil || width <= 0 || height <= 0 {
+		return
+	}
+
+	d.width = width
+	d.height = height
+}
+
+// load shows the current tab, fetching it unless it is cached or when
+// force is set. Keys pressed while fetching are handled afterwards.
+func (d *dashboard) load(ctx context.Context, force bool) {
+	key := d.view.cacheKey()
+	if _, ok := d.cache[key]; ok && !force {
+		d.draw(false)
+
+		return
+	}
+
+	d.draw(true)
+	d.cache[key] = d.fetch(ctx, key)
+	d.draw(false)
+}
+
+func (d *dashboard) fetch(ctx context.Context, key cacheKey) content {
+	accessToken, err := d.token(ctx)
+	if err != nil {
+		return content{Lines: nil, Err: err, Updated: time.Now()}
+	}
+
+	// The command's own table and warnings become the tab content.
+	lines, err := output.CaptureLines(
+		d.appOpts,
+		func(appOpts app.Options) error {
+			appOpts.Stderr = appOpts.Stdout
+
+			return tabs[key.Tab].fetch(ctx, appOpts, accessToken, key.Range)
+		},
+	)
+
+	return content{Lines: lines, Err: err, Updated: time.Now()}
+}
+
+func (d *dashboard) draw(loading bool) {
+	current, ok := d.cache[d.view.cacheKey()]
+	if !ok || loading {
+		current = content{Lines: nil, Err: nil, Updated: current.Updated}
+	}
+
+	d.view.Scroll = min(d.view.Scroll, max(len(current.Lines)-1, 0))
+
+	d.write(clearScreen + strings.Join(
+		renderScreen(d.view, current, loading, d.width, d.height),
+		lineBreak,
+	))
+}
+
+func (d *dashboard) write(text string) {
+	_, _ = d.out.WriteString(text)
+}
+
+// renderScreen lays out the tab bar, the period line, as much of the tab
+// content as fits from the scroll offset, and the key help.
+func renderScreen(
+	state view,
+	current content,
+	loading bool,
+	width int,
+	height int,
+) []string {
+	lines := []string{
+		renderTabBar(state.Tab),
+		clip(renderPeriod(state, current, loading), width),
+		emptyString,
+	}
+
+	body := current.Lines
+	if current.Err != nil {
+		body = append(slices.Clone(body), "error: "+current.Err.Error())
+	}
+
+	body = body[min(state.Scroll, len(body)):]
+	for _, line := range body[:min(len(body), max(height-chromeLines, 0))] {
+		lines = append(lines, clip(line, width))
+	}
+
+	for len(lines) < height-1 {
+		lines = append(lines, emptyString)
+	}
+
+	return append(lines, clip(footerText, width))
+}
+
+func renderTabBar(active int) string {
+	labels := make([]string, 0, len(tabs))
+
+	for index, page := range tabs {
+		label := " " + strconv.Itoa(index+1) + " " + page.Name + " "
+		if index == active {
+			label = reverseVideo + label + resetStyle
+		}
+
+		labels = append(labels, label)
+	}
+
+	return strings.Join(labels, " ")
+}
+
+func renderPeriod(state view, current content, loading bool) string {
+	page := tabs[state.Tab]
+
+	period := page.Period
+	if page.Ranged {
+		period = "last " + Ranges[state.Range]
+	}
+
+	line := page.Name + ": " + period
+
+	switch {
+	case loading:
+		line += " - loading..."
+	case !current.Updated.IsZero():
+		line += " - updated " + current.Updated.Format(clockLayout)
+	}
+
+	return line
+}
+
+// clip cuts line to width runes so long table rows do not wrap.
+func clip(line string, width int) string {
+	runes := []rune(line)
+	if width <= 0 || len(runes) <= width {
+		return line
+	}
+
+	return string(runes[:width])
+}
@@ -0,0 +1,117 @@
+//nolint:testpackage // test unexported helpers.
+package tui
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestHandleKey switches tabs, steps ranges, and quits.
+func TestHandleKey(t *testing.T) {
+	t.Parallel()
+
+	state := view{Tab: 0, Range: 1, Scroll: 0}
+
+	steps := []struct {
+		key  string
+		want command
+		tab  int
+		rng  int
+	}{
+		{key: "+", want: commandLoad, tab: 0, rng: 2},
+		{key: "]", want: commandLoad, tab: 0, rng: 3},
+		{key: "+", want: commandNone, tab: 0, rng: 3},
+		{key: decodeKey([]byte("\x1b[C")), want: commandLoad, tab: 1, rng: 3},
+		{key: "-", want: commandNone, tab: 1, rng: 3},
+		{key: "4", want: commandLoad, tab: 3, rng: 3},
+		{key: "4", want: commandNone, tab: 3, rng: 3},
+		{key: decodeKey([]byte("\t")), want: commandLoad, tab: 0, rng: 3},
+		{key: decodeKey([]byte("\x1b[Z")), want: commandLoad, tab: 3, rng: 3},
+		{key: "[", want: commandLoad, tab: 3, rng: 2},
+		{key: "9", want: commandNone, tab: 3, rng: 2},
+		{key: "r", want: commandRefresh, tab: 3, rng: 2},
+		{key: "q", want: commandQuit, tab: 3, rng: 2},
+		{key: decodeKey([]byte("\x03")), want: commandQuit, tab: 3, rng: 2},
+	}
+
+	for _, step := range steps {
+		got := state.handleKey(step.key)
+		if got != step.want || state.Tab != step.tab ||
+			state.Range != step.rng {
+			t.Fatalf(
+				"%q: got %v tab %d range %d, want %v tab %d range %d",
+				step.key,
+				got,
+				state.Tab,
+				state.Range,
+				step.want,
+				step.tab,
+				step.rng,
+			)
+		}
+	}
+}
+
+// TestHandleKeyScroll never scrolls above the first line and resets the
+// offset on tab switches.
+func TestHandleKeyScroll(t *testing.T) {
+	t.Parallel()
+
+	state := view{Tab: 0, Range: 1, Scroll: 0}
+
+	if got := state.handleKey("k"); got != commandNone {
+		t.Fatalf("scroll up at top got %v", got)
+	}
+
+	state.handleKey("j")
+	state.handleKey(decodeKey([]byte("\x1b[B")))
+
+	if state.Scroll != 2 {
+		t.Fatalf("scroll got %d want 2", state.Scroll)
+	}
+
+	state.handleKey("2")
+
+	if state.Scroll != 0 {
+		t.Fatalf("scroll after tab switch got %d", state.Scroll)
+	}
+}
+
+// TestRenderScreen fills the height, clips long rows, and scrolls.
+func TestRenderScreen(t *testing.T) {
+	t.Parallel()
+
+	current := content{
+		Lines:   []string{"header", "row 1", "row 2 is much too long"},
+		Err:     errors.New("boom"),
+		Updated: time.Time{},
+	}
+	state := view{Tab: 0, Range: 0, Scroll: 1}
+
+	lines := renderScreen(state, current, false, 10, 7)
+	if len(lines) != 7 {
+		t.Fatalf("got %d lines want 7: %q", len(lines), lines)
+	}
+
+	want := []string{"Weight: la", "", "row 1", "row 2 is m", "error: boo"}
+	if !slices.Equal(lines[1:6], want) {
+		t.Fatalf("got %q want %q", lines[1:6], want)
+	}
+
+	if lines[6] != clip(footerText, 10) {
+		t.Fatalf("footer got %q", lines[6])
+	}
+
+	loading := renderScreen(
+		view{Tab: 1, Range: 0, Scroll: 0},
+		content{Lines: nil, Err: nil, Updated: time.Time{}},
+		true,
+		80,
+		5,
+	)
+	if loading[1] != "Sleep: last night - loading..." {
+		t.Fatalf("period got %q", loading[1])
+	}
+}
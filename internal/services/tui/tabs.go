@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/services/activity"
+	"github.com/mreimbold/withings-cli/internal/services/heart"
+	"github.com/mreimbold/withings-cli/internal/services/measures"
+	"github.com/mreimbold/withings-cli/internal/services/sleep"
+)
+
+const (
+	weightType     = "weight"
+	realCategory   = "real"
+	lastNightStart = "yesterday"
+	todayDate      = "today"
+)
+
+// tab is one dashboard page. fetch runs a data command and returns what
+// it printed; Ranged tabs follow the selected range, the others cover a
+// fixed period described by Period.
+type tab struct {
+	Name   string
+	Ranged bool
+	Period string
+	fetch  func(
+		ctx context.Context,
+		appOpts app.Options,
+		accessToken string,
+		start string,
+	) error
+}
+
+//nolint:gochecknoglobals // Static tab table.
+var tabs = []tab{
+	{Name: "Weight", Ranged: true, Period: emptyString, fetch: fetchWeight},
+	{Name: "Sleep", Ranged: false, Period: "last night", fetch: fetchSleep},
+	{
+		Name:   "Activity",
+		Ranged: false,
+		Period: "today",
+		fetch:  fetchActivity,
+	},
+	{Name: "Heart", Ranged: true, Period: emptyString, fetch: fetchHeart},
+}
+
+func fetchWeight(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	start string,
+) error {
+	//nolint:exhaustruct // Only the weight trend filters are set.
+	opts := measures.Options{
+		TimeRange: params.TimeRange{Start: start, End: emptyString},
+		Types:     weightType,
+		Category:  realCategory,
+	}
+
+	//nolint:wrapcheck // The message is shown as the tab content.
+	return measures.Trend(ctx, opts, appOpts, accessToken)
+}
+
+func fetchSleep(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	_ string,
+) error {
+	//nolint:exhaustruct // Only the time range is set.
+	opts := sleep.Options{
+		TimeRange: params.TimeRange{Start: lastNightStart, End: emptyString},
+	}
+
+	//nolint:wrapcheck // The message is shown as the tab content.
+	return sleep.Run(ctx, opts, appOpts, accessToken)
+}
+
+func fetchActivity(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	_ string,
+) error {
+	//nolint:exhaustruct // Only the date is set.
+	opts := activity.Options{Date: params.Date{Date: todayDate}}
+
+	//nolint:wrapcheck // The message is shown as the tab content.
+	return activity.Run(ctx, opts, appOpts, accessToken)
+}
+
+func fetchHeart(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	start string,
+) error {
+	//nolint:exhaustruct // Only the time range is set.
+	opts := heart.Options{
+		TimeRange: params.TimeRange{Start: start, End: emptyString},
+	}
+
+	//nolint:wrapcheck // The message is shown as the tab content.
+	return heart.Run(ctx, opts, appOpts, accessToken)
+}
+
+// tableOptions forces the table layout every tab is drawn from,
+// whatever output flags the dashboard was started with.
+func tableOptions(appOpts app.Options) app.Options {
+	appOpts.JSON = false
+	appOpts.Plain = false
+	appOpts.NDJSON = false
+	appOpts.Quiet = false
+	appOpts.Template = emptyString
+	appOpts.Markdown = false
+	appOpts.Fields = emptyString
+	appOpts.Columns = emptyString
+
+	return appOpts
+}
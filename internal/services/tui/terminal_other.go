@@ -0,0 +1,28 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupportedPlatform = errors.New(
+	"the dashboard is not supported on this platform",
+)
+
+func checkTerminal(_ ...*os.File) error {
+	return errUnsupportedPlatform
+}
+
+func makeRaw(_ *os.File) (func(), error) {
+	return nil, errUnsupportedPlatform
+}
+
+func terminalSize(_ *os.File) (int, int, error) {
+	return 0, 0, errUnsupportedPlatform
+}
+
+func notifyResize() (<-chan os.Signal, func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,116 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+type winsize struct {
+	Rows   uint16
+	Cols   uint16
+	XPixel uint16
+	YPixel uint16
+}
+
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		fd,
+		request,
+		uintptr(arg),
+	)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func getTermios(file *os.File) (syscall.Termios, error) {
+	var termios syscall.Termios
+
+	//nolint:gosec // ioctl needs a pointer to the termios struct.
+	err := ioctl(file.Fd(), ioctlGetTermios, unsafe.Pointer(&termios))
+	if err != nil {
+		return termios, fmt.Errorf("read terminal mode: %w", err)
+	}
+
+	return termios, nil
+}
+
+func setTermios(file *os.File, termios syscall.Termios) error {
+	//nolint:gosec // ioctl needs a pointer to the termios struct.
+	err := ioctl(file.Fd(), ioctlSetTermios, unsafe.Pointer(&termios))
+	if err != nil {
+		return fmt.Errorf("set terminal mode: %w", err)
+	}
+
+	return nil
+}
+
+// checkTerminal fails unless every file is an interactive terminal.
+func checkTerminal(files ...*os.File) error {
+	for _, file := range files {
+		_, err := getTermios(file)
+		if err != nil {
+			return errNotTerminal
+		}
+	}
+
+	return nil
+}
+
+// makeRaw switches the terminal to unbuffered input without echo, so
+// single key presses reach the dashboard, and returns a function that
+// restores the previous mode. Output processing stays on.
+func makeRaw(file *os.File) (func(), error) {
+	saved, err := getTermios(file)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := saved
+	raw.Iflag &^= syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.ISIG |
+		syscall.IEXTEN
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	err = setTermios(file, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { _ = setTermios(file, saved) }, nil
+}
+
+// terminalSize returns the columns and rows of the terminal.
+func terminalSize(file *os.File) (int, int, error) {
+	var size winsize
+
+	err := ioctl(
+		file.Fd(),
+		syscall.TIOCGWINSZ,
+		//nolint:gosec // ioctl needs a pointer to the winsize struct.
+		unsafe.Pointer(&size),
+	)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read terminal size: %w", err)
+	}
+
+	return int(size.Cols), int(size.Rows), nil
+}
+
+// notifyResize delivers a value on the returned channel whenever the
+// terminal is resized.
+func notifyResize() (<-chan os.Signal, func()) {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+
+	return resized, func() { signal.Stop(resized) }
+}
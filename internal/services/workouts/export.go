@@ -0,0 +1,277 @@
+package workouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+// Workout export formats.
+const (
+	FormatFIT = "fit"
+	FormatTCX = "tcx"
+	FormatGPX = "gpx"
+)
+
+const exportFileMode = 0o600
+
+var (
+	errExportID       = errors.New("--id must be a positive workout ID")
+	errExportFormat   = errors.New("invalid --file-format (want fit, tcx, or gpx)")
+	errExportNotFound = errors.New("workout not found")
+)
+
+// ExportOptions captures workout export parameters.
+type ExportOptions struct {
+	// Query bounds the getworkouts lookup of ID.
+	Query  Options
+	ID     int64
+	Format string
+	Output string
+}
+
+// exportWorkout is a workout with its heart-rate samples, oldest first.
+type exportWorkout struct {
+	Workout   series
+	HeartRate []heartRateSample
+}
+
+// Export looks up a workout by ID and writes it as FIT, TCX, or GPX with
+// the heart-rate series recorded during it, when there is one.
+func Export(
+	ctx context.Context,
+	opts ExportOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	format, err := resolveExportFormat(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	if opts.ID <= defaultInt64 {
+		return app.NewExitError(app.ExitCodeUsage, errExportID)
+	}
+
+	values, err := buildParams(opts.Query)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	workout, err := findWorkout(ctx, appOpts, accessToken, values, opts.ID)
+	if err != nil {
+		return err
+	}
+
+	samples, err := fetchHeartRate(ctx, appOpts, accessToken, workout)
+	if err != nil {
+		if !errors.Is(err, withings.ErrAPI) {
+			return err
+		}
+
+		_ = output.WriteWarning(appOpts, fmt.Sprintf(
+			"warning: exporting without heart rate: %v",
+			err,
+		))
+	}
+
+	exported := exportWorkout{Workout: workout, HeartRate: samples}
+
+	if format == FormatGPX {
+		_ = output.WriteWarning(
+			appOpts,
+			"warning: Withings workouts have no GPS positions; "+
+				"the GPX track has no points",
+		)
+	}
+
+	encoded, err := encodeExport(format, exported)
+	if err != nil {
+		return err
+	}
+
+	return writeExport(appOpts, opts, exported, encoded)
+}
+
+// resolveExportFormat prefers --file-format, then the --dest extension.
+func resolveExportFormat(opts ExportOptions) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == emptyString {
+		format = strings.ToLower(
+			strings.TrimPrefix(filepath.Ext(opts.Output), "."),
+		)
+	}
+
+	switch format {
+	case FormatFIT, FormatTCX, FormatGPX:
+		return format, nil
+	default:
+		return emptyString, fmt.Errorf("%w: %q", errExportFormat, format)
+	}
+}
+
+// FormatChoices lists the --file-format values.
+func FormatChoices() []output.Choice {
+	return []output.Choice{
+		{Name: FormatFIT, Value: "Garmin FIT activity"},
+		{Name: FormatTCX, Value: "Training Center XML"},
+		{Name: FormatGPX, Value: "GPX 1.1 (no positions)"},
+	}
+}
+
+// findWorkout pages through getworkouts until it sees id.
+func findWorkout(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	values url.Values,
+	id int64,
+) (series, error) {
+	for {
+		decoded, err := fetchPage(ctx, appOpts, accessToken, values)
+		if err != nil {
+			return series{}, err
+		}
+
+		for _, workout := range decoded.Body.Series {
+			if workout.ID == id {
+				return workout, nil
+			}
+		}
+
+		if !decoded.Body.More || !advanceOffset(values, decoded.Body.Offset) {
+			return series{}, app.NewExitError(app.ExitCodeFailure, fmt.Errorf(
+				"%w: %d (widen the range with --start/--end)",
+				errExportNotFound,
+				id,
+			))
+		}
+	}
+}
+
+func encodeExport(format string, exported exportWorkout) ([]byte, error) {
+	switch format {
+	case FormatFIT:
+		return encodeFIT(exported), nil
+	case FormatGPX:
+		return encodeGPX(exported)
+	default:
+		return encodeTCX(exported)
+	}
+}
+
+func writeExport(
+	appOpts app.Options,
+	opts ExportOptions,
+	exported exportWorkout,
+	encoded []byte,
+) error {
+	if opts.Output == emptyString {
+		if appOpts.Quiet {
+			return nil
+		}
+
+		return output.WriteRaw(appOpts, encoded)
+	}
+
+	err := os.WriteFile(opts.Output, encoded, exportFileMode)
+	if err != nil {
+		return fmt.Errorf("write workout file: %w", err)
+	}
+
+	if appOpts.Quiet {
+		return nil
+	}
+
+	return output.WriteLine(appOpts, fmt.Sprintf(
+		"saved workout %d (%s, %d heart-rate samples) to %s",
+		opts.ID,
+		CategoryName(exported.Workout.Category),
+		len(exported.HeartRate),
+		opts.Output,
+	))
+}
+
+func exportTime(epoch int64) time.Time {
+	return time.Unix(epoch, defaultInt64).UTC()
+}
+
+// sportFormat is how a workout category is labeled in FIT and TCX.
+type sportFormat struct {
+	FIT byte
+	TCX string
+}
+
+//nolint:gochecknoglobals // Static map of workout categories to sports.
+var exportSports = map[int]sportFormat{
+	1:   {FIT: 11, TCX: tcxSportOther},
+	2:   {FIT: 1, TCX: exportSportRun},
+	3:   {FIT: 17, TCX: tcxSportOther},
+	5:   {FIT: 2, TCX: exportSportCyc},
+	6:   {FIT: 2, TCX: exportSportCyc},
+	7:   {FIT: 5, TCX: tcxSportOther},
+	12:  {FIT: 8, TCX: tcxSportOther},
+	16:  {FIT: 10, TCX: tcxSportOther},
+	17:  {FIT: 10, TCX: tcxSportOther},
+	18:  {FIT: 4, TCX: tcxSportOther},
+	20:  {FIT: 6, TCX: tcxSportOther},
+	21:  {FIT: 7, TCX: tcxSportOther},
+	27:  {FIT: 25, TCX: tcxSportOther},
+	34:  {FIT: 13, TCX: tcxSportOther},
+	35:  {FIT: 14, TCX: tcxSportOther},
+	187: {FIT: 15, TCX: tcxSportOther},
+	306: {FIT: 11, TCX: tcxSportOther},
+	307: {FIT: 1, TCX: exportSportRun},
+	308: {FIT: 2, TCX: exportSportCyc},
+}
+
+// exportSport maps a category to its FIT sport and TCX Sport attribute;
+// unknown categories are generic / Other.
+func exportSport(category int) sportFormat {
+	sport, ok := exportSports[category]
+	if !ok {
+		return sportFormat{FIT: 0, TCX: tcxSportOther}
+	}
+
+	return sport
+}
+
+// heartRateStats prefers the workout's hr_average and hr_max and falls
+// back to the samples.
+func heartRateStats(exported exportWorkout) (int, int) {
+	average := int(math.Round(exported.Workout.Data.HRAverage))
+	maximum := int(math.Round(exported.Workout.Data.HRMax))
+
+	if len(exported.HeartRate) == defaultInt {
+		return average, maximum
+	}
+
+	total, peak := defaultInt, defaultInt
+
+	for _, sample := range exported.HeartRate {
+		total += sample.BPM
+		peak = max(peak, sample.BPM)
+	}
+
+	if average <= defaultInt {
+		average = int(math.Round(
+			float64(total) / float64(len(exported.HeartRate)),
+		))
+	}
+
+	if maximum <= defaultInt {
+		maximum = peak
+	}
+
+	return average, maximum
+}
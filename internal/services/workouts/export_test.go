@@ -0,0 +1,128 @@
+//nolint:testpackage // test unexported helpers.
+package workouts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestResolveExportFormat prefers --file-format over the --dest extension.
+func TestResolveExportFormat(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		format string
+		output string
+		want   string
+	}{
+		{format: "FIT", output: "run.tcx", want: FormatFIT},
+		{format: workoutsTestEmpty, output: "run.tcx", want: FormatTCX},
+		{format: workoutsTestEmpty, output: "run.GPX", want: FormatGPX},
+	}
+
+	for _, test := range cases {
+		got, err := resolveExportFormat(ExportOptions{
+			Query:  testOptions(),
+			ID:     1,
+			Format: test.format,
+			Output: test.output,
+		})
+		if err != nil || got != test.want {
+			t.Fatalf("%q/%q got %q, %v", test.format, test.output, got, err)
+		}
+	}
+
+	_, err := resolveExportFormat(ExportOptions{
+		Query:  testOptions(),
+		ID:     1,
+		Format: workoutsTestEmpty,
+		Output: workoutsTestEmpty,
+	})
+	if !errors.Is(err, errExportFormat) {
+		t.Fatalf(workoutsTestErrFmt, err, errExportFormat)
+	}
+}
+
+// TestDecodeHeartRate keeps sorted readings inside the workout.
+func TestDecodeHeartRate(t *testing.T) {
+	t.Parallel()
+
+	workout := testSeries(workoutsTestRun)
+	payload := `{"status":0,"body":{"series":{` +
+		`"1767081720":{"heart_rate":150},"1767081660":{"heart_rate":120},` +
+		`"1767081700":{"heart_rate":0},"1767090000":{"heart_rate":90}}}}`
+
+	samples, err := decodeHeartRate([]byte(payload), workout)
+	if err != nil {
+		t.Fatalf("decodeHeartRate: %v", err)
+	}
+
+	if len(samples) != 2 || samples[0].BPM != 120 || samples[1].BPM != 150 {
+		t.Fatalf("got %+v", samples)
+	}
+
+	empty := `{"status":0,"body":{"series":[]}}`
+
+	samples, err = decodeHeartRate([]byte(empty), workout)
+	if err != nil || len(samples) != workoutsTestDefaultInt {
+		t.Fatalf("empty series got %+v, %v", samples, err)
+	}
+}
+
+// TestEncodeFIT writes a header and file CRC a FIT reader accepts.
+func TestEncodeFIT(t *testing.T) {
+	t.Parallel()
+
+	encoded := encodeFIT(testExport())
+
+	if !bytes.Equal(encoded[8:12], []byte(fitSignature)) {
+		t.Fatalf("signature %q", encoded[8:12])
+	}
+
+	size := binary.LittleEndian.Uint32(encoded[4:8])
+	if int(size) != len(encoded)-fitHeaderSize-2 {
+		t.Fatalf("data size %d for %d bytes", size, len(encoded))
+	}
+
+	if fitCRC(encoded[:12]) != binary.LittleEndian.Uint16(encoded[12:14]) {
+		t.Fatal("header CRC mismatch")
+	}
+
+	if fitCRC(encoded) != workoutsTestDefaultInt {
+		t.Fatal("file CRC mismatch")
+	}
+}
+
+// TestEncodeTCX writes the lap summary and a heart-rate trackpoint.
+func TestEncodeTCX(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := encodeTCX(testExport())
+	if err != nil {
+		t.Fatalf("encodeTCX: %v", err)
+	}
+
+	for _, want := range []string{
+		`<Activity Sport="Running">`,
+		"<TotalTimeSeconds>1800</TotalTimeSeconds>",
+		"<Calories>313</Calories>",
+		"<Time>2025-12-30T08:01:00Z</Time>",
+		"<Value>140</Value>",
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Fatalf("missing %q in\n%s", want, encoded)
+		}
+	}
+}
+
+func testExport() exportWorkout {
+	return exportWorkout{
+		Workout: testSeries(workoutsTestRun),
+		HeartRate: []heartRateSample{
+			{Time: workoutsTestStart + 60, BPM: 140},
+		},
+	}
+}
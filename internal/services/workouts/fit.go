@@ -0,0 +1,241 @@
+package workouts
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// FIT activity files are a 14-byte header, definition and data messages,
+// and a CRC over everything before it. Timestamps count seconds from
+// 1989-12-31T00:00:00Z.
+const (
+	fitHeaderSize      = 14
+	fitProtocolVersion = 0x20
+	fitProfileVersion  = 2132
+	fitSignature       = ".FIT"
+	fitEpochOffset     = 631065600
+	fitDefinitionFlag  = 0x40
+	fitLittleEndian    = 0
+	fitMillis          = 1000
+	fitCentimeters     = 100
+	fitManufacturerDev = 255
+	fitFileActivity    = 4
+	fitCRCNibble       = 0xf
+	fitCRCShift        = 4
+	fitCRCMask         = 0x0fff
+
+	fitBaseEnum   = 0x00
+	fitBaseUint8  = 0x02
+	fitBaseUint16 = 0x84
+	fitBaseUint32 = 0x86
+
+	fitInvalidUint8 = 0xff
+
+	fitMesgFileID   = 0
+	fitMesgSession  = 18
+	fitMesgLap      = 19
+	fitMesgRecord   = 20
+	fitMesgEvent    = 21
+	fitMesgActivity = 34
+
+	fitFieldTimestamp = 253
+
+	fitEventTimer     = 0
+	fitEventSession   = 8
+	fitEventLap       = 9
+	fitEventActivity  = 26
+	fitEventStart     = 0
+	fitEventStop      = 1
+	fitEventStopAll   = 4
+	fitActivityManual = 0
+)
+
+//nolint:gochecknoglobals // FIT SDK CRC-16 nibble table.
+var fitCRCTable = [16]uint16{
+	0x0000, 0xcc01, 0xd801, 0x1400, 0xf001, 0x3c00, 0x2800, 0xe401,
+	0xa001, 0x6c00, 0x7800, 0xb401, 0x5000, 0x9c01, 0x8801, 0x4400,
+}
+
+// fitField is one field of a message; its size follows from Base.
+type fitField struct {
+	Num   byte
+	Base  byte
+	Value uint32
+}
+
+// fitWriter assigns each global message number a local type, writing its
+// definition before the first data message of that type.
+type fitWriter struct {
+	data   bytes.Buffer
+	locals map[uint16]byte
+}
+
+// encodeFIT writes the workout as a FIT activity: file_id, timer events,
+// one record per heart-rate sample, one lap, one session, and the
+// activity.
+func encodeFIT(exported exportWorkout) []byte {
+	workout := exported.Workout
+	start := fitTime(workout.StartDate)
+	end := fitTime(max(workout.EndDate, workout.StartDate))
+	elapsed := uint32(workoutDuration(workout) * fitMillis)
+	distance := uint32(math.Round(workout.Data.Distance * fitCentimeters))
+	calories := uint32(math.Round(workout.Data.Calories))
+	sport := uint32(exportSport(workout.Category).FIT)
+	average, maximum := heartRateStats(exported)
+
+	writer := fitWriter{data: bytes.Buffer{}, locals: map[uint16]byte{}}
+	writer.message(fitMesgFileID, []fitField{
+		{Num: 0, Base: fitBaseEnum, Value: fitFileActivity},
+		{Num: 1, Base: fitBaseUint16, Value: fitManufacturerDev},
+		{Num: 2, Base: fitBaseUint16, Value: 0},
+		{Num: 4, Base: fitBaseUint32, Value: start},
+	})
+	writer.message(fitMesgEvent, fitTimerEvent(start, fitEventStart))
+
+	for _, sample := range exported.HeartRate {
+		writer.message(fitMesgRecord, []fitField{
+			{Num: fitFieldTimestamp, Base: fitBaseUint32, Value: fitTime(
+				sample.Time,
+			)},
+			{Num: 3, Base: fitBaseUint8, Value: fitUint8(sample.BPM)},
+		})
+	}
+
+	writer.message(fitMesgEvent, fitTimerEvent(end, fitEventStopAll))
+	writer.message(fitMesgLap, []fitField{
+		{Num: fitFieldTimestamp, Base: fitBaseUint32, Value: end},
+		{Num: 0, Base: fitBaseEnum, Value: fitEventLap},
+		{Num: 1, Base: fitBaseEnum, Value: fitEventStop},
+		{Num: 2, Base: fitBaseUint32, Value: start},
+		{Num: 7, Base: fitBaseUint32, Value: elapsed},
+		{Num: 8, Base: fitBaseUint32, Value: elapsed},
+		{Num: 9, Base: fitBaseUint32, Value: distance},
+		{Num: 11, Base: fitBaseUint16, Value: calories},
+		{Num: 15, Base: fitBaseUint8, Value: fitUint8(average)},
+		{Num: 16, Base: fitBaseUint8, Value: fitUint8(maximum)},
+		{Num: 25, Base: fitBaseEnum, Value: sport},
+	})
+	writer.message(fitMesgSession, []fitField{
+		{Num: fitFieldTimestamp, Base: fitBaseUint32, Value: end},
+		{Num: 0, Base: fitBaseEnum, Value: fitEventSession},
+		{Num: 1, Base: fitBaseEnum, Value: fitEventStop},
+		{Num: 2, Base: fitBaseUint32, Value: start},
+		{Num: 5, Base: fitBaseEnum, Value: sport},
+		{Num: 6, Base: fitBaseEnum, Value: 0},
+		{Num: 7, Base: fitBaseUint32, Value: elapsed},
+		{Num: 8, Base: fitBaseUint32, Value: elapsed},
+		{Num: 9, Base: fitBaseUint32, Value: distance},
+		{Num: 11, Base: fitBaseUint16, Value: calories},
+		{Num: 16, Base: fitBaseUint8, Value: fitUint8(average)},
+		{Num: 17, Base: fitBaseUint8, Value: fitUint8(maximum)},
+		{Num: 25, Base: fitBaseUint16, Value: 0},
+		{Num: 26, Base: fitBaseUint16, Value: 1},
+	})
+	writer.message(fitMesgActivity, []fitField{
+		{Num: fitFieldTimestamp, Base: fitBaseUint32, Value: end},
+		{Num: 0, Base: fitBaseUint32, Value: elapsed},
+		{Num: 1, Base: fitBaseUint16, Value: 1},
+		{Num: 2, Base: fitBaseEnum, Value: fitActivityManual},
+		{Num: 3, Base: fitBaseEnum, Value: fitEventActivity},
+		{Num: 4, Base: fitBaseEnum, Value: fitEventStop},
+	})
+
+	return writer.bytes()
+}
+
+func fitTimerEvent(timestamp uint32, eventType uint32) []fitField {
+	return []fitField{
+		{Num: fitFieldTimestamp, Base: fitBaseUint32, Value: timestamp},
+		{Num: 0, Base: fitBaseEnum, Value: fitEventTimer},
+		{Num: 1, Base: fitBaseEnum, Value: eventType},
+	}
+}
+
+func (w *fitWriter) message(global uint16, fields []fitField) {
+	local, ok := w.locals[global]
+	if !ok {
+		local = byte(len(w.locals))
+		w.locals[global] = local
+
+		w.data.WriteByte(fitDefinitionFlag | local)
+		w.data.WriteByte(0)
+		w.data.WriteByte(fitLittleEndian)
+		_ = binary.Write(&w.data, binary.LittleEndian, global)
+		w.data.WriteByte(byte(len(fields)))
+
+		for _, field := range fields {
+			size := fitFieldSize(field.Base)
+			w.data.Write([]byte{field.Num, size, field.Base})
+		}
+	}
+
+	w.data.WriteByte(local)
+
+	for _, field := range fields {
+		switch field.Base {
+		case fitBaseUint32:
+			_ = binary.Write(&w.data, binary.LittleEndian, field.Value)
+		case fitBaseUint16:
+			_ = binary.Write(&w.data, binary.LittleEndian, uint16(field.Value))
+		default:
+			w.data.WriteByte(byte(field.Value))
+		}
+	}
+}
+
+// bytes returns the header, the messages, and the file CRC.
+func (w *fitWriter) bytes() []byte {
+	header := make([]byte, fitHeaderSize)
+	header[0] = fitHeaderSize
+	header[1] = fitProtocolVersion
+	binary.LittleEndian.PutUint16(header[2:], fitProfileVersion)
+	binary.LittleEndian.PutUint32(header[4:], uint32(w.data.Len()))
+	copy(header[8:], fitSignature)
+	binary.LittleEndian.PutUint16(header[12:], fitCRC(header[:12]))
+
+	file := append(header, w.data.Bytes()...)
+
+	return binary.LittleEndian.AppendUint16(file, fitCRC(file))
+}
+
+func fitFieldSize(base byte) byte {
+	switch base {
+	case fitBaseUint32:
+		return 4
+	case fitBaseUint16:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// fitCRC is the FIT SDK CRC-16 of data.
+func fitCRC(data []byte) uint16 {
+	var crc uint16
+
+	for _, value := range data {
+		nibbles := []byte{value & fitCRCNibble, value >> fitCRCShift}
+		for _, nibble := range nibbles {
+			entry := fitCRCTable[crc&fitCRCNibble]
+			crc = (crc >> fitCRCShift) & fitCRCMask
+			crc = crc ^ entry ^ fitCRCTable[nibble]
+		}
+	}
+
+	return crc
+}
+
+func fitTime(epoch int64) uint32 {
+	return uint32(max(epoch-fitEpochOffset, defaultInt64))
+}
+
+// fitUint8 clamps a bpm to a FIT uint8, using the invalid value when
+// there is none.
+func fitUint8(value int) uint32 {
+	if value <= defaultInt {
+		return fitInvalidUint8
+	}
+
+	return uint32(min(value, fitInvalidUint8-1))
+}
@@ -0,0 +1,159 @@
+package workouts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	actionIntraday     = "getintradayactivity"
+	startDateTimeParam = "startdate"
+	endDateTimeParam   = "enddate"
+	heartRateField     = "heart_rate"
+	int64BitSize       = 64
+)
+
+// heartRateSample is one intraday heart-rate reading in bpm.
+type heartRateSample struct {
+	Time int64
+	BPM  int
+}
+
+type intradayResponse struct {
+	Status int          `json:"status"`
+	Body   intradayBody `json:"body"`
+	Error  string       `json:"error"`
+	Detail string       `json:"detail"`
+}
+
+type intradayBody struct {
+	Series json.RawMessage `json:"series"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type intradayValues struct {
+	HeartRate int `json:"heart_rate"`
+}
+
+// fetchHeartRate reads the intraday heart rate recorded between the start
+// and end of workout.
+func fetchHeartRate(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	workout series,
+) ([]heartRateSample, error) {
+	if workoutDuration(workout) == defaultInt64 {
+		return nil, nil
+	}
+
+	values := url.Values{}
+	values.Set(
+		startDateTimeParam,
+		strconv.FormatInt(workout.StartDate, numberBase10),
+	)
+	values.Set(
+		endDateTimeParam,
+		strconv.FormatInt(workout.EndDate, numberBase10),
+	)
+	values.Set(dataFieldsParam, heartRateField)
+
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		serviceForBase(baseURL),
+		actionIntraday,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return nil, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	return decodeHeartRate(payload, workout)
+}
+
+// decodeHeartRate reads the epoch-keyed intraday series, keeping non-zero
+// readings inside the workout. Withings returns an empty array instead of
+// an object when there is no data.
+func decodeHeartRate(
+	payload []byte,
+	workout series,
+) ([]heartRateSample, error) {
+	var decoded intradayResponse
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := bytes.TrimSpace(decoded.Body.Series)
+	if len(trimmed) == defaultInt || trimmed[0] != '{' {
+		return nil, nil
+	}
+
+	var byEpoch map[string]intradayValues
+
+	err = json.Unmarshal(trimmed, &byEpoch)
+	if err != nil {
+		return nil, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode intraday series: %w", err),
+		)
+	}
+
+	samples := make([]heartRateSample, defaultInt, len(byEpoch))
+
+	for key, values := range byEpoch {
+		epoch, err := strconv.ParseInt(key, numberBase10, int64BitSize)
+		if err != nil || values.HeartRate <= defaultInt ||
+			epoch < workout.StartDate || epoch > workout.EndDate {
+			continue
+		}
+
+		samples = append(samples, heartRateSample{
+			Time: epoch,
+			BPM:  values.HeartRate,
+		})
+	}
+
+	sort.Slice(samples, func(left, right int) bool {
+		return samples[left].Time < samples[right].Time
+	})
+
+	return samples, nil
+}
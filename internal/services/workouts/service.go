@@ -0,0 +1,587 @@
+// Package workouts handles Withings workout endpoints.
+package workouts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/filters"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/params"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	serviceName       = "v2/measure"
+	serviceShort      = "measure"
+	serviceV2Suffix   = "/v2"
+	actionGet         = "getworkouts"
+	startDateParam    = "startdateymd"
+	endDateParam      = "enddateymd"
+	lastUpdateParam   = "lastupdate"
+	userIDParam       = "userid"
+	offsetParam       = "offset"
+	dataFieldsParam   = "data_fields"
+	dataFields        = "calories,distance,steps,elevation,hr_average,hr_max"
+	categoryDelimiter = ","
+	floatBitSize      = 64
+	numberBase10      = 10
+	rowsHeaderCount   = 1
+	tableMinWidth     = 0
+	tableTabWidth     = 0
+	tablePadding      = 2
+	tablePadChar      = ' '
+	tableFlags        = 0
+	tableHeader       = "Start\tEnd\tDuration\tCategory\tCalories\t" +
+		"Distance\tSteps\tHR Avg\tHR Max\tElevation\tID"
+	plainHeader = "start\tend\tduration\tcategory\tcalories\t" +
+		"distance\tsteps\thr_average\thr_max\televation\tid"
+	defaultInt   = 0
+	defaultInt64 = 0
+	emptyString  = ""
+)
+
+var errInvalidCategory = errors.New("invalid workout category")
+
+// Options captures workout query parameters.
+type Options struct {
+	TimeRange  params.TimeRange
+	Date       params.Date
+	Pagination params.Pagination
+	User       params.User
+	LastUpdate params.LastUpdate
+	Category   string
+	Now        func() time.Time
+}
+
+// Run fetches workouts and writes output.
+func Run(
+	ctx context.Context,
+	opts Options,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	categories, err := parseCategories(opts.Category)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	values, err := buildParams(opts)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	decoded, err := fetchPage(ctx, appOpts, accessToken, values)
+	if err != nil {
+		return err
+	}
+
+	return writeBody(appOpts, filterCategories(decoded.Body, categories))
+}
+
+func fetchPage(
+	ctx context.Context,
+	appOpts app.Options,
+	accessToken string,
+	values url.Values,
+) (response, error) {
+	baseURL := withings.APIBaseURL(appOpts.BaseURL, appOpts.Cloud)
+
+	req, _, err := withings.BuildRequest(
+		ctx,
+		baseURL,
+		serviceForBase(baseURL),
+		actionGet,
+		accessToken,
+		values,
+	)
+	if err != nil {
+		return response{}, fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := withings.NewClient(appOpts).Do(req)
+	if err != nil {
+		return response{}, app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := withings.ReadPayload(resp)
+	if err != nil {
+		return response{}, fmt.Errorf("read response: %w", err)
+	}
+
+	return decodeResponse(payload)
+}
+
+func serviceForBase(baseURL string) string {
+	trimmed := strings.TrimRight(baseURL, "/")
+	if strings.HasSuffix(trimmed, serviceV2Suffix) {
+		return serviceShort
+	}
+
+	return serviceName
+}
+
+func buildParams(opts Options) (url.Values, error) {
+	values := url.Values{}
+
+	nowFunc := opts.Now
+	if nowFunc == nil {
+		nowFunc = time.Now
+	}
+
+	err := applyTimeFilters(
+		&values,
+		opts.Date,
+		opts.TimeRange,
+		opts.LastUpdate,
+		nowFunc,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	applyUser(&values, opts.User)
+	applyOffset(&values, opts.Pagination)
+	values.Set(dataFieldsParam, dataFields)
+
+	return values, nil
+}
+
+func applyTimeFilters(
+	values *url.Values,
+	date params.Date,
+	timeRange params.TimeRange,
+	lastUpdate params.LastUpdate,
+	nowFunc func() time.Time,
+) error {
+	err := filters.ApplyLastUpdateFilter(
+		values,
+		lastUpdateParam,
+		lastUpdate,
+		date,
+		timeRange,
+		errs.ErrInvalidLastUpdate,
+		errs.ErrLastUpdateConflict,
+	)
+	if err != nil {
+		return fmt.Errorf("apply last-update filter: %w", err)
+	}
+
+	if lastUpdate.LastUpdate == defaultInt64 &&
+		date.Date == emptyString &&
+		timeRange.End == emptyString {
+		timeRange.End = nowFunc().UTC().Format(time.RFC3339)
+	}
+
+	dateRange, err := filters.ResolveDateRange(
+		date,
+		timeRange,
+		errs.ErrInvalidStartTime,
+		errs.ErrInvalidEndTime,
+	)
+	if err != nil {
+		return fmt.Errorf("resolve date range: %w", err)
+	}
+
+	filters.ApplyDateRangeParams(
+		values,
+		startDateParam,
+		endDateParam,
+		dateRange,
+	)
+
+	return nil
+}
+
+func applyUser(values *url.Values, user params.User) {
+	if user.UserID == emptyString {
+		return
+	}
+
+	values.Set(userIDParam, user.UserID)
+}
+
+func applyOffset(values *url.Values, pagination params.Pagination) {
+	if pagination.Offset > defaultInt {
+		values.Set(offsetParam, strconv.Itoa(pagination.Offset))
+	}
+}
+
+func parseCategories(raw string) (map[int]bool, error) {
+	if strings.TrimSpace(raw) == emptyString {
+		return nil, nil //nolint:nilnil // No filter means all categories.
+	}
+
+	categories := map[int]bool{}
+
+	for part := range strings.SplitSeq(raw, categoryDelimiter) {
+		trimmed := strings.ToLower(strings.TrimSpace(part))
+		if trimmed == emptyString {
+			continue
+		}
+
+		category, err := resolveCategory(trimmed)
+		if err != nil {
+			return nil, err
+		}
+
+		categories[category] = true
+	}
+
+	return categories, nil
+}
+
+func resolveCategory(value string) (int, error) {
+	if numeric, err := strconv.Atoi(value); err == nil {
+		return numeric, nil
+	}
+
+	normalized := strings.NewReplacer("-", "_", " ", "_").Replace(value)
+
+	category, ok := categoryByName[normalized]
+	if !ok {
+		return defaultInt, fmt.Errorf("%w: %q", errInvalidCategory, value)
+	}
+
+	return category, nil
+}
+
+//nolint:gochecknoglobals // Static lookup tables for workout categories.
+var (
+	categoryNameByID = map[int]string{
+		1:   "walk",
+		2:   "run",
+		3:   "hiking",
+		4:   "skating",
+		5:   "bmx",
+		6:   "bicycling",
+		7:   "swimming",
+		8:   "surfing",
+		9:   "kitesurfing",
+		10:  "windsurfing",
+		11:  "bodyboard",
+		12:  "tennis",
+		13:  "table_tennis",
+		14:  "squash",
+		15:  "badminton",
+		16:  "lift_weights",
+		17:  "calisthenics",
+		18:  "elliptical",
+		19:  "pilates",
+		20:  "basketball",
+		21:  "soccer",
+		22:  "football",
+		23:  "rugby",
+		24:  "volleyball",
+		25:  "waterpolo",
+		26:  "horse_riding",
+		27:  "golf",
+		28:  "yoga",
+		29:  "dancing",
+		30:  "boxing",
+		31:  "fencing",
+		32:  "wrestling",
+		33:  "martial_arts",
+		34:  "skiing",
+		35:  "snowboarding",
+		36:  "other",
+		128: "no_activity",
+		187: "rowing",
+		188: "zumba",
+		191: "baseball",
+		192: "handball",
+		193: "hockey",
+		194: "ice_hockey",
+		195: "climbing",
+		196: "ice_skating",
+		272: "multi_sport",
+		306: "indoor_walk",
+		307: "indoor_running",
+		308: "indoor_cycling",
+	}
+	categoryByName = invertCategories(categoryNameByID)
+)
+
+func invertCategories(byID map[int]string) map[string]int {
+	byName := make(map[string]int, len(byID))
+	for id, name := range byID {
+		byName[name] = id
+	}
+
+	return byName
+}
+
+// CategoryName returns the workout category name for an ID.
+func CategoryName(category int) string {
+	if name, ok := categoryNameByID[category]; ok {
+		return name
+	}
+
+	return strconv.Itoa(category)
+}
+
+// CategoryChoices lists the accepted --category names and their IDs.
+func CategoryChoices() []output.Choice {
+	choices := make([]output.Choice, defaultInt, len(categoryByName))
+	for name, id := range categoryByName {
+		choices = append(choices, output.Choice{
+			Name:  name,
+			Value: strconv.Itoa(id),
+		})
+	}
+
+	return choices
+}
+
+type response struct {
+	Status int    `json:"status"`
+	Body   body   `json:"body"`
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+}
+
+type body struct {
+	Series []series `json:"series"`
+	More   bool     `json:"more"`
+	Offset int      `json:"offset"`
+}
+
+type series struct {
+	ID        int64  `json:"id"`
+	Category  int    `json:"category"`
+	Timezone  string `json:"timezone"`
+	Model     int    `json:"model"`
+	StartDate int64  `json:"startdate"`
+	EndDate   int64  `json:"enddate"`
+	Date      string `json:"date"`
+	DeviceID  string `json:"deviceid"`
+	Data      data   `json:"data"`
+}
+
+//nolint:tagliatelle // Withings API uses snake_case JSON fields.
+type data struct {
+	Calories  float64 `json:"calories"`
+	Distance  float64 `json:"distance"`
+	Steps     float64 `json:"steps"`
+	Elevation float64 `json:"elevation"`
+	HRAverage float64 `json:"hr_average"`
+	HRMax     float64 `json:"hr_max"`
+}
+
+type row struct {
+	Start     string
+	End       string
+	Duration  string
+	Category  string
+	Calories  string
+	Distance  string
+	Steps     string
+	HRAverage string
+	HRMax     string
+	Elevation string
+	ID        string
+}
+
+func filterCategories(body body, categories map[int]bool) body {
+	if len(categories) == defaultInt {
+		return body
+	}
+
+	kept := make([]series, defaultInt, len(body.Series))
+
+	for _, workout := range body.Series {
+		if categories[workout.Category] {
+			kept = append(kept, workout)
+		}
+	}
+
+	body.Series = kept
+
+	return body
+}
+
+func writeBody(opts app.Options, body body) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON && opts.Fields == emptyString {
+		return writeJSONOutput(opts, body)
+	}
+
+	rows := buildRows(body)
+
+	if opts.Plain || opts.NDJSON || opts.JSON {
+		return writePlainOutput(opts, rows)
+	}
+
+	return writeTableOutput(opts, rows)
+}
+
+func writeJSONOutput(opts app.Options, body body) error {
+	err := output.WriteRawJSON(opts, body)
+	if err != nil {
+		return fmt.Errorf("write json output: %w", err)
+	}
+
+	return nil
+}
+
+func writePlainOutput(opts app.Options, rows []row) error {
+	err := output.WritePlain(opts, formatLines(rows))
+	if err != nil {
+		return fmt.Errorf("write plain output: %w", err)
+	}
+
+	return nil
+}
+
+func writeTableOutput(opts app.Options, rows []row) error {
+	table, err := formatTable(opts, rows)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func decodeResponse(payload []byte) (response, error) {
+	var decoded response
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return response{}, app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	err = withings.StatusError(
+		decoded.Status,
+		decoded.Error,
+		decoded.Detail,
+		payload,
+	)
+	if err != nil {
+		return response{}, err
+	}
+
+	return decoded, nil
+}
+
+func buildRows(body body) []row {
+	rows := make([]row, defaultInt, len(body.Series))
+
+	for _, workout := range body.Series {
+		location := workoutLocation(workout.Timezone)
+		rows = append(rows, row{
+			Start:     formatTime(workout.StartDate, location),
+			End:       formatTime(workout.EndDate, location),
+			Duration:  formatInt64(workoutDuration(workout)),
+			Category:  CategoryName(workout.Category),
+			Calories:  formatFloat(workout.Data.Calories),
+			Distance:  formatFloat(workout.Data.Distance),
+			Steps:     formatFloat(workout.Data.Steps),
+			HRAverage: formatFloat(workout.Data.HRAverage),
+			HRMax:     formatFloat(workout.Data.HRMax),
+			Elevation: formatFloat(workout.Data.Elevation),
+			ID:        formatInt64(workout.ID),
+		})
+	}
+
+	return rows
+}
+
+func workoutDuration(workout series) int64 {
+	if workout.EndDate <= workout.StartDate {
+		return defaultInt64
+	}
+
+	return workout.EndDate - workout.StartDate
+}
+
+func workoutLocation(timezone string) *time.Location {
+	if timezone == emptyString {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+func formatTime(epoch int64, location *time.Location) string {
+	if epoch == defaultInt64 {
+		return emptyString
+	}
+
+	return time.Unix(epoch, defaultInt64).In(location).Format(time.RFC3339)
+}
+
+func formatInt64(value int64) string {
+	return strconv.FormatInt(value, numberBase10)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, floatBitSize)
+}
+
+func formatTable(opts app.Options, rows []row) (string, error) {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, tableHeader)
+
+	for _, row := range rows {
+		lines = append(lines, formatRow(row))
+	}
+
+	table, err := output.RenderTable(opts, plainHeader, lines)
+	if err != nil {
+		return emptyString, fmt.Errorf("render workouts table: %w", err)
+	}
+
+	return table, nil
+}
+
+func formatLines(rows []row) []string {
+	lines := make([]string, defaultInt, len(rows)+rowsHeaderCount)
+	lines = append(lines, plainHeader)
+
+	for _, row := range rows {
+		lines = append(lines, formatRow(row))
+	}
+
+	return lines
+}
+
+func formatRow(row row) string {
+	return strings.Join([]string{
+		row.Start,
+		row.End,
+		row.Duration,
+		row.Category,
+		row.Calories,
+		row.Distance,
+		row.Steps,
+		row.HRAverage,
+		row.HRMax,
+		row.Elevation,
+		row.ID,
+	}, "\t")
+}
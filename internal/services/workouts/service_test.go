@@ -0,0 +1,225 @@
+//nolint:testpackage // test unexported helpers.
+package workouts
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/errs"
+	"github.com/mreimbold/withings-cli/internal/params"
+)
+
+const (
+	workoutsTestDate       = "2025-12-30"
+	workoutsTestUserID     = "user-123"
+	workoutsTestOffset     = 5
+	workoutsTestLastUpdate = 100
+	workoutsTestRangeValue = "1"
+	workoutsTestYear       = 2025
+	workoutsTestMonth      = 12
+	workoutsTestDay        = 30
+	workoutsTestHour       = 8
+	workoutsTestStart      = 1767081600
+	workoutsTestEnd        = 1767083400
+	workoutsTestRun        = 2
+	workoutsTestBike       = 6
+	workoutsTestUnknown    = 999
+	workoutsTestCalories   = 312.5
+	workoutsTestEmpty      = ""
+	workoutsTestDefaultInt = 0
+	workoutsTestBuildErr   = "buildParams: %v"
+	workoutsTestErrFmt     = "err got %v want %v"
+	workoutsTestExpectErr  = "expected error"
+)
+
+// TestBuildParamsDate builds date-scoped params.
+func TestBuildParamsDate(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions()
+	opts.Date = params.Date{Date: workoutsTestDate}
+	opts.Pagination.Offset = workoutsTestOffset
+	opts.User = params.User{UserID: workoutsTestUserID}
+
+	values, err := buildParams(opts)
+	if err != nil {
+		t.Fatalf(workoutsTestBuildErr, err)
+	}
+
+	assertValue(t, values.Get(startDateParam), workoutsTestDate, "start")
+	assertValue(t, values.Get(endDateParam), workoutsTestDate, "end")
+	assertValue(t, values.Get(offsetParam), "5", "offset")
+	assertValue(t, values.Get(userIDParam), workoutsTestUserID, "userid")
+	assertValue(t, values.Get(dataFieldsParam), dataFields, "data_fields")
+}
+
+// TestBuildParamsDefaultEnd uses today's date when end is omitted.
+func TestBuildParamsDefaultEnd(t *testing.T) {
+	t.Parallel()
+
+	fixedNow := time.Date(
+		workoutsTestYear,
+		time.Month(workoutsTestMonth),
+		workoutsTestDay,
+		workoutsTestHour,
+		workoutsTestDefaultInt,
+		workoutsTestDefaultInt,
+		workoutsTestDefaultInt,
+		time.UTC,
+	)
+
+	opts := testOptions()
+	opts.Now = func() time.Time { return fixedNow }
+
+	values, err := buildParams(opts)
+	if err != nil {
+		t.Fatalf(workoutsTestBuildErr, err)
+	}
+
+	assertValue(t, values.Get(endDateParam), workoutsTestDate, "end")
+	assertValue(t, values.Get(startDateParam), workoutsTestEmpty, "start")
+}
+
+// TestBuildParamsLastUpdateConflict rejects mixed filters.
+func TestBuildParamsLastUpdateConflict(t *testing.T) {
+	t.Parallel()
+
+	opts := testOptions()
+	opts.TimeRange.Start = workoutsTestRangeValue
+	opts.LastUpdate = params.LastUpdate{LastUpdate: workoutsTestLastUpdate}
+
+	_, err := buildParams(opts)
+	if err == nil {
+		t.Fatal(workoutsTestExpectErr)
+	}
+
+	if !errors.Is(err, errs.ErrLastUpdateConflict) {
+		t.Fatalf(workoutsTestErrFmt, err, errs.ErrLastUpdateConflict)
+	}
+}
+
+// TestParseCategories resolves names, aliases, and numeric IDs.
+func TestParseCategories(t *testing.T) {
+	t.Parallel()
+
+	categories, err := parseCategories("Run, bicycling,indoor-cycling,36")
+	if err != nil {
+		t.Fatalf("parseCategories: %v", err)
+	}
+
+	for _, want := range []int{workoutsTestRun, workoutsTestBike, 308, 36} {
+		if !categories[want] {
+			t.Fatalf("category %d missing from %v", want, categories)
+		}
+	}
+
+	_, err = parseCategories("unicycling")
+	if !errors.Is(err, errInvalidCategory) {
+		t.Fatalf(workoutsTestErrFmt, err, errInvalidCategory)
+	}
+
+	categories, err = parseCategories(workoutsTestEmpty)
+	if err != nil || categories != nil {
+		t.Fatalf("empty filter got %v, %v", categories, err)
+	}
+}
+
+// TestFilterCategories keeps only requested workout categories.
+func TestFilterCategories(t *testing.T) {
+	t.Parallel()
+
+	input := body{
+		Series: []series{
+			testSeries(workoutsTestRun),
+			testSeries(workoutsTestBike),
+		},
+		More:   false,
+		Offset: workoutsTestDefaultInt,
+	}
+
+	filtered := filterCategories(input, map[int]bool{workoutsTestBike: true})
+	if len(filtered.Series) != 1 {
+		t.Fatalf("series got %d want 1", len(filtered.Series))
+	}
+
+	if filtered.Series[0].Category != workoutsTestBike {
+		t.Fatalf(
+			"category got %d want %d",
+			filtered.Series[0].Category,
+			workoutsTestBike,
+		)
+	}
+
+	unfiltered := filterCategories(input, nil)
+	if len(unfiltered.Series) != len(input.Series) {
+		t.Fatalf("unfiltered series got %d", len(unfiltered.Series))
+	}
+}
+
+// TestBuildRows formats workout rows with category names.
+func TestBuildRows(t *testing.T) {
+	t.Parallel()
+
+	rows := buildRows(body{
+		Series: []series{
+			testSeries(workoutsTestRun),
+			testSeries(workoutsTestUnknown),
+		},
+		More:   false,
+		Offset: workoutsTestDefaultInt,
+	})
+
+	assertValue(t, rows[0].Category, "run", "category")
+	assertValue(t, rows[0].Duration, "1800", "duration")
+	assertValue(t, rows[0].Calories, "312.5", "calories")
+	assertValue(t, rows[0].Start, "2025-12-30T08:00:00Z", "start")
+	assertValue(t, rows[1].Category, "999", "unknown category")
+}
+
+func testOptions() Options {
+	return Options{
+		TimeRange: params.TimeRange{
+			Start: workoutsTestEmpty,
+			End:   workoutsTestEmpty,
+		},
+		Date: params.Date{Date: workoutsTestEmpty},
+		Pagination: params.Pagination{
+			Limit:  workoutsTestDefaultInt,
+			Offset: workoutsTestDefaultInt,
+		},
+		User:       params.User{UserID: workoutsTestEmpty},
+		LastUpdate: params.LastUpdate{LastUpdate: workoutsTestDefaultInt},
+		Category:   workoutsTestEmpty,
+		Now:        nil,
+	}
+}
+
+func testSeries(category int) series {
+	return series{
+		ID:        workoutsTestDefaultInt,
+		Category:  category,
+		Timezone:  "UTC",
+		Model:     workoutsTestDefaultInt,
+		StartDate: workoutsTestStart,
+		EndDate:   workoutsTestEnd,
+		Date:      workoutsTestDate,
+		DeviceID:  workoutsTestEmpty,
+		Data: data{
+			Calories:  workoutsTestCalories,
+			Distance:  workoutsTestDefaultInt,
+			Steps:     workoutsTestDefaultInt,
+			Elevation: workoutsTestDefaultInt,
+			HRAverage: workoutsTestDefaultInt,
+			HRMax:     workoutsTestDefaultInt,
+		},
+	}
+}
+
+func assertValue(t *testing.T, got, want, label string) {
+	t.Helper()
+
+	if got != want {
+		t.Fatalf("%s got %q want %q", label, got, want)
+	}
+}
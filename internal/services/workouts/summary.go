@@ -0,0 +1,241 @@
+package workouts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	summaryAllCategory = "all"
+	summaryTableHeader = "Category\tCount\tDuration\tCalories\tDistance"
+	summaryPlainHeader = "category\tcount\tduration\tcalories\tdistance"
+)
+
+// SummaryOptions captures workout summary parameters.
+type SummaryOptions struct {
+	Query  Options
+	ByType bool
+}
+
+//nolint:tagliatelle // Keep snake_case like the Withings API fields.
+type summaryGroup struct {
+	Category   string  `json:"category"`
+	CategoryID int     `json:"category_id,omitempty"`
+	Count      int     `json:"count"`
+	Duration   int64   `json:"duration"`
+	Calories   float64 `json:"calories"`
+	Distance   float64 `json:"distance"`
+}
+
+//nolint:tagliatelle // Keep snake_case like the Withings API fields.
+type summary struct {
+	ByType bool           `json:"by_type"`
+	Groups []summaryGroup `json:"groups"`
+}
+
+// Summary aggregates workouts over a range, optionally per category.
+func Summary(
+	ctx context.Context,
+	opts SummaryOptions,
+	appOpts app.Options,
+	accessToken string,
+) error {
+	categories, err := parseCategories(opts.Query.Category)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	values, err := buildParams(opts.Query)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeUsage, err)
+	}
+
+	var workouts []series
+
+	for {
+		decoded, err := fetchPage(ctx, appOpts, accessToken, values)
+		if err != nil {
+			return err
+		}
+
+		page := filterCategories(decoded.Body, categories)
+		workouts = append(workouts, page.Series...)
+
+		if !decoded.Body.More || !advanceOffset(values, decoded.Body.Offset) {
+			break
+		}
+	}
+
+	return writeSummary(appOpts, summarize(workouts, opts.ByType))
+}
+
+// advanceOffset moves to the next page and reports whether it moved, so
+// a server that repeats an offset cannot loop forever.
+func advanceOffset(values url.Values, next int) bool {
+	current, _ := strconv.Atoi(values.Get(offsetParam))
+	if next <= current {
+		return false
+	}
+
+	values.Set(offsetParam, strconv.Itoa(next))
+
+	return true
+}
+
+func summarize(workouts []series, byType bool) summary {
+	groups := map[int]*summaryGroup{}
+
+	var order []int
+
+	for _, workout := range workouts {
+		key := defaultInt
+		if byType {
+			key = workout.Category
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = newSummaryGroup(key, byType)
+			groups[key] = group
+			order = append(order, key)
+		}
+
+		group.Count++
+		group.Duration += workoutDuration(workout)
+		group.Calories += workout.Data.Calories
+		group.Distance += workout.Data.Distance
+	}
+
+	result := summary{
+		ByType: byType,
+		Groups: make([]summaryGroup, defaultInt, len(order)),
+	}
+
+	for _, key := range order {
+		result.Groups = append(result.Groups, *groups[key])
+	}
+
+	sort.SliceStable(result.Groups, func(left, right int) bool {
+		a, b := result.Groups[left], result.Groups[right]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+
+		return a.Category < b.Category
+	})
+
+	return result
+}
+
+func newSummaryGroup(category int, byType bool) *summaryGroup {
+	if !byType {
+		return &summaryGroup{
+			Category:   summaryAllCategory,
+			CategoryID: defaultInt,
+			Count:      defaultInt,
+			Duration:   defaultInt64,
+			Calories:   defaultInt,
+			Distance:   defaultInt,
+		}
+	}
+
+	return &summaryGroup{
+		Category:   CategoryName(category),
+		CategoryID: category,
+		Count:      defaultInt,
+		Duration:   defaultInt64,
+		Calories:   defaultInt,
+		Distance:   defaultInt,
+	}
+}
+
+func writeSummary(opts app.Options, result summary) error {
+	if opts.Quiet {
+		return nil
+	}
+
+	if opts.JSON {
+		err := output.WriteRawJSON(opts, result)
+		if err != nil {
+			return fmt.Errorf("write json output: %w", err)
+		}
+
+		return nil
+	}
+
+	if opts.Plain || opts.NDJSON {
+		err := output.WritePlain(opts, formatSummaryLines(result))
+		if err != nil {
+			return fmt.Errorf("write plain output: %w", err)
+		}
+
+		return nil
+	}
+
+	table, err := formatSummaryTable(result)
+	if err != nil {
+		return err
+	}
+
+	err = output.WriteLine(opts, table)
+	if err != nil {
+		return fmt.Errorf("write table output: %w", err)
+	}
+
+	return nil
+}
+
+func summaryCells(group summaryGroup) []string {
+	return []string{
+		group.Category,
+		strconv.Itoa(group.Count),
+		formatInt64(group.Duration),
+		formatFloat(group.Calories),
+		formatFloat(group.Distance),
+	}
+}
+
+func formatSummaryLines(result summary) []string {
+	lines := make([]string, defaultInt, len(result.Groups)+rowsHeaderCount)
+	lines = append(lines, summaryPlainHeader)
+
+	for _, group := range result.Groups {
+		lines = append(lines, strings.Join(summaryCells(group), "\t"))
+	}
+
+	return lines
+}
+
+func formatSummaryTable(result summary) (string, error) {
+	var buffer bytes.Buffer
+
+	writer := tabwriter.NewWriter(
+		&buffer,
+		tableMinWidth,
+		tableTabWidth,
+		tablePadding,
+		tablePadChar,
+		tableFlags,
+	)
+	_, _ = fmt.Fprintln(writer, summaryTableHeader)
+
+	for _, group := range result.Groups {
+		_, _ = fmt.Fprintln(writer, strings.Join(summaryCells(group), "\t"))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return emptyString, fmt.Errorf("render workouts summary: %w", err)
+	}
+
+	return strings.TrimRight(buffer.String(), "\n"), nil
+}
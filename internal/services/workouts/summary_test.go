@@ -0,0 +1,74 @@
+//nolint:testpackage // test unexported helpers.
+package workouts
+
+import (
+	"net/url"
+	"testing"
+)
+
+const summaryTestRunCount = 2
+
+// TestSummarizeByType totals per category, longest first.
+func TestSummarizeByType(t *testing.T) {
+	t.Parallel()
+
+	run := testSeries(workoutsTestRun)
+	bike := testSeries(workoutsTestBike)
+	bike.EndDate = bike.StartDate + 1
+
+	result := summarize([]series{bike, run, run}, true)
+
+	if len(result.Groups) != summaryTestRunCount {
+		t.Fatalf("groups got %d want %d", len(result.Groups),
+			summaryTestRunCount)
+	}
+
+	first := result.Groups[0]
+	assertValue(t, first.Category, CategoryName(workoutsTestRun), "category")
+
+	if first.Count != summaryTestRunCount {
+		t.Fatalf("count got %d want %d", first.Count, summaryTestRunCount)
+	}
+
+	wantDuration := summaryTestRunCount * workoutDuration(run)
+	if first.Duration != wantDuration {
+		t.Fatalf("duration got %d want %d", first.Duration, wantDuration)
+	}
+
+	if first.Calories != summaryTestRunCount*workoutsTestCalories {
+		t.Fatalf("calories got %v", first.Calories)
+	}
+}
+
+// TestSummarizeAll folds every workout into one group.
+func TestSummarizeAll(t *testing.T) {
+	t.Parallel()
+
+	result := summarize([]series{
+		testSeries(workoutsTestRun),
+		testSeries(workoutsTestBike),
+	}, false)
+
+	if len(result.Groups) != 1 {
+		t.Fatalf("groups got %d want 1", len(result.Groups))
+	}
+
+	assertValue(t, result.Groups[0].Category, summaryAllCategory, "category")
+}
+
+// TestAdvanceOffset stops when the server does not move forward.
+func TestAdvanceOffset(t *testing.T) {
+	t.Parallel()
+
+	values := url.Values{}
+
+	if !advanceOffset(values, workoutsTestOffset) {
+		t.Fatal("expected offset to advance")
+	}
+
+	assertValue(t, values.Get(offsetParam), "5", "offset")
+
+	if advanceOffset(values, workoutsTestOffset) {
+		t.Fatal("expected repeated offset to stop")
+	}
+}
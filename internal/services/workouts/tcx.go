@@ -0,0 +1,198 @@
+package workouts
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	tcxNamespace = "http://www.garmin.com/xmlschemas/" +
+		"TrainingCenterDatabase/v2"
+	gpxNamespace   = "http://www.topografix.com/GPX/1/1"
+	gpxVersion     = "1.1"
+	exportCreator  = "withings-cli"
+	tcxIntensity   = "Active"
+	tcxTrigger     = "Manual"
+	tcxSportOther  = "Other"
+	xmlIndent      = "  "
+	metersPerKm    = 1000
+	kmPrecision    = 2
+	exportSportRun = "Running"
+	exportSportCyc = "Biking"
+)
+
+type tcxDatabase struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Namespace  string        `xml:"xmlns,attr"`
+	Activities tcxActivities `xml:"Activities"`
+}
+
+type tcxActivities struct {
+	Activity tcxActivity `xml:"Activity"`
+}
+
+type tcxActivity struct {
+	Sport string `xml:"Sport,attr"`
+	ID    string `xml:"Id"`
+	Lap   tcxLap `xml:"Lap"`
+	Notes string `xml:"Notes,omitempty"`
+}
+
+type tcxLap struct {
+	StartTime           string        `xml:"StartTime,attr"`
+	TotalTimeSeconds    int64         `xml:"TotalTimeSeconds"`
+	DistanceMeters      float64       `xml:"DistanceMeters"`
+	Calories            int           `xml:"Calories"`
+	AverageHeartRateBpm *tcxHeartRate `xml:"AverageHeartRateBpm,omitempty"`
+	MaximumHeartRateBpm *tcxHeartRate `xml:"MaximumHeartRateBpm,omitempty"`
+	Intensity           string        `xml:"Intensity"`
+	TriggerMethod       string        `xml:"TriggerMethod"`
+	Track               *tcxTrack     `xml:"Track,omitempty"`
+}
+
+type tcxHeartRate struct {
+	Value int `xml:"Value"`
+}
+
+type tcxTrack struct {
+	Trackpoints []tcxTrackpoint `xml:"Trackpoint"`
+}
+
+type tcxTrackpoint struct {
+	Time         string       `xml:"Time"`
+	HeartRateBpm tcxHeartRate `xml:"HeartRateBpm"`
+}
+
+type gpxFile struct {
+	XMLName   xml.Name    `xml:"gpx"`
+	Namespace string      `xml:"xmlns,attr"`
+	Version   string      `xml:"version,attr"`
+	Creator   string      `xml:"creator,attr"`
+	Metadata  gpxMetadata `xml:"metadata"`
+	Track     gpxTrack    `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Time string `xml:"time"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Desc    string     `xml:"desc"`
+	Type    string     `xml:"type"`
+	Segment gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct{}
+
+// encodeTCX writes the workout as one lap with a heart-rate-only track.
+func encodeTCX(exported exportWorkout) ([]byte, error) {
+	workout := exported.Workout
+	start := exportTime(workout.StartDate).Format(time.RFC3339)
+	average, maximum := heartRateStats(exported)
+
+	lap := tcxLap{
+		StartTime:           start,
+		TotalTimeSeconds:    workoutDuration(workout),
+		DistanceMeters:      workout.Data.Distance,
+		Calories:            int(math.Round(workout.Data.Calories)),
+		AverageHeartRateBpm: tcxBPM(average),
+		MaximumHeartRateBpm: tcxBPM(maximum),
+		Intensity:           tcxIntensity,
+		TriggerMethod:       tcxTrigger,
+		Track:               nil,
+	}
+
+	if len(exported.HeartRate) > defaultInt {
+		track := tcxTrack{
+			Trackpoints: make(
+				[]tcxTrackpoint,
+				defaultInt,
+				len(exported.HeartRate),
+			),
+		}
+
+		for _, sample := range exported.HeartRate {
+			track.Trackpoints = append(track.Trackpoints, tcxTrackpoint{
+				Time:         exportTime(sample.Time).Format(time.RFC3339),
+				HeartRateBpm: tcxHeartRate{Value: sample.BPM},
+			})
+		}
+
+		lap.Track = &track
+	}
+
+	return marshalXML(tcxDatabase{
+		XMLName:   xml.Name{Space: emptyString, Local: emptyString},
+		Namespace: tcxNamespace,
+		Activities: tcxActivities{Activity: tcxActivity{
+			Sport: exportSport(workout.Category).TCX,
+			ID:    start,
+			Lap:   lap,
+			Notes: CategoryName(workout.Category),
+		}},
+	})
+}
+
+// encodeGPX writes a track without points (Withings has no positions);
+// the summary goes into the track description.
+func encodeGPX(exported exportWorkout) ([]byte, error) {
+	workout := exported.Workout
+	name := CategoryName(workout.Category)
+	average, maximum := heartRateStats(exported)
+
+	return marshalXML(gpxFile{
+		XMLName:   xml.Name{Space: emptyString, Local: emptyString},
+		Namespace: gpxNamespace,
+		Version:   gpxVersion,
+		Creator:   exportCreator,
+		Metadata: gpxMetadata{
+			Name: name,
+			Time: exportTime(workout.StartDate).Format(time.RFC3339),
+		},
+		Track: gpxTrack{
+			Name: name,
+			Desc: fmt.Sprintf(
+				"duration %s, distance %s km, calories %s kcal, "+
+					"hr avg %d max %d",
+				formatDuration(workoutDuration(workout)),
+				strconv.FormatFloat(
+					workout.Data.Distance/metersPerKm,
+					'f',
+					kmPrecision,
+					floatBitSize,
+				),
+				formatFloat(math.Round(workout.Data.Calories)),
+				average,
+				maximum,
+			),
+			Type:    name,
+			Segment: gpxSegment{},
+		},
+	})
+}
+
+func marshalXML(document any) ([]byte, error) {
+	encoded, err := xml.MarshalIndent(document, emptyString, xmlIndent)
+	if err != nil {
+		return nil, fmt.Errorf("encode xml: %w", err)
+	}
+
+	return append(append([]byte(xml.Header), encoded...), '\n'), nil
+}
+
+func tcxBPM(value int) *tcxHeartRate {
+	if value <= defaultInt {
+		return nil
+	}
+
+	return &tcxHeartRate{Value: value}
+}
+
+func formatDuration(seconds int64) string {
+	return (time.Duration(seconds) * time.Second).String()
+}
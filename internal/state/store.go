@@ -0,0 +1,333 @@
+// Package state persists local CLI state that must survive (bookmarks,
+// dedupe sets, histories) as named key/value tables in one versioned JSON
+// file.
+//
+// Access is serialized within the process by a mutex and across processes
+// by a lock file next to the store, so concurrent commands never lose each
+// other's writes. Files are replaced atomically.
+//
+// The response cache of package withings is deliberately not kept here:
+// its entries are up to 32 MiB each, would be rewritten with the whole
+// file on every update, and may be lost at any time, so each lives in its
+// own file that is replaced atomically without a lock.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	fileMode     = 0o600
+	dirMode      = 0o700
+	lockSuffix   = ".lock"
+	lockRetry    = 20 * time.Millisecond
+	lockTimeout  = 5 * time.Second
+	staleLockAge = 30 * time.Second
+	tempPattern  = ".state-*"
+)
+
+var (
+	// ErrNewerVersion indicates a store written by a newer CLI release.
+	ErrNewerVersion = errors.New("state store was written by a newer version")
+	// ErrLocked indicates the lock could not be acquired in time.
+	ErrLocked = errors.New("state store is locked")
+)
+
+// CurrentVersion is the schema version written by this release.
+//
+//nolint:gochecknoglobals // Derived from the static migration list.
+var CurrentVersion = len(migrations)
+
+// migrations[i] upgrades a document from version i to i+1. Append new
+// steps; never edit released ones.
+//
+//nolint:gochecknoglobals // Static migration list.
+var migrations = []func(*document) error{
+	// 1: named tables of JSON values.
+	func(doc *document) error {
+		if doc.Tables == nil {
+			doc.Tables = map[string]map[string]json.RawMessage{}
+		}
+
+		return nil
+	},
+}
+
+type document struct {
+	Version int                                   `json:"version"`
+	Tables  map[string]map[string]json.RawMessage `json:"tables"`
+}
+
+// Store is a state file. The zero value is not usable; call Open.
+type Store struct {
+	path string
+	mu   *sync.Mutex
+}
+
+//nolint:gochecknoglobals // Process-wide locks per store path.
+var (
+	pathLocks   = map[string]*sync.Mutex{}
+	pathLocksMu sync.Mutex
+)
+
+// Open returns the store at path. The file is created on the first
+// update.
+func Open(path string) *Store {
+	cleaned := filepath.Clean(path)
+
+	pathLocksMu.Lock()
+	defer pathLocksMu.Unlock()
+
+	lock, ok := pathLocks[cleaned]
+	if !ok {
+		lock = &sync.Mutex{}
+		pathLocks[cleaned] = lock
+	}
+
+	return &Store{path: cleaned, mu: lock}
+}
+
+// Path returns the store file path.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// View runs fn against the current contents without saving.
+func (s *Store) View(fn func(tx *Tx) error) error {
+	return s.run(fn, false)
+}
+
+// Update runs fn with exclusive access and saves its changes when fn
+// returns nil.
+func (s *Store) Update(fn func(tx *Tx) error) error {
+	return s.run(fn, true)
+}
+
+func (s *Store) run(fn func(tx *Tx) error, write bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	release, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	doc, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	tx := &Tx{doc: doc, changed: false}
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	if !write || !tx.changed {
+		return nil
+	}
+
+	return s.save(doc)
+}
+
+// lock creates the lock file exclusively, removing it when it was left
+// behind by a crashed process.
+func (s *Store) lock() (func(), error) {
+	lockPath := s.path + lockSuffix
+
+	err := os.MkdirAll(filepath.Dir(s.path), dirMode)
+	if err != nil {
+		return nil, fmt.Errorf("create state directory: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(
+			lockPath,
+			os.O_CREATE|os.O_EXCL|os.O_WRONLY,
+			fileMode,
+		)
+		if err == nil {
+			_, _ = file.WriteString(strconv.Itoa(os.Getpid()))
+			_ = file.Close()
+
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock state: %w", err)
+		}
+
+		info, statErr := os.Stat(lockPath)
+		if statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			_ = os.Remove(lockPath)
+
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", ErrLocked, lockPath)
+		}
+
+		time.Sleep(lockRetry)
+	}
+}
+
+func (s *Store) load() (*document, error) {
+	doc := &document{Version: 0, Tables: nil}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read state: %w", err)
+	}
+
+	if len(data) > 0 {
+		err = json.Unmarshal(data, doc)
+		if err != nil {
+			return nil, fmt.Errorf("decode state %s: %w", s.path, err)
+		}
+	}
+
+	return doc, migrate(doc)
+}
+
+func migrate(doc *document) error {
+	if doc.Version > CurrentVersion {
+		return fmt.Errorf(
+			"%w (version %d, supported %d)",
+			ErrNewerVersion,
+			doc.Version,
+			CurrentVersion,
+		)
+	}
+
+	for doc.Version < CurrentVersion {
+		err := migrations[doc.Version](doc)
+		if err != nil {
+			return fmt.Errorf("migrate state to %d: %w", doc.Version+1, err)
+		}
+
+		doc.Version++
+	}
+
+	return nil
+}
+
+func (s *Store) save(doc *document) error {
+	encoded, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), tempPattern)
+	if err != nil {
+		return fmt.Errorf("write state: %w", err)
+	}
+
+	_, err = temp.Write(append(encoded, '\n'))
+	closeErr := temp.Close()
+
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(temp.Name(), fileMode)
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), s.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+
+		return fmt.Errorf("write state: %w", err)
+	}
+
+	return nil
+}
+
+// Tx reads and changes the store inside View or Update.
+type Tx struct {
+	doc     *document
+	changed bool
+}
+
+// Get decodes the value stored under key in table into value and reports
+// whether it exists.
+func (t *Tx) Get(table, key string, value any) (bool, error) {
+	raw, ok := t.doc.Tables[table][key]
+	if !ok {
+		return false, nil
+	}
+
+	err := json.Unmarshal(raw, value)
+	if err != nil {
+		return true, fmt.Errorf("decode state %s/%s: %w", table, key, err)
+	}
+
+	return true, nil
+}
+
+// Put stores value under key in table.
+func (t *Tx) Put(table, key string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode state %s/%s: %w", table, key, err)
+	}
+
+	rows, ok := t.doc.Tables[table]
+	if !ok {
+		rows = map[string]json.RawMessage{}
+		t.doc.Tables[table] = rows
+	}
+
+	rows[key] = encoded
+	t.changed = true
+
+	return nil
+}
+
+// Delete removes key from table.
+func (t *Tx) Delete(table, key string) {
+	rows, ok := t.doc.Tables[table]
+	if !ok {
+		return
+	}
+
+	if _, ok = rows[key]; !ok {
+		return
+	}
+
+	delete(rows, key)
+
+	if len(rows) == 0 {
+		delete(t.doc.Tables, table)
+	}
+
+	t.changed = true
+}
+
+// Keys returns the keys of table in sorted order.
+func (t *Tx) Keys(table string) []string {
+	keys := make([]string, 0, len(t.doc.Tables[table]))
+	for key := range t.doc.Tables[table] {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,167 @@
+//nolint:testpackage // test unexported helpers.
+package state
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testTable   = "bookmarks"
+	testKey     = "measures"
+	testWriters = 8
+)
+
+// TestStoreRoundTrip writes, reads, lists, and deletes values.
+func TestStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := Open(filepath.Join(t.TempDir(), "state.json"))
+
+	err := store.Update(func(tx *Tx) error {
+		return tx.Put(testTable, testKey, 1700000000)
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	var got int64
+
+	err = store.View(func(tx *Tx) error {
+		found, err := tx.Get(testTable, testKey, &got)
+		if !found {
+			t.Fatal("value not found")
+		}
+
+		if keys := tx.Keys(testTable); len(keys) != 1 || keys[0] != testKey {
+			t.Fatalf("keys: got %v", keys)
+		}
+
+		return err
+	})
+	if err != nil || got != 1700000000 {
+		t.Fatalf("view: got %d (%v)", got, err)
+	}
+
+	err = store.Update(func(tx *Tx) error {
+		tx.Delete(testTable, testKey)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	err = store.View(func(tx *Tx) error {
+		if len(tx.Keys(testTable)) != 0 {
+			t.Fatal("table not empty")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("view: %v", err)
+	}
+}
+
+// TestStoreConcurrentUpdates keeps every write from parallel updaters,
+// including ones on separate Store values for the same file.
+func TestStoreConcurrentUpdates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	var group sync.WaitGroup
+
+	for index := range testWriters {
+		group.Go(func() {
+			err := Open(path).Update(func(tx *Tx) error {
+				return tx.Put(testTable, strconv.Itoa(index), index)
+			})
+			if err != nil {
+				t.Errorf("update %d: %v", index, err)
+			}
+		})
+	}
+
+	group.Wait()
+
+	err := Open(path).View(func(tx *Tx) error {
+		if keys := tx.Keys(testTable); len(keys) != testWriters {
+			t.Fatalf("got %d keys want %d", len(keys), testWriters)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("view: %v", err)
+	}
+
+	_, err = os.Stat(path + lockSuffix)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("lock file left behind: %v", err)
+	}
+}
+
+// TestStoreVersions migrates old files and refuses newer ones.
+func TestStoreVersions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+
+	err := os.WriteFile(oldPath, []byte(`{"version":0}`), fileMode)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	err = Open(oldPath).Update(func(tx *Tx) error {
+		return tx.Put(testTable, testKey, true)
+	})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	err = os.WriteFile(newPath, []byte(`{"version":999}`), fileMode)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	err = Open(newPath).View(func(*Tx) error { return nil })
+	if !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("got %v want ErrNewerVersion", err)
+	}
+}
+
+// TestStoreStaleLock removes locks left behind by crashed runs.
+func TestStoreStaleLock(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	lockPath := path + lockSuffix
+
+	err := os.WriteFile(lockPath, nil, fileMode)
+	if err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	stale := time.Now().Add(-2 * staleLockAge)
+
+	err = os.Chtimes(lockPath, stale, stale)
+	if err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	err = Open(path).Update(func(tx *Tx) error {
+		return tx.Put(testTable, testKey, 1)
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+}
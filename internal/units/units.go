@@ -0,0 +1,130 @@
+// Package units converts metric display values to the selected unit system.
+package units
+
+import (
+	"math"
+	"strconv"
+)
+
+const (
+	// Metric keeps the API's metric values unchanged.
+	Metric = "metric"
+	// Imperial converts mass to lb, temperature to F, and distances to
+	// mi or ft.
+	Imperial = "imperial"
+	// Mass is a weight in kg.
+	Mass = "mass"
+	// Temperature is a temperature in C.
+	Temperature = "temperature"
+	// Distance is a distance covered in m.
+	Distance = "distance"
+	// Elevation is a climbed height in m.
+	Elevation = "elevation"
+
+	kgPerLb       = 0.45359237
+	metersPerMile = 1609.344
+	metersPerFoot = 0.3048
+	fahrenheitMul = 1.8
+	fahrenheitAdd = 32
+	floatBitSize  = 64
+	floatFormat   = 'f'
+	shortestPrec  = -1
+	massDigits    = 1
+	tempDigits    = 1
+	mileDigits    = 2
+	footDigits    = 0
+)
+
+type conversion struct {
+	Metric   string
+	Imperial string
+	Convert  func(float64) float64
+	Digits   int
+}
+
+//nolint:gochecknoglobals // Static conversion table.
+var conversions = map[string]conversion{
+	Mass: {
+		Metric:   "kg",
+		Imperial: "lb",
+		Convert:  func(kg float64) float64 { return kg / kgPerLb },
+		Digits:   massDigits,
+	},
+	Temperature: {
+		Metric:   "C",
+		Imperial: "F",
+		Convert: func(celsius float64) float64 {
+			return celsius*fahrenheitMul + fahrenheitAdd
+		},
+		Digits: tempDigits,
+	},
+	Distance: {
+		Metric:   "m",
+		Imperial: "mi",
+		Convert:  func(m float64) float64 { return m / metersPerMile },
+		Digits:   mileDigits,
+	},
+	Elevation: {
+		Metric:   "m",
+		Imperial: "ft",
+		Convert:  func(m float64) float64 { return m / metersPerFoot },
+		Digits:   footDigits,
+	},
+}
+
+// Valid reports whether system names a supported unit system.
+func Valid(system string) bool {
+	return system == Metric || system == Imperial
+}
+
+// Label returns the unit label of quantity in system.
+func Label(system, quantity string) string {
+	conv, ok := conversions[quantity]
+	if !ok {
+		return ""
+	}
+
+	if system == Imperial {
+		return conv.Imperial
+	}
+
+	return conv.Metric
+}
+
+// Convert converts a metric value of quantity to system. Imperial values
+// are rounded to the precision the unit is usually displayed with; metric
+// values are returned unchanged.
+func Convert(system, quantity string, value float64) float64 {
+	conv, ok := conversions[quantity]
+	if !ok || system != Imperial {
+		return value
+	}
+
+	scale := math.Pow10(conv.Digits)
+
+	return math.Round(conv.Convert(value)*scale) / scale
+}
+
+// FormatValue converts a formatted metric value. Values that are not
+// numbers, or that need no conversion, are returned as-is.
+func FormatValue(system, quantity, value string) string {
+	if system != Imperial {
+		return value
+	}
+
+	if _, ok := conversions[quantity]; !ok {
+		return value
+	}
+
+	parsed, err := strconv.ParseFloat(value, floatBitSize)
+	if err != nil {
+		return value
+	}
+
+	return strconv.FormatFloat(
+		Convert(system, quantity, parsed),
+		floatFormat,
+		shortestPrec,
+		floatBitSize,
+	)
+}
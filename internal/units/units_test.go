@@ -0,0 +1,51 @@
+//nolint:testpackage // test unexported helpers.
+package units
+
+import "testing"
+
+// TestFormatValue converts and rounds imperial values only.
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		system   string
+		quantity string
+		value    string
+		want     string
+	}{
+		{Imperial, Mass, "72.345", "159.5"},
+		{Imperial, Temperature, "37", "98.6"},
+		{Imperial, Distance, "5000", "3.11"},
+		{Imperial, Elevation, "12.5", "41"},
+		{Imperial, "steps", "1200", "1200"},
+		{Imperial, Mass, "n/a", "n/a"},
+		{Metric, Mass, "72.345", "72.345"},
+	}
+
+	for _, test := range cases {
+		got := FormatValue(test.system, test.quantity, test.value)
+		if got != test.want {
+			t.Fatalf(
+				"%s %s %s got %q want %q",
+				test.system,
+				test.quantity,
+				test.value,
+				got,
+				test.want,
+			)
+		}
+	}
+}
+
+// TestLabel names the unit of each system.
+func TestLabel(t *testing.T) {
+	t.Parallel()
+
+	if got := Label(Imperial, Distance); got != "mi" {
+		t.Fatalf("imperial distance got %q want mi", got)
+	}
+
+	if got := Label(Metric, Temperature); got != "C" {
+		t.Fatalf("metric temperature got %q want C", got)
+	}
+}
@@ -25,7 +25,7 @@ func APIBaseURL(baseOverride, cloud string) string {
 		return strings.TrimRight(baseOverride, "/")
 	}
 
-	if cloud == "us" {
+	if cloud == cloudUS {
 		return apiBaseUS
 	}
 
@@ -47,6 +47,29 @@ func BuildRequest(
 	action string,
 	accessToken string,
 	params url.Values,
+) (*http.Request, string, error) {
+	return NewRequest(
+		ctx,
+		http.MethodPost,
+		baseURL,
+		service,
+		action,
+		accessToken,
+		params,
+	)
+}
+
+// NewRequest constructs an authenticated Withings request. GET and HEAD
+// carry the action and params in the query string; every other method
+// sends them as a form body, which is returned for display.
+func NewRequest(
+	ctx context.Context,
+	method string,
+	baseURL string,
+	service string,
+	action string,
+	accessToken string,
+	params url.Values,
 ) (*http.Request, string, error) {
 	endpoint := ServiceEndpoint(baseURL, service)
 
@@ -59,19 +82,26 @@ func BuildRequest(
 		}
 	}
 
-	body := values.Encode()
+	encoded := values.Encode()
+	body := encoded
 
-	req, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		endpoint,
-		strings.NewReader(body),
-	)
+	var reader io.Reader = strings.NewReader(encoded)
+
+	if method == http.MethodGet || method == http.MethodHead {
+		endpoint += "?" + encoded
+		body = ""
+		reader = http.NoBody
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
 	if err != nil {
 		return nil, "", fmt.Errorf("build api request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", apiContentTypeForm)
+	if body != "" {
+		req.Header.Set("Content-Type", apiContentTypeForm)
+	}
+
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
 	return req, body, nil
@@ -97,6 +127,13 @@ func ReadPayload(resp *http.Response) ([]byte, error) {
 		return nil, app.NewExitError(app.ExitCodeFailure, closeErr)
 	}
 
+	if unsupportedStatus(resp.StatusCode) {
+		return nil, app.NewExitError(
+			app.ExitCodeAPI,
+			fmt.Errorf("%w: %w: %s", ErrAPI, ErrUnsupported, resp.Status),
+		)
+	}
+
 	if resp.StatusCode < http.StatusOK ||
 		resp.StatusCode >= http.StatusMultipleChoices {
 		return nil, app.NewExitError(
@@ -0,0 +1,236 @@
+package withings
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	// EnvCacheHome overrides the cache root, as in the XDG base directory
+	// specification.
+	EnvCacheHome = "XDG_CACHE_HOME"
+
+	defaultCacheRelPath = ".cache"
+	cacheAppDir         = "withings-cli"
+	cacheResponsesDir   = "responses"
+	cacheFileExt        = ".json"
+	cacheTempPattern    = ".response-*"
+	cacheFileMode       = 0o600
+	cacheDirMode        = 0o700
+	cacheKeySeparator   = "\n"
+	cacheMaxSize        = 32 << 20
+	headerCache         = "X-Withings-Cli-Cache"
+	cacheHit            = "hit"
+)
+
+// Cache stores successful responses of read actions on disk for TTL. A
+// nil Cache disables caching.
+//
+// Entries are one file each rather than rows of the state store: they
+// are large and disposable, and concurrent writers of one key store the
+// same response, so an atomic rename is enough and no lock is taken.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	now func() time.Time
+}
+
+// NewCache returns the response cache for --cache-ttl, or nil when the
+// TTL is zero, --no-cache, --fixtures, --record, or --replay is set, or
+// the cache directory is unknown.
+func NewCache(opts app.Options) *Cache {
+	if opts.NoCache || opts.CacheTTL <= noDelay || offline(opts) ||
+		opts.Record != "" {
+		return nil
+	}
+
+	dir, err := CacheDir()
+	if err != nil {
+		return nil
+	}
+
+	return &Cache{
+		Dir: filepath.Join(dir, cacheResponsesDir),
+		TTL: opts.CacheTTL,
+		now: time.Now,
+	}
+}
+
+// CacheDir returns $XDG_CACHE_HOME/withings-cli, falling back to
+// ~/.cache/withings-cli.
+func CacheDir() (string, error) {
+	root := os.Getenv(EnvCacheHome)
+	if !filepath.IsAbs(root) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve home directory: %w", err)
+		}
+
+		root = filepath.Join(home, defaultCacheRelPath)
+	}
+
+	return filepath.Join(root, cacheAppDir), nil
+}
+
+// key identifies req by endpoint, action, exact parameters, and user
+// (through the access token, so a cache is never shared between
+// accounts). It reports false for requests that must not be cached:
+// anything but a read action sent as a form POST.
+func (c *Cache) key(req *http.Request) (string, bool) {
+	if c == nil || req.Method != http.MethodPost || req.GetBody == nil {
+		return "", false
+	}
+
+	reader, err := req.GetBody()
+	if err != nil {
+		return "", false
+	}
+
+	body, err := io.ReadAll(reader)
+	_ = reader.Close()
+
+	if err != nil {
+		return "", false
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil || !IsReadAction(values.Get(apiActionKey)) {
+		return "", false
+	}
+
+	sum := sha256.Sum256([]byte(req.URL.String() + cacheKeySeparator +
+		values.Encode() + cacheKeySeparator + req.Header.Get(headerAuth)))
+
+	return hex.EncodeToString(sum[:]), true
+}
+
+// load returns the cached response for key unless it expired.
+func (c *Cache) load(req *http.Request, key string) (*http.Response, bool) {
+	path := c.path(key)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	if c.now().Sub(info.ModTime()) >= c.TTL {
+		_ = os.Remove(path)
+
+		return nil, false
+	}
+
+	//nolint:gosec // Path is derived from a hex digest.
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	return &http.Response{
+		Status:     "200 " + http.StatusText(http.StatusOK),
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header: http.Header{
+			headerContentType: []string{"application/json"},
+			headerCache:       []string{cacheHit},
+		},
+		Body:             io.NopCloser(bytes.NewReader(body)),
+		ContentLength:    int64(len(body)),
+		TransferEncoding: nil,
+		Close:            false,
+		Uncompressed:     false,
+		Trailer:          nil,
+		Request:          req,
+		TLS:              nil,
+	}, true
+}
+
+// save buffers the body of resp and stores it under key when the request
+// succeeded (HTTP 200 and Withings status 0). Write failures only cost
+// the next call a cache miss, so they are ignored.
+func (c *Cache) save(key string, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	closeErr := resp.Body.Close()
+
+	err = errors.Join(err, closeErr)
+	if err != nil {
+		return nil, fmt.Errorf("read api response: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(body) <= cacheMaxSize && succeeded(body) {
+		_ = c.write(key, body)
+	}
+
+	return resp, nil
+}
+
+func (c *Cache) write(key string, body []byte) error {
+	err := os.MkdirAll(c.Dir, cacheDirMode)
+	if err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	temp, err := os.CreateTemp(c.Dir, cacheTempPattern)
+	if err != nil {
+		return fmt.Errorf("create cache entry: %w", err)
+	}
+
+	_, err = temp.Write(body)
+	err = errors.Join(err, temp.Chmod(cacheFileMode), temp.Close())
+
+	if err == nil {
+		err = os.Rename(temp.Name(), c.path(key))
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+cacheFileExt)
+}
+
+func succeeded(body []byte) bool {
+	status, ok := bodyStatus(body)
+
+	return ok && status == StatusOK
+}
+
+// bodyStatus reads the Withings status of a response envelope; ok is
+// false when body is not one.
+func bodyStatus(body []byte) (int, bool) {
+	var envelope struct {
+		Status *int `json:"status"`
+	}
+
+	err := json.Unmarshal(body, &envelope)
+	if err != nil || envelope.Status == nil {
+		return defaultStatus, false
+	}
+
+	return *envelope.Status, true
+}
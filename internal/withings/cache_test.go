@@ -0,0 +1,211 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	cacheTestTTL  = 10 * time.Minute
+	cacheTestBody = `{"status":0,"body":{}}`
+)
+
+// TestCacheServesReadsWithinTTL answers the second identical read from
+// disk and goes back to the server once the entry expired.
+func TestCacheServesReadsWithinTTL(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+
+	server := cacheTestServer(&hits, cacheTestBody)
+	defer server.Close()
+
+	now := time.Now()
+	client := cacheTestClient(t, &now)
+
+	for range 2 {
+		body := cacheTestDo(t, client, server.URL, "getmeas", "token")
+		if body != cacheTestBody {
+			t.Fatalf("body got %q", body)
+		}
+	}
+
+	if hits.Load() != 1 {
+		t.Fatalf("server hits got %d want 1", hits.Load())
+	}
+
+	now = now.Add(cacheTestTTL + time.Second)
+
+	cacheTestDo(t, client, server.URL, "getmeas", "token")
+
+	if hits.Load() != 2 {
+		t.Fatalf("server hits after expiry got %d want 2", hits.Load())
+	}
+}
+
+// TestCacheSkipsWritesErrorsAndOtherUsers never caches state changes or
+// failed calls and keeps users apart.
+func TestCacheSkipsWritesErrorsAndOtherUsers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		action string
+		body   string
+		token  string
+	}{
+		{name: "write", action: "subscribe", body: cacheTestBody, token: "a"},
+		{name: "status", action: "getmeas", body: `{"status":401}`, token: "a"},
+		{name: "users", action: "getmeas", body: cacheTestBody, token: ""},
+	}
+
+	for _, test := range tests {
+		var hits atomic.Int32
+
+		server := cacheTestServer(&hits, test.body)
+		now := time.Now()
+		client := cacheTestClient(t, &now)
+
+		for index := range 2 {
+			token := test.token
+			if token == "" {
+				token = "user" + string(rune('a'+index))
+			}
+
+			cacheTestDo(t, client, server.URL, test.action, token)
+		}
+
+		server.Close()
+
+		if hits.Load() != 2 {
+			t.Fatalf("%s: server hits got %d want 2", test.name, hits.Load())
+		}
+	}
+}
+
+// TestCacheKeyKeepsExactTimestamps never lets two different ranges
+// share an entry, even when they fall within one TTL.
+func TestCacheKeyKeepsExactTimestamps(t *testing.T) {
+	t.Parallel()
+
+	cache := &Cache{Dir: t.TempDir(), TTL: cacheTestTTL, now: time.Now}
+
+	keyAt := func(end int64) string {
+		req, _, err := BuildRequest(
+			context.Background(),
+			"https://example.com",
+			"measure",
+			"getmeas",
+			"token",
+			url.Values{"enddate": {strconv.FormatInt(end, 10)}},
+		)
+		if err != nil {
+			t.Fatalf("BuildRequest: %v", err)
+		}
+
+		key, ok := cache.key(req)
+		if !ok {
+			t.Fatal("read request is not cacheable")
+		}
+
+		return key
+	}
+
+	if keyAt(1_200_000_000) != keyAt(1_200_000_000) {
+		t.Fatal("identical timestamps got different keys")
+	}
+
+	if keyAt(1_200_000_000) == keyAt(1_200_000_030) {
+		t.Fatal("timestamps within the TTL got the same key")
+	}
+}
+
+// TestNewCacheDisabled returns nil without a TTL or with --no-cache.
+func TestNewCacheDisabled(t *testing.T) {
+	t.Setenv(EnvCacheHome, t.TempDir())
+
+	if NewCache(app.Options{CacheTTL: 0}) != nil {
+		t.Fatal("cache enabled without a TTL")
+	}
+
+	if NewCache(app.Options{CacheTTL: cacheTestTTL, NoCache: true}) != nil {
+		t.Fatal("cache enabled with --no-cache")
+	}
+
+	if NewCache(app.Options{CacheTTL: cacheTestTTL}) == nil {
+		t.Fatal("cache disabled with a TTL")
+	}
+}
+
+func cacheTestServer(hits *atomic.Int32, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			_, _ = io.Copy(io.Discard, req.Body)
+
+			hits.Add(1)
+
+			_, _ = io.WriteString(writer, body)
+		},
+	))
+}
+
+func cacheTestClient(t *testing.T, now *time.Time) *Client {
+	t.Helper()
+
+	var delays []time.Duration
+
+	client := testClient(noRetries, &delays)
+	client.Cache = &Cache{
+		Dir: t.TempDir(),
+		TTL: cacheTestTTL,
+		now: func() time.Time { return *now },
+	}
+
+	return client
+}
+
+func cacheTestDo(
+	t *testing.T,
+	client *Client,
+	target string,
+	action string,
+	token string,
+) string {
+	t.Helper()
+
+	req, _, err := BuildRequest(
+		context.Background(),
+		target,
+		"measure",
+		action,
+		token,
+		url.Values{},
+	)
+	if err != nil {
+		t.Fatalf("BuildRequest: %v", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+
+	return string(body)
+}
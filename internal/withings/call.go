@@ -0,0 +1,99 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	serviceV2Prefix = "v2/"
+	serviceV2Suffix = "/v2"
+)
+
+// Caller sends actions with one access token and decodes their bodies,
+// for commands that combine several endpoints into one view.
+type Caller struct {
+	Options     app.Options
+	AccessToken string
+}
+
+type callResponse struct {
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+	Error  string          `json:"error"`
+}
+
+// Call sends action to service and decodes the response body into
+// target. A non-zero API status is returned as an *APIError.
+func (c Caller) Call(
+	ctx context.Context,
+	service string,
+	action string,
+	values url.Values,
+	target any,
+) error {
+	baseURL := APIBaseURL(c.Options.BaseURL, c.Options.Cloud)
+
+	req, _, err := BuildRequest(
+		ctx,
+		baseURL,
+		ServicePath(baseURL, service),
+		action,
+		c.AccessToken,
+		values,
+	)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := NewClient(c.Options).Do(req)
+	if err != nil {
+		return app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := ReadPayload(resp)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	var decoded callResponse
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	err = StatusError(decoded.Status, decoded.Error, "", payload)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(decoded.Body, target)
+	if err != nil {
+		return app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api body: %w", err),
+		)
+	}
+
+	return nil
+}
+
+// ServicePath drops the v2 prefix of service when the base URL already
+// points at /v2.
+func ServicePath(baseURL, service string) string {
+	if strings.HasSuffix(strings.TrimRight(baseURL, "/"), serviceV2Suffix) {
+		return strings.TrimPrefix(service, serviceV2Prefix)
+	}
+
+	return service
+}
@@ -0,0 +1,79 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	callTestStatus = 293
+	callTestSteps  = 12
+)
+
+// TestCallerCall decodes the body and turns a non-zero status into an
+// *APIError.
+func TestCallerCall(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/v2/measure" {
+				t.Errorf("path got %q", req.URL.Path)
+			}
+
+			if req.FormValue("action") == "fail" {
+				_, _ = writer.Write([]byte(
+					`{"status":293,"error":"invalid params"}`,
+				))
+
+				return
+			}
+
+			_, _ = writer.Write([]byte(`{"status":0,"body":{"steps":12}}`))
+		},
+	))
+	t.Cleanup(server.Close)
+
+	caller := Caller{
+		Options:     app.Options{BaseURL: server.URL + "/v2"},
+		AccessToken: "token",
+	}
+
+	var body struct {
+		Steps int `json:"steps"`
+	}
+
+	err := caller.Call(t.Context(), "v2/measure", "getactivity", nil, &body)
+	if err != nil || body.Steps != callTestSteps {
+		t.Fatalf("got %+v, %v", body, err)
+	}
+
+	err = caller.Call(t.Context(), "v2/measure", "fail", nil, &body)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != callTestStatus {
+		t.Fatalf("got %v want status %d", err, callTestStatus)
+	}
+}
+
+// TestServicePath keeps the v2 prefix unless the base URL ends in /v2.
+func TestServicePath(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		base string
+		want string
+	}{
+		{base: "https://wbsapi.withings.net", want: "v2/sleep"},
+		{base: "http://127.0.0.1:9899/v2/", want: "sleep"},
+	} {
+		if got := ServicePath(test.base, "v2/sleep"); got != test.want {
+			t.Fatalf("%s got %q want %q", test.base, got, test.want)
+		}
+	}
+}
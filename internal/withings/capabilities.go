@@ -0,0 +1,151 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	// CapabilitiesPath is the document a mock or staging server can serve
+	// under --base-url to list the actions it implements.
+	CapabilitiesPath = "capabilities"
+
+	capabilitiesTimeout = 5 * time.Second
+	capabilitiesMaxSize = 1 << 20
+	actionKeySeparator  = " "
+)
+
+// ErrUnsupported marks a service or action the API server does not
+// implement: it answered 404, 405, or 501, or the Withings "unknown
+// action" status.
+var ErrUnsupported = errors.New("not supported by the API server")
+
+// Capabilities lists the actions a --base-url server implements. The zero
+// value (and nil) supports everything, which is what the real API and
+// servers without a capabilities document get.
+type Capabilities struct {
+	actions map[string]bool
+}
+
+// capabilitiesDocument is the probe format, e.g.
+// {"actions": ["measure getmeas", "v2/sleep getsummary"]}.
+type capabilitiesDocument struct {
+	Actions []string `json:"actions"`
+}
+
+//nolint:gochecknoglobals // One probe per base URL and process.
+var capabilitiesCache sync.Map
+
+// Supports reports whether service/action can be called. service uses
+// the canonical name, e.g. "v2/sleep", even when --base-url ends in /v2.
+func (c *Capabilities) Supports(service, action string) bool {
+	if c == nil || c.actions == nil {
+		return true
+	}
+
+	return c.actions[service+actionKeySeparator+action]
+}
+
+// ProbeCapabilities fetches the capabilities document of a --base-url
+// override once per process. Without an override, or when the server has
+// no usable document, every action counts as supported and unsupported
+// ones are only detected from their failures (see Degradable).
+func ProbeCapabilities(ctx context.Context, opts app.Options) *Capabilities {
+	if opts.BaseURL == "" {
+		return &Capabilities{actions: nil}
+	}
+
+	baseURL := APIBaseURL(opts.BaseURL, opts.Cloud)
+	if cached, ok := capabilitiesCache.Load(baseURL); ok {
+		caps, _ := cached.(*Capabilities)
+
+		return caps
+	}
+
+	caps := fetchCapabilities(ctx, opts, baseURL)
+	capabilitiesCache.Store(baseURL, caps)
+
+	return caps
+}
+
+// Degradable reports whether a composite command may skip the part that
+// failed with err instead of failing: only against a --base-url override,
+// --fixtures, or --replay, and only when the server (or fixture
+// directory, or cassette) does not implement the action.
+func Degradable(opts app.Options, err error) bool {
+	return (opts.BaseURL != "" || offline(opts)) &&
+		errors.Is(err, ErrUnsupported)
+}
+
+// offline reports whether responses come from files (--fixtures or
+// --replay) instead of the network.
+func offline(opts app.Options) bool {
+	return opts.Fixtures != "" || opts.Replay != ""
+}
+
+func fetchCapabilities(
+	ctx context.Context,
+	opts app.Options,
+	baseURL string,
+) *Capabilities {
+	unknown := &Capabilities{actions: nil}
+
+	ctx, cancel := context.WithTimeout(ctx, capabilitiesTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		ServiceEndpoint(baseURL, CapabilitiesPath),
+		nil,
+	)
+	if err != nil {
+		return unknown
+	}
+
+	client := NewClient(opts)
+	client.Retries = noRetries
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return unknown
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return unknown
+	}
+
+	var document capabilitiesDocument
+
+	err = json.NewDecoder(
+		io.LimitReader(resp.Body, capabilitiesMaxSize),
+	).Decode(&document)
+	if err != nil || document.Actions == nil {
+		return unknown
+	}
+
+	actions := make(map[string]bool, len(document.Actions))
+	for _, entry := range document.Actions {
+		actions[strings.Join(strings.Fields(entry), actionKeySeparator)] = true
+	}
+
+	return &Capabilities{actions: actions}
+}
+
+// unsupportedStatus reports HTTP statuses that mean the endpoint does not
+// exist on the server.
+func unsupportedStatus(code int) bool {
+	return code == http.StatusNotFound ||
+		code == http.StatusMethodNotAllowed ||
+		code == http.StatusNotImplemented
+}
@@ -0,0 +1,94 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestProbeCapabilities reads the document once per base URL.
+func TestProbeCapabilities(t *testing.T) {
+	t.Parallel()
+
+	var probes atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			probes.Add(1)
+			_, _ = io.WriteString(
+				writer,
+				`{"actions":["measure  getmeas","v2/sleep getsummary"]}`,
+			)
+		},
+	))
+	defer server.Close()
+
+	opts := app.Options{BaseURL: server.URL}
+
+	caps := ProbeCapabilities(t.Context(), opts)
+	if !caps.Supports("measure", "getmeas") ||
+		!caps.Supports("v2/sleep", "getsummary") ||
+		caps.Supports("v2/heart", "list") {
+		t.Fatalf("unexpected capabilities %+v", caps)
+	}
+
+	_ = ProbeCapabilities(t.Context(), opts)
+
+	if probes.Load() != 1 {
+		t.Fatalf("probes got %d want 1", probes.Load())
+	}
+}
+
+// TestProbeCapabilitiesUnknown supports everything without a document.
+func TestProbeCapabilitiesUnknown(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	caps := ProbeCapabilities(t.Context(), app.Options{BaseURL: server.URL})
+	if !caps.Supports("v2/heart", "list") {
+		t.Fatal("missing document should support every action")
+	}
+
+	if !ProbeCapabilities(t.Context(), app.Options{}).Supports("x", "y") {
+		t.Fatal("the real API should support every action")
+	}
+}
+
+// TestUnsupportedErrors marks missing endpoints and unknown actions.
+func TestUnsupportedErrors(t *testing.T) {
+	t.Parallel()
+
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+
+	_, err := ReadPayload(resp)
+	if !errors.Is(err, ErrUnsupported) || !errors.Is(err, ErrAPI) {
+		t.Fatalf("404: got %v", err)
+	}
+
+	unknown := &APIError{Status: statusUnknownAction, Message: "unknown"}
+	if !errors.Is(unknown, ErrUnsupported) {
+		t.Fatal("unknown action should be unsupported")
+	}
+
+	if errors.Is(&APIError{Status: statusInvalidToken}, ErrUnsupported) {
+		t.Fatal("invalid token is not unsupported")
+	}
+
+	opts := app.Options{BaseURL: "http://127.0.0.1:1"}
+	if !Degradable(opts, err) || Degradable(app.Options{}, err) {
+		t.Fatal("only --base-url overrides degrade")
+	}
+}
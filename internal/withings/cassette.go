@@ -0,0 +1,341 @@
+package withings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	cassetteVersion     = 1
+	cassetteFileMode    = 0o600
+	headerSetCookie     = "Set-Cookie"
+	headerContentLength = "Content-Length"
+)
+
+// ErrCassetteVersion marks a --replay file written by an unknown version.
+var ErrCassetteVersion = errors.New("unsupported cassette version")
+
+// cassette is the --record/--replay file: sanitized request/response
+// pairs in the order they happened. Requests keep no host or headers and
+// secrets are redacted like in traces, so cassettes can be attached to
+// bug reports.
+type cassette struct {
+	Version      int           `json:"version"`
+	Interactions []interaction `json:"interactions"`
+}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method  string `json:"method"`
+	Service string `json:"service"`
+	Action  string `json:"action"`
+	Form    string `json:"form"`
+}
+
+type recordedResponse struct {
+	Status     string      `json:"status"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	// Body holds JSON responses as-is and anything else as a string.
+	Body json.RawMessage `json:"body"`
+}
+
+// recorders and players hold one transport per cassette path, so every
+// client of a run appends to (or consumes from) the same cassette.
+//
+//nolint:gochecknoglobals // One cassette per path and process.
+var recorders, players sync.Map
+
+// CheckReplay loads a --replay cassette so a missing or invalid file is
+// reported before any request is made.
+func CheckReplay(path string) error {
+	_, err := loadPlayer(path)
+
+	return err
+}
+
+// recordTransport sends requests to base and appends each sanitized
+// round trip to the cassette, rewriting the file every time so an
+// interrupted run still leaves a usable cassette.
+type recordTransport struct {
+	base http.RoundTripper
+	path string
+	mu   sync.Mutex
+	tape cassette
+}
+
+func recorder(path string, base http.RoundTripper) *recordTransport {
+	fresh := &recordTransport{
+		base: base,
+		path: path,
+		mu:   sync.Mutex{},
+		tape: cassette{Version: cassetteVersion, Interactions: nil},
+	}
+
+	shared, _ := recorders.LoadOrStore(path, fresh)
+	transport, _ := shared.(*recordTransport)
+
+	return transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Keep net errors intact.
+	}
+
+	body, err := peekResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Clone()
+	header.Del(headerSetCookie)
+	// The body is re-indented in the cassette, so its length changes.
+	header.Del(headerContentLength)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tape.Interactions = append(t.tape.Interactions, interaction{
+		Request: request,
+		Response: recordedResponse{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       recordBody(body),
+		},
+	})
+
+	var payload bytes.Buffer
+
+	encoder := json.NewEncoder(&payload)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(t.tape)
+	if err != nil {
+		return nil, fmt.Errorf("encode cassette: %w", err)
+	}
+
+	err = os.WriteFile(t.path, payload.Bytes(), cassetteFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("write cassette: %w", err)
+	}
+
+	return resp, nil
+}
+
+// recordRequest reduces req to its service, action, and redacted form.
+func recordRequest(req *http.Request) (recordedRequest, error) {
+	body, err := peekRequestBody(req)
+	if err != nil {
+		return recordedRequest{}, err
+	}
+
+	form := req.URL.RawQuery
+	if len(body) > 0 {
+		form = string(body)
+	}
+
+	values, _ := url.ParseQuery(form)
+
+	return recordedRequest{
+		Method:  req.Method,
+		Service: strings.Trim(req.URL.Path, "/"),
+		Action:  values.Get(apiActionKey),
+		Form:    redactForm(form),
+	}, nil
+}
+
+func recordBody(body []byte) json.RawMessage {
+	if len(body) > 0 && json.Valid(body) {
+		return redactJSON(body)
+	}
+
+	encoded, _ := json.Marshal(string(body))
+
+	return encoded
+}
+
+// replayTransport answers requests from a cassette. Each interaction is
+// used once: an exact match of the sanitized form wins, otherwise the
+// next unused one for the same service and action, so relative ranges
+// such as --start 7d still replay on a later day. Anything else gets a
+// 404, which callers report as an unsupported endpoint.
+type replayTransport struct {
+	mu   sync.Mutex
+	tape cassette
+	used []bool
+}
+
+func loadPlayer(path string) (*replayTransport, error) {
+	if shared, ok := players.Load(path); ok {
+		transport, _ := shared.(*replayTransport)
+
+		return transport, nil
+	}
+
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+
+	var tape cassette
+
+	err = json.Unmarshal(payload, &tape)
+	if err != nil {
+		return nil, fmt.Errorf("decode cassette %s: %w", path, err)
+	}
+
+	if tape.Version != cassetteVersion {
+		return nil, fmt.Errorf(
+			"%w %d in %s",
+			ErrCassetteVersion,
+			tape.Version,
+			path,
+		)
+	}
+
+	shared, _ := players.LoadOrStore(path, &replayTransport{
+		mu:   sync.Mutex{},
+		tape: tape,
+		used: make([]bool, len(tape.Interactions)),
+	})
+	transport, _ := shared.(*replayTransport)
+
+	return transport, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	request, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	recorded, ok := t.next(request)
+	if !ok {
+		return fixtureResponse(
+			req,
+			http.StatusNotFound,
+			fmt.Sprintf(
+				"404 Not Found (not in cassette: %s %s)",
+				request.Service,
+				request.Action,
+			),
+			nil,
+		), nil
+	}
+
+	body := replayBody(recorded.Body)
+
+	resp := fixtureResponse(req, recorded.StatusCode, recorded.Status, body)
+	resp.Header = recorded.Header.Clone()
+
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+
+	return resp, nil
+}
+
+// replayBody undoes recordBody: strings are unquoted and JSON loses the
+// cassette's indentation.
+func replayBody(raw json.RawMessage) []byte {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return []byte(text)
+	}
+
+	var compact bytes.Buffer
+
+	if json.Compact(&compact, raw) != nil {
+		return raw
+	}
+
+	return compact.Bytes()
+}
+
+// next claims the interaction that answers request.
+func (t *replayTransport) next(
+	request recordedRequest,
+) (recordedResponse, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	match := -1
+
+	for index, candidate := range t.tape.Interactions {
+		if t.used[index] ||
+			candidate.Request.Method != request.Method ||
+			candidate.Request.Service != request.Service ||
+			candidate.Request.Action != request.Action {
+			continue
+		}
+
+		if candidate.Request.Form == request.Form {
+			match = index
+
+			break
+		}
+
+		if match < 0 {
+			match = index
+		}
+	}
+
+	if match < 0 {
+		return recordedResponse{}, false
+	}
+
+	t.used[match] = true
+
+	return t.tape.Interactions[match].Response, true
+}
+
+// replayOrError returns the cassette player, or a transport failing every
+// request when the cassette cannot be loaded (CheckReplay reports that
+// before commands run).
+func replayOrError(path string) http.RoundTripper {
+	transport, err := loadPlayer(path)
+	if err != nil {
+		return failingTransport{err: err}
+	}
+
+	return transport
+}
+
+type failingTransport struct {
+	err error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	return nil, t.err
+}
@@ -0,0 +1,385 @@
+package withings
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+const (
+	// DefaultRetries is the number of retries after the first attempt.
+	DefaultRetries = 2
+	// DefaultRetryBackoff is the delay before the first retry; it doubles
+	// on every further attempt.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultTimeout bounds each request attempt, including reading the
+	// response body.
+	DefaultTimeout     = 30 * time.Second
+	maxRetryDelay      = 30 * time.Second
+	retryBackoffFactor = 2
+	noRetries          = 0
+	noDelay            = time.Duration(0)
+	headerRetryAfter   = "Retry-After"
+	defaultStatus      = -1
+	dialOperation      = "dial"
+)
+
+var errRequestBody = errors.New("request body cannot be replayed")
+
+// retryStatuses are the Withings statuses of an HTTP 200 response that
+// are worth retrying: rate limiting, server timeouts, and unknown server
+// errors.
+//
+//nolint:gochecknoglobals // Static set of transient Withings statuses.
+var retryStatuses = map[int]bool{
+	statusTimeout:         true,
+	statusTooManyRequests: true,
+	statusUnknownError:    true,
+}
+
+//nolint:gochecknoglobals // Shared transport so connections are reused.
+var sharedHTTPClient = &http.Client{
+	Transport:     http.DefaultTransport,
+	CheckRedirect: nil,
+	Jar:           nil,
+	Timeout:       noDelay,
+}
+
+// Client sends API requests over a shared HTTP client, throttles them to
+// the rate limit, and retries read actions on 5xx, 429, transient network
+// failures, and the transient Withings statuses (601 rate limited, 522
+// timeout, 2555 unknown error) that arrive in the body of an HTTP 200,
+// with exponential backoff. Writes are only retried when the connection
+// failed before the request was sent, since the server may have applied
+// them otherwise. Each attempt gets its own Timeout deadline. Read
+// actions are answered from Cache while fresh.
+type Client struct {
+	HTTP    *http.Client
+	Retries int
+	Backoff time.Duration
+	Timeout time.Duration
+	Limiter *Limiter
+	Cache   *Cache
+	Sleep   func(ctx context.Context, delay time.Duration) error
+	Warn    func(message string)
+	// DryRun, when set, receives the request instead of the server; Do
+	// then fails with ErrDryRun.
+	DryRun func(req *http.Request) error
+}
+
+// NewClient builds a Client from the retry, timeout, rate limit, cache,
+// --dry-run, and --verbose settings in opts.
+func NewClient(opts app.Options) *Client {
+	return &Client{
+		HTTP:    HTTPClient(opts),
+		Retries: max(opts.Retries, noRetries),
+		Backoff: max(opts.RetryBackoff, noDelay),
+		Timeout: max(opts.Timeout, noDelay),
+		Limiter: sharedLimiter(opts.RateLimit),
+		Cache:   NewCache(opts),
+		Sleep:   sleepContext,
+		Warn: func(message string) {
+			_ = output.WriteWarning(opts, message)
+		},
+		DryRun: dryRunPrinter(opts),
+	}
+}
+
+// Do sends req, replaying its body on retries. The final response or error
+// is returned unchanged so callers keep their own status handling.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if c.DryRun != nil {
+		err := c.DryRun(req)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, ErrDryRun
+	}
+
+	if state := reauthFrom(req.Context()); state != nil {
+		req = state.current(req)
+	}
+
+	key, cacheable := c.Cache.key(req)
+	if !cacheable {
+		return c.sendAuthorized(req)
+	}
+
+	if resp, ok := c.Cache.load(req, key); ok {
+		return resp, nil
+	}
+
+	resp, err := c.sendAuthorized(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Cache.save(key, resp)
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	retry := retryable
+
+	action, err := requestAction(req)
+	if err != nil || !IsReadAction(action) {
+		retry = retryableUnsent
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		err = c.Limiter.Wait(req.Context(), c.Sleep)
+		if err != nil {
+			return nil, err
+		}
+
+		attemptReq, cancel := c.withDeadline(attemptReq)
+
+		//nolint:bodyclose // Returned to the caller or drained below.
+		resp, err := c.HTTP.Do(attemptReq)
+		if resp != nil {
+			c.checkClock(resp)
+		}
+
+		status := defaultStatus
+		if err == nil && resp.StatusCode == http.StatusOK {
+			status, err = bufferStatus(resp)
+		}
+
+		if attempt >= c.Retries || !retry(req.Context(), resp, err, status) {
+			return c.finish(req, resp, err, cancel)
+		}
+
+		delay := c.retryDelay(attempt, resp)
+
+		if resp != nil && err == nil {
+			drain(resp)
+		}
+
+		cancel()
+
+		err = c.Sleep(req.Context(), delay)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// withDeadline bounds one attempt by Timeout. The returned cancel func
+// must run once the attempt's response is no longer read.
+func (c *Client) withDeadline(
+	req *http.Request,
+) (*http.Request, context.CancelFunc) {
+	if c.Timeout <= noDelay {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.Timeout)
+
+	return req.WithContext(ctx), cancel
+}
+
+// finish hands the final attempt to the caller. The deadline stays
+// active until the body is closed, so slow bodies time out as well.
+func (c *Client) finish(
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	cancel context.CancelFunc,
+) (*http.Response, error) {
+	if err != nil {
+		cancel()
+
+		if errors.Is(err, context.DeadlineExceeded) &&
+			req.Context().Err() == nil {
+			return nil, fmt.Errorf(
+				"request timed out after %s (--timeout): %w",
+				c.Timeout,
+				err,
+			)
+		}
+
+		return nil, err //nolint:wrapcheck // Keep net errors intact.
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+// cancelBody releases the attempt deadline when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases its deadline.
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err //nolint:wrapcheck // Keep body errors intact.
+}
+
+func (c *Client) checkClock(resp *http.Response) {
+	warning := clock.observe(resp, time.Now())
+	if warning != "" && c.Warn != nil {
+		c.Warn(warning)
+	}
+}
+
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errRequestBody
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("replay request body: %w", err)
+	}
+
+	clone := req.Clone(req.Context())
+	clone.Body = body
+
+	return clone, nil
+}
+
+// bufferStatus reads the body of resp within the attempt and restores it
+// for the caller, returning the Withings status it carries.
+func bufferStatus(resp *http.Response) (int, error) {
+	body, err := io.ReadAll(resp.Body)
+	closeErr := resp.Body.Close()
+
+	err = errors.Join(err, closeErr)
+	if err != nil {
+		return defaultStatus, fmt.Errorf("read api response: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	status, _ := bodyStatus(body)
+
+	return status, nil
+}
+
+// retryable reports whether an attempt failed transiently: a network
+// error, HTTP 429 or 5xx, or a transient Withings body status.
+func retryable(
+	ctx context.Context,
+	resp *http.Response,
+	err error,
+	status int,
+) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		var netErr net.Error
+
+		return errors.As(err, &netErr) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError ||
+		retryStatuses[status]
+}
+
+// retryableUnsent reports whether an attempt failed before the request
+// left the client, which is the only failure a write can safely retry.
+func retryableUnsent(
+	ctx context.Context,
+	_ *http.Response,
+	err error,
+	_ int,
+) bool {
+	if ctx.Err() != nil || err == nil {
+		return false
+	}
+
+	var (
+		dnsErr *net.DNSError
+		opErr  *net.OpError
+	)
+
+	return errors.As(err, &dnsErr) ||
+		errors.As(err, &opErr) && opErr.Op == dialOperation
+}
+
+// retryDelay prefers a server Retry-After over exponential backoff.
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(
+			resp.Header.Get(headerRetryAfter),
+			time.Now(),
+		); ok {
+			return min(delay, maxRetryDelay)
+		}
+	}
+
+	delay := c.Backoff
+	for range attempt {
+		delay *= retryBackoffFactor
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+
+	return delay
+}
+
+// parseRetryAfter reads delta-seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return noDelay, false
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return max(time.Duration(seconds)*time.Second, noDelay), true
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return noDelay, false
+	}
+
+	return max(at.Sub(now), noDelay), true
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("wait before request: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,449 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	clientTestBody     = "action=getmeas"
+	clientTestAttempts = 3
+	clientTestBackoff  = 100 * time.Millisecond
+	clientTestTimeout  = 50 * time.Millisecond
+)
+
+// TestClientRetriesServerErrors replays the body until a 200 arrives.
+func TestClientRetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+			if string(body) != clientTestBody {
+				t.Errorf("body got %q want %q", body, clientTestBody)
+			}
+
+			if attempts.Add(1) < clientTestAttempts {
+				writer.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			writer.WriteHeader(http.StatusOK)
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	client := testClient(DefaultRetries, &delays)
+
+	resp, err := client.Do(testRequest(t, server.URL))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status got %d want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	want := []time.Duration{clientTestBackoff, 2 * clientTestBackoff}
+	if len(delays) != len(want) || delays[0] != want[0] ||
+		delays[1] != want[1] {
+		t.Fatalf("delays got %v want %v", delays, want)
+	}
+}
+
+// TestClientHonorsRetryAfter uses the server delay for 429 responses.
+func TestClientHonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			if attempts.Add(1) == 1 {
+				writer.Header().Set(headerRetryAfter, "7")
+				writer.WriteHeader(http.StatusTooManyRequests)
+
+				return
+			}
+
+			writer.WriteHeader(http.StatusOK)
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	resp, err := testClient(1, &delays).Do(testRequest(t, server.URL))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if len(delays) != 1 || delays[0] != 7*time.Second {
+		t.Fatalf("delays got %v want [7s]", delays)
+	}
+}
+
+// TestClientNoRetryOnClientError returns 4xx responses immediately.
+func TestClientNoRetryOnClientError(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			attempts.Add(1)
+			writer.WriteHeader(http.StatusBadRequest)
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	resp, err := testClient(DefaultRetries, &delays).Do(
+		testRequest(t, server.URL),
+	)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if attempts.Load() != 1 {
+		t.Fatalf("attempts got %d want 1", attempts.Load())
+	}
+}
+
+// TestClientRetriesBodyStatuses retries the transient Withings statuses
+// sent with HTTP 200 and hands the final body to the caller intact.
+func TestClientRetriesBodyStatuses(t *testing.T) {
+	t.Parallel()
+
+	for _, status := range []int{
+		statusTooManyRequests,
+		statusTimeout,
+		statusUnknownError,
+	} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(
+				func(writer http.ResponseWriter, _ *http.Request) {
+					if attempts.Add(1) < clientTestAttempts {
+						_, _ = fmt.Fprintf(writer, `{"status":%d}`, status)
+
+						return
+					}
+
+					_, _ = io.WriteString(writer, `{"status":0,"body":{}}`)
+				},
+			))
+			defer server.Close()
+
+			var delays []time.Duration
+
+			resp, err := testClient(DefaultRetries, &delays).Do(
+				testRequest(t, server.URL),
+			)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if string(body) != `{"status":0,"body":{}}` {
+				t.Fatalf("body got %q", body)
+			}
+
+			if attempts.Load() != clientTestAttempts ||
+				len(delays) != clientTestAttempts-1 {
+				t.Fatalf(
+					"attempts got %d delays %v",
+					attempts.Load(),
+					delays,
+				)
+			}
+		})
+	}
+}
+
+// TestClientKeepsLastBodyStatus returns the final transient status once
+// the retries are used up.
+func TestClientKeepsLastBodyStatus(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			attempts.Add(1)
+			_, _ = fmt.Fprintf(writer, `{"status":%d}`, statusTooManyRequests)
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	resp, err := testClient(1, &delays).Do(testRequest(t, server.URL))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if string(body) != `{"status":601}` || attempts.Load() != 2 {
+		t.Fatalf("body %q after %d attempts", body, attempts.Load())
+	}
+}
+
+// TestClientNoRetryOnWrite sends setmeas once, whether the server fails
+// with 5xx or a transient body status, so a write is never applied twice.
+func TestClientNoRetryOnWrite(t *testing.T) {
+	t.Parallel()
+
+	for _, respond := range []func(http.ResponseWriter){
+		func(writer http.ResponseWriter) {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+		},
+		func(writer http.ResponseWriter) {
+			_, _ = fmt.Fprintf(writer, `{"status":%d}`, statusUnknownError)
+		},
+	} {
+		var attempts atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(
+			func(writer http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+				respond(writer)
+			},
+		))
+
+		var delays []time.Duration
+
+		resp, err := testClient(DefaultRetries, &delays).Do(
+			testActionRequest(t, server.URL, "setmeas"),
+		)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+
+		_ = resp.Body.Close()
+
+		server.Close()
+
+		if attempts.Load() != 1 || len(delays) != 0 {
+			t.Fatalf("attempts got %d delays %v", attempts.Load(), delays)
+		}
+	}
+}
+
+// TestClientRetriesUnsentWrite retries a write whose connection was
+// refused, since it never reached the server.
+func TestClientRetriesUnsentWrite(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	target := server.URL
+	server.Close()
+
+	var delays []time.Duration
+
+	//nolint:bodyclose // The request fails before a response exists.
+	_, err := testClient(DefaultRetries, &delays).Do(
+		testActionRequest(t, target, "setmeas"),
+	)
+	if err == nil || len(delays) != DefaultRetries {
+		t.Fatalf("err %v delays %v", err, delays)
+	}
+}
+
+// TestParseRetryAfterDate accepts HTTP dates.
+func TestParseRetryAfterDate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+	value := now.Add(time.Minute).Format(http.TimeFormat)
+
+	delay, ok := parseRetryAfter(value, now)
+	if !ok || delay != time.Minute {
+		t.Fatalf("delay got %v, %t want 1m", delay, ok)
+	}
+}
+
+// TestClientTimeoutPerAttempt retries attempts that exceed the deadline
+// and keeps the deadline open while the caller reads the body.
+func TestClientTimeoutPerAttempt(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			_, _ = io.Copy(io.Discard, req.Body)
+
+			if attempts.Add(1) == 1 {
+				<-req.Context().Done()
+
+				return
+			}
+
+			_, _ = io.WriteString(writer, clientTestBody)
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	client := testClient(1, &delays)
+	client.Timeout = clientTestTimeout
+
+	resp, err := client.Do(testRequest(t, server.URL))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil || string(body) != clientTestBody {
+		t.Fatalf("body got %q, %v", body, err)
+	}
+
+	if attempts.Load() != 2 {
+		t.Fatalf("attempts got %d want 2", attempts.Load())
+	}
+}
+
+// TestClientTimeoutError names the timeout once retries run out.
+func TestClientTimeoutError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, req *http.Request) {
+			// Read the body so the server notices the client hanging up.
+			_, _ = io.Copy(io.Discard, req.Body)
+			<-req.Context().Done()
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	client := testClient(noRetries, &delays)
+	client.Timeout = clientTestTimeout
+
+	resp, err := client.Do(testRequest(t, server.URL))
+	if err == nil {
+		_ = resp.Body.Close()
+
+		t.Fatal("expected a timeout")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) ||
+		!strings.Contains(err.Error(), "--timeout") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+// TestClientDryRun hands the request to the hook and never sends it.
+func TestClientDryRun(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(_ http.ResponseWriter, _ *http.Request) {
+			t.Error("dry run reached the server")
+		},
+	))
+	defer server.Close()
+
+	var (
+		delays    []time.Duration
+		described []string
+	)
+
+	client := testClient(noRetries, &delays)
+	client.DryRun = func(req *http.Request) error {
+		lines, err := DescribeRequest(req)
+		described = lines
+
+		return err
+	}
+
+	resp, err := client.Do(testRequest(t, server.URL))
+	if err == nil {
+		_ = resp.Body.Close()
+	}
+
+	if !errors.Is(err, ErrDryRun) {
+		t.Fatalf("got %v want ErrDryRun", err)
+	}
+
+	want := []string{"POST " + server.URL + "/measure", clientTestBody}
+	if strings.Join(described, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("described %q want %q", described, want)
+	}
+}
+
+func testClient(retries int, delays *[]time.Duration) *Client {
+	return &Client{
+		HTTP:    sharedHTTPClient,
+		Retries: retries,
+		Backoff: clientTestBackoff,
+		Timeout: noDelay,
+		Limiter: nil,
+		Sleep: func(_ context.Context, delay time.Duration) error {
+			*delays = append(*delays, delay)
+
+			return nil
+		},
+		Warn:   nil,
+		DryRun: nil,
+	}
+}
+
+func testRequest(t *testing.T, target string) *http.Request {
+	t.Helper()
+
+	return testActionRequest(t, target, "getmeas")
+}
+
+func testActionRequest(t *testing.T, target, action string) *http.Request {
+	t.Helper()
+
+	req, _, err := BuildRequest(
+		context.Background(),
+		target,
+		"measure",
+		action,
+		"token",
+		url.Values{},
+	)
+	if err != nil {
+		t.Fatalf("BuildRequest: %v", err)
+	}
+
+	return req
+}
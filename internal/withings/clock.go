@@ -0,0 +1,78 @@
+package withings
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// ClockSkewThreshold is the local/server clock difference that triggers
+	// a warning. The Date header only has one-second resolution.
+	ClockSkewThreshold = time.Minute
+	headerDate         = "Date"
+)
+
+//nolint:gochecknoglobals // Process-wide measurement shared by all clients.
+var clock = &clockState{
+	mu:       sync.Mutex{},
+	skew:     noDelay,
+	measured: false,
+	warned:   false,
+}
+
+type clockState struct {
+	mu       sync.Mutex
+	skew     time.Duration
+	measured bool
+	warned   bool
+}
+
+// ClockSkew returns how far the server clock is ahead of the local clock
+// (negative when the local clock runs ahead), as measured from the Date
+// header of the most recent API response. ok is false until a response
+// with a Date header has been seen.
+func ClockSkew() (time.Duration, bool) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	return clock.skew, clock.measured
+}
+
+// observe records the skew for resp and returns a warning the first time
+// it exceeds ClockSkewThreshold.
+func (c *clockState) observe(resp *http.Response, local time.Time) string {
+	server, err := http.ParseTime(resp.Header.Get(headerDate))
+	if err != nil {
+		return ""
+	}
+
+	skew := server.Sub(local).Round(time.Second)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.skew = skew
+	c.measured = true
+
+	if c.warned || (skew < ClockSkewThreshold && -skew < ClockSkewThreshold) {
+		return ""
+	}
+
+	c.warned = true
+
+	return fmt.Sprintf(
+		"warning: local clock differs from the Withings API by %s; "+
+			"token expiry and time filters may be off",
+		formatSkew(skew),
+	)
+}
+
+func formatSkew(skew time.Duration) string {
+	if skew < noDelay {
+		return (-skew).String() + " (local clock ahead)"
+	}
+
+	return skew.String() + " (local clock behind)"
+}
@@ -0,0 +1,65 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestClockObserveWarnsOnce records skew and warns once past the threshold.
+func TestClockObserveWarnsOnce(t *testing.T) {
+	t.Parallel()
+
+	state := &clockState{
+		mu:       sync.Mutex{},
+		skew:     noDelay,
+		measured: false,
+		warned:   false,
+	}
+	server := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+
+	warning := state.observe(testDateResponse(server), server.Add(time.Second))
+	if warning != "" || state.skew != -time.Second || !state.measured {
+		t.Fatalf("small skew got %q, %v", warning, state.skew)
+	}
+
+	local := server.Add(-5 * time.Minute)
+
+	warning = state.observe(testDateResponse(server), local)
+	if !strings.Contains(warning, "5m0s (local clock behind)") {
+		t.Fatalf("warning got %q", warning)
+	}
+
+	if warning = state.observe(testDateResponse(server), local); warning != "" {
+		t.Fatalf("second warning got %q", warning)
+	}
+}
+
+// TestClockObserveIgnoresMissingDate keeps the previous measurement.
+func TestClockObserveIgnoresMissingDate(t *testing.T) {
+	t.Parallel()
+
+	state := &clockState{
+		mu:       sync.Mutex{},
+		skew:     time.Second,
+		measured: true,
+		warned:   false,
+	}
+
+	//nolint:exhaustruct // Only headers are read.
+	warning := state.observe(&http.Response{Header: http.Header{}}, time.Now())
+	if warning != "" || state.skew != time.Second {
+		t.Fatalf("missing date got %q, %v", warning, state.skew)
+	}
+}
+
+func testDateResponse(server time.Time) *http.Response {
+	header := http.Header{}
+	header.Set(headerDate, server.Format(http.TimeFormat))
+
+	//nolint:exhaustruct // Only headers are read.
+	return &http.Response{Header: header}
+}
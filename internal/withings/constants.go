@@ -1,6 +1,8 @@
 package withings
 
 const (
+	cloudEU   = "eu"
+	cloudUS   = "us"
 	apiBaseEU = "https://wbsapi.withings.net"
 	apiBaseUS = "https://wbsapi.us.withingsmed.net"
 )
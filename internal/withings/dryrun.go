@@ -0,0 +1,65 @@
+package withings
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+)
+
+// ErrDryRun stops a command at its first request under --dry-run. The
+// request was printed, so the command succeeded.
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// dryRunPrinter returns the Client.DryRun hook for --dry-run, or nil.
+func dryRunPrinter(opts app.Options) func(req *http.Request) error {
+	if !opts.DryRun {
+		return nil
+	}
+
+	return func(req *http.Request) error {
+		lines, err := DescribeRequest(req)
+		if err != nil {
+			return err
+		}
+
+		err = output.WriteOutput(opts, lines)
+		if err != nil {
+			return fmt.Errorf("write dry run output: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// DescribeRequest renders req as its request line ("POST <url>") and,
+// when it has one, the form body. Headers are left out, so the access
+// token never shows up.
+func DescribeRequest(req *http.Request) ([]string, error) {
+	lines := []string{req.Method + " " + req.URL.String()}
+
+	if req.GetBody == nil {
+		return lines, nil
+	}
+
+	reader, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+
+	if len(body) > 0 {
+		lines = append(lines, string(body))
+	}
+
+	return lines, nil
+}
@@ -1,6 +1,114 @@
 package withings
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
 
 // ErrAPI indicates a non-success response from the Withings API.
 var ErrAPI = errors.New("withings API error")
+
+// Withings statuses returned for tokens the API does not accept, which is
+// also what a token issued by the other cloud looks like.
+const (
+	statusAuthFailedFirst = 100
+	statusAuthFailedMid   = 101
+	statusAuthFailedLast  = 102
+	statusAuthFailed      = 200
+	statusInvalidToken    = 401
+	statusUnknownAction   = 2554
+)
+
+// APIError carries a non-zero Withings status and its message.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+// Error explains known statuses, e.g. "withings API error: token expired
+// or invalid - run `withings auth refresh` (status 401: invalid token)",
+// and formats others like "withings API error: 503: invalid params".
+func (e *APIError) Error() string {
+	info, ok := statusTable[e.Status]
+	if !ok {
+		return fmt.Sprintf("%s: %d: %s", ErrAPI, e.Status, e.Message)
+	}
+
+	if e.Message == "" {
+		return fmt.Sprintf("%s: %s (status %d)", ErrAPI, info.Message, e.Status)
+	}
+
+	return fmt.Sprintf(
+		"%s: %s (status %d: %s)",
+		ErrAPI,
+		info.Message,
+		e.Status,
+		e.Message,
+	)
+}
+
+// ExitCode maps the status to an exit code; statuses without a specific
+// meaning exit with the API error code.
+func (e *APIError) ExitCode() int {
+	info, ok := statusTable[e.Status]
+	if !ok {
+		return app.ExitCodeAPI
+	}
+
+	return info.ExitCode
+}
+
+// Unwrap lets errors.Is match ErrAPI.
+func (e *APIError) Unwrap() error {
+	return ErrAPI
+}
+
+// Is lets errors.Is match ErrUnsupported for the "unknown action"
+// status.
+func (e *APIError) Is(target error) bool {
+	return target == ErrUnsupported && e.Status == statusUnknownAction
+}
+
+// AuthRejected reports whether the status means the token was rejected,
+// the usual symptom of an account that lives on the other cloud.
+func (e *APIError) AuthRejected() bool {
+	if e.Status >= statusAuthFailedFirst && e.Status <= statusAuthFailedLast {
+		return true
+	}
+
+	return e.Status == statusAuthFailed || e.Status == statusInvalidToken
+}
+
+// CloudHint suggests retrying on the other cloud when err is an API error
+// that rejected the token, or returns "" when no hint applies.
+func CloudHint(err error, cloud string) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.AuthRejected() {
+		return ""
+	}
+
+	other := cloudUS
+	if cloud == cloudUS {
+		other = cloudEU
+	}
+
+	return fmt.Sprintf(
+		"hint: the %s cloud rejected this token; if your Withings account "+
+			"is on the %s cloud, retry with --cloud %s "+
+			"(log in again with `withings auth login --cloud %s` if needed)",
+		defaultIfEmpty(cloud, cloudEU),
+		other,
+		other,
+		other,
+	)
+}
+
+func defaultIfEmpty(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,115 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestAPIErrorKeepsMessage matches ErrAPI and explains known statuses
+// next to the original wording.
+func TestAPIErrorKeepsMessage(t *testing.T) {
+	t.Parallel()
+
+	err := error(&APIError{Status: statusInvalidToken, Message: "invalid"})
+	if !errors.Is(err, ErrAPI) {
+		t.Fatal("APIError does not match ErrAPI")
+	}
+
+	want := "withings API error: token expired or invalid - run " +
+		"`withings auth refresh` (status 401: invalid)"
+	if err.Error() != want {
+		t.Fatalf("message got %q", err.Error())
+	}
+
+	err = &APIError{Status: 9999, Message: "odd"}
+	if err.Error() != "withings API error: 9999: odd" {
+		t.Fatalf("unknown status message got %q", err.Error())
+	}
+}
+
+// TestAPIErrorExitCode maps statuses to exit codes.
+func TestAPIErrorExitCode(t *testing.T) {
+	t.Parallel()
+
+	cases := map[int]int{
+		statusInvalidToken:     app.ExitCodeAuth,
+		statusAuthFailed:       app.ExitCodeAuth,
+		statusBadParams:        app.ExitCodeUsage,
+		statusTooManyRequests:  app.ExitCodeAPI,
+		statusServiceUndefined: app.ExitCodeAPI,
+		9999:                   app.ExitCodeAPI,
+	}
+
+	for status, want := range cases {
+		got := (&APIError{Status: status, Message: "x"}).ExitCode()
+		if got != want {
+			t.Fatalf("status %d exit code got %d want %d", status, got, want)
+		}
+	}
+}
+
+// TestCloudHint suggests the other cloud for rejected tokens only.
+func TestCloudHint(t *testing.T) {
+	t.Parallel()
+
+	rejected := app.NewExitError(
+		app.ExitCodeAPI,
+		fmt.Errorf("wrapped: %w", &APIError{Status: 101, Message: "x"}),
+	)
+
+	hint := CloudHint(rejected, cloudEU)
+	if !strings.Contains(hint, "--cloud us") {
+		t.Fatalf("eu hint got %q", hint)
+	}
+
+	hint = CloudHint(rejected, cloudUS)
+	if !strings.Contains(hint, "--cloud eu") {
+		t.Fatalf("us hint got %q", hint)
+	}
+
+	other := &APIError{Status: 503, Message: "invalid params"}
+	if hint = CloudHint(other, cloudEU); hint != "" {
+		t.Fatalf("hint for 503 got %q", hint)
+	}
+
+	if hint = CloudHint(ErrAPI, cloudEU); hint != "" {
+		t.Fatalf("hint for plain error got %q", hint)
+	}
+}
+
+// TestStatusErrorMessage falls back from error to detail to payload.
+func TestStatusErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	if err := StatusError(StatusOK, "", "", nil); err != nil {
+		t.Fatalf("ok status got %v", err)
+	}
+
+	for _, test := range []struct {
+		errText string
+		detail  string
+		want    string
+	}{
+		{errText: "bad", detail: "more", want: "bad"},
+		{errText: "", detail: "more", want: "more"},
+		{errText: "", detail: "", want: "raw"},
+	} {
+		err := StatusError(statusInvalidToken, test.errText, test.detail,
+			[]byte(" raw\n"))
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Message != test.want {
+			t.Fatalf("got %v want message %q", err, test.want)
+		}
+
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeAPI {
+			t.Fatalf("got %v want exit code %d", err, app.ExitCodeAPI)
+		}
+	}
+}
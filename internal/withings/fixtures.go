@@ -0,0 +1,102 @@
+package withings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fixtureExtension   = ".json"
+	contentTypeJSON    = "application/json"
+	fixtureUnknownName = "unknown"
+)
+
+// fixtureTransport answers requests from canned JSON files instead of the
+// network: the response to service "v2/measure" action "getactivity" is
+// <dir>/v2/measure/getactivity.json. Requests without a fixture get a 404,
+// which callers report as an unsupported endpoint.
+type fixtureTransport struct {
+	dir string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	action, err := requestAction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	service := strings.Trim(req.URL.Path, "/")
+	name := path.Join(service, action+fixtureExtension)
+
+	payload, err := os.ReadFile(filepath.Join(t.dir, filepath.FromSlash(name)))
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fixtureResponse(
+			req,
+			http.StatusNotFound,
+			fmt.Sprintf("404 Not Found (no fixture %s)", name),
+			nil,
+		), nil
+	case err != nil:
+		return nil, fmt.Errorf("read fixture: %w", err)
+	}
+
+	return fixtureResponse(req, http.StatusOK, "200 OK", payload), nil
+}
+
+// requestAction reads the action from the query string or the form body.
+func requestAction(req *http.Request) (string, error) {
+	if action := req.URL.Query().Get(apiActionKey); action != "" {
+		return action, nil
+	}
+
+	body, err := peekRequestBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil || values.Get(apiActionKey) == "" {
+		return fixtureUnknownName, nil //nolint:nilerr // No action: 404.
+	}
+
+	return values.Get(apiActionKey), nil
+}
+
+func fixtureResponse(
+	req *http.Request,
+	code int,
+	status string,
+	payload []byte,
+) *http.Response {
+	header := http.Header{}
+	header.Set(headerContentType, contentTypeJSON)
+
+	return &http.Response{
+		Status:           status,
+		StatusCode:       code,
+		Proto:            req.Proto,
+		ProtoMajor:       req.ProtoMajor,
+		ProtoMinor:       req.ProtoMinor,
+		Header:           header,
+		Body:             io.NopCloser(bytes.NewReader(payload)),
+		ContentLength:    int64(len(payload)),
+		TransferEncoding: nil,
+		Close:            false,
+		Uncompressed:     false,
+		Trailer:          nil,
+		Request:          req,
+		TLS:              nil,
+	}
+}
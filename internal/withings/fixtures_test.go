@@ -0,0 +1,80 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFixtureTransport serves fixtures by service/action and 404s misses.
+func TestFixtureTransport(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	want := `{"status":0,"body":{}}`
+
+	err := os.MkdirAll(filepath.Join(dir, "measure"), 0o750)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(
+		filepath.Join(dir, "measure", "getmeas.json"),
+		[]byte(want),
+		0o600,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := fixtureTransport{dir: dir}
+
+	resp := fixtureRoundTrip(t, transport, "action=getmeas&meastype=1")
+	defer resp.Body.Close()
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK || string(got) != want {
+		t.Fatalf("hit got %d %q", resp.StatusCode, got)
+	}
+
+	miss := fixtureRoundTrip(t, transport, "action=getintradayactivity")
+	defer miss.Body.Close()
+
+	if miss.StatusCode != http.StatusNotFound ||
+		!strings.Contains(miss.Status, "getintradayactivity.json") {
+		t.Fatalf("miss got %d %q", miss.StatusCode, miss.Status)
+	}
+}
+
+func fixtureRoundTrip(
+	t *testing.T,
+	transport fixtureTransport,
+	form string,
+) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodPost,
+		"https://wbsapi.withings.net/measure",
+		strings.NewReader(form),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp
+}
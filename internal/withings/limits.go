@@ -0,0 +1,133 @@
+package withings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+var (
+	// ErrInvalidLimit indicates a negative --limit.
+	ErrInvalidLimit = errors.New("--limit must not be negative")
+	// ErrInvalidPage indicates a --page below 1.
+	ErrInvalidPage = errors.New("--page must be at least 1")
+	// ErrInvalidPerPage indicates a negative --per-page.
+	ErrInvalidPerPage = errors.New("--per-page must not be negative")
+	// ErrPagingUnsupported indicates --page on an endpoint without limit.
+	ErrPagingUnsupported = errors.New("--page/--per-page not supported")
+)
+
+const (
+	// NoLimit marks endpoints that do not accept a limit parameter.
+	NoLimit = 0
+
+	maxPageSize = 300
+	firstPage   = 1
+)
+
+// maxLimits is the endpoint registry of the largest page size each list
+// action serves. Values above it are capped or rejected inconsistently by
+// the API, so the CLI clamps them up front.
+//
+//nolint:gochecknoglobals // Static endpoint registry.
+var maxLimits = map[string]int{
+	"measure getmeas":        maxPageSize,
+	"v2/measure getactivity": maxPageSize,
+	"v2/measure getworkouts": NoLimit,
+	"v2/sleep getsummary":    maxPageSize,
+	"v2/heart list":          maxPageSize,
+}
+
+// MaxLimit returns the largest --limit service/action accepts, or NoLimit
+// when the endpoint takes no limit.
+func MaxLimit(service, action string) int {
+	return maxLimits[service+" "+action]
+}
+
+// CheckLimit validates limit against the endpoint registry. It returns the
+// limit to send and, when the value was changed, a warning explaining why.
+func CheckLimit(service, action string, limit int) (int, string, error) {
+	if limit < 0 {
+		return limit, "", app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %d", ErrInvalidLimit, limit),
+		)
+	}
+
+	maxLimit := MaxLimit(service, action)
+
+	switch {
+	case limit == 0:
+		return limit, "", nil
+	case maxLimit == NoLimit:
+		return 0, fmt.Sprintf(
+			"warning: %s %s does not support --limit; ignoring it",
+			service,
+			action,
+		), nil
+	case limit > maxLimit:
+		return maxLimit, fmt.Sprintf(
+			"warning: --limit %d exceeds the %s %s maximum; using %d",
+			limit,
+			service,
+			action,
+			maxLimit,
+		), nil
+	default:
+		return limit, "", nil
+	}
+}
+
+// PageWindow translates a 1-based page and a page size into the limit and
+// offset to send. A perPage of 0 uses the endpoint maximum; larger values
+// are clamped to it with a warning, before the offset is computed, so
+// pages never overlap.
+func PageWindow(
+	service, action string,
+	page, perPage int,
+) (int, int, string, error) {
+	switch {
+	case page < firstPage:
+		return 0, 0, "", app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %d", ErrInvalidPage, page),
+		)
+	case perPage < 0:
+		return 0, 0, "", app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf("%w: %d", ErrInvalidPerPage, perPage),
+		)
+	}
+
+	maxLimit := MaxLimit(service, action)
+	if maxLimit == NoLimit {
+		return 0, 0, "", app.NewExitError(
+			app.ExitCodeUsage,
+			fmt.Errorf(
+				"%w: %s %s takes no page size; use --offset",
+				ErrPagingUnsupported,
+				service,
+				action,
+			),
+		)
+	}
+
+	warning := ""
+
+	switch {
+	case perPage == 0:
+		perPage = maxLimit
+	case perPage > maxLimit:
+		warning = fmt.Sprintf(
+			"warning: --per-page %d exceeds the %s %s maximum; using %d",
+			perPage,
+			service,
+			action,
+			maxLimit,
+		)
+		perPage = maxLimit
+	}
+
+	return perPage, (page - firstPage) * perPage, warning, nil
+}
@@ -0,0 +1,137 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestCheckLimit clamps to the registry maximum and warns on changes.
+func TestCheckLimit(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		action  string
+		limit   int
+		want    int
+		warning bool
+	}{
+		{"unset", "getmeas", 0, 0, false},
+		{"within", "getmeas", 100, 100, false},
+		{"clamped", "getmeas", 500, maxPageSize, true},
+		{"unsupported", "getworkouts", 10, 0, true},
+	}
+
+	for _, tc := range cases {
+		service := "measure"
+		if tc.action == "getworkouts" {
+			service = "v2/measure"
+		}
+
+		got, warning, err := CheckLimit(service, tc.action, tc.limit)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if got != tc.want || (warning != "") != tc.warning {
+			t.Fatalf("%s: got %d %q want %d", tc.name, got, warning, tc.want)
+		}
+	}
+}
+
+// TestCheckLimitNegative rejects negative limits as usage errors.
+func TestCheckLimitNegative(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := CheckLimit("measure", "getmeas", -1)
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+		t.Fatalf("got %v want usage error", err)
+	}
+
+	if !errors.Is(err, ErrInvalidLimit) {
+		t.Fatalf("got %v want %v", err, ErrInvalidLimit)
+	}
+}
+
+// TestPageWindow maps pages onto limit/offset after clamping the size.
+func TestPageWindow(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		page    int
+		perPage int
+		limit   int
+		offset  int
+		warning bool
+	}{
+		{"first", 1, 50, 50, 0, false},
+		{"third", 3, 50, 50, 100, false},
+		{"default-size", 2, 0, maxPageSize, maxPageSize, false},
+		{"clamped", 2, 500, maxPageSize, maxPageSize, true},
+	}
+
+	for _, tc := range cases {
+		limit, offset, warning, err := PageWindow(
+			"measure",
+			"getmeas",
+			tc.page,
+			tc.perPage,
+		)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if limit != tc.limit || offset != tc.offset ||
+			(warning != "") != tc.warning {
+			t.Fatalf(
+				"%s: got %d/%d %q want %d/%d",
+				tc.name,
+				limit,
+				offset,
+				warning,
+				tc.limit,
+				tc.offset,
+			)
+		}
+	}
+}
+
+// TestPageWindowErrors rejects bad pages and limitless endpoints.
+func TestPageWindowErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		action  string
+		page    int
+		perPage int
+		want    error
+	}{
+		{"page-zero", "getmeas", 0, 10, ErrInvalidPage},
+		{"negative-size", "getmeas", 1, -1, ErrInvalidPerPage},
+		{"unsupported", "getworkouts", 1, 10, ErrPagingUnsupported},
+	}
+
+	for _, tc := range cases {
+		service := "measure"
+		if tc.action == "getworkouts" {
+			service = "v2/measure"
+		}
+
+		_, _, _, err := PageWindow(service, tc.action, tc.page, tc.perPage)
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v want %v", tc.name, err, tc.want)
+		}
+
+		var exitErr *app.ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+			t.Fatalf("%s: got %v want usage error", tc.name, err)
+		}
+	}
+}
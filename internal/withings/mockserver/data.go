@@ -0,0 +1,613 @@
+package mockserver
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	startDateKey  = "startdate"
+	endDateKey    = "enddate"
+	startYMDKey   = "startdateymd"
+	endYMDKey     = "enddateymd"
+	lastUpdateKey = "lastupdate"
+	measTypeKey   = "meastype"
+	measTypesKey  = "meastypes"
+	dateLayout    = "2006-01-02"
+	listSeparator = ","
+	timezone      = "Europe/Berlin"
+	scaleDeviceID = "a3f1c0de5b7e4f1e9d2c8b7a6f5e4d3c2b1a0f9e"
+	watchDeviceID = "f9e8d7c6b5a4938271605f4e3d2c1b0a99887766"
+	mockUserID    = 1234567
+	tokenLifetime = 3 * time.Hour
+	tokenScope    = "user.info,user.metrics,user.activity"
+	defaultDays   = 7
+	maxDays       = 366
+	workoutEvery  = 3
+	hoursPerDay   = 24
+
+	typeWeight    = 1
+	typeFatRatio  = 6
+	typeDiastolic = 9
+	typeSystolic  = 10
+	typePulse     = 11
+
+	weighingHour = 7
+	bpMinute     = 30
+	workoutHour  = 18
+	ecgHour      = 9
+	bedtimeHour  = 23
+	wakeHour     = 7
+
+	strideCM      = 76
+	centimeters   = 100
+	caloriesStep  = 20
+	restCalories  = 2100
+	deepShare     = 5
+	remShare      = 4
+	unitGrams     = -3
+	unitPermille  = -1
+	unitNone      = 0
+	formatMetric  = 3
+	sleepModel    = 32
+	watchModel    = 93
+	scaleModel    = 5
+	walking       = 1
+	cycling       = 6
+	categoryReal  = 1
+	workoutLength = 45 * time.Minute
+	stepsGoal     = 8000
+	sleepGoal     = 8 * 60 * 60
+	weightGoal    = 75000
+)
+
+var errInvalidDate = errors.New("invalid date parameter")
+
+// metric is a daily value drifting around base by up to spread.
+type metric struct {
+	base   int64
+	spread int64
+}
+
+// metrics are the baselines of every generated value, keyed by the API
+// field name where there is one.
+//
+//nolint:gochecknoglobals // Static table of generated values.
+var metrics = map[string]metric{
+	"weight":            {base: 78400, spread: 900},
+	"fat_ratio":         {base: 215, spread: 12},
+	"systolic":          {base: 121, spread: 14},
+	"diastolic":         {base: 78, spread: 9},
+	"pulse":             {base: 63, spread: 7},
+	"steps":             {base: 8200, spread: 3500},
+	"elevation":         {base: 12, spread: 10},
+	"soft":              {base: 3600, spread: 1200},
+	"moderate":          {base: 1500, spread: 600},
+	"intense":           {base: 600, spread: 400},
+	"hr_average":        {base: 72, spread: 6},
+	"hr_min":            {base: 52, spread: 4},
+	"hr_max":            {base: 148, spread: 20},
+	"hr_zone_0":         {base: 36000, spread: 3000},
+	"hr_zone_1":         {base: 5400, spread: 1800},
+	"hr_zone_3":         {base: 120, spread: 120},
+	"bedtime":           {base: 0, spread: 40},
+	"wakeup":            {base: 0, spread: 30},
+	"wakeupduration":    {base: 1500, spread: 900},
+	"sleep_score":       {base: 78, spread: 12},
+	"wakeupcount":       {base: 2, spread: 2},
+	"durationtosleep":   {base: 600, spread: 300},
+	"durationtowakeup":  {base: 300, spread: 200},
+	"sleep_hr_average":  {base: 56, spread: 4},
+	"sleep_hr_min":      {base: 47, spread: 3},
+	"sleep_hr_max":      {base: 74, spread: 8},
+	"rr_average":        {base: 14, spread: 2},
+	"breathing":         {base: 8, spread: 6},
+	"ahi":               {base: 3, spread: 2},
+	"snoring":           {base: 900, spread: 600},
+	"workout_steps":     {base: 5200, spread: 800},
+	"workout_calories":  {base: 310, spread: 60},
+	"workout_elevation": {base: 20, spread: 15},
+	"workout_hr_avg":    {base: 128, spread: 10},
+	"workout_hr_min":    {base: 92, spread: 8},
+	"workout_hr_max":    {base: 161, spread: 12},
+	"workout_zone":      {base: 600, spread: 300},
+	"workout_intensity": {base: 50, spread: 20},
+	"hr_asleep":         {base: 54, spread: 4},
+	"hr_awake":          {base: 78, spread: 12},
+	"hr_workout":        {base: 142, spread: 14},
+	"rr_asleep":         {base: 14, spread: 2},
+	"steps_awake":       {base: 90, spread: 90},
+	"steps_workout":     {base: 1100, spread: 150},
+}
+
+// window is the requested time range.
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+// parseWindow reads the unix or YYYY-MM-DD range of a request, or
+// lastupdate, defaulting to the last week and capped at a year.
+func parseWindow(params url.Values, now time.Time) (window, error) {
+	span := window{start: now.AddDate(0, 0, -defaultDays), end: now}
+
+	for _, bound := range []struct {
+		key    string
+		ymd    bool
+		target *time.Time
+	}{
+		{key: startDateKey, ymd: false, target: &span.start},
+		{key: endDateKey, ymd: false, target: &span.end},
+		{key: lastUpdateKey, ymd: false, target: &span.start},
+		{key: startYMDKey, ymd: true, target: &span.start},
+		{key: endYMDKey, ymd: true, target: &span.end},
+	} {
+		raw := params.Get(bound.key)
+		if raw == emptyString {
+			continue
+		}
+
+		value, err := parseBound(raw, bound.ymd)
+		if err != nil {
+			return span, fmt.Errorf("%w %s=%q", errInvalidDate, bound.key, raw)
+		}
+
+		*bound.target = value
+	}
+
+	if span.end.After(now) {
+		span.end = now
+	}
+
+	if bound := span.end.AddDate(0, 0, -maxDays); span.start.Before(bound) {
+		span.start = bound
+	}
+
+	return span, nil
+}
+
+func parseBound(raw string, ymd bool) (time.Time, error) {
+	if ymd {
+		value, err := time.ParseInLocation(dateLayout, raw, time.UTC)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse date: %w", err)
+		}
+
+		// enddateymd is inclusive.
+		return value.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse unix time: %w", err)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// days lists the midnights of every day the window touches.
+func (w window) days() []time.Time {
+	var days []time.Time
+
+	day := time.Date(
+		w.start.Year(), w.start.Month(), w.start.Day(), 0, 0, 0, 0, time.UTC,
+	)
+	for ; !day.After(w.end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	return days
+}
+
+func (w window) contains(value time.Time) bool {
+	return !value.Before(w.start) && !value.After(w.end)
+}
+
+// daily returns metric name on day. The offset from the baseline is
+// derived from the day and the name, so the same day always yields the
+// same values.
+func daily(day time.Time, name string) int64 {
+	return vary(name, day.Format(dateLayout))
+}
+
+// sampled is daily for a single sample time.
+func sampled(at time.Time, name string) int64 {
+	return vary(name, strconv.FormatInt(at.Unix(), 10))
+}
+
+func vary(name string, seed string) int64 {
+	value := metrics[name]
+	width := 2*value.spread + 1
+	hash := crc32.ChecksumIEEE([]byte(name + seed))
+
+	return value.base + int64(hash)%width - value.spread
+}
+
+// dayNumber counts days since the unix epoch.
+func dayNumber(day time.Time) int64 {
+	return day.Unix() / int64((hoursPerDay * time.Hour).Seconds())
+}
+
+// dailyFields fills fields with the metrics of the same name.
+func dailyFields(day time.Time, fields map[string]any, names ...string) {
+	for _, name := range names {
+		fields[name] = daily(day, name)
+	}
+}
+
+func getMeas(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := measTypes(params)
+	groups := []map[string]any{}
+
+	for _, day := range span.days() {
+		weighed := day.Add(weighingHour * time.Hour)
+		measured := weighed.Add(bpMinute * time.Minute)
+
+		for _, group := range []struct {
+			at       time.Time
+			measures []map[string]any
+		}{
+			{at: weighed, measures: []map[string]any{
+				measure(typeWeight, daily(day, "weight"), unitGrams),
+				measure(typeFatRatio, daily(day, "fat_ratio"), unitPermille),
+			}},
+			{at: measured, measures: []map[string]any{
+				measure(typeSystolic, daily(day, "systolic"), unitNone),
+				measure(typeDiastolic, daily(day, "diastolic"), unitNone),
+				measure(typePulse, daily(day, "pulse"), unitNone),
+			}},
+		} {
+			kept := filterMeasures(group.measures, wanted)
+			if len(kept) == 0 || !span.contains(group.at) {
+				continue
+			}
+
+			groups = append(groups, map[string]any{
+				"grpid":    group.at.Unix(),
+				"attrib":   0,
+				"date":     group.at.Unix(),
+				"created":  group.at.Unix(),
+				"modified": group.at.Unix(),
+				"category": categoryReal,
+				"deviceid": scaleDeviceID,
+				"timezone": timezone,
+				"measures": kept,
+			})
+		}
+	}
+
+	return map[string]any{
+		"updatetime":  now.Unix(),
+		"timezone":    timezone,
+		"measuregrps": groups,
+		"more":        0,
+		"offset":      0,
+	}, nil
+}
+
+func measure(kind int, value, unit int64) map[string]any {
+	return map[string]any{
+		"type":  kind,
+		"value": value,
+		"unit":  unit,
+		"algo":  0,
+		"fm":    formatMetric,
+	}
+}
+
+// measTypes reads meastype or meastypes; nil keeps every type.
+func measTypes(params url.Values) map[int]bool {
+	raw := params.Get(measTypeKey)
+	if raw == emptyString {
+		raw = params.Get(measTypesKey)
+	}
+
+	if raw == emptyString {
+		return nil
+	}
+
+	wanted := map[int]bool{}
+
+	for entry := range strings.SplitSeq(raw, listSeparator) {
+		kind, err := strconv.Atoi(strings.TrimSpace(entry))
+		if err == nil {
+			wanted[kind] = true
+		}
+	}
+
+	return wanted
+}
+
+func filterMeasures(
+	measures []map[string]any,
+	wanted map[int]bool,
+) []map[string]any {
+	if wanted == nil {
+		return measures
+	}
+
+	var kept []map[string]any
+
+	for _, entry := range measures {
+		kind, _ := entry["type"].(int)
+		if wanted[kind] {
+			kept = append(kept, entry)
+		}
+	}
+
+	return kept
+}
+
+func getActivity(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := []map[string]any{}
+
+	for _, day := range span.days() {
+		steps := daily(day, "steps")
+		intense := daily(day, "intense")
+		moderate := daily(day, "moderate")
+
+		item := map[string]any{
+			"date":          day.Format(dateLayout),
+			"timezone":      timezone,
+			"deviceid":      watchDeviceID,
+			"is_tracker":    true,
+			"steps":         steps,
+			"distance":      steps * strideCM / centimeters,
+			"active":        moderate + intense,
+			"moderate":      moderate,
+			"intense":       intense,
+			"calories":      steps / caloriesStep,
+			"totalcalories": restCalories + steps/caloriesStep,
+			"hr_zone_2":     intense,
+		}
+		dailyFields(
+			day, item, "elevation", "soft", "hr_average", "hr_min",
+			"hr_max", "hr_zone_0", "hr_zone_1", "hr_zone_3",
+		)
+
+		activities = append(activities, item)
+	}
+
+	return map[string]any{
+		"activities": activities,
+		"more":       false,
+		"offset":     0,
+	}, nil
+}
+
+func getSleepSummary(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	series := []map[string]any{}
+
+	for _, day := range span.days() {
+		start, end := night(day)
+		if end.After(now) {
+			continue
+		}
+
+		series = append(series, map[string]any{
+			"date":      day.Format(dateLayout),
+			"startdate": start.Unix(),
+			"enddate":   end.Unix(),
+			"timezone":  timezone,
+			"model":     sleepModel,
+			"duration":  int64(end.Sub(start).Seconds()),
+			"data":      sleepData(day, int64(end.Sub(start).Seconds())),
+		})
+	}
+
+	return map[string]any{"series": series, "more": false, "offset": 0}, nil
+}
+
+// night returns the bedtime before and the wake-up time on day.
+func night(day time.Time) (time.Time, time.Time) {
+	start := day.AddDate(0, 0, -1).
+		Add(bedtimeHour * time.Hour).
+		Add(time.Duration(daily(day, "bedtime")) * time.Minute)
+	end := day.Add(wakeHour * time.Hour).
+		Add(time.Duration(daily(day, "wakeup")) * time.Minute)
+
+	return start, end
+}
+
+// sleepData splits the night into sleep states that add up to inBed.
+func sleepData(day time.Time, inBed int64) map[string]any {
+	awake := daily(day, "wakeupduration")
+	asleep := inBed - awake
+	deep := asleep / deepShare
+	rem := asleep / remShare
+
+	data := map[string]any{
+		"total_timeinbed":                  inBed,
+		"total_sleep_time":                 asleep,
+		"sleep_efficiency":                 float64(asleep) / float64(inBed),
+		"wakeupduration":                   awake,
+		"deepsleepduration":                deep,
+		"remsleepduration":                 rem,
+		"lightsleepduration":               asleep - deep - rem,
+		"hr_average":                       daily(day, "sleep_hr_average"),
+		"hr_min":                           daily(day, "sleep_hr_min"),
+		"hr_max":                           daily(day, "sleep_hr_max"),
+		"breathing_disturbances_intensity": daily(day, "breathing"),
+		"apnea_hypopnea_index":             daily(day, "ahi"),
+	}
+	dailyFields(
+		day, data, "sleep_score", "wakeupcount", "durationtosleep",
+		"durationtowakeup", "rr_average", "snoring",
+	)
+
+	return data
+}
+
+func getWorkouts(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	series := []map[string]any{}
+
+	for _, day := range span.days() {
+		start := day.Add(workoutHour * time.Hour)
+		number := dayNumber(day)
+
+		if number%workoutEvery != 0 || !span.contains(start) {
+			continue
+		}
+
+		category := walking
+		if number%(2*workoutEvery) != 0 {
+			category = cycling
+		}
+
+		series = append(series, map[string]any{
+			"id":        start.Unix(),
+			"category":  category,
+			"timezone":  timezone,
+			"model":     watchModel,
+			"attrib":    0,
+			"startdate": start.Unix(),
+			"enddate":   start.Add(workoutLength).Unix(),
+			"date":      day.Format(dateLayout),
+			"deviceid":  watchDeviceID,
+			"data":      workoutData(day),
+		})
+	}
+
+	return map[string]any{"series": series, "more": false, "offset": 0}, nil
+}
+
+func workoutData(day time.Time) map[string]any {
+	steps := daily(day, "workout_steps")
+	zone := daily(day, "workout_zone")
+
+	return map[string]any{
+		"steps":          steps,
+		"distance":       steps * strideCM / centimeters,
+		"calories":       daily(day, "workout_calories"),
+		"elevation":      daily(day, "workout_elevation"),
+		"intensity":      daily(day, "workout_intensity"),
+		"hr_average":     daily(day, "workout_hr_avg"),
+		"hr_min":         daily(day, "workout_hr_min"),
+		"hr_max":         daily(day, "workout_hr_max"),
+		"hr_zone_0":      zone,
+		"hr_zone_1":      zone,
+		"hr_zone_2":      zone,
+		"hr_zone_3":      int64(workoutLength.Seconds()) - 3*zone,
+		"pause_duration": 0,
+	}
+}
+
+func listHeart(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	series := []map[string]any{}
+
+	for _, day := range span.days() {
+		taken := day.Add(ecgHour * time.Hour)
+		if !span.contains(taken) {
+			continue
+		}
+
+		series = append(series, map[string]any{
+			"signalid":   taken.Unix(),
+			"timestamp":  taken.Unix(),
+			"deviceid":   watchDeviceID,
+			"model":      watchModel,
+			"afib":       0,
+			"heart_rate": daily(day, "pulse"),
+			"timezone":   timezone,
+		})
+	}
+
+	return map[string]any{
+		"timezone": timezone,
+		"series":   series,
+		"more":     false,
+		"offset":   0,
+	}, nil
+}
+
+func getDevices(_ url.Values, now time.Time) (any, error) {
+	synced := now.Add(-time.Hour).Unix()
+	first := now.AddDate(-1, 0, 0).Unix()
+
+	return map[string]any{"devices": []map[string]any{
+		{
+			"type":               "Scale",
+			"model":              "Body+",
+			"model_id":           scaleModel,
+			"battery":            "high",
+			"deviceid":           scaleDeviceID,
+			"hash_deviceid":      scaleDeviceID,
+			"timezone":           timezone,
+			"last_session_date":  synced,
+			"first_session_date": first,
+			"fw":                 "1940",
+			"mac_address":        "00:24:e4:aa:bb:01",
+		},
+		{
+			"type":               "Activity Tracker",
+			"model":              "ScanWatch",
+			"model_id":           watchModel,
+			"battery":            "medium",
+			"deviceid":           watchDeviceID,
+			"hash_deviceid":      watchDeviceID,
+			"timezone":           timezone,
+			"last_session_date":  synced,
+			"first_session_date": first,
+			"fw":                 "2310",
+			"mac_address":        "00:24:e4:aa:bb:02",
+		},
+	}}, nil
+}
+
+// getGoals returns fixed steps, sleep, and weight goals.
+func getGoals(_ url.Values, _ time.Time) (any, error) {
+	return map[string]any{"goals": map[string]any{
+		"steps":  stepsGoal,
+		"sleep":  sleepGoal,
+		"weight": map[string]any{"value": weightGoal, "unit": unitGrams},
+	}}, nil
+}
+
+// requestToken accepts any code or refresh token and issues a fresh pair.
+func requestToken(_ url.Values, now time.Time) (any, error) {
+	return map[string]any{
+		"userid":        mockUserID,
+		"access_token":  fmt.Sprintf("mock-access-%d", now.Unix()),
+		"refresh_token": fmt.Sprintf("mock-refresh-%d", now.Unix()),
+		"expires_in":    int64(tokenLifetime.Seconds()),
+		"scope":         tokenScope,
+		"token_type":    "Bearer",
+	}, nil
+}
+
+// getNonce issues a nonce for signed actions.
+func getNonce(_ url.Values, now time.Time) (any, error) {
+	return map[string]any{
+		"nonce": fmt.Sprintf("mock-nonce-%d", now.UnixNano()),
+	}, nil
+}
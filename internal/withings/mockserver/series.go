@@ -0,0 +1,175 @@
+package mockserver
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dataFieldsKey = "data_fields"
+	intradayStep  = 10 * time.Minute
+	sleepSegment  = 30 * time.Minute
+	sleepStep     = 5 * time.Minute
+)
+
+// sleepStages is the order a night cycles through: light, deep, light,
+// REM.
+//
+//nolint:gochecknoglobals // Static stage cycle.
+var sleepStages = []int{1, 2, 1, 3}
+
+// getIntraday returns a sample every intradayStep. Heart rate rests at
+// night, rises by day, and reaches the upper zones during workouts.
+func getIntraday(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := dataFields(params)
+	series := map[string]map[string]any{}
+
+	at := span.start.Truncate(intradayStep)
+	if at.Before(span.start) {
+		at = at.Add(intradayStep)
+	}
+
+	for ; !at.After(span.end); at = at.Add(intradayStep) {
+		sample := map[string]any{}
+
+		for name, value := range intradaySample(at) {
+			if fields == nil || fields[name] {
+				sample[name] = value
+			}
+		}
+
+		series[strconv.FormatInt(at.Unix(), 10)] = sample
+	}
+
+	return map[string]any{"series": series}, nil
+}
+
+func intradaySample(at time.Time) map[string]any {
+	phase := activityPhase(at)
+	steps := int64(0)
+
+	if phase != "asleep" {
+		steps = sampled(at, "steps_"+phase)
+	}
+
+	return map[string]any{
+		"heart_rate": sampled(at, "hr_"+phase),
+		"steps":      steps,
+		"calories":   steps / caloriesStep,
+		"distance":   steps * strideCM / centimeters,
+		"duration":   int64(intradayStep.Seconds()),
+	}
+}
+
+// activityPhase places at in the night, a workout, or the rest of the
+// day, matching the sleep and workout actions.
+func activityPhase(at time.Time) string {
+	day := at.Truncate(hoursPerDay * time.Hour)
+	workout := day.Add(workoutHour * time.Hour)
+
+	switch {
+	case at.Hour() < wakeHour || at.Hour() >= bedtimeHour:
+		return "asleep"
+	case dayNumber(day)%workoutEvery == 0 &&
+		!at.Before(workout) && at.Before(workout.Add(workoutLength)):
+		return "workout"
+	default:
+		return "awake"
+	}
+}
+
+// getSleep returns the stage segments of every night that overlaps the
+// window, with hr and rr samples every sleepStep.
+func getSleep(params url.Values, now time.Time) (any, error) {
+	span, err := parseWindow(params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := dataFields(params)
+	series := []map[string]any{}
+	days := span.days()
+
+	// The night before the day after the window may start inside it.
+	if len(days) > 0 {
+		days = append(days, days[len(days)-1].AddDate(0, 0, 1))
+	}
+
+	for _, day := range days {
+		start, end := night(day)
+		if end.After(now) {
+			continue
+		}
+
+		for index, from := 0, start; from.Before(end); index++ {
+			until := from.Add(sleepSegment)
+			if until.After(end) {
+				until = end
+			}
+
+			if until.After(span.start) && !from.After(span.end) {
+				series = append(series, sleepSegmentData(
+					from, until, sleepStages[index%len(sleepStages)], fields,
+				))
+			}
+
+			from = until
+		}
+	}
+
+	return map[string]any{"series": series, "more": false, "offset": 0}, nil
+}
+
+func sleepSegmentData(
+	from time.Time,
+	until time.Time,
+	state int,
+	fields map[string]bool,
+) map[string]any {
+	segment := map[string]any{
+		"startdate": from.Unix(),
+		"enddate":   until.Unix(),
+		"state":     state,
+	}
+
+	for field, metric := range map[string]string{
+		"hr": "hr_asleep",
+		"rr": "rr_asleep",
+	} {
+		if fields != nil && !fields[field] {
+			continue
+		}
+
+		samples := map[string]int64{}
+		for at := from; at.Before(until); at = at.Add(sleepStep) {
+			samples[strconv.FormatInt(at.Unix(), 10)] = sampled(at, metric)
+		}
+
+		segment[field] = samples
+	}
+
+	return segment
+}
+
+// dataFields reads data_fields; nil keeps every field.
+func dataFields(params url.Values) map[string]bool {
+	raw := params.Get(dataFieldsKey)
+	if raw == emptyString {
+		return nil
+	}
+
+	fields := map[string]bool{}
+
+	for entry := range strings.SplitSeq(raw, listSeparator) {
+		fields[strings.TrimSpace(entry)] = true
+	}
+
+	return fields
+}
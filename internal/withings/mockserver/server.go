@@ -0,0 +1,256 @@
+// Package mockserver imitates the Withings API with generated but
+// realistic measure, activity, intraday, sleep, workout, heart, device,
+// goal, and token responses, so end-to-end tests run hermetically and
+// users can point --base-url at a local server.
+package mockserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+	"github.com/mreimbold/withings-cli/internal/output"
+	"github.com/mreimbold/withings-cli/internal/withings"
+)
+
+const (
+	actionKey           = "action"
+	bearerPrefix        = "Bearer "
+	contentType         = "Content-Type"
+	contentTypeJSON     = "application/json"
+	tokenService        = "v2/oauth2"
+	signatureService    = "v2/signature"
+	statusOK            = 0
+	statusInvalidParams = 503
+	statusInvalidToken  = 401
+	statusUnknownAction = 2554
+	readHeaderTimeout   = 10 * time.Second
+	shutdownTimeout     = 5 * time.Second
+	emptyString         = ""
+)
+
+var errMethod = errors.New("only GET and POST are supported")
+
+// Options configures the server.
+type Options struct {
+	Listen string
+}
+
+// actionFunc builds the response body of one action from its parameters.
+type actionFunc func(params url.Values, now time.Time) (any, error)
+
+// services maps each service path and action to its generator.
+//
+//nolint:gochecknoglobals // Static table of mocked endpoints.
+var services = map[string]map[string]actionFunc{
+	"measure": {
+		"getmeas": getMeas,
+	},
+	"v2/measure": {
+		"getactivity":         getActivity,
+		"getintradayactivity": getIntraday,
+		"getworkouts":         getWorkouts,
+	},
+	"v2/sleep": {
+		"get":        getSleep,
+		"getsummary": getSleepSummary,
+	},
+	"v2/heart": {
+		"list": listHeart,
+	},
+	"v2/user": {
+		"getdevice": getDevices,
+		"getgoals":  getGoals,
+	},
+	tokenService: {
+		"requesttoken": requestToken,
+	},
+	signatureService: {
+		"getnonce": getNonce,
+	},
+}
+
+// envelope is the Withings response wrapper.
+type envelope struct {
+	Status int    `json:"status"`
+	Body   any    `json:"body"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Run serves the mock API on opts.Listen until ctx is done or the process
+// receives SIGINT or SIGTERM.
+func Run(ctx context.Context, opts Options, appOpts app.Options) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var listenConfig net.ListenConfig
+
+	listener, err := listenConfig.Listen(ctx, "tcp", opts.Listen)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", opts.Listen, err)
+	}
+
+	//nolint:exhaustruct // Optional server fields are omitted.
+	server := &http.Server{
+		Handler:           NewHandler(time.Now),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	err = output.WriteProgress(
+		appOpts,
+		fmt.Sprintf(
+			"serving mock Withings API on http://%s (use it with --base-url)",
+			listener.Addr(),
+		),
+	)
+	if err != nil {
+		_ = listener.Close()
+
+		return err
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("serve: %w", err)
+		}
+
+		return nil
+	case <-ctx.Done():
+	}
+
+	//nolint:contextcheck // The parent context is already cancelled.
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		shutdownTimeout,
+	)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("shutdown server: %w", err)
+	}
+
+	return nil
+}
+
+// NewHandler returns the mock API. now anchors requests without a date
+// range; the same day always yields the same values.
+func NewHandler(now func() time.Time) http.Handler {
+	return handler{now: now}
+}
+
+type handler struct {
+	now func() time.Time
+}
+
+// ServeHTTP answers like the real API: unknown services get a 404,
+// unknown actions status 2554, and data requests without a bearer token
+// status 401, all but the 404 with HTTP 200. Signatures are not checked.
+func (h handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodPost {
+		http.Error(writer, errMethod.Error(), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	service := strings.Trim(request.URL.Path, "/")
+	if service == withings.CapabilitiesPath {
+		writeJSON(writer, map[string][]string{"actions": capabilities()})
+
+		return
+	}
+
+	actions, ok := services[service]
+	if !ok {
+		http.NotFound(writer, request)
+
+		return
+	}
+
+	err := request.ParseForm()
+	if err != nil {
+		writeStatus(writer, statusInvalidParams, err.Error())
+
+		return
+	}
+
+	action, ok := actions[request.Form.Get(actionKey)]
+
+	switch {
+	case !ok:
+		writeStatus(writer, statusUnknownAction, "Unknown action")
+	case !public(service) && !hasBearer(request):
+		writeStatus(writer, statusInvalidToken, "invalid_token")
+	default:
+		body, err := action(request.Form, h.now().UTC())
+		if err != nil {
+			writeStatus(writer, statusInvalidParams, err.Error())
+
+			return
+		}
+
+		writeJSON(writer, envelope{Status: statusOK, Body: body, Error: ""})
+	}
+}
+
+// capabilities lists every mocked action in the probe format, e.g.
+// "v2/sleep getsummary".
+func capabilities() []string {
+	var actions []string
+
+	for _, service := range slices.Sorted(maps.Keys(services)) {
+		for _, action := range slices.Sorted(maps.Keys(services[service])) {
+			actions = append(actions, service+" "+action)
+		}
+	}
+
+	return actions
+}
+
+// public reports whether service authenticates with the client
+// credentials instead of a bearer token.
+func public(service string) bool {
+	return service == tokenService || service == signatureService
+}
+
+func hasBearer(request *http.Request) bool {
+	token, ok := strings.CutPrefix(
+		request.Header.Get("Authorization"),
+		bearerPrefix,
+	)
+
+	return ok && strings.TrimSpace(token) != emptyString
+}
+
+func writeStatus(writer http.ResponseWriter, status int, message string) {
+	writeJSON(writer, envelope{
+		Status: status,
+		Body:   map[string]any{},
+		Error:  message,
+	})
+}
+
+func writeJSON(writer http.ResponseWriter, payload any) {
+	writer.Header().Set(contentType, contentTypeJSON)
+
+	_ = json.NewEncoder(writer).Encode(payload)
+}
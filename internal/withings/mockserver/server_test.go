@@ -0,0 +1,291 @@
+//nolint:testpackage // test unexported helpers.
+package mockserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBearer = "Bearer mock"
+
+//nolint:gochecknoglobals // Fixed clock of the tests.
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+// TestHandlerErrors mirrors the API's unknown-action and token failures.
+func TestHandlerErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		path   string
+		form   string
+		bearer string
+		status int
+	}{
+		{
+			name:   "unknown action",
+			path:   "/measure",
+			form:   "action=nope",
+			bearer: testBearer,
+			status: statusUnknownAction,
+		},
+		{
+			name:   "missing token",
+			path:   "/measure",
+			form:   "action=getmeas",
+			bearer: emptyString,
+			status: statusInvalidToken,
+		},
+		{
+			name:   "token without bearer",
+			path:   "/v2/oauth2",
+			form:   "action=requesttoken&grant_type=refresh_token",
+			bearer: emptyString,
+			status: statusOK,
+		},
+		{
+			name:   "bad date",
+			path:   "/v2/sleep",
+			form:   "action=getsummary&startdateymd=yesterday",
+			bearer: testBearer,
+			status: statusInvalidParams,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got envelope
+
+			recorder := post(t, test.path, test.form, test.bearer, &got)
+			if recorder.Code != http.StatusOK || got.Status != test.status {
+				t.Fatalf(
+					"got HTTP %d status %d want status %d",
+					recorder.Code,
+					got.Status,
+					test.status,
+				)
+			}
+		})
+	}
+
+	recorder := post(t, "/v2/nope", "action=get", testBearer, nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("unknown service got HTTP %d", recorder.Code)
+	}
+}
+
+// TestCapabilities lists every mocked action.
+func TestCapabilities(t *testing.T) {
+	t.Parallel()
+
+	request := httptest.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		"/capabilities",
+		nil,
+	)
+	recorder := httptest.NewRecorder()
+
+	NewHandler(fixedNow).ServeHTTP(recorder, request)
+
+	var got struct {
+		Actions []string `json:"actions"`
+	}
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"measure getmeas", "v2/sleep getsummary"} {
+		if !slices.Contains(got.Actions, want) {
+			t.Fatalf("capabilities %v miss %q", got.Actions, want)
+		}
+	}
+}
+
+// TestGetMeas honours the range and meastype and repeats its values.
+func TestGetMeas(t *testing.T) {
+	t.Parallel()
+
+	params := url.Values{}
+	params.Set(actionKey, "getmeas")
+	params.Set(measTypeKey, "1")
+	params.Set(startDateKey, "1772668800") // 2026-03-05T00:00:00Z
+	params.Set(endDateKey, "1772841600")   // 2026-03-07T00:00:00Z
+
+	var first, second struct {
+		Body struct {
+			Groups []struct {
+				Date     int64 `json:"date"`
+				Measures []struct {
+					Type  int   `json:"type"`
+					Value int64 `json:"value"`
+				} `json:"measures"`
+			} `json:"measuregrps"`
+		} `json:"body"`
+	}
+
+	post(t, "/measure", params.Encode(), testBearer, &first)
+	post(t, "/measure", params.Encode(), testBearer, &second)
+
+	if len(first.Body.Groups) != 2 {
+		t.Fatalf("got %d groups want 2", len(first.Body.Groups))
+	}
+
+	for index, group := range first.Body.Groups {
+		if len(group.Measures) != 1 || group.Measures[0].Type != typeWeight {
+			t.Fatalf("group %d measures %+v", index, group.Measures)
+		}
+
+		value := group.Measures[0].Value
+		if value != second.Body.Groups[index].Measures[0].Value {
+			t.Fatalf("group %d is not deterministic", index)
+		}
+
+		weight := metrics["weight"]
+		if value < weight.base-weight.spread ||
+			value > weight.base+weight.spread {
+			t.Fatalf("weight %d out of range", value)
+		}
+	}
+}
+
+// TestGetIntraday samples every step, honours data_fields, and raises
+// the heart rate during workouts.
+func TestGetIntraday(t *testing.T) {
+	t.Parallel()
+
+	params := url.Values{}
+	params.Set(actionKey, "getintradayactivity")
+	params.Set(dataFieldsKey, "heart_rate")
+	params.Set(startDateKey, "1772989200") // 2026-03-08T17:00:00Z
+	params.Set(endDateKey, "1772996400")   // 2026-03-08T19:00:00Z
+
+	var got struct {
+		Body struct {
+			Series map[string]map[string]int64 `json:"series"`
+		} `json:"body"`
+	}
+
+	post(t, "/v2/measure", params.Encode(), testBearer, &got)
+
+	// 17:00 to 19:00 inclusive, every 10 minutes.
+	if len(got.Body.Series) != 13 {
+		t.Fatalf("got %d samples want 13", len(got.Body.Series))
+	}
+
+	// 2026-03-08 is a workout day (day number divisible by 3).
+	resting := got.Body.Series["1772989200"]
+	working := got.Body.Series["1772993400"] // 18:10
+
+	if len(resting) != 1 || working["heart_rate"] <= resting["heart_rate"] {
+		t.Fatalf("resting %v workout %v", resting, working)
+	}
+}
+
+// TestGetSleep splits each night into contiguous stage segments with
+// the requested vitals.
+func TestGetSleep(t *testing.T) {
+	t.Parallel()
+
+	params := url.Values{}
+	params.Set(actionKey, "get")
+	params.Set(dataFieldsKey, "hr")
+	params.Set(startDateKey, "1772870400") // 2026-03-07T08:00:00Z
+	params.Set(endDateKey, "1772956800")   // 2026-03-08T08:00:00Z
+
+	var got struct {
+		Body struct {
+			Series []struct {
+				Start int64            `json:"startdate"`
+				End   int64            `json:"enddate"`
+				State int              `json:"state"`
+				HR    map[string]int64 `json:"hr"`
+				RR    map[string]int64 `json:"rr"`
+			} `json:"series"`
+		} `json:"body"`
+	}
+
+	post(t, "/v2/sleep", params.Encode(), testBearer, &got)
+
+	series := got.Body.Series
+	if len(series) == 0 {
+		t.Fatal("no sleep segments")
+	}
+
+	for index, segment := range series {
+		if index > 0 && segment.Start != series[index-1].End {
+			t.Fatalf("segment %d starts at %d after %d", index,
+				segment.Start, series[index-1].End)
+		}
+
+		if len(segment.HR) == 0 || segment.RR != nil {
+			t.Fatalf("segment %d fields hr=%v rr=%v", index, segment.HR, segment.RR)
+		}
+	}
+}
+
+// TestWindowEndsNow keeps generated data out of the future.
+func TestWindowEndsNow(t *testing.T) {
+	t.Parallel()
+
+	params := url.Values{}
+	params.Set(endYMDKey, testNow.Format(dateLayout))
+
+	span, err := parseWindow(params, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !span.end.Equal(testNow) || len(span.days()) != defaultDays+1 {
+		t.Fatalf("got %v to %v", span.start, span.end)
+	}
+}
+
+func fixedNow() time.Time {
+	return testNow
+}
+
+func post(
+	t *testing.T,
+	path string,
+	form string,
+	bearer string,
+	target any,
+) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequestWithContext(
+		t.Context(),
+		http.MethodPost,
+		path,
+		strings.NewReader(form),
+	)
+	request.Header.Set(contentType, "application/x-www-form-urlencoded")
+
+	if bearer != emptyString {
+		request.Header.Set("Authorization", bearer)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	NewHandler(fixedNow).ServeHTTP(recorder, request)
+
+	if target != nil {
+		err := json.Unmarshal(recorder.Body.Bytes(), target)
+		if err != nil {
+			t.Fatalf("decode %s: %v", recorder.Body.String(), err)
+		}
+	}
+
+	return recorder
+}
@@ -0,0 +1,105 @@
+package withings
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRateLimit is the Withings API quota in requests per minute.
+	DefaultRateLimit = 120
+	// NoRateLimit disables client-side throttling.
+	NoRateLimit     = 0
+	rateLimitWindow = time.Minute
+	noSlots         = 0
+)
+
+//nolint:gochecknoglobals // Limiters are shared by every client in-process.
+var (
+	sharedLimitersMu sync.Mutex
+	sharedLimiters   = map[int]*Limiter{}
+)
+
+// Limiter allows at most Limit requests in any rolling window. Each call to
+// Wait reserves a slot, so concurrent callers queue instead of bursting.
+type Limiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	slots  []time.Time
+}
+
+// NewLimiter returns a limiter for perMinute requests per minute, or nil
+// when perMinute disables throttling.
+func NewLimiter(perMinute int) *Limiter {
+	if perMinute <= NoRateLimit {
+		return nil
+	}
+
+	return &Limiter{
+		mu:     sync.Mutex{},
+		limit:  perMinute,
+		window: rateLimitWindow,
+		slots:  make([]time.Time, noSlots, perMinute),
+	}
+}
+
+// sharedLimiter returns the process-wide limiter for perMinute so page
+// loops and concurrent commands draw from the same quota.
+func sharedLimiter(perMinute int) *Limiter {
+	if perMinute <= NoRateLimit {
+		return nil
+	}
+
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+
+	limiter, ok := sharedLimiters[perMinute]
+	if !ok {
+		limiter = NewLimiter(perMinute)
+		sharedLimiters[perMinute] = limiter
+	}
+
+	return limiter
+}
+
+// Wait blocks until a request may be sent. A nil limiter never waits.
+func (l *Limiter) Wait(
+	ctx context.Context,
+	sleep func(ctx context.Context, delay time.Duration) error,
+) error {
+	if l == nil {
+		return nil
+	}
+
+	delay := l.reserve(time.Now())
+	if delay <= noDelay {
+		return nil
+	}
+
+	return sleep(ctx, delay)
+}
+
+// reserve records the next free slot and returns how long to wait for it.
+// Slots stay sorted because each new one is the oldest slot plus window.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+	for len(l.slots) > noSlots && !l.slots[0].After(cutoff) {
+		l.slots = l.slots[1:]
+	}
+
+	if len(l.slots) < l.limit {
+		l.slots = append(l.slots, now)
+
+		return noDelay
+	}
+
+	at := l.slots[0].Add(l.window)
+	l.slots = append(l.slots[1:], at)
+
+	return at.Sub(now)
+}
@@ -0,0 +1,81 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const limiterTestLimit = 2
+
+// TestLimiterReserve queues requests beyond the limit into later slots.
+func TestLimiterReserve(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter(limiterTestLimit)
+	start := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+
+	want := []time.Duration{
+		noDelay,
+		noDelay,
+		rateLimitWindow,
+		rateLimitWindow,
+	}
+	for index, expected := range want {
+		got := limiter.reserve(start)
+		if got != expected {
+			t.Fatalf("reserve %d got %v want %v", index, got, expected)
+		}
+	}
+
+	later := start.Add(2*rateLimitWindow + time.Second)
+	if got := limiter.reserve(later); got != noDelay {
+		t.Fatalf("reserve after window got %v want 0s", got)
+	}
+}
+
+// TestLimiterWait sleeps only once the window is full.
+func TestLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	var delays []time.Duration
+
+	sleep := func(_ context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+
+		return nil
+	}
+
+	limiter := NewLimiter(1)
+	for range limiterTestLimit {
+		err := limiter.Wait(context.Background(), sleep)
+		if err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+
+	if len(delays) != 1 || delays[0] <= noDelay {
+		t.Fatalf("delays got %v want one positive delay", delays)
+	}
+
+	var disabled *Limiter
+
+	err := disabled.Wait(context.Background(), sleep)
+	if err != nil || len(delays) != 1 {
+		t.Fatalf("nil limiter waited: %v %v", err, delays)
+	}
+}
+
+// TestSharedLimiter reuses limiters per rate and disables zero.
+func TestSharedLimiter(t *testing.T) {
+	t.Parallel()
+
+	if sharedLimiter(DefaultRateLimit) != sharedLimiter(DefaultRateLimit) {
+		t.Fatal("shared limiter not reused")
+	}
+
+	if sharedLimiter(NoRateLimit) != nil {
+		t.Fatal("zero rate limit should disable throttling")
+	}
+}
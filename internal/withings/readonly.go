@@ -0,0 +1,42 @@
+package withings
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// ErrReadOnly indicates a state-changing call was refused by --read-only.
+var ErrReadOnly = errors.New(
+	"refusing to modify data: read-only mode is enabled",
+)
+
+// Withings names read actions get, list, or get<thing>; everything else
+// (subscribe, revoke, activate, ...) may change state.
+const (
+	readActionGet  = "get"
+	readActionList = "list"
+)
+
+// CheckWrite refuses action on service when read-only mode is enabled and
+// the action is not a known read. Call it right before sending a request.
+func CheckWrite(opts app.Options, service, action string) error {
+	if !opts.ReadOnly || IsReadAction(action) {
+		return nil
+	}
+
+	return app.NewExitError(
+		app.ExitCodeUsage,
+		fmt.Errorf("%w (%s %s)", ErrReadOnly, service, action),
+	)
+}
+
+// IsReadAction reports whether action only reads data.
+func IsReadAction(action string) bool {
+	lowered := strings.ToLower(strings.TrimSpace(action))
+
+	return lowered == readActionList ||
+		strings.HasPrefix(lowered, readActionGet)
+}
@@ -0,0 +1,41 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+// TestCheckWrite blocks non-read actions only in read-only mode.
+func TestCheckWrite(t *testing.T) {
+	t.Parallel()
+
+	var opts app.Options
+
+	err := CheckWrite(opts, "notify", "subscribe")
+	if err != nil {
+		t.Fatalf("writable subscribe: %v", err)
+	}
+
+	opts.ReadOnly = true
+
+	for _, action := range []string{"getmeas", "get", "list", "GetDevice"} {
+		err = CheckWrite(opts, "measure", action)
+		if err != nil {
+			t.Fatalf("read-only %s: %v", action, err)
+		}
+	}
+
+	err = CheckWrite(opts, "notify", "revoke")
+
+	var exitErr *app.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != app.ExitCodeUsage {
+		t.Fatalf("revoke got %v want usage error", err)
+	}
+
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("revoke got %v want %v", err, ErrReadOnly)
+	}
+}
@@ -0,0 +1,156 @@
+package withings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// TokenSource refreshes the access token when the API rejects it.
+type TokenSource interface {
+	// Refresh exchanges the stored refresh token for a new access token.
+	Refresh(ctx context.Context) (string, error)
+}
+
+type tokenSourceKey struct{}
+
+// reauth refreshes at most once per run and swaps the rejected token for
+// the new one in every later request of that run.
+type reauth struct {
+	source   TokenSource
+	mu       sync.Mutex
+	tried    bool
+	rejected string
+	token    string
+}
+
+// WithTokenSource lets clients refresh the token through source and retry
+// once when a request made with ctx gets Withings status 401.
+func WithTokenSource(ctx context.Context, source TokenSource) context.Context {
+	//nolint:exhaustruct // Refresh state starts empty.
+	return context.WithValue(ctx, tokenSourceKey{}, &reauth{source: source})
+}
+
+func reauthFrom(ctx context.Context) *reauth {
+	state, _ := ctx.Value(tokenSourceKey{}).(*reauth)
+
+	return state
+}
+
+// current returns req with the refreshed token when it still carries the
+// rejected one.
+func (r *reauth) current(req *http.Request) *http.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.token == "" || bearerToken(req) != r.rejected {
+		return req
+	}
+
+	return withToken(req, r.token)
+}
+
+// refresh returns a new token for the rejected one, or false when a
+// refresh was already attempted for another token or failed.
+func (r *reauth) refresh(
+	ctx context.Context,
+	rejected string,
+) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.tried {
+		return r.token, r.token != "" && rejected == r.rejected, nil
+	}
+
+	r.tried = true
+	r.rejected = rejected
+
+	token, err := r.source.Refresh(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf(
+			"token rejected and refresh failed: %w",
+			err,
+		)
+	}
+
+	r.token = token
+
+	return token, true, nil
+}
+
+// sendAuthorized sends req and, when Withings rejects its token with
+// status 401, refreshes the token once and sends req again.
+func (c *Client) sendAuthorized(req *http.Request) (*http.Response, error) {
+	state := reauthFrom(req.Context())
+	if state == nil || bearerToken(req) == "" {
+		return c.send(req)
+	}
+
+	resp, err := c.send(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	closeErr := resp.Body.Close()
+
+	err = errors.Join(err, closeErr)
+	if err != nil {
+		return nil, fmt.Errorf("read api response: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !tokenRejected(body) {
+		return resp, nil
+	}
+
+	token, ok, err := state.refresh(req.Context(), bearerToken(req))
+	if err != nil && c.Warn != nil {
+		c.Warn(err.Error())
+	}
+
+	if !ok {
+		return resp, nil
+	}
+
+	retry, err := rewind(req, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.send(withToken(retry, token))
+}
+
+func tokenRejected(body []byte) bool {
+	var envelope struct {
+		Status int `json:"status"`
+	}
+
+	err := json.Unmarshal(body, &envelope)
+
+	return err == nil && envelope.Status == statusInvalidToken
+}
+
+func bearerToken(req *http.Request) string {
+	token, ok := strings.CutPrefix(req.Header.Get(headerAuth), bearerPrefix)
+	if !ok {
+		return ""
+	}
+
+	return token
+}
+
+func withToken(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set(headerAuth, bearerPrefix+token)
+
+	return clone
+}
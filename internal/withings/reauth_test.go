@@ -0,0 +1,129 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const (
+	reauthOldToken = "old"
+	reauthNewToken = "new"
+	reauthOK       = `{"status":0,"body":{}}`
+)
+
+type countingSource struct {
+	calls int
+}
+
+func (s *countingSource) Refresh(context.Context) (string, error) {
+	s.calls++
+
+	return reauthNewToken, nil
+}
+
+// TestClientRefreshesRejectedToken retries once with a refreshed token
+// and sends later requests with it directly.
+func TestClientRefreshesRejectedToken(t *testing.T) {
+	t.Parallel()
+
+	var seen []string
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, req *http.Request) {
+			_ = req.ParseForm()
+			token := bearerToken(req)
+			seen = append(seen, token+" "+req.Form.Get(apiActionKey))
+
+			if token != reauthNewToken {
+				_, _ = writer.Write([]byte(`{"status":401,"error":"invalid"}`))
+
+				return
+			}
+
+			_, _ = writer.Write([]byte(reauthOK))
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	source := &countingSource{calls: 0}
+	client := testClient(noRetries, &delays)
+	ctx := WithTokenSource(context.Background(), source)
+
+	for range 2 {
+		req, _, err := BuildRequest(
+			ctx,
+			server.URL,
+			"measure",
+			"getmeas",
+			reauthOldToken,
+			url.Values{},
+		)
+		if err != nil {
+			t.Fatalf("BuildRequest: %v", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+
+		payload, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if string(payload) != reauthOK {
+			t.Fatalf("payload got %s", payload)
+		}
+	}
+
+	if source.calls != 1 {
+		t.Fatalf("refreshes got %d want 1", source.calls)
+	}
+
+	want := []string{"old getmeas", "new getmeas", "new getmeas"}
+	if len(seen) != len(want) {
+		t.Fatalf("requests got %q want %q", seen, want)
+	}
+
+	for index := range want {
+		if seen[index] != want[index] {
+			t.Fatalf("requests got %q want %q", seen, want)
+		}
+	}
+}
+
+// TestClientRefreshesOnce returns the second 401 to the caller.
+func TestClientRefreshesOnce(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			_, _ = writer.Write([]byte(`{"status":401,"error":"invalid"}`))
+		},
+	))
+	defer server.Close()
+
+	var delays []time.Duration
+
+	source := &countingSource{calls: 0}
+	req := testRequest(t, server.URL)
+	req = req.WithContext(WithTokenSource(req.Context(), source))
+
+	resp, err := testClient(noRetries, &delays).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	_ = resp.Body.Close()
+
+	if !tokenRejected([]byte(`{"status":401}`)) || source.calls != 1 {
+		t.Fatalf("refreshes got %d want 1", source.calls)
+	}
+}
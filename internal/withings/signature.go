@@ -0,0 +1,155 @@
+package withings
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	// SignatureService issues the nonces signed actions need.
+	SignatureService = "v2/signature"
+	// ActionGetNonce requests a single-use nonce.
+	ActionGetNonce = "getnonce"
+
+	signatureSeparator = ","
+	paramClientID      = "client_id"
+	paramNonce         = "nonce"
+	paramSignature     = "signature"
+	paramTimestamp     = "timestamp"
+	decimalBase        = 10
+)
+
+// ErrNoNonce indicates a getnonce response without a nonce.
+var ErrNoNonce = errors.New("getnonce returned no nonce")
+
+// Credentials are the app's client ID and secret, which signed actions
+// use instead of an access token.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+type nonceResponse struct {
+	Status int       `json:"status"`
+	Body   nonceBody `json:"body"`
+	Error  string    `json:"error"`
+}
+
+type nonceBody struct {
+	Nonce string `json:"nonce"`
+}
+
+// Sign returns the hex HMAC-SHA256 of values joined by commas, keyed with
+// the client secret. Withings signs the values of action, client_id, and
+// nonce (or timestamp for getnonce), in that order.
+func Sign(secret string, values ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(strings.Join(values, signatureSeparator)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignParams adds client_id, nonce, and the signature of action to
+// params.
+func SignParams(
+	params url.Values,
+	action string,
+	creds Credentials,
+	nonce string,
+) {
+	params.Set(paramClientID, creds.ClientID)
+	params.Set(paramNonce, nonce)
+	params.Set(
+		paramSignature,
+		Sign(creds.ClientSecret, action, creds.ClientID, nonce),
+	)
+}
+
+// GetNonce requests a nonce for one signed call. The timestamp is
+// corrected by the measured clock skew, since Withings rejects clocks that
+// are too far off.
+func GetNonce(
+	ctx context.Context,
+	opts app.Options,
+	creds Credentials,
+) (string, error) {
+	now := time.Now()
+	if skew, ok := ClockSkew(); ok {
+		now = now.Add(skew)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), decimalBase)
+
+	params := url.Values{}
+	params.Set(paramClientID, creds.ClientID)
+	params.Set(paramTimestamp, timestamp)
+	params.Set(
+		paramSignature,
+		Sign(creds.ClientSecret, ActionGetNonce, creds.ClientID, timestamp),
+	)
+
+	req, _, err := NewRequest(
+		ctx,
+		http.MethodPost,
+		APIBaseURL(opts.BaseURL, opts.Cloud),
+		SignatureService,
+		ActionGetNonce,
+		"",
+		params,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Del(headerAuth)
+
+	client := NewClient(opts)
+	// Nonces are single-use, so they must never come from the cache.
+	client.Cache = nil
+
+	//nolint:bodyclose // ReadPayload closes the response body.
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", app.NewExitError(app.ExitCodeNetwork, err)
+	}
+
+	payload, err := ReadPayload(resp)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	var decoded nonceResponse
+
+	err = json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return "", app.NewExitError(
+			app.ExitCodeFailure,
+			fmt.Errorf("decode api response: %w", err),
+		)
+	}
+
+	if decoded.Status != StatusOK {
+		return "", app.NewExitError(
+			app.ExitCodeAPI,
+			&APIError{Status: decoded.Status, Message: decoded.Error},
+		)
+	}
+
+	if decoded.Body.Nonce == "" {
+		return "", app.NewExitError(app.ExitCodeAPI, ErrNoNonce)
+	}
+
+	return decoded.Body.Nonce, nil
+}
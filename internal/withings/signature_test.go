@@ -0,0 +1,97 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	signatureTestID     = "id"
+	signatureTestSecret = "secret"
+)
+
+// TestSign computes the HMAC-SHA256 of the comma-joined values.
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	got := Sign(
+		signatureTestSecret,
+		ActionGetNonce,
+		signatureTestID,
+		"1792124407",
+	)
+	want := "8af76bab6ed4a00b46afd3582bb14802f99feec394c3b4811249f45ddb3de42b"
+
+	if got != want {
+		t.Fatalf("signature got %q want %q", got, want)
+	}
+
+	params := url.Values{}
+	creds := Credentials{
+		ClientID:     signatureTestID,
+		ClientSecret: signatureTestSecret,
+	}
+
+	SignParams(params, "createuser", creds, "n1")
+
+	if params.Get(paramSignature) !=
+		Sign(signatureTestSecret, "createuser", signatureTestID, "n1") ||
+		params.Get(paramNonce) != "n1" ||
+		params.Get(paramClientID) != signatureTestID {
+		t.Fatalf("signed params %v", params)
+	}
+}
+
+// TestGetNonce signs the timestamp and sends no access token.
+func TestGetNonce(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, request *http.Request) {
+			_ = request.ParseForm()
+
+			form := request.Form
+			want := Sign(
+				signatureTestSecret,
+				ActionGetNonce,
+				form.Get(paramClientID),
+				form.Get(paramTimestamp),
+			)
+
+			_, err := strconv.ParseInt(form.Get(paramTimestamp), 10, 64)
+
+			switch {
+			case request.URL.Path != "/"+SignatureService,
+				request.Header.Get(headerAuth) != "",
+				err != nil,
+				form.Get(paramSignature) != want:
+				_, _ = io.WriteString(writer, `{"status":503,"error":"bad"}`)
+			default:
+				_, _ = io.WriteString(
+					writer,
+					`{"status":0,"body":{"nonce":"n1"}}`,
+				)
+			}
+		},
+	))
+	defer server.Close()
+
+	var opts app.Options
+
+	opts.BaseURL = server.URL
+
+	nonce, err := GetNonce(t.Context(), opts, Credentials{
+		ClientID:     signatureTestID,
+		ClientSecret: signatureTestSecret,
+	})
+	if err != nil || nonce != "n1" {
+		t.Fatalf("got %q, %v", nonce, err)
+	}
+}
@@ -1,4 +1,112 @@
 package withings
 
+import (
+	"strings"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
 // StatusOK indicates a successful API response status.
 const StatusOK = 0
+
+// Withings statuses with a known meaning beyond the API message.
+const (
+	statusUnauthorized     = 214
+	statusNoAccess         = 250
+	statusInvalidParams    = 293
+	statusUnauthorizedApp  = 277
+	statusBadParams        = 503
+	statusTimeout          = 522
+	statusTooManyRequests  = 601
+	statusUnknownError     = 2555
+	statusServiceUndefined = 2556
+)
+
+// statusInfo is what a Withings status means for the user and which exit
+// code it maps to.
+type statusInfo struct {
+	Message  string
+	ExitCode int
+}
+
+//nolint:gochecknoglobals // Static lookup table of Withings statuses.
+var statusTable = map[int]statusInfo{
+	statusAuthFailedFirst: authFailedStatus,
+	statusAuthFailedMid:   authFailedStatus,
+	statusAuthFailedLast:  authFailedStatus,
+	statusAuthFailed:      authFailedStatus,
+	statusInvalidToken: {
+		Message:  "token expired or invalid - run `withings auth refresh`",
+		ExitCode: app.ExitCodeAuth,
+	},
+	statusUnauthorized:    scopeStatus,
+	statusUnauthorizedApp: scopeStatus,
+	statusNoAccess: {
+		Message: "this user has not granted access to the data - " +
+			"check --user-id or log in again",
+		ExitCode: app.ExitCodeAuth,
+	},
+	statusInvalidParams: invalidParamsStatus,
+	statusBadParams:     invalidParamsStatus,
+	statusTimeout: {
+		Message:  "Withings server timed out - retry later",
+		ExitCode: app.ExitCodeAPI,
+	},
+	statusTooManyRequests: {
+		Message: "rate limited - retry after 60s " +
+			"(or lower rate_limit_per_minute)",
+		ExitCode: app.ExitCodeAPI,
+	},
+	statusUnknownAction: {
+		Message:  "unknown action - the API does not offer this endpoint",
+		ExitCode: app.ExitCodeAPI,
+	},
+	statusUnknownError: {
+		Message:  "unknown Withings server error - retry later",
+		ExitCode: app.ExitCodeAPI,
+	},
+	statusServiceUndefined: {
+		Message:  "service not defined - check --base-url and --cloud",
+		ExitCode: app.ExitCodeAPI,
+	},
+}
+
+//nolint:gochecknoglobals // Shared entries of the status table.
+var (
+	authFailedStatus = statusInfo{
+		Message:  "authentication failed - run `withings auth login`",
+		ExitCode: app.ExitCodeAuth,
+	}
+	scopeStatus = statusInfo{
+		Message: "the app is not allowed to do this - log in again " +
+			"with the required scopes",
+		ExitCode: app.ExitCodeAuth,
+	}
+	invalidParamsStatus = statusInfo{
+		Message:  "invalid parameters - check the flag values",
+		ExitCode: app.ExitCodeUsage,
+	}
+)
+
+// StatusError turns a non-zero response status into an *APIError with
+// exit code ExitCodeAPI. The message is the response error, else its
+// detail, else the raw payload; StatusOK yields nil.
+func StatusError(status int, errText, detail string, payload []byte) error {
+	if status == StatusOK {
+		return nil
+	}
+
+	message := errText
+	if message == "" {
+		message = detail
+	}
+
+	if message == "" {
+		message = strings.TrimSpace(string(payload))
+	}
+
+	return app.NewExitError(
+		app.ExitCodeAPI,
+		&APIError{Status: status, Message: message},
+	)
+}
@@ -0,0 +1,319 @@
+package withings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mreimbold/withings-cli/internal/app"
+)
+
+const (
+	// TraceSummary prints one line per request (-v).
+	TraceSummary = 1
+	// TraceHeaders adds sanitized headers and form bodies (-vv).
+	TraceHeaders = 2
+	// TraceBodies adds the response bodies (-vvv).
+	TraceBodies = 3
+
+	redacted          = "[REDACTED]"
+	headerAuth        = "Authorization"
+	headerContentType = "Content-Type"
+	bearerPrefix      = "Bearer "
+	tracePrefix       = "* "
+	requestPrefix     = "> "
+	responsePrefix    = "< "
+)
+
+// secretKeys are form, query, and JSON keys whose values never reach the
+// trace output.
+//
+//nolint:gochecknoglobals // Static redaction list.
+var secretKeys = []string{
+	"access_token",
+	"refresh_token",
+	"client_secret",
+	"code",
+	"code_verifier",
+}
+
+//nolint:gochecknoglobals // Compiled once for every traced response.
+var secretJSONPattern = regexp.MustCompile(
+	`("(?:access_token|refresh_token|client_secret)"\s*:\s*)"[^"]*"`,
+)
+
+// traceTransport logs every round trip to out. Each retry is a separate
+// round trip, so it is traced separately.
+type traceTransport struct {
+	base  http.RoundTripper
+	level int
+	out   io.Writer
+	mu    sync.Mutex
+	now   func() time.Time
+}
+
+// HTTPClient returns the shared HTTP client, wrapped in a tracing
+// transport that writes to stderr when --verbose is set. With --fixtures
+// or --replay responses come from files instead of the network, and
+// --record saves every round trip to a cassette.
+func HTTPClient(opts app.Options) *http.Client {
+	base := baseTransport(opts)
+
+	if opts.Verbose < TraceSummary || opts.Quiet {
+		if base == http.DefaultTransport {
+			return sharedHTTPClient
+		}
+
+		return &http.Client{
+			Transport:     base,
+			CheckRedirect: nil,
+			Jar:           nil,
+			Timeout:       noDelay,
+		}
+	}
+
+	transport := newTraceTransport(base, opts.Verbose)
+	if opts.Stderr != nil {
+		transport.out = opts.Stderr
+	}
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: nil,
+		Jar:           nil,
+		Timeout:       noDelay,
+	}
+}
+
+// baseTransport picks where requests go: fixture files, a cassette, or
+// the network.
+func baseTransport(opts app.Options) http.RoundTripper {
+	switch {
+	case opts.Fixtures != "":
+		return fixtureTransport{dir: opts.Fixtures}
+	case opts.Replay != "":
+		return replayOrError(opts.Replay)
+	case opts.Record != "":
+		return recorder(opts.Record, http.DefaultTransport)
+	default:
+		return http.DefaultTransport
+	}
+}
+
+func newTraceTransport(base http.RoundTripper, level int) *traceTransport {
+	return &traceTransport{
+		base:  base,
+		level: level,
+		out:   os.Stderr,
+		mu:    sync.Mutex{},
+		now:   time.Now,
+	}
+}
+
+// RoundTrip sends req and writes its trace once the response headers (or
+// the error) arrived.
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+
+	if t.level >= TraceHeaders {
+		body, err := peekRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		requestBody = body
+	}
+
+	started := t.now()
+
+	resp, err := t.base.RoundTrip(req)
+	elapsed := t.now().Sub(started).Round(time.Millisecond)
+
+	var responseBody []byte
+
+	if err == nil && t.level >= TraceBodies {
+		responseBody, err = peekResponseBody(resp)
+		if err != nil {
+			resp = nil
+		}
+	}
+
+	var trace strings.Builder
+
+	writeSummary(&trace, req, resp, err, elapsed)
+
+	if t.level >= TraceHeaders {
+		writeRequest(&trace, req, requestBody)
+
+		if resp != nil {
+			writeHeaders(&trace, responsePrefix, resp.Header)
+		}
+	}
+
+	if len(responseBody) > 0 {
+		trace.WriteString(responsePrefix)
+		trace.Write(redactJSON(responseBody))
+		trace.WriteByte('\n')
+	}
+
+	t.mu.Lock()
+	_, _ = io.WriteString(t.out, trace.String())
+	t.mu.Unlock()
+
+	return resp, err //nolint:wrapcheck // Keep net errors intact.
+}
+
+func writeSummary(
+	trace *strings.Builder,
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	elapsed time.Duration,
+) {
+	outcome := "error: "
+	if err != nil {
+		outcome += err.Error()
+	} else {
+		outcome = resp.Status
+	}
+
+	fmt.Fprintf(
+		trace,
+		"%s%s %s %s (%s)\n",
+		tracePrefix,
+		req.Method,
+		redactURL(req.URL),
+		outcome,
+		elapsed,
+	)
+}
+
+func writeRequest(trace *strings.Builder, req *http.Request, body []byte) {
+	writeHeaders(trace, requestPrefix, req.Header)
+
+	if len(body) == 0 {
+		return
+	}
+
+	trace.WriteString(requestPrefix)
+
+	contentType := req.Header.Get(headerContentType)
+	if strings.HasPrefix(contentType, apiContentTypeForm) {
+		trace.WriteString(redactForm(string(body)))
+	} else {
+		trace.Write(redactJSON(body))
+	}
+
+	trace.WriteByte('\n')
+}
+
+func writeHeaders(trace *strings.Builder, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(
+				trace,
+				"%s%s: %s\n",
+				prefix,
+				name,
+				redactHeader(name, value),
+			)
+		}
+	}
+}
+
+func redactHeader(name, value string) string {
+	if !strings.EqualFold(name, headerAuth) {
+		return value
+	}
+
+	if strings.HasPrefix(value, bearerPrefix) {
+		return bearerPrefix + redacted
+	}
+
+	return redacted
+}
+
+func redactURL(target *url.URL) string {
+	if target.RawQuery == "" {
+		return target.String()
+	}
+
+	clone := *target
+	clone.RawQuery = redactForm(target.RawQuery)
+
+	return clone.String()
+}
+
+// redactForm replaces secret values in a URL-encoded form. Bodies that do
+// not parse are hidden entirely rather than risking a leak.
+func redactForm(body string) string {
+	values, err := url.ParseQuery(body)
+	if err != nil {
+		return redacted
+	}
+
+	for _, key := range secretKeys {
+		if values.Has(key) {
+			values.Set(key, redacted)
+		}
+	}
+
+	return values.Encode()
+}
+
+func redactJSON(body []byte) []byte {
+	return secretJSONPattern.ReplaceAll(body, []byte(`$1"`+redacted+`"`))
+}
+
+// peekRequestBody reads a copy of the request body through GetBody, so
+// the body sent on the wire stays untouched. Streaming bodies without
+// GetBody are not traced.
+func peekRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody == nil {
+		return nil, nil
+	}
+
+	reader, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("trace request body: %w", err)
+	}
+
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("trace request body: %w", err)
+	}
+
+	return body, nil
+}
+
+// peekResponseBody buffers the response body and puts it back so callers
+// still read it in full.
+func peekResponseBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("trace response body: %w", err)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
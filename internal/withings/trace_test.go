@@ -0,0 +1,176 @@
+//nolint:testpackage // test unexported helpers.
+package withings
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	traceTestToken    = "secret-access"
+	traceTestResponse = `{"status":0,"body":{"access_token":"secret-access"}}`
+)
+
+// TestTraceTransportLevels prints more detail per level and never leaks
+// the token.
+func TestTraceTransportLevels(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(writer http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(writer, traceTestResponse)
+		},
+	))
+	defer server.Close()
+
+	tests := []struct {
+		level   int
+		want    []string
+		notWant []string
+	}{
+		{
+			level:   TraceSummary,
+			want:    []string{"* POST " + server.URL + "/measure 200 OK"},
+			notWant: []string{"> ", "< "},
+		},
+		{
+			level: TraceHeaders,
+			want: []string{
+				"> Authorization: Bearer " + redacted,
+				"> action=getmeas",
+				"< Content-Type: ",
+			},
+			notWant: []string{`< {"status"`},
+		},
+		{
+			level: TraceBodies,
+			want: []string{
+				`< {"status":0,"body":{"access_token":"` + redacted,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var trace bytes.Buffer
+
+		transport := newTraceTransport(http.DefaultTransport, test.level)
+		transport.out = &trace
+
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Do(traceRequest(t, server.URL+"/measure"))
+		if err != nil {
+			t.Fatalf("level %d: Do: %v", test.level, err)
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+
+		if string(body) != traceTestResponse {
+			t.Fatalf("level %d: body got %q", test.level, body)
+		}
+
+		got := trace.String()
+		if strings.Contains(got, traceTestToken) {
+			t.Fatalf("level %d: token leaked:\n%s", test.level, got)
+		}
+
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Fatalf("level %d: missing %q in:\n%s", test.level, want, got)
+			}
+		}
+
+		for _, notWant := range test.notWant {
+			if strings.Contains(got, notWant) {
+				t.Fatalf(
+					"level %d: unexpected %q in:\n%s",
+					test.level,
+					notWant,
+					got,
+				)
+			}
+		}
+	}
+}
+
+// TestRedactForm hides secrets in form bodies and query strings.
+func TestRedactForm(t *testing.T) {
+	t.Parallel()
+
+	got := redactForm(
+		"action=requesttoken&client_id=abc&client_secret=s&code=c" +
+			"&refresh_token=r",
+	)
+
+	want := "action=requesttoken&client_id=abc" +
+		"&client_secret=%5BREDACTED%5D&code=%5BREDACTED%5D" +
+		"&refresh_token=%5BREDACTED%5D"
+	if got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+
+	if got := redactForm("%zz"); got != redacted {
+		t.Fatalf("unparsable body got %q", got)
+	}
+
+	target, _ := url.Parse("https://example.com/v2/user?access_token=x&a=1")
+	if got := redactURL(target); strings.Contains(got, "access_token=x") {
+		t.Fatalf("query token leaked: %s", got)
+	}
+}
+
+// TestTraceTransportError reports transport failures in the summary.
+func TestTraceTransportError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var trace bytes.Buffer
+
+	transport := newTraceTransport(http.DefaultTransport, TraceSummary)
+	transport.out = &trace
+	transport.now = func() time.Time { return time.Time{} }
+
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Do(traceRequest(t, server.URL))
+	if err == nil {
+		_ = resp.Body.Close()
+
+		t.Fatal("expected an error")
+	}
+
+	if !strings.Contains(trace.String(), " error: ") ||
+		!strings.HasSuffix(trace.String(), "(0s)\n") {
+		t.Fatalf("unexpected trace %q", trace.String())
+	}
+}
+
+func traceRequest(t *testing.T, target string) *http.Request {
+	t.Helper()
+
+	req, _, err := BuildRequest(
+		context.Background(),
+		target,
+		"",
+		"getmeas",
+		traceTestToken,
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("BuildRequest: %v", err)
+	}
+
+	req.URL, _ = url.Parse(target)
+
+	return req
+}